@@ -0,0 +1,46 @@
+package userland
+
+import (
+	"github.com/go-errors/errors"
+
+	"context"
+	"time"
+)
+
+var (
+	//ErrClientNotFound represent api client is not found when searching in repository
+	ErrClientNotFound = errors.New("Client not found")
+)
+
+var (
+	ClientTypeWeb    = "web"
+	ClientTypeMobile = "mobile"
+	ClientTypeServer = "server"
+
+	ClientStatusActive   = "active"
+	ClientStatusDisabled = "disabled"
+)
+
+//Client is domain entity of a registered api client, public clients cannot keep a secret so they have none
+type Client struct {
+	ID             int
+	Name           string
+	Type           string
+	Public         bool
+	SecretHash     string
+	AllowedOrigins []string
+	Status         string
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+//Clients is collection of Client
+type Clients []Client
+
+//ClientRepository provide an interface to get registered api clients
+type ClientRepository interface {
+	Find(ctx context.Context, id int) (Client, error)
+	FindAll(ctx context.Context) (Clients, error)
+	Insert(ctx context.Context, client *Client) error
+	Update(ctx context.Context, client Client) error
+}
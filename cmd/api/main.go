@@ -2,25 +2,35 @@ package main
 
 import (
 	"context"
+	"flag"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
 	"cloud.google.com/go/storage"
+	"github.com/AdhityaRamadhanus/userland"
 	_http "github.com/AdhityaRamadhanus/userland/pkg/common/http"
 	"github.com/AdhityaRamadhanus/userland/pkg/common/http/clients/mailing"
 	"github.com/AdhityaRamadhanus/userland/pkg/common/http/middlewares"
+	"github.com/AdhityaRamadhanus/userland/pkg/common/revocation"
 	"github.com/AdhityaRamadhanus/userland/pkg/config"
 	server "github.com/AdhityaRamadhanus/userland/pkg/server/api"
 	"github.com/AdhityaRamadhanus/userland/pkg/server/api/handlers"
 	"github.com/AdhityaRamadhanus/userland/pkg/service/authentication"
+	"github.com/AdhityaRamadhanus/userland/pkg/service/client"
 	"github.com/AdhityaRamadhanus/userland/pkg/service/event"
 	"github.com/AdhityaRamadhanus/userland/pkg/service/profile"
+	"github.com/AdhityaRamadhanus/userland/pkg/service/saml"
 	"github.com/AdhityaRamadhanus/userland/pkg/service/session"
 	"github.com/AdhityaRamadhanus/userland/pkg/storage/gcs"
+	"github.com/AdhityaRamadhanus/userland/pkg/storage/maxmind"
+	"github.com/AdhityaRamadhanus/userland/pkg/storage/memory"
 	"github.com/AdhityaRamadhanus/userland/pkg/storage/postgres"
 	"github.com/AdhityaRamadhanus/userland/pkg/storage/redis"
+	"github.com/AdhityaRamadhanus/userland/pkg/storage/sqlite"
+	_redis "github.com/go-redis/redis"
 	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
 	"github.com/sirupsen/logrus"
@@ -28,7 +38,8 @@ import (
 
 func buildConfig() *config.Configuration {
 	envPath := ".env"
-	if err := godotenv.Load(envPath); err != nil {
+	// .env is optional, variables may come from the environment and --storage=memory run without any
+	if err := godotenv.Load(envPath); err != nil && !os.IsNotExist(err) {
 		logrus.Fatalf("godotenv.Load(%q) err = %v", envPath, err)
 	}
 
@@ -56,94 +67,390 @@ func setupLogger(cfg config.LogConfig) {
 	}
 }
 
-func main() {
-	cfg := buildConfig()
-	setupLogger(cfg.Log)
+//buildSessionRepository keep sessions in redis, or in the sessions table of the configured database
+func buildSessionRepository(cfg config.SessionConfig, databaseSessionRepository userland.SessionRepository, redisClient *_redis.Client) userland.SessionRepository {
+	switch cfg.Storage {
+	case config.SessionStoragePostgres, config.SessionStorageSQLite:
+		return databaseSessionRepository
+	case config.SessionStorageRedis, "":
+		return redis.NewSessionRepository(redisClient)
+	default:
+		logrus.Fatalf("Unknown session storage %q", cfg.Storage)
+	}
+
+	return nil
+}
+
+func buildRateLimiter(cfg config.RateLimitConfig, rateLimitService userland.RateLimitService) middlewares.RateLimiter {
+	policies := map[string]middlewares.RateLimitPolicy{}
+	if cfg.Enabled {
+		for route, policy := range cfg.Policies {
+			policies[route] = middlewares.RateLimitPolicy{
+				Key: policy.Key,
+				RateLimit: userland.RateLimit{
+					Limit:  policy.Limit,
+					Burst:  policy.Burst,
+					Window: policy.Window,
+				},
+			}
+		}
+	}
 
-	logrus.Debug("Connecting to postgres at", cfg.Postgres)
-	pgConn, err := postgres.CreateConnection(cfg.Postgres)
+	rateLimiter, err := middlewares.RateLimit(rateLimitService, policies)
 	if err != nil {
-		logrus.Fatalf("postgres.CreateConnection() err = %v", err)
+		logrus.Fatalf("middlewares.RateLimit(cfg) err = %v", err)
 	}
-	defer pgConn.Close()
+	return rateLimiter
+}
+
+//databaseRepositories hold every repository backed by the configured database, close release its connection
+type databaseRepositories struct {
+	userRepository                userland.UserRepository
+	eventRepository               userland.EventRepository
+	txManager                     userland.TxManager
+	loginRiskAssessmentRepository userland.LoginRiskAssessmentRepository
+	identityProviderRepository    userland.IdentityProviderRepository
+	clientRepository              userland.ClientRepository
+	sessionRepository             userland.SessionRepository
+	dataExportRepository          userland.DataExportRepository
+	close                         func()
+}
+
+//buildDatabaseRepositories connect only to the configured database driver, every table live in that one database
+func buildDatabaseRepositories(cfg *config.Configuration) databaseRepositories {
+	switch cfg.Database.Driver {
+	case config.DatabaseDriverPostgres, "":
+		logrus.Debug("Connecting to postgres at", cfg.Postgres)
+		pgConn, err := postgres.CreateConnection(cfg.Postgres)
+		if err != nil {
+			logrus.Fatalf("postgres.CreateConnection() err = %v", err)
+		}
+		pgOptions := []postgres.RepositoryOption{postgres.WithQueryTimeout(cfg.Postgres.QueryTimeout)}
+		return databaseRepositories{
+			userRepository:                postgres.NewUserRepository(pgConn, pgOptions...),
+			eventRepository:               postgres.NewEventRepository(pgConn, pgOptions...),
+			txManager:                     postgres.NewTxManager(pgConn, pgOptions...),
+			loginRiskAssessmentRepository: postgres.NewLoginRiskAssessmentRepository(pgConn, pgOptions...),
+			identityProviderRepository:    postgres.NewIdentityProviderRepository(pgConn, pgOptions...),
+			clientRepository:              postgres.NewClientRepository(pgConn, pgOptions...),
+			sessionRepository:             postgres.NewSessionRepository(pgConn, pgOptions...),
+			dataExportRepository:          postgres.NewDataExportRepository(pgConn, pgOptions...),
+			close:                         func() { pgConn.Close() },
+		}
+	case config.DatabaseDriverSQLite:
+		logrus.Debug("Opening sqlite at ", cfg.SQLite.Path)
+		sqliteConn, err := sqlite.CreateConnection(cfg.SQLite)
+		if err != nil {
+			logrus.Fatalf("sqlite.CreateConnection() err = %v", err)
+		}
+		sqliteOptions := []sqlite.RepositoryOption{sqlite.WithQueryTimeout(cfg.SQLite.QueryTimeout)}
+		return databaseRepositories{
+			userRepository:                sqlite.NewUserRepository(sqliteConn, sqliteOptions...),
+			eventRepository:               sqlite.NewEventRepository(sqliteConn, sqliteOptions...),
+			txManager:                     sqlite.NewTxManager(sqliteConn, sqliteOptions...),
+			loginRiskAssessmentRepository: sqlite.NewLoginRiskAssessmentRepository(sqliteConn, sqliteOptions...),
+			identityProviderRepository:    sqlite.NewIdentityProviderRepository(sqliteConn, sqliteOptions...),
+			clientRepository:              sqlite.NewClientRepository(sqliteConn, sqliteOptions...),
+			sessionRepository:             sqlite.NewSessionRepository(sqliteConn, sqliteOptions...),
+			dataExportRepository:          sqlite.NewDataExportRepository(sqliteConn, sqliteOptions...),
+			close:                         func() { sqliteConn.Close() },
+		}
+	default:
+		logrus.Fatalf("Unknown database driver %q", cfg.Database.Driver)
+	}
+
+	return databaseRepositories{}
+}
+
+const (
+	storageDefault = "default"
+	storageMemory  = "memory"
+)
+
+//storages hold every repository and storage backed service of the api, close release their connections
+type storages struct {
+	userRepository                userland.UserRepository
+	eventRepository               userland.EventRepository
+	txManager                     userland.TxManager
+	loginRiskAssessmentRepository userland.LoginRiskAssessmentRepository
+	identityProviderRepository    userland.IdentityProviderRepository
+	clientRepository              userland.ClientRepository
+	sessionRepository             userland.SessionRepository
+	dataExportRepository          userland.DataExportRepository
+	trustedDeviceRepository       userland.TrustedDeviceRepository
+	keyValueService               userland.KeyValueService
+	revocationService             userland.RevocationService
+	rateLimitService              userland.RateLimitService
+	objectStorageService          userland.ObjectStorageService
+	mailingClient                 mailing.Client
+	close                         func()
+}
+
+//buildDefaultStorages connect to the configured database, redis, GCS and the mailing service
+func buildDefaultStorages(ctx context.Context, cfg *config.Configuration) storages {
+	database := buildDatabaseRepositories(cfg)
 
 	logrus.Debug("Connecting to redis at", cfg.Redis)
 	redisClient, err := redis.CreateClient(cfg.Redis, 0)
 	if err != nil {
 		logrus.Fatalf("redis.CreateClient(cfg, 0) err = %v", err)
 	}
-	defer redisClient.Close()
 
 	redisRateClient, err := redis.CreateClient(cfg.Redis, 1)
 	if err != nil {
 		logrus.Fatalf("redis.CreateClient(cfg, 1) err = %v", err)
 	}
-	defer redisRateClient.Close()
 
-	ctx := context.Background()
 	gcsClient, err := storage.NewClient(ctx)
 	if err != nil {
 		logrus.Fatalf("(GCS) storage.NewClient(ctx) err = %v", err)
 	}
 
 	mailHTTPClient := _http.NewInstrumentedClient("mailing", _http.WithClientTimeout(5*time.Second))
+
+	return storages{
+		userRepository:                database.userRepository,
+		eventRepository:               database.eventRepository,
+		txManager:                     database.txManager,
+		loginRiskAssessmentRepository: database.loginRiskAssessmentRepository,
+		identityProviderRepository:    database.identityProviderRepository,
+		clientRepository:              database.clientRepository,
+		sessionRepository:             buildSessionRepository(cfg.Session, database.sessionRepository, redisClient),
+		dataExportRepository:          database.dataExportRepository,
+		trustedDeviceRepository:       redis.NewTrustedDeviceRepository(redisClient),
+		keyValueService:               redis.NewKeyValueService(redisClient),
+		revocationService:             redis.NewRevocationService(redisClient),
+		rateLimitService:              redis.NewRateLimitService(redisRateClient),
+		objectStorageService:          gcs.NewObjectStorageService(gcsClient, cfg.GCP.BucketName),
+		mailingClient:                 mailing.NewMailingClient(cfg.Mail.Host, mailing.WithHTTPClient(mailHTTPClient), mailing.WithBasicAuth(cfg.Mail.AuthUser, cfg.Mail.AuthPassword)),
+		close: func() {
+			gcsClient.Close()
+			redisRateClient.Close()
+			redisClient.Close()
+			database.close()
+		},
+	}
+}
+
+//buildMemoryStorages keep everything in the process memory, nothing survive a restart, and log emails instead of sending them
+func buildMemoryStorages() storages {
+	logrus.Warn("Using in-memory storage, data is lost when the server stop")
+	userRepository := memory.NewUserRepository()
+	eventRepository := memory.NewEventRepository()
+	sessionRepository := memory.NewSessionRepository()
+	loginRiskAssessmentRepository := memory.NewLoginRiskAssessmentRepository()
+	return storages{
+		userRepository:                userRepository,
+		eventRepository:               eventRepository,
+		txManager:                     memory.NewTxManager(userRepository, eventRepository, sessionRepository, loginRiskAssessmentRepository),
+		loginRiskAssessmentRepository: loginRiskAssessmentRepository,
+		identityProviderRepository:    memory.NewIdentityProviderRepository(),
+		clientRepository:              memory.NewClientRepository(),
+		sessionRepository:             sessionRepository,
+		dataExportRepository:          memory.NewDataExportRepository(),
+		trustedDeviceRepository:       memory.NewTrustedDeviceRepository(),
+		keyValueService:               memory.NewKeyValueService(),
+		revocationService:             memory.NewRevocationService(),
+		rateLimitService:              memory.NewRateLimitService(),
+		objectStorageService:          memory.NewObjectStorageService("memory://objects"),
+		mailingClient:                 mailing.NewLogClient(),
+		close:                         func() {},
+	}
+}
+
+func main() {
+	storageMode := flag.String("storage", storageDefault, "where data is kept, default (postgres, redis and GCS) or memory")
+	flag.Parse()
+
+	cfg := buildConfig()
+	setupLogger(cfg.Log)
+
+	if flag.Arg(0) == "migrate" {
+		if err := runMigrate(cfg, flag.Args()[1:]); err != nil {
+			logrus.Fatalf("migrate err = %v", err)
+		}
+		return
+	}
+
+	ctx := context.Background()
+	var stores storages
+	switch *storageMode {
+	case storageDefault:
+		stores = buildDefaultStorages(ctx, cfg)
+	case storageMemory:
+		stores = buildMemoryStorages()
+	default:
+		logrus.Fatalf("Unknown storage %q", *storageMode)
+	}
+	defer stores.close()
+
 	// mailing Client
-	mailClient := mailing.NewMailingClient(cfg.Mail.Host, mailing.WithHTTPClient(mailHTTPClient), mailing.WithBasicAuth(cfg.Mail.AuthUser, cfg.Mail.AuthPassword))
+	mailClient := stores.mailingClient
 	// repositories
-	userRepository := postgres.NewUserRepository(pgConn)
-	eventRepository := postgres.NewEventRepository(pgConn)
-	sessionRepository := redis.NewSessionRepository(redisClient)
-	keyValueSvc := redis.NewKeyValueService(redisClient)
-	objectStorageSvc := gcs.NewObjectStorageService(gcsClient, cfg.GCP.BucketName)
+	userRepository := stores.userRepository
+	eventRepository := stores.eventRepository
+	txManager := stores.txManager
+	loginRiskAssessmentRepository := stores.loginRiskAssessmentRepository
+	identityProviderRepository := stores.identityProviderRepository
+	clientRepository := stores.clientRepository
+	sessionRepository := stores.sessionRepository
+	dataExportRepository := stores.dataExportRepository
+	trustedDeviceRepository := stores.trustedDeviceRepository
+	keyValueSvc := stores.keyValueService
+	revocationSvc := stores.revocationService
+	objectStorageSvc := stores.objectStorageService
+	geolocationSvc, err := maxmind.NewGeolocationService(cfg.Geolocation.CityDatabase, cfg.Geolocation.ASNDatabase)
+	if err != nil {
+		logrus.Fatalf("maxmind.NewGeolocationService(cfg) err = %v", err)
+	}
+	defer geolocationSvc.Close()
 
 	// services
 	authSvc := authentication.NewService(
 		authentication.WithConfiguration(cfg),
 		authentication.WithKeyValueService(keyValueSvc),
 		authentication.WithMailingClient(mailClient),
+		authentication.WithEventRepository(eventRepository),
 		authentication.WithUserRepository(userRepository),
+		authentication.WithTrustedDeviceRepository(trustedDeviceRepository),
+		authentication.WithGeolocationService(geolocationSvc),
+		authentication.WithLoginRiskAssessmentRepository(loginRiskAssessmentRepository),
 	)
 	// authInstSvc := authentication.NewInstrumentorService(metrics.PrometheusRequestLatency("service", "authentication", authentication.MetricKeys), authSvc)
 
 	profileSvc := profile.NewService(
+		profile.WithConfiguration(cfg),
 		profile.WithKeyValueService(keyValueSvc),
 		profile.WithMailingClient(mailClient),
 		profile.WithObjectStorageService(objectStorageSvc),
 		profile.WithUserRepository(userRepository),
+		profile.WithEventRepository(eventRepository),
+		profile.WithSessionRepository(sessionRepository),
+		profile.WithTrustedDeviceRepository(trustedDeviceRepository),
+		profile.WithDataExportRepository(dataExportRepository),
+		profile.WithTxManager(txManager),
+	)
+
+	sessionSvc := session.NewService(
+		session.WithConfiguration(cfg),
+		session.WithKeyValueService(keyValueSvc),
+		session.WithSessionRepository(sessionRepository),
+		session.WithTrustedDeviceRepository(trustedDeviceRepository),
+		session.WithRevocationService(revocationSvc),
+		session.WithEventRepository(eventRepository),
+		session.WithGeolocationService(geolocationSvc),
+	)
+	eventSvc := event.NewService(
+		event.WithEventRepository(eventRepository),
+		event.WithGeolocationService(geolocationSvc),
+	)
+	samlSvc := saml.NewService(
+		saml.WithConfiguration(cfg),
+		saml.WithIdentityProviderRepository(identityProviderRepository),
+		saml.WithUserRepository(userRepository),
+		saml.WithKeyValueService(keyValueSvc),
+		saml.WithMailingClient(mailClient),
+	)
+
+	clientSvc := client.NewService(
+		client.WithConfiguration(cfg),
+		client.WithClientRepository(clientRepository),
+		client.WithKeyValueService(keyValueSvc),
 	)
 
-	sessionSvc := session.NewService(session.WithConfiguration(cfg), session.WithKeyValueService(keyValueSvc), session.WithSessionRepository(sessionRepository))
-	eventSvc := event.NewService(event.WithEventRepository(eventRepository))
+	revocationCache := revocation.NewCache(revocationSvc, revocation.WithSyncInterval(cfg.StatelessAuth.SyncInterval))
+	if len(cfg.StatelessAuth.RouteGroups) > 0 {
+		go revocationCache.Run(ctx)
+	}
+
+	purger := profile.NewPurger(profileSvc, profile.WithPurgeInterval(cfg.AccountDeletion.PurgeInterval))
+	go purger.Run(ctx)
+
+	exporter := profile.NewExporter(profileSvc, profile.WithExportInterval(cfg.DataExport.Interval))
+	go exporter.Run(ctx)
 
-	authenticator := middlewares.TokenAuth(keyValueSvc, cfg.JWTSecret)
-	ratelimiter := middlewares.RateLimit(redisRateClient)
+	statefulAuthenticator := middlewares.TokenAuth(keyValueSvc, cfg.JWTSecret, middlewares.WithSessionActivityRecorder(sessionSvc))
+	statelessAuthenticator := middlewares.StatelessTokenAuth(keyValueSvc, cfg.JWTSecret, revocationCache, cfg.StatelessAuth.MaxStaleness, middlewares.WithSessionActivityRecorder(sessionSvc))
+	authenticator := func(routeGroup string) middlewares.Middleware {
+		if cfg.StatelessAuth.Enabled(routeGroup) {
+			return statelessAuthenticator
+		}
+		return statefulAuthenticator
+	}
+	// a token issued by any route group may be used on the stateless ones
+	exposeJWT := len(cfg.StatelessAuth.RouteGroups) > 0
+	ratelimiter := buildRateLimiter(cfg.RateLimit, stores.rateLimitService)
+	// unknown clients are only rejected by the route groups serving api clients,
+	// health, metrics, saml, admin and emailed link routes can't present client credentials
+	clientVerification := middlewares.Middleware(func(next http.Handler) http.Handler { return next })
+	if cfg.Client.RejectUnknown {
+		clientVerification = middlewares.RequireVerifiedClient
+	}
 
 	healthHandler := handlers.HealthzHandler{}
 	metricHandler := handlers.MetricHandler{}
 	authenticationHandler := handlers.AuthenticationHandler{
 		RateLimiter:           ratelimiter,
-		Authenticator:         authenticator,
+		ClientVerification:    clientVerification,
+		Authenticator:         authenticator(config.RouteGroupAuthentication),
 		Authorization:         middlewares.Authorize,
 		ProfileService:        profileSvc,
 		AuthenticationService: authSvc,
 		SessionService:        sessionSvc,
 		EventService:          eventSvc,
+		ExposeJWT:             exposeJWT,
 	}
 	profileHandler := handlers.ProfileHandler{
-		Authorization:  middlewares.Authorize,
-		RateLimiter:    ratelimiter,
-		Authenticator:  authenticator,
-		ProfileService: profileSvc,
-		EventService:   eventSvc,
+		Authorization:        middlewares.Authorize,
+		RateLimiter:          ratelimiter,
+		Authenticator:        authenticator(config.RouteGroupProfile),
+		RecentAuthentication: middlewares.RequireRecentAuthentication,
+		ClientVerification:   clientVerification,
+		ProfileService:       profileSvc,
+		SessionService:       sessionSvc,
+		EventService:         eventSvc,
+		Exporter:             exporter,
 	}
 	sessionHandler := handlers.SessionHandler{
-		Authorization:  middlewares.Authorize,
-		Authenticator:  authenticator,
-		ProfileService: profileSvc,
-		SessionService: sessionSvc,
+		Authorization:        middlewares.Authorize,
+		Authenticator:        authenticator(config.RouteGroupSession),
+		RecentAuthentication: middlewares.RequireRecentAuthentication,
+		ClientVerification:   clientVerification,
+		ProfileService:       profileSvc,
+		SessionService:       sessionSvc,
+		ExposeJWT:            exposeJWT,
+	}
+	samlHandler := handlers.SAMLHandler{
+		AdminAuthenticator: middlewares.BasicAuth(cfg.API.AdminUser, cfg.API.AdminPassword),
+		SAMLService:        samlSvc,
+		SessionService:     sessionSvc,
+		EventService:       eventSvc,
+		ExposeJWT:          exposeJWT,
 	}
 
-	server := server.NewServer(cfg.API, metricHandler, healthHandler, authenticationHandler, profileHandler, sessionHandler)
+	revocationHandler := handlers.RevocationHandler{
+		Authenticator:     middlewares.BasicAuth(cfg.API.AdminUser, cfg.API.AdminPassword),
+		RevocationService: revocationSvc,
+	}
+	clientHandler := handlers.ClientHandler{
+		AdminAuthenticator: middlewares.BasicAuth(cfg.API.AdminUser, cfg.API.AdminPassword),
+		ClientService:      clientSvc,
+	}
+
+	trustedProxies, err := middlewares.ParseTrustedProxies(cfg.API.TrustedProxies)
+	if err != nil {
+		logrus.Fatalf("middlewares.ParseTrustedProxies(cfg) err = %v", err)
+	}
+	forwardedHeader, err := middlewares.ParseForwardedHeader(cfg.API.ForwardedHeader)
+	if err != nil {
+		logrus.Fatalf("middlewares.ParseForwardedHeader(cfg) err = %v", err)
+	}
+	server := server.NewServer(cfg.API, metricHandler, healthHandler, authenticationHandler, profileHandler, sessionHandler, samlHandler, revocationHandler, clientHandler)
+	server.ClientParser = middlewares.ParseClientInfo(
+		trustedProxies,
+		middlewares.WithForwardedHeader(forwardedHeader),
+		middlewares.WithClientVerifier(clientSvc),
+	)
 	srv := server.CreateHTTPServer()
 
 	// Handle SIGINT, SIGTERN, SIGHUP signal from OS
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strconv"
+
+	"github.com/AdhityaRamadhanus/userland/pkg/common/migration"
+	"github.com/AdhityaRamadhanus/userland/pkg/config"
+	"github.com/AdhityaRamadhanus/userland/pkg/storage/postgres"
+	"github.com/AdhityaRamadhanus/userland/pkg/storage/sqlite"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const migrateUsage = "usage: api migrate [--database=postgres|sqlite] up | down N | status | force VERSION"
+
+//buildMigrator connect to database and return a migrator of its embedded migrations
+func buildMigrator(cfg *config.Configuration, database string) (*migration.Migrator, *sqlx.DB, error) {
+	var (
+		conn       *sqlx.DB
+		driver     migration.Driver
+		migrations []migration.Migration
+		err        error
+	)
+	switch database {
+	case config.DatabaseDriverPostgres:
+		if conn, err = postgres.CreateConnection(cfg.Postgres); err != nil {
+			return nil, nil, errors.Wrap(err, "postgres.CreateConnection() err")
+		}
+		driver = postgres.NewMigrationDriver(conn)
+		migrations, err = postgres.Migrations()
+	case config.DatabaseDriverSQLite:
+		if conn, err = sqlite.CreateConnection(cfg.SQLite); err != nil {
+			return nil, nil, errors.Wrap(err, "sqlite.CreateConnection() err")
+		}
+		driver = sqlite.NewMigrationDriver(conn)
+		migrations, err = sqlite.Migrations()
+	default:
+		return nil, nil, errors.Errorf("unknown database %q", database)
+	}
+	if err != nil {
+		conn.Close()
+		return nil, nil, errors.Wrap(err, "Migrations() err")
+	}
+
+	return migration.NewMigrator(driver, migrations), conn, nil
+}
+
+//runMigrate run the migrate subcommand, migrations are embedded in the binary so no sql file is needed
+func runMigrate(cfg *config.Configuration, args []string) error {
+	flags := flag.NewFlagSet("migrate", flag.ExitOnError)
+	defaultDatabase := cfg.Database.Driver
+	if defaultDatabase == "" {
+		defaultDatabase = config.DatabaseDriverPostgres
+	}
+	database := flags.String("database", defaultDatabase, "database to migrate, postgres or sqlite, default to database.driver")
+	flags.Parse(args)
+	if flags.NArg() == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	migrator, conn, err := buildMigrator(cfg, *database)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	ctx := context.Background()
+	switch command := flags.Arg(0); {
+	case command == "up" && flags.NArg() == 1:
+		applied, err := migrator.Up(ctx)
+		for _, m := range applied {
+			logrus.Info("Applied ", m)
+		}
+		if err == nil && len(applied) == 0 {
+			logrus.Info("No pending migration")
+		}
+		return err
+	case command == "down" && flags.NArg() == 2:
+		n, err := strconv.Atoi(flags.Arg(1))
+		if err != nil || n < 1 {
+			return errors.Errorf("down take a positive number of migrations, got %q", flags.Arg(1))
+		}
+		reverted, err := migrator.Down(ctx, n)
+		for _, m := range reverted {
+			logrus.Info("Reverted ", m)
+		}
+		return err
+	case command == "status" && flags.NArg() == 1:
+		status, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("version: %d, dirty: %t\n", status.Version, status.Dirty)
+		for _, m := range status.Pending {
+			fmt.Printf("pending: %s\n", m)
+		}
+		return nil
+	case command == "force" && flags.NArg() == 2:
+		version, err := strconv.Atoi(flags.Arg(1))
+		if err != nil || version < 0 {
+			return errors.Errorf("force take a version, got %q", flags.Arg(1))
+		}
+		if err := migrator.Force(ctx, version); err != nil {
+			return err
+		}
+		logrus.Info("Forced version ", version)
+		return nil
+	default:
+		return errors.New(migrateUsage)
+	}
+}
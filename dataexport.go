@@ -0,0 +1,45 @@
+package userland
+
+import (
+	"github.com/go-errors/errors"
+
+	"context"
+	"time"
+)
+
+var (
+	//ErrDataExportNotFound represent data export is not found or already claimed by another exporter
+	ErrDataExportNotFound = errors.New("Data export not found")
+)
+
+//DataExport is a requested export of user data, it is pending until completed and its archive is deleted once expired
+type DataExport struct {
+	ID     int
+	UserID int
+	// Path of the archive in object storage, empty until completed
+	Path        string
+	RequestedAt time.Time
+	// StartedAt is when an exporter claimed the export, zero while it wait for one
+	StartedAt   time.Time
+	CompletedAt time.Time
+	// ExpiredAt is when the download link expire, zero until completed
+	ExpiredAt time.Time
+}
+
+//DataExports is collection of data export
+type DataExports []DataExport
+
+//DataExportRepository provide an interface to keep track of data exports from request until their archive is deleted
+type DataExportRepository interface {
+	Insert(ctx context.Context, dataExport *DataExport) error
+	// FindAllPending return exports not completed yet and not claimed since startedBefore, oldest first
+	FindAllPending(ctx context.Context, startedBefore time.Time) (DataExports, error)
+	// Claim mark a pending export as started, it fail with ErrDataExportNotFound when another exporter claimed it since startedBefore
+	Claim(ctx context.Context, id int, startedBefore time.Time) error
+	Complete(ctx context.Context, id int, path string, expiredAt time.Time) error
+	// FindAllExpired return completed exports whose download link expired before expiredBefore
+	FindAllExpired(ctx context.Context, expiredBefore time.Time) (DataExports, error)
+	FindAllByUserID(ctx context.Context, userID int) (DataExports, error)
+	// Delete of a missing export is not an error
+	Delete(ctx context.Context, id int) error
+}
@@ -1,6 +1,7 @@
 package userland
 
 import (
+	"context"
 	"time"
 )
 
@@ -13,8 +14,13 @@ type Event struct {
 	IP         string
 	ClientID   int
 	ClientName string
-	Timestamp  time.Time
-	CreatedAt  time.Time
+	Country    string
+	City       string
+	ASN        int
+	// ChangedFields is the name of every field changed by the event, never their values
+	ChangedFields []string
+	Timestamp     time.Time
+	CreatedAt     time.Time
 }
 
 //Events is collection of Event
@@ -36,7 +42,7 @@ type EventPagingOptions struct {
 
 //EventRepository provide an interface to get user events
 type EventRepository interface {
-	FindAll(filter EventFilterOptions, paging EventPagingOptions) (Events, int, error)
-	Insert(event Event) error
-	DeleteAllByUserID(userID int) error
+	FindAll(ctx context.Context, filter EventFilterOptions, paging EventPagingOptions) (Events, int, error)
+	Insert(ctx context.Context, event Event) error
+	DeleteAllByUserID(ctx context.Context, userID int) error
 }
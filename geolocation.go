@@ -0,0 +1,15 @@
+package userland
+
+//Geolocation is what is known about the origin of an ip address, fields are empty when unknown
+type Geolocation struct {
+	Country   string
+	City      string
+	ASN       int
+	Latitude  float64
+	Longitude float64
+}
+
+//GeolocationService provide an interface to locate ip addresses
+type GeolocationService interface {
+	Lookup(ip string) (Geolocation, error)
+}
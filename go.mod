@@ -4,19 +4,17 @@ go 1.12
 
 require (
 	cloud.google.com/go v0.38.0
-	github.com/AdhityaRamadhanus/chronicle v0.0.0-20181104100529-12c91852031a // indirect
-	github.com/AdhityaRamadhanus/godex v0.0.0-20190820083838-58f02e89e36d // indirect
 	github.com/NYTimes/gziphandler v1.1.1
 	github.com/VividCortex/gohistogram v1.0.0 // indirect
 	github.com/asaskevich/govalidator v0.0.0-20190424111038-f61b66f89f4a
+	github.com/beevik/etree v1.1.0
 	github.com/dgrijalva/jwt-go v3.2.0+incompatible
 	github.com/go-errors/errors v1.0.1
 	github.com/go-kit/kit v0.8.0
 	github.com/go-redis/redis v6.15.5+incompatible
 	github.com/gocraft/work v0.5.1
+	github.com/golang/protobuf v1.3.2 // indirect
 	github.com/gomodule/redigo v2.0.0+incompatible
-	github.com/google/uuid v1.1.1 // indirect
-	github.com/googleapis/gax-go v2.0.2+incompatible // indirect
 	github.com/gorilla/mux v1.7.3
 	github.com/jmoiron/sqlx v1.2.0
 	github.com/joho/godotenv v1.3.0
@@ -24,27 +22,25 @@ require (
 	github.com/kelseyhightower/envconfig v1.4.0
 	github.com/lib/pq v1.2.0
 	github.com/mailjet/mailjet-apiv3-go v0.0.0-20190724151621-55e56f74078c
+	github.com/mattn/go-sqlite3 v1.9.0
+	github.com/mssola/user_agent v0.5.3
 	github.com/onsi/ginkgo v1.10.1 // indirect
 	github.com/onsi/gomega v1.7.0 // indirect
+	github.com/oschwald/maxminddb-golang v1.8.0
 	github.com/pkg/errors v0.8.1
 	github.com/prometheus/client_golang v0.9.3
 	github.com/prometheus/common v0.4.0
-	github.com/pyros2097/go-embed v0.0.0-20160412061840-4274f3450521 // indirect
 	github.com/robfig/cron v1.2.0 // indirect
 	github.com/rs/cors v1.7.0
-	github.com/sarulabs/di v2.0.0+incompatible
+	github.com/russellhaering/goxmldsig v1.4.0
 	github.com/satori/go.uuid v1.2.0
-	github.com/sebest/logrusly v0.0.0-20180315190218-3235eccb8edc // indirect
-	github.com/segmentio/go-loggly v0.5.0 // indirect
 	github.com/sirupsen/logrus v1.4.2
 	github.com/skip2/go-qrcode v0.0.0-20190110000554-dc11ecdae0a9
-	github.com/spf13/viper v1.4.0 // indirect
-	github.com/stretchr/testify v1.4.0
-	github.com/teambition/ratelimiter-go v1.0.1
-	github.com/ulule/limiter v2.2.2+incompatible // indirect
-	github.com/ulule/limiter/v3 v3.3.2 // indirect
+	github.com/stretchr/testify v1.6.1
 	go.opencensus.io v0.22.1 // indirect
 	golang.org/x/crypto v0.0.0-20190308221718-c2843e01d9a2
-	google.golang.org/api v0.10.0 // indirect
+	golang.org/x/net v0.0.0-20190724013045-ca1201d0de80 // indirect
+	google.golang.org/api v0.10.0
+	google.golang.org/grpc v1.21.0 // indirect
 	gopkg.in/yaml.v2 v2.2.2
 )
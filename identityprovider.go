@@ -0,0 +1,57 @@
+package userland
+
+import (
+	"github.com/go-errors/errors"
+
+	"context"
+	"strings"
+	"time"
+)
+
+var (
+	//ErrIdentityProviderNotFound represent identity provider is not found when searching in repository
+	ErrIdentityProviderNotFound = errors.New("Identity provider not found")
+	//ErrIdentityProviderLinkNotFound represent a subject of identity provider that is not linked to any user
+	ErrIdentityProviderLinkNotFound = errors.New("Identity provider link not found")
+)
+
+//IdentityProvider is domain entity of a tenant SAML 2.0 identity provider
+type IdentityProvider struct {
+	ID               int
+	Tenant           string
+	EntityID         string
+	SSOURL           string
+	Certificates     []string
+	AttributeMapping map[string]string
+	// Domains are the email domains the identity provider is authoritative for
+	Domains   []string
+	Metadata  string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+//Owns return true when email belong to one of the identity provider domains
+func (i IdentityProvider) Owns(email string) bool {
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return false
+	}
+	domain := email[at+1:]
+	for _, ownedDomain := range i.Domains {
+		if strings.EqualFold(domain, ownedDomain) {
+			return true
+		}
+	}
+	return false
+}
+
+//IdentityProviderRepository provide an interface to get tenant identity providers
+type IdentityProviderRepository interface {
+	FindByTenant(ctx context.Context, tenant string) (IdentityProvider, error)
+	Insert(ctx context.Context, identityProvider *IdentityProvider) error
+	Update(ctx context.Context, identityProvider IdentityProvider) error
+	// FindLinkedUserID return the user a subject (NameID) of identity provider signed in as before
+	FindLinkedUserID(ctx context.Context, identityProviderID int, nameID string) (userID int, err error)
+	// Link is idempotent, linking a subject again to the same user is not an error
+	Link(ctx context.Context, identityProviderID int, nameID string, userID int) error
+}
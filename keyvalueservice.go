@@ -3,6 +3,7 @@ package userland
 import (
 	"github.com/go-errors/errors"
 
+	"context"
 	"time"
 )
 
@@ -13,8 +14,13 @@ var (
 
 //KeyValueService provide an interface to get key
 type KeyValueService interface {
-	Set(key string, value []byte) error
-	SetEx(key string, value []byte, expirationInSeconds time.Duration) error
-	Get(key string) ([]byte, error)
-	Delete(key string) error
+	Set(ctx context.Context, key string, value []byte) error
+	SetEx(ctx context.Context, key string, value []byte, expirationInSeconds time.Duration) error
+	Get(ctx context.Context, key string) ([]byte, error)
+	Delete(ctx context.Context, key string) error
+	Expire(ctx context.Context, key string, expiration time.Duration) error
+	// SetNX set value of key only when key doesn't exist yet, set is false when the key is already taken
+	SetNX(ctx context.Context, key string, value []byte, expiration time.Duration) (set bool, err error)
+	// Incr atomically increment the integer value of key, missing key count from zero, and make key expire after expiration
+	Incr(ctx context.Context, key string, expiration time.Duration) (value int64, err error)
 }
@@ -0,0 +1,30 @@
+package userland
+
+import (
+	"context"
+	"time"
+)
+
+var (
+	LoginRiskDecisionAllow     = "allow"
+	LoginRiskDecisionChallenge = "challenge"
+	LoginRiskDecisionBlock     = "block"
+)
+
+//LoginRiskAssessment is a record of the signals found on a login attempt and the decision made from them
+type LoginRiskAssessment struct {
+	ID        int
+	UserID    int
+	IP        string
+	UserAgent string
+	Score     int
+	Signals   []string
+	Decision  string
+	CreatedAt time.Time
+}
+
+//LoginRiskAssessmentRepository provide an interface to record login risk assessments for review
+type LoginRiskAssessmentRepository interface {
+	Insert(ctx context.Context, assessment *LoginRiskAssessment) error
+	DeleteAllByUserID(ctx context.Context, userID int) error
+}
@@ -1,6 +1,9 @@
 package userland
 
-import "io"
+import (
+	"context"
+	"io"
+)
 
 //ObjectMetaData is data about object
 type ObjectMetaData struct {
@@ -14,6 +17,8 @@ type ObjectMetaData struct {
 //ObjectStorageService provide an interface to get objects
 type ObjectStorageService interface {
 	// should write with options
-	Write(reader io.Reader, metadata ObjectMetaData) (string, error)
-	Fetch(path string) ([]byte, ObjectMetaData, error)
+	Write(ctx context.Context, reader io.Reader, metadata ObjectMetaData) (string, error)
+	Fetch(ctx context.Context, path string) ([]byte, ObjectMetaData, error)
+	// Delete of a missing object is not an error
+	Delete(ctx context.Context, path string) error
 }
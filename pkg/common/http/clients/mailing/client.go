@@ -17,6 +17,7 @@ var (
 type Client interface {
 	SendOTPEmail(recipientAddress string, recipientName string, otpType string, otp string) error
 	SendVerificationEmail(recipientAddress string, recipientName string, verificationLink string) error
+	SendNoticeEmail(recipientAddress string, recipientName string, subject string, message string, actionLink string) error
 }
 
 type client struct {
@@ -115,3 +116,37 @@ func (c client) SendVerificationEmail(recipientAddress string, recipientName str
 
 	return nil
 }
+
+func (c client) SendNoticeEmail(recipientAddress string, recipientName string, subject string, message string, actionLink string) error {
+	url := fmt.Sprintf("%s/api/mail/notice", c.baseURL)
+
+	requestBody := map[string]interface{}{
+		"recipient":      recipientAddress,
+		"recipient_name": recipientName,
+		"subject":        subject,
+		"message":        message,
+		"action_link":    actionLink,
+	}
+	jsonBytes, err := json.Marshal(requestBody)
+	if err != nil {
+		return errors.Wrapf(err, "json.Marshal() err")
+	}
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(jsonBytes))
+	if err != nil {
+		return errors.Wrapf(err, "http.NewRequest() err")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.SetBasicAuth(c.username, c.password)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "httpClient.Do() err")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.Wrapf(ErrSendEmailFailed, "%s return status code = %d", url, resp.StatusCode)
+	}
+
+	return nil
+}
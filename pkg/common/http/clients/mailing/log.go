@@ -0,0 +1,38 @@
+package mailing
+
+import (
+	"github.com/sirupsen/logrus"
+)
+
+type logClient struct{}
+
+//NewLogClient return a Client logging emails instead of sending them, for running without a mailing service
+func NewLogClient() Client {
+	return logClient{}
+}
+
+func (c logClient) SendOTPEmail(recipientAddress string, recipientName string, otpType string, otp string) error {
+	logrus.WithFields(logrus.Fields{
+		"recipient": recipientAddress,
+		"type":      otpType,
+		"otp":       otp,
+	}).Info("OTP email")
+	return nil
+}
+
+func (c logClient) SendVerificationEmail(recipientAddress string, recipientName string, verificationLink string) error {
+	logrus.WithFields(logrus.Fields{
+		"recipient": recipientAddress,
+		"link":      verificationLink,
+	}).Info("Verification email")
+	return nil
+}
+
+func (c logClient) SendNoticeEmail(recipientAddress string, recipientName string, subject string, message string, actionLink string) error {
+	logrus.WithFields(logrus.Fields{
+		"recipient": recipientAddress,
+		"subject":   subject,
+		"link":      actionLink,
+	}).Info("Notice email")
+	return nil
+}
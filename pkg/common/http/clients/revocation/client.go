@@ -0,0 +1,194 @@
+package revocation
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	_http "github.com/AdhityaRamadhanus/userland/pkg/common/http"
+	"github.com/AdhityaRamadhanus/userland/pkg/common/security"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	ErrStreamFailed = errors.New("Failed to stream revocations")
+)
+
+//Revocation is a session revocation received from userland api
+type Revocation struct {
+	SessionID string    `json:"session_id"`
+	UserID    int       `json:"user_id"`
+	Reason    string    `json:"reason"`
+	RevokedAt time.Time `json:"revoked_at"`
+}
+
+//Client follow revocation feed of userland api and maintain a deny list of revoked sessions
+type Client interface {
+	Run(ctx context.Context) error
+	IsRevoked(sessionID string) bool
+}
+
+type client struct {
+	username      string
+	password      string
+	baseURL       string
+	httpClient    _http.Client
+	retryInterval time.Duration
+	denyList      *DenyList
+	onRevocation  func(Revocation)
+
+	mutex       sync.Mutex
+	lastEventID string
+}
+
+func WithBasicAuth(username, password string) func(client *client) {
+	return func(client *client) {
+		client.username = username
+		client.password = password
+	}
+}
+
+//WithHTTPClient set http client used for streaming, it must not have a timeout shorter than a stream
+func WithHTTPClient(c _http.Client) func(client *client) {
+	return func(client *client) {
+		client.httpClient = c
+	}
+}
+
+func WithRetryInterval(retryInterval time.Duration) func(client *client) {
+	return func(client *client) {
+		client.retryInterval = retryInterval
+	}
+}
+
+//WithDenyListTTL set how long revoked sessions are remembered, should be at least the longest token expiration
+func WithDenyListTTL(ttl time.Duration) func(client *client) {
+	return func(client *client) {
+		client.denyList = NewDenyList(ttl)
+	}
+}
+
+//WithRevocationHandler is called for every revocation received, eg: to evict a local token cache
+func WithRevocationHandler(handler func(Revocation)) func(client *client) {
+	return func(client *client) {
+		client.onRevocation = handler
+	}
+}
+
+func NewRevocationClient(baseURL string, options ...func(*client)) Client {
+	client := &client{
+		baseURL:       baseURL,
+		httpClient:    &http.Client{},
+		retryInterval: time.Second,
+		denyList:      NewDenyList(security.RefreshAccessTokenExpiration),
+	}
+	for _, option := range options {
+		option(client)
+	}
+
+	return client
+}
+
+//Run stream revocations until ctx is done, reconnecting from the last received event
+func (c *client) Run(ctx context.Context) error {
+	for {
+		if err := c.stream(ctx); err != nil && ctx.Err() == nil {
+			logrus.WithError(err).Warn("Revocation stream disconnected, reconnecting")
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(c.retryInterval):
+		}
+	}
+}
+
+func (c *client) IsRevoked(sessionID string) bool {
+	return c.denyList.Contains(sessionID)
+}
+
+func (c *client) stream(ctx context.Context) error {
+	url := fmt.Sprintf("%s/api/internal/revocations", c.baseURL)
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return errors.Wrapf(err, "http.NewRequest() err")
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Accept", "text/event-stream")
+	req.SetBasicAuth(c.username, c.password)
+
+	c.mutex.Lock()
+	lastEventID := c.lastEventID
+	c.mutex.Unlock()
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	} else {
+		// first connection, backfill everything that could still be valid
+		since := time.Now().Add(-c.denyList.ttl)
+		query := req.URL.Query()
+		query.Set("since", strconv.FormatInt(since.UnixNano()/int64(time.Millisecond), 10))
+		req.URL.RawQuery = query.Encode()
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "httpClient.Do() err")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.Wrapf(ErrStreamFailed, "%s return status code = %d", url, resp.StatusCode)
+	}
+
+	return c.readEvents(bufio.NewScanner(resp.Body))
+}
+
+//readEvents parse server-sent events, only data and id fields are used
+func (c *client) readEvents(scanner *bufio.Scanner) error {
+	eventID := ""
+	data := []string{}
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if len(data) > 0 {
+				c.dispatch(eventID, strings.Join(data, "\n"))
+			}
+			eventID = ""
+			data = []string{}
+		case strings.HasPrefix(line, ":"):
+			// comment, used as keep-alive
+		case strings.HasPrefix(line, "id:"):
+			eventID = strings.TrimSpace(strings.TrimPrefix(line, "id:"))
+		case strings.HasPrefix(line, "data:"):
+			data = append(data, strings.TrimSpace(strings.TrimPrefix(line, "data:")))
+		}
+	}
+
+	return scanner.Err()
+}
+
+func (c *client) dispatch(eventID string, data string) {
+	revocation := Revocation{}
+	if err := json.Unmarshal([]byte(data), &revocation); err != nil {
+		return
+	}
+
+	c.denyList.Add(revocation.SessionID, revocation.RevokedAt)
+	if eventID != "" {
+		c.mutex.Lock()
+		c.lastEventID = eventID
+		c.mutex.Unlock()
+	}
+	if c.onRevocation != nil {
+		c.onRevocation(revocation)
+	}
+}
@@ -0,0 +1,79 @@
+// +build unit
+
+package revocation_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/AdhityaRamadhanus/userland/pkg/common/http/clients/revocation"
+)
+
+func TestClient_Run(t *testing.T) {
+	revokedAt := time.Now().Format(time.RFC3339Nano)
+	lastEventIDs := make(chan string, 2)
+	ts := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		lastEventIDs <- req.Header.Get("Last-Event-ID")
+		res.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(res, "retry: 1000\n\n")
+		fmt.Fprint(res, ": keep-alive\n\n")
+		fmt.Fprintf(res, "id: 42\nevent: revocation\ndata: {\"session_id\":\"revoked-session\",\"user_id\":1,\"reason\":\"logout\",\"revoked_at\":%q}\n\n", revokedAt)
+	}))
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	received := make(chan revocation.Revocation, 2)
+	client := revocation.NewRevocationClient(ts.URL,
+		revocation.WithRetryInterval(10*time.Millisecond),
+		revocation.WithRevocationHandler(func(r revocation.Revocation) {
+			received <- r
+		}),
+	)
+	go client.Run(ctx)
+
+	select {
+	case r := <-received:
+		if r.SessionID != "revoked-session" {
+			t.Errorf("Revocation.SessionID = %s; want revoked-session", r.SessionID)
+		}
+	case <-ctx.Done():
+		t.Fatal("no revocation received")
+	}
+
+	if !client.IsRevoked("revoked-session") {
+		t.Error("client.IsRevoked(revoked-session) = false; want true")
+	}
+	if client.IsRevoked("active-session") {
+		t.Error("client.IsRevoked(active-session) = true; want false")
+	}
+
+	// reconnection resume from the last received event
+	<-lastEventIDs
+	select {
+	case lastEventID := <-lastEventIDs:
+		if lastEventID != "42" {
+			t.Errorf("Last-Event-ID = %q; want 42", lastEventID)
+		}
+	case <-ctx.Done():
+		t.Fatal("client didn't reconnect")
+	}
+}
+
+func TestDenyList(t *testing.T) {
+	denyList := revocation.NewDenyList(time.Minute)
+	denyList.Add("recent-session", time.Now())
+	denyList.Add("old-session", time.Now().Add(-time.Hour))
+
+	if !denyList.Contains("recent-session") {
+		t.Error("denyList.Contains(recent-session) = false; want true")
+	}
+	if denyList.Contains("old-session") {
+		t.Error("denyList.Contains(old-session) = true; want false")
+	}
+}
@@ -0,0 +1,56 @@
+package revocation
+
+import (
+	"sync"
+	"time"
+)
+
+//DenyList is an in-memory set of revoked session ids, entries are dropped once no token of the session can still be valid
+type DenyList struct {
+	mutex     sync.RWMutex
+	entries   map[string]time.Time
+	ttl       time.Duration
+	lastPrune time.Time
+}
+
+//NewDenyList construct a DenyList keeping entries for ttl after their revocation
+func NewDenyList(ttl time.Duration) *DenyList {
+	return &DenyList{
+		entries: map[string]time.Time{},
+		ttl:     ttl,
+	}
+}
+
+//Add a revoked session
+func (d *DenyList) Add(sessionID string, revokedAt time.Time) {
+	now := time.Now()
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.entries[sessionID] = revokedAt.Add(d.ttl)
+	if now.Sub(d.lastPrune) > time.Minute {
+		for id, expiredAt := range d.entries {
+			if now.After(expiredAt) {
+				delete(d.entries, id)
+			}
+		}
+		d.lastPrune = now
+	}
+}
+
+//Contains return true when session is revoked
+func (d *DenyList) Contains(sessionID string) bool {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	expiredAt, ok := d.entries[sessionID]
+	return ok && time.Now().Before(expiredAt)
+}
+
+//Len return number of entries, including the ones waiting to be pruned
+func (d *DenyList) Len() int {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	return len(d.entries)
+}
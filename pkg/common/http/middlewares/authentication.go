@@ -8,14 +8,39 @@ import (
 
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/AdhityaRamadhanus/userland"
 	"github.com/AdhityaRamadhanus/userland/pkg/common/contextkey"
 	"github.com/AdhityaRamadhanus/userland/pkg/common/http/render"
 	"github.com/AdhityaRamadhanus/userland/pkg/common/keygenerator"
+	"github.com/AdhityaRamadhanus/userland/pkg/common/security"
 	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/sirupsen/logrus"
 )
 
+//SessionActivityRecorder record activity of the session behind an authenticated request
+type SessionActivityRecorder interface {
+	TouchSession(ctx context.Context, userID int, sessionID string, ip string) error
+}
+
+//RevocationChecker tell whether a session is revoked and how out of date that answer may be
+type RevocationChecker interface {
+	IsRevoked(sessionID string) bool
+	Staleness() time.Duration
+}
+
+type tokenAuthOptions struct {
+	sessionActivityRecorder SessionActivityRecorder
+}
+
+//WithSessionActivityRecorder make TokenAuth record last seen time and ip of user sessions
+func WithSessionActivityRecorder(recorder SessionActivityRecorder) func(*tokenAuthOptions) {
+	return func(options *tokenAuthOptions) {
+		options.sessionActivityRecorder = recorder
+	}
+}
+
 func parseAuthorizationHeader(authHeader, scheme string) (cred string, err error) {
 	splittedHeader := strings.Split(authHeader, " ")
 	if len(splittedHeader) != 2 {
@@ -29,7 +54,12 @@ func parseAuthorizationHeader(authHeader, scheme string) (cred string, err error
 }
 
 //Authenticate request
-func TokenAuth(keyValueService userland.KeyValueService, jwtSecret string) Middleware {
+func TokenAuth(keyValueService userland.KeyValueService, jwtSecret string, options ...func(*tokenAuthOptions)) Middleware {
+	tokenAuthOptions := &tokenAuthOptions{}
+	for _, option := range options {
+		option(tokenAuthOptions)
+	}
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
 			authHeader, ok := req.Header["Authorization"]
@@ -56,7 +86,7 @@ func TokenAuth(keyValueService userland.KeyValueService, jwtSecret string) Middl
 				return
 			}
 
-			token, err := keyValueService.Get(keygenerator.TokenKey(cred))
+			token, err := keyValueService.Get(req.Context(), keygenerator.TokenKey(cred))
 			if err != nil {
 				render.JSON(res, http.StatusUnauthorized, map[string]interface{}{
 					"status": http.StatusUnauthorized,
@@ -68,13 +98,7 @@ func TokenAuth(keyValueService userland.KeyValueService, jwtSecret string) Middl
 				return
 			}
 
-			jwtToken, err := jwt.Parse(string(token), func(token *jwt.Token) (interface{}, error) {
-				if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-					return nil, errors.New("Unexpected signing method")
-				}
-				// TODO move to config
-				return []byte(jwtSecret), nil
-			})
+			claims, err := parseAccessToken(string(token), jwtSecret)
 			if err != nil {
 				render.JSON(res, http.StatusUnauthorized, map[string]interface{}{
 					"status": http.StatusUnauthorized,
@@ -86,14 +110,154 @@ func TokenAuth(keyValueService userland.KeyValueService, jwtSecret string) Middl
 				return
 			}
 
-			claims, _ := jwtToken.Claims.(jwt.MapClaims)
 			req = req.WithContext(context.WithValue(req.Context(), contextkey.AccessToken, map[string]interface{}(claims)))
 			req = req.WithContext(context.WithValue(req.Context(), contextkey.AccessTokenKey, cred))
+			if tokenAuthOptions.sessionActivityRecorder != nil {
+				recordSessionActivity(tokenAuthOptions.sessionActivityRecorder, req, claims, cred)
+			}
+			next.ServeHTTP(res, req)
+		})
+	}
+}
+
+/*
+StatelessTokenAuth authenticate request with the JWT itself as bearer, verified locally without a key value round-trip.
+The jti claim is the session id, it is checked against revocationChecker as long as the checker is not staler than maxStaleness.
+Opaque bearers, scopes other than user (refresh and tfa tokens are single use) and any token while the checker is too stale
+are verified against keyValueService like TokenAuth does. Sliding session expiration is not enforced for locally verified tokens.
+*/
+func StatelessTokenAuth(keyValueService userland.KeyValueService, jwtSecret string, revocationChecker RevocationChecker, maxStaleness time.Duration, options ...func(*tokenAuthOptions)) Middleware {
+	tokenAuthOptions := &tokenAuthOptions{}
+	for _, option := range options {
+		option(tokenAuthOptions)
+	}
+	statefulTokenAuth := TokenAuth(keyValueService, jwtSecret, options...)
+
+	return func(next http.Handler) http.Handler {
+		statefulNext := statefulTokenAuth(next)
+		return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			authHeader, ok := req.Header["Authorization"]
+			if !ok || len(authHeader) == 0 { // invalid header
+				render.JSON(res, http.StatusUnauthorized, map[string]interface{}{
+					"status": http.StatusUnauthorized,
+					"error": map[string]interface{}{
+						"code":    "ErrInvalidAuthorizationHeader",
+						"message": "Authorization Header is not present",
+					},
+				})
+				return
+			}
+
+			cred, err := parseAuthorizationHeader(authHeader[0], "Bearer")
+			if err != nil {
+				render.JSON(res, http.StatusUnauthorized, map[string]interface{}{
+					"status": http.StatusUnauthorized,
+					"error": map[string]interface{}{
+						"code":    "ErrInvalidAuthorizationHeader",
+						"message": err.Error(),
+					},
+				})
+				return
+			}
+
+			// opaque session id
+			if strings.Count(cred, ".") != 2 {
+				statefulNext.ServeHTTP(res, req)
+				return
+			}
+
+			claims, err := parseAccessToken(cred, jwtSecret)
+			if err != nil {
+				render.JSON(res, http.StatusUnauthorized, map[string]interface{}{
+					"status": http.StatusUnauthorized,
+					"error": map[string]interface{}{
+						"code":    "ErrInvalidAccessToken",
+						"message": err.Error(),
+					},
+				})
+				return
+			}
+
+			sessionID, _ := claims["jti"].(string)
+			scope, _ := claims["scope"].(string)
+			if sessionID == "" {
+				render.JSON(res, http.StatusUnauthorized, map[string]interface{}{
+					"status": http.StatusUnauthorized,
+					"error": map[string]interface{}{
+						"code":    "ErrInvalidAccessToken",
+						"message": "Token has no jti",
+					},
+				})
+				return
+			}
+
+			if scope != security.UserTokenScope || revocationChecker.Staleness() > maxStaleness {
+				token, err := keyValueService.Get(req.Context(), keygenerator.TokenKey(sessionID))
+				if err != nil || string(token) != cred {
+					render.JSON(res, http.StatusUnauthorized, map[string]interface{}{
+						"status": http.StatusUnauthorized,
+						"error": map[string]interface{}{
+							"code":    "ErrInvalidAccessToken",
+							"message": "Token is expired/not found",
+						},
+					})
+					return
+				}
+			} else if revocationChecker.IsRevoked(sessionID) {
+				render.JSON(res, http.StatusUnauthorized, map[string]interface{}{
+					"status": http.StatusUnauthorized,
+					"error": map[string]interface{}{
+						"code":    "ErrInvalidAccessToken",
+						"message": "Token is revoked",
+					},
+				})
+				return
+			}
+
+			req = req.WithContext(context.WithValue(req.Context(), contextkey.AccessToken, map[string]interface{}(claims)))
+			req = req.WithContext(context.WithValue(req.Context(), contextkey.AccessTokenKey, sessionID))
+			if tokenAuthOptions.sessionActivityRecorder != nil {
+				recordSessionActivity(tokenAuthOptions.sessionActivityRecorder, req, claims, sessionID)
+			}
 			next.ServeHTTP(res, req)
 		})
 	}
 }
 
+//parseAccessToken verify signature and expiration of a JWT
+func parseAccessToken(tokenString string, jwtSecret string) (jwt.MapClaims, error) {
+	jwtToken, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.New("Unexpected signing method")
+		}
+		return []byte(jwtSecret), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	claims, _ := jwtToken.Claims.(jwt.MapClaims)
+	return claims, nil
+}
+
+//recordSessionActivity only track user tokens, other scopes are not backed by a session
+func recordSessionActivity(recorder SessionActivityRecorder, req *http.Request, claims jwt.MapClaims, sessionID string) {
+	scope, _ := claims["scope"].(string)
+	userID, ok := claims["userid"].(float64)
+	if scope != security.UserTokenScope || !ok {
+		return
+	}
+
+	ip := ""
+	if clientInfo, ok := req.Context().Value(contextkey.ClientInfo).(map[string]interface{}); ok {
+		ip, _ = clientInfo["ip"].(string)
+	}
+
+	if err := recorder.TouchSession(req.Context(), int(userID), sessionID, ip); err != nil {
+		logrus.WithError(err).WithField("session_id", sessionID).Error("Failed to record session activity")
+	}
+}
+
 func BasicAuth(username, password string) Middleware {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
@@ -3,18 +3,22 @@
 package middlewares_test
 
 import (
+	"context"
 	"encoding/base64"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/AdhityaRamadhanus/userland"
+	"github.com/AdhityaRamadhanus/userland/pkg/common/contextkey"
 	"github.com/AdhityaRamadhanus/userland/pkg/common/http/middlewares"
 	"github.com/AdhityaRamadhanus/userland/pkg/common/keygenerator"
 	"github.com/AdhityaRamadhanus/userland/pkg/common/security"
 	"github.com/AdhityaRamadhanus/userland/pkg/mocks/repository"
+	"github.com/AdhityaRamadhanus/userland/pkg/mocks/service/session"
 )
 
 func TestTokenAuth(t *testing.T) {
@@ -94,6 +98,297 @@ func TestTokenAuth(t *testing.T) {
 	}
 }
 
+func TestTokenAuth_withSessionActivityRecorder(t *testing.T) {
+	user := userland.User{
+		Fullname: "Adhitya Ramadhanus",
+		Email:    "adhitya.ramadhanus@gmail.com",
+		ID:       1,
+	}
+
+	type args struct {
+		scope string
+	}
+	testCases := []struct {
+		name             string
+		args             args
+		wantTouchSession bool
+	}{
+		{
+			name: "user token record activity",
+			args: args{
+				scope: security.UserTokenScope,
+			},
+			wantTouchSession: true,
+		},
+		{
+			name: "refresh token doesn't record activity",
+			args: args{
+				scope: security.RefreshTokenScope,
+			},
+			wantTouchSession: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			accessToken, err := security.CreateAccessToken(user, "jwtsecret_test", security.AccessTokenOptions{
+				Expiration: security.UserAccessTokenExpiration,
+				Scope:      tc.args.scope,
+			})
+			if err != nil {
+				t.Fatalf("security.CreateAccessToken() err = %v; want nil", err)
+			}
+
+			keyValueService := repository.KeyValueService{}
+			keyValueService.On("Get", keygenerator.TokenKey(accessToken.Key)).Return([]byte(accessToken.Value), nil)
+			sessionService := session.SimpleSessionService{CalledMethods: map[string]bool{}}
+
+			authenticator := middlewares.TokenAuth(&keyValueService, "jwtsecret_test", middlewares.WithSessionActivityRecorder(sessionService))
+			handler := func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte("OK"))
+			}
+			mw := authenticator(http.HandlerFunc(handler))
+
+			req, err := http.NewRequest(http.MethodGet, "/", nil)
+			if err != nil {
+				t.Fatalf("http.NewRequest() err = %v; want nil", err)
+			}
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", accessToken.Key))
+			res := httptest.NewRecorder()
+
+			mw.ServeHTTP(res, req)
+			if statusCode := res.Result().StatusCode; statusCode != http.StatusOK {
+				t.Fatalf("middlewares.TokenAuth() res.StatusCode = %d; want %d", statusCode, http.StatusOK)
+			}
+			if gotTouchSession := sessionService.CalledMethods["TouchSession"]; gotTouchSession != tc.wantTouchSession {
+				t.Errorf("SessionService.TouchSession() called = %t; want %t", gotTouchSession, tc.wantTouchSession)
+			}
+		})
+	}
+}
+
+type revocationChecker struct {
+	revoked   map[string]bool
+	staleness time.Duration
+}
+
+func (r revocationChecker) IsRevoked(sessionID string) bool {
+	return r.revoked[sessionID]
+}
+
+func (r revocationChecker) Staleness() time.Duration {
+	return r.staleness
+}
+
+func TestStatelessTokenAuth(t *testing.T) {
+	user := userland.User{
+		Fullname: "Adhitya Ramadhanus",
+		Email:    "adhitya.ramadhanus@gmail.com",
+		ID:       1,
+	}
+	createAccessToken := func(scope string) security.AccessToken {
+		accessToken, err := security.CreateAccessToken(user, "jwtsecret_test", security.AccessTokenOptions{
+			Expiration: security.UserAccessTokenExpiration,
+			Scope:      scope,
+		})
+		if err != nil {
+			t.Fatalf("security.CreateAccessToken() err = %v; want nil", err)
+		}
+		return accessToken
+	}
+	accessToken := createAccessToken(security.UserTokenScope)
+	revokedAccessToken := createAccessToken(security.UserTokenScope)
+	refreshToken := createAccessToken(security.RefreshTokenScope)
+
+	keyValueService := repository.KeyValueService{}
+	keyValueService.On("Get", keygenerator.TokenKey(accessToken.Key)).Return([]byte(accessToken.Value), nil)
+	keyValueService.On("Get", keygenerator.TokenKey(revokedAccessToken.Key)).Return(nil, userland.ErrKeyNotFound)
+	keyValueService.On("Get", keygenerator.TokenKey(refreshToken.Key)).Return(nil, userland.ErrKeyNotFound)
+	keyValueService.On("Get", keygenerator.TokenKey("test")).Return(nil, userland.ErrKeyNotFound)
+
+	freshChecker := revocationChecker{
+		revoked: map[string]bool{revokedAccessToken.Key: true},
+	}
+	staleChecker := revocationChecker{
+		revoked:   map[string]bool{},
+		staleness: time.Minute,
+	}
+
+	type args struct {
+		authHeader        string
+		revocationChecker middlewares.RevocationChecker
+	}
+	testCases := []struct {
+		name           string
+		args           args
+		wantStatusCode int
+	}{
+		{
+			name: "valid jwt bearer",
+			args: args{
+				authHeader:        fmt.Sprintf("Bearer %s", accessToken.Value),
+				revocationChecker: freshChecker,
+			},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name: "revoked jwt bearer",
+			args: args{
+				authHeader:        fmt.Sprintf("Bearer %s", revokedAccessToken.Value),
+				revocationChecker: freshChecker,
+			},
+			wantStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name: "revoked jwt bearer with stale checker fallback to key value",
+			args: args{
+				authHeader:        fmt.Sprintf("Bearer %s", revokedAccessToken.Value),
+				revocationChecker: staleChecker,
+			},
+			wantStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name: "valid jwt bearer with stale checker fallback to key value",
+			args: args{
+				authHeader:        fmt.Sprintf("Bearer %s", accessToken.Value),
+				revocationChecker: staleChecker,
+			},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name: "used refresh token is always checked against key value",
+			args: args{
+				authHeader:        fmt.Sprintf("Bearer %s", refreshToken.Value),
+				revocationChecker: freshChecker,
+			},
+			wantStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name: "jwt bearer with invalid signature",
+			args: args{
+				authHeader:        fmt.Sprintf("Bearer %sx", accessToken.Value),
+				revocationChecker: freshChecker,
+			},
+			wantStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name: "valid opaque bearer",
+			args: args{
+				authHeader:        fmt.Sprintf("Bearer %s", accessToken.Key),
+				revocationChecker: freshChecker,
+			},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name: "expired opaque bearer",
+			args: args{
+				authHeader:        "Bearer test",
+				revocationChecker: freshChecker,
+			},
+			wantStatusCode: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			authenticator := middlewares.StatelessTokenAuth(&keyValueService, "jwtsecret_test", tc.args.revocationChecker, 10*time.Second)
+			handler := func(w http.ResponseWriter, r *http.Request) {
+				if sessionID := r.Context().Value(contextkey.AccessTokenKey).(string); sessionID != accessToken.Key {
+					t.Errorf("context AccessTokenKey = %q; want %q", sessionID, accessToken.Key)
+				}
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte("OK"))
+			}
+			mw := authenticator(http.HandlerFunc(handler))
+
+			req, err := http.NewRequest(http.MethodGet, "/", nil)
+			if err != nil {
+				t.Fatalf("http.NewRequest() err = %v; want nil", err)
+			}
+			req.Header.Set("Authorization", tc.args.authHeader)
+			res := httptest.NewRecorder()
+
+			mw.ServeHTTP(res, req)
+			statusCode := res.Result().StatusCode
+			if statusCode != tc.wantStatusCode {
+				body, _ := ioutil.ReadAll(res.Result().Body)
+				defer res.Result().Body.Close()
+				t.Logf("response %s\n", string(body))
+				t.Errorf("middlewares.StatelessTokenAuth() res.StatusCode = %d; want %d", statusCode, tc.wantStatusCode)
+			}
+		})
+	}
+}
+
+func benchmarkAuthenticator(b *testing.B, authenticator middlewares.Middleware, bearer string) {
+	mw := authenticator(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	req, err := http.NewRequest(http.MethodGet, "/", nil)
+	if err != nil {
+		b.Fatalf("http.NewRequest() err = %v; want nil", err)
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", bearer))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		res := httptest.NewRecorder()
+		mw.ServeHTTP(res, req)
+		if res.Code != http.StatusOK {
+			b.Fatalf("res.StatusCode = %d; want %d", res.Code, http.StatusOK)
+		}
+	}
+}
+
+//keyValueLatency emulate a key value round-trip, a local redis usually answer within this time
+var keyValueLatency = 200 * time.Microsecond
+
+type slowKeyValueService struct {
+	repository.KeyValueService
+	tokens map[string][]byte
+}
+
+func (s *slowKeyValueService) Get(ctx context.Context, key string) ([]byte, error) {
+	time.Sleep(keyValueLatency)
+	token, ok := s.tokens[key]
+	if !ok {
+		return nil, userland.ErrKeyNotFound
+	}
+	return token, nil
+}
+
+func BenchmarkTokenAuth(b *testing.B) {
+	accessToken, err := security.CreateAccessToken(userland.User{ID: 1}, "jwtsecret_test", security.AccessTokenOptions{
+		Expiration: security.UserAccessTokenExpiration,
+		Scope:      security.UserTokenScope,
+	})
+	if err != nil {
+		b.Fatalf("security.CreateAccessToken() err = %v; want nil", err)
+	}
+	keyValueService := &slowKeyValueService{tokens: map[string][]byte{
+		keygenerator.TokenKey(accessToken.Key): []byte(accessToken.Value),
+	}}
+
+	benchmarkAuthenticator(b, middlewares.TokenAuth(keyValueService, "jwtsecret_test"), accessToken.Key)
+}
+
+func BenchmarkStatelessTokenAuth(b *testing.B) {
+	accessToken, err := security.CreateAccessToken(userland.User{ID: 1}, "jwtsecret_test", security.AccessTokenOptions{
+		Expiration: security.UserAccessTokenExpiration,
+		Scope:      security.UserTokenScope,
+	})
+	if err != nil {
+		b.Fatalf("security.CreateAccessToken() err = %v; want nil", err)
+	}
+	keyValueService := &slowKeyValueService{tokens: map[string][]byte{
+		keygenerator.TokenKey(accessToken.Key): []byte(accessToken.Value),
+	}}
+	checker := revocationChecker{revoked: map[string]bool{}}
+
+	benchmarkAuthenticator(b, middlewares.StatelessTokenAuth(keyValueService, "jwtsecret_test", checker, 10*time.Second), accessToken.Value)
+}
+
 func TestBasicAuth(t *testing.T) {
 	username := "test"
 	password := "coba"
@@ -2,46 +2,261 @@ package middlewares
 
 import (
 	"context"
+	"net"
 	"net/http"
 	"strconv"
 	"strings"
 
+	"github.com/AdhityaRamadhanus/userland"
 	"github.com/AdhityaRamadhanus/userland/pkg/common/contextkey"
+	"github.com/AdhityaRamadhanus/userland/pkg/common/http/render"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
 )
 
-func getClientIP(req *http.Request) string {
-	ipAddress, xRealIPPresent := req.Header["X-Real-Ip"]
-	if xRealIPPresent {
-		return ipAddress[0]
+//ClientVerifier resolve the registered client presenting a client credential
+type ClientVerifier interface {
+	VerifyClient(ctx context.Context, credential string, secret string, origin string) (userland.Client, error)
+}
+
+//Forwarding headers a trusted proxy can tell the client ip with
+const (
+	ForwardedHeader     = "Forwarded"
+	XForwardedForHeader = "X-Forwarded-For"
+	XRealIPHeader       = "X-Real-Ip"
+)
+
+type clientInfoOptions struct {
+	clientVerifier  ClientVerifier
+	forwardedHeader string
+}
+
+//WithClientVerifier make ParseClientInfo identify clients by the X-API-Client credential (and X-API-Client-Secret for confidential clients)
+//instead of trusting X-API-ClientID
+func WithClientVerifier(verifier ClientVerifier) func(*clientInfoOptions) {
+	return func(options *clientInfoOptions) {
+		options.clientVerifier = verifier
 	}
+}
 
-	ipAddress, xForwardedForPresent := req.Header["X-Forwarded-For"]
-	if xForwardedForPresent {
-		return ipAddress[0]
+//WithForwardedHeader make ParseClientInfo read the client ip only from header, it has to be the one header trusted proxies set,
+//otherwise a client can send it through the proxies untouched. Default is X-Forwarded-For
+func WithForwardedHeader(header string) func(*clientInfoOptions) {
+	return func(options *clientInfoOptions) {
+		options.forwardedHeader = header
 	}
+}
 
-	return req.RemoteAddr
+//ParseForwardedHeader check header is one of the supported forwarding headers and return its canonical name, empty header is X-Forwarded-For
+func ParseForwardedHeader(header string) (string, error) {
+	if header == "" {
+		return XForwardedForHeader, nil
+	}
+	header = http.CanonicalHeaderKey(strings.TrimSpace(header))
+	switch header {
+	case ForwardedHeader, XForwardedForHeader, XRealIPHeader:
+		return header, nil
+	}
+	return "", errors.Errorf("unsupported forwarded header %q, it has to be one of %s, %s or %s", header, ForwardedHeader, XForwardedForHeader, XRealIPHeader)
+}
+
+//ParseTrustedProxies parse list of CIDRs or single ip addresses of proxies allowed to tell the client ip
+func ParseTrustedProxies(proxies []string) ([]*net.IPNet, error) {
+	trustedProxies := []*net.IPNet{}
+	for _, proxy := range proxies {
+		proxy = strings.TrimSpace(proxy)
+		if proxy == "" {
+			continue
+		}
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return nil, errors.Errorf("invalid trusted proxy %q", proxy)
+			}
+			if ip.To4() != nil {
+				proxy += "/32"
+			} else {
+				proxy += "/128"
+			}
+		}
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, errors.Wrapf(err, "net.ParseCIDR(%q) err", proxy)
+		}
+		trustedProxies = append(trustedProxies, network)
+	}
+	return trustedProxies, nil
+}
+
+func isTrustedProxy(ip net.IP, trustedProxies []*net.IPNet) bool {
+	for _, network := range trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+//stripPort remove port and brackets from an address, "1.2.3.4:80" and "[::1]:80" become "1.2.3.4" and "::1"
+func stripPort(address string) string {
+	address = strings.Trim(strings.TrimSpace(address), `"`)
+	if strings.HasPrefix(address, "[") {
+		if end := strings.Index(address, "]"); end > 0 {
+			return address[1:end]
+		}
+		return address
+	}
+	// more than one colon is an ipv6 address without port
+	if strings.Count(address, ":") == 1 {
+		return address[:strings.Index(address, ":")]
+	}
+	return address
+}
+
+//forwardedFor return the for= parameters of RFC 7239 Forwarded headers, leftmost hop first
+func forwardedFor(headers []string) []string {
+	hops := []string{}
+	for _, header := range headers {
+		for _, element := range strings.Split(header, ",") {
+			for _, pair := range strings.Split(element, ";") {
+				pair = strings.TrimSpace(pair)
+				if len(pair) > 4 && strings.EqualFold(pair[:4], "for=") {
+					hops = append(hops, pair[4:])
+				}
+			}
+		}
+	}
+	return hops
 }
 
-//LogRequest with info level every http request, unless production
-func ParseClientInfo(next http.Handler) http.Handler {
+//forwardedForHops return hops of forwardedHeader only, other forwarding headers are ignored as the proxies don't overwrite them
+func forwardedForHops(req *http.Request, forwardedHeader string) []string {
+	switch forwardedHeader {
+	case ForwardedHeader:
+		return forwardedFor(req.Header[ForwardedHeader])
+	case XRealIPHeader:
+		if xRealIP := req.Header.Get(XRealIPHeader); xRealIP != "" {
+			return []string{xRealIP}
+		}
+		return nil
+	default:
+		hops := []string{}
+		for _, header := range req.Header[XForwardedForHeader] {
+			hops = append(hops, strings.Split(header, ",")...)
+		}
+		return hops
+	}
+}
+
+//getClientIP return ip of the client, forwardedHeader is only believed when the request come through trusted proxies.
+//Hops are read right to left and the first one that is not a trusted proxy is the client, so values prepended by the client are ignored
+func getClientIP(req *http.Request, trustedProxies []*net.IPNet, forwardedHeader string) string {
+	clientIP := stripPort(req.RemoteAddr)
+	ip := net.ParseIP(clientIP)
+	if ip == nil || !isTrustedProxy(ip, trustedProxies) {
+		return clientIP
+	}
+
+	hops := forwardedForHops(req, forwardedHeader)
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := stripPort(hops[i])
+		ip := net.ParseIP(hop)
+		// obfuscated or unknown hop, the trusted proxy next to it is the best we know
+		if ip == nil {
+			return clientIP
+		}
+		clientIP = hop
+		if !isTrustedProxy(ip, trustedProxies) {
+			break
+		}
+	}
+
+	return clientIP
+}
+
+//ParseClientInfo put client id, name, ip and user agent of the request in its context,
+//ip is taken from the forwarded header only when the request come from one of trustedProxies
+func ParseClientInfo(trustedProxies []*net.IPNet, options ...func(*clientInfoOptions)) Middleware {
+	clientInfoOptions := &clientInfoOptions{forwardedHeader: XForwardedForHeader}
+	for _, option := range options {
+		option(clientInfoOptions)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			clientInfoMap := map[string]interface{}{
+				"client_id":       -1,
+				"client_name":     "unknown",
+				"client_verified": false,
+				"ip":              getClientIP(req, trustedProxies, clientInfoOptions.forwardedHeader),
+				"user_agent":      req.Header.Get("User-Agent"),
+			}
+
+			if clientInfoOptions.clientVerifier != nil {
+				client, err := verifyClient(req, clientInfoOptions.clientVerifier)
+				if err != nil {
+					// kept for RequireVerifiedClient to tell why the client is rejected
+					clientInfoMap["client_error"] = err.Error()
+				} else {
+					clientInfoMap["client_id"] = client.ID
+					clientInfoMap["client_name"] = client.Name
+					clientInfoMap["client_verified"] = true
+				}
+			} else if clientInfo := req.Header.Get("X-API-ClientID"); clientInfo != "" {
+				// unverified legacy header, assume clientInfo is in "<name>:<id>" format
+				clientInfoSplitted := strings.SplitN(clientInfo, ":", 2)
+				if len(clientInfoSplitted) == 2 {
+					if clientID, err := strconv.Atoi(clientInfoSplitted[1]); err == nil {
+						clientInfoMap["client_id"] = clientID
+						clientInfoMap["client_name"] = clientInfoSplitted[0]
+					}
+				}
+			}
+
+			req = req.WithContext(context.WithValue(req.Context(), contextkey.ClientInfo, map[string]interface{}(clientInfoMap)))
+			next.ServeHTTP(res, req)
+		})
+	}
+}
+
+//RequireVerifiedClient reject requests whose client wasn't verified by ParseClientInfo, it is applied to the route groups
+//serving api clients. CORS preflights carry no credentials and are let through
+func RequireVerifiedClient(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
-		clientInfoMap := map[string]interface{}{
-			"client_id":   -1,
-			"client_name": "unknown",
-			"ip":          getClientIP(req),
-			"user_agent":  req.Header.Get("User-Agent"),
-		}
-		clientInfo, clientInfoPresent := req.Header["X-API-ClientID"]
-		if clientInfoPresent {
-			// assume clientInfo is in "<name>:<id>" format
-			clientInfoSplitted := strings.Split(clientInfo[0], ":")
-			clientInfoMap["client_id"], _ = strconv.Atoi(clientInfoSplitted[1])
-			clientInfoMap["client_name"] = clientInfoSplitted[0]
-		}
-
-		// get IP address
-		req = req.WithContext(context.WithValue(req.Context(), contextkey.ClientInfo, map[string]interface{}(clientInfoMap)))
+		if req.Method == http.MethodOptions {
+			next.ServeHTTP(res, req)
+			return
+		}
+
+		clientInfo, _ := req.Context().Value(contextkey.ClientInfo).(map[string]interface{})
+		if verified, _ := clientInfo["client_verified"].(bool); !verified {
+			message, ok := clientInfo["client_error"].(string)
+			if !ok {
+				message = "Client is not verified"
+			}
+			render.JSON(res, http.StatusUnauthorized, map[string]interface{}{
+				"status": http.StatusUnauthorized,
+				"error": map[string]interface{}{
+					"code":    "ErrInvalidClient",
+					"message": message,
+				},
+			})
+			return
+		}
 		next.ServeHTTP(res, req)
 	})
 }
+
+func verifyClient(req *http.Request, verifier ClientVerifier) (userland.Client, error) {
+	credential := req.Header.Get("X-API-Client")
+	if credential == "" {
+		return userland.Client{}, errors.New("X-API-Client header is not present")
+	}
+
+	client, err := verifier.VerifyClient(req.Context(), credential, req.Header.Get("X-API-Client-Secret"), req.Header.Get("Origin"))
+	if err != nil {
+		logrus.WithError(err).WithField("x-request-id", req.Header.Get("X-Request-ID")).Warn("Unverified client")
+		return userland.Client{}, err
+	}
+	return client, nil
+}
@@ -0,0 +1,410 @@
+//+build unit
+
+package middlewares_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/AdhityaRamadhanus/userland"
+	"github.com/AdhityaRamadhanus/userland/pkg/common/contextkey"
+	"github.com/AdhityaRamadhanus/userland/pkg/common/http/middlewares"
+	"github.com/AdhityaRamadhanus/userland/pkg/mocks/service/client"
+	"github.com/pkg/errors"
+)
+
+func TestParseTrustedProxies(t *testing.T) {
+	testCases := []struct {
+		name    string
+		proxies []string
+		wantLen int
+		wantErr bool
+	}{
+		{
+			name:    "cidrs",
+			proxies: []string{"10.0.0.0/8", "2001:db8::/32"},
+			wantLen: 2,
+		},
+		{
+			name:    "single ips",
+			proxies: []string{"10.0.0.1", "::1", " "},
+			wantLen: 2,
+		},
+		{
+			name:    "invalid ip",
+			proxies: []string{"10.0.0.300"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid cidr",
+			proxies: []string{"10.0.0.0/33"},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			trustedProxies, err := middlewares.ParseTrustedProxies(tc.proxies)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("middlewares.ParseTrustedProxies(%v) err = %v; want err %t", tc.proxies, err, tc.wantErr)
+			}
+			if len(trustedProxies) != tc.wantLen {
+				t.Errorf("middlewares.ParseTrustedProxies(%v) len = %d; want %d", tc.proxies, len(trustedProxies), tc.wantLen)
+			}
+		})
+	}
+}
+
+func TestParseForwardedHeader(t *testing.T) {
+	testCases := []struct {
+		name       string
+		header     string
+		wantHeader string
+		wantErr    bool
+	}{
+		{
+			name:       "default",
+			header:     "",
+			wantHeader: middlewares.XForwardedForHeader,
+		},
+		{
+			name:       "canonicalized",
+			header:     "x-real-ip",
+			wantHeader: middlewares.XRealIPHeader,
+		},
+		{
+			name:       "forwarded",
+			header:     "Forwarded",
+			wantHeader: middlewares.ForwardedHeader,
+		},
+		{
+			name:    "unsupported",
+			header:  "X-Client-IP",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			header, err := middlewares.ParseForwardedHeader(tc.header)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("middlewares.ParseForwardedHeader(%q) err = %v; want err %t", tc.header, err, tc.wantErr)
+			}
+			if header != tc.wantHeader {
+				t.Errorf("middlewares.ParseForwardedHeader(%q) = %q; want %q", tc.header, header, tc.wantHeader)
+			}
+		})
+	}
+}
+
+func TestParseClientInfo_clientIP(t *testing.T) {
+	trustedProxies, err := middlewares.ParseTrustedProxies([]string{"10.0.0.0/8", "2001:db8::/32"})
+	if err != nil {
+		t.Fatalf("middlewares.ParseTrustedProxies() err = %v; want nil", err)
+	}
+
+	type args struct {
+		remoteAddr      string
+		forwardedHeader string
+		headers         map[string][]string
+	}
+	testCases := []struct {
+		name   string
+		args   args
+		wantIP string
+	}{
+		{
+			name: "direct client, port stripped",
+			args: args{
+				remoteAddr: "203.0.113.7:52100",
+			},
+			wantIP: "203.0.113.7",
+		},
+		{
+			name: "direct ipv6 client, port stripped",
+			args: args{
+				remoteAddr: "[2001:dead::7]:52100",
+			},
+			wantIP: "2001:dead::7",
+		},
+		{
+			name: "untrusted remote spoofing x-forwarded-for",
+			args: args{
+				remoteAddr: "203.0.113.7:52100",
+				headers:    map[string][]string{"X-Forwarded-For": {"1.1.1.1"}},
+			},
+			wantIP: "203.0.113.7",
+		},
+		{
+			name: "untrusted remote spoofing x-real-ip",
+			args: args{
+				remoteAddr: "203.0.113.7:52100",
+				headers:    map[string][]string{"X-Real-Ip": {"1.1.1.1"}},
+			},
+			wantIP: "203.0.113.7",
+		},
+		{
+			name: "trusted proxy with x-real-ip",
+			args: args{
+				remoteAddr:      "10.0.0.2:443",
+				forwardedHeader: middlewares.XRealIPHeader,
+				headers:         map[string][]string{"X-Real-Ip": {"198.51.100.1"}},
+			},
+			wantIP: "198.51.100.1",
+		},
+		{
+			name: "trusted proxy with x-forwarded-for",
+			args: args{
+				remoteAddr: "10.0.0.2:443",
+				headers:    map[string][]string{"X-Forwarded-For": {"198.51.100.1"}},
+			},
+			wantIP: "198.51.100.1",
+		},
+		{
+			name: "client prepending spoofed hop",
+			args: args{
+				remoteAddr: "10.0.0.2:443",
+				headers:    map[string][]string{"X-Forwarded-For": {"1.1.1.1, 198.51.100.1"}},
+			},
+			wantIP: "198.51.100.1",
+		},
+		{
+			name: "chain of trusted proxies",
+			args: args{
+				remoteAddr: "10.0.0.2:443",
+				headers:    map[string][]string{"X-Forwarded-For": {"1.1.1.1, 198.51.100.1, 10.0.0.5", "10.0.0.3"}},
+			},
+			wantIP: "198.51.100.1",
+		},
+		{
+			name: "every hop trusted",
+			args: args{
+				remoteAddr: "10.0.0.2:443",
+				headers:    map[string][]string{"X-Forwarded-For": {"10.0.0.4, 10.0.0.3"}},
+			},
+			wantIP: "10.0.0.4",
+		},
+		{
+			name: "x-forwarded-for hop with port",
+			args: args{
+				remoteAddr: "10.0.0.2:443",
+				headers:    map[string][]string{"X-Forwarded-For": {"198.51.100.1:8080"}},
+			},
+			wantIP: "198.51.100.1",
+		},
+		{
+			name: "garbage hop",
+			args: args{
+				remoteAddr: "10.0.0.2:443",
+				headers:    map[string][]string{"X-Forwarded-For": {"not-an-ip"}},
+			},
+			wantIP: "10.0.0.2",
+		},
+		{
+			name: "forwarded header",
+			args: args{
+				remoteAddr:      "10.0.0.2:443",
+				forwardedHeader: middlewares.ForwardedHeader,
+				headers:         map[string][]string{"Forwarded": {"for=198.51.100.1;proto=https;by=10.0.0.2"}},
+			},
+			wantIP: "198.51.100.1",
+		},
+		{
+			name: "forwarded header with quoted ipv6 and port",
+			args: args{
+				remoteAddr:      "[2001:db8::2]:443",
+				forwardedHeader: middlewares.ForwardedHeader,
+				headers:         map[string][]string{"Forwarded": {`for="[2001:dead::1]:4711", for=10.0.0.5`}},
+			},
+			wantIP: "2001:dead::1",
+		},
+		{
+			name: "spoofed forwarded header ignored when x-forwarded-for is trusted",
+			args: args{
+				remoteAddr: "10.0.0.2:443",
+				headers: map[string][]string{
+					"Forwarded":       {"For=1.1.1.1"},
+					"X-Forwarded-For": {"198.51.100.1"},
+				},
+			},
+			wantIP: "198.51.100.1",
+		},
+		{
+			name: "spoofed x-forwarded-for ignored when forwarded is trusted",
+			args: args{
+				remoteAddr:      "10.0.0.2:443",
+				forwardedHeader: middlewares.ForwardedHeader,
+				headers: map[string][]string{
+					"Forwarded":       {"For=198.51.100.1"},
+					"X-Forwarded-For": {"1.1.1.1"},
+				},
+			},
+			wantIP: "198.51.100.1",
+		},
+		{
+			name: "spoofed x-real-ip ignored when x-forwarded-for is trusted",
+			args: args{
+				remoteAddr: "10.0.0.2:443",
+				headers:    map[string][]string{"X-Real-Ip": {"1.1.1.1"}},
+			},
+			wantIP: "10.0.0.2",
+		},
+		{
+			name: "forwarded header with obfuscated hop",
+			args: args{
+				remoteAddr:      "10.0.0.2:443",
+				forwardedHeader: middlewares.ForwardedHeader,
+				headers:         map[string][]string{"Forwarded": {"for=_hidden, for=10.0.0.5"}},
+			},
+			wantIP: "10.0.0.5",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			gotIP := ""
+			forwardedHeader, err := middlewares.ParseForwardedHeader(tc.args.forwardedHeader)
+			if err != nil {
+				t.Fatalf("middlewares.ParseForwardedHeader(%q) err = %v; want nil", tc.args.forwardedHeader, err)
+			}
+			handler := middlewares.ParseClientInfo(trustedProxies, middlewares.WithForwardedHeader(forwardedHeader))(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+				clientInfo := req.Context().Value(contextkey.ClientInfo).(map[string]interface{})
+				gotIP = clientInfo["ip"].(string)
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tc.args.remoteAddr
+			for key, values := range tc.args.headers {
+				req.Header[key] = values
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			if gotIP != tc.wantIP {
+				t.Errorf("clientInfo[ip] = %q; want %q", gotIP, tc.wantIP)
+			}
+		})
+	}
+}
+
+func TestParseClientInfo_client(t *testing.T) {
+	webClient := userland.Client{ID: 7, Name: "web", Type: userland.ClientTypeWeb, Public: true}
+	clientService := client.ClientService{}
+	clientService.On("VerifyClient", "7.signature", "", "https://userland.io").Return(webClient, nil)
+	clientService.On("VerifyClient", "7.forged", "", "").Return(userland.Client{}, errors.New("Client credential is invalid"))
+
+	testCases := []struct {
+		name           string
+		verify         bool
+		rejectUnknown  bool
+		method         string
+		headers        map[string]string
+		wantStatusCode int
+		wantClientID   int
+		wantClientName string
+	}{
+		{
+			name:           "legacy header without verifier",
+			headers:        map[string]string{"X-API-ClientID": "web:7"},
+			wantStatusCode: http.StatusOK,
+			wantClientID:   7,
+			wantClientName: "web",
+		},
+		{
+			name:           "legacy header missing colon",
+			headers:        map[string]string{"X-API-ClientID": "web"},
+			wantStatusCode: http.StatusOK,
+			wantClientID:   -1,
+			wantClientName: "unknown",
+		},
+		{
+			name:           "legacy header ignored by verifier",
+			verify:         true,
+			headers:        map[string]string{"X-API-ClientID": "web:7"},
+			wantStatusCode: http.StatusOK,
+			wantClientID:   -1,
+			wantClientName: "unknown",
+		},
+		{
+			name:           "verified client",
+			verify:         true,
+			headers:        map[string]string{"X-API-Client": "7.signature", "Origin": "https://userland.io"},
+			wantStatusCode: http.StatusOK,
+			wantClientID:   7,
+			wantClientName: "web",
+		},
+		{
+			name:           "forged credential marked unknown",
+			verify:         true,
+			headers:        map[string]string{"X-API-Client": "7.forged"},
+			wantStatusCode: http.StatusOK,
+			wantClientID:   -1,
+			wantClientName: "unknown",
+		},
+		{
+			name:           "forged credential rejected",
+			verify:         true,
+			rejectUnknown:  true,
+			headers:        map[string]string{"X-API-Client": "7.forged"},
+			wantStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:           "missing credential rejected",
+			verify:         true,
+			rejectUnknown:  true,
+			wantStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:           "unverified legacy header rejected",
+			rejectUnknown:  true,
+			headers:        map[string]string{"X-API-ClientID": "web:7"},
+			wantStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:           "preflight without credential let through",
+			verify:         true,
+			rejectUnknown:  true,
+			method:         http.MethodOptions,
+			wantStatusCode: http.StatusOK,
+			wantClientID:   -1,
+			wantClientName: "unknown",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var clientInfo map[string]interface{}
+			var handler http.Handler = http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+				clientInfo = req.Context().Value(contextkey.ClientInfo).(map[string]interface{})
+			})
+			clientParser := middlewares.ParseClientInfo(nil)
+			if tc.verify {
+				clientParser = middlewares.ParseClientInfo(nil, middlewares.WithClientVerifier(&clientService))
+			}
+			if tc.rejectUnknown {
+				handler = middlewares.RequireVerifiedClient(handler)
+			}
+
+			method := tc.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			req := httptest.NewRequest(method, "/", nil)
+			for key, value := range tc.headers {
+				req.Header.Set(key, value)
+			}
+			res := httptest.NewRecorder()
+			clientParser(handler).ServeHTTP(res, req)
+
+			if res.Code != tc.wantStatusCode {
+				t.Fatalf("res.Code = %d; want %d", res.Code, tc.wantStatusCode)
+			}
+			if tc.wantStatusCode != http.StatusOK {
+				return
+			}
+			if clientInfo["client_id"] != tc.wantClientID || clientInfo["client_name"] != tc.wantClientName {
+				t.Errorf("clientInfo = %v; want client_id %d and client_name %q", clientInfo, tc.wantClientID, tc.wantClientName)
+			}
+		})
+	}
+}
@@ -20,6 +20,13 @@ func (lrw *logMetricResponseWriter) WriteHeader(code int) {
 	lrw.ResponseWriter.WriteHeader(code)
 }
 
+//Flush let streaming handlers flush through the wrapped writer
+func (lrw *logMetricResponseWriter) Flush() {
+	if flusher, ok := lrw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
 var (
 	LogMetricKeys = []string{"method", "route", "status_code"}
 )
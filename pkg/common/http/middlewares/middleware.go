@@ -1,6 +1,10 @@
 package middlewares
 
-import "net/http"
+import (
+	"net/http"
+	"time"
+)
 
 type Middleware func(next http.Handler) http.Handler
 type MiddlewareWithArgs func(next http.Handler, args ...interface{}) http.Handler
+type MiddlewareWithMaxAge func(next http.Handler, maxAge time.Duration) http.Handler
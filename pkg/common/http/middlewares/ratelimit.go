@@ -1,59 +1,159 @@
 package middlewares
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/AdhityaRamadhanus/userland"
 	"github.com/AdhityaRamadhanus/userland/pkg/common/contextkey"
-	redis "github.com/go-redis/redis"
-	ratelimiter "github.com/teambition/ratelimiter-go"
+	"github.com/AdhityaRamadhanus/userland/pkg/common/http/render"
+	"github.com/AdhityaRamadhanus/userland/pkg/common/keygenerator"
+	"github.com/AdhityaRamadhanus/userland/pkg/common/metrics"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
 )
 
-type redisRateLimitClient struct {
-	*redis.Client
-}
+const (
+	RateLimitKeyIP     = "ip"
+	RateLimitKeyUser   = "user"
+	RateLimitKeyClient = "client"
+	RateLimitKeyEmail  = "email"
+	// RateLimitKeyEmailIP limit an email per ip, so requests from elsewhere can't use up the limit of the account owner
+	RateLimitKeyEmailIP = "email_ip"
+)
 
-func (c *redisRateLimitClient) RateDel(key string) error {
-	return c.Del(key).Err()
-}
-func (c *redisRateLimitClient) RateEvalSha(sha1 string, keys []string, args ...interface{}) (interface{}, error) {
-	return c.EvalSha(sha1, keys, args...).Result()
-}
-func (c *redisRateLimitClient) RateScriptLoad(script string) (string, error) {
-	return c.ScriptLoad(script).Result()
+var throttledRequests = metrics.PrometheusThrottledRequests("api", []string{"route", "key"})
+
+//RateLimitPolicy limit requests to a route per Key, one of ip, user, client, email or email_ip
+type RateLimitPolicy struct {
+	Key string
+	userland.RateLimit
 }
 
-func RateLimit(redisClient *redis.Client) MiddlewareWithArgs {
-	return func(next http.Handler, args ...interface{}) http.Handler {
-		limiter := ratelimiter.New(ratelimiter.Options{
-			Max:      args[0].(int),
-			Duration: args[1].(time.Duration), // limit to 1000 requests in 1 minute.
-			Client:   &redisRateLimitClient{redisClient},
-		})
-		return (http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
-			clientInfo := req.Context().Value(contextkey.ClientInfo).(map[string]interface{})
-			ctx, err := limiter.Get(fmt.Sprintf("%s:%s", req.URL.Path, clientInfo["ip"].(string)))
-			if err != nil {
-				http.Error(res, err.Error(), 500)
-				return
+//RateLimiter return the middleware enforcing the rate limit policy of the named route
+type RateLimiter func(route string) Middleware
+
+//RateLimit build a RateLimiter from policies by route name, routes without a policy are not limited.
+//Requests whose key can't be resolved (no access token, unknown client or no email in the body) are limited by ip
+func RateLimit(rateLimitService userland.RateLimitService, policies map[string]RateLimitPolicy) (RateLimiter, error) {
+	for route, policy := range policies {
+		switch policy.Key {
+		case RateLimitKeyIP, RateLimitKeyUser, RateLimitKeyClient, RateLimitKeyEmail, RateLimitKeyEmailIP:
+		default:
+			return nil, errors.Errorf("unknown rate limit key %q of route %q", policy.Key, route)
+		}
+		if policy.Limit <= 0 || policy.Window <= 0 {
+			return nil, errors.Errorf("rate limit of route %q need a positive limit and window", route)
+		}
+	}
+
+	return func(route string) Middleware {
+		policy, ok := policies[route]
+		if !ok {
+			return func(next http.Handler) http.Handler {
+				return next
 			}
+		}
+
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+				strategy, value := rateLimitKey(req, policy.Key)
+				result, err := rateLimitService.Allow(req.Context(), keygenerator.RateLimitKey(route, strategy, value), policy.RateLimit)
+				if err != nil {
+					// a broken limiter shouldn't take the api down with it
+					logrus.WithError(err).WithField("route", route).Error("Failed to count request against rate limit")
+					next.ServeHTTP(res, req)
+					return
+				}
+
+				burst := policy.Burst
+				if burst <= 0 {
+					burst = policy.Limit
+				}
+				header := res.Header()
+				header.Set("X-Ratelimit-Limit", strconv.Itoa(burst))
+				header.Set("X-Ratelimit-Remaining", strconv.Itoa(result.Remaining))
+				header.Set("X-Ratelimit-Reset", strconv.FormatInt(time.Now().Add(result.ResetAfter).Unix(), 10))
+
+				if !result.Allowed {
+					retryAfter := int64(math.Ceil(result.RetryAfter.Seconds()))
+					header.Set("Retry-After", strconv.FormatInt(retryAfter, 10))
+					throttledRequests.With("route", route, "key", strategy).Add(1)
+					render.JSON(res, http.StatusTooManyRequests, map[string]interface{}{
+						"status": http.StatusTooManyRequests,
+						"error": map[string]interface{}{
+							"code":    "ErrRateLimitExceeded",
+							"message": fmt.Sprintf("Rate limit exceeded, retry in %d seconds", retryAfter),
+						},
+					})
+					return
+				}
 
-			header := res.Header()
-			header.Set("X-Ratelimit-Limit", strconv.FormatInt(int64(ctx.Total), 10))
-			header.Set("X-Ratelimit-Remaining", strconv.FormatInt(int64(ctx.Remaining), 10))
-			header.Set("X-Ratelimit-Reset", strconv.FormatInt(ctx.Reset.Unix(), 10))
-
-			if ctx.Remaining < 0 {
-				after := int64(ctx.Reset.Sub(time.Now())) / 1e9
-				header.Set("Retry-After", strconv.FormatInt(after, 10))
-				res.WriteHeader(429)
-				fmt.Fprintf(res, "Rate limit exceeded, retry in %d seconds.\n", after)
-				return
+				next.ServeHTTP(res, req)
+			})
+		}
+	}, nil
+}
+
+//rateLimitKey return strategy actually used and value identifying the request under it
+func rateLimitKey(req *http.Request, strategy string) (string, string) {
+	switch strategy {
+	case RateLimitKeyUser:
+		if accessToken, ok := req.Context().Value(contextkey.AccessToken).(map[string]interface{}); ok {
+			if userID, ok := accessToken["userid"].(float64); ok {
+				return RateLimitKeyUser, strconv.Itoa(int(userID))
+			}
+		}
+	case RateLimitKeyClient:
+		if clientInfo, ok := req.Context().Value(contextkey.ClientInfo).(map[string]interface{}); ok {
+			if clientID, ok := clientInfo["client_id"].(int); ok && clientID != -1 {
+				return RateLimitKeyClient, strconv.Itoa(clientID)
 			}
+		}
+	case RateLimitKeyEmail:
+		if email := peekEmail(req); email != "" {
+			return RateLimitKeyEmail, email
+		}
+	}
+
+	ip := ""
+	if clientInfo, ok := req.Context().Value(contextkey.ClientInfo).(map[string]interface{}); ok {
+		ip, _ = clientInfo["ip"].(string)
+	}
+	if strategy == RateLimitKeyEmailIP {
+		if email := peekEmail(req); email != "" {
+			return RateLimitKeyEmailIP, fmt.Sprintf("%s:%s", email, ip)
+		}
+	}
+	return RateLimitKeyIP, ip
+}
+
+//peekEmail read the email field of a json body and put the body back for the next handler
+func peekEmail(req *http.Request) string {
+	if req.Body == nil {
+		return ""
+	}
+	// Read Body, limit to 1 MB //
+	body, err := ioutil.ReadAll(io.LimitReader(req.Body, 1048576))
+	req.Body.Close()
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return ""
+	}
 
-			next.ServeHTTP(res, req)
-		}))
+	emailRequest := struct {
+		Email string `json:"email"`
+	}{}
+	if err := json.Unmarshal(body, &emailRequest); err != nil {
+		return ""
 	}
+	return strings.ToLower(strings.TrimSpace(emailRequest.Email))
 }
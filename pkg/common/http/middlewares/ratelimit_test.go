@@ -0,0 +1,191 @@
+//+build unit
+
+package middlewares_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AdhityaRamadhanus/userland"
+	"github.com/AdhityaRamadhanus/userland/pkg/common/contextkey"
+	"github.com/AdhityaRamadhanus/userland/pkg/common/http/middlewares"
+)
+
+type fakeRateLimitService struct {
+	counts map[string]int
+}
+
+func (f *fakeRateLimitService) Allow(ctx context.Context, key string, rateLimit userland.RateLimit) (userland.RateLimitResult, error) {
+	f.counts[key]++
+	if f.counts[key] > rateLimit.Burst {
+		return userland.RateLimitResult{RetryAfter: 1500 * time.Millisecond, ResetAfter: rateLimit.Window}, nil
+	}
+	return userland.RateLimitResult{Allowed: true, Remaining: rateLimit.Burst - f.counts[key], ResetAfter: rateLimit.Window}, nil
+}
+
+func TestRateLimit_invalidPolicy(t *testing.T) {
+	testCases := []struct {
+		name   string
+		policy middlewares.RateLimitPolicy
+	}{
+		{
+			name:   "unknown key",
+			policy: middlewares.RateLimitPolicy{Key: "session", RateLimit: userland.RateLimit{Limit: 10, Window: time.Minute}},
+		},
+		{
+			name:   "no window",
+			policy: middlewares.RateLimitPolicy{Key: middlewares.RateLimitKeyIP, RateLimit: userland.RateLimit{Limit: 10}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := middlewares.RateLimit(&fakeRateLimitService{}, map[string]middlewares.RateLimitPolicy{"login": tc.policy})
+			if err == nil {
+				t.Errorf("middlewares.RateLimit() err = nil; want err")
+			}
+		})
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	type request struct {
+		body        string
+		ip          string
+		accessToken map[string]interface{}
+	}
+	testCases := []struct {
+		name             string
+		route            string
+		key              string
+		requests         []request
+		wantStatusCodes  []int
+		wantRateLimitKey string
+	}{
+		{
+			name:            "route without policy",
+			route:           "register",
+			key:             middlewares.RateLimitKeyIP,
+			requests:        []request{{}, {}, {}},
+			wantStatusCodes: []int{http.StatusOK, http.StatusOK, http.StatusOK},
+		},
+		{
+			name:             "by ip",
+			route:            "login",
+			key:              middlewares.RateLimitKeyIP,
+			requests:         []request{{}, {}, {}},
+			wantStatusCodes:  []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests},
+			wantRateLimitKey: "rate-limit:login:ip:127.0.0.1",
+		},
+		{
+			name:             "by email",
+			route:            "login",
+			key:              middlewares.RateLimitKeyEmail,
+			requests:         []request{{body: `{"email":"john@example.com"}`}, {body: `{"email":" John@Example.com"}`}, {body: `{"email":"jane@example.com"}`}},
+			wantStatusCodes:  []int{http.StatusOK, http.StatusOK, http.StatusOK},
+			wantRateLimitKey: "rate-limit:login:email:john@example.com",
+		},
+		{
+			name:             "by email without email",
+			route:            "login",
+			key:              middlewares.RateLimitKeyEmail,
+			requests:         []request{{body: `{}`}},
+			wantStatusCodes:  []int{http.StatusOK},
+			wantRateLimitKey: "rate-limit:login:ip:127.0.0.1",
+		},
+		{
+			name:             "by email per ip",
+			route:            "login",
+			key:              middlewares.RateLimitKeyEmailIP,
+			requests:         []request{{body: `{"email":"john@example.com"}`}, {body: `{"email":" John@Example.com"}`}, {body: `{"email":"john@example.com"}`}, {body: `{"email":"john@example.com"}`, ip: "10.0.0.1"}},
+			wantStatusCodes:  []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests, http.StatusOK},
+			wantRateLimitKey: "rate-limit:login:email_ip:john@example.com:127.0.0.1",
+		},
+		{
+			name:             "by email per ip without email",
+			route:            "login",
+			key:              middlewares.RateLimitKeyEmailIP,
+			requests:         []request{{body: `{}`}},
+			wantStatusCodes:  []int{http.StatusOK},
+			wantRateLimitKey: "rate-limit:login:ip:127.0.0.1",
+		},
+		{
+			name:             "by user",
+			route:            "login",
+			key:              middlewares.RateLimitKeyUser,
+			requests:         []request{{accessToken: map[string]interface{}{"userid": float64(1)}}, {accessToken: map[string]interface{}{"userid": float64(2)}}},
+			wantStatusCodes:  []int{http.StatusOK, http.StatusOK},
+			wantRateLimitKey: "rate-limit:login:user:1",
+		},
+		{
+			name:             "by user without access token",
+			route:            "login",
+			key:              middlewares.RateLimitKeyUser,
+			requests:         []request{{}},
+			wantStatusCodes:  []int{http.StatusOK},
+			wantRateLimitKey: "rate-limit:login:ip:127.0.0.1",
+		},
+		{
+			name:             "by client",
+			route:            "login",
+			key:              middlewares.RateLimitKeyClient,
+			requests:         []request{{}, {}, {}},
+			wantStatusCodes:  []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests},
+			wantRateLimitKey: "rate-limit:login:client:7",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rateLimitService := &fakeRateLimitService{counts: map[string]int{}}
+			rateLimiter, err := middlewares.RateLimit(rateLimitService, map[string]middlewares.RateLimitPolicy{
+				"login": {Key: tc.key, RateLimit: userland.RateLimit{Limit: 10, Burst: 2, Window: time.Minute}},
+			})
+			if err != nil {
+				t.Fatalf("middlewares.RateLimit() err = %v; want nil", err)
+			}
+
+			for i, r := range tc.requests {
+				gotBody := ""
+				handler := rateLimiter(tc.route)(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+					body, _ := ioutil.ReadAll(req.Body)
+					gotBody = string(body)
+				}))
+
+				req := httptest.NewRequest(http.MethodPost, "/api/auth/login", strings.NewReader(r.body))
+				ip := r.ip
+				if ip == "" {
+					ip = "127.0.0.1"
+				}
+				ctx := context.WithValue(req.Context(), contextkey.ClientInfo, map[string]interface{}{"ip": ip, "client_id": 7})
+				if r.accessToken != nil {
+					ctx = context.WithValue(ctx, contextkey.AccessToken, r.accessToken)
+				}
+				res := httptest.NewRecorder()
+				handler.ServeHTTP(res, req.WithContext(ctx))
+
+				if res.Code != tc.wantStatusCodes[i] {
+					t.Fatalf("request %d res.Code = %d; want %d", i, res.Code, tc.wantStatusCodes[i])
+				}
+				if res.Code == http.StatusTooManyRequests && res.Header().Get("Retry-After") != "2" {
+					t.Errorf("Retry-After = %q; want %q", res.Header().Get("Retry-After"), "2")
+				}
+				if res.Code == http.StatusOK && gotBody != r.body {
+					t.Errorf("next handler body = %q; want %q", gotBody, r.body)
+				}
+			}
+
+			if tc.wantRateLimitKey != "" && rateLimitService.counts[tc.wantRateLimitKey] == 0 {
+				t.Errorf("rate limit keys = %v; want %q counted", rateLimitService.counts, tc.wantRateLimitKey)
+			}
+			if tc.wantRateLimitKey == "" && len(rateLimitService.counts) > 0 {
+				t.Errorf("rate limit keys = %v; want none counted", rateLimitService.counts)
+			}
+		})
+	}
+}
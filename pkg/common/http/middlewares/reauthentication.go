@@ -0,0 +1,31 @@
+package middlewares
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/AdhityaRamadhanus/userland/pkg/common/contextkey"
+	"github.com/AdhityaRamadhanus/userland/pkg/common/http/render"
+	"github.com/AdhityaRamadhanus/userland/pkg/common/security"
+)
+
+//RequireRecentAuthentication reject token whose user authenticated longer than max age ago, client should reauthenticate to get a fresh token
+func RequireRecentAuthentication(next http.Handler, maxAge time.Duration) http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		accessToken := req.Context().Value(contextkey.AccessToken).(map[string]interface{})
+
+		authentication := security.AuthenticationFromClaims(accessToken)
+		if time.Since(authentication.Time) > maxAge {
+			render.JSON(res, http.StatusUnauthorized, map[string]interface{}{
+				"status": http.StatusUnauthorized,
+				"error": map[string]interface{}{
+					"code":    "ErrReauthenticationRequired",
+					"message": "Recent authentication is required, reauthenticate and retry with the new token",
+				},
+			})
+			return
+		}
+
+		next.ServeHTTP(res, req)
+	})
+}
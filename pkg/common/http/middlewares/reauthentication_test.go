@@ -0,0 +1,104 @@
+//+build unit
+
+package middlewares_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/AdhityaRamadhanus/userland"
+	"github.com/AdhityaRamadhanus/userland/pkg/common/contextkey"
+	"github.com/AdhityaRamadhanus/userland/pkg/common/http/middlewares"
+	"github.com/AdhityaRamadhanus/userland/pkg/common/security"
+	jwt "github.com/dgrijalva/jwt-go"
+)
+
+func TestRequireRecentAuthentication(t *testing.T) {
+	user := userland.User{
+		Fullname: "Adhitya Ramadhanus",
+		Email:    "adhitya.ramadhanus@gmail.com",
+		ID:       1,
+	}
+	jwtSecret := "jwtsecret_test"
+	freshAccessToken, _ := security.CreateAccessToken(user, jwtSecret, security.AccessTokenOptions{
+		Expiration: security.UserAccessTokenExpiration,
+		Scope:      security.UserTokenScope,
+		Authentication: security.Authentication{
+			Methods: []string{security.PasswordAuthenticationMethod},
+		},
+	})
+	staleAccessToken, _ := security.CreateAccessToken(user, jwtSecret, security.AccessTokenOptions{
+		Expiration: security.UserAccessTokenExpiration,
+		Scope:      security.UserTokenScope,
+		Authentication: security.Authentication{
+			Time:    time.Now().Add(-time.Hour),
+			Methods: []string{security.PasswordAuthenticationMethod},
+		},
+	})
+
+	type args struct {
+		accessToken security.AccessToken
+	}
+	testCases := []struct {
+		name           string
+		args           args
+		wantStatusCode int
+	}{
+		{
+			name: "success",
+			args: args{
+				accessToken: freshAccessToken,
+			},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name: "reauthentication required",
+			args: args{
+				accessToken: staleAccessToken,
+			},
+			wantStatusCode: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			jwtToken, err := jwt.Parse(tc.args.accessToken.Value, func(token *jwt.Token) (interface{}, error) {
+				return []byte(jwtSecret), nil
+			})
+			if err != nil {
+				t.Fatalf("jwt.Parse() err = %v; want nil", err)
+			}
+
+			claims, ok := jwtToken.Claims.(jwt.MapClaims)
+			if !ok {
+				t.Fatalf("failed to assert jwtToken")
+			}
+
+			req, err := http.NewRequest(http.MethodGet, "/", nil)
+			if err != nil {
+				t.Fatalf("http.NewRequest() err = %v; want nil", err)
+			}
+			req = req.WithContext(context.WithValue(req.Context(), contextkey.AccessToken, map[string]interface{}(claims)))
+			res := httptest.NewRecorder()
+
+			handler := func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte("OK"))
+			}
+			mw := middlewares.RequireRecentAuthentication(http.HandlerFunc(handler), security.ReauthenticationMaxAge)
+
+			mw.ServeHTTP(res, req)
+			defer res.Result().Body.Close()
+			statusCode := res.Result().StatusCode
+			if statusCode != tc.wantStatusCode {
+				body, _ := ioutil.ReadAll(res.Result().Body)
+				t.Logf("response %s\n", string(body))
+				t.Errorf("middlewares.RequireRecentAuthentication() res.StatusCode = %d; want %d", statusCode, tc.wantStatusCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,20 @@
+package middlewares
+
+import (
+	"net/http"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+//ClearWriteDeadline lift the server write timeout for long lived responses like server-sent events,
+//it has to wrap the writer given by net/http, before any middleware replace it
+func ClearWriteDeadline(nextHandler http.Handler) http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		if err := http.NewResponseController(res).SetWriteDeadline(time.Time{}); err != nil {
+			log.WithError(err).Warn("Failed to clear write deadline")
+		}
+
+		nextHandler.ServeHTTP(res, req)
+	})
+}
@@ -0,0 +1,61 @@
+//+build unit
+
+package middlewares_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/AdhityaRamadhanus/userland/pkg/common/http/middlewares"
+)
+
+func TestClearWriteDeadline(t *testing.T) {
+	writeTimeout := 50 * time.Millisecond
+	// the handler write past the server write timeout
+	slowHandler := http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.WriteHeader(http.StatusOK)
+		res.(http.Flusher).Flush()
+		time.Sleep(4 * writeTimeout)
+		fmt.Fprint(res, "done")
+	})
+
+	testCases := []struct {
+		name     string
+		handler  http.Handler
+		wantBody string
+	}{
+		{
+			name:     "cut by write timeout",
+			handler:  slowHandler,
+			wantBody: "",
+		},
+		{
+			name:     "write deadline cleared",
+			handler:  middlewares.ClearWriteDeadline(slowHandler),
+			wantBody: "done",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ts := httptest.NewUnstartedServer(tc.handler)
+			ts.Config.WriteTimeout = writeTimeout
+			ts.Start()
+			defer ts.Close()
+
+			res, err := http.Get(ts.URL)
+			if err != nil {
+				t.Fatalf("http.Get() err = %v; want nil", err)
+			}
+			defer res.Body.Close()
+			body, _ := ioutil.ReadAll(res.Body)
+			if string(body) != tc.wantBody {
+				t.Errorf("body = %q; want %q", string(body), tc.wantBody)
+			}
+		})
+	}
+}
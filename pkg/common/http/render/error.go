@@ -37,6 +37,16 @@ func FailedToUnmarshalJSONError(res http.ResponseWriter, err error) error {
 	})
 }
 
+func PreconditionFailedError(res http.ResponseWriter, err error) error {
+	return JSON(res, http.StatusPreconditionFailed, map[string]interface{}{
+		"status": http.StatusPreconditionFailed,
+		"error": map[string]interface{}{
+			"code":    "ErrPreconditionFailed",
+			"message": err.Error(),
+		},
+	})
+}
+
 func InternalServerError(res http.ResponseWriter, err error) error {
 	return JSON(res, http.StatusInternalServerError, map[string]interface{}{
 		"status": http.StatusInternalServerError,
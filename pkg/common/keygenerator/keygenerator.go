@@ -27,3 +27,67 @@ func SessionListKey(userID int) string {
 func ForgotPasswordKey(uuid string) string {
 	return fmt.Sprintf("%s:%s", "forgot-password-token", uuid)
 }
+
+func SAMLAssertionKey(tenant string, assertionID string) string {
+	return fmt.Sprintf("saml-assertion:%s:%s", tenant, assertionID)
+}
+
+func TrustedDeviceListKey(userID int) string {
+	return fmt.Sprintf("trusted-devices:%d", userID)
+}
+
+func ReauthenticationKey(userID int, sessionID string) string {
+	return fmt.Sprintf("reauthentication:%d:%s", userID, sessionID)
+}
+
+func SessionActivityKey(sessionID string) string {
+	return fmt.Sprintf("session-activity:%s", sessionID)
+}
+
+func SessionRefreshTokenKey(sessionID string) string {
+	return fmt.Sprintf("session-refresh-token:%s", sessionID)
+}
+
+func SessionRevocationChannel() string {
+	return "session-revocations"
+}
+
+func SessionRevocationLogKey() string {
+	return "session-revocations:log"
+}
+
+func SignInDenialKey(token string) string {
+	return fmt.Sprintf("signin-denial:%s", token)
+}
+
+func PendingSignInAlertKey(tfaToken string) string {
+	return fmt.Sprintf("pending-signin-alert:%s", tfaToken)
+}
+
+func LoginFailureKey(userID int) string {
+	return fmt.Sprintf("login-failures:%d", userID)
+}
+
+func RateLimitKey(route string, strategy string, value string) string {
+	return fmt.Sprintf("rate-limit:%s:%s:%s", route, strategy, value)
+}
+
+func AccountRestoreKey(token string) string {
+	return fmt.Sprintf("account-restore:%s", token)
+}
+
+func DataExportKey(token string) string {
+	return fmt.Sprintf("data-export:%s", token)
+}
+
+func SessionLimitLockKey(userID int) string {
+	return fmt.Sprintf("session-limit-lock:%d", userID)
+}
+
+func ClientKey(clientID int) string {
+	return fmt.Sprintf("client:%d", clientID)
+}
+
+func TOTPUsedKey(userID int, code string) string {
+	return fmt.Sprintf("totp-used:%d:%s", userID, code)
+}
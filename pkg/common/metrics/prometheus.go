@@ -16,3 +16,13 @@ func PrometheusRequestLatency(namespace, service string, fieldKeys []string) met
 	}, fieldKeys)
 
 }
+
+// return counter
+func PrometheusThrottledRequests(namespace string, fieldKeys []string) metrics.Counter {
+	return kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "rate_limit",
+		Name:      "throttled_requests_total",
+		Help:      "Total requests rejected by rate limit policies.",
+	}, fieldKeys)
+}
@@ -0,0 +1,233 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	//ErrDirty represent a database where a migration failed halfway, it has to be fixed by hand and forced to a version
+	ErrDirty = errors.New("Database is dirty, fix it and force a version")
+	//ErrUnknownVersion represent a version that has no migration
+	ErrUnknownVersion = errors.New("Unknown migration version")
+
+	fileNamePattern = regexp.MustCompile(`^([0-9]+)_(.+)\.(up|down)\.sql$`)
+)
+
+//Migration is one schema change read from <version>_<name>.up.sql and <version>_<name>.down.sql
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+//String return the file name prefix of migration
+func (migration Migration) String() string {
+	return fmt.Sprintf("%06d_%s", migration.Version, migration.Name)
+}
+
+//Parse build migrations sorted by version from the content of sql files keyed by file name
+func Parse(files map[string]string) ([]Migration, error) {
+	migrationsByVersion := map[int]*Migration{}
+	for fileName, query := range files {
+		match := fileNamePattern.FindStringSubmatch(fileName)
+		if match == nil {
+			return nil, errors.Errorf("migration file %q is not <version>_<name>.<up|down>.sql", fileName)
+		}
+
+		version, err := strconv.Atoi(match[1])
+		if err != nil || version == 0 {
+			return nil, errors.Errorf("migration file %q has an invalid version", fileName)
+		}
+		migration, ok := migrationsByVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			migrationsByVersion[version] = migration
+		}
+		if migration.Name != match[2] {
+			return nil, errors.Errorf("migration version %d is used by %q and %q", version, migration.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			migration.Up = query
+		} else {
+			migration.Down = query
+		}
+	}
+
+	migrations := []Migration{}
+	for _, migration := range migrationsByVersion {
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+//Driver apply migrations to a database, every method except Lock is called while holding the lock
+type Driver interface {
+	// Lock block until no other migrator run against the database
+	Lock(ctx context.Context) error
+	Unlock(ctx context.Context) error
+	// Version return 0 when no migration is applied
+	Version(ctx context.Context) (version int, dirty bool, err error)
+	SetVersion(ctx context.Context, version int, dirty bool) error
+	Exec(ctx context.Context, query string) error
+}
+
+//Status is the state of a database against the known migrations
+type Status struct {
+	Version int
+	Dirty   bool
+	Pending []Migration
+}
+
+//Migrator run migrations, a database is marked dirty before a migration and clean after it succeed
+type Migrator struct {
+	driver     Driver
+	migrations []Migration
+}
+
+//NewMigrator is constructor to create Migrator, migrations must be sorted by version
+func NewMigrator(driver Driver, migrations []Migration) *Migrator {
+	return &Migrator{
+		driver:     driver,
+		migrations: migrations,
+	}
+}
+
+//withLock run fn while holding the driver lock
+func (m Migrator) withLock(ctx context.Context, fn func() error) (err error) {
+	if err := m.driver.Lock(ctx); err != nil {
+		return errors.Wrap(err, "driver.Lock() err")
+	}
+	defer func() {
+		if unlockErr := m.driver.Unlock(ctx); unlockErr != nil && err == nil {
+			err = errors.Wrap(unlockErr, "driver.Unlock() err")
+		}
+	}()
+
+	return fn()
+}
+
+//cleanVersion return the current version, failing when the database is dirty
+func (m Migrator) cleanVersion(ctx context.Context) (int, error) {
+	version, dirty, err := m.driver.Version(ctx)
+	if err != nil {
+		return 0, errors.Wrap(err, "driver.Version() err")
+	}
+	if dirty {
+		return 0, errors.Wrapf(ErrDirty, "version %d", version)
+	}
+	return version, nil
+}
+
+//index return the position of version in migrations, version 0 is before the first migration
+func (m Migrator) index(version int) (int, error) {
+	if version == 0 {
+		return -1, nil
+	}
+	for i, migration := range m.migrations {
+		if migration.Version == version {
+			return i, nil
+		}
+	}
+	return 0, errors.Wrapf(ErrUnknownVersion, "version %d", version)
+}
+
+func (m Migrator) run(ctx context.Context, migration Migration, query string, version int) error {
+	if err := m.driver.SetVersion(ctx, version, true); err != nil {
+		return errors.Wrap(err, "driver.SetVersion() err")
+	}
+	if err := m.driver.Exec(ctx, query); err != nil {
+		return errors.Wrapf(err, "migration %s err", migration)
+	}
+	if err := m.driver.SetVersion(ctx, version, false); err != nil {
+		return errors.Wrap(err, "driver.SetVersion() err")
+	}
+	return nil
+}
+
+//Up apply every pending migration in order and return them
+func (m Migrator) Up(ctx context.Context) (applied []Migration, err error) {
+	err = m.withLock(ctx, func() error {
+		version, err := m.cleanVersion(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if migration.Version <= version {
+				continue
+			}
+			if err := m.run(ctx, migration, migration.Up, migration.Version); err != nil {
+				return err
+			}
+			applied = append(applied, migration)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+//Down revert the n latest applied migrations, it stop early when no migration is left
+func (m Migrator) Down(ctx context.Context, n int) (reverted []Migration, err error) {
+	err = m.withLock(ctx, func() error {
+		version, err := m.cleanVersion(ctx)
+		if err != nil {
+			return err
+		}
+		i, err := m.index(version)
+		if err != nil {
+			return err
+		}
+
+		for ; i >= 0 && len(reverted) < n; i-- {
+			previousVersion := 0
+			if i > 0 {
+				previousVersion = m.migrations[i-1].Version
+			}
+			if err := m.run(ctx, m.migrations[i], m.migrations[i].Down, previousVersion); err != nil {
+				return err
+			}
+			reverted = append(reverted, m.migrations[i])
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+//Status return the current version and the migrations not applied yet
+func (m Migrator) Status(ctx context.Context) (status Status, err error) {
+	err = m.withLock(ctx, func() error {
+		version, dirty, err := m.driver.Version(ctx)
+		if err != nil {
+			return errors.Wrap(err, "driver.Version() err")
+		}
+
+		status = Status{Version: version, Dirty: dirty, Pending: []Migration{}}
+		for _, migration := range m.migrations {
+			if migration.Version > version {
+				status.Pending = append(status.Pending, migration)
+			}
+		}
+		return nil
+	})
+	return status, err
+}
+
+//Force set the version and clear the dirty flag without running any migration
+func (m Migrator) Force(ctx context.Context, version int) error {
+	if _, err := m.index(version); err != nil {
+		return err
+	}
+
+	return m.withLock(ctx, func() error {
+		return m.driver.SetVersion(ctx, version, false)
+	})
+}
@@ -0,0 +1,129 @@
+// +build unit
+
+package migration_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/AdhityaRamadhanus/userland/pkg/common/migration"
+	"github.com/pkg/errors"
+)
+
+//fakeDriver keep the version in memory and fail every query equal to failQuery
+type fakeDriver struct {
+	version   int
+	dirty     bool
+	locked    bool
+	executed  []string
+	failQuery string
+}
+
+func (d *fakeDriver) Lock(ctx context.Context) error {
+	d.locked = true
+	return nil
+}
+
+func (d *fakeDriver) Unlock(ctx context.Context) error {
+	d.locked = false
+	return nil
+}
+
+func (d *fakeDriver) Version(ctx context.Context) (int, bool, error) {
+	return d.version, d.dirty, nil
+}
+
+func (d *fakeDriver) SetVersion(ctx context.Context, version int, dirty bool) error {
+	d.version, d.dirty = version, dirty
+	return nil
+}
+
+func (d *fakeDriver) Exec(ctx context.Context, query string) error {
+	if !d.locked {
+		return errors.New("not locked")
+	}
+	if query == d.failQuery {
+		return errors.New("syntax error")
+	}
+	d.executed = append(d.executed, query)
+	return nil
+}
+
+func testMigrations(t *testing.T) []migration.Migration {
+	migrations, err := migration.Parse(map[string]string{
+		"000002_add_bio.up.sql":        "up 2",
+		"000002_add_bio.down.sql":      "down 2",
+		"000001_create_users.up.sql":   "up 1",
+		"000001_create_users.down.sql": "down 1",
+		"000003_add_phone.up.sql":      "up 3",
+		"000003_add_phone.down.sql":    "down 3",
+	})
+	if err != nil {
+		t.Fatalf("migration.Parse() err = %v; want nil", err)
+	}
+	return migrations
+}
+
+func TestParse(t *testing.T) {
+	migrations := testMigrations(t)
+	if len(migrations) != 3 || migrations[0].String() != "000001_create_users" || migrations[2].Down != "down 3" {
+		t.Errorf("migration.Parse() = %+v; want 3 migrations sorted by version", migrations)
+	}
+
+	invalidFiles := []map[string]string{
+		{"create_users.up.sql": ""},
+		{"000000_create_users.up.sql": ""},
+		{"000001_create_users.up.sql": "", "000001_create_events.down.sql": ""},
+	}
+	for _, files := range invalidFiles {
+		if _, err := migration.Parse(files); err == nil {
+			t.Errorf("migration.Parse(%v) err = nil; want err", files)
+		}
+	}
+}
+
+func TestMigrator(t *testing.T) {
+	driver := &fakeDriver{version: 1}
+	migrator := migration.NewMigrator(driver, testMigrations(t))
+
+	applied, err := migrator.Up(context.Background())
+	if err != nil || len(applied) != 2 || driver.version != 3 || driver.dirty || driver.locked {
+		t.Fatalf("migrator.Up() = %d migrations, %v, version %d; want 2, nil, 3", len(applied), err, driver.version)
+	}
+
+	reverted, err := migrator.Down(context.Background(), 5)
+	if err != nil || len(reverted) != 3 || driver.version != 0 {
+		t.Fatalf("migrator.Down(5) = %d migrations, %v, version %d; want 3, nil, 0", len(reverted), err, driver.version)
+	}
+	want := []string{"up 2", "up 3", "down 3", "down 2", "down 1"}
+	if len(driver.executed) != len(want) {
+		t.Fatalf("executed %v; want %v", driver.executed, want)
+	}
+	for i := range want {
+		if driver.executed[i] != want[i] {
+			t.Errorf("executed[%d] = %q; want %q", i, driver.executed[i], want[i])
+		}
+	}
+}
+
+func TestMigrator_dirty(t *testing.T) {
+	driver := &fakeDriver{failQuery: "up 2"}
+	migrator := migration.NewMigrator(driver, testMigrations(t))
+
+	if _, err := migrator.Up(context.Background()); err == nil {
+		t.Fatalf("migrator.Up() err = nil; want err")
+	}
+	if driver.version != 2 || !driver.dirty {
+		t.Fatalf("version, dirty = %d, %v after failed migration; want 2, true", driver.version, driver.dirty)
+	}
+	if _, err := migrator.Up(context.Background()); errors.Cause(err) != migration.ErrDirty {
+		t.Errorf("migrator.Up() err = %v on dirty database; want %v", err, migration.ErrDirty)
+	}
+
+	if err := migrator.Force(context.Background(), 4); errors.Cause(err) != migration.ErrUnknownVersion {
+		t.Errorf("migrator.Force(4) err = %v; want %v", err, migration.ErrUnknownVersion)
+	}
+	if err := migrator.Force(context.Background(), 1); err != nil || driver.version != 1 || driver.dirty {
+		t.Errorf("migrator.Force(1) = %v, version %d, dirty %v; want nil, 1, false", err, driver.version, driver.dirty)
+	}
+}
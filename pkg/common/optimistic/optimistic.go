@@ -0,0 +1,51 @@
+package optimistic
+
+import (
+	"context"
+
+	"github.com/AdhityaRamadhanus/userland"
+)
+
+//MaxAttempts is how many times UpdateUser store a change before giving up with userland.ErrConcurrentModification
+var MaxAttempts = 3
+
+//UpdateUser apply change to user and store it, when user was modified concurrently change is applied again on a fresh copy,
+//change may run more than once so it must only set fields
+func UpdateUser(ctx context.Context, userRepository userland.UserRepository, user userland.User, change func(user *userland.User)) (userland.User, error) {
+	return UpdateUserChecked(ctx, userRepository, user, func(user *userland.User) error {
+		change(user)
+		return nil
+	})
+}
+
+//UpdateUserChecked is UpdateUser for a change that depend on the stored user, change see every fresh copy
+//and can refuse it, its error is returned as is and nothing is stored
+func UpdateUserChecked(ctx context.Context, userRepository userland.UserRepository, user userland.User, change func(user *userland.User) error) (userland.User, error) {
+	return store(ctx, userRepository, userRepository.Update, user, change)
+}
+
+//StoreBackupCodesChecked is UpdateUserChecked for backup codes, they are stored with StoreBackupCodes
+//so consuming one code twice concurrently is caught by the version check
+func StoreBackupCodesChecked(ctx context.Context, userRepository userland.UserRepository, user userland.User, change func(user *userland.User) error) (userland.User, error) {
+	return store(ctx, userRepository, userRepository.StoreBackupCodes, user, change)
+}
+
+func store(ctx context.Context, userRepository userland.UserRepository, write func(ctx context.Context, user userland.User) error, user userland.User, change func(user *userland.User) error) (userland.User, error) {
+	for attempt := 1; ; attempt++ {
+		if err := change(&user); err != nil {
+			return userland.User{}, err
+		}
+		err := write(ctx, user)
+		if err == nil {
+			user.Version++
+			return user, nil
+		}
+		if err != userland.ErrConcurrentModification || attempt >= MaxAttempts {
+			return userland.User{}, err
+		}
+
+		if user, err = userRepository.Find(ctx, user.ID); err != nil {
+			return userland.User{}, err
+		}
+	}
+}
@@ -0,0 +1,160 @@
+// +build unit
+
+package optimistic_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/AdhityaRamadhanus/userland"
+	"github.com/AdhityaRamadhanus/userland/pkg/common/optimistic"
+	"github.com/AdhityaRamadhanus/userland/pkg/storage/memory"
+	"github.com/AdhityaRamadhanus/userland/pkg/userlandtest"
+)
+
+//conflictingUserRepository reject every update
+type conflictingUserRepository struct {
+	userland.UserRepository
+	updates *int
+}
+
+func (c conflictingUserRepository) Update(ctx context.Context, user userland.User) error {
+	*c.updates++
+	return userland.ErrConcurrentModification
+}
+
+func TestUpdateUser(t *testing.T) {
+	userRepository := memory.NewUserRepository()
+	user := userlandtest.TestCreateUser(t, userRepository)
+
+	// another request change the bio after user was read
+	concurrent := *user
+	concurrent.Bio = "concurrent bio"
+	if err := userRepository.Update(context.Background(), concurrent); err != nil {
+		t.Fatalf("userRepository.Update() err = %v; want nil", err)
+	}
+
+	updated, err := optimistic.UpdateUser(context.Background(), userRepository, *user, func(user *userland.User) {
+		user.Fullname = "Retried"
+	})
+	if err != nil {
+		t.Fatalf("optimistic.UpdateUser() err = %v; want nil", err)
+	}
+	if updated.Version != user.Version+2 {
+		t.Errorf("optimistic.UpdateUser() version = %d; want %d", updated.Version, user.Version+2)
+	}
+
+	found, err := userRepository.Find(context.Background(), user.ID)
+	if err != nil {
+		t.Fatalf("userRepository.Find() err = %v; want nil", err)
+	}
+	if found.Fullname != "Retried" || found.Bio != "concurrent bio" {
+		t.Errorf("userRepository.Find() = %q, %q; want both changes kept", found.Fullname, found.Bio)
+	}
+}
+
+func TestUpdateUser_giveUp(t *testing.T) {
+	updates := 0
+	userRepository := memory.NewUserRepository()
+	user := userlandtest.TestCreateUser(t, userRepository)
+
+	_, err := optimistic.UpdateUser(context.Background(), conflictingUserRepository{userRepository, &updates}, *user, func(user *userland.User) {})
+	if err != userland.ErrConcurrentModification {
+		t.Errorf("optimistic.UpdateUser() err = %v; want %v", err, userland.ErrConcurrentModification)
+	}
+	if updates != optimistic.MaxAttempts {
+		t.Errorf("optimistic.UpdateUser() tried %d updates; want %d", updates, optimistic.MaxAttempts)
+	}
+}
+
+func TestUpdateUserChecked_refused(t *testing.T) {
+	userRepository := memory.NewUserRepository()
+	user := userlandtest.TestCreateUser(t, userRepository)
+
+	// another request change the bio after user was read, the retried change refuse the fresh copy
+	concurrent := *user
+	concurrent.Bio = "concurrent bio"
+	if err := userRepository.Update(context.Background(), concurrent); err != nil {
+		t.Fatalf("userRepository.Update() err = %v; want nil", err)
+	}
+
+	errRefused := errors.New("refused")
+	_, err := optimistic.UpdateUserChecked(context.Background(), userRepository, *user, func(user *userland.User) error {
+		if user.Bio == "concurrent bio" {
+			return errRefused
+		}
+		user.Fullname = "Retried"
+		return nil
+	})
+	if err != errRefused {
+		t.Fatalf("optimistic.UpdateUserChecked() err = %v; want %v", err, errRefused)
+	}
+
+	found, err := userRepository.Find(context.Background(), user.ID)
+	if err != nil {
+		t.Fatalf("userRepository.Find() err = %v; want nil", err)
+	}
+	if found.Fullname == "Retried" {
+		t.Errorf("userRepository.Find() fullname = %q; want refused change not stored", found.Fullname)
+	}
+}
+
+func TestStoreBackupCodesChecked_concurrentConsumption(t *testing.T) {
+	userRepository := memory.NewUserRepository()
+	user := userlandtest.TestCreateUser(t, userRepository)
+	user.BackupCodes = []string{"code1", "code2"}
+	if err := userRepository.StoreBackupCodes(context.Background(), *user); err != nil {
+		t.Fatalf("userRepository.StoreBackupCodes() err = %v; want nil", err)
+	}
+	user.Version++
+
+	// both requests read user before either consume code1, the loser see it gone after retrying
+	errConsumed := errors.New("consumed")
+	consume := func(user *userland.User) error {
+		for idx, code := range user.BackupCodes {
+			if code == "code1" {
+				user.BackupCodes = append(user.BackupCodes[:idx], user.BackupCodes[idx+1:]...)
+				return nil
+			}
+		}
+		return errConsumed
+	}
+	const attempts = 2
+	errs := make(chan error, attempts)
+	var wg sync.WaitGroup
+	for i := 0; i < attempts; i++ {
+		// every request hold its own copy of the codes
+		read := *user
+		read.BackupCodes = append([]string(nil), user.BackupCodes...)
+		wg.Add(1)
+		go func(user userland.User) {
+			defer wg.Done()
+			_, err := optimistic.StoreBackupCodesChecked(context.Background(), userRepository, user, consume)
+			errs <- err
+		}(read)
+	}
+	wg.Wait()
+	close(errs)
+
+	consumed := 0
+	for err := range errs {
+		if err == nil {
+			consumed++
+		} else if err != errConsumed {
+			t.Errorf("optimistic.StoreBackupCodesChecked() err = %v; want nil or %v", err, errConsumed)
+		}
+	}
+	if consumed != 1 {
+		t.Errorf("optimistic.StoreBackupCodesChecked() consumed code1 %d times; want 1", consumed)
+	}
+
+	found, err := userRepository.Find(context.Background(), user.ID)
+	if err != nil {
+		t.Fatalf("userRepository.Find() err = %v; want nil", err)
+	}
+	if len(found.BackupCodes) != 1 || found.BackupCodes[0] != "code2" {
+		t.Errorf("userRepository.Find() BackupCodes = %v; want [code2]", found.BackupCodes)
+	}
+}
@@ -0,0 +1,148 @@
+package revocation
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/AdhityaRamadhanus/userland"
+	revocationclient "github.com/AdhityaRamadhanus/userland/pkg/common/http/clients/revocation"
+	"github.com/AdhityaRamadhanus/userland/pkg/common/security"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	//DefaultSyncInterval is how often the cache poll for revocations
+	DefaultSyncInterval = time.Second
+	//SyncOverlap is subtracted from every poll window to tolerate clock skew between publishers
+	SyncOverlap = 5 * time.Second
+)
+
+//Cache keep revoked session ids (the jti of access tokens) in memory for local token verification,
+//it subscribe for instant updates and poll userland.RevocationService in background so a missed message is picked up within one sync interval
+type Cache struct {
+	revocationService userland.RevocationService
+	syncInterval      time.Duration
+	ttl               time.Duration
+	denyList          *revocationclient.DenyList
+
+	mutex        sync.RWMutex
+	lastSyncedAt time.Time
+}
+
+func WithSyncInterval(syncInterval time.Duration) func(cache *Cache) {
+	return func(cache *Cache) {
+		if syncInterval > 0 {
+			cache.syncInterval = syncInterval
+		}
+	}
+}
+
+//WithTTL set how long revoked sessions are remembered, should be at least the longest token expiration
+func WithTTL(ttl time.Duration) func(cache *Cache) {
+	return func(cache *Cache) {
+		cache.ttl = ttl
+	}
+}
+
+func NewCache(revocationService userland.RevocationService, options ...func(*Cache)) *Cache {
+	cache := &Cache{
+		revocationService: revocationService,
+		syncInterval:      DefaultSyncInterval,
+		ttl:               security.RefreshAccessTokenExpiration,
+	}
+	for _, option := range options {
+		option(cache)
+	}
+	cache.denyList = revocationclient.NewDenyList(cache.ttl)
+
+	return cache
+}
+
+//Run keep the cache in sync until ctx is done
+func (c *Cache) Run(ctx context.Context) error {
+	go c.follow(ctx)
+
+	since := time.Now().Add(-c.ttl)
+	ticker := time.NewTicker(c.syncInterval)
+	defer ticker.Stop()
+	for {
+		syncStartedAt := time.Now()
+		if err := c.sync(ctx, since, syncStartedAt); err != nil {
+			logrus.WithError(err).Warn("Failed to sync revocation cache")
+		} else {
+			since = syncStartedAt.Add(-SyncOverlap)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+//IsRevoked return true when session is known to be revoked
+func (c *Cache) IsRevoked(sessionID string) bool {
+	return c.denyList.Contains(sessionID)
+}
+
+//Staleness is the time since the last successful sync, a cache that never synced is infinitely stale
+func (c *Cache) Staleness() time.Duration {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	if c.lastSyncedAt.IsZero() {
+		return time.Duration(math.MaxInt64)
+	}
+	return time.Since(c.lastSyncedAt)
+}
+
+func (c *Cache) sync(ctx context.Context, since time.Time, syncStartedAt time.Time) error {
+	revocations, err := c.revocationService.FindAllSince(ctx, since)
+	if err != nil {
+		return err
+	}
+	for _, revocation := range revocations {
+		c.denyList.Add(revocation.SessionID, revocation.RevokedAt)
+	}
+
+	// anything revoked after the poll started may be missing, so freshness count from the start
+	c.mutex.Lock()
+	c.lastSyncedAt = syncStartedAt
+	c.mutex.Unlock()
+	return nil
+}
+
+func (c *Cache) follow(ctx context.Context) {
+	for {
+		subscription, err := c.revocationService.Subscribe(ctx)
+		if err != nil {
+			logrus.WithError(err).Warn("Failed to subscribe to revocations")
+		} else {
+			c.consume(ctx, subscription)
+			subscription.Close()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(c.syncInterval):
+		}
+	}
+}
+
+func (c *Cache) consume(ctx context.Context, subscription userland.RevocationSubscription) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case revocation, ok := <-subscription.Revocations():
+			if !ok {
+				return
+			}
+			c.denyList.Add(revocation.SessionID, revocation.RevokedAt)
+		}
+	}
+}
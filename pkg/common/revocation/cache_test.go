@@ -0,0 +1,48 @@
+//+build unit
+
+package revocation_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/AdhityaRamadhanus/userland"
+	"github.com/AdhityaRamadhanus/userland/pkg/common/revocation"
+	"github.com/AdhityaRamadhanus/userland/pkg/mocks/repository"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCache_Run(t *testing.T) {
+	subscription := repository.RevocationSubscription{RevocationsChan: make(chan userland.SessionRevocation, 1)}
+	revocationService := repository.RevocationService{}
+	revocationService.On("FindAllSince", mock.AnythingOfType("time.Time")).Return(userland.SessionRevocations{
+		{SessionID: "polled-session", UserID: 1, Reason: userland.SessionEndReasonLogout, RevokedAt: time.Now()},
+	}, nil)
+	revocationService.On("Subscribe").Return(subscription, nil)
+
+	cache := revocation.NewCache(&revocationService, revocation.WithSyncInterval(10*time.Millisecond))
+	if staleness := cache.Staleness(); staleness < time.Hour {
+		t.Errorf("Cache.Staleness() before Run = %v; want never synced", staleness)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go cache.Run(ctx)
+
+	subscription.RevocationsChan <- userland.SessionRevocation{SessionID: "pushed-session", UserID: 1, Reason: userland.SessionEndReasonRevoked, RevokedAt: time.Now()}
+	deadline := time.Now().Add(time.Second)
+	for !(cache.IsRevoked("polled-session") && cache.IsRevoked("pushed-session")) {
+		if time.Now().After(deadline) {
+			t.Fatalf("Cache.IsRevoked() = false; want polled and pushed sessions revoked")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if cache.IsRevoked("active-session") {
+		t.Errorf("Cache.IsRevoked(active-session) = true; want false")
+	}
+	if staleness := cache.Staleness(); staleness > time.Second {
+		t.Errorf("Cache.Staleness() = %v; want <= 1s", staleness)
+	}
+}
@@ -14,6 +14,11 @@ var (
 	TFATokenScope     = "tfa"
 	UserTokenScope    = "user"
 	RefreshTokenScope = "refresh"
+
+	// authentication methods references (amr claim), see RFC 8176
+	PasswordAuthenticationMethod = "pwd"
+	OTPAuthenticationMethod      = "otp"
+	SAMLAuthenticationMethod     = "saml"
 )
 
 type AccessToken struct {
@@ -24,13 +29,45 @@ type AccessToken struct {
 }
 
 type AccessTokenOptions struct {
-	Expiration  time.Duration
-	Scope       string
-	CustomClaim map[string]interface{}
+	Expiration     time.Duration
+	Scope          string
+	CustomClaim    map[string]interface{}
+	Authentication Authentication
+}
+
+//Authentication describe when and how the user last proved their identity, carried by auth_time and amr claims
+type Authentication struct {
+	// Time default to token issuance
+	Time    time.Time
+	Methods []string
+}
+
+//AuthenticationFromClaims read auth_time and amr claims of a parsed token, tokens without auth_time are authenticated at issuance
+func AuthenticationFromClaims(claims map[string]interface{}) Authentication {
+	authentication := Authentication{
+		Methods: []string{},
+	}
+
+	authTime, ok := claims["auth_time"].(float64)
+	if !ok {
+		authTime, _ = claims["iat"].(float64)
+	}
+	authentication.Time = time.Unix(int64(authTime), 0)
+
+	methods, _ := claims["amr"].([]interface{})
+	for _, method := range methods {
+		if method, ok := method.(string); ok {
+			authentication.Methods = append(authentication.Methods, method)
+		}
+	}
+
+	return authentication
 }
 
 func CreateAccessToken(user userland.User, jwtSecret string, options AccessTokenOptions) (AccessToken, error) {
 	nowInSeconds := time.Now().Unix()
+	// generate session id, also the jti so a JWT bearer can be mapped back to its session
+	sessionID := GenerateUUID()
 
 	// generate value token
 	claims := jwt.MapClaims{
@@ -43,6 +80,7 @@ func CreateAccessToken(user userland.User, jwtSecret string, options AccessToken
 		"fullname": user.Fullname,
 		"email":    user.Email,
 		"userid":   user.ID,
+		"jti":      sessionID,
 	}
 
 	if len(options.CustomClaim) > 0 {
@@ -51,6 +89,15 @@ func CreateAccessToken(user userland.User, jwtSecret string, options AccessToken
 		}
 	}
 
+	authTime := options.Authentication.Time
+	if authTime.IsZero() {
+		authTime = time.Unix(nowInSeconds, 0)
+	}
+	claims["auth_time"] = authTime.Unix()
+	if len(options.Authentication.Methods) > 0 {
+		claims["amr"] = options.Authentication.Methods
+	}
+
 	expirationEpoch := nowInSeconds + int64(options.Expiration.Seconds())
 	claims["exp"] = expirationEpoch
 
@@ -60,9 +107,6 @@ func CreateAccessToken(user userland.User, jwtSecret string, options AccessToken
 		return AccessToken{}, err
 	}
 
-	// generate session id
-	sessionID := GenerateUUID()
-
 	return AccessToken{
 		Key:       sessionID,
 		Value:     tokenString,
@@ -3,11 +3,13 @@
 package security_test
 
 import (
+	"reflect"
 	"testing"
 	"time"
 
 	"github.com/AdhityaRamadhanus/userland"
 	"github.com/AdhityaRamadhanus/userland/pkg/common/security"
+	jwt "github.com/dgrijalva/jwt-go"
 )
 
 func TestCreateAccessToken(t *testing.T) {
@@ -39,9 +41,88 @@ func TestCreateAccessToken(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			if _, err := security.CreateAccessToken(tc.args.user, "jwtsecret_test", tc.args.opt); err != tc.wantErr {
+			accessToken, err := security.CreateAccessToken(tc.args.user, "jwtsecret_test", tc.args.opt)
+			if err != tc.wantErr {
 				t.Fatalf("security.CreateAccessToken() err = %v; want %v", err, tc.wantErr)
 			}
+
+			claims := jwt.MapClaims{}
+			if _, err := jwt.ParseWithClaims(accessToken.Value, claims, func(token *jwt.Token) (interface{}, error) {
+				return []byte("jwtsecret_test"), nil
+			}); err != nil {
+				t.Fatalf("jwt.ParseWithClaims() err = %v; want nil", err)
+			}
+			if claims["jti"] != accessToken.Key {
+				t.Errorf("claims[jti] = %v; want %s", claims["jti"], accessToken.Key)
+			}
+		})
+	}
+}
+
+func TestAuthenticationFromClaims(t *testing.T) {
+	user := userland.User{
+		Fullname: "Adhitya Ramadhanus",
+		Email:    "adhitya.ramadhanus@gmail.com",
+		ID:       1,
+	}
+	authTime := time.Now().Add(-time.Hour).Truncate(time.Second)
+
+	testCases := []struct {
+		name               string
+		opt                security.AccessTokenOptions
+		wantAuthentication security.Authentication
+	}{
+		{
+			name: "authenticated at issuance",
+			opt: security.AccessTokenOptions{
+				Expiration: security.UserAccessTokenExpiration,
+				Scope:      security.UserTokenScope,
+			},
+			wantAuthentication: security.Authentication{
+				Methods: []string{},
+			},
+		},
+		{
+			name: "carried authentication",
+			opt: security.AccessTokenOptions{
+				Expiration: security.UserAccessTokenExpiration,
+				Scope:      security.UserTokenScope,
+				Authentication: security.Authentication{
+					Time:    authTime,
+					Methods: []string{security.PasswordAuthenticationMethod, security.OTPAuthenticationMethod},
+				},
+			},
+			wantAuthentication: security.Authentication{
+				Time:    authTime,
+				Methods: []string{security.PasswordAuthenticationMethod, security.OTPAuthenticationMethod},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			accessToken, err := security.CreateAccessToken(user, "jwtsecret_test", tc.opt)
+			if err != nil {
+				t.Fatalf("security.CreateAccessToken() err = %v; want nil", err)
+			}
+			jwtToken, err := jwt.Parse(accessToken.Value, func(token *jwt.Token) (interface{}, error) {
+				return []byte("jwtsecret_test"), nil
+			})
+			if err != nil {
+				t.Fatalf("jwt.Parse() err = %v; want nil", err)
+			}
+			claims, _ := jwtToken.Claims.(jwt.MapClaims)
+
+			authentication := security.AuthenticationFromClaims(claims)
+			if !reflect.DeepEqual(authentication.Methods, tc.wantAuthentication.Methods) {
+				t.Errorf("security.AuthenticationFromClaims() methods = %v; want %v", authentication.Methods, tc.wantAuthentication.Methods)
+			}
+			if tc.wantAuthentication.Time.IsZero() {
+				tc.wantAuthentication.Time = time.Unix(int64(claims["iat"].(float64)), 0)
+			}
+			if !authentication.Time.Equal(tc.wantAuthentication.Time) {
+				t.Errorf("security.AuthenticationFromClaims() time = %v; want %v", authentication.Time, tc.wantAuthentication.Time)
+			}
 		})
 	}
 }
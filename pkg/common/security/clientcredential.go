@@ -0,0 +1,46 @@
+package security
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrInvalidClientCredential = errors.New("Invalid client credential")
+)
+
+func clientCredentialSignature(clientID string, key string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(clientID))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+//SignClientCredential create credential of client in <client id>.<signature> format, signed with key
+func SignClientCredential(clientID int, key string) string {
+	id := strconv.Itoa(clientID)
+	return id + "." + clientCredentialSignature(id, key)
+}
+
+//ParseClientCredential verify credential signature and return the client id it was signed for
+func ParseClientCredential(credential string, key string) (clientID int, err error) {
+	splittedCredential := strings.SplitN(credential, ".", 2)
+	if len(splittedCredential) != 2 {
+		return 0, ErrInvalidClientCredential
+	}
+	id, signature := splittedCredential[0], splittedCredential[1]
+
+	if !hmac.Equal([]byte(signature), []byte(clientCredentialSignature(id, key))) {
+		return 0, ErrInvalidClientCredential
+	}
+
+	clientID, err = strconv.Atoi(id)
+	if err != nil {
+		return 0, ErrInvalidClientCredential
+	}
+	return clientID, nil
+}
@@ -8,4 +8,9 @@ var (
 	TFATokenExpiration           = time.Second * 60 * 2       // 2 minutes
 	ForgotPassExpiration         = time.Second * 60 * 5       // 5 minutes
 	EmailVerificationExpiration  = time.Second * 60 * 2       // 2 minutes
+	TrustedDeviceExpiration      = time.Hour * 24 * 30        // 30 days
+	ReauthenticationExpiration   = time.Second * 60 * 5       // 5 minutes
+	ReauthenticationMaxAge       = time.Second * 60 * 5       // 5 minutes
+	SignInDenialExpiration       = time.Hour * 24 * 7         // 7 days
+	LoginFailureExpiration       = time.Second * 60 * 15      // 15 minutes
 )
@@ -9,11 +9,14 @@ import (
 	"github.com/pkg/errors"
 )
 
+//MaxOTPLength is the longest OTP GenerateOTP can make
+const MaxOTPLength = 8
+
 func GenerateOTP(length int) (string, error) {
 	if length < 0 {
 		return "", errors.New("OTP Length Must be Positive")
 	}
-	if length > 8 {
+	if length > MaxOTPLength {
 		return "", errors.New("Max OTP Length 8")
 	}
 	max := int64(math.Pow10(length) - 1)
@@ -0,0 +1,26 @@
+package security
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+
+	"github.com/pkg/errors"
+)
+
+//GenerateRandomToken generate url safe random token from n random bytes
+func GenerateRandomToken(n int) (string, error) {
+	randomBytes := make([]byte, n)
+	if _, err := rand.Read(randomBytes); err != nil {
+		return "", errors.Wrap(err, "rand.Read() err")
+	}
+
+	return base64.RawURLEncoding.EncodeToString(randomBytes), nil
+}
+
+//HashToken hash high entropy token, use HashPassword for user chosen secrets
+func HashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
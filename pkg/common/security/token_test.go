@@ -0,0 +1,120 @@
+// +build unit
+
+package security_test
+
+import (
+	"testing"
+
+	"github.com/AdhityaRamadhanus/userland/pkg/common/security"
+)
+
+func TestGenerateRandomToken(t *testing.T) {
+	type args struct {
+		n int
+	}
+
+	testCases := []struct {
+		name    string
+		args    args
+		wantLen int
+	}{
+		{
+			name: "32 bytes token",
+			args: args{
+				n: 32,
+			},
+			wantLen: 43,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			token, err := security.GenerateRandomToken(tc.args.n)
+			if err != nil {
+				t.Fatalf("security.GenerateRandomToken(%d) err = %v; want nil", tc.args.n, err)
+			}
+			if len(token) != tc.wantLen {
+				t.Errorf("len(security.GenerateRandomToken(%d)) = %d; want %d", tc.args.n, len(token), tc.wantLen)
+			}
+			otherToken, _ := security.GenerateRandomToken(tc.args.n)
+			if token == otherToken {
+				t.Errorf("security.GenerateRandomToken(%d) generate same token twice", tc.args.n)
+			}
+		})
+	}
+}
+
+func TestHashToken(t *testing.T) {
+	token := "device-token"
+	if security.HashToken(token) != security.HashToken(token) {
+		t.Fatalf("security.HashToken(%q) is not deterministic", token)
+	}
+	if security.HashToken(token) == security.HashToken("other-device-token") {
+		t.Fatalf("security.HashToken() collide for different token")
+	}
+	if security.HashToken(token) == token {
+		t.Fatalf("security.HashToken(%q) = token; want hashed token", token)
+	}
+}
+
+func TestParseClientCredential(t *testing.T) {
+	key := "client-signing-key"
+	credential := security.SignClientCredential(42, key)
+
+	type args struct {
+		credential string
+		key        string
+	}
+	testCases := []struct {
+		name         string
+		args         args
+		wantClientID int
+		wantErr      error
+	}{
+		{
+			name: "valid",
+			args: args{
+				credential: credential,
+				key:        key,
+			},
+			wantClientID: 42,
+			wantErr:      nil,
+		},
+		{
+			name: "signed with other key",
+			args: args{
+				credential: credential,
+				key:        "other-key",
+			},
+			wantErr: security.ErrInvalidClientCredential,
+		},
+		{
+			name: "tampered client id",
+			args: args{
+				credential: "43" + credential[2:],
+				key:        key,
+			},
+			wantErr: security.ErrInvalidClientCredential,
+		},
+		{
+			name: "legacy name and id",
+			args: args{
+				credential: "web:42",
+				key:        key,
+			},
+			wantErr: security.ErrInvalidClientCredential,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			clientID, err := security.ParseClientCredential(tc.args.credential, tc.args.key)
+			if err != tc.wantErr {
+				t.Fatalf("security.ParseClientCredential(%q) err = %v; want %v", tc.args.credential, err, tc.wantErr)
+			}
+			if clientID != tc.wantClientID {
+				t.Errorf("security.ParseClientCredential(%q) clientID = %d; want %d", tc.args.credential, clientID, tc.wantClientID)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package security
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	//TOTPPeriod is how long a TOTP code is valid, authenticator apps use 30 seconds by default
+	TOTPPeriod = 30 * time.Second
+	//TOTPDigits is the length of a TOTP code
+	TOTPDigits = 6
+	//TOTPSkew is how many periods before and after now a code is still accepted, device clocks drift
+	TOTPSkew = 1
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+//GenerateTOTPSecret generate base32 secret shared with an authenticator app (RFC 6238)
+func GenerateTOTPSecret() (string, error) {
+	key := make([]byte, 20)
+	if _, err := rand.Read(key); err != nil {
+		return "", errors.Wrap(err, "rand.Read() err")
+	}
+
+	return totpEncoding.EncodeToString(key), nil
+}
+
+//GenerateTOTP return TOTP code of secret at t
+func GenerateTOTP(secret string, t time.Time) (string, error) {
+	key, err := decodeTOTPSecret(secret)
+	if err != nil {
+		return "", err
+	}
+
+	return totpCode(key, totpCounter(t)), nil
+}
+
+//ValidateTOTP check code against TOTP codes of secret around t, TOTPSkew periods either way
+func ValidateTOTP(secret string, code string, t time.Time) bool {
+	key, err := decodeTOTPSecret(secret)
+	if err != nil {
+		return false
+	}
+
+	counter := totpCounter(t)
+	valid := false
+	for skew := -TOTPSkew; skew <= TOTPSkew; skew++ {
+		if subtle.ConstantTimeCompare([]byte(totpCode(key, uint64(int64(counter)+int64(skew)))), []byte(code)) == 1 {
+			valid = true
+		}
+	}
+	return valid
+}
+
+func decodeTOTPSecret(secret string) ([]byte, error) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(strings.TrimRight(secret, "=")))
+	if err != nil {
+		return nil, errors.Wrap(err, "base32 decode TOTP secret err")
+	}
+	if len(key) == 0 {
+		return nil, errors.New("TOTP secret is empty")
+	}
+	return key, nil
+}
+
+func totpCounter(t time.Time) uint64 {
+	return uint64(t.Unix() / int64(TOTPPeriod/time.Second))
+}
+
+//totpCode is HOTP (RFC 4226) of key at counter
+func totpCode(key []byte, counter uint64) string {
+	message := make([]byte, 8)
+	binary.BigEndian.PutUint64(message, counter)
+	mac := hmac.New(sha1.New, key)
+	mac.Write(message)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", TOTPDigits, value%uint32(math.Pow10(TOTPDigits)))
+}
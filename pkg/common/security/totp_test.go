@@ -0,0 +1,73 @@
+// +build all common unit
+
+package security_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/AdhityaRamadhanus/userland/pkg/common/security"
+)
+
+// secret of RFC 6238 test vectors, base32 of "12345678901234567890"
+const rfcTOTPSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestGenerateTOTP(t *testing.T) {
+	// RFC 6238 sha1 vectors truncated to 6 digits
+	testCases := []struct {
+		unix     int64
+		wantCode string
+	}{
+		{unix: 59, wantCode: "287082"},
+		{unix: 1111111109, wantCode: "081804"},
+		{unix: 1111111111, wantCode: "050471"},
+		{unix: 1234567890, wantCode: "005924"},
+		{unix: 2000000000, wantCode: "279037"},
+	}
+
+	for _, tc := range testCases {
+		code, err := security.GenerateTOTP(rfcTOTPSecret, time.Unix(tc.unix, 0))
+		if err != nil {
+			t.Fatalf("security.GenerateTOTP(secret, %d) err = %v; want nil", tc.unix, err)
+		}
+		if code != tc.wantCode {
+			t.Errorf("security.GenerateTOTP(secret, %d) = %q; want %q", tc.unix, code, tc.wantCode)
+		}
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	secret, err := security.GenerateTOTPSecret()
+	if err != nil {
+		t.Fatalf("security.GenerateTOTPSecret() err = %v; want nil", err)
+	}
+	now := time.Now()
+	code, err := security.GenerateTOTP(secret, now)
+	if err != nil {
+		t.Fatalf("security.GenerateTOTP() err = %v; want nil", err)
+	}
+
+	testCases := []struct {
+		name      string
+		secret    string
+		code      string
+		at        time.Time
+		wantValid bool
+	}{
+		{name: "current period", secret: secret, code: code, at: now, wantValid: true},
+		{name: "previous period", secret: secret, code: code, at: now.Add(security.TOTPPeriod), wantValid: true},
+		{name: "next period", secret: secret, code: code, at: now.Add(-security.TOTPPeriod), wantValid: true},
+		{name: "expired", secret: secret, code: code, at: now.Add(3 * security.TOTPPeriod), wantValid: false},
+		{name: "wrong code", secret: secret, code: "12345x", at: now, wantValid: false},
+		{name: "empty secret", secret: "", code: code, at: now, wantValid: false},
+		{name: "malformed secret", secret: "not base32!", code: code, at: now, wantValid: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if valid := security.ValidateTOTP(tc.secret, tc.code, tc.at); valid != tc.wantValid {
+				t.Errorf("security.ValidateTOTP() = %t; want %t", valid, tc.wantValid)
+			}
+		})
+	}
+}
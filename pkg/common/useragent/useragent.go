@@ -0,0 +1,59 @@
+package useragent
+
+import (
+	"strings"
+
+	"github.com/mssola/user_agent"
+)
+
+const (
+	//DeviceTypeBot for crawlers and other automated clients
+	DeviceTypeBot = "bot"
+	//DeviceTypeMobile for phones
+	DeviceTypeMobile = "mobile"
+	//DeviceTypeTablet for tablets
+	DeviceTypeTablet = "tablet"
+	//DeviceTypeDesktop for everything else that looks like a browser
+	DeviceTypeDesktop = "desktop"
+	//DeviceTypeUnknown when user agent is empty
+	DeviceTypeUnknown = "unknown"
+)
+
+//Device is the parsed representation of a user agent string
+type Device struct {
+	Browser string
+	OS      string
+	Type    string
+}
+
+//Parse user agent string into Device, empty user agent yields unknown device
+func Parse(userAgent string) Device {
+	if strings.TrimSpace(userAgent) == "" {
+		return Device{Type: DeviceTypeUnknown}
+	}
+
+	ua := user_agent.New(userAgent)
+	browserName, browserVersion := ua.Browser()
+	browser := strings.TrimSpace(browserName + " " + browserVersion)
+
+	return Device{
+		Browser: browser,
+		OS:      ua.OSInfo().FullName,
+		Type:    deviceType(ua, userAgent),
+	}
+}
+
+func deviceType(ua *user_agent.UserAgent, userAgent string) string {
+	switch {
+	case ua.Bot():
+		return DeviceTypeBot
+	case strings.Contains(userAgent, "iPad"),
+		strings.Contains(userAgent, "Tablet"),
+		strings.Contains(userAgent, "Android") && !strings.Contains(userAgent, "Mobile"):
+		return DeviceTypeTablet
+	case ua.Mobile():
+		return DeviceTypeMobile
+	default:
+		return DeviceTypeDesktop
+	}
+}
@@ -0,0 +1,74 @@
+// +build unit
+
+package useragent_test
+
+import (
+	"testing"
+
+	"github.com/AdhityaRamadhanus/userland/pkg/common/useragent"
+)
+
+func TestParse(t *testing.T) {
+	type args struct {
+		userAgent string
+	}
+
+	testCases := []struct {
+		name        string
+		args        args
+		wantBrowser string
+		wantType    string
+	}{
+		{
+			name: "empty user agent",
+			args: args{
+				userAgent: "",
+			},
+			wantType: useragent.DeviceTypeUnknown,
+		},
+		{
+			name: "desktop chrome",
+			args: args{
+				userAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/76.0.3809.100 Safari/537.36",
+			},
+			wantBrowser: "Chrome 76.0.3809.100",
+			wantType:    useragent.DeviceTypeDesktop,
+		},
+		{
+			name: "iphone safari",
+			args: args{
+				userAgent: "Mozilla/5.0 (iPhone; CPU iPhone OS 12_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/12.1 Mobile/15E148 Safari/604.1",
+			},
+			wantBrowser: "Safari 12.1",
+			wantType:    useragent.DeviceTypeMobile,
+		},
+		{
+			name: "ipad safari",
+			args: args{
+				userAgent: "Mozilla/5.0 (iPad; CPU OS 12_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/12.1 Mobile/15E148 Safari/604.1",
+			},
+			wantBrowser: "Safari 12.1",
+			wantType:    useragent.DeviceTypeTablet,
+		},
+		{
+			name: "googlebot",
+			args: args{
+				userAgent: "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+			},
+			wantBrowser: "Googlebot 2.1",
+			wantType:    useragent.DeviceTypeBot,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			device := useragent.Parse(tc.args.userAgent)
+			if device.Type != tc.wantType {
+				t.Errorf("useragent.Parse(%q).Type = %q; want %q", tc.args.userAgent, device.Type, tc.wantType)
+			}
+			if device.Browser != tc.wantBrowser {
+				t.Errorf("useragent.Parse(%q).Browser = %q; want %q", tc.args.userAgent, device.Browser, tc.wantBrowser)
+			}
+		})
+	}
+}
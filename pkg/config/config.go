@@ -2,7 +2,9 @@ package config
 
 import (
 	"os"
+	"time"
 
+	"github.com/AdhityaRamadhanus/userland/pkg/common/security"
 	"github.com/kelseyhightower/envconfig"
 	"github.com/pkg/errors"
 	"gopkg.in/yaml.v2"
@@ -10,20 +12,39 @@ import (
 
 // Configuration provide package level configuration for vendtron by reading from config.yaml first then overwrite if any with env (Default read from .env)
 type Configuration struct {
-	Env       string         `yaml:"env"`
-	API       ApiConfig      `yaml:"api"`
-	Mail      MailConfig     `yaml:"mail"`
-	Redis     RedisConfig    `yaml:"redis"`
-	Postgres  PostgresConfig `yaml:"postgres"`
-	Mailjet   MailjetConfig  `yaml:"mailjet"`
-	GCP       GCPConfig      `yaml:"gcp"`
-	Log       LogConfig      `yaml:"log"`
-	JWTSecret string         `yaml:"jwt_secret" envconfig:"JWT_SECRET"`
+	Env             string                `yaml:"env"`
+	API             ApiConfig             `yaml:"api"`
+	Mail            MailConfig            `yaml:"mail"`
+	Redis           RedisConfig           `yaml:"redis"`
+	Postgres        PostgresConfig        `yaml:"postgres"`
+	Database        DatabaseConfig        `yaml:"database"`
+	SQLite          SQLiteConfig          `yaml:"sqlite"`
+	Mailjet         MailjetConfig         `yaml:"mailjet"`
+	GCP             GCPConfig             `yaml:"gcp"`
+	Log             LogConfig             `yaml:"log"`
+	SAML            SAMLConfig            `yaml:"saml"`
+	TrustedDevice   TrustedDeviceConfig   `yaml:"trusted_device"`
+	TFA             TFAConfig             `yaml:"tfa"`
+	Session         SessionConfig         `yaml:"session"`
+	StatelessAuth   StatelessAuthConfig   `yaml:"stateless_auth"`
+	LoginAlert      LoginAlertConfig      `yaml:"login_alert"`
+	AccountDeletion AccountDeletionConfig `yaml:"account_deletion"`
+	DataExport      DataExportConfig      `yaml:"data_export"`
+	Geolocation     GeolocationConfig     `yaml:"geolocation"`
+	Risk            RiskConfig            `yaml:"risk"`
+	Client          ClientConfig          `yaml:"client"`
+	RateLimit       RateLimitConfig       `yaml:"rate_limit"`
+	JWTSecret       string                `yaml:"jwt_secret" envconfig:"JWT_SECRET"`
 }
 
 type ApiConfig struct {
-	Port int    `yaml:"port" envconfig:"API_PORT"`
-	Host string `yaml:"host" envconfig:"API_HOST"`
+	Port           int      `yaml:"port" envconfig:"API_PORT"`
+	Host           string   `yaml:"host" envconfig:"API_HOST"`
+	AdminUser      string   `yaml:"admin_user" envconfig:"API_ADMIN_USER"`
+	AdminPassword  string   `yaml:"admin_password" envconfig:"API_ADMIN_PASSWORD"`
+	TrustedProxies []string `yaml:"trusted_proxies" envconfig:"API_TRUSTED_PROXIES"`
+	// ForwardedHeader is the one header trusted proxies set the client ip in: Forwarded, X-Forwarded-For or X-Real-IP
+	ForwardedHeader string `yaml:"forwarded_header" envconfig:"API_FORWARDED_HEADER"`
 }
 
 type MailConfig struct {
@@ -41,15 +62,32 @@ type RedisConfig struct {
 	Host     string `yaml:"host" envconfig:"REDIS_HOST"`
 	Password string `yaml:"password" envconfig:"REDIS_PASSWORD"`
 	DB       int    `yaml:"db" envconfig:"REDIS_DB"`
+	// CommandTimeout bound dialing, writing and reading of every command, 0 keep go-redis defaults
+	CommandTimeout time.Duration `yaml:"command_timeout" envconfig:"REDIS_COMMAND_TIMEOUT"`
 }
 
 type PostgresConfig struct {
-	Port     int    `yaml:"port" envconfig:"POSTGRES_PORT"`
-	Host     string `yaml:"host" envconfig:"POSTGRES_HOST"`
-	User     string `yaml:"user" envconfig:"POSTGRES_USER"`
-	Password string `yaml:"password" envconfig:"POSTGRES_PASSWORD"`
-	DBName   string `yaml:"dbname" envconfig:"POSTGRES_DBNAME"`
-	SSLMode  string `yaml:"sslmode" envconfig:"POSTGRES_SSLMODE"`
+	Port         int           `yaml:"port" envconfig:"POSTGRES_PORT"`
+	Host         string        `yaml:"host" envconfig:"POSTGRES_HOST"`
+	User         string        `yaml:"user" envconfig:"POSTGRES_USER"`
+	Password     string        `yaml:"password" envconfig:"POSTGRES_PASSWORD"`
+	DBName       string        `yaml:"dbname" envconfig:"POSTGRES_DBNAME"`
+	SSLMode      string        `yaml:"sslmode" envconfig:"POSTGRES_SSLMODE"`
+	QueryTimeout time.Duration `yaml:"query_timeout" envconfig:"POSTGRES_QUERY_TIMEOUT"`
+}
+
+const (
+	DatabaseDriverPostgres = "postgres"
+	DatabaseDriverSQLite   = "sqlite"
+)
+
+type DatabaseConfig struct {
+	Driver string `yaml:"driver" envconfig:"DATABASE_DRIVER"`
+}
+
+type SQLiteConfig struct {
+	Path         string        `yaml:"path" envconfig:"SQLITE_PATH"`
+	QueryTimeout time.Duration `yaml:"query_timeout" envconfig:"SQLITE_QUERY_TIMEOUT"`
 }
 
 type GCPConfig struct {
@@ -67,6 +105,110 @@ type LogConfig struct {
 	Format string `yaml:"format" envconfig:"LOG_FORMAT"`
 }
 
+type SAMLConfig struct {
+	BaseURL string `yaml:"base_url" envconfig:"SAML_BASE_URL"`
+}
+
+type TrustedDeviceConfig struct {
+	Expiration time.Duration `yaml:"expiration" envconfig:"TRUSTED_DEVICE_EXPIRATION"`
+}
+
+type TFAConfig struct {
+	BackupCodeCount  int `yaml:"backup_code_count" envconfig:"TFA_BACKUP_CODE_COUNT"`
+	BackupCodeLength int `yaml:"backup_code_length" envconfig:"TFA_BACKUP_CODE_LENGTH"`
+}
+
+const (
+	SessionStorageRedis    = "redis"
+	SessionStoragePostgres = "postgres"
+	SessionStorageSQLite   = "sqlite"
+)
+
+const (
+	SessionLimitPolicyReject      = "reject"
+	SessionLimitPolicyEvictOldest = "evict_oldest"
+)
+
+type SessionConfig struct {
+	Storage              string         `yaml:"storage" envconfig:"SESSION_STORAGE"`
+	ActivityThrottle     time.Duration  `yaml:"activity_throttle" envconfig:"SESSION_ACTIVITY_THROTTLE"`
+	SlidingExpiration    time.Duration  `yaml:"sliding_expiration" envconfig:"SESSION_SLIDING_EXPIRATION"`
+	LimitPolicy          string         `yaml:"limit_policy" envconfig:"SESSION_LIMIT_POLICY"`
+	MaxSessions          int            `yaml:"max_sessions" envconfig:"SESSION_MAX_SESSIONS"`
+	MaxSessionsPerClient int            `yaml:"max_sessions_per_client" envconfig:"SESSION_MAX_SESSIONS_PER_CLIENT"`
+	ClientSessionLimits  map[string]int `yaml:"client_session_limits" envconfig:"SESSION_CLIENT_SESSION_LIMITS"`
+}
+
+const (
+	RouteGroupAuthentication = "authentication"
+	RouteGroupProfile        = "profile"
+	RouteGroupSession        = "session"
+)
+
+type StatelessAuthConfig struct {
+	RouteGroups  []string      `yaml:"route_groups" envconfig:"STATELESS_AUTH_ROUTE_GROUPS"`
+	SyncInterval time.Duration `yaml:"sync_interval" envconfig:"STATELESS_AUTH_SYNC_INTERVAL"`
+	MaxStaleness time.Duration `yaml:"max_staleness" envconfig:"STATELESS_AUTH_MAX_STALENESS"`
+}
+
+//Enabled return true when routeGroup should verify access token locally
+func (s StatelessAuthConfig) Enabled(routeGroup string) bool {
+	for _, group := range s.RouteGroups {
+		if group == routeGroup {
+			return true
+		}
+	}
+	return false
+}
+
+type LoginAlertConfig struct {
+	Enabled    bool   `yaml:"enabled" envconfig:"LOGIN_ALERT_ENABLED"`
+	History    int    `yaml:"history" envconfig:"LOGIN_ALERT_HISTORY"`
+	DenialLink string `yaml:"denial_link" envconfig:"LOGIN_ALERT_DENIAL_LINK"`
+}
+
+type AccountDeletionConfig struct {
+	GracePeriod   time.Duration `yaml:"grace_period" envconfig:"ACCOUNT_DELETION_GRACE_PERIOD"`
+	PurgeInterval time.Duration `yaml:"purge_interval" envconfig:"ACCOUNT_DELETION_PURGE_INTERVAL"`
+	RestoreLink   string        `yaml:"restore_link" envconfig:"ACCOUNT_DELETION_RESTORE_LINK"`
+}
+
+type DataExportConfig struct {
+	DownloadLink   string        `yaml:"download_link" envconfig:"DATA_EXPORT_DOWNLOAD_LINK"`
+	LinkExpiration time.Duration `yaml:"link_expiration" envconfig:"DATA_EXPORT_LINK_EXPIRATION"`
+	QueueSize      int           `yaml:"queue_size" envconfig:"DATA_EXPORT_QUEUE_SIZE"`
+	Interval       time.Duration `yaml:"interval" envconfig:"DATA_EXPORT_INTERVAL"`
+}
+
+type GeolocationConfig struct {
+	CityDatabase string `yaml:"city_database" envconfig:"GEOLOCATION_CITY_DATABASE"`
+	ASNDatabase  string `yaml:"asn_database" envconfig:"GEOLOCATION_ASN_DATABASE"`
+}
+
+type RiskConfig struct {
+	Enabled            bool    `yaml:"enabled" envconfig:"RISK_ENABLED"`
+	ChallengeThreshold int     `yaml:"challenge_threshold" envconfig:"RISK_CHALLENGE_THRESHOLD"`
+	BlockThreshold     int     `yaml:"block_threshold" envconfig:"RISK_BLOCK_THRESHOLD"`
+	MaxTravelSpeed     float64 `yaml:"max_travel_speed" envconfig:"RISK_MAX_TRAVEL_SPEED"`
+}
+
+type ClientConfig struct {
+	SigningKey    string `yaml:"signing_key" envconfig:"CLIENT_SIGNING_KEY"`
+	RejectUnknown bool   `yaml:"reject_unknown" envconfig:"CLIENT_REJECT_UNKNOWN"`
+}
+
+type RateLimitPolicy struct {
+	Key    string        `yaml:"key"`
+	Limit  int           `yaml:"limit"`
+	Burst  int           `yaml:"burst"`
+	Window time.Duration `yaml:"window"`
+}
+
+type RateLimitConfig struct {
+	Enabled  bool                       `yaml:"enabled" envconfig:"RATE_LIMIT_ENABLED"`
+	Policies map[string]RateLimitPolicy `yaml:"policies" ignored:"true"`
+}
+
 func Build(yamlPath, envPrefix string) (*Configuration, error) {
 	var cfg Configuration
 	f, err := os.Open(yamlPath)
@@ -96,6 +238,14 @@ func Build(yamlPath, envPrefix string) (*Configuration, error) {
 		return nil, errors.Wrap(err, "envconfig.Process(envPrefix, &cfg.Postgres) err")
 	}
 
+	if err := envconfig.Process(envPrefix, &cfg.Database); err != nil {
+		return nil, errors.Wrap(err, "envconfig.Process(envPrefix, &cfg.Database) err")
+	}
+
+	if err := envconfig.Process(envPrefix, &cfg.SQLite); err != nil {
+		return nil, errors.Wrap(err, "envconfig.Process(envPrefix, &cfg.SQLite) err")
+	}
+
 	if err := envconfig.Process(envPrefix, &cfg.Mailjet); err != nil {
 		return nil, errors.Wrap(err, "envconfig.Process(envPrefix, &cfg.Mailjet) err")
 	}
@@ -108,5 +258,74 @@ func Build(yamlPath, envPrefix string) (*Configuration, error) {
 		return nil, errors.Wrap(err, "envconfig.Process(envPrefix, &cfg.Log) err")
 	}
 
+	if err := envconfig.Process(envPrefix, &cfg.SAML); err != nil {
+		return nil, errors.Wrap(err, "envconfig.Process(envPrefix, &cfg.SAML) err")
+	}
+
+	if err := envconfig.Process(envPrefix, &cfg.TrustedDevice); err != nil {
+		return nil, errors.Wrap(err, "envconfig.Process(envPrefix, &cfg.TrustedDevice) err")
+	}
+
+	if err := envconfig.Process(envPrefix, &cfg.TFA); err != nil {
+		return nil, errors.Wrap(err, "envconfig.Process(envPrefix, &cfg.TFA) err")
+	}
+	// backup codes are generated as OTP, a longer code would fail every TFA activation
+	if cfg.TFA.BackupCodeLength < 0 || cfg.TFA.BackupCodeLength > security.MaxOTPLength {
+		return nil, errors.Errorf("tfa.backup_code_length %d is out of range, it can be at most %d", cfg.TFA.BackupCodeLength, security.MaxOTPLength)
+	}
+
+	if err := envconfig.Process(envPrefix, &cfg.Session); err != nil {
+		return nil, errors.Wrap(err, "envconfig.Process(envPrefix, &cfg.Session) err")
+	}
+	// sessions kept in a sql table live in the configured database, there is no second connection for them
+	if storage := cfg.Session.Storage; storage == SessionStoragePostgres || storage == SessionStorageSQLite {
+		driver := cfg.Database.Driver
+		if driver == "" {
+			driver = DatabaseDriverPostgres
+		}
+		if storage != driver {
+			return nil, errors.Errorf("session.storage %q needs database.driver %q, got %q", storage, storage, driver)
+		}
+	}
+
+	if err := envconfig.Process(envPrefix, &cfg.StatelessAuth); err != nil {
+		return nil, errors.Wrap(err, "envconfig.Process(envPrefix, &cfg.StatelessAuth) err")
+	}
+
+	if err := envconfig.Process(envPrefix, &cfg.LoginAlert); err != nil {
+		return nil, errors.Wrap(err, "envconfig.Process(envPrefix, &cfg.LoginAlert) err")
+	}
+
+	if err := envconfig.Process(envPrefix, &cfg.Geolocation); err != nil {
+		return nil, errors.Wrap(err, "envconfig.Process(envPrefix, &cfg.Geolocation) err")
+	}
+
+	if err := envconfig.Process(envPrefix, &cfg.Risk); err != nil {
+		return nil, errors.Wrap(err, "envconfig.Process(envPrefix, &cfg.Risk) err")
+	}
+
+	if err := envconfig.Process(envPrefix, &cfg.Client); err != nil {
+		return nil, errors.Wrap(err, "envconfig.Process(envPrefix, &cfg.Client) err")
+	}
+	// client credentials are signed with their own key, sharing the access token secret would let one leak forge both
+	if cfg.Client.RejectUnknown && cfg.Client.SigningKey == "" {
+		return nil, errors.New("client.signing_key is required when client.reject_unknown is enabled")
+	}
+	if cfg.Client.SigningKey != "" && cfg.Client.SigningKey == cfg.JWTSecret {
+		return nil, errors.New("client.signing_key must differ from jwt_secret")
+	}
+
+	if err := envconfig.Process(envPrefix, &cfg.AccountDeletion); err != nil {
+		return nil, errors.Wrap(err, "envconfig.Process(envPrefix, &cfg.AccountDeletion) err")
+	}
+
+	if err := envconfig.Process(envPrefix, &cfg.DataExport); err != nil {
+		return nil, errors.Wrap(err, "envconfig.Process(envPrefix, &cfg.DataExport) err")
+	}
+
+	if err := envconfig.Process(envPrefix, &cfg.RateLimit); err != nil {
+		return nil, errors.Wrap(err, "envconfig.Process(envPrefix, &cfg.RateLimit) err")
+	}
+
 	return &cfg, nil
 }
@@ -2,6 +2,9 @@ package middlewares
 
 import (
 	"net/http"
+	"time"
+
+	"github.com/AdhityaRamadhanus/userland/pkg/common/http/middlewares"
 )
 
 var (
@@ -16,4 +19,14 @@ var (
 			next.ServeHTTP(res, req)
 		})
 	}
+
+	BypassWithMaxAge = func(next http.Handler, maxAge time.Duration) http.Handler {
+		return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			next.ServeHTTP(res, req)
+		})
+	}
+
+	BypassRateLimiter = func(route string) middlewares.Middleware {
+		return Bypass
+	}
 )
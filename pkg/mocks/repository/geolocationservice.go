@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"github.com/AdhityaRamadhanus/userland"
+)
+
+// GeolocationService locate ip addresses from Geolocations, any other ip is located at DefaultGeolocation
+type GeolocationService struct {
+	Geolocations       map[string]userland.Geolocation
+	DefaultGeolocation userland.Geolocation
+}
+
+func (m GeolocationService) Lookup(ip string) (userland.Geolocation, error) {
+	if geolocation, ok := m.Geolocations[ip]; ok {
+		return geolocation, nil
+	}
+
+	return m.DefaultGeolocation, nil
+}
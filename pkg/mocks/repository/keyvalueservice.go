@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"time"
 
 	"github.com/stretchr/testify/mock"
@@ -10,25 +11,25 @@ type KeyValueService struct {
 	mock.Mock
 }
 
-func (m KeyValueService) Set(key string, value []byte) error {
+func (m KeyValueService) Set(ctx context.Context, key string, value []byte) error {
 	args := m.Called(key, value)
 
 	return args.Get(0).(error)
 }
 
-func (m KeyValueService) SetEx(key string, value []byte, expirationInSeconds time.Duration) error {
+func (m KeyValueService) SetEx(ctx context.Context, key string, value []byte, expirationInSeconds time.Duration) error {
 	args := m.Called(key, value, expirationInSeconds)
 
 	return args.Get(0).(error)
 }
 
-func (m KeyValueService) Delete(key string) error {
+func (m KeyValueService) Delete(ctx context.Context, key string) error {
 	args := m.Called(key)
 
 	return args.Get(0).(error)
 }
 
-func (m KeyValueService) Get(key string) ([]byte, error) {
+func (m KeyValueService) Get(ctx context.Context, key string) ([]byte, error) {
 	args := m.Called(key)
 	if args.Get(1) == nil {
 		return args.Get(0).([]byte), nil
@@ -36,3 +37,27 @@ func (m KeyValueService) Get(key string) ([]byte, error) {
 
 	return nil, args.Get(1).(error)
 }
+
+func (m KeyValueService) Expire(ctx context.Context, key string, expiration time.Duration) error {
+	args := m.Called(key, expiration)
+
+	return args.Get(0).(error)
+}
+
+func (m KeyValueService) SetNX(ctx context.Context, key string, value []byte, expiration time.Duration) (bool, error) {
+	args := m.Called(key, value, expiration)
+	if args.Get(1) == nil {
+		return args.Bool(0), nil
+	}
+
+	return false, args.Get(1).(error)
+}
+
+func (m KeyValueService) Incr(ctx context.Context, key string, expiration time.Duration) (int64, error) {
+	args := m.Called(key, expiration)
+	if args.Get(1) == nil {
+		return args.Get(0).(int64), nil
+	}
+
+	return 0, args.Get(1).(error)
+}
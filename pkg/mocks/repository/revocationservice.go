@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/AdhityaRamadhanus/userland"
+	"github.com/stretchr/testify/mock"
+)
+
+type RevocationService struct {
+	mock.Mock
+}
+
+func (m RevocationService) Publish(ctx context.Context, revocation userland.SessionRevocation) error {
+	args := m.Called(revocation)
+
+	return args.Error(0)
+}
+
+func (m RevocationService) FindAllSince(ctx context.Context, since time.Time) (userland.SessionRevocations, error) {
+	args := m.Called(since)
+	if args.Get(1) == nil {
+		return args.Get(0).(userland.SessionRevocations), nil
+	}
+
+	return nil, args.Get(1).(error)
+}
+
+func (m RevocationService) Subscribe(ctx context.Context) (userland.RevocationSubscription, error) {
+	args := m.Called()
+	if args.Get(1) == nil {
+		return args.Get(0).(userland.RevocationSubscription), nil
+	}
+
+	return nil, args.Get(1).(error)
+}
+
+// RevocationSubscription feed revocations pushed to RevocationsChan
+type RevocationSubscription struct {
+	RevocationsChan chan userland.SessionRevocation
+}
+
+func (m RevocationSubscription) Revocations() <-chan userland.SessionRevocation {
+	return m.RevocationsChan
+}
+
+func (m RevocationSubscription) Close() error {
+	return nil
+}
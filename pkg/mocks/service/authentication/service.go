@@ -1,8 +1,11 @@
 package authentication
 
 import (
+	"context"
+
 	"github.com/AdhityaRamadhanus/userland"
 	"github.com/AdhityaRamadhanus/userland/pkg/common/security"
+	_authentication "github.com/AdhityaRamadhanus/userland/pkg/service/authentication"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -10,13 +13,13 @@ type AuthenticationService struct {
 	mock.Mock
 }
 
-func (m AuthenticationService) Register(user userland.User) error {
+func (m AuthenticationService) Register(ctx context.Context, user userland.User) error {
 	args := m.Called(user)
 
 	return args.Get(0).(error)
 }
 
-func (m AuthenticationService) RequestVerification(verificationType string, email string) (verificationID string, err error) {
+func (m AuthenticationService) RequestVerification(ctx context.Context, verificationType string, email string) (verificationID string, err error) {
 	args := m.Called(verificationType, email)
 
 	if args.Get(1) == nil {
@@ -26,14 +29,14 @@ func (m AuthenticationService) RequestVerification(verificationType string, emai
 	return "", args.Get(1).(error)
 }
 
-func (m AuthenticationService) VerifyAccount(verificationID string, email string, code string) error {
+func (m AuthenticationService) VerifyAccount(ctx context.Context, verificationID string, email string, code string) error {
 	args := m.Called(verificationID, email, code)
 
 	return args.Get(0).(error)
 }
 
-func (m AuthenticationService) Login(email, password string) (requireTFA bool, accessToken security.AccessToken, err error) {
-	args := m.Called(email, password)
+func (m AuthenticationService) Login(ctx context.Context, email, password string, options _authentication.LoginOptions) (requireTFA bool, accessToken security.AccessToken, err error) {
+	args := m.Called(email, password, options)
 
 	if args.Get(2) == nil {
 		return args.Get(0).(bool), args.Get(1).(security.AccessToken), nil
@@ -42,7 +45,7 @@ func (m AuthenticationService) Login(email, password string) (requireTFA bool, a
 	return true, security.AccessToken{}, args.Get(2).(error)
 }
 
-func (m AuthenticationService) VerifyTFA(tfaToken string, userID int, code string) (accessToken security.AccessToken, err error) {
+func (m AuthenticationService) VerifyTFA(ctx context.Context, tfaToken string, userID int, code string) (accessToken security.AccessToken, err error) {
 	args := m.Called(tfaToken, userID, code)
 
 	if args.Get(1) == nil {
@@ -52,7 +55,17 @@ func (m AuthenticationService) VerifyTFA(tfaToken string, userID int, code strin
 	return security.AccessToken{}, args.Get(1).(error)
 }
 
-func (m AuthenticationService) VerifyTFABypass(tfaToken string, userID int, code string) (accessToken security.AccessToken, err error) {
+func (m AuthenticationService) TrustDevice(ctx context.Context, userID int, device userland.TrustedDevice) (deviceToken string, trustedDevice userland.TrustedDevice, err error) {
+	args := m.Called(userID, device)
+
+	if args.Get(2) == nil {
+		return args.Get(0).(string), args.Get(1).(userland.TrustedDevice), nil
+	}
+
+	return "", userland.TrustedDevice{}, args.Get(2).(error)
+}
+
+func (m AuthenticationService) VerifyTFABypass(ctx context.Context, tfaToken string, userID int, code string) (accessToken security.AccessToken, err error) {
 	args := m.Called(tfaToken, userID, code)
 
 	if args.Get(1) == nil {
@@ -62,7 +75,7 @@ func (m AuthenticationService) VerifyTFABypass(tfaToken string, userID int, code
 	return security.AccessToken{}, args.Get(1).(error)
 }
 
-func (m AuthenticationService) ForgotPassword(email string) (verificationID string, err error) {
+func (m AuthenticationService) ForgotPassword(ctx context.Context, email string) (verificationID string, err error) {
 	args := m.Called(email)
 
 	if args.Get(1) == nil {
@@ -72,8 +85,38 @@ func (m AuthenticationService) ForgotPassword(email string) (verificationID stri
 	return "", args.Get(1).(error)
 }
 
-func (m AuthenticationService) ResetPassword(forgotPassToken string, newPassword string) error {
+func (m AuthenticationService) ResetPassword(ctx context.Context, forgotPassToken string, newPassword string) (userID int, err error) {
 	args := m.Called(forgotPassToken, newPassword)
 
+	if args.Get(1) == nil {
+		return args.Int(0), nil
+	}
+
+	return 0, args.Get(1).(error)
+}
+
+func (m AuthenticationService) RequestReauthentication(ctx context.Context, userID int, sessionID string) error {
+	args := m.Called(userID, sessionID)
+
 	return args.Get(0).(error)
 }
+
+func (m AuthenticationService) Reauthenticate(ctx context.Context, userID int, sessionID string, method string, credential string) (accessToken security.AccessToken, err error) {
+	args := m.Called(userID, sessionID, method, credential)
+
+	if args.Get(1) == nil {
+		return args.Get(0).(security.AccessToken), nil
+	}
+
+	return security.AccessToken{}, args.Get(1).(error)
+}
+
+func (m AuthenticationService) DenySignIn(ctx context.Context, token string) (userID int, err error) {
+	args := m.Called(token)
+
+	if args.Get(1) == nil {
+		return args.Int(0), nil
+	}
+
+	return 0, args.Get(1).(error)
+}
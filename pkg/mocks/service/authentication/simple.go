@@ -1,50 +1,73 @@
 package authentication
 
 import (
+	"context"
+
 	"github.com/AdhityaRamadhanus/userland"
 	"github.com/AdhityaRamadhanus/userland/pkg/common/security"
+	_authentication "github.com/AdhityaRamadhanus/userland/pkg/service/authentication"
 )
 
 type SimpleAuthenticationService struct {
 	CalledMethods map[string]bool
 }
 
-func (m SimpleAuthenticationService) Register(user userland.User) error {
+func (m SimpleAuthenticationService) Register(ctx context.Context, user userland.User) error {
 	m.CalledMethods["Register"] = true
 	return nil
 }
 
-func (m SimpleAuthenticationService) RequestVerification(verificationType string, email string) (verificationID string, err error) {
+func (m SimpleAuthenticationService) RequestVerification(ctx context.Context, verificationType string, email string) (verificationID string, err error) {
 	m.CalledMethods["RequestVerification"] = true
 	return "", nil
 }
 
-func (m SimpleAuthenticationService) VerifyAccount(verificationID string, email string, code string) error {
+func (m SimpleAuthenticationService) VerifyAccount(ctx context.Context, verificationID string, email string, code string) error {
 	m.CalledMethods["VerifyAccount"] = true
 	return nil
 }
 
-func (m SimpleAuthenticationService) Login(email, password string) (requireTFA bool, accessToken security.AccessToken, err error) {
+func (m SimpleAuthenticationService) Login(ctx context.Context, email, password string, options _authentication.LoginOptions) (requireTFA bool, accessToken security.AccessToken, err error) {
 	m.CalledMethods["Login"] = true
 	return false, security.AccessToken{}, nil
 }
 
-func (m SimpleAuthenticationService) VerifyTFA(tfaToken string, userID int, code string) (accessToken security.AccessToken, err error) {
+func (m SimpleAuthenticationService) VerifyTFA(ctx context.Context, tfaToken string, userID int, code string) (accessToken security.AccessToken, err error) {
 	m.CalledMethods["VerifyTFA"] = true
 	return security.AccessToken{}, nil
 }
 
-func (m SimpleAuthenticationService) VerifyTFABypass(tfaToken string, userID int, code string) (accessToken security.AccessToken, err error) {
+func (m SimpleAuthenticationService) TrustDevice(ctx context.Context, userID int, device userland.TrustedDevice) (deviceToken string, trustedDevice userland.TrustedDevice, err error) {
+	m.CalledMethods["TrustDevice"] = true
+	return "", device, nil
+}
+
+func (m SimpleAuthenticationService) VerifyTFABypass(ctx context.Context, tfaToken string, userID int, code string) (accessToken security.AccessToken, err error) {
 	m.CalledMethods["VerifyTFABypass"] = true
 	return security.AccessToken{}, nil
 }
 
-func (m SimpleAuthenticationService) ForgotPassword(email string) (verificationID string, err error) {
+func (m SimpleAuthenticationService) ForgotPassword(ctx context.Context, email string) (verificationID string, err error) {
 	m.CalledMethods["ForgotPassword"] = true
 	return "", nil
 }
 
-func (m SimpleAuthenticationService) ResetPassword(forgotPassToken string, newPassword string) error {
+func (m SimpleAuthenticationService) ResetPassword(ctx context.Context, forgotPassToken string, newPassword string) (userID int, err error) {
 	m.CalledMethods["ResetPassword"] = true
+	return 0, nil
+}
+
+func (m SimpleAuthenticationService) RequestReauthentication(ctx context.Context, userID int, sessionID string) error {
+	m.CalledMethods["RequestReauthentication"] = true
 	return nil
 }
+
+func (m SimpleAuthenticationService) Reauthenticate(ctx context.Context, userID int, sessionID string, method string, credential string) (accessToken security.AccessToken, err error) {
+	m.CalledMethods["Reauthenticate"] = true
+	return security.AccessToken{}, nil
+}
+
+func (m SimpleAuthenticationService) DenySignIn(ctx context.Context, token string) (userID int, err error) {
+	m.CalledMethods["DenySignIn"] = true
+	return 0, nil
+}
@@ -0,0 +1,62 @@
+package client
+
+import (
+	"context"
+
+	"github.com/AdhityaRamadhanus/userland"
+	"github.com/stretchr/testify/mock"
+)
+
+type ClientService struct {
+	mock.Mock
+}
+
+func (m ClientService) RegisterClient(ctx context.Context, client userland.Client) (userland.Client, string, string, error) {
+	args := m.Called(client)
+
+	if args.Get(3) == nil {
+		return args.Get(0).(userland.Client), args.String(1), args.String(2), nil
+	}
+
+	return userland.Client{}, "", "", args.Get(3).(error)
+}
+
+func (m ClientService) ListClients(ctx context.Context) (userland.Clients, error) {
+	args := m.Called()
+
+	if args.Get(1) == nil {
+		return args.Get(0).(userland.Clients), nil
+	}
+
+	return nil, args.Get(1).(error)
+}
+
+func (m ClientService) GetClient(ctx context.Context, clientID int) (userland.Client, error) {
+	args := m.Called(clientID)
+
+	if args.Get(1) == nil {
+		return args.Get(0).(userland.Client), nil
+	}
+
+	return userland.Client{}, args.Get(1).(error)
+}
+
+func (m ClientService) UpdateClient(ctx context.Context, client userland.Client) (userland.Client, error) {
+	args := m.Called(client)
+
+	if args.Get(1) == nil {
+		return args.Get(0).(userland.Client), nil
+	}
+
+	return userland.Client{}, args.Get(1).(error)
+}
+
+func (m ClientService) VerifyClient(ctx context.Context, credential string, secret string, origin string) (userland.Client, error) {
+	args := m.Called(credential, secret, origin)
+
+	if args.Get(1) == nil {
+		return args.Get(0).(userland.Client), nil
+	}
+
+	return userland.Client{}, args.Get(1).(error)
+}
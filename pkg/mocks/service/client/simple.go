@@ -0,0 +1,41 @@
+package client
+
+import (
+	"context"
+
+	"github.com/AdhityaRamadhanus/userland"
+)
+
+type SimpleClientService struct {
+	CalledMethods map[string]bool
+}
+
+func (m SimpleClientService) RegisterClient(ctx context.Context, client userland.Client) (userland.Client, string, string, error) {
+	m.CalledMethods["RegisterClient"] = true
+
+	return client, "", "", nil
+}
+
+func (m SimpleClientService) ListClients(ctx context.Context) (userland.Clients, error) {
+	m.CalledMethods["ListClients"] = true
+
+	return userland.Clients{}, nil
+}
+
+func (m SimpleClientService) GetClient(ctx context.Context, clientID int) (userland.Client, error) {
+	m.CalledMethods["GetClient"] = true
+
+	return userland.Client{ID: clientID}, nil
+}
+
+func (m SimpleClientService) UpdateClient(ctx context.Context, client userland.Client) (userland.Client, error) {
+	m.CalledMethods["UpdateClient"] = true
+
+	return client, nil
+}
+
+func (m SimpleClientService) VerifyClient(ctx context.Context, credential string, secret string, origin string) (userland.Client, error) {
+	m.CalledMethods["VerifyClient"] = true
+
+	return userland.Client{}, nil
+}
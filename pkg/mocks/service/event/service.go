@@ -1,6 +1,8 @@
 package event
 
 import (
+	"context"
+
 	"github.com/stretchr/testify/mock"
 )
 
@@ -8,8 +10,14 @@ type EventService struct {
 	mock.Mock
 }
 
-func (m EventService) Log(eventName string, userID int, clientInfo map[string]interface{}) error {
+func (m EventService) Log(ctx context.Context, eventName string, userID int, clientInfo map[string]interface{}) error {
 	args := m.Called(eventName, userID, clientInfo)
 
 	return args.Get(0).(error)
 }
+
+func (m EventService) LogChanges(ctx context.Context, eventName string, userID int, clientInfo map[string]interface{}, changedFields []string) error {
+	args := m.Called(eventName, userID, clientInfo, changedFields)
+
+	return args.Get(0).(error)
+}
@@ -20,3 +20,9 @@ func (m MailingService) SendVerificationEmail(recipient mailing.MailAddress, ver
 
 	return args.Get(0).(error)
 }
+
+func (m MailingService) SendNoticeEmail(recipient mailing.MailAddress, subject string, message string, actionLink string) error {
+	args := m.Called(recipient, subject, message, actionLink)
+
+	return args.Get(0).(error)
+}
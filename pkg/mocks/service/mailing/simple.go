@@ -17,3 +17,8 @@ func (m SimpleMailingService) SendVerificationEmail(recipient mailing.MailAddres
 	m.CalledMethods["SendVerificationEmail"] = true
 	return nil
 }
+
+func (m SimpleMailingService) SendNoticeEmail(recipient mailing.MailAddress, subject string, message string, actionLink string) error {
+	m.CalledMethods["SendNoticeEmail"] = true
+	return nil
+}
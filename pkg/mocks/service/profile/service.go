@@ -1,6 +1,7 @@
 package profile
 
 import (
+	"context"
 	"io"
 
 	"github.com/AdhityaRamadhanus/userland"
@@ -11,7 +12,7 @@ type ProfileService struct {
 	mock.Mock
 }
 
-func (m ProfileService) ProfileByEmail(email string) (userland.User, error) {
+func (m ProfileService) ProfileByEmail(ctx context.Context, email string) (userland.User, error) {
 	args := m.Called(email)
 
 	if args.Get(1) == nil {
@@ -21,7 +22,7 @@ func (m ProfileService) ProfileByEmail(email string) (userland.User, error) {
 	return userland.User{}, args.Get(1).(error)
 }
 
-func (m ProfileService) Profile(userID int) (userland.User, error) {
+func (m ProfileService) Profile(ctx context.Context, userID int) (userland.User, error) {
 	args := m.Called(userID)
 
 	if args.Get(1) == nil {
@@ -31,19 +32,25 @@ func (m ProfileService) Profile(userID int) (userland.User, error) {
 	return userland.User{}, args.Get(1).(error)
 }
 
-func (m ProfileService) SetProfile(user userland.User) error {
+func (m ProfileService) SetProfile(ctx context.Context, user userland.User) error {
 	args := m.Called(user)
 
-	return args.Get(0).(error)
+	return args.Error(0)
+}
+
+func (m ProfileService) UpdateProfile(ctx context.Context, userID int, version int, patch userland.ProfilePatch) error {
+	args := m.Called(userID, version, patch)
+
+	return args.Error(0)
 }
 
-func (m ProfileService) SetProfilePicture(user userland.User, image io.Reader) error {
+func (m ProfileService) SetProfilePicture(ctx context.Context, user userland.User, image io.Reader) error {
 	args := m.Called(user)
 
 	return args.Get(0).(error)
 }
 
-func (m ProfileService) RequestChangeEmail(user userland.User, newEmail string) (verificationID string, err error) {
+func (m ProfileService) RequestChangeEmail(ctx context.Context, user userland.User, newEmail string) (verificationID string, err error) {
 	args := m.Called(user, newEmail)
 
 	if args.Get(1) == nil {
@@ -53,19 +60,19 @@ func (m ProfileService) RequestChangeEmail(user userland.User, newEmail string)
 	return "", args.Get(1).(error)
 }
 
-func (m ProfileService) ChangeEmail(user userland.User, verificationID string) error {
+func (m ProfileService) ChangeEmail(ctx context.Context, user userland.User, verificationID string) error {
 	args := m.Called(user, verificationID)
 
 	return args.Get(0).(error)
 }
 
-func (m ProfileService) ChangePassword(user userland.User, oldPassword, newPassword string) error {
+func (m ProfileService) ChangePassword(ctx context.Context, user userland.User, oldPassword, newPassword string) error {
 	args := m.Called(user, oldPassword, newPassword)
 
 	return args.Get(0).(error)
 }
 
-func (m ProfileService) EnrollTFA(user userland.User) (secret string, qrcodeImageBase64 string, err error) {
+func (m ProfileService) EnrollTFA(ctx context.Context, user userland.User) (secret string, qrcodeImageBase64 string, err error) {
 	args := m.Called(user)
 
 	if args.Get(2) == nil {
@@ -75,7 +82,7 @@ func (m ProfileService) EnrollTFA(user userland.User) (secret string, qrcodeImag
 	return "", "", args.Get(2).(error)
 }
 
-func (m ProfileService) ActivateTFA(user userland.User, secret string, code string) ([]string, error) {
+func (m ProfileService) ActivateTFA(ctx context.Context, user userland.User, secret string, code string) ([]string, error) {
 	args := m.Called(user, secret, code)
 
 	if args.Get(1) == nil {
@@ -85,19 +92,85 @@ func (m ProfileService) ActivateTFA(user userland.User, secret string, code stri
 	return nil, args.Get(1).(error)
 }
 
-func (m ProfileService) RemoveTFA(user userland.User, currPassword string) error {
+func (m ProfileService) RemoveTFA(ctx context.Context, user userland.User, currPassword string) error {
 	args := m.Called(user)
 
 	return args.Get(0).(error)
 }
 
-func (m ProfileService) DeleteAccount(user userland.User, currPassword string) error {
+func (m ProfileService) RegenerateBackupCodes(ctx context.Context, user userland.User) ([]string, error) {
+	args := m.Called(user)
+
+	if args.Get(1) == nil {
+		return args.Get(0).([]string), nil
+	}
+
+	return nil, args.Get(1).(error)
+}
+
+func (m ProfileService) DeleteAccount(ctx context.Context, user userland.User, currPassword string) error {
 	args := m.Called(user)
 
 	return args.Get(0).(error)
 }
 
-func (m ProfileService) ListEvents(user userland.User, pagingOptions userland.EventPagingOptions) (userland.Events, int, error) {
+func (m ProfileService) RestoreAccount(ctx context.Context, token string) (int, error) {
+	args := m.Called(token)
+
+	if args.Get(1) == nil {
+		return args.Get(0).(int), nil
+	}
+
+	return 0, args.Get(1).(error)
+}
+
+func (m ProfileService) PurgeDeletedAccounts(ctx context.Context) (int, error) {
+	args := m.Called()
+
+	if args.Get(1) == nil {
+		return args.Get(0).(int), nil
+	}
+
+	return 0, args.Get(1).(error)
+}
+
+func (m ProfileService) RequestExport(ctx context.Context, userID int) error {
+	args := m.Called(userID)
+
+	return args.Error(0)
+}
+
+func (m ProfileService) ExportPendingData(ctx context.Context) (int, error) {
+	args := m.Called()
+
+	if args.Get(1) == nil {
+		return args.Get(0).(int), nil
+	}
+
+	return 0, args.Get(1).(error)
+}
+
+func (m ProfileService) PurgeExpiredExports(ctx context.Context) (int, error) {
+	args := m.Called()
+
+	if args.Get(1) == nil {
+		return args.Get(0).(int), nil
+	}
+
+	return 0, args.Get(1).(error)
+}
+
+func (m ProfileService) DownloadExport(ctx context.Context, token string) ([]byte, error) {
+	args := m.Called(token)
+
+	if args.Get(1) == nil {
+		return args.Get(0).([]byte), nil
+	}
+
+	return nil, args.Get(1).(error)
+}
+
+func (m ProfileService) ListEvents(ctx context.Context, user userland.User, pagingOptions userland.EventPagingOptions) (userland.Events, int, error) {
 	args := m.Called(user, pagingOptions)
 
 	if args.Get(1) == nil {
@@ -1,6 +1,7 @@
 package profile
 
 import (
+	"context"
 	"io"
 
 	"github.com/AdhityaRamadhanus/userland"
@@ -10,62 +11,102 @@ type SimpleProfileService struct {
 	CalledMethods map[string]bool
 }
 
-func (m SimpleProfileService) ProfileByEmail(email string) (userland.User, error) {
+func (m SimpleProfileService) ProfileByEmail(ctx context.Context, email string) (userland.User, error) {
 	m.CalledMethods["ProfileByEmail"] = true
 	return userland.User{}, nil
 }
 
-func (m SimpleProfileService) Profile(userID int) (userland.User, error) {
+func (m SimpleProfileService) Profile(ctx context.Context, userID int) (userland.User, error) {
 	m.CalledMethods["Profile"] = true
 	return userland.User{}, nil
 }
 
-func (m SimpleProfileService) SetProfile(user userland.User) error {
+func (m SimpleProfileService) SetProfile(ctx context.Context, user userland.User) error {
 	m.CalledMethods["SetProfile"] = true
 	return nil
 }
 
-func (m SimpleProfileService) SetProfilePicture(user userland.User, image io.Reader) error {
+func (m SimpleProfileService) UpdateProfile(ctx context.Context, userID int, version int, patch userland.ProfilePatch) error {
+	m.CalledMethods["UpdateProfile"] = true
+	return nil
+}
+
+func (m SimpleProfileService) SetProfilePicture(ctx context.Context, user userland.User, image io.Reader) error {
 	m.CalledMethods["SetProfilePicture"] = true
 	return nil
 }
 
-func (m SimpleProfileService) RequestChangeEmail(user userland.User, newEmail string) (verificationID string, err error) {
+func (m SimpleProfileService) RequestChangeEmail(ctx context.Context, user userland.User, newEmail string) (verificationID string, err error) {
 	m.CalledMethods["RequestChangeEmail"] = true
 	return "", nil
 }
 
-func (m SimpleProfileService) ChangeEmail(user userland.User, verificationID string) error {
+func (m SimpleProfileService) ChangeEmail(ctx context.Context, user userland.User, verificationID string) error {
 	m.CalledMethods["ChangeEmail"] = true
 	return nil
 }
 
-func (m SimpleProfileService) ChangePassword(user userland.User, oldPassword, newPassword string) error {
+func (m SimpleProfileService) ChangePassword(ctx context.Context, user userland.User, oldPassword, newPassword string) error {
 	m.CalledMethods["ChangePassword"] = true
 	return nil
 }
 
-func (m SimpleProfileService) EnrollTFA(user userland.User) (secret string, qrcodeImageBase64 string, err error) {
+func (m SimpleProfileService) EnrollTFA(ctx context.Context, user userland.User) (secret string, qrcodeImageBase64 string, err error) {
 	m.CalledMethods["EnrollTFA"] = true
 	return "", "", nil
 }
 
-func (m SimpleProfileService) ActivateTFA(user userland.User, secret string, code string) ([]string, error) {
+func (m SimpleProfileService) ActivateTFA(ctx context.Context, user userland.User, secret string, code string) ([]string, error) {
 	m.CalledMethods["ActivateTFA"] = true
 	return []string{}, nil
 }
 
-func (m SimpleProfileService) RemoveTFA(user userland.User, currPassword string) error {
+func (m SimpleProfileService) RemoveTFA(ctx context.Context, user userland.User, currPassword string) error {
 	m.CalledMethods["RemoveTFA"] = true
 	return nil
 }
 
-func (m SimpleProfileService) DeleteAccount(user userland.User, currPassword string) error {
+func (m SimpleProfileService) RegenerateBackupCodes(ctx context.Context, user userland.User) ([]string, error) {
+	m.CalledMethods["RegenerateBackupCodes"] = true
+	return []string{}, nil
+}
+
+func (m SimpleProfileService) DeleteAccount(ctx context.Context, user userland.User, currPassword string) error {
 	m.CalledMethods["DeleteAccount"] = true
 	return nil
 }
 
-func (m SimpleProfileService) ListEvents(user userland.User, pagingOptions userland.EventPagingOptions) (userland.Events, int, error) {
+func (m SimpleProfileService) RestoreAccount(ctx context.Context, token string) (int, error) {
+	m.CalledMethods["RestoreAccount"] = true
+	return 0, nil
+}
+
+func (m SimpleProfileService) PurgeDeletedAccounts(ctx context.Context) (int, error) {
+	m.CalledMethods["PurgeDeletedAccounts"] = true
+	return 0, nil
+}
+
+func (m SimpleProfileService) RequestExport(ctx context.Context, userID int) error {
+	m.CalledMethods["RequestExport"] = true
+	return nil
+}
+
+func (m SimpleProfileService) ExportPendingData(ctx context.Context) (int, error) {
+	m.CalledMethods["ExportPendingData"] = true
+	return 0, nil
+}
+
+func (m SimpleProfileService) PurgeExpiredExports(ctx context.Context) (int, error) {
+	m.CalledMethods["PurgeExpiredExports"] = true
+	return 0, nil
+}
+
+func (m SimpleProfileService) DownloadExport(ctx context.Context, token string) ([]byte, error) {
+	m.CalledMethods["DownloadExport"] = true
+	return []byte{}, nil
+}
+
+func (m SimpleProfileService) ListEvents(ctx context.Context, user userland.User, pagingOptions userland.EventPagingOptions) (userland.Events, int, error) {
 	m.CalledMethods["ListEvents"] = true
 	return userland.Events{}, 0, nil
 }
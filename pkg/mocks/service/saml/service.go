@@ -0,0 +1,43 @@
+package saml
+
+import (
+	"context"
+
+	"github.com/AdhityaRamadhanus/userland"
+	"github.com/AdhityaRamadhanus/userland/pkg/common/security"
+	"github.com/stretchr/testify/mock"
+)
+
+type SAMLService struct {
+	mock.Mock
+}
+
+func (m SAMLService) ImportIdentityProvider(ctx context.Context, tenant string, metadata []byte, attributeMapping map[string]string, domains []string) (userland.IdentityProvider, error) {
+	args := m.Called(tenant, metadata, attributeMapping, domains)
+
+	if args.Get(1) == nil {
+		return args.Get(0).(userland.IdentityProvider), nil
+	}
+
+	return userland.IdentityProvider{}, args.Get(1).(error)
+}
+
+func (m SAMLService) ServiceProviderMetadata(ctx context.Context, tenant string) ([]byte, error) {
+	args := m.Called(tenant)
+
+	if args.Get(1) == nil {
+		return args.Get(0).([]byte), nil
+	}
+
+	return nil, args.Get(1).(error)
+}
+
+func (m SAMLService) ConsumeAssertion(ctx context.Context, tenant string, samlResponse string) (userland.User, bool, security.AccessToken, error) {
+	args := m.Called(tenant, samlResponse)
+
+	if args.Get(3) == nil {
+		return args.Get(0).(userland.User), args.Bool(1), args.Get(2).(security.AccessToken), nil
+	}
+
+	return userland.User{}, false, security.AccessToken{}, args.Get(3).(error)
+}
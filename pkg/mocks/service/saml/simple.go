@@ -0,0 +1,30 @@
+package saml
+
+import (
+	"context"
+
+	"github.com/AdhityaRamadhanus/userland"
+	"github.com/AdhityaRamadhanus/userland/pkg/common/security"
+)
+
+type SimpleSAMLService struct {
+	CalledMethods map[string]bool
+}
+
+func (m SimpleSAMLService) ImportIdentityProvider(ctx context.Context, tenant string, metadata []byte, attributeMapping map[string]string, domains []string) (userland.IdentityProvider, error) {
+	m.CalledMethods["ImportIdentityProvider"] = true
+
+	return userland.IdentityProvider{Tenant: tenant}, nil
+}
+
+func (m SimpleSAMLService) ServiceProviderMetadata(ctx context.Context, tenant string) ([]byte, error) {
+	m.CalledMethods["ServiceProviderMetadata"] = true
+
+	return []byte{}, nil
+}
+
+func (m SimpleSAMLService) ConsumeAssertion(ctx context.Context, tenant string, samlResponse string) (userland.User, bool, security.AccessToken, error) {
+	m.CalledMethods["ConsumeAssertion"] = true
+
+	return userland.User{}, false, security.AccessToken{}, nil
+}
@@ -1,6 +1,8 @@
 package session
 
 import (
+	"context"
+
 	"github.com/AdhityaRamadhanus/userland"
 	"github.com/AdhityaRamadhanus/userland/pkg/common/security"
 	"github.com/stretchr/testify/mock"
@@ -10,13 +12,13 @@ type SessionService struct {
 	mock.Mock
 }
 
-func (m SessionService) CreateSession(userID int, session userland.Session) error {
+func (m SessionService) CreateSession(ctx context.Context, userID int, session userland.Session) error {
 	args := m.Called(userID, session)
 
 	return args.Get(0).(error)
 }
 
-func (m SessionService) ListSession(userID int) (userland.Sessions, error) {
+func (m SessionService) ListSession(ctx context.Context, userID int) (userland.Sessions, error) {
 	args := m.Called(userID)
 
 	if args.Get(1) == nil {
@@ -26,20 +28,32 @@ func (m SessionService) ListSession(userID int) (userland.Sessions, error) {
 	return nil, args.Get(1).(error)
 }
 
-func (m SessionService) EndSession(userID int, currentSessionID string) error {
+func (m SessionService) TouchSession(ctx context.Context, userID int, sessionID string, ip string) error {
+	args := m.Called(userID, sessionID, ip)
+
+	return args.Get(0).(error)
+}
+
+func (m SessionService) EndSession(ctx context.Context, userID int, currentSessionID string) error {
 	args := m.Called(userID, currentSessionID)
 
 	return args.Get(0).(error)
 }
 
-func (m SessionService) EndOtherSessions(userID int, currentSessionID string) error {
+func (m SessionService) EndOtherSessions(ctx context.Context, userID int, currentSessionID string) error {
 	args := m.Called(userID, currentSessionID)
 
 	return args.Get(0).(error)
 }
 
-func (m SessionService) CreateRefreshToken(user userland.User, currentSessionID string) (security.AccessToken, error) {
-	args := m.Called(user, currentSessionID)
+func (m SessionService) EndRefreshedSession(ctx context.Context, userID int, previousSessionID string) error {
+	args := m.Called(userID, previousSessionID)
+
+	return args.Get(0).(error)
+}
+
+func (m SessionService) CreateRefreshToken(ctx context.Context, user userland.User, currentSessionID string, authentication security.Authentication) (security.AccessToken, error) {
+	args := m.Called(user, currentSessionID, authentication)
 
 	if args.Get(1) == nil {
 		return args.Get(0).(security.AccessToken), nil
@@ -48,8 +62,8 @@ func (m SessionService) CreateRefreshToken(user userland.User, currentSessionID
 	return security.AccessToken{}, args.Get(1).(error)
 }
 
-func (m SessionService) CreateNewAccessToken(user userland.User, refreshTokenID string) (security.AccessToken, error) {
-	args := m.Called(user, refreshTokenID)
+func (m SessionService) CreateNewAccessToken(ctx context.Context, user userland.User, refreshTokenID string, authentication security.Authentication) (security.AccessToken, error) {
+	args := m.Called(user, refreshTokenID, authentication)
 
 	if args.Get(1) == nil {
 		return args.Get(0).(security.AccessToken), nil
@@ -58,12 +72,35 @@ func (m SessionService) CreateNewAccessToken(user userland.User, refreshTokenID
 	return security.AccessToken{}, args.Get(1).(error)
 }
 
+func (m SessionService) ListTrustedDevices(ctx context.Context, userID int) (userland.TrustedDevices, error) {
+	args := m.Called(userID)
+
+	if args.Get(1) == nil {
+		return args.Get(0).(userland.TrustedDevices), nil
+	}
+
+	return nil, args.Get(1).(error)
+}
+
+func (m SessionService) RevokeTrustedDevice(ctx context.Context, userID int, deviceID string) error {
+	args := m.Called(userID, deviceID)
+
+	return args.Get(0).(error)
+}
+
+func (m SessionService) RevokeAllTrustedDevices(ctx context.Context, userID int) error {
+	args := m.Called(userID)
+
+	return args.Get(0).(error)
+}
+
 //Service provide an interface to story domain service
 // type Service interface {
 // 	CreateSession(userID int, session userland.Session) error
 // 	ListSession(userID int) (userland.Sessions, error)
+// 	TouchSession(userID int, sessionID string, ip string) error
 // 	EndSession(userID int, currentSessionID string) error
 // 	EndOtherSessions(userID int, currentSessionID string) error
-// 	CreateRefreshToken(user userland.User, currentSessionID string) (security.AccessToken, error)
-// 	CreateNewAccessToken(user userland.User, refreshTokenID string) (security.AccessToken, error)
+// 	CreateRefreshToken(user userland.User, currentSessionID string, authentication security.Authentication) (security.AccessToken, error)
+// 	CreateNewAccessToken(user userland.User, refreshTokenID string, authentication security.Authentication) (security.AccessToken, error)
 // }
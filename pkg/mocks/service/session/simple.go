@@ -1,6 +1,8 @@
 package session
 
 import (
+	"context"
+
 	"github.com/AdhityaRamadhanus/userland"
 	"github.com/AdhityaRamadhanus/userland/pkg/common/security"
 )
@@ -9,38 +11,68 @@ type SimpleSessionService struct {
 	CalledMethods map[string]bool
 }
 
-func (m SimpleSessionService) CreateSession(userID int, session userland.Session) error {
+func (m SimpleSessionService) CreateSession(ctx context.Context, userID int, session userland.Session) error {
 	m.CalledMethods["CreateSession"] = true
 
 	return nil
 }
 
-func (m SimpleSessionService) ListSession(userID int) (userland.Sessions, error) {
+func (m SimpleSessionService) ListSession(ctx context.Context, userID int) (userland.Sessions, error) {
 	m.CalledMethods["ListSession"] = true
 
 	return userland.Sessions{}, nil
 }
 
-func (m SimpleSessionService) EndSession(userID int, currentSessionID string) error {
+func (m SimpleSessionService) TouchSession(ctx context.Context, userID int, sessionID string, ip string) error {
+	m.CalledMethods["TouchSession"] = true
+
+	return nil
+}
+
+func (m SimpleSessionService) EndSession(ctx context.Context, userID int, currentSessionID string) error {
 	m.CalledMethods["EndSession"] = true
 
 	return nil
 }
 
-func (m SimpleSessionService) EndOtherSessions(userID int, currentSessionID string) error {
+func (m SimpleSessionService) EndOtherSessions(ctx context.Context, userID int, currentSessionID string) error {
 	m.CalledMethods["EndOtherSessions"] = true
 
 	return nil
 }
 
-func (m SimpleSessionService) CreateRefreshToken(user userland.User, currentSessionID string) (security.AccessToken, error) {
+func (m SimpleSessionService) EndRefreshedSession(ctx context.Context, userID int, previousSessionID string) error {
+	m.CalledMethods["EndRefreshedSession"] = true
+
+	return nil
+}
+
+func (m SimpleSessionService) CreateRefreshToken(ctx context.Context, user userland.User, currentSessionID string, authentication security.Authentication) (security.AccessToken, error) {
 	m.CalledMethods["CreateRefreshToken"] = true
 
 	return security.AccessToken{}, nil
 }
 
-func (m SimpleSessionService) CreateNewAccessToken(user userland.User, refreshTokenID string) (security.AccessToken, error) {
+func (m SimpleSessionService) CreateNewAccessToken(ctx context.Context, user userland.User, refreshTokenID string, authentication security.Authentication) (security.AccessToken, error) {
 	m.CalledMethods["CreateNewAccessToken"] = true
 
 	return security.AccessToken{}, nil
 }
+
+func (m SimpleSessionService) ListTrustedDevices(ctx context.Context, userID int) (userland.TrustedDevices, error) {
+	m.CalledMethods["ListTrustedDevices"] = true
+
+	return userland.TrustedDevices{}, nil
+}
+
+func (m SimpleSessionService) RevokeTrustedDevice(ctx context.Context, userID int, deviceID string) error {
+	m.CalledMethods["RevokeTrustedDevice"] = true
+
+	return nil
+}
+
+func (m SimpleSessionService) RevokeAllTrustedDevices(ctx context.Context, userID int) error {
+	m.CalledMethods["RevokeAllTrustedDevices"] = true
+
+	return nil
+}
@@ -10,3 +10,8 @@ import (
 type Handler interface {
 	RegisterRoutes(router *mux.Router)
 }
+
+//StreamingHandler is implemented by handler with long lived responses, its streaming routes skip gzip and the write timeout
+type StreamingHandler interface {
+	RegisterStreamingRoutes(router *mux.Router)
+}
@@ -5,7 +5,6 @@ import (
 	"io"
 	"io/ioutil"
 	"net/http"
-	"time"
 
 	"github.com/AdhityaRamadhanus/userland/pkg/server/api/serializers"
 
@@ -24,12 +23,15 @@ import (
 
 type AuthenticationHandler struct {
 	Authenticator         middlewares.Middleware
-	RateLimiter           middlewares.MiddlewareWithArgs
+	RateLimiter           middlewares.RateLimiter
+	ClientVerification    middlewares.Middleware
 	Authorization         middlewares.MiddlewareWithArgs
 	AuthenticationService authentication.Service
 	SessionService        session.Service
 	ProfileService        profile.Service
 	EventService          event.Service
+	// ExposeJWT add the signed JWT to issued access tokens, for route groups with stateless authentication
+	ExposeJWT bool
 }
 
 func (h AuthenticationHandler) RegisterRoutes(router *mux.Router) {
@@ -38,15 +40,19 @@ func (h AuthenticationHandler) RegisterRoutes(router *mux.Router) {
 	authenticate := h.Authenticator
 	authorize := h.Authorization
 	ratelimit := h.RateLimiter
-
-	registerUser := http.HandlerFunc(h.registerUser)
-	requestVerification := ratelimit(http.HandlerFunc(h.requestVerification), 10, time.Minute)
-	verifyAccount := http.HandlerFunc(h.verifyAccount)
-	login := http.HandlerFunc(h.login)
-	forgotPassword := ratelimit(http.HandlerFunc(h.forgotPassword), 10, time.Minute)
-	resetPassword := http.HandlerFunc(h.resetPassword)
-	verifyTFA := authenticate(authorize(http.HandlerFunc(h.verifyTFA), security.TFATokenScope))
-	verifyTFABypass := authenticate(authorize(http.HandlerFunc(h.verifyTFABypass), security.TFATokenScope))
+	requireClient := h.ClientVerification
+
+	registerUser := requireClient(ratelimit("register")(http.HandlerFunc(h.registerUser)))
+	requestVerification := requireClient(ratelimit("request_verification")(http.HandlerFunc(h.requestVerification)))
+	verifyAccount := requireClient(ratelimit("verify_account")(http.HandlerFunc(h.verifyAccount)))
+	login := requireClient(ratelimit("login")(http.HandlerFunc(h.login)))
+	forgotPassword := requireClient(ratelimit("forgot_password")(http.HandlerFunc(h.forgotPassword)))
+	resetPassword := requireClient(ratelimit("reset_password")(http.HandlerFunc(h.resetPassword)))
+	denySignIn := requireClient(ratelimit("deny_signin")(http.HandlerFunc(h.denySignIn)))
+	verifyTFA := requireClient(authenticate(authorize(ratelimit("verify_tfa")(http.HandlerFunc(h.verifyTFA)), security.TFATokenScope)))
+	verifyTFABypass := requireClient(authenticate(authorize(ratelimit("verify_tfa_bypass")(http.HandlerFunc(h.verifyTFABypass)), security.TFATokenScope)))
+	requestReauthentication := requireClient(authenticate(authorize(ratelimit("request_reauthentication")(http.HandlerFunc(h.requestReauthentication)), security.UserTokenScope)))
+	reauthenticate := requireClient(authenticate(authorize(ratelimit("reauthenticate")(http.HandlerFunc(h.reauthenticate)), security.UserTokenScope)))
 
 	subRouter.Handle("/auth/register", registerUser).Methods("POST")
 
@@ -58,8 +64,13 @@ func (h AuthenticationHandler) RegisterRoutes(router *mux.Router) {
 	subRouter.Handle("/auth/password/forgot", forgotPassword).Methods("POST")
 	subRouter.Handle("/auth/password/reset", resetPassword).Methods("POST")
 
+	subRouter.Handle("/auth/signin/deny", denySignIn).Methods("POST")
+
 	subRouter.Handle("/auth/tfa/verify", verifyTFA).Methods("POST")
 	subRouter.Handle("/auth/tfa/bypass", verifyTFABypass).Methods("POST")
+
+	subRouter.Handle("/auth/reauthenticate/otp", requestReauthentication).Methods("POST")
+	subRouter.Handle("/auth/reauthenticate", reauthenticate).Methods("POST")
 }
 
 func (h AuthenticationHandler) registerUser(res http.ResponseWriter, req *http.Request) {
@@ -105,7 +116,7 @@ func (h AuthenticationHandler) registerUser(res http.ResponseWriter, req *http.R
 		Password: registerUserRequest.Password,
 	}
 
-	if err = h.AuthenticationService.Register(newUser); err != nil {
+	if err = h.AuthenticationService.Register(req.Context(), newUser); err != nil {
 		handleServiceError(res, req, err)
 		return
 	}
@@ -144,7 +155,7 @@ func (h AuthenticationHandler) requestVerification(res http.ResponseWriter, req
 
 	verificationType := requestVerificationRequest.Type
 	verificationRecipient := requestVerificationRequest.Recipient
-	if _, err = h.AuthenticationService.RequestVerification(verificationType, verificationRecipient); err != nil {
+	if _, err = h.AuthenticationService.RequestVerification(req.Context(), verificationType, verificationRecipient); err != nil {
 		handleServiceError(res, req, err)
 		return
 	}
@@ -185,7 +196,7 @@ func (h AuthenticationHandler) verifyAccount(res http.ResponseWriter, req *http.
 	verificationID := verifyAccountRequest.VerificationID
 	email := verifyAccountRequest.Email
 	code := verifyAccountRequest.Code
-	if err = h.AuthenticationService.VerifyAccount(verificationID, email, code); err != nil {
+	if err = h.AuthenticationService.VerifyAccount(req.Context(), verificationID, email, code); err != nil {
 		handleServiceError(res, req, err)
 		return
 	}
@@ -203,8 +214,9 @@ func (h AuthenticationHandler) login(res http.ResponseWriter, req *http.Request)
 	}
 
 	loginRequest := struct {
-		Email    string `json:"email" valid:"required,email,stringlength(1|64)"`
-		Password string `json:"password" valid:"required,stringlength(6|128)"`
+		Email       string `json:"email" valid:"required,email,stringlength(1|64)"`
+		Password    string `json:"password" valid:"required,stringlength(6|128)"`
+		DeviceToken string `json:"device_token" valid:"stringlength(1|256)"`
 	}{}
 
 	// Deserialize
@@ -225,33 +237,44 @@ func (h AuthenticationHandler) login(res http.ResponseWriter, req *http.Request)
 
 	email := loginRequest.Email
 	password := loginRequest.Password
-	user, err := h.ProfileService.ProfileByEmail(email)
+	user, err := h.ProfileService.ProfileByEmail(req.Context(), email)
 	if err != nil {
 		handleServiceError(res, req, err)
 		return
 	}
 
-	requireTFA, accessToken, err := h.AuthenticationService.Login(email, password)
+	loginOptions := authentication.LoginOptions{
+		DeviceToken: loginRequest.DeviceToken,
+		UserAgent:   clientInfo["user_agent"].(string),
+		IP:          clientInfo["ip"].(string),
+		ClientID:    clientInfo["client_id"].(int),
+		ClientName:  clientInfo["client_name"].(string),
+	}
+	requireTFA, accessToken, err := h.AuthenticationService.Login(req.Context(), email, password, loginOptions)
 	if err != nil {
 		handleServiceError(res, req, err)
 		return
 	}
 
 	if !requireTFA {
-		h.SessionService.CreateSession(user.ID, userland.Session{
+		if err := h.SessionService.CreateSession(req.Context(), user.ID, userland.Session{
 			ID:         accessToken.Key,
 			Token:      accessToken.Value,
 			IP:         clientInfo["ip"].(string),
 			ClientID:   clientInfo["client_id"].(int),
 			ClientName: clientInfo["client_name"].(string),
+			UserAgent:  clientInfo["user_agent"].(string),
 			Expiration: security.UserAccessTokenExpiration,
-		})
+		}); err != nil {
+			handleServiceError(res, req, err)
+			return
+		}
 	}
 
-	defer h.EventService.Log(authentication.EventLogin, user.ID, clientInfo)
+	defer h.EventService.Log(req.Context(), authentication.EventLogin, user.ID, clientInfo)
 	render.JSON(res, http.StatusOK, map[string]interface{}{
 		"require_tfa":  requireTFA,
-		"access_token": serializers.SerializeAccessTokenToJSON(accessToken),
+		"access_token": serializers.SerializeAccessTokenToJSON(accessToken, h.ExposeJWT),
 	})
 }
 
@@ -285,15 +308,15 @@ func (h AuthenticationHandler) forgotPassword(res http.ResponseWriter, req *http
 	}
 
 	email := forgotPasswordRequest.Email
-	if _, err = h.AuthenticationService.ForgotPassword(email); err != nil {
+	if _, err = h.AuthenticationService.ForgotPassword(req.Context(), email); err != nil {
 		handleServiceError(res, req, err)
 		return
 	}
 
 	defer func(email string, clientInfo map[string]interface{}) {
-		user, err := h.ProfileService.ProfileByEmail(email)
+		user, err := h.ProfileService.ProfileByEmail(req.Context(), email)
 		if err == nil {
-			h.EventService.Log(authentication.EventForgotPassword, user.ID, clientInfo)
+			h.EventService.Log(req.Context(), authentication.EventForgotPassword, user.ID, clientInfo)
 		}
 	}(email, clientInfo)
 	render.JSON(res, http.StatusOK, map[string]interface{}{"success": true})
@@ -336,7 +359,14 @@ func (h AuthenticationHandler) resetPassword(res http.ResponseWriter, req *http.
 
 	resetToken := resetPasswordRequest.Token
 	newPassword := resetPasswordRequest.Password
-	if err = h.AuthenticationService.ResetPassword(resetToken, newPassword); err != nil {
+	userID, err := h.AuthenticationService.ResetPassword(req.Context(), resetToken, newPassword)
+	if err != nil {
+		handleServiceError(res, req, err)
+		return
+	}
+
+	// a device trusted by whoever knew the old password shouldn't skip TFA anymore
+	if err := h.SessionService.RevokeAllTrustedDevices(req.Context(), userID); err != nil {
 		handleServiceError(res, req, err)
 		return
 	}
@@ -344,6 +374,56 @@ func (h AuthenticationHandler) resetPassword(res http.ResponseWriter, req *http.
 	render.JSON(res, http.StatusOK, map[string]interface{}{"success": true})
 }
 
+//denySignIn is the "this wasn't me" action of new sign-in alert, every session and trusted device of the user is revoked
+func (h AuthenticationHandler) denySignIn(res http.ResponseWriter, req *http.Request) {
+	clientInfo := req.Context().Value(contextkey.ClientInfo).(map[string]interface{})
+	// Read Body, limit to 1 MB //
+	body, err := ioutil.ReadAll(io.LimitReader(req.Body, 1048576))
+	if err != nil {
+		render.FailedToReadBodyError(res, err)
+		return
+	}
+
+	denySignInRequest := struct {
+		Token string `json:"token" valid:"required,stringlength(1|256)"`
+	}{}
+
+	// Deserialize
+	if err := json.Unmarshal(body, &denySignInRequest); err != nil {
+		render.FailedToUnmarshalJSONError(res, err)
+		return
+	}
+
+	if err := req.Body.Close(); err != nil {
+		render.InternalServerError(res, err)
+		return
+	}
+
+	if ok, err := govalidator.ValidateStruct(denySignInRequest); !ok || err != nil {
+		render.InvalidRequestError(res, err)
+		return
+	}
+
+	userID, err := h.AuthenticationService.DenySignIn(req.Context(), denySignInRequest.Token)
+	if err != nil {
+		handleServiceError(res, req, err)
+		return
+	}
+
+	// no session is kept, the request doesn't come from one
+	if err := h.SessionService.EndOtherSessions(req.Context(), userID, ""); err != nil {
+		handleServiceError(res, req, err)
+		return
+	}
+	if err := h.SessionService.RevokeAllTrustedDevices(req.Context(), userID); err != nil {
+		handleServiceError(res, req, err)
+		return
+	}
+
+	defer h.EventService.Log(req.Context(), authentication.EventDenySignIn, userID, clientInfo)
+	render.JSON(res, http.StatusOK, map[string]interface{}{"success": true})
+}
+
 func (h AuthenticationHandler) verifyTFA(res http.ResponseWriter, req *http.Request) {
 	clientInfo := req.Context().Value(contextkey.ClientInfo).(map[string]interface{})
 	tfaAccessToken := req.Context().Value(contextkey.AccessToken).(map[string]interface{})
@@ -358,7 +438,8 @@ func (h AuthenticationHandler) verifyTFA(res http.ResponseWriter, req *http.Requ
 	}
 
 	verifyTFARequest := struct {
-		Code string `json:"code" valid:"required,stringlength(6|6)"`
+		Code           string `json:"code" valid:"required,stringlength(6|6)"`
+		RememberDevice bool   `json:"remember_device"`
 	}{}
 
 	// Deserialize
@@ -377,25 +458,44 @@ func (h AuthenticationHandler) verifyTFA(res http.ResponseWriter, req *http.Requ
 		return
 	}
 
-	accessToken, err := h.AuthenticationService.VerifyTFA(tfaAccessTokenKey, userID, verifyTFARequest.Code)
+	accessToken, err := h.AuthenticationService.VerifyTFA(req.Context(), tfaAccessTokenKey, userID, verifyTFARequest.Code)
 	if err != nil {
 		handleServiceError(res, req, err)
 		return
 	}
 
-	// suppress error
-	h.SessionService.CreateSession(userID, userland.Session{
+	if err := h.SessionService.CreateSession(req.Context(), userID, userland.Session{
 		ID:         accessToken.Key,
 		Token:      accessToken.Value,
 		IP:         clientInfo["ip"].(string),
 		ClientID:   clientInfo["client_id"].(int),
 		ClientName: clientInfo["client_name"].(string),
+		UserAgent:  clientInfo["user_agent"].(string),
 		Expiration: security.UserAccessTokenExpiration,
-	})
+	}); err != nil {
+		handleServiceError(res, req, err)
+		return
+	}
 
-	render.JSON(res, http.StatusOK, map[string]interface{}{
-		"access_token": serializers.SerializeAccessTokenToJSON(accessToken),
-	})
+	response := map[string]interface{}{
+		"access_token": serializers.SerializeAccessTokenToJSON(accessToken, h.ExposeJWT),
+	}
+	if verifyTFARequest.RememberDevice {
+		deviceToken, trustedDevice, err := h.AuthenticationService.TrustDevice(req.Context(), userID, userland.TrustedDevice{
+			UserAgent:  clientInfo["user_agent"].(string),
+			IP:         clientInfo["ip"].(string),
+			ClientID:   clientInfo["client_id"].(int),
+			ClientName: clientInfo["client_name"].(string),
+		})
+		if err != nil {
+			handleServiceError(res, req, err)
+			return
+		}
+		defer h.EventService.Log(req.Context(), authentication.EventTrustDevice, userID, clientInfo)
+		response["device_token"] = serializers.SerializeDeviceTokenToJSON(deviceToken, trustedDevice)
+	}
+
+	render.JSON(res, http.StatusOK, response)
 }
 
 func (h AuthenticationHandler) verifyTFABypass(res http.ResponseWriter, req *http.Request) {
@@ -411,7 +511,8 @@ func (h AuthenticationHandler) verifyTFABypass(res http.ResponseWriter, req *htt
 	}
 
 	verifyTFARequest := struct {
-		Code string `json:"code" valid:"required,stringlength(6|6)"`
+		Code           string `json:"code" valid:"required,stringlength(1|8)"`
+		RememberDevice bool   `json:"remember_device"`
 	}{}
 
 	// Deserialize
@@ -430,22 +531,120 @@ func (h AuthenticationHandler) verifyTFABypass(res http.ResponseWriter, req *htt
 		return
 	}
 
-	accessToken, err := h.AuthenticationService.VerifyTFABypass(tfaAccessTokenKey, userID, verifyTFARequest.Code)
+	accessToken, err := h.AuthenticationService.VerifyTFABypass(req.Context(), tfaAccessTokenKey, userID, verifyTFARequest.Code)
 	if err != nil {
 		handleServiceError(res, req, err)
 		return
 	}
 
-	h.SessionService.CreateSession(userID, userland.Session{
+	if err := h.SessionService.CreateSession(req.Context(), userID, userland.Session{
 		ID:         accessToken.Key,
 		Token:      accessToken.Value,
 		IP:         clientInfo["ip"].(string),
 		ClientID:   clientInfo["client_id"].(int),
 		ClientName: clientInfo["client_name"].(string),
+		UserAgent:  clientInfo["user_agent"].(string),
 		Expiration: security.UserAccessTokenExpiration,
-	})
+	}); err != nil {
+		handleServiceError(res, req, err)
+		return
+	}
+
+	response := map[string]interface{}{
+		"access_token": serializers.SerializeAccessTokenToJSON(accessToken, h.ExposeJWT),
+	}
+	if verifyTFARequest.RememberDevice {
+		deviceToken, trustedDevice, err := h.AuthenticationService.TrustDevice(req.Context(), userID, userland.TrustedDevice{
+			UserAgent:  clientInfo["user_agent"].(string),
+			IP:         clientInfo["ip"].(string),
+			ClientID:   clientInfo["client_id"].(int),
+			ClientName: clientInfo["client_name"].(string),
+		})
+		if err != nil {
+			handleServiceError(res, req, err)
+			return
+		}
+		defer h.EventService.Log(req.Context(), authentication.EventTrustDevice, userID, clientInfo)
+		response["device_token"] = serializers.SerializeDeviceTokenToJSON(deviceToken, trustedDevice)
+	}
+
+	render.JSON(res, http.StatusOK, response)
+}
+
+func (h AuthenticationHandler) requestReauthentication(res http.ResponseWriter, req *http.Request) {
+	accessToken := req.Context().Value(contextkey.AccessToken).(map[string]interface{})
+	accessTokenKey := req.Context().Value(contextkey.AccessTokenKey).(string)
+	userID := int(accessToken["userid"].(float64))
+
+	if err := h.AuthenticationService.RequestReauthentication(req.Context(), userID, accessTokenKey); err != nil {
+		handleServiceError(res, req, err)
+		return
+	}
+
+	render.JSON(res, http.StatusOK, map[string]interface{}{"success": true})
+}
+
+func (h AuthenticationHandler) reauthenticate(res http.ResponseWriter, req *http.Request) {
+	clientInfo := req.Context().Value(contextkey.ClientInfo).(map[string]interface{})
+	accessToken := req.Context().Value(contextkey.AccessToken).(map[string]interface{})
+	accessTokenKey := req.Context().Value(contextkey.AccessTokenKey).(string)
+	userID := int(accessToken["userid"].(float64))
+
+	// Read Body, limit to 1 MB //
+	body, err := ioutil.ReadAll(io.LimitReader(req.Body, 1048576))
+	if err != nil {
+		render.FailedToReadBodyError(res, err)
+		return
+	}
+
+	reauthenticateRequest := struct {
+		Method   string `json:"method" valid:"required,in(password|otp|totp)"`
+		Password string `json:"password" valid:"stringlength(6|128)"`
+		Code     string `json:"code" valid:"stringlength(6|6)"`
+	}{}
+
+	// Deserialize
+	if err := json.Unmarshal(body, &reauthenticateRequest); err != nil {
+		render.FailedToUnmarshalJSONError(res, err)
+		return
+	}
+
+	if err := req.Body.Close(); err != nil {
+		render.InternalServerError(res, err)
+		return
+	}
+
+	if ok, err := govalidator.ValidateStruct(reauthenticateRequest); !ok || err != nil {
+		render.InvalidRequestError(res, err)
+		return
+	}
+
+	credential := reauthenticateRequest.Password
+	if reauthenticateRequest.Method != authentication.ReauthenticationByPassword {
+		credential = reauthenticateRequest.Code
+	}
+
+	elevatedAccessToken, err := h.AuthenticationService.Reauthenticate(req.Context(), userID, accessTokenKey, reauthenticateRequest.Method, credential)
+	if err != nil {
+		handleServiceError(res, req, err)
+		return
+	}
+
+	if err := h.SessionService.CreateSession(req.Context(), userID, userland.Session{
+		ID:         elevatedAccessToken.Key,
+		Token:      elevatedAccessToken.Value,
+		IP:         clientInfo["ip"].(string),
+		ClientID:   clientInfo["client_id"].(int),
+		ClientName: clientInfo["client_name"].(string),
+		UserAgent:  clientInfo["user_agent"].(string),
+		Expiration: security.ReauthenticationExpiration,
+	}); err != nil {
+		handleServiceError(res, req, err)
+		return
+	}
 
+	defer h.EventService.Log(req.Context(), authentication.EventReauthenticate, userID, clientInfo)
 	render.JSON(res, http.StatusOK, map[string]interface{}{
-		"access_token": serializers.SerializeAccessTokenToJSON(accessToken),
+		"access_token": serializers.SerializeAccessTokenToJSON(elevatedAccessToken, h.ExposeJWT),
 	})
 }
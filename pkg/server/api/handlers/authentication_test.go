@@ -26,7 +26,8 @@ func TestAuthenticationHandler_inputValidation(t *testing.T) {
 	eventService := event.SimpleEventService{CalledMethods: map[string]bool{}}
 
 	authenticationHandler := handlers.AuthenticationHandler{
-		RateLimiter:           middlewares.BypassWithArgs,
+		RateLimiter:           middlewares.BypassRateLimiter,
+		ClientVerification:    middlewares.Bypass,
 		Authorization:         middlewares.BypassWithArgs,
 		Authenticator:         middlewares.Authentication,
 		ProfileService:        profileService,
@@ -101,6 +102,19 @@ func TestAuthenticationHandler_inputValidation(t *testing.T) {
 			},
 			wantStatusCode: http.StatusOK,
 		},
+		{
+			name: "POST api/auth/login with device token",
+			args: args{
+				method: http.MethodPost,
+				path:   "api/auth/login",
+				requestBody: map[string]interface{}{
+					"email":        "adhitya.ramadhanus@gmail.com",
+					"password":     "test123",
+					"device_token": "device-id.secret",
+				},
+			},
+			wantStatusCode: http.StatusOK,
+		},
 		{
 			name: "POST api/auth/password/forgot",
 			args: args{
@@ -125,6 +139,26 @@ func TestAuthenticationHandler_inputValidation(t *testing.T) {
 			},
 			wantStatusCode: http.StatusOK,
 		},
+		{
+			name: "POST api/auth/signin/deny",
+			args: args{
+				method: http.MethodPost,
+				path:   "api/auth/signin/deny",
+				requestBody: map[string]interface{}{
+					"token": "asdasdasdasdasdasdasd",
+				},
+			},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name: "POST api/auth/signin/deny without token",
+			args: args{
+				method:      http.MethodPost,
+				path:        "api/auth/signin/deny",
+				requestBody: map[string]interface{}{},
+			},
+			wantStatusCode: http.StatusUnprocessableEntity,
+		},
 		{
 			name: "POST api/auth/tfa/verify",
 			args: args{
@@ -136,6 +170,18 @@ func TestAuthenticationHandler_inputValidation(t *testing.T) {
 			},
 			wantStatusCode: http.StatusOK,
 		},
+		{
+			name: "POST api/auth/tfa/verify and remember device",
+			args: args{
+				method: http.MethodPost,
+				path:   "api/auth/tfa/verify",
+				requestBody: map[string]interface{}{
+					"code":            "123123",
+					"remember_device": true,
+				},
+			},
+			wantStatusCode: http.StatusOK,
+		},
 		{
 			name: "POST api/auth/tfa/bypass",
 			args: args{
@@ -148,6 +194,62 @@ func TestAuthenticationHandler_inputValidation(t *testing.T) {
 			},
 			wantStatusCode: http.StatusOK,
 		},
+		{
+			name: "POST api/auth/reauthenticate/otp",
+			args: args{
+				method:      http.MethodPost,
+				path:        "api/auth/reauthenticate/otp",
+				requestBody: map[string]interface{}{},
+			},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name: "POST api/auth/reauthenticate with password",
+			args: args{
+				method: http.MethodPost,
+				path:   "api/auth/reauthenticate",
+				requestBody: map[string]interface{}{
+					"method":   "password",
+					"password": "test123",
+				},
+			},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name: "POST api/auth/reauthenticate with otp",
+			args: args{
+				method: http.MethodPost,
+				path:   "api/auth/reauthenticate",
+				requestBody: map[string]interface{}{
+					"method": "otp",
+					"code":   "123123",
+				},
+			},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name: "POST api/auth/reauthenticate with totp",
+			args: args{
+				method: http.MethodPost,
+				path:   "api/auth/reauthenticate",
+				requestBody: map[string]interface{}{
+					"method": "totp",
+					"code":   "123123",
+				},
+			},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name: "POST api/auth/reauthenticate with unknown method",
+			args: args{
+				method: http.MethodPost,
+				path:   "api/auth/reauthenticate",
+				requestBody: map[string]interface{}{
+					"method": "carrier-pigeon",
+				},
+			},
+			wantStatusCode: http.StatusUnprocessableEntity,
+		},
 	}
 
 	for _, tc := range testCases {
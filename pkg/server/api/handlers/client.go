@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"github.com/AdhityaRamadhanus/userland"
+	"github.com/AdhityaRamadhanus/userland/pkg/common/http/middlewares"
+	"github.com/AdhityaRamadhanus/userland/pkg/common/http/render"
+	"github.com/AdhityaRamadhanus/userland/pkg/server/api/serializers"
+	"github.com/AdhityaRamadhanus/userland/pkg/service/client"
+	"github.com/asaskevich/govalidator"
+	"github.com/gorilla/mux"
+)
+
+type ClientHandler struct {
+	AdminAuthenticator middlewares.Middleware
+	ClientService      client.Service
+}
+
+func (h ClientHandler) RegisterRoutes(router *mux.Router) {
+	subRouter := router.PathPrefix("/api").Subrouter()
+	// middlewares
+	authenticateAdmin := h.AdminAuthenticator
+
+	registerClient := authenticateAdmin(http.HandlerFunc(h.registerClient))
+	listClients := authenticateAdmin(http.HandlerFunc(h.listClients))
+	getClient := authenticateAdmin(http.HandlerFunc(h.getClient))
+	updateClient := authenticateAdmin(http.HandlerFunc(h.updateClient))
+
+	subRouter.Handle("/clients", registerClient).Methods("POST")
+	subRouter.Handle("/clients", listClients).Methods("GET")
+	subRouter.Handle("/clients/{client_id:[0-9]+}", getClient).Methods("GET")
+	subRouter.Handle("/clients/{client_id:[0-9]+}", updateClient).Methods("PATCH")
+}
+
+func (h ClientHandler) registerClient(res http.ResponseWriter, req *http.Request) {
+	// Read Body, limit to 1 MB //
+	body, err := ioutil.ReadAll(io.LimitReader(req.Body, 1048576))
+	if err != nil {
+		render.FailedToReadBodyError(res, err)
+		return
+	}
+
+	registerClientRequest := struct {
+		Name           string   `json:"name" valid:"required,stringlength(1|128)"`
+		Type           string   `json:"type" valid:"required,in(web|mobile|server)"`
+		Public         bool     `json:"public"`
+		AllowedOrigins []string `json:"allowed_origins"`
+	}{}
+
+	// Deserialize
+	if err := json.Unmarshal(body, &registerClientRequest); err != nil {
+		render.FailedToUnmarshalJSONError(res, err)
+		return
+	}
+
+	if err := req.Body.Close(); err != nil {
+		render.InternalServerError(res, err)
+		return
+	}
+
+	if ok, err := govalidator.ValidateStruct(registerClientRequest); !ok || err != nil {
+		render.InvalidRequestError(res, err)
+		return
+	}
+
+	registeredClient, credential, secret, err := h.ClientService.RegisterClient(req.Context(), userland.Client{
+		Name:           registerClientRequest.Name,
+		Type:           registerClientRequest.Type,
+		Public:         registerClientRequest.Public,
+		AllowedOrigins: registerClientRequest.AllowedOrigins,
+	})
+	if err != nil {
+		handleServiceError(res, req, err)
+		return
+	}
+
+	// secret is only ever shown here
+	response := map[string]interface{}{
+		"client":     serializers.SerializeClientToJSON(registeredClient),
+		"credential": credential,
+	}
+	if secret != "" {
+		response["secret"] = secret
+	}
+	render.JSON(res, http.StatusCreated, response)
+}
+
+func (h ClientHandler) listClients(res http.ResponseWriter, req *http.Request) {
+	clients, err := h.ClientService.ListClients(req.Context())
+	if err != nil {
+		handleServiceError(res, req, err)
+		return
+	}
+
+	serializedClients := []map[string]interface{}{}
+	for _, registeredClient := range clients {
+		serializedClients = append(serializedClients, serializers.SerializeClientToJSON(registeredClient))
+	}
+	render.JSON(res, http.StatusOK, map[string]interface{}{
+		"clients": serializedClients,
+	})
+}
+
+func (h ClientHandler) getClient(res http.ResponseWriter, req *http.Request) {
+	clientID, _ := strconv.Atoi(mux.Vars(req)["client_id"])
+	registeredClient, err := h.ClientService.GetClient(req.Context(), clientID)
+	if err != nil {
+		handleServiceError(res, req, err)
+		return
+	}
+
+	render.JSON(res, http.StatusOK, map[string]interface{}{
+		"client": serializers.SerializeClientToJSON(registeredClient),
+	})
+}
+
+func (h ClientHandler) updateClient(res http.ResponseWriter, req *http.Request) {
+	clientID, _ := strconv.Atoi(mux.Vars(req)["client_id"])
+	// Read Body, limit to 1 MB //
+	body, err := ioutil.ReadAll(io.LimitReader(req.Body, 1048576))
+	if err != nil {
+		render.FailedToReadBodyError(res, err)
+		return
+	}
+
+	updateClientRequest := struct {
+		Name           string   `json:"name" valid:"required,stringlength(1|128)"`
+		AllowedOrigins []string `json:"allowed_origins"`
+		Status         string   `json:"status" valid:"required,in(active|disabled)"`
+	}{}
+
+	// Deserialize
+	if err := json.Unmarshal(body, &updateClientRequest); err != nil {
+		render.FailedToUnmarshalJSONError(res, err)
+		return
+	}
+
+	if err := req.Body.Close(); err != nil {
+		render.InternalServerError(res, err)
+		return
+	}
+
+	if ok, err := govalidator.ValidateStruct(updateClientRequest); !ok || err != nil {
+		render.InvalidRequestError(res, err)
+		return
+	}
+
+	updatedClient, err := h.ClientService.UpdateClient(req.Context(), userland.Client{
+		ID:             clientID,
+		Name:           updateClientRequest.Name,
+		AllowedOrigins: updateClientRequest.AllowedOrigins,
+		Status:         updateClientRequest.Status,
+	})
+	if err != nil {
+		handleServiceError(res, req, err)
+		return
+	}
+
+	render.JSON(res, http.StatusOK, map[string]interface{}{
+		"client": serializers.SerializeClientToJSON(updatedClient),
+	})
+}
@@ -0,0 +1,141 @@
+//+build unit
+
+package handlers_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	_http "github.com/AdhityaRamadhanus/userland/pkg/common/http"
+	"github.com/AdhityaRamadhanus/userland/pkg/mocks/middlewares"
+	"github.com/AdhityaRamadhanus/userland/pkg/mocks/service/client"
+	"github.com/AdhityaRamadhanus/userland/pkg/server/api/handlers"
+	"github.com/gorilla/mux"
+)
+
+func TestClientHandler_inputValidation(t *testing.T) {
+	clientService := client.SimpleClientService{CalledMethods: map[string]bool{}}
+
+	clientHandler := handlers.ClientHandler{
+		AdminAuthenticator: middlewares.Bypass,
+		ClientService:      clientService,
+	}
+	router := mux.NewRouter().StrictSlash(true)
+	clientHandler.RegisterRoutes(router)
+
+	ts := httptest.NewServer(middlewares.ClientParser(router))
+	defer ts.Close()
+
+	type args struct {
+		path        string
+		method      string
+		requestBody map[string]interface{}
+	}
+	testCases := []struct {
+		name           string
+		args           args
+		wantStatusCode int
+	}{
+		{
+			name: "POST api/clients",
+			args: args{
+				method: http.MethodPost,
+				path:   "api/clients",
+				requestBody: map[string]interface{}{
+					"name":            "web",
+					"type":            "web",
+					"public":          true,
+					"allowed_origins": []string{"https://userland.io"},
+				},
+			},
+			wantStatusCode: http.StatusCreated,
+		},
+		{
+			name: "POST api/clients with unknown type",
+			args: args{
+				method: http.MethodPost,
+				path:   "api/clients",
+				requestBody: map[string]interface{}{
+					"name": "tv",
+					"type": "tv",
+				},
+			},
+			wantStatusCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name: "POST api/clients without name",
+			args: args{
+				method: http.MethodPost,
+				path:   "api/clients",
+				requestBody: map[string]interface{}{
+					"type": "server",
+				},
+			},
+			wantStatusCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name: "GET api/clients",
+			args: args{
+				method: http.MethodGet,
+				path:   "api/clients",
+			},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name: "GET api/clients/{client_id}",
+			args: args{
+				method: http.MethodGet,
+				path:   "api/clients/1",
+			},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name: "PATCH api/clients/{client_id}",
+			args: args{
+				method: http.MethodPatch,
+				path:   "api/clients/1",
+				requestBody: map[string]interface{}{
+					"name":   "web",
+					"status": "disabled",
+				},
+			},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name: "PATCH api/clients/{client_id} with unknown status",
+			args: args{
+				method: http.MethodPatch,
+				path:   "api/clients/1",
+				requestBody: map[string]interface{}{
+					"name":   "web",
+					"status": "deleted",
+				},
+			},
+			wantStatusCode: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			url := fmt.Sprintf("%s/%s", ts.URL, tc.args.path)
+			req, err := _http.CreateJSONRequest(tc.args.method, url, tc.args.requestBody)
+			if err != nil {
+				t.Fatalf("_http.CreateJSONRequest() err = %v; want nil", err)
+			}
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("http.DefaultClient.Do() err = %v; want nil", err)
+			}
+			statusCode := res.StatusCode
+			if statusCode != tc.wantStatusCode {
+				body, _ := ioutil.ReadAll(res.Body)
+				defer res.Body.Close()
+				t.Logf("response %s\n", string(body))
+				t.Errorf("%s res.StatusCode = %d; want %d", tc.args.path, statusCode, tc.wantStatusCode)
+			}
+		})
+	}
+}
@@ -7,7 +7,10 @@ import (
 	"github.com/AdhityaRamadhanus/userland"
 	"github.com/AdhityaRamadhanus/userland/pkg/common/http/render"
 	"github.com/AdhityaRamadhanus/userland/pkg/service/authentication"
+	"github.com/AdhityaRamadhanus/userland/pkg/service/client"
 	"github.com/AdhityaRamadhanus/userland/pkg/service/profile"
+	"github.com/AdhityaRamadhanus/userland/pkg/service/saml"
+	"github.com/AdhityaRamadhanus/userland/pkg/service/session"
 	"github.com/sirupsen/logrus"
 )
 
@@ -20,6 +23,14 @@ var (
 			HTTPCode: http.StatusNotFound,
 			ErrCode:  "ErrUserNotFound",
 		},
+		userland.ErrConcurrentModification: {
+			HTTPCode: http.StatusConflict,
+			ErrCode:  "ErrConcurrentModification",
+		},
+		userland.ErrAccountDeleted: {
+			HTTPCode: http.StatusForbidden,
+			ErrCode:  "ErrAccountDeleted",
+		},
 		authentication.ErrUserRegistered: {
 			HTTPCode: http.StatusBadRequest,
 			ErrCode:  "ErrUserRegistered",
@@ -40,6 +51,18 @@ var (
 			HTTPCode: http.StatusBadRequest,
 			ErrCode:  "ErrOTPInvalid",
 		},
+		authentication.ErrTOTPNotEnabled: {
+			HTTPCode: http.StatusBadRequest,
+			ErrCode:  "ErrTOTPNotEnabled",
+		},
+		authentication.ErrSignInDenialInvalid: {
+			HTTPCode: http.StatusBadRequest,
+			ErrCode:  "ErrSignInDenialInvalid",
+		},
+		authentication.ErrLoginBlocked: {
+			HTTPCode: http.StatusForbidden,
+			ErrCode:  "ErrLoginBlocked",
+		},
 		profile.ErrWrongOTP: {
 			HTTPCode: http.StatusNotFound,
 			ErrCode:  "ErrWrongOTP",
@@ -52,6 +75,106 @@ var (
 			HTTPCode: http.StatusNotFound,
 			ErrCode:  "ErrEmailAlreadyUsed",
 		},
+		profile.ErrTFANotEnabled: {
+			HTTPCode: http.StatusBadRequest,
+			ErrCode:  "ErrTFANotEnabled",
+		},
+		profile.ErrRestoreInvalid: {
+			HTTPCode: http.StatusBadRequest,
+			ErrCode:  "ErrRestoreInvalid",
+		},
+		profile.ErrExportInvalid: {
+			HTTPCode: http.StatusNotFound,
+			ErrCode:  "ErrExportInvalid",
+		},
+		profile.ErrExportQueueFull: {
+			HTTPCode: http.StatusServiceUnavailable,
+			ErrCode:  "ErrExportQueueFull",
+		},
+		userland.ErrTrustedDeviceNotFound: {
+			HTTPCode: http.StatusNotFound,
+			ErrCode:  "ErrTrustedDeviceNotFound",
+		},
+		session.ErrSessionLimitReached: {
+			HTTPCode: http.StatusForbidden,
+			ErrCode:  "ErrSessionLimitReached",
+		},
+		userland.ErrIdentityProviderNotFound: {
+			HTTPCode: http.StatusNotFound,
+			ErrCode:  "ErrIdentityProviderNotFound",
+		},
+		userland.ErrClientNotFound: {
+			HTTPCode: http.StatusNotFound,
+			ErrCode:  "ErrClientNotFound",
+		},
+		client.ErrInvalidClientType: {
+			HTTPCode: http.StatusBadRequest,
+			ErrCode:  "ErrInvalidClientType",
+		},
+		client.ErrInvalidClientStatus: {
+			HTTPCode: http.StatusBadRequest,
+			ErrCode:  "ErrInvalidClientStatus",
+		},
+		client.ErrPublicServerClient: {
+			HTTPCode: http.StatusBadRequest,
+			ErrCode:  "ErrPublicServerClient",
+		},
+		client.ErrNoClientSigningKey: {
+			HTTPCode: http.StatusServiceUnavailable,
+			ErrCode:  "ErrNoClientSigningKey",
+		},
+		saml.ErrInvalidMetadata: {
+			HTTPCode: http.StatusBadRequest,
+			ErrCode:  "ErrInvalidMetadata",
+		},
+		saml.ErrInvalidResponse: {
+			HTTPCode: http.StatusBadRequest,
+			ErrCode:  "ErrInvalidResponse",
+		},
+		saml.ErrInvalidSignature: {
+			HTTPCode: http.StatusUnauthorized,
+			ErrCode:  "ErrInvalidSignature",
+		},
+		saml.ErrInvalidDestination: {
+			HTTPCode: http.StatusUnauthorized,
+			ErrCode:  "ErrInvalidDestination",
+		},
+		saml.ErrResponseNotSuccess: {
+			HTTPCode: http.StatusUnauthorized,
+			ErrCode:  "ErrResponseNotSuccess",
+		},
+		saml.ErrEncryptedAssertionNotSupported: {
+			HTTPCode: http.StatusBadRequest,
+			ErrCode:  "ErrEncryptedAssertionNotSupported",
+		},
+		saml.ErrInvalidIssuer: {
+			HTTPCode: http.StatusUnauthorized,
+			ErrCode:  "ErrInvalidIssuer",
+		},
+		saml.ErrInvalidAudience: {
+			HTTPCode: http.StatusUnauthorized,
+			ErrCode:  "ErrInvalidAudience",
+		},
+		saml.ErrAssertionExpired: {
+			HTTPCode: http.StatusUnauthorized,
+			ErrCode:  "ErrAssertionExpired",
+		},
+		saml.ErrSubjectNotConfirmed: {
+			HTTPCode: http.StatusUnauthorized,
+			ErrCode:  "ErrSubjectNotConfirmed",
+		},
+		saml.ErrAssertionReplayed: {
+			HTTPCode: http.StatusUnauthorized,
+			ErrCode:  "ErrAssertionReplayed",
+		},
+		saml.ErrMissingEmailAttribute: {
+			HTTPCode: http.StatusBadRequest,
+			ErrCode:  "ErrMissingEmailAttribute",
+		},
+		saml.ErrEmailDomainNotOwned: {
+			HTTPCode: http.StatusForbidden,
+			ErrCode:  "ErrEmailDomainNotOwned",
+		},
 	}
 )
 
@@ -73,7 +196,7 @@ func handleServiceError(res http.ResponseWriter, req *http.Request, err error) {
 		"route":        req.URL.Path,
 		"status_code":  http.StatusInternalServerError,
 		"stack_trace":  fmt.Sprintf("%+v", err),
-		"client":       req.Header.Get("X-API-ClientID"),
+		"client":       req.Header.Get("X-API-Client"),
 		"x-request-id": req.Header.Get("X-Request-ID"),
 	}).WithError(err).Error("Error Handler")
 
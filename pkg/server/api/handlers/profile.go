@@ -9,8 +9,9 @@ import (
 	"io/ioutil"
 	"math"
 	"net/http"
+	"regexp"
 	"strconv"
-	"time"
+	"strings"
 
 	"github.com/AdhityaRamadhanus/userland/pkg/server/api/serializers"
 
@@ -25,16 +26,22 @@ import (
 	"github.com/AdhityaRamadhanus/userland/pkg/common/http/render"
 	"github.com/AdhityaRamadhanus/userland/pkg/service/event"
 	"github.com/AdhityaRamadhanus/userland/pkg/service/profile"
+	"github.com/AdhityaRamadhanus/userland/pkg/service/session"
 
 	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
 )
 
 type ProfileHandler struct {
-	Authorization  middlewares.MiddlewareWithArgs
-	Authenticator  middlewares.Middleware
-	RateLimiter    middlewares.MiddlewareWithArgs
-	ProfileService profile.Service
-	EventService   event.Service
+	Authorization        middlewares.MiddlewareWithArgs
+	Authenticator        middlewares.Middleware
+	RateLimiter          middlewares.RateLimiter
+	RecentAuthentication middlewares.MiddlewareWithMaxAge
+	ClientVerification   middlewares.Middleware
+	ProfileService       profile.Service
+	SessionService       session.Service
+	EventService         event.Service
+	Exporter             *profile.Exporter
 }
 
 func (h ProfileHandler) RegisterRoutes(router *mux.Router) {
@@ -43,24 +50,34 @@ func (h ProfileHandler) RegisterRoutes(router *mux.Router) {
 	authenticate := h.Authenticator
 	authorize := h.Authorization
 	ratelimit := h.RateLimiter
-
-	getProfile := authenticate(authorize(http.HandlerFunc(h.getProfile), security.UserTokenScope))
-	updateProfile := authenticate(authorize(http.HandlerFunc(h.updateProfile), security.UserTokenScope))
-	setPicture := authenticate(authorize(http.HandlerFunc(h.setPicture), security.UserTokenScope))
-	deletePicture := authenticate(authorize(http.HandlerFunc(h.deletePicture), security.UserTokenScope))
-	getEmail := authenticate(authorize(http.HandlerFunc(h.getEmail), security.UserTokenScope))
-	requestChangeEmail := ratelimit(authenticate(authorize(http.HandlerFunc(h.requestChangeEmail), security.UserTokenScope)), 10, time.Minute)
-	changeEmail := authenticate(authorize(http.HandlerFunc(h.changeEmail), security.UserTokenScope))
-	changePassword := ratelimit(authenticate(authorize(http.HandlerFunc(h.changePassword), security.UserTokenScope)), 10, time.Minute)
-	getTFAStatus := authenticate(authorize(http.HandlerFunc(h.getTFAStatus), security.UserTokenScope))
-	enrollTFA := authenticate(authorize(http.HandlerFunc(h.enrollTFA), security.UserTokenScope))
-	activateTFA := authenticate(authorize(http.HandlerFunc(h.activateTFA), security.UserTokenScope))
-	removeTFA := authenticate(authorize(http.HandlerFunc(h.removeTFA), security.UserTokenScope))
-	deleteAccount := authenticate(authorize(http.HandlerFunc(h.deleteAccount), security.UserTokenScope))
-	getEvents := authenticate(authorize(http.HandlerFunc(h.getEvents), security.UserTokenScope))
+	requireRecentAuth := h.RecentAuthentication
+	requireClient := h.ClientVerification
+
+	getProfile := requireClient(authenticate(authorize(http.HandlerFunc(h.getProfile), security.UserTokenScope)))
+	updateProfile := requireClient(authenticate(authorize(http.HandlerFunc(h.updateProfile), security.UserTokenScope)))
+	patchProfile := requireClient(authenticate(authorize(http.HandlerFunc(h.patchProfile), security.UserTokenScope)))
+	setPicture := requireClient(authenticate(authorize(http.HandlerFunc(h.setPicture), security.UserTokenScope)))
+	deletePicture := requireClient(authenticate(authorize(http.HandlerFunc(h.deletePicture), security.UserTokenScope)))
+	getEmail := requireClient(authenticate(authorize(http.HandlerFunc(h.getEmail), security.UserTokenScope)))
+	requestChangeEmail := requireClient(authenticate(authorize(ratelimit("request_change_email")(requireRecentAuth(http.HandlerFunc(h.requestChangeEmail), security.ReauthenticationMaxAge)), security.UserTokenScope)))
+	changeEmail := requireClient(authenticate(authorize(http.HandlerFunc(h.changeEmail), security.UserTokenScope)))
+	changePassword := requireClient(authenticate(authorize(ratelimit("change_password")(requireRecentAuth(http.HandlerFunc(h.changePassword), security.ReauthenticationMaxAge)), security.UserTokenScope)))
+	getTFAStatus := requireClient(authenticate(authorize(http.HandlerFunc(h.getTFAStatus), security.UserTokenScope)))
+	enrollTFA := requireClient(authenticate(authorize(requireRecentAuth(http.HandlerFunc(h.enrollTFA), security.ReauthenticationMaxAge), security.UserTokenScope)))
+	activateTFA := requireClient(authenticate(authorize(http.HandlerFunc(h.activateTFA), security.UserTokenScope)))
+	removeTFA := requireClient(authenticate(authorize(requireRecentAuth(http.HandlerFunc(h.removeTFA), security.ReauthenticationMaxAge), security.UserTokenScope)))
+	getBackupCodesStatus := requireClient(authenticate(authorize(http.HandlerFunc(h.getBackupCodesStatus), security.UserTokenScope)))
+	regenerateBackupCodes := requireClient(authenticate(authorize(requireRecentAuth(http.HandlerFunc(h.regenerateBackupCodes), security.ReauthenticationMaxAge), security.UserTokenScope)))
+	deleteAccount := requireClient(authenticate(authorize(requireRecentAuth(http.HandlerFunc(h.deleteAccount), security.ReauthenticationMaxAge), security.UserTokenScope)))
+	getEvents := requireClient(authenticate(authorize(http.HandlerFunc(h.getEvents), security.UserTokenScope)))
+	restoreAccount := requireClient(ratelimit("restore_account")(http.HandlerFunc(h.restoreAccount)))
+	requestExport := requireClient(authenticate(authorize(ratelimit("request_export")(requireRecentAuth(http.HandlerFunc(h.requestExport), security.ReauthenticationMaxAge)), security.UserTokenScope)))
+	// the emailed export link is opened by a browser, so it can't present client credentials
+	downloadExport := http.HandlerFunc(h.downloadExport)
 
 	subRouter.Handle("/me", getProfile).Methods("GET")
 	subRouter.Handle("/me", updateProfile).Methods("POST")
+	subRouter.Handle("/me", patchProfile).Methods("PATCH")
 
 	subRouter.Handle("/me/picture", setPicture).Methods("POST")
 	subRouter.Handle("/me/picture", deletePicture).Methods("DELETE")
@@ -75,30 +92,43 @@ func (h ProfileHandler) RegisterRoutes(router *mux.Router) {
 	subRouter.Handle("/me/tfa/enroll", enrollTFA).Methods("GET")
 	subRouter.Handle("/me/tfa/enroll", activateTFA).Methods("POST")
 	subRouter.Handle("/me/tfa/remove", removeTFA).Methods("POST")
+	subRouter.Handle("/me/tfa/backup_codes", getBackupCodesStatus).Methods("GET")
+	subRouter.Handle("/me/tfa/backup_codes", regenerateBackupCodes).Methods("POST")
 
 	subRouter.Handle("/me/delete", deleteAccount).Methods("DELETE")
 	subRouter.Handle("/me/events", getEvents).Methods("GET")
+	subRouter.Handle("/me/export", requestExport).Methods("POST")
+
+	subRouter.Handle("/account/restore", restoreAccount).Methods("POST")
+	subRouter.Handle("/account/export", downloadExport).Methods("GET")
 }
 
 func (h ProfileHandler) getProfile(res http.ResponseWriter, req *http.Request) {
 	userID := getUserIDFromContext(req)
-	user, err := h.ProfileService.Profile(userID)
+	user, err := h.ProfileService.Profile(req.Context(), userID)
 	if err != nil {
 		handleServiceError(res, req, err)
 		return
 	}
 
+	res.Header().Set("ETag", userETag(user))
 	render.JSON(res, http.StatusOK, serializers.SerializeUserToJSON(user))
 }
 
 func (h ProfileHandler) updateProfile(res http.ResponseWriter, req *http.Request) {
 	userID := getUserIDFromContext(req)
-	user, err := h.ProfileService.Profile(userID)
+	user, err := h.ProfileService.Profile(req.Context(), userID)
 	if err != nil {
 		handleServiceError(res, req, err)
 		return
 	}
 
+	ifMatch := req.Header.Get("If-Match")
+	if len(ifMatch) > 0 && !matchETag(ifMatch, userETag(user)) {
+		render.PreconditionFailedError(res, userland.ErrConcurrentModification)
+		return
+	}
+
 	// Read Body, limit to 1 MB //
 	body, err := ioutil.ReadAll(io.LimitReader(req.Body, 1048576))
 	if err != nil {
@@ -140,17 +170,94 @@ func (h ProfileHandler) updateProfile(res http.ResponseWriter, req *http.Request
 		user.WebURL = updateProfileRequest.Web
 	}
 
-	if err = h.ProfileService.SetProfile(user); err != nil {
+	if err = h.ProfileService.SetProfile(req.Context(), user); err != nil {
+		if err == userland.ErrConcurrentModification && len(ifMatch) > 0 {
+			render.PreconditionFailedError(res, err)
+			return
+		}
 		handleServiceError(res, req, err)
 		return
 	}
 
+	user.Version++
+	res.Header().Set("ETag", userETag(user))
 	render.JSON(res, http.StatusOK, map[string]interface{}{"success": true})
 }
 
+//patchProfile apply a JSON Merge Patch (RFC 7396) to the profile, only the members present in the patch are changed
+func (h ProfileHandler) patchProfile(res http.ResponseWriter, req *http.Request) {
+	clientInfo := req.Context().Value(contextkey.ClientInfo).(map[string]interface{})
+	userID := getUserIDFromContext(req)
+	user, err := h.ProfileService.Profile(req.Context(), userID)
+	if err != nil {
+		handleServiceError(res, req, err)
+		return
+	}
+
+	ifMatch := req.Header.Get("If-Match")
+	if len(ifMatch) > 0 && !matchETag(ifMatch, userETag(user)) {
+		render.PreconditionFailedError(res, userland.ErrConcurrentModification)
+		return
+	}
+
+	// Read Body, limit to 1 MB //
+	body, err := ioutil.ReadAll(io.LimitReader(req.Body, 1048576))
+	if err != nil {
+		render.FailedToReadBodyError(res, err)
+		return
+	}
+
+	// Deserialize, the profile is an object so the patch must be one too
+	mergePatch := map[string]json.RawMessage{}
+	if err := json.Unmarshal(body, &mergePatch); err != nil {
+		render.FailedToUnmarshalJSONError(res, err)
+		return
+	}
+	if mergePatch == nil {
+		render.FailedToUnmarshalJSONError(res, errors.New("merge patch must be a json object"))
+		return
+	}
+
+	if err := req.Body.Close(); err != nil {
+		render.InternalServerError(res, err)
+		return
+	}
+
+	patch, changedFields, err := buildProfilePatch(user, mergePatch)
+	if err != nil {
+		render.InvalidRequestError(res, err)
+		return
+	}
+
+	// a patch that change nothing is not stored and not logged
+	if len(changedFields) > 0 {
+		version := 0
+		if len(ifMatch) > 0 {
+			version = user.Version
+		}
+		if err := h.ProfileService.UpdateProfile(req.Context(), userID, version, patch); err != nil {
+			if err == userland.ErrConcurrentModification {
+				render.PreconditionFailedError(res, err)
+				return
+			}
+			handleServiceError(res, req, err)
+			return
+		}
+
+		if user, err = h.ProfileService.Profile(req.Context(), userID); err != nil {
+			handleServiceError(res, req, err)
+			return
+		}
+		defer h.EventService.LogChanges(req.Context(), profile.EventUpdateProfile, userID, clientInfo, changedFields)
+	}
+
+	res.Header().Set("ETag", userETag(user))
+	render.JSON(res, http.StatusOK, serializers.SerializeUserToJSON(user))
+}
+
 func (h ProfileHandler) getEmail(res http.ResponseWriter, req *http.Request) {
 	userID := getUserIDFromContext(req)
-	user, err := h.ProfileService.Profile(userID)
+	user, err := h.ProfileService.Profile(req.Context(), userID)
 	if err != nil {
 		handleServiceError(res, req, err)
 		return
@@ -163,7 +270,7 @@ func (h ProfileHandler) requestChangeEmail(res http.ResponseWriter, req *http.Re
 	clientInfo := req.Context().Value(contextkey.ClientInfo).(map[string]interface{})
 	userID := getUserIDFromContext(req)
 
-	user, err := h.ProfileService.Profile(userID)
+	user, err := h.ProfileService.Profile(req.Context(), userID)
 	if err != nil {
 		handleServiceError(res, req, err)
 		return
@@ -196,12 +303,12 @@ func (h ProfileHandler) requestChangeEmail(res http.ResponseWriter, req *http.Re
 		return
 	}
 
-	if _, err = h.ProfileService.RequestChangeEmail(user, changeEmailRequest.Email); err != nil {
+	if _, err = h.ProfileService.RequestChangeEmail(req.Context(), user, changeEmailRequest.Email); err != nil {
 		handleServiceError(res, req, err)
 		return
 	}
 
-	defer h.EventService.Log(profile.EventChangeEmailRequest, userID, clientInfo)
+	defer h.EventService.Log(req.Context(), profile.EventChangeEmailRequest, userID, clientInfo)
 	render.JSON(res, http.StatusOK, map[string]interface{}{"success": true})
 }
 
@@ -209,7 +316,7 @@ func (h ProfileHandler) changeEmail(res http.ResponseWriter, req *http.Request)
 	clientInfo := req.Context().Value(contextkey.ClientInfo).(map[string]interface{})
 	userID := getUserIDFromContext(req)
 
-	user, err := h.ProfileService.Profile(userID)
+	user, err := h.ProfileService.Profile(req.Context(), userID)
 	if err != nil {
 		handleServiceError(res, req, err)
 		return
@@ -242,12 +349,12 @@ func (h ProfileHandler) changeEmail(res http.ResponseWriter, req *http.Request)
 		return
 	}
 
-	if err = h.ProfileService.ChangeEmail(user, changeEmailRequest.Token); err != nil {
+	if err = h.ProfileService.ChangeEmail(req.Context(), user, changeEmailRequest.Token); err != nil {
 		handleServiceError(res, req, err)
 		return
 	}
 
-	defer h.EventService.Log(profile.EventChangeEmail, userID, clientInfo)
+	defer h.EventService.Log(req.Context(), profile.EventChangeEmail, userID, clientInfo)
 	render.JSON(res, http.StatusOK, map[string]interface{}{"success": true})
 }
 
@@ -255,7 +362,7 @@ func (h ProfileHandler) changePassword(res http.ResponseWriter, req *http.Reques
 	clientInfo := req.Context().Value(contextkey.ClientInfo).(map[string]interface{})
 	userID := getUserIDFromContext(req)
 
-	user, err := h.ProfileService.Profile(userID)
+	user, err := h.ProfileService.Profile(req.Context(), userID)
 	if err != nil {
 		handleServiceError(res, req, err)
 		return
@@ -297,18 +404,22 @@ func (h ProfileHandler) changePassword(res http.ResponseWriter, req *http.Reques
 
 	oldPassword := changePasswordRequest.CurrentPassword
 	newPassword := changePasswordRequest.NewPassword
-	if err = h.ProfileService.ChangePassword(user, oldPassword, newPassword); err != nil {
+	if err = h.ProfileService.ChangePassword(req.Context(), user, oldPassword, newPassword); err != nil {
+		handleServiceError(res, req, err)
+		return
+	}
+	if err := h.SessionService.RevokeAllTrustedDevices(req.Context(), userID); err != nil {
 		handleServiceError(res, req, err)
 		return
 	}
 
-	defer h.EventService.Log(profile.EventChangePassword, userID, clientInfo)
+	defer h.EventService.Log(req.Context(), profile.EventChangePassword, userID, clientInfo)
 	render.JSON(res, http.StatusOK, map[string]interface{}{"success": true})
 }
 
 func (h ProfileHandler) getTFAStatus(res http.ResponseWriter, req *http.Request) {
 	userID := getUserIDFromContext(req)
-	user, err := h.ProfileService.Profile(userID)
+	user, err := h.ProfileService.Profile(req.Context(), userID)
 	if err != nil {
 		handleServiceError(res, req, err)
 		return
@@ -325,13 +436,13 @@ func (h ProfileHandler) getTFAStatus(res http.ResponseWriter, req *http.Request)
 func (h ProfileHandler) enrollTFA(res http.ResponseWriter, req *http.Request) {
 	userID := getUserIDFromContext(req)
 
-	user, err := h.ProfileService.Profile(userID)
+	user, err := h.ProfileService.Profile(req.Context(), userID)
 	if err != nil {
 		handleServiceError(res, req, err)
 		return
 	}
 
-	secret, qrCodeImageBase64, err := h.ProfileService.EnrollTFA(user)
+	secret, qrCodeImageBase64, err := h.ProfileService.EnrollTFA(req.Context(), user)
 	if err != nil {
 		handleServiceError(res, req, err)
 		return
@@ -347,7 +458,7 @@ func (h ProfileHandler) activateTFA(res http.ResponseWriter, req *http.Request)
 	clientInfo := req.Context().Value(contextkey.ClientInfo).(map[string]interface{})
 	userID := getUserIDFromContext(req)
 
-	user, err := h.ProfileService.Profile(userID)
+	user, err := h.ProfileService.Profile(req.Context(), userID)
 	if err != nil {
 		handleServiceError(res, req, err)
 		return
@@ -380,13 +491,13 @@ func (h ProfileHandler) activateTFA(res http.ResponseWriter, req *http.Request)
 		return
 	}
 
-	backupCodes, err := h.ProfileService.ActivateTFA(user, activateTFARequest.Secret, activateTFARequest.Code)
+	backupCodes, err := h.ProfileService.ActivateTFA(req.Context(), user, activateTFARequest.Secret, activateTFARequest.Code)
 	if err != nil {
 		handleServiceError(res, req, err)
 		return
 	}
 
-	defer h.EventService.Log(profile.EventEnableTFA, userID, clientInfo)
+	defer h.EventService.Log(req.Context(), profile.EventEnableTFA, userID, clientInfo)
 	render.JSON(res, http.StatusOK, map[string]interface{}{"backup_codes": backupCodes})
 }
 
@@ -394,7 +505,7 @@ func (h ProfileHandler) removeTFA(res http.ResponseWriter, req *http.Request) {
 	clientInfo := req.Context().Value(contextkey.ClientInfo).(map[string]interface{})
 	userID := getUserIDFromContext(req)
 
-	user, err := h.ProfileService.Profile(userID)
+	user, err := h.ProfileService.Profile(req.Context(), userID)
 	if err != nil {
 		handleServiceError(res, req, err)
 		return
@@ -427,18 +538,56 @@ func (h ProfileHandler) removeTFA(res http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	if err = h.ProfileService.RemoveTFA(user, removeTFARequest.CurrentPassword); err != nil {
+	if err = h.ProfileService.RemoveTFA(req.Context(), user, removeTFARequest.CurrentPassword); err != nil {
 		handleServiceError(res, req, err)
 		return
 	}
 
-	defer h.EventService.Log(profile.EventDisableTFA, userID, clientInfo)
+	defer h.EventService.Log(req.Context(), profile.EventDisableTFA, userID, clientInfo)
 	render.JSON(res, http.StatusOK, map[string]interface{}{"success": true})
 }
 
+func (h ProfileHandler) getBackupCodesStatus(res http.ResponseWriter, req *http.Request) {
+	userID := getUserIDFromContext(req)
+	user, err := h.ProfileService.Profile(req.Context(), userID)
+	if err != nil {
+		handleServiceError(res, req, err)
+		return
+	}
+
+	response := map[string]interface{}{"remaining": 0, "created_at": nil}
+	if user.TFAEnabled {
+		response["remaining"] = len(user.BackupCodes)
+		if !user.BackupCodesCreatedAt.IsZero() {
+			response["created_at"] = user.BackupCodesCreatedAt
+		}
+	}
+	render.JSON(res, http.StatusOK, response)
+}
+
+func (h ProfileHandler) regenerateBackupCodes(res http.ResponseWriter, req *http.Request) {
+	clientInfo := req.Context().Value(contextkey.ClientInfo).(map[string]interface{})
+	userID := getUserIDFromContext(req)
+	user, err := h.ProfileService.Profile(req.Context(), userID)
+	if err != nil {
+		handleServiceError(res, req, err)
+		return
+	}
+
+	backupCodes, err := h.ProfileService.RegenerateBackupCodes(req.Context(), user)
+	if err != nil {
+		handleServiceError(res, req, err)
+		return
+	}
+
+	defer h.EventService.Log(req.Context(), profile.EventRegenerateBackupCodes, userID, clientInfo)
+	render.JSON(res, http.StatusOK, map[string]interface{}{"backup_codes": backupCodes})
+}
+
 func (h ProfileHandler) deleteAccount(res http.ResponseWriter, req *http.Request) {
+	clientInfo := req.Context().Value(contextkey.ClientInfo).(map[string]interface{})
 	userID := getUserIDFromContext(req)
-	user, err := h.ProfileService.Profile(userID)
+	user, err := h.ProfileService.Profile(req.Context(), userID)
 	if err != nil {
 		handleServiceError(res, req, err)
 		return
@@ -471,24 +620,75 @@ func (h ProfileHandler) deleteAccount(res http.ResponseWriter, req *http.Request
 		return
 	}
 
-	if err = h.ProfileService.DeleteAccount(user, removeTFARequest.CurrentPassword); err != nil {
+	if err = h.ProfileService.DeleteAccount(req.Context(), user, removeTFARequest.CurrentPassword); err != nil {
 		handleServiceError(res, req, err)
 		return
 	}
 
+	// account pending deletion can't sign in, so every session including this one is ended
+	if err := h.SessionService.EndOtherSessions(req.Context(), userID, ""); err != nil {
+		handleServiceError(res, req, err)
+		return
+	}
+	if err := h.SessionService.RevokeAllTrustedDevices(req.Context(), userID); err != nil {
+		handleServiceError(res, req, err)
+		return
+	}
+
+	defer h.EventService.Log(req.Context(), profile.EventDeleteAccount, userID, clientInfo)
+	render.JSON(res, http.StatusOK, map[string]interface{}{"success": true})
+}
+
+//restoreAccount cancel a pending deletion with the token from the deletion email, the user sign in again afterward
+func (h ProfileHandler) restoreAccount(res http.ResponseWriter, req *http.Request) {
+	clientInfo := req.Context().Value(contextkey.ClientInfo).(map[string]interface{})
+	// Read Body, limit to 1 MB //
+	body, err := ioutil.ReadAll(io.LimitReader(req.Body, 1048576))
+	if err != nil {
+		render.FailedToReadBodyError(res, err)
+		return
+	}
+
+	restoreAccountRequest := struct {
+		Token string `json:"token" valid:"required,stringlength(1|256)"`
+	}{}
+
+	// Deserialize
+	if err := json.Unmarshal(body, &restoreAccountRequest); err != nil {
+		render.FailedToUnmarshalJSONError(res, err)
+		return
+	}
+
+	if err := req.Body.Close(); err != nil {
+		render.InternalServerError(res, err)
+		return
+	}
+
+	if ok, err := govalidator.ValidateStruct(restoreAccountRequest); !ok || err != nil {
+		render.InvalidRequestError(res, err)
+		return
+	}
+
+	userID, err := h.ProfileService.RestoreAccount(req.Context(), restoreAccountRequest.Token)
+	if err != nil {
+		handleServiceError(res, req, err)
+		return
+	}
+
+	defer h.EventService.Log(req.Context(), profile.EventRestoreAccount, userID, clientInfo)
 	render.JSON(res, http.StatusOK, map[string]interface{}{"success": true})
 }
 
 func (h ProfileHandler) deletePicture(res http.ResponseWriter, req *http.Request) {
 	userID := getUserIDFromContext(req)
-	user, err := h.ProfileService.Profile(userID)
+	user, err := h.ProfileService.Profile(req.Context(), userID)
 	if err != nil {
 		handleServiceError(res, req, err)
 		return
 	}
 
 	user.PictureURL = ""
-	err = h.ProfileService.SetProfile(user)
+	err = h.ProfileService.SetProfile(req.Context(), user)
 	if err != nil {
 		handleServiceError(res, req, err)
 		return
@@ -499,7 +699,7 @@ func (h ProfileHandler) deletePicture(res http.ResponseWriter, req *http.Request
 
 func (h ProfileHandler) setPicture(res http.ResponseWriter, req *http.Request) {
 	userID := getUserIDFromContext(req)
-	user, err := h.ProfileService.Profile(userID)
+	user, err := h.ProfileService.Profile(req.Context(), userID)
 	if err != nil {
 		handleServiceError(res, req, err)
 		return
@@ -546,7 +746,7 @@ func (h ProfileHandler) setPicture(res http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	if err := h.ProfileService.SetProfilePicture(user, bytes.NewReader(imageBytes)); err != nil {
+	if err := h.ProfileService.SetProfilePicture(req.Context(), user, bytes.NewReader(imageBytes)); err != nil {
 		handleServiceError(res, req, err)
 		return
 	}
@@ -554,6 +754,46 @@ func (h ProfileHandler) setPicture(res http.ResponseWriter, req *http.Request) {
 	render.JSON(res, http.StatusOK, map[string]interface{}{"success": true})
 }
 
+//requestExport queue a data export, the user is emailed a download link once it is ready
+func (h ProfileHandler) requestExport(res http.ResponseWriter, req *http.Request) {
+	clientInfo := req.Context().Value(contextkey.ClientInfo).(map[string]interface{})
+	userID := getUserIDFromContext(req)
+
+	if err := h.Exporter.Enqueue(req.Context(), userID); err != nil {
+		handleServiceError(res, req, err)
+		return
+	}
+
+	defer h.EventService.Log(req.Context(), profile.EventRequestExport, userID, clientInfo)
+	render.JSON(res, http.StatusAccepted, map[string]interface{}{"success": true})
+}
+
+//downloadExport serve the zip of a data export, the token from the emailed link is the only credential
+func (h ProfileHandler) downloadExport(res http.ResponseWriter, req *http.Request) {
+	downloadExportRequest := struct {
+		Token string `valid:"required,stringlength(1|256)"`
+	}{
+		Token: req.URL.Query().Get("token"),
+	}
+
+	if ok, err := govalidator.ValidateStruct(downloadExportRequest); !ok || err != nil {
+		render.InvalidRequestError(res, err)
+		return
+	}
+
+	archive, err := h.ProfileService.DownloadExport(req.Context(), downloadExportRequest.Token)
+	if err != nil {
+		handleServiceError(res, req, err)
+		return
+	}
+
+	res.Header().Set("Content-Type", "application/zip")
+	res.Header().Set("Content-Disposition", `attachment; filename="userland_export.zip"`)
+	res.Header().Set("Cache-Control", "private, no-store")
+	res.WriteHeader(http.StatusOK)
+	res.Write(archive)
+}
+
 func (h ProfileHandler) getEvents(res http.ResponseWriter, req *http.Request) {
 	userID := getUserIDFromContext(req)
 	limit, _ := strconv.Atoi(req.URL.Query().Get("limit"))
@@ -594,7 +834,7 @@ func (h ProfileHandler) getEvents(res http.ResponseWriter, req *http.Request) {
 		SortBy: "timestamp",
 		Order:  order,
 	}
-	events, eventsCount, err := h.EventService.ListEvents(filter, paging)
+	events, eventsCount, err := h.EventService.ListEvents(req.Context(), filter, paging)
 	if err != nil {
 		handleServiceError(res, req, err)
 		return
@@ -622,6 +862,96 @@ func (h ProfileHandler) getEvents(res http.ResponseWriter, req *http.Request) {
 	})
 }
 
+//e164 match an international phone number, eg: +6281234567890
+var e164 = regexp.MustCompile(`^\+[1-9][0-9]{1,14}$`)
+
+//buildProfilePatch validate every member of mergePatch and return the patch with the names of the fields it actually change,
+//a null member remove the field
+func buildProfilePatch(user userland.User, mergePatch map[string]json.RawMessage) (userland.ProfilePatch, []string, error) {
+	patch := userland.ProfilePatch{}
+	fields := []struct {
+		name     string
+		current  string
+		target   **string
+		required bool
+		validate func(value string) bool
+		message  string
+	}{
+		{"fullname", user.Fullname, &patch.Fullname, true, func(value string) bool {
+			return govalidator.StringLength(value, "3", "128")
+		}, "must be 3 to 128 characters"},
+		{"phone", user.Phone, &patch.Phone, false, e164.MatchString, "must be an E.164 phone number"},
+		{"location", user.Location, &patch.Location, false, func(value string) bool {
+			return govalidator.StringLength(value, "1", "128")
+		}, "must be at most 128 characters"},
+		{"bio", user.Bio, &patch.Bio, false, func(value string) bool {
+			return govalidator.StringLength(value, "1", "255")
+		}, "must be at most 255 characters"},
+		{"web", user.WebURL, &patch.WebURL, false, func(value string) bool {
+			return govalidator.StringLength(value, "1", "128") && govalidator.IsURL(value)
+		}, "must be a URL of at most 128 characters"},
+	}
+
+	fieldErrors := govalidator.Errors{}
+	known := map[string]bool{}
+	changedFields := []string{}
+	for _, field := range fields {
+		known[field.name] = true
+		raw, ok := mergePatch[field.name]
+		if !ok {
+			continue
+		}
+
+		value := ""
+		if string(raw) != "null" {
+			if err := json.Unmarshal(raw, &value); err != nil {
+				fieldErrors = append(fieldErrors, govalidator.Error{Name: field.name, Err: errors.New("must be a string or null")})
+				continue
+			}
+		}
+		if len(value) == 0 && field.required {
+			fieldErrors = append(fieldErrors, govalidator.Error{Name: field.name, Err: errors.New("can't be removed")})
+			continue
+		}
+		if len(value) > 0 && !field.validate(value) {
+			fieldErrors = append(fieldErrors, govalidator.Error{Name: field.name, Err: errors.New(field.message)})
+			continue
+		}
+
+		if value != field.current {
+			patchedValue := value
+			*field.target = &patchedValue
+			changedFields = append(changedFields, field.name)
+		}
+	}
+	for name := range mergePatch {
+		if !known[name] {
+			fieldErrors = append(fieldErrors, govalidator.Error{Name: name, Err: errors.New("can't be patched")})
+		}
+	}
+
+	if len(fieldErrors) > 0 {
+		return userland.ProfilePatch{}, nil, fieldErrors
+	}
+	return patch, changedFields, nil
+}
+
+//userETag identify the version of user served by /api/me
+func userETag(user userland.User) string {
+	return fmt.Sprintf(`"%d"`, user.Version)
+}
+
+//matchETag compare If-Match header against etag, weak tags never match
+func matchETag(ifMatch string, etag string) bool {
+	for _, candidate := range strings.Split(ifMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
 func getUserIDFromContext(req *http.Request) int {
 	accessToken := req.Context().Value(contextkey.AccessToken).(map[string]interface{})
 	userID := int(accessToken["userid"].(float64))
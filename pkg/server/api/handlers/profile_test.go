@@ -4,6 +4,8 @@ package handlers_test
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -11,26 +13,37 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/AdhityaRamadhanus/userland"
 	_http "github.com/AdhityaRamadhanus/userland/pkg/common/http"
 	"github.com/AdhityaRamadhanus/userland/pkg/mocks/middlewares"
 	"github.com/AdhityaRamadhanus/userland/pkg/mocks/service/event"
 	"github.com/AdhityaRamadhanus/userland/pkg/mocks/service/profile"
+	"github.com/AdhityaRamadhanus/userland/pkg/mocks/service/session"
 	"github.com/AdhityaRamadhanus/userland/pkg/server/api/handlers"
+	_profile "github.com/AdhityaRamadhanus/userland/pkg/service/profile"
 	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/mock"
 )
 
 func TestProfileHandler_inputValidation(t *testing.T) {
 	profileService := profile.SimpleProfileService{CalledMethods: map[string]bool{}}
+	sessionService := session.SimpleSessionService{CalledMethods: map[string]bool{}}
 	eventService := event.SimpleEventService{CalledMethods: map[string]bool{}}
 
 	profileHandler := handlers.ProfileHandler{
-		RateLimiter:    middlewares.BypassWithArgs,
-		Authorization:  middlewares.BypassWithArgs,
-		Authenticator:  middlewares.Authentication,
-		ProfileService: profileService,
-		EventService:   eventService,
+		RateLimiter:          middlewares.BypassRateLimiter,
+		Authorization:        middlewares.BypassWithArgs,
+		RecentAuthentication: middlewares.BypassWithMaxAge,
+		ClientVerification:   middlewares.Bypass,
+		Authenticator:        middlewares.Authentication,
+		ProfileService:       profileService,
+		SessionService:       sessionService,
+		EventService:         eventService,
 	}
 	router := mux.NewRouter().StrictSlash(true)
 	profileHandler.RegisterRoutes(router)
@@ -126,6 +139,15 @@ func TestProfileHandler_inputValidation(t *testing.T) {
 			},
 			wantStatusCode: http.StatusOK,
 		},
+		{
+			name: "POST api/me/tfa/backup_codes",
+			args: args{
+				method:      http.MethodPost,
+				path:        "api/me/tfa/backup_codes",
+				requestBody: map[string]interface{}{},
+			},
+			wantStatusCode: http.StatusOK,
+		},
 		{
 			name: "DELETE api/me/delete",
 			args: args{
@@ -137,6 +159,26 @@ func TestProfileHandler_inputValidation(t *testing.T) {
 			},
 			wantStatusCode: http.StatusOK,
 		},
+		{
+			name: "POST api/account/restore",
+			args: args{
+				method: http.MethodPost,
+				path:   "api/account/restore",
+				requestBody: map[string]interface{}{
+					"token": "some-restore-token",
+				},
+			},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name: "POST api/account/restore without token",
+			args: args{
+				method:      http.MethodPost,
+				path:        "api/account/restore",
+				requestBody: map[string]interface{}{},
+			},
+			wantStatusCode: http.StatusUnprocessableEntity,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -166,11 +208,13 @@ func TestProfileHandler_setPictureValidation(t *testing.T) {
 	eventService := event.SimpleEventService{CalledMethods: map[string]bool{}}
 
 	profileHandler := handlers.ProfileHandler{
-		RateLimiter:    middlewares.BypassWithArgs,
-		Authorization:  middlewares.BypassWithArgs,
-		Authenticator:  middlewares.Authentication,
-		ProfileService: profileService,
-		EventService:   eventService,
+		RateLimiter:          middlewares.BypassRateLimiter,
+		Authorization:        middlewares.BypassWithArgs,
+		RecentAuthentication: middlewares.BypassWithMaxAge,
+		ClientVerification:   middlewares.Bypass,
+		Authenticator:        middlewares.Authentication,
+		ProfileService:       profileService,
+		EventService:         eventService,
 	}
 	router := mux.NewRouter().StrictSlash(true)
 	profileHandler.RegisterRoutes(router)
@@ -230,3 +274,511 @@ func TestProfileHandler_setPictureValidation(t *testing.T) {
 		})
 	}
 }
+
+func TestProfileHandler_etag(t *testing.T) {
+	type args struct {
+		method        string
+		ifMatch       string
+		setProfileErr error
+	}
+	testCases := []struct {
+		name           string
+		args           args
+		wantStatusCode int
+		wantETag       string
+	}{
+		{
+			name:           "GET api/me",
+			args:           args{method: http.MethodGet},
+			wantStatusCode: http.StatusOK,
+			wantETag:       `"3"`,
+		},
+		{
+			name:           "POST api/me with current version",
+			args:           args{method: http.MethodPost, ifMatch: `"3"`},
+			wantStatusCode: http.StatusOK,
+			wantETag:       `"4"`,
+		},
+		{
+			name:           "POST api/me with stale version",
+			args:           args{method: http.MethodPost, ifMatch: `"2"`},
+			wantStatusCode: http.StatusPreconditionFailed,
+		},
+		{
+			name:           "POST api/me with current version modified concurrently",
+			args:           args{method: http.MethodPost, ifMatch: `"3"`, setProfileErr: userland.ErrConcurrentModification},
+			wantStatusCode: http.StatusPreconditionFailed,
+		},
+		{
+			name:           "POST api/me without If-Match modified concurrently",
+			args:           args{method: http.MethodPost, setProfileErr: userland.ErrConcurrentModification},
+			wantStatusCode: http.StatusConflict,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			profileService := profile.ProfileService{}
+			profileService.On("Profile", 1).Return(userland.User{ID: 1, Fullname: "adhitya ramadhanus", Version: 3}, nil)
+			profileService.On("SetProfile", mock.Anything).Return(tc.args.setProfileErr)
+			profileHandler := handlers.ProfileHandler{
+				RateLimiter:          middlewares.BypassRateLimiter,
+				Authorization:        middlewares.BypassWithArgs,
+				RecentAuthentication: middlewares.BypassWithMaxAge,
+				ClientVerification:   middlewares.Bypass,
+				Authenticator:        middlewares.Authentication,
+				ProfileService:       &profileService,
+				EventService:         event.SimpleEventService{CalledMethods: map[string]bool{}},
+			}
+			router := mux.NewRouter().StrictSlash(true)
+			profileHandler.RegisterRoutes(router)
+			ts := httptest.NewServer(middlewares.ClientParser(router))
+			defer ts.Close()
+
+			req, err := _http.CreateJSONRequest(tc.args.method, fmt.Sprintf("%s/api/me", ts.URL), map[string]interface{}{
+				"fullname": "adhitya ramadhanus",
+			})
+			if err != nil {
+				t.Fatalf("_http.CreateJSONRequest() err = %v; want nil", err)
+			}
+			if len(tc.args.ifMatch) > 0 {
+				req.Header.Set("If-Match", tc.args.ifMatch)
+			}
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("http.DefaultClient.Do() err = %v; want nil", err)
+			}
+			defer res.Body.Close()
+
+			if res.StatusCode != tc.wantStatusCode {
+				t.Errorf("res.StatusCode = %d; want %d", res.StatusCode, tc.wantStatusCode)
+			}
+			if etag := res.Header.Get("ETag"); etag != tc.wantETag {
+				t.Errorf("res.Header.Get(ETag) = %q; want %q", etag, tc.wantETag)
+			}
+		})
+	}
+}
+
+func TestProfileHandler_backupCodesStatus(t *testing.T) {
+	createdAt := time.Date(2019, time.January, 1, 0, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		name          string
+		user          userland.User
+		wantCreatedAt interface{}
+	}{
+		{
+			name:          "tfa disabled",
+			user:          userland.User{ID: 1},
+			wantCreatedAt: nil,
+		},
+		{
+			name:          "tfa enabled without backup codes",
+			user:          userland.User{ID: 1, TFAEnabled: true},
+			wantCreatedAt: nil,
+		},
+		{
+			name:          "tfa enabled",
+			user:          userland.User{ID: 1, TFAEnabled: true, BackupCodes: []string{"x"}, BackupCodesCreatedAt: createdAt},
+			wantCreatedAt: createdAt.Format(time.RFC3339),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			profileService := profile.ProfileService{}
+			profileService.On("Profile", 1).Return(tc.user, nil)
+			profileHandler := handlers.ProfileHandler{
+				RateLimiter:          middlewares.BypassRateLimiter,
+				Authorization:        middlewares.BypassWithArgs,
+				RecentAuthentication: middlewares.BypassWithMaxAge,
+				ClientVerification:   middlewares.Bypass,
+				Authenticator:        middlewares.Authentication,
+				ProfileService:       &profileService,
+				EventService:         event.SimpleEventService{CalledMethods: map[string]bool{}},
+			}
+			router := mux.NewRouter().StrictSlash(true)
+			profileHandler.RegisterRoutes(router)
+			ts := httptest.NewServer(middlewares.ClientParser(router))
+			defer ts.Close()
+
+			res, err := http.Get(fmt.Sprintf("%s/api/me/tfa/backup_codes", ts.URL))
+			if err != nil {
+				t.Fatalf("http.Get() err = %v; want nil", err)
+			}
+			defer res.Body.Close()
+
+			response := map[string]interface{}{}
+			if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+				t.Fatalf("json.Decode() err = %v; want nil", err)
+			}
+			if createdAt, ok := response["created_at"]; !ok || createdAt != tc.wantCreatedAt {
+				t.Errorf("response[created_at] = %v; want %v", createdAt, tc.wantCreatedAt)
+			}
+		})
+	}
+}
+
+//changesEventService send the changed fields of every LogChanges call to changes
+type changesEventService struct {
+	event.SimpleEventService
+	changes chan []string
+}
+
+func (c changesEventService) LogChanges(ctx context.Context, eventName string, userID int, clientInfo map[string]interface{}, changedFields []string) error {
+	c.changes <- changedFields
+	return nil
+}
+
+func TestProfileHandler_patch(t *testing.T) {
+	newBio := "new bio"
+	newPhone := "+6281234567890"
+	removed := ""
+	type args struct {
+		patch            string
+		ifMatch          string
+		updateProfileErr error
+	}
+	testCases := []struct {
+		name              string
+		args              args
+		wantPatch         *userland.ProfilePatch
+		wantVersion       int
+		wantStatusCode    int
+		wantChangedFields []string
+	}{
+		{
+			name:              "PATCH api/me set and remove fields",
+			args:              args{patch: `{"bio": "new bio", "location": null, "phone": "+6281234567890"}`},
+			wantPatch:         &userland.ProfilePatch{Bio: &newBio, Location: &removed, Phone: &newPhone},
+			wantStatusCode:    http.StatusOK,
+			wantChangedFields: []string{"phone", "location", "bio"},
+		},
+		{
+			name:              "PATCH api/me with current version",
+			args:              args{patch: `{"bio": "new bio"}`, ifMatch: `"3"`},
+			wantPatch:         &userland.ProfilePatch{Bio: &newBio},
+			wantVersion:       3,
+			wantStatusCode:    http.StatusOK,
+			wantChangedFields: []string{"bio"},
+		},
+		{
+			name:           "PATCH api/me without change",
+			args:           args{patch: `{"fullname": "adhitya ramadhanus", "web": null}`},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name:           "PATCH api/me with stale version",
+			args:           args{patch: `{"bio": "new bio"}`, ifMatch: `"2"`},
+			wantStatusCode: http.StatusPreconditionFailed,
+		},
+		{
+			name:           "PATCH api/me with current version modified concurrently",
+			args:           args{patch: `{"bio": "new bio"}`, ifMatch: `"3"`, updateProfileErr: userland.ErrConcurrentModification},
+			wantPatch:      &userland.ProfilePatch{Bio: &newBio},
+			wantVersion:    3,
+			wantStatusCode: http.StatusPreconditionFailed,
+		},
+		{
+			name:           "PATCH api/me invalid phone",
+			args:           args{patch: `{"phone": "081234567890"}`},
+			wantStatusCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "PATCH api/me invalid web",
+			args:           args{patch: `{"web": "not a url"}`},
+			wantStatusCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "PATCH api/me bio too long",
+			args:           args{patch: fmt.Sprintf(`{"bio": %q}`, strings.Repeat("a", 256))},
+			wantStatusCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "PATCH api/me remove fullname",
+			args:           args{patch: `{"fullname": null}`},
+			wantStatusCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "PATCH api/me non string value",
+			args:           args{patch: `{"location": 42}`},
+			wantStatusCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "PATCH api/me unknown field",
+			args:           args{patch: `{"email": "adhitya@example.com"}`},
+			wantStatusCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "PATCH api/me non object patch",
+			args:           args{patch: `["bio"]`},
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:           "PATCH api/me null patch",
+			args:           args{patch: `null`},
+			wantStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			user := userland.User{ID: 1, Fullname: "adhitya ramadhanus", Location: "Jakarta", Version: 3}
+			patchedUser := user
+			patchedUser.Version = 4
+			profileService := profile.ProfileService{}
+			profileService.On("Profile", 1).Return(user, nil).Once()
+			profileService.On("Profile", 1).Return(patchedUser, nil)
+			if tc.wantPatch != nil {
+				profileService.On("UpdateProfile", 1, tc.wantVersion, *tc.wantPatch).Return(tc.args.updateProfileErr)
+			}
+			eventService := changesEventService{
+				SimpleEventService: event.SimpleEventService{CalledMethods: map[string]bool{}},
+				changes:            make(chan []string, 1),
+			}
+			profileHandler := handlers.ProfileHandler{
+				RateLimiter:          middlewares.BypassRateLimiter,
+				Authorization:        middlewares.BypassWithArgs,
+				RecentAuthentication: middlewares.BypassWithMaxAge,
+				ClientVerification:   middlewares.Bypass,
+				Authenticator:        middlewares.Authentication,
+				ProfileService:       &profileService,
+				EventService:         eventService,
+			}
+			router := mux.NewRouter().StrictSlash(true)
+			profileHandler.RegisterRoutes(router)
+			ts := httptest.NewServer(middlewares.ClientParser(router))
+			defer ts.Close()
+
+			req, err := _http.CreateJSONRequest(http.MethodPatch, fmt.Sprintf("%s/api/me", ts.URL), json.RawMessage(tc.args.patch))
+			if err != nil {
+				t.Fatalf("_http.CreateJSONRequest() err = %v; want nil", err)
+			}
+			req.Header.Set("Content-Type", "application/merge-patch+json")
+			if len(tc.args.ifMatch) > 0 {
+				req.Header.Set("If-Match", tc.args.ifMatch)
+			}
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("http.DefaultClient.Do() err = %v; want nil", err)
+			}
+			defer res.Body.Close()
+
+			if res.StatusCode != tc.wantStatusCode {
+				t.Fatalf("res.StatusCode = %d; want %d", res.StatusCode, tc.wantStatusCode)
+			}
+			if tc.wantPatch == nil {
+				profileService.AssertNotCalled(t, "UpdateProfile", mock.Anything, mock.Anything, mock.Anything)
+			}
+			if tc.wantChangedFields == nil {
+				return
+			}
+
+			if etag := res.Header.Get("ETag"); etag != `"4"` {
+				t.Errorf("res.Header.Get(ETag) = %q; want %q", etag, `"4"`)
+			}
+			select {
+			case changedFields := <-eventService.changes:
+				if !reflect.DeepEqual(changedFields, tc.wantChangedFields) {
+					t.Errorf("EventService.LogChanges() changedFields = %v; want %v", changedFields, tc.wantChangedFields)
+				}
+			case <-time.After(time.Second):
+				t.Errorf("EventService.LogChanges() not called; want changedFields %v", tc.wantChangedFields)
+			}
+		})
+	}
+}
+
+func TestProfileHandler_deleteAccount(t *testing.T) {
+	profileService := profile.SimpleProfileService{CalledMethods: map[string]bool{}}
+	sessionService := session.SimpleSessionService{CalledMethods: map[string]bool{}}
+	eventService := event.SimpleEventService{CalledMethods: map[string]bool{}}
+
+	profileHandler := handlers.ProfileHandler{
+		RateLimiter:          middlewares.BypassRateLimiter,
+		Authorization:        middlewares.BypassWithArgs,
+		RecentAuthentication: middlewares.BypassWithMaxAge,
+		ClientVerification:   middlewares.Bypass,
+		Authenticator:        middlewares.Authentication,
+		ProfileService:       profileService,
+		SessionService:       sessionService,
+		EventService:         eventService,
+	}
+	router := mux.NewRouter().StrictSlash(true)
+	profileHandler.RegisterRoutes(router)
+
+	ts := httptest.NewServer(middlewares.ClientParser(router))
+	defer ts.Close()
+
+	req, err := _http.CreateJSONRequest(http.MethodDelete, fmt.Sprintf("%s/api/me/delete", ts.URL), map[string]interface{}{"password": "123123"})
+	if err != nil {
+		t.Fatalf("_http.CreateJSONRequest() err = %v; want nil", err)
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("http.DefaultClient.Do() err = %v; want nil", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("res.StatusCode = %d; want %d", res.StatusCode, http.StatusOK)
+	}
+
+	// pending account can't sign in, so no session should outlive the request
+	for _, method := range []string{"DeleteAccount", "EndOtherSessions", "RevokeAllTrustedDevices"} {
+		if !profileService.CalledMethods[method] && !sessionService.CalledMethods[method] {
+			t.Errorf("%s is not called", method)
+		}
+	}
+}
+
+func TestProfileHandler_export(t *testing.T) {
+	profileService := profile.ProfileService{}
+	profileService.On("RequestExport", mock.Anything).Return(nil).Once()
+	profileService.On("RequestExport", mock.Anything).Return(_profile.ErrExportQueueFull)
+	profileService.On("DownloadExport", "some-export-token").Return([]byte("PK"), nil)
+	profileService.On("DownloadExport", "some-gibberish-token").Return(nil, _profile.ErrExportInvalid)
+	eventService := event.SimpleEventService{CalledMethods: map[string]bool{}}
+
+	profileHandler := handlers.ProfileHandler{
+		RateLimiter:          middlewares.BypassRateLimiter,
+		Authorization:        middlewares.BypassWithArgs,
+		RecentAuthentication: middlewares.BypassWithMaxAge,
+		ClientVerification:   middlewares.Bypass,
+		Authenticator:        middlewares.Authentication,
+		ProfileService:       &profileService,
+		EventService:         eventService,
+		// the exporter is not run, the queue is full after one request
+		Exporter: _profile.NewExporter(&profileService),
+	}
+	router := mux.NewRouter().StrictSlash(true)
+	profileHandler.RegisterRoutes(router)
+
+	ts := httptest.NewServer(middlewares.ClientParser(router))
+	defer ts.Close()
+
+	type args struct {
+		method string
+		path   string
+	}
+	testCases := []struct {
+		name            string
+		args            args
+		wantStatusCode  int
+		wantContentType string
+	}{
+		{
+			name:           "POST api/me/export",
+			args:           args{method: http.MethodPost, path: "api/me/export"},
+			wantStatusCode: http.StatusAccepted,
+		},
+		{
+			name:           "POST api/me/export with full queue",
+			args:           args{method: http.MethodPost, path: "api/me/export"},
+			wantStatusCode: http.StatusServiceUnavailable,
+		},
+		{
+			name:            "GET api/account/export",
+			args:            args{method: http.MethodGet, path: "api/account/export?token=some-export-token"},
+			wantStatusCode:  http.StatusOK,
+			wantContentType: "application/zip",
+		},
+		{
+			name:           "GET api/account/export with invalid token",
+			args:           args{method: http.MethodGet, path: "api/account/export?token=some-gibberish-token"},
+			wantStatusCode: http.StatusNotFound,
+		},
+		{
+			name:           "GET api/account/export without token",
+			args:           args{method: http.MethodGet, path: "api/account/export"},
+			wantStatusCode: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(tc.args.method, fmt.Sprintf("%s/%s", ts.URL, tc.args.path), nil)
+			if err != nil {
+				t.Fatalf("http.NewRequest() err = %v; want nil", err)
+			}
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("http.DefaultClient.Do() err = %v; want nil", err)
+			}
+			defer res.Body.Close()
+
+			if res.StatusCode != tc.wantStatusCode {
+				body, _ := ioutil.ReadAll(res.Body)
+				t.Logf("response %s\n", string(body))
+				t.Fatalf("res.StatusCode = %d; want %d", res.StatusCode, tc.wantStatusCode)
+			}
+			if tc.wantContentType != "" && res.Header.Get("Content-Type") != tc.wantContentType {
+				t.Errorf("res.Header.Get(Content-Type) = %q; want %q", res.Header.Get("Content-Type"), tc.wantContentType)
+			}
+		})
+	}
+}
+
+func TestProfileHandler_exportWithClientVerification(t *testing.T) {
+	profileService := profile.ProfileService{}
+	profileService.On("DownloadExport", "some-export-token").Return([]byte("PK"), nil)
+
+	// every client is unknown, as when reject_unknown is on and a browser open the emailed link
+	rejectClient := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			res.WriteHeader(http.StatusUnauthorized)
+		})
+	}
+	profileHandler := handlers.ProfileHandler{
+		RateLimiter:          middlewares.BypassRateLimiter,
+		Authorization:        middlewares.BypassWithArgs,
+		RecentAuthentication: middlewares.BypassWithMaxAge,
+		ClientVerification:   rejectClient,
+		Authenticator:        middlewares.Authentication,
+		ProfileService:       &profileService,
+		EventService:         event.SimpleEventService{CalledMethods: map[string]bool{}},
+		Exporter:             _profile.NewExporter(&profileService),
+	}
+	router := mux.NewRouter().StrictSlash(true)
+	profileHandler.RegisterRoutes(router)
+
+	ts := httptest.NewServer(middlewares.ClientParser(router))
+	defer ts.Close()
+
+	testCases := []struct {
+		name           string
+		method         string
+		path           string
+		wantStatusCode int
+	}{
+		{
+			name:           "POST api/me/export",
+			method:         http.MethodPost,
+			path:           "api/me/export",
+			wantStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:           "GET api/account/export",
+			method:         http.MethodGet,
+			path:           "api/account/export?token=some-export-token",
+			wantStatusCode: http.StatusOK,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(tc.method, fmt.Sprintf("%s/%s", ts.URL, tc.path), nil)
+			if err != nil {
+				t.Fatalf("http.NewRequest() err = %v; want nil", err)
+			}
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("http.DefaultClient.Do() err = %v; want nil", err)
+			}
+			defer res.Body.Close()
+
+			if res.StatusCode != tc.wantStatusCode {
+				t.Fatalf("res.StatusCode = %d; want %d", res.StatusCode, tc.wantStatusCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/AdhityaRamadhanus/userland"
+	"github.com/AdhityaRamadhanus/userland/pkg/common/http/middlewares"
+	"github.com/AdhityaRamadhanus/userland/pkg/common/http/render"
+	"github.com/AdhityaRamadhanus/userland/pkg/server/api/serializers"
+	"github.com/gorilla/mux"
+)
+
+var (
+	//DefaultRevocationStreamDuration is how long a stream is held open, clients reconnect with Last-Event-ID
+	DefaultRevocationStreamDuration = 5 * time.Minute
+	//RevocationStreamKeepAlive is the interval of comment lines sent to keep idle streams open
+	RevocationStreamKeepAlive = 5 * time.Second
+)
+
+type RevocationHandler struct {
+	Authenticator     middlewares.Middleware
+	RevocationService userland.RevocationService
+	StreamDuration    time.Duration
+}
+
+//RegisterRoutes register nothing, revocations are only streamed, see RegisterStreamingRoutes
+func (h RevocationHandler) RegisterRoutes(router *mux.Router) {}
+
+func (h RevocationHandler) RegisterStreamingRoutes(router *mux.Router) {
+	subRouter := router.PathPrefix("/api").Subrouter()
+
+	authenticate := h.Authenticator
+
+	streamRevocations := authenticate(http.HandlerFunc(h.streamRevocations))
+
+	subRouter.Handle("/internal/revocations", streamRevocations).Methods("GET")
+}
+
+//streamRevocations replay revocations after Last-Event-ID (or since query, both in epoch millis) then stream new ones as server-sent events
+func (h RevocationHandler) streamRevocations(res http.ResponseWriter, req *http.Request) {
+	flusher, ok := res.(http.Flusher)
+	if !ok {
+		render.JSON(res, http.StatusInternalServerError, map[string]interface{}{
+			"status": http.StatusInternalServerError,
+			"error": map[string]interface{}{
+				"code":    "ErrStreamingUnsupported",
+				"message": "Streaming is not supported",
+			},
+		})
+		return
+	}
+
+	lastEventID := req.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = req.URL.Query().Get("since")
+	}
+	var since time.Time
+	if lastEventID != "" {
+		sinceMillis, err := strconv.ParseInt(lastEventID, 10, 64)
+		if err != nil {
+			render.JSON(res, http.StatusBadRequest, map[string]interface{}{
+				"status": http.StatusBadRequest,
+				"error": map[string]interface{}{
+					"code":    "ErrInvalidRequest",
+					"message": "Last-Event-ID/since must be epoch in milliseconds",
+				},
+			})
+			return
+		}
+		since = time.Unix(0, sinceMillis*int64(time.Millisecond))
+	}
+
+	// subscribe before replaying so nothing published in between is lost, duplicates are harmless for a deny list
+	subscription, err := h.RevocationService.Subscribe(req.Context())
+	if err != nil {
+		handleServiceError(res, req, err)
+		return
+	}
+	defer subscription.Close()
+
+	revocations := userland.SessionRevocations{}
+	if !since.IsZero() {
+		revocations, err = h.RevocationService.FindAllSince(req.Context(), since)
+		if err != nil {
+			handleServiceError(res, req, err)
+			return
+		}
+	}
+
+	res.Header().Set("Content-Type", "text/event-stream")
+	res.Header().Set("Cache-Control", "no-cache")
+	res.Header().Set("X-Accel-Buffering", "no")
+	res.WriteHeader(http.StatusOK)
+
+	fmt.Fprintf(res, "retry: %d\n\n", time.Second/time.Millisecond)
+	for _, revocation := range revocations {
+		writeRevocationEvent(res, revocation)
+	}
+	flusher.Flush()
+
+	streamDuration := h.StreamDuration
+	if streamDuration <= 0 {
+		streamDuration = DefaultRevocationStreamDuration
+	}
+	streamTimer := time.NewTimer(streamDuration)
+	defer streamTimer.Stop()
+	keepAliveTicker := time.NewTicker(RevocationStreamKeepAlive)
+	defer keepAliveTicker.Stop()
+
+	for {
+		select {
+		case <-req.Context().Done():
+			return
+		case <-streamTimer.C:
+			return
+		case <-keepAliveTicker.C:
+			fmt.Fprint(res, ": keep-alive\n\n")
+			flusher.Flush()
+		case revocation, ok := <-subscription.Revocations():
+			if !ok {
+				return
+			}
+			writeRevocationEvent(res, revocation)
+			flusher.Flush()
+		}
+	}
+}
+
+func writeRevocationEvent(res http.ResponseWriter, revocation userland.SessionRevocation) {
+	data, err := json.Marshal(serializers.SerializeSessionRevocationToJSON(revocation))
+	if err != nil {
+		return
+	}
+	eventID := revocation.RevokedAt.UnixNano() / int64(time.Millisecond)
+	fmt.Fprintf(res, "id: %d\nevent: revocation\ndata: %s\n\n", eventID, data)
+}
@@ -0,0 +1,100 @@
+//+build unit
+
+package handlers_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AdhityaRamadhanus/userland"
+	"github.com/AdhityaRamadhanus/userland/pkg/mocks/middlewares"
+	"github.com/AdhityaRamadhanus/userland/pkg/mocks/repository"
+	"github.com/AdhityaRamadhanus/userland/pkg/server/api/handlers"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRevocationHandler_streamRevocations(t *testing.T) {
+	revokedAt := time.Now()
+	replayedRevocation := userland.SessionRevocation{SessionID: "replayed-session", UserID: 1, Reason: userland.SessionEndReasonLogout, RevokedAt: revokedAt}
+	liveRevocation := userland.SessionRevocation{SessionID: "live-session", UserID: 1, Reason: userland.SessionEndReasonRevoked, RevokedAt: revokedAt}
+
+	type args struct {
+		query string
+	}
+	testCases := []struct {
+		name           string
+		args           args
+		wantStatusCode int
+		wantSessionIDs []string
+	}{
+		{
+			name: "live only",
+			args: args{
+				query: "",
+			},
+			wantStatusCode: http.StatusOK,
+			wantSessionIDs: []string{liveRevocation.SessionID},
+		},
+		{
+			name: "replay since",
+			args: args{
+				query: fmt.Sprintf("?since=%d", revokedAt.Add(-time.Minute).UnixNano()/int64(time.Millisecond)),
+			},
+			wantStatusCode: http.StatusOK,
+			wantSessionIDs: []string{replayedRevocation.SessionID, liveRevocation.SessionID},
+		},
+		{
+			name: "invalid since",
+			args: args{
+				query: "?since=yesterday",
+			},
+			wantStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// closing the feed end the stream
+			subscription := repository.RevocationSubscription{RevocationsChan: make(chan userland.SessionRevocation, 1)}
+			subscription.RevocationsChan <- liveRevocation
+			close(subscription.RevocationsChan)
+
+			revocationService := repository.RevocationService{}
+			revocationService.On("Subscribe").Return(subscription, nil)
+			revocationService.On("FindAllSince", mock.Anything).Return(userland.SessionRevocations{replayedRevocation}, nil)
+
+			revocationHandler := handlers.RevocationHandler{
+				Authenticator:     middlewares.Bypass,
+				RevocationService: &revocationService,
+			}
+			router := mux.NewRouter().StrictSlash(true)
+			revocationHandler.RegisterStreamingRoutes(router)
+
+			ts := httptest.NewServer(router)
+			defer ts.Close()
+
+			res, err := http.Get(fmt.Sprintf("%s/api/internal/revocations%s", ts.URL, tc.args.query))
+			if err != nil {
+				t.Fatalf("http.Get() err = %v; want nil", err)
+			}
+			defer res.Body.Close()
+			body, _ := ioutil.ReadAll(res.Body)
+
+			if res.StatusCode != tc.wantStatusCode {
+				t.Logf("response %s\n", string(body))
+				t.Fatalf("res.StatusCode = %d; want %d", res.StatusCode, tc.wantStatusCode)
+			}
+			for _, sessionID := range tc.wantSessionIDs {
+				if !strings.Contains(string(body), sessionID) {
+					t.Errorf("response %s doesn't contain %s", string(body), sessionID)
+				}
+			}
+		})
+	}
+}
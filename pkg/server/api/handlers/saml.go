@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/AdhityaRamadhanus/userland"
+	"github.com/AdhityaRamadhanus/userland/pkg/common/contextkey"
+	"github.com/AdhityaRamadhanus/userland/pkg/common/http/middlewares"
+	"github.com/AdhityaRamadhanus/userland/pkg/common/http/render"
+	"github.com/AdhityaRamadhanus/userland/pkg/common/security"
+	"github.com/AdhityaRamadhanus/userland/pkg/server/api/serializers"
+	"github.com/AdhityaRamadhanus/userland/pkg/service/event"
+	"github.com/AdhityaRamadhanus/userland/pkg/service/saml"
+	"github.com/AdhityaRamadhanus/userland/pkg/service/session"
+	"github.com/asaskevich/govalidator"
+	"github.com/gorilla/mux"
+)
+
+type SAMLHandler struct {
+	AdminAuthenticator middlewares.Middleware
+	SAMLService        saml.Service
+	SessionService     session.Service
+	EventService       event.Service
+	// ExposeJWT add the signed JWT to issued access tokens, for route groups with stateless authentication
+	ExposeJWT bool
+}
+
+func (h SAMLHandler) RegisterRoutes(router *mux.Router) {
+	subRouter := router.PathPrefix("/api").Subrouter()
+	// middlewares
+	authenticateAdmin := h.AdminAuthenticator
+
+	importIdentityProvider := authenticateAdmin(http.HandlerFunc(h.importIdentityProvider))
+	getMetadata := http.HandlerFunc(h.getMetadata)
+	consumeAssertion := http.HandlerFunc(h.consumeAssertion)
+
+	subRouter.Handle("/saml/{tenant:[a-z0-9-]+}/idp", importIdentityProvider).Methods("POST")
+	subRouter.Handle("/saml/{tenant:[a-z0-9-]+}/metadata", getMetadata).Methods("GET")
+	subRouter.Handle("/saml/{tenant:[a-z0-9-]+}/acs", consumeAssertion).Methods("POST")
+}
+
+func (h SAMLHandler) importIdentityProvider(res http.ResponseWriter, req *http.Request) {
+	tenant := mux.Vars(req)["tenant"]
+	// Read Body, limit to 1 MB //
+	body, err := ioutil.ReadAll(io.LimitReader(req.Body, 1048576))
+	if err != nil {
+		render.FailedToReadBodyError(res, err)
+		return
+	}
+
+	importIdentityProviderRequest := struct {
+		Metadata         string            `json:"metadata" valid:"required"`
+		AttributeMapping map[string]string `json:"attribute_mapping"`
+		// accounts are only provisioned or linked for emails in these domains
+		Domains []string `json:"domains" valid:"required"`
+	}{}
+
+	// Deserialize
+	if err := json.Unmarshal(body, &importIdentityProviderRequest); err != nil {
+		render.FailedToUnmarshalJSONError(res, err)
+		return
+	}
+
+	if err := req.Body.Close(); err != nil {
+		render.InternalServerError(res, err)
+		return
+	}
+
+	if ok, err := govalidator.ValidateStruct(importIdentityProviderRequest); !ok || err != nil {
+		render.InvalidRequestError(res, err)
+		return
+	}
+
+	metadata := []byte(importIdentityProviderRequest.Metadata)
+	attributeMapping := importIdentityProviderRequest.AttributeMapping
+	identityProvider, err := h.SAMLService.ImportIdentityProvider(req.Context(), tenant, metadata, attributeMapping, importIdentityProviderRequest.Domains)
+	if err != nil {
+		handleServiceError(res, req, err)
+		return
+	}
+
+	render.JSON(res, http.StatusOK, map[string]interface{}{
+		"identity_provider": serializers.SerializeIdentityProviderToJSON(identityProvider),
+	})
+}
+
+func (h SAMLHandler) getMetadata(res http.ResponseWriter, req *http.Request) {
+	tenant := mux.Vars(req)["tenant"]
+	metadata, err := h.SAMLService.ServiceProviderMetadata(req.Context(), tenant)
+	if err != nil {
+		handleServiceError(res, req, err)
+		return
+	}
+
+	res.Header().Set("Content-Type", "application/samlmetadata+xml")
+	res.WriteHeader(http.StatusOK)
+	res.Write(metadata)
+}
+
+func (h SAMLHandler) consumeAssertion(res http.ResponseWriter, req *http.Request) {
+	clientInfo := req.Context().Value(contextkey.ClientInfo).(map[string]interface{})
+	tenant := mux.Vars(req)["tenant"]
+
+	req.Body = http.MaxBytesReader(res, req.Body, 1048576)
+	if err := req.ParseForm(); err != nil {
+		render.FailedToReadBodyError(res, err)
+		return
+	}
+
+	consumeAssertionRequest := struct {
+		SAMLResponse string `valid:"required"`
+	}{
+		SAMLResponse: req.PostForm.Get("SAMLResponse"),
+	}
+
+	if ok, err := govalidator.ValidateStruct(consumeAssertionRequest); !ok || err != nil {
+		render.InvalidRequestError(res, err)
+		return
+	}
+
+	user, requireTFA, accessToken, err := h.SAMLService.ConsumeAssertion(req.Context(), tenant, consumeAssertionRequest.SAMLResponse)
+	if err != nil {
+		handleServiceError(res, req, err)
+		return
+	}
+
+	// tfa token is exchanged at /auth/tfa/verify, which create the session
+	if !requireTFA {
+		if err := h.SessionService.CreateSession(req.Context(), user.ID, userland.Session{
+			ID:         accessToken.Key,
+			Token:      accessToken.Value,
+			IP:         clientInfo["ip"].(string),
+			ClientID:   clientInfo["client_id"].(int),
+			ClientName: clientInfo["client_name"].(string),
+			UserAgent:  clientInfo["user_agent"].(string),
+			Expiration: security.UserAccessTokenExpiration,
+		}); err != nil {
+			handleServiceError(res, req, err)
+			return
+		}
+	}
+
+	defer h.EventService.Log(req.Context(), saml.EventSAMLLogin, user.ID, clientInfo)
+	render.JSON(res, http.StatusOK, map[string]interface{}{
+		"require_tfa":  requireTFA,
+		"access_token": serializers.SerializeAccessTokenToJSON(accessToken, h.ExposeJWT),
+	})
+}
@@ -0,0 +1,174 @@
+//+build unit
+
+package handlers_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	_http "github.com/AdhityaRamadhanus/userland/pkg/common/http"
+	"github.com/AdhityaRamadhanus/userland/pkg/mocks/middlewares"
+	"github.com/AdhityaRamadhanus/userland/pkg/mocks/service/event"
+	"github.com/AdhityaRamadhanus/userland/pkg/mocks/service/saml"
+	"github.com/AdhityaRamadhanus/userland/pkg/mocks/service/session"
+	"github.com/AdhityaRamadhanus/userland/pkg/server/api/handlers"
+	"github.com/gorilla/mux"
+)
+
+func TestSAMLHandler_inputValidation(t *testing.T) {
+	samlService := saml.SimpleSAMLService{CalledMethods: map[string]bool{}}
+	sessionService := session.SimpleSessionService{CalledMethods: map[string]bool{}}
+	eventService := event.SimpleEventService{CalledMethods: map[string]bool{}}
+
+	samlHandler := handlers.SAMLHandler{
+		AdminAuthenticator: middlewares.Bypass,
+		SAMLService:        samlService,
+		SessionService:     sessionService,
+		EventService:       eventService,
+	}
+	router := mux.NewRouter().StrictSlash(true)
+	samlHandler.RegisterRoutes(router)
+
+	ts := httptest.NewServer(middlewares.ClientParser(router))
+	defer ts.Close()
+
+	type args struct {
+		path        string
+		method      string
+		requestBody map[string]interface{}
+	}
+	testCases := []struct {
+		name           string
+		args           args
+		wantStatusCode int
+	}{
+		{
+			name: "POST api/saml/{tenant}/idp",
+			args: args{
+				method: http.MethodPost,
+				path:   "api/saml/acme/idp",
+				requestBody: map[string]interface{}{
+					"metadata": "<EntityDescriptor/>",
+					"attribute_mapping": map[string]string{
+						"fullname": "displayName",
+					},
+					"domains": []string{"acme.com"},
+				},
+			},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name: "POST api/saml/{tenant}/idp without domains",
+			args: args{
+				method: http.MethodPost,
+				path:   "api/saml/acme/idp",
+				requestBody: map[string]interface{}{
+					"metadata": "<EntityDescriptor/>",
+				},
+			},
+			wantStatusCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name: "POST api/saml/{tenant}/idp without metadata",
+			args: args{
+				method:      http.MethodPost,
+				path:        "api/saml/acme/idp",
+				requestBody: map[string]interface{}{},
+			},
+			wantStatusCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name: "GET api/saml/{tenant}/metadata",
+			args: args{
+				method: http.MethodGet,
+				path:   "api/saml/acme/metadata",
+			},
+			wantStatusCode: http.StatusOK,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			url := fmt.Sprintf("%s/%s", ts.URL, tc.args.path)
+			req, err := _http.CreateJSONRequest(tc.args.method, url, tc.args.requestBody)
+			if err != nil {
+				t.Fatalf("_http.CreateJSONRequest() err = %v; want nil", err)
+			}
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("http.DefaultClient.Do() err = %v; want nil", err)
+			}
+			statusCode := res.StatusCode
+			if statusCode != tc.wantStatusCode {
+				body, _ := ioutil.ReadAll(res.Body)
+				defer res.Body.Close()
+				t.Logf("response %s\n", string(body))
+				t.Errorf("%s res.StatusCode = %d; want %d", tc.args.path, statusCode, tc.wantStatusCode)
+			}
+		})
+	}
+}
+
+func TestSAMLHandler_consumeAssertionValidation(t *testing.T) {
+	samlService := saml.SimpleSAMLService{CalledMethods: map[string]bool{}}
+	sessionService := session.SimpleSessionService{CalledMethods: map[string]bool{}}
+	eventService := event.SimpleEventService{CalledMethods: map[string]bool{}}
+
+	samlHandler := handlers.SAMLHandler{
+		AdminAuthenticator: middlewares.Bypass,
+		SAMLService:        samlService,
+		SessionService:     sessionService,
+		EventService:       eventService,
+	}
+	router := mux.NewRouter().StrictSlash(true)
+	samlHandler.RegisterRoutes(router)
+
+	ts := httptest.NewServer(middlewares.ClientParser(router))
+	defer ts.Close()
+
+	testCases := []struct {
+		name              string
+		form              url.Values
+		wantStatusCode    int
+		wantCreateSession bool
+	}{
+		{
+			name:              "POST api/saml/{tenant}/acs",
+			form:              url.Values{"SAMLResponse": []string{"PFJlc3BvbnNlLz4="}},
+			wantStatusCode:    http.StatusOK,
+			wantCreateSession: true,
+		},
+		{
+			name:              "POST api/saml/{tenant}/acs without SAMLResponse",
+			form:              url.Values{"RelayState": []string{"test"}},
+			wantStatusCode:    http.StatusUnprocessableEntity,
+			wantCreateSession: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			delete(sessionService.CalledMethods, "CreateSession")
+			url := fmt.Sprintf("%s/%s", ts.URL, "api/saml/acme/acs")
+			res, err := http.Post(url, "application/x-www-form-urlencoded", strings.NewReader(tc.form.Encode()))
+			if err != nil {
+				t.Fatalf("http.Post() err = %v; want nil", err)
+			}
+			statusCode := res.StatusCode
+			if statusCode != tc.wantStatusCode {
+				body, _ := ioutil.ReadAll(res.Body)
+				defer res.Body.Close()
+				t.Logf("response %s\n", string(body))
+				t.Errorf("res.StatusCode = %d; want %d", statusCode, tc.wantStatusCode)
+			}
+			if sessionService.CalledMethods["CreateSession"] != tc.wantCreateSession {
+				t.Errorf("CreateSession called = %t; want %t", sessionService.CalledMethods["CreateSession"], tc.wantCreateSession)
+			}
+		})
+	}
+}
@@ -16,10 +16,14 @@ import (
 )
 
 type SessionHandler struct {
-	Authorization  middlewares.MiddlewareWithArgs
-	Authenticator  middlewares.Middleware
-	SessionService session.Service
-	ProfileService profile.Service
+	Authorization        middlewares.MiddlewareWithArgs
+	Authenticator        middlewares.Middleware
+	RecentAuthentication middlewares.MiddlewareWithMaxAge
+	ClientVerification   middlewares.Middleware
+	SessionService       session.Service
+	ProfileService       profile.Service
+	// ExposeJWT add the signed JWT to issued access tokens, for route groups with stateless authentication
+	ExposeJWT bool
 }
 
 func (h SessionHandler) RegisterRoutes(router *mux.Router) {
@@ -27,18 +31,27 @@ func (h SessionHandler) RegisterRoutes(router *mux.Router) {
 
 	authenticate := h.Authenticator
 	authorize := h.Authorization
-
-	listSession := authenticate(authorize(http.HandlerFunc(h.listSession), security.UserTokenScope))
-	endCurrentSession := authenticate(authorize(http.HandlerFunc(h.endCurrentSession), security.UserTokenScope))
-	endOtherSession := authenticate(authorize(http.HandlerFunc(h.endOtherSession), security.UserTokenScope))
-	createRefreshToken := authenticate(authorize(http.HandlerFunc(h.createRefreshToken), security.UserTokenScope))
-	createNewAccessToken := authenticate(authorize(http.HandlerFunc(h.createNewAccessToken), security.RefreshTokenScope))
+	requireRecentAuth := h.RecentAuthentication
+	requireClient := h.ClientVerification
+
+	listSession := requireClient(authenticate(authorize(http.HandlerFunc(h.listSession), security.UserTokenScope)))
+	endCurrentSession := requireClient(authenticate(authorize(http.HandlerFunc(h.endCurrentSession), security.UserTokenScope)))
+	endOtherSession := requireClient(authenticate(authorize(requireRecentAuth(http.HandlerFunc(h.endOtherSession), security.ReauthenticationMaxAge), security.UserTokenScope)))
+	createRefreshToken := requireClient(authenticate(authorize(http.HandlerFunc(h.createRefreshToken), security.UserTokenScope)))
+	createNewAccessToken := requireClient(authenticate(authorize(http.HandlerFunc(h.createNewAccessToken), security.RefreshTokenScope)))
+	listTrustedDevices := requireClient(authenticate(authorize(http.HandlerFunc(h.listTrustedDevices), security.UserTokenScope)))
+	revokeTrustedDevice := requireClient(authenticate(authorize(http.HandlerFunc(h.revokeTrustedDevice), security.UserTokenScope)))
+	revokeAllTrustedDevices := requireClient(authenticate(authorize(http.HandlerFunc(h.revokeAllTrustedDevices), security.UserTokenScope)))
 
 	subRouter.Handle("/me/session", listSession).Methods("GET")
 	subRouter.Handle("/me/session", endCurrentSession).Methods("DELETE")
 	subRouter.Handle("/me/session/other", endOtherSession).Methods("DELETE")
 	subRouter.Handle("/me/session/refresh_token", createRefreshToken).Methods("GET")
 	subRouter.Handle("/me/session/access_token", createNewAccessToken).Methods("GET")
+
+	subRouter.Handle("/me/devices", listTrustedDevices).Methods("GET")
+	subRouter.Handle("/me/devices", revokeAllTrustedDevices).Methods("DELETE")
+	subRouter.Handle("/me/devices/{device_id}", revokeTrustedDevice).Methods("DELETE")
 }
 
 func (h SessionHandler) listSession(res http.ResponseWriter, req *http.Request) {
@@ -46,7 +59,7 @@ func (h SessionHandler) listSession(res http.ResponseWriter, req *http.Request)
 	accessTokenKey := req.Context().Value(contextkey.AccessTokenKey).(string)
 	userID := int(accessToken["userid"].(float64))
 
-	sessions, err := h.SessionService.ListSession(userID)
+	sessions, err := h.SessionService.ListSession(req.Context(), userID)
 	if err != nil {
 		handleServiceError(res, req, err)
 		return
@@ -60,7 +73,7 @@ func (h SessionHandler) endCurrentSession(res http.ResponseWriter, req *http.Req
 	accessTokenKey := req.Context().Value(contextkey.AccessTokenKey).(string)
 	userID := int(accessToken["userid"].(float64))
 
-	err := h.SessionService.EndSession(userID, accessTokenKey)
+	err := h.SessionService.EndSession(req.Context(), userID, accessTokenKey)
 	if err != nil {
 		handleServiceError(res, req, err)
 		return
@@ -74,7 +87,7 @@ func (h SessionHandler) endOtherSession(res http.ResponseWriter, req *http.Reque
 	accessTokenKey := req.Context().Value(contextkey.AccessTokenKey).(string)
 	userID := int(accessToken["userid"].(float64))
 
-	err := h.SessionService.EndOtherSessions(userID, accessTokenKey)
+	err := h.SessionService.EndOtherSessions(req.Context(), userID, accessTokenKey)
 	if err != nil {
 		handleServiceError(res, req, err)
 		return
@@ -88,20 +101,20 @@ func (h SessionHandler) createRefreshToken(res http.ResponseWriter, req *http.Re
 	accessTokenKey := req.Context().Value(contextkey.AccessTokenKey).(string)
 	userID := int(accessToken["userid"].(float64))
 
-	user, err := h.ProfileService.Profile(userID)
+	user, err := h.ProfileService.Profile(req.Context(), userID)
 	if err != nil {
 		handleServiceError(res, req, err)
 		return
 	}
 
-	refreshToken, err := h.SessionService.CreateRefreshToken(user, accessTokenKey)
+	refreshToken, err := h.SessionService.CreateRefreshToken(req.Context(), user, accessTokenKey, security.AuthenticationFromClaims(accessToken))
 	if err != nil {
 		handleServiceError(res, req, err)
 		return
 	}
 
 	render.JSON(res, http.StatusOK, map[string]interface{}{
-		"access_token": serializers.SerializeAccessTokenToJSON(refreshToken),
+		"access_token": serializers.SerializeAccessTokenToJSON(refreshToken, h.ExposeJWT),
 	})
 }
 
@@ -112,34 +125,86 @@ func (h SessionHandler) createNewAccessToken(res http.ResponseWriter, req *http.
 	userID := int(refreshToken["userid"].(float64))
 	prevSessionID := refreshToken["previous_session_id"].(string)
 
-	user, err := h.ProfileService.Profile(userID)
+	user, err := h.ProfileService.Profile(req.Context(), userID)
 	if err != nil {
 		handleServiceError(res, req, err)
 		return
 	}
 
 	// create access token
-	accessToken, err := h.SessionService.CreateNewAccessToken(user, refreshTokenKey)
+	accessToken, err := h.SessionService.CreateNewAccessToken(req.Context(), user, refreshTokenKey, security.AuthenticationFromClaims(refreshToken))
 	if err != nil {
 		handleServiceError(res, req, err)
 		return
 	}
 	// create session
-	h.SessionService.CreateSession(user.ID, userland.Session{
+	if err := h.SessionService.CreateSession(req.Context(), user.ID, userland.Session{
 		ID:         accessToken.Key,
 		Token:      accessToken.Value,
 		IP:         clientInfo["ip"].(string),
 		ClientID:   clientInfo["client_id"].(int),
 		ClientName: clientInfo["client_name"].(string),
+		UserAgent:  clientInfo["user_agent"].(string),
 		Expiration: security.UserAccessTokenExpiration,
-	})
-	// delete prev session
-	h.SessionService.EndSession(user.ID, prevSessionID)
+		// the previous session doesn't count toward session limits
+		PreviousSessionID: prevSessionID,
+	}); err != nil {
+		handleServiceError(res, req, err)
+		return
+	}
+	// end prev session, its JWT is revoked too
+	if err := h.SessionService.EndRefreshedSession(req.Context(), user.ID, prevSessionID); err != nil {
+		handleServiceError(res, req, err)
+		return
+	}
 	render.JSON(res, http.StatusOK, map[string]interface{}{
-		"access_token": serializers.SerializeAccessTokenToJSON(accessToken),
+		"access_token": serializers.SerializeAccessTokenToJSON(accessToken, h.ExposeJWT),
 	})
 }
 
+func (h SessionHandler) listTrustedDevices(res http.ResponseWriter, req *http.Request) {
+	accessToken := req.Context().Value(contextkey.AccessToken).(map[string]interface{})
+	userID := int(accessToken["userid"].(float64))
+
+	devices, err := h.SessionService.ListTrustedDevices(req.Context(), userID)
+	if err != nil {
+		handleServiceError(res, req, err)
+		return
+	}
+
+	serializedDevices := []map[string]interface{}{}
+	for _, device := range devices {
+		serializedDevices = append(serializedDevices, serializers.SerializeTrustedDeviceToJSON(device))
+	}
+
+	render.JSON(res, http.StatusOK, map[string]interface{}{"data": serializedDevices})
+}
+
+func (h SessionHandler) revokeTrustedDevice(res http.ResponseWriter, req *http.Request) {
+	accessToken := req.Context().Value(contextkey.AccessToken).(map[string]interface{})
+	userID := int(accessToken["userid"].(float64))
+	deviceID := mux.Vars(req)["device_id"]
+
+	if err := h.SessionService.RevokeTrustedDevice(req.Context(), userID, deviceID); err != nil {
+		handleServiceError(res, req, err)
+		return
+	}
+
+	render.JSON(res, http.StatusOK, map[string]interface{}{"success": true})
+}
+
+func (h SessionHandler) revokeAllTrustedDevices(res http.ResponseWriter, req *http.Request) {
+	accessToken := req.Context().Value(contextkey.AccessToken).(map[string]interface{})
+	userID := int(accessToken["userid"].(float64))
+
+	if err := h.SessionService.RevokeAllTrustedDevices(req.Context(), userID); err != nil {
+		handleServiceError(res, req, err)
+		return
+	}
+
+	render.JSON(res, http.StatusOK, map[string]interface{}{"success": true})
+}
+
 func serializeSessions(sessions userland.Sessions, currentSessionID string) []map[string]interface{} {
 	serializedSessions := []map[string]interface{}{}
 	for _, _session := range sessions {
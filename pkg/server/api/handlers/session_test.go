@@ -25,10 +25,12 @@ func TestSessionHandler_inputValidation(t *testing.T) {
 		"previous_session_id": "test",
 	}
 	sessionHandler := handlers.SessionHandler{
-		Authorization:  middlewares.BypassWithArgs,
-		Authenticator:  middlewares.AuthenticationWithCustomClaims(tokenClaims),
-		ProfileService: profileService,
-		SessionService: sessionService,
+		Authorization:        middlewares.BypassWithArgs,
+		RecentAuthentication: middlewares.BypassWithMaxAge,
+		ClientVerification:   middlewares.Bypass,
+		Authenticator:        middlewares.AuthenticationWithCustomClaims(tokenClaims),
+		ProfileService:       profileService,
+		SessionService:       sessionService,
 	}
 	router := mux.NewRouter().StrictSlash(true)
 	sessionHandler.RegisterRoutes(router)
@@ -64,6 +66,32 @@ func TestSessionHandler_inputValidation(t *testing.T) {
 			},
 			wantStatusCode: http.StatusOK,
 		},
+		{
+			name: "GET api/me/devices",
+			args: args{
+				method: http.MethodGet,
+				path:   "api/me/devices",
+			},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name: "DELETE api/me/devices",
+			args: args{
+				method:      http.MethodDelete,
+				path:        "api/me/devices",
+				requestBody: map[string]interface{}{},
+			},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name: "DELETE api/me/devices/{device_id}",
+			args: args{
+				method:      http.MethodDelete,
+				path:        "api/me/devices/test",
+				requestBody: map[string]interface{}{},
+			},
+			wantStatusCode: http.StatusOK,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -2,10 +2,15 @@ package serializers
 
 import "github.com/AdhityaRamadhanus/userland/pkg/common/security"
 
-func SerializeAccessTokenToJSON(accessToken security.AccessToken) map[string]interface{} {
-	return map[string]interface{}{
+//SerializeAccessTokenToJSON include the signed JWT only with exposeJWT, it is only needed by routes verifying tokens statelessly
+func SerializeAccessTokenToJSON(accessToken security.AccessToken, exposeJWT bool) map[string]interface{} {
+	serialized := map[string]interface{}{
 		"value":      accessToken.Key,
 		"type":       accessToken.Type,
 		"expired_at": accessToken.ExpiredAt,
 	}
+	if exposeJWT {
+		serialized["jwt"] = accessToken.Value
+	}
+	return serialized
 }
@@ -0,0 +1,16 @@
+package serializers
+
+import "github.com/AdhityaRamadhanus/userland"
+
+func SerializeClientToJSON(client userland.Client) map[string]interface{} {
+	return map[string]interface{}{
+		"id":              client.ID,
+		"name":            client.Name,
+		"type":            client.Type,
+		"public":          client.Public,
+		"allowed_origins": client.AllowedOrigins,
+		"status":          client.Status,
+		"created_at":      client.CreatedAt,
+		"updated_at":      client.UpdatedAt,
+	}
+}
@@ -11,6 +11,12 @@ func SerializeEventToJSON(event userland.Event) map[string]interface{} {
 			"id":   event.ClientID,
 			"name": event.ClientName,
 		},
-		"created_at": event.Timestamp,
+		"location": map[string]interface{}{
+			"country": event.Country,
+			"city":    event.City,
+			"asn":     event.ASN,
+		},
+		"changed_fields": event.ChangedFields,
+		"created_at":     event.Timestamp,
 	}
 }
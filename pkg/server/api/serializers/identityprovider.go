@@ -0,0 +1,15 @@
+package serializers
+
+import "github.com/AdhityaRamadhanus/userland"
+
+func SerializeIdentityProviderToJSON(identityProvider userland.IdentityProvider) map[string]interface{} {
+	return map[string]interface{}{
+		"tenant":            identityProvider.Tenant,
+		"entity_id":         identityProvider.EntityID,
+		"sso_url":           identityProvider.SSOURL,
+		"attribute_mapping": identityProvider.AttributeMapping,
+		"domains":           identityProvider.Domains,
+		"created_at":        identityProvider.CreatedAt,
+		"updated_at":        identityProvider.UpdatedAt,
+	}
+}
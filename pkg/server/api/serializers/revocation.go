@@ -0,0 +1,12 @@
+package serializers
+
+import "github.com/AdhityaRamadhanus/userland"
+
+func SerializeSessionRevocationToJSON(revocation userland.SessionRevocation) map[string]interface{} {
+	return map[string]interface{}{
+		"session_id": revocation.SessionID,
+		"user_id":    revocation.UserID,
+		"reason":     revocation.Reason,
+		"revoked_at": revocation.RevokedAt,
+	}
+}
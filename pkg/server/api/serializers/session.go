@@ -10,7 +10,21 @@ func SerializeSessionToJSON(session userland.Session) map[string]interface{} {
 			"id":   session.ClientID,
 			"name": session.ClientName,
 		},
-		"created_at": session.CreatedAt,
-		"updated_at": session.UpdatedAt,
+		"user_agent": session.UserAgent,
+		"device": map[string]interface{}{
+			"browser": session.Browser,
+			"os":      session.OS,
+			"type":    session.DeviceType,
+		},
+		"location": map[string]interface{}{
+			"country": session.Country,
+			"city":    session.City,
+			"asn":     session.ASN,
+		},
+		"last_seen_at": session.LastSeenAt,
+		"last_seen_ip": session.LastSeenIP,
+		"expired_at":   session.ExpiredAt,
+		"created_at":   session.CreatedAt,
+		"updated_at":   session.UpdatedAt,
 	}
 }
@@ -0,0 +1,26 @@
+package serializers
+
+import "github.com/AdhityaRamadhanus/userland"
+
+func SerializeTrustedDeviceToJSON(device userland.TrustedDevice) map[string]interface{} {
+	return map[string]interface{}{
+		"device_id":  device.ID,
+		"user_agent": device.UserAgent,
+		"ip":         device.IP,
+		"client": map[string]interface{}{
+			"id":   device.ClientID,
+			"name": device.ClientName,
+		},
+		"last_used_at": device.LastUsedAt,
+		"expired_at":   device.ExpiredAt,
+		"created_at":   device.CreatedAt,
+	}
+}
+
+func SerializeDeviceTokenToJSON(deviceToken string, device userland.TrustedDevice) map[string]interface{} {
+	return map[string]interface{}{
+		"value":      deviceToken,
+		"device_id":  device.ID,
+		"expired_at": device.ExpiredAt,
+	}
+}
@@ -6,6 +6,7 @@ func SerializeUserToJSON(user userland.User) map[string]interface{} {
 	return map[string]interface{}{
 		"id":         user.ID,
 		"fullname":   user.Fullname,
+		"phone":      user.Phone,
 		"location":   user.Location,
 		"bio":        user.Bio,
 		"web":        user.WebURL,
@@ -16,39 +16,72 @@ import (
 
 //Server hold mux Router and information of host port and address of our app
 type Server struct {
-	Router  *mux.Router
-	Address string
+	Router *mux.Router
+	// StreamRouter hold the routes of StreamingHandler, they are matched before Router
+	StreamRouter *mux.Router
+	Address      string
+	// ClientParser put client info in request context, defaults to trusting no proxy and no client
+	ClientParser middlewares.Middleware
 }
 
 //NewServer create Server from Handler
 func NewServer(cfg config.ApiConfig, Handlers ...Handler) *Server {
 	router := mux.NewRouter().StrictSlash(true)
+	streamRouter := mux.NewRouter().StrictSlash(true)
 
 	for _, handler := range Handlers {
 		handler.RegisterRoutes(router)
+		if streamingHandler, ok := handler.(StreamingHandler); ok {
+			streamingHandler.RegisterStreamingRoutes(streamRouter)
+		}
 	}
 
 	return &Server{
-		Router:  router,
-		Address: fmt.Sprintf("%s:%d", cfg.Host, cfg.Port),
+		Router:       router,
+		StreamRouter: streamRouter,
+		Address:      fmt.Sprintf("%s:%d", cfg.Host, cfg.Port),
 	}
 }
 
 //CreateHTTPServer will return http.Server for flexible use like testing
 func (s Server) CreateHTTPServer() *http.Server {
+	clientParser := s.ClientParser
+	if clientParser == nil {
+		clientParser = middlewares.ParseClientInfo(nil)
+	}
+
+	logMetricRequest := middlewares.LogMetricRequest(
+		metrics.PrometheusRequestLatency("api", "server", middlewares.LogMetricKeys),
+	)
+	// gzip buffer small writes and the write timeout cut long streams, so streams have their own chain
+	streamMiddlewares := []alice.Constructor{
+		middlewares.ClearWriteDeadline,
+		middlewares.PanicHandler,
+		middlewares.TraceRequest,
+		alice.Constructor(clientParser),
+		alice.Constructor(logMetricRequest),
+	}
+
 	middlewares := []alice.Constructor{
 		middlewares.PanicHandler,
 		gziphandler.GzipHandler,
 		middlewares.TraceRequest,
-		middlewares.ParseClientInfo,
+		alice.Constructor(clientParser),
 		cors.Default().Handler,
-		alice.Constructor(middlewares.LogMetricRequest(
-			metrics.PrometheusRequestLatency("api", "server", middlewares.LogMetricKeys),
-		)),
+		alice.Constructor(logMetricRequest),
 	}
 
+	handler := alice.New(middlewares...).Then(s.Router)
+	streamHandler := alice.New(streamMiddlewares...).Then(s.StreamRouter)
 	srv := &http.Server{
-		Handler:      alice.New(middlewares...).Then(s.Router),
+		Handler: http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			var match mux.RouteMatch
+			if s.StreamRouter != nil && s.StreamRouter.Match(req, &match) {
+				streamHandler.ServeHTTP(res, req)
+				return
+			}
+			handler.ServeHTTP(res, req)
+		}),
 		Addr:         s.Address,
 		WriteTimeout: 15 * time.Second,
 		ReadTimeout:  5 * time.Second,
@@ -25,9 +25,11 @@ func (h MailingHandler) RegisterRoutes(router *mux.Router) {
 
 	sendEmailOTP := authenticate(http.HandlerFunc(h.sendEmailOTP))
 	sendEmailVerification := authenticate(http.HandlerFunc(h.sendEmailVerification))
+	sendEmailNotice := authenticate(http.HandlerFunc(h.sendEmailNotice))
 
 	subRouter.Handle("/mail/otp", sendEmailOTP).Methods("POST")
 	subRouter.Handle("/mail/verification", sendEmailVerification).Methods("POST")
+	subRouter.Handle("/mail/notice", sendEmailNotice).Methods("POST")
 }
 
 func (h MailingHandler) sendEmailOTP(res http.ResponseWriter, req *http.Request) {
@@ -117,3 +119,47 @@ func (h MailingHandler) sendEmailVerification(res http.ResponseWriter, req *http
 	}
 	render.JSON(res, http.StatusOK, map[string]interface{}{"success": true})
 }
+
+func (h MailingHandler) sendEmailNotice(res http.ResponseWriter, req *http.Request) {
+	// Read Body, limit to 1 MB //
+	body, err := ioutil.ReadAll(io.LimitReader(req.Body, 1048576))
+	if err != nil {
+		render.FailedToReadBodyError(res, err)
+		return
+	}
+
+	noticeEmailRequest := struct {
+		Subject       string `json:"subject" valid:"required,stringlength(1|128)"`
+		Message       string `json:"message" valid:"required,stringlength(1|2048)"`
+		ActionLink    string `json:"action_link" valid:"url"`
+		Recipient     string `json:"recipient" valid:"required,email,stringlength(6|128)"`
+		RecipientName string `json:"recipient_name" valid:"required"`
+	}{}
+
+	// Deserialize
+	if err := json.Unmarshal(body, &noticeEmailRequest); err != nil {
+		render.FailedToUnmarshalJSONError(res, err)
+		return
+	}
+
+	if err := req.Body.Close(); err != nil {
+		render.InternalServerError(res, err)
+		return
+	}
+
+	if ok, err := govalidator.ValidateStruct(noticeEmailRequest); !ok || err != nil {
+		render.InvalidRequestError(res, err)
+		return
+	}
+
+	recipient := mailing.MailAddress{
+		Address: noticeEmailRequest.Recipient,
+		Name:    noticeEmailRequest.RecipientName,
+	}
+
+	if err := h.MailingService.SendNoticeEmail(recipient, noticeEmailRequest.Subject, noticeEmailRequest.Message, noticeEmailRequest.ActionLink); err != nil {
+		handleServiceError(res, req, err)
+		return
+	}
+	render.JSON(res, http.StatusOK, map[string]interface{}{"success": true})
+}
@@ -66,6 +66,20 @@ func TestMailingHandler(t *testing.T) {
 			},
 			wantStatusCode: http.StatusOK,
 		},
+		{
+			name: "POST api/mail/notice",
+			args: args{
+				method: http.MethodPost,
+				path:   "api/mail/notice",
+				requestBody: map[string]interface{}{
+					"recipient_name": "Adhitya Ramadhanus",
+					"recipient":      "adhitya.ramadhanus@gmail.com",
+					"subject":        "Running low on backup codes",
+					"message":        "You have 2 backup codes left",
+				},
+			},
+			wantStatusCode: http.StatusOK,
+		},
 	}
 
 	for _, tc := range testCases {
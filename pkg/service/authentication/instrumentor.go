@@ -1,6 +1,7 @@
 package authentication
 
 import (
+	"context"
 	"time"
 
 	"github.com/AdhityaRamadhanus/userland"
@@ -28,66 +29,98 @@ func NewInstrumentorService(latency metrics.Histogram, s Service) Service {
 	return service
 }
 
-func (s instrumentorService) Register(user userland.User) error {
+func (s instrumentorService) Register(ctx context.Context, user userland.User) error {
 	defer func(begin time.Time) {
 		s.requestLatency.With("method", "Register").Observe(time.Since(begin).Seconds())
 	}(time.Now())
 
-	return s.next.Register(user)
+	return s.next.Register(ctx, user)
 }
 
-func (s instrumentorService) RequestVerification(verificationType string, email string) (verificationID string, err error) {
+func (s instrumentorService) RequestVerification(ctx context.Context, verificationType string, email string) (verificationID string, err error) {
 	defer func(begin time.Time) {
 		s.requestLatency.With("method", "RequestVerification").Observe(time.Since(begin).Seconds())
 	}(time.Now())
 
-	return s.next.RequestVerification(verificationType, email)
+	return s.next.RequestVerification(ctx, verificationType, email)
 }
 
-func (s instrumentorService) VerifyAccount(verificationID string, email string, code string) error {
+func (s instrumentorService) VerifyAccount(ctx context.Context, verificationID string, email string, code string) error {
 	defer func(begin time.Time) {
 		s.requestLatency.With("method", "VerifyAccount").Observe(time.Since(begin).Seconds())
 	}(time.Now())
 
-	return s.next.VerifyAccount(verificationID, email, code)
+	return s.next.VerifyAccount(ctx, verificationID, email, code)
 }
 
-func (s instrumentorService) Login(email, password string) (requireTFA bool, accessToken security.AccessToken, err error) {
+func (s instrumentorService) Login(ctx context.Context, email, password string, options LoginOptions) (requireTFA bool, accessToken security.AccessToken, err error) {
 	defer func(begin time.Time) {
 		s.requestLatency.With("method", "Login").Observe(time.Since(begin).Seconds())
 	}(time.Now())
 
-	return s.next.Login(email, password)
+	return s.next.Login(ctx, email, password, options)
 }
 
-func (s instrumentorService) VerifyTFA(tfaToken string, userID int, code string) (accessToken security.AccessToken, err error) {
+func (s instrumentorService) VerifyTFA(ctx context.Context, tfaToken string, userID int, code string) (accessToken security.AccessToken, err error) {
 	defer func(begin time.Time) {
 		s.requestLatency.With("method", "VerifyTFA").Observe(time.Since(begin).Seconds())
 	}(time.Now())
 
-	return s.next.VerifyTFA(tfaToken, userID, code)
+	return s.next.VerifyTFA(ctx, tfaToken, userID, code)
 }
 
-func (s instrumentorService) VerifyTFABypass(tfaToken string, userID int, code string) (accessToken security.AccessToken, err error) {
+func (s instrumentorService) TrustDevice(ctx context.Context, userID int, device userland.TrustedDevice) (deviceToken string, trustedDevice userland.TrustedDevice, err error) {
+	defer func(begin time.Time) {
+		s.requestLatency.With("method", "TrustDevice").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return s.next.TrustDevice(ctx, userID, device)
+}
+
+func (s instrumentorService) VerifyTFABypass(ctx context.Context, tfaToken string, userID int, code string) (accessToken security.AccessToken, err error) {
 	defer func(begin time.Time) {
 		s.requestLatency.With("method", "VerifyTFABypass").Observe(time.Since(begin).Seconds())
 	}(time.Now())
 
-	return s.next.VerifyTFABypass(tfaToken, userID, code)
+	return s.next.VerifyTFABypass(ctx, tfaToken, userID, code)
 }
 
-func (s instrumentorService) ForgotPassword(email string) (verificationID string, err error) {
+func (s instrumentorService) ForgotPassword(ctx context.Context, email string) (verificationID string, err error) {
 	defer func(begin time.Time) {
 		s.requestLatency.With("method", "ForgotPassword").Observe(time.Since(begin).Seconds())
 	}(time.Now())
 
-	return s.next.ForgotPassword(email)
+	return s.next.ForgotPassword(ctx, email)
 }
 
-func (s instrumentorService) ResetPassword(forgotPassToken string, newPassword string) error {
+func (s instrumentorService) ResetPassword(ctx context.Context, forgotPassToken string, newPassword string) (int, error) {
 	defer func(begin time.Time) {
 		s.requestLatency.With("method", "ResetPassword").Observe(time.Since(begin).Seconds())
 	}(time.Now())
 
-	return s.next.ResetPassword(forgotPassToken, newPassword)
+	return s.next.ResetPassword(ctx, forgotPassToken, newPassword)
+}
+
+func (s instrumentorService) RequestReauthentication(ctx context.Context, userID int, sessionID string) error {
+	defer func(begin time.Time) {
+		s.requestLatency.With("method", "RequestReauthentication").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return s.next.RequestReauthentication(ctx, userID, sessionID)
+}
+
+func (s instrumentorService) Reauthenticate(ctx context.Context, userID int, sessionID string, method string, credential string) (security.AccessToken, error) {
+	defer func(begin time.Time) {
+		s.requestLatency.With("method", "Reauthenticate").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return s.next.Reauthenticate(ctx, userID, sessionID, method, credential)
+}
+
+func (s instrumentorService) DenySignIn(ctx context.Context, token string) (int, error) {
+	defer func(begin time.Time) {
+		s.requestLatency.With("method", "DenySignIn").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return s.next.DenySignIn(ctx, token)
 }
@@ -0,0 +1,191 @@
+package authentication
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/AdhityaRamadhanus/userland"
+	"github.com/AdhityaRamadhanus/userland/pkg/common/keygenerator"
+	"github.com/AdhityaRamadhanus/userland/pkg/common/security"
+	"github.com/AdhityaRamadhanus/userland/pkg/common/useragent"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	LoginRiskSignalNewIP            = "new_ip"
+	LoginRiskSignalUnusualUserAgent = "unusual_user_agent"
+	LoginRiskSignalFailedAttempts   = "failed_attempts"
+	LoginRiskSignalImpossibleTravel = "impossible_travel"
+
+	// weight of each signal added to the risk score
+	NewIPWeight             = 20
+	UnusualUserAgentWeight  = 20
+	FailedAttemptWeight     = 10 // for each failed attempt
+	MaxFailedAttemptsWeight = 40
+	ImpossibleTravelWeight  = 50
+
+	// geolocation of an ip is rarely more precise than this many km
+	ImpossibleTravelMinDistance = 100.0
+	// km/h when no speed is configured, roughly a commercial flight
+	DefaultMaxTravelSpeed = 1000.0
+
+	earthRadius                 = 6371.0 // km
+	minimumElapsedBetweenLogins = time.Minute
+)
+
+//assessLoginRisk score a login attempt from its signals, record the assessment and return the decision,
+//risk is not assessed (always allowed) when disabled by configuration
+func (s service) assessLoginRisk(ctx context.Context, user userland.User, options LoginOptions) (decision string) {
+	if !s.config.Risk.Enabled {
+		return userland.LoginRiskDecisionAllow
+	}
+
+	assessment := userland.LoginRiskAssessment{
+		UserID:    user.ID,
+		IP:        options.IP,
+		UserAgent: options.UserAgent,
+		Signals:   []string{},
+	}
+	addSignal := func(signal string, weight int) {
+		assessment.Signals = append(assessment.Signals, signal)
+		assessment.Score += weight
+	}
+
+	if failures := s.loginFailures(ctx, user); failures > 0 {
+		weight := failures * FailedAttemptWeight
+		if weight > MaxFailedAttemptsWeight {
+			weight = MaxFailedAttemptsWeight
+		}
+		addSignal(LoginRiskSignalFailedAttempts, weight)
+	}
+
+	// bots and clients hiding their user agent are unusual no matter the history
+	device := useragent.Parse(options.UserAgent)
+	unusualUserAgent := device.Type == useragent.DeviceTypeBot || device.Type == useragent.DeviceTypeUnknown
+
+	if s.eventRepository != nil {
+		events, err := s.recentLogins(ctx, user)
+		if err != nil {
+			log.WithError(err).Error("Error finding recent logins")
+		}
+		// first login has nothing to compare with
+		if len(events) > 0 {
+			knownIP, knownDevice := false, false
+			for _, event := range events {
+				if event.IP == options.IP {
+					knownIP = true
+				}
+				eventDevice := useragent.Parse(event.UserAgent)
+				if eventDevice.OS == device.OS && eventDevice.Type == device.Type {
+					knownDevice = true
+				}
+			}
+			if !knownIP {
+				addSignal(LoginRiskSignalNewIP, NewIPWeight)
+			}
+			if !knownDevice {
+				unusualUserAgent = true
+			}
+			if s.isImpossibleTravel(events[0], options.IP) {
+				addSignal(LoginRiskSignalImpossibleTravel, ImpossibleTravelWeight)
+			}
+		}
+	}
+	if unusualUserAgent {
+		addSignal(LoginRiskSignalUnusualUserAgent, UnusualUserAgentWeight)
+	}
+
+	assessment.Decision = s.loginRiskDecision(assessment.Score)
+	if s.loginRiskAssessmentRepository != nil {
+		if err := s.loginRiskAssessmentRepository.Insert(ctx, &assessment); err != nil {
+			log.WithError(err).Error("Error recording login risk assessment")
+		}
+	}
+
+	if assessment.Decision != userland.LoginRiskDecisionAllow {
+		log.WithFields(log.Fields{
+			"user_id":  user.ID,
+			"ip":       options.IP,
+			"score":    assessment.Score,
+			"signals":  assessment.Signals,
+			"decision": assessment.Decision,
+		}).Warn("Risky login")
+	}
+	return assessment.Decision
+}
+
+func (s service) loginRiskDecision(score int) string {
+	switch {
+	case s.config.Risk.BlockThreshold > 0 && score >= s.config.Risk.BlockThreshold:
+		return userland.LoginRiskDecisionBlock
+	case s.config.Risk.ChallengeThreshold > 0 && score >= s.config.Risk.ChallengeThreshold:
+		return userland.LoginRiskDecisionChallenge
+	default:
+		return userland.LoginRiskDecisionAllow
+	}
+}
+
+//isImpossibleTravel return true when going from the previous login location to ip need to be faster than the configured speed
+func (s service) isImpossibleTravel(previousLogin userland.Event, ip string) bool {
+	if s.geolocationService == nil || previousLogin.IP == "" || previousLogin.IP == ip {
+		return false
+	}
+
+	from, err := s.geolocationService.Lookup(previousLogin.IP)
+	if err != nil || (from.Latitude == 0 && from.Longitude == 0) {
+		return false
+	}
+	to, err := s.geolocationService.Lookup(ip)
+	if err != nil || (to.Latitude == 0 && to.Longitude == 0) {
+		return false
+	}
+
+	distance := haversineDistance(from, to)
+	if distance < ImpossibleTravelMinDistance {
+		return false
+	}
+
+	elapsed := time.Since(previousLogin.Timestamp)
+	if elapsed < minimumElapsedBetweenLogins {
+		elapsed = minimumElapsedBetweenLogins
+	}
+	maxTravelSpeed := s.config.Risk.MaxTravelSpeed
+	if maxTravelSpeed <= 0 {
+		maxTravelSpeed = DefaultMaxTravelSpeed
+	}
+	return distance/elapsed.Hours() > maxTravelSpeed
+}
+
+//haversineDistance return great-circle distance between two locations in km
+func haversineDistance(from, to userland.Geolocation) float64 {
+	toRadians := func(degree float64) float64 { return degree * math.Pi / 180 }
+	deltaLatitude := toRadians(to.Latitude - from.Latitude)
+	deltaLongitude := toRadians(to.Longitude - from.Longitude)
+
+	a := math.Sin(deltaLatitude/2)*math.Sin(deltaLatitude/2) +
+		math.Cos(toRadians(from.Latitude))*math.Cos(toRadians(to.Latitude))*math.Sin(deltaLongitude/2)*math.Sin(deltaLongitude/2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(a))
+}
+
+func (s service) loginFailures(ctx context.Context, user userland.User) int {
+	failuresBytes, err := s.keyValueService.Get(ctx, keygenerator.LoginFailureKey(user.ID))
+	if err != nil {
+		return 0
+	}
+	failures, _ := strconv.Atoi(string(failuresBytes))
+	return failures
+}
+
+//countLoginFailure increment failed attempts of user, the counter expire some time after the last failure
+func (s service) countLoginFailure(ctx context.Context, user userland.User) {
+	loginFailureKey := keygenerator.LoginFailureKey(user.ID)
+	if _, err := s.keyValueService.Incr(ctx, loginFailureKey, security.LoginFailureExpiration); err != nil {
+		log.WithError(err).Error("Error counting login failure")
+	}
+}
+
+func (s service) resetLoginFailures(ctx context.Context, user userland.User) {
+	s.keyValueService.Delete(ctx, keygenerator.LoginFailureKey(user.ID))
+}
@@ -1,12 +1,20 @@
 package authentication
 
 import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/AdhityaRamadhanus/userland"
 	mailing "github.com/AdhityaRamadhanus/userland/pkg/common/http/clients/mailing"
 	"github.com/AdhityaRamadhanus/userland/pkg/common/keygenerator"
+	"github.com/AdhityaRamadhanus/userland/pkg/common/optimistic"
 	"github.com/AdhityaRamadhanus/userland/pkg/common/security"
+	"github.com/AdhityaRamadhanus/userland/pkg/common/useragent"
 	"github.com/AdhityaRamadhanus/userland/pkg/config"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
@@ -15,6 +23,19 @@ import (
 var (
 	EventLogin          = "user.authentication.login"
 	EventForgotPassword = "user.authentication.forgot_password"
+	EventTrustDevice    = "user.authentication.trust_device"
+	EventReauthenticate = "user.authentication.reauthenticate"
+	EventDenySignIn     = "user.authentication.deny_signin"
+
+	ReauthenticationByPassword = "password"
+	ReauthenticationByOTP      = "otp"
+	ReauthenticationByTOTP     = "totp"
+
+	// user is warned once when this many backup codes are left
+	BackupCodesWarningThreshold = 2
+
+	// login is compared with this many recent logins when no history size is configured
+	DefaultLoginAlertHistory = 20
 
 	ErrUserRegistered        = errors.New("User already registered")
 	ErrUserNotVerified       = errors.New("User not verified")
@@ -23,18 +44,36 @@ var (
 	ErrWrongOTP              = errors.New("Wrong OTP")
 	ErrWrongBackupCode       = errors.New("code doesn't match any backup codes")
 	ErrOTPInvalid            = errors.New("OTP Invalid")
+	ErrTOTPNotEnabled        = errors.New("TOTP is not enabled, TFA must be activated first")
+	ErrSignInDenialInvalid   = errors.New("Sign-in denial link is invalid or expired")
+	ErrLoginBlocked          = errors.New("Login blocked, it looks too risky")
+	ErrAccountDeleted        = userland.ErrAccountDeleted
 )
 
 //Service provide an interface to story domain service
 type Service interface {
-	Register(user userland.User) error
-	RequestVerification(verificationType string, email string) (verificationID string, err error)
-	VerifyAccount(verificationID string, email string, code string) error
-	Login(email, password string) (requireTFA bool, accessToken security.AccessToken, err error)
-	VerifyTFA(tfaToken string, userID int, code string) (accessToken security.AccessToken, err error)
-	VerifyTFABypass(tfaToken string, userID int, code string) (accessToken security.AccessToken, err error)
-	ForgotPassword(email string) (verificationID string, err error)
-	ResetPassword(forgotPassToken string, newPassword string) error
+	Register(ctx context.Context, user userland.User) error
+	RequestVerification(ctx context.Context, verificationType string, email string) (verificationID string, err error)
+	VerifyAccount(ctx context.Context, verificationID string, email string, code string) error
+	Login(ctx context.Context, email, password string, options LoginOptions) (requireTFA bool, accessToken security.AccessToken, err error)
+	VerifyTFA(ctx context.Context, tfaToken string, userID int, code string) (accessToken security.AccessToken, err error)
+	TrustDevice(ctx context.Context, userID int, device userland.TrustedDevice) (deviceToken string, trustedDevice userland.TrustedDevice, err error)
+	VerifyTFABypass(ctx context.Context, tfaToken string, userID int, code string) (accessToken security.AccessToken, err error)
+	ForgotPassword(ctx context.Context, email string) (verificationID string, err error)
+	ResetPassword(ctx context.Context, forgotPassToken string, newPassword string) (userID int, err error)
+	RequestReauthentication(ctx context.Context, userID int, sessionID string) error
+	Reauthenticate(ctx context.Context, userID int, sessionID string, method string, credential string) (accessToken security.AccessToken, err error)
+	DenySignIn(ctx context.Context, token string) (userID int, err error)
+}
+
+//LoginOptions carry optional context of a login attempt
+type LoginOptions struct {
+	// DeviceToken issued by TrustDevice, let the user skip TFA on the same device
+	DeviceToken string
+	UserAgent   string
+	IP          string
+	ClientID    int
+	ClientName  string
 }
 
 func WithUserRepository(userRepository userland.UserRepository) func(service *service) {
@@ -43,12 +82,36 @@ func WithUserRepository(userRepository userland.UserRepository) func(service *se
 	}
 }
 
+func WithEventRepository(eventRepository userland.EventRepository) func(service *service) {
+	return func(service *service) {
+		service.eventRepository = eventRepository
+	}
+}
+
+func WithGeolocationService(geolocationService userland.GeolocationService) func(service *service) {
+	return func(service *service) {
+		service.geolocationService = geolocationService
+	}
+}
+
+func WithLoginRiskAssessmentRepository(loginRiskAssessmentRepository userland.LoginRiskAssessmentRepository) func(service *service) {
+	return func(service *service) {
+		service.loginRiskAssessmentRepository = loginRiskAssessmentRepository
+	}
+}
+
 func WithKeyValueService(keyValueService userland.KeyValueService) func(service *service) {
 	return func(service *service) {
 		service.keyValueService = keyValueService
 	}
 }
 
+func WithTrustedDeviceRepository(trustedDeviceRepository userland.TrustedDeviceRepository) func(service *service) {
+	return func(service *service) {
+		service.trustedDeviceRepository = trustedDeviceRepository
+	}
+}
+
 func WithMailingClient(mailingClient mailing.Client) func(service *service) {
 	return func(service *service) {
 		service.mailingClient = mailingClient
@@ -71,15 +134,19 @@ func NewService(options ...func(*service)) Service {
 }
 
 type service struct {
-	config          *config.Configuration
-	mailingClient   mailing.Client
-	userRepository  userland.UserRepository
-	keyValueService userland.KeyValueService
+	config                        *config.Configuration
+	mailingClient                 mailing.Client
+	eventRepository               userland.EventRepository
+	userRepository                userland.UserRepository
+	keyValueService               userland.KeyValueService
+	trustedDeviceRepository       userland.TrustedDeviceRepository
+	geolocationService            userland.GeolocationService
+	loginRiskAssessmentRepository userland.LoginRiskAssessmentRepository
 }
 
-func (s service) Register(user userland.User) (err error) {
+func (s service) Register(ctx context.Context, user userland.User) (err error) {
 	user.Password = security.HashPassword(user.Password)
-	if err := s.userRepository.Insert(&user); err != nil {
+	if err := s.userRepository.Insert(ctx, &user); err != nil {
 		if err == userland.ErrDuplicateKey {
 			return ErrUserRegistered
 		}
@@ -89,8 +156,8 @@ func (s service) Register(user userland.User) (err error) {
 	return nil
 }
 
-func (s service) RequestVerification(verificationType string, email string) (verificationID string, err error) {
-	user, err := s.userRepository.FindByEmail(email)
+func (s service) RequestVerification(ctx context.Context, verificationType string, email string) (verificationID string, err error) {
+	user, err := s.userRepository.FindByEmail(ctx, email)
 	if err != nil {
 		return "", err
 	}
@@ -105,7 +172,7 @@ func (s service) RequestVerification(verificationType string, email string) (ver
 		// create redis key verification
 		verificationID := security.GenerateUUID()
 		emailVerificationKey := keygenerator.EmailVerificationKey(user.ID, verificationID)
-		s.keyValueService.SetEx(emailVerificationKey, []byte(code), security.EmailVerificationExpiration)
+		s.keyValueService.SetEx(ctx, emailVerificationKey, []byte(code), security.EmailVerificationExpiration)
 		// call mail service here
 		// TODO change verificationLink to use mail host via mailing client
 		// TODO see if wee need to return error isntead of just logging
@@ -119,14 +186,14 @@ func (s service) RequestVerification(verificationType string, email string) (ver
 	}
 }
 
-func (s service) VerifyAccount(verificationID string, email string, code string) (err error) {
-	user, err := s.userRepository.FindByEmail(email)
+func (s service) VerifyAccount(ctx context.Context, verificationID string, email string, code string) (err error) {
+	user, err := s.userRepository.FindByEmail(ctx, email)
 	if err != nil {
 		return err
 	}
 
 	verificationKey := keygenerator.EmailVerificationKey(user.ID, verificationID)
-	expectedCode, err := s.keyValueService.Get(verificationKey)
+	expectedCode, err := s.keyValueService.Get(ctx, verificationKey)
 	if err != nil {
 		return err
 	}
@@ -135,18 +202,26 @@ func (s service) VerifyAccount(verificationID string, email string, code string)
 		return ErrWrongOTP
 	}
 
-	defer s.keyValueService.Delete(verificationKey)
-	user.Verified = true
-	return s.userRepository.Update(user)
+	defer s.keyValueService.Delete(ctx, verificationKey)
+	_, err = optimistic.UpdateUser(ctx, s.userRepository, user, func(user *userland.User) {
+		user.Verified = true
+	})
+	return err
 }
 
-func (s service) loginWithTFA(user userland.User) (accessToken security.AccessToken, err error) {
+func (s service) loginWithTFA(ctx context.Context, user userland.User) (accessToken security.AccessToken, err error) {
+	return StartTFA(ctx, s.keyValueService, s.mailingClient, s.config.JWTSecret, user)
+}
+
+//StartTFA create a tfa token and email its otp, VerifyTFA exchange the token and otp for an access token,
+//it is shared with sign in methods outside this service that still have to honour tfa
+func StartTFA(ctx context.Context, keyValueService userland.KeyValueService, mailingClient mailing.Client, jwtSecret string, user userland.User) (accessToken security.AccessToken, err error) {
 	code, err := security.GenerateOTP(6)
 	if err != nil {
 		return security.AccessToken{}, err
 	}
 
-	accessToken, err = security.CreateAccessToken(user, s.config.JWTSecret, security.AccessTokenOptions{
+	accessToken, err = security.CreateAccessToken(user, jwtSecret, security.AccessTokenOptions{
 		Expiration: security.TFATokenExpiration,
 		Scope:      security.TFATokenScope,
 	})
@@ -155,22 +230,25 @@ func (s service) loginWithTFA(user userland.User) (accessToken security.AccessTo
 	}
 
 	tfaKey := keygenerator.TFAVerificationKey(user.ID, accessToken.Key)
-	s.keyValueService.SetEx(tfaKey, []byte(code), security.TFATokenExpiration)
+	keyValueService.SetEx(ctx, tfaKey, []byte(code), security.TFATokenExpiration)
 
 	tokenKey := keygenerator.TokenKey(accessToken.Key)
-	s.keyValueService.SetEx(tokenKey, []byte(accessToken.Value), security.TFATokenExpiration)
+	keyValueService.SetEx(ctx, tokenKey, []byte(accessToken.Value), security.TFATokenExpiration)
 
 	// TODO return error?
-	if err := s.mailingClient.SendOTPEmail(user.Email, user.Fullname, "TFA Verification", code); err != nil {
+	if err := mailingClient.SendOTPEmail(user.Email, user.Fullname, "TFA Verification", code); err != nil {
 		log.WithError(err).Error("Error sending email")
 	}
 	return accessToken, nil
 }
 
-func (s service) loginNormal(user userland.User) (accessToken security.AccessToken, err error) {
+func (s service) loginNormal(user userland.User, authenticationMethods ...string) (accessToken security.AccessToken, err error) {
 	accessToken, err = security.CreateAccessToken(user, s.config.JWTSecret, security.AccessTokenOptions{
 		Expiration: security.UserAccessTokenExpiration,
 		Scope:      security.UserTokenScope,
+		Authentication: security.Authentication{
+			Methods: authenticationMethods,
+		},
 	})
 	if err != nil {
 		return security.AccessToken{}, err
@@ -178,13 +256,14 @@ func (s service) loginNormal(user userland.User) (accessToken security.AccessTok
 	return accessToken, nil
 }
 
-func (s service) Login(email, password string) (requireTFA bool, accessToken security.AccessToken, err error) {
-	user, err := s.userRepository.FindByEmail(email)
+func (s service) Login(ctx context.Context, email, password string, options LoginOptions) (requireTFA bool, accessToken security.AccessToken, err error) {
+	user, err := s.userRepository.FindByEmail(ctx, email)
 	if err != nil {
 		return false, security.AccessToken{}, err
 	}
 
 	if err = security.ComparePassword(user.Password, password); err != nil {
+		s.countLoginFailure(ctx, user)
 		return false, security.AccessToken{}, ErrWrongPassword
 	}
 
@@ -193,25 +272,85 @@ func (s service) Login(email, password string) (requireTFA bool, accessToken sec
 		return false, security.AccessToken{}, ErrUserNotVerified
 	}
 
-	if user.TFAEnabled {
-		accessToken, err := s.loginWithTFA(user)
-		return true, accessToken, err
+	if err := user.CheckNotDeleted(); err != nil {
+		return false, security.AccessToken{}, err
 	}
 
-	accessToken, err = s.loginNormal(user)
+	// risk is assessed before this login become part of the history it is compared with
+	decision := s.assessLoginRisk(ctx, user, options)
+	if decision == userland.LoginRiskDecisionBlock {
+		return false, security.AccessToken{}, ErrLoginBlocked
+	}
+
+	// like risk, newness is decided before this login become part of the history,
+	// but the alert is only sent once authentication completes
+	newSignIn := s.isNewSignIn(ctx, user, options)
+
+	// risky login has to prove itself with an emailed otp, trusted device or not
+	if decision == userland.LoginRiskDecisionChallenge {
+		return s.loginWithPendingAlert(ctx, user, newSignIn, options)
+	}
+	s.resetLoginFailures(ctx, user)
+
+	if user.TFAEnabled && !s.isTrustedDevice(ctx, user, options) {
+		return s.loginWithPendingAlert(ctx, user, newSignIn, options)
+	}
+
+	accessToken, err = s.loginNormal(user, security.PasswordAuthenticationMethod)
+	if err == nil && newSignIn {
+		go s.alertNewSignIn(user, options)
+	}
 	return false, accessToken, err
 }
 
-func (s service) VerifyTFA(tfaToken string, userID int, code string) (accessToken security.AccessToken, err error) {
+//loginWithPendingAlert start tfa and keep the new sign-in alert with the tfa token until tfa is verified
+func (s service) loginWithPendingAlert(ctx context.Context, user userland.User, newSignIn bool, options LoginOptions) (requireTFA bool, accessToken security.AccessToken, err error) {
+	accessToken, err = s.loginWithTFA(ctx, user)
+	if err != nil || !newSignIn {
+		return true, accessToken, err
+	}
+
+	// the alert doesn't need the device token, so it is not kept
+	options.DeviceToken = ""
+	optionsJSON, err := json.Marshal(options)
+	if err != nil {
+		return true, security.AccessToken{}, err
+	}
+	pendingAlertKey := keygenerator.PendingSignInAlertKey(accessToken.Key)
+	if err := s.keyValueService.SetEx(ctx, pendingAlertKey, optionsJSON, security.TFATokenExpiration); err != nil {
+		log.WithError(err).Error("Error storing pending sign-in alert")
+	}
+	return true, accessToken, nil
+}
+
+//alertPendingSignIn send the new sign-in alert kept with a tfa token once tfa is verified
+func (s service) alertPendingSignIn(ctx context.Context, user userland.User, tfaToken string) {
+	pendingAlertKey := keygenerator.PendingSignInAlertKey(tfaToken)
+	optionsJSON, err := s.keyValueService.Get(ctx, pendingAlertKey)
+	if err != nil {
+		// known sign-in or alert disabled
+		return
+	}
+	defer s.keyValueService.Delete(ctx, pendingAlertKey)
+
+	options := LoginOptions{}
+	if err := json.Unmarshal(optionsJSON, &options); err != nil {
+		log.WithError(err).Error("Error reading pending sign-in alert")
+		return
+	}
+	go s.alertNewSignIn(user, options)
+}
+
+func (s service) VerifyTFA(ctx context.Context, tfaToken string, userID int, code string) (accessToken security.AccessToken, err error) {
 	// find user
-	user, err := s.userRepository.Find(userID)
+	user, err := s.userRepository.Find(ctx, userID)
 	if err != nil {
 		return security.AccessToken{}, err
 	}
 
 	tfaVerificationID := keygenerator.TFAVerificationKey(user.ID, tfaToken)
 	tfaTokenKey := keygenerator.TokenKey(tfaToken)
-	expectedCode, err := s.keyValueService.Get(tfaVerificationID)
+	expectedCode, err := s.keyValueService.Get(ctx, tfaVerificationID)
 	if err != nil {
 		return security.AccessToken{}, err
 	}
@@ -221,51 +360,123 @@ func (s service) VerifyTFA(tfaToken string, userID int, code string) (accessToke
 		return security.AccessToken{}, ErrWrongOTP
 	}
 
-	defer s.keyValueService.Delete(tfaVerificationID)
-	defer s.keyValueService.Delete(tfaTokenKey)
-	return s.loginNormal(user)
+	defer s.keyValueService.Delete(ctx, tfaVerificationID)
+	defer s.keyValueService.Delete(ctx, tfaTokenKey)
+	s.resetLoginFailures(ctx, user)
+	s.alertPendingSignIn(ctx, user, tfaToken)
+	return s.loginNormal(user, security.PasswordAuthenticationMethod, security.OTPAuthenticationMethod)
 }
 
-func (s service) VerifyTFABypass(tfaToken string, userID int, code string) (accessToken security.AccessToken, err error) {
+func (s service) VerifyTFABypass(ctx context.Context, tfaToken string, userID int, code string) (accessToken security.AccessToken, err error) {
 	// find user
-	user, err := s.userRepository.Find(userID)
+	user, err := s.userRepository.Find(ctx, userID)
 	if err != nil {
 		return security.AccessToken{}, err
 	}
 
-	codeFound := false
-	foundIdx := -1
-	for idx, backupCode := range user.BackupCodes {
-		if err = security.ComparePassword(backupCode, code); err == nil {
-			codeFound = true
-			foundIdx = idx
-			break
+	// a code consumed by a concurrent verification is gone from the fresh copy and refused
+	user, err = optimistic.StoreBackupCodesChecked(ctx, s.userRepository, user, func(user *userland.User) error {
+		for idx, backupCode := range user.BackupCodes {
+			if err := security.ComparePassword(backupCode, code); err == nil {
+				user.BackupCodes = append(user.BackupCodes[:idx], user.BackupCodes[idx+1:]...)
+				return nil
+			}
 		}
+		return ErrWrongBackupCode
+	})
+	if err != nil {
+		return security.AccessToken{}, err
 	}
-
-	if !codeFound {
-		return security.AccessToken{}, ErrWrongBackupCode
+	if len(user.BackupCodes) == BackupCodesWarningThreshold {
+		message := fmt.Sprintf("You have %d TFA backup codes left, generate a new set before you run out of them.", len(user.BackupCodes))
+		// TODO return error?
+		if err := s.mailingClient.SendNoticeEmail(user.Email, user.Fullname, "Running low on TFA backup codes", message, ""); err != nil {
+			log.WithError(err).Error("Error sending email")
+		}
 	}
 
-	user.BackupCodes = append(user.BackupCodes[:foundIdx], user.BackupCodes[foundIdx+1:]...)
-	s.userRepository.StoreBackupCodes(user)
-
 	tfaVerificationID := keygenerator.TFAVerificationKey(user.ID, tfaToken)
 	tfaTokenKey := keygenerator.TokenKey(tfaVerificationID)
-	defer s.keyValueService.Delete(tfaVerificationID)
-	defer s.keyValueService.Delete(tfaTokenKey)
-	return s.loginNormal(user)
+	defer s.keyValueService.Delete(ctx, tfaVerificationID)
+	defer s.keyValueService.Delete(ctx, tfaTokenKey)
+	s.alertPendingSignIn(ctx, user, tfaToken)
+	return s.loginNormal(user, security.PasswordAuthenticationMethod, security.OTPAuthenticationMethod)
+}
+
+func (s service) trustedDeviceExpiration() time.Duration {
+	if s.config.TrustedDevice.Expiration > 0 {
+		return s.config.TrustedDevice.Expiration
+	}
+	return security.TrustedDeviceExpiration
+}
+
+func (s service) isTrustedDevice(ctx context.Context, user userland.User, options LoginOptions) bool {
+	if s.trustedDeviceRepository == nil || options.DeviceToken == "" {
+		return false
+	}
+
+	// device token is formatted as <device id>.<secret>
+	splittedToken := strings.SplitN(options.DeviceToken, ".", 2)
+	if len(splittedToken) != 2 {
+		return false
+	}
+	deviceID, secret := splittedToken[0], splittedToken[1]
+
+	device, err := s.trustedDeviceRepository.Find(ctx, user.ID, deviceID)
+	if err != nil {
+		return false
+	}
+
+	// device trust is only valid for tfa that is enabled before the device is trusted
+	if user.TFAEnabledAt.After(device.CreatedAt) {
+		return false
+	}
+
+	if device.UserAgent != options.UserAgent {
+		return false
+	}
+
+	if subtle.ConstantTimeCompare([]byte(security.HashToken(secret)), []byte(device.TokenHash)) != 1 {
+		return false
+	}
+
+	device.LastUsedAt = time.Now()
+	if err := s.trustedDeviceRepository.Update(ctx, user.ID, device); err != nil {
+		log.WithError(err).Error("Error updating trusted device")
+	}
+	return true
+}
+
+func (s service) TrustDevice(ctx context.Context, userID int, device userland.TrustedDevice) (deviceToken string, trustedDevice userland.TrustedDevice, err error) {
+	secret, err := security.GenerateRandomToken(32)
+	if err != nil {
+		return "", userland.TrustedDevice{}, err
+	}
+
+	device.ID = security.GenerateUUID()
+	device.TokenHash = security.HashToken(secret)
+	device.Expiration = s.trustedDeviceExpiration()
+	if err := s.trustedDeviceRepository.Create(ctx, userID, device); err != nil {
+		return "", userland.TrustedDevice{}, err
+	}
+
+	trustedDevice, err = s.trustedDeviceRepository.Find(ctx, userID, device.ID)
+	if err != nil {
+		return "", userland.TrustedDevice{}, err
+	}
+
+	return fmt.Sprintf("%s.%s", device.ID, secret), trustedDevice, nil
 }
 
-func (s service) ForgotPassword(email string) (verificationID string, err error) {
-	user, err := s.userRepository.FindByEmail(email)
+func (s service) ForgotPassword(ctx context.Context, email string) (verificationID string, err error) {
+	user, err := s.userRepository.FindByEmail(ctx, email)
 	if err != nil {
 		return "", err
 	}
 
 	verificationID = security.GenerateUUID()
 	forgotPassKey := keygenerator.ForgotPasswordKey(verificationID)
-	s.keyValueService.SetEx(forgotPassKey, []byte(user.Email), security.ForgotPassExpiration)
+	s.keyValueService.SetEx(ctx, forgotPassKey, []byte(user.Email), security.ForgotPassExpiration)
 	// call mail service
 	// TODO return error?
 	if err := s.mailingClient.SendOTPEmail(user.Email, user.Fullname, "Forgot Password", verificationID); err != nil {
@@ -274,21 +485,222 @@ func (s service) ForgotPassword(email string) (verificationID string, err error)
 	return verificationID, nil
 }
 
-func (s service) ResetPassword(forgotPassToken string, newPassword string) (err error) {
+//ResetPassword set the password of the user owning forgotPassToken, revoking trusted devices is left to the caller
+func (s service) ResetPassword(ctx context.Context, forgotPassToken string, newPassword string) (userID int, err error) {
 	// verify token
 	forgotPassKey := keygenerator.ForgotPasswordKey(forgotPassToken)
-	email, err := s.keyValueService.Get(forgotPassKey)
+	email, err := s.keyValueService.Get(ctx, forgotPassKey)
 	if err != nil {
-		return ErrOTPInvalid
+		return 0, ErrOTPInvalid
 	}
 
-	user, err := s.userRepository.FindByEmail(string(email))
+	user, err := s.userRepository.FindByEmail(ctx, string(email))
 	if err != nil {
-		return err
+		return 0, err
 	}
 
 	// update password
-	user.Password = security.HashPassword(newPassword)
-	defer s.keyValueService.Delete(forgotPassKey)
-	return s.userRepository.Update(user)
+	password := security.HashPassword(newPassword)
+	defer s.keyValueService.Delete(ctx, forgotPassKey)
+	if _, err = optimistic.UpdateUser(ctx, s.userRepository, user, func(user *userland.User) {
+		user.Password = password
+	}); err != nil {
+		return 0, err
+	}
+	return user.ID, nil
+}
+
+func (s service) RequestReauthentication(ctx context.Context, userID int, sessionID string) (err error) {
+	user, err := s.userRepository.Find(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	code, err := security.GenerateOTP(6)
+	if err != nil {
+		return err
+	}
+
+	// code is bound to the session requesting it
+	reauthenticationKey := keygenerator.ReauthenticationKey(user.ID, sessionID)
+	if err := s.keyValueService.SetEx(ctx, reauthenticationKey, []byte(code), security.ReauthenticationExpiration); err != nil {
+		return err
+	}
+
+	// TODO return error?
+	if err := s.mailingClient.SendOTPEmail(user.Email, user.Fullname, "Reauthentication", code); err != nil {
+		log.WithError(err).Error("Error sending email")
+	}
+	return nil
+}
+
+func (s service) Reauthenticate(ctx context.Context, userID int, sessionID string, method string, credential string) (accessToken security.AccessToken, err error) {
+	user, err := s.userRepository.Find(ctx, userID)
+	if err != nil {
+		return security.AccessToken{}, err
+	}
+
+	if err := user.CheckNotDeleted(); err != nil {
+		return security.AccessToken{}, err
+	}
+
+	authenticationMethod := ""
+	switch method {
+	case ReauthenticationByPassword:
+		if err = security.ComparePassword(user.Password, credential); err != nil {
+			return security.AccessToken{}, ErrWrongPassword
+		}
+		authenticationMethod = security.PasswordAuthenticationMethod
+	case ReauthenticationByOTP:
+		reauthenticationKey := keygenerator.ReauthenticationKey(user.ID, sessionID)
+		expectedCode, err := s.keyValueService.Get(ctx, reauthenticationKey)
+		if err != nil {
+			return security.AccessToken{}, ErrOTPInvalid
+		}
+		if subtle.ConstantTimeCompare(expectedCode, []byte(credential)) != 1 {
+			return security.AccessToken{}, ErrWrongOTP
+		}
+		defer s.keyValueService.Delete(ctx, reauthenticationKey)
+		authenticationMethod = security.OTPAuthenticationMethod
+	case ReauthenticationByTOTP:
+		if !user.TFAEnabled || user.TFASecret == "" {
+			return security.AccessToken{}, ErrTOTPNotEnabled
+		}
+		if !security.ValidateTOTP(user.TFASecret, credential, time.Now()) {
+			return security.AccessToken{}, ErrWrongOTP
+		}
+		// a code stays valid for a few periods, it is only accepted once meanwhile
+		totpUsedKey := keygenerator.TOTPUsedKey(user.ID, credential)
+		set, err := s.keyValueService.SetNX(ctx, totpUsedKey, []byte(sessionID), time.Duration(2*security.TOTPSkew+1)*security.TOTPPeriod)
+		if err != nil {
+			return security.AccessToken{}, err
+		}
+		if !set {
+			return security.AccessToken{}, ErrOTPInvalid
+		}
+		authenticationMethod = security.OTPAuthenticationMethod
+	default:
+		return security.AccessToken{}, ErrServiceNotImplemented
+	}
+
+	// elevated token is short lived, sensitive operation only need it for a moment
+	return security.CreateAccessToken(user, s.config.JWTSecret, security.AccessTokenOptions{
+		Expiration: security.ReauthenticationExpiration,
+		Scope:      security.UserTokenScope,
+		Authentication: security.Authentication{
+			Methods: []string{authenticationMethod},
+		},
+	})
+}
+
+//recentLogins return the latest login events of user, newest first
+func (s service) recentLogins(ctx context.Context, user userland.User) (userland.Events, error) {
+	history := s.config.LoginAlert.History
+	if history <= 0 {
+		history = DefaultLoginAlertHistory
+	}
+	filter := userland.EventFilterOptions{
+		UserID: user.ID,
+		Event:  EventLogin,
+	}
+	paging := userland.EventPagingOptions{
+		Limit:  history,
+		SortBy: "timestamp",
+		Order:  "desc",
+	}
+	events, _, err := s.eventRepository.FindAll(ctx, filter, paging)
+	return events, err
+}
+
+//isNewSignIn check whether login come from an ip or a device (user agent and client) not seen in recent logins
+func (s service) isNewSignIn(ctx context.Context, user userland.User, options LoginOptions) bool {
+	if s.eventRepository == nil || !s.config.LoginAlert.Enabled {
+		return false
+	}
+
+	events, err := s.recentLogins(ctx, user)
+	if err != nil {
+		log.WithError(err).Error("Error finding recent logins")
+		return false
+	}
+	// first login has nothing to compare with
+	if len(events) == 0 {
+		return false
+	}
+
+	knownIP, knownDevice := false, false
+	for _, event := range events {
+		if event.IP == options.IP {
+			knownIP = true
+		}
+		if event.UserAgent == options.UserAgent && event.ClientID == options.ClientID && event.ClientName == options.ClientName {
+			knownDevice = true
+		}
+	}
+	return !knownIP || !knownDevice
+}
+
+//alertNewSignIn email the user about a new sign-in with a link to DenySignIn,
+//it runs in its own goroutine after the request is done so it doesn't use the request context
+func (s service) alertNewSignIn(user userland.User, options LoginOptions) {
+	ctx := context.Background()
+	token, err := security.GenerateRandomToken(32)
+	if err != nil {
+		log.WithError(err).Error("Error generating sign-in denial token")
+		return
+	}
+	denialKey := keygenerator.SignInDenialKey(security.HashToken(token))
+	if err := s.keyValueService.SetEx(ctx, denialKey, []byte(strconv.Itoa(user.ID)), security.SignInDenialExpiration); err != nil {
+		log.WithError(err).Error("Error storing sign-in denial token")
+		return
+	}
+
+	device := useragent.Parse(options.UserAgent)
+	message := fmt.Sprintf(
+		"Your account was signed in from %s on %s (IP %s, client %s) at %s. If this was you, you can ignore this email. If it wasn't, use the link below to sign out everywhere and reset your password.",
+		device.Browser, device.OS, options.IP, options.ClientName, time.Now().UTC().Format(time.RFC1123),
+	)
+	actionLink := fmt.Sprintf("%s?token=%s", s.config.LoginAlert.DenialLink, token)
+	if err := s.mailingClient.SendNoticeEmail(user.Email, user.Fullname, "New sign-in to your account", message, actionLink); err != nil {
+		log.WithError(err).Error("Error sending email")
+	}
+}
+
+//DenySignIn lock the account owning a sign-in alert token out of its current password and start a password reset,
+//ending sessions is left to the caller
+func (s service) DenySignIn(ctx context.Context, token string) (userID int, err error) {
+	denialKey := keygenerator.SignInDenialKey(security.HashToken(token))
+	userIDBytes, err := s.keyValueService.Get(ctx, denialKey)
+	if err != nil {
+		return 0, ErrSignInDenialInvalid
+	}
+
+	userID, err = strconv.Atoi(string(userIDBytes))
+	if err != nil {
+		return 0, ErrSignInDenialInvalid
+	}
+
+	user, err := s.userRepository.Find(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	// whoever signed in knows the current password
+	scrambledPassword, err := security.GenerateRandomToken(32)
+	if err != nil {
+		return 0, err
+	}
+	password := security.HashPassword(scrambledPassword)
+	user, err = optimistic.UpdateUser(ctx, s.userRepository, user, func(user *userland.User) {
+		user.Password = password
+	})
+	if err != nil {
+		return 0, err
+	}
+	s.keyValueService.Delete(ctx, denialKey)
+
+	if _, err := s.ForgotPassword(ctx, user.Email); err != nil {
+		return 0, err
+	}
+	return user.ID, nil
 }
@@ -3,6 +3,9 @@
 package authentication_test
 
 import (
+	"context"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -11,6 +14,7 @@ import (
 	"github.com/AdhityaRamadhanus/userland/pkg/common/metrics"
 	"github.com/AdhityaRamadhanus/userland/pkg/common/security"
 	"github.com/AdhityaRamadhanus/userland/pkg/config"
+	"github.com/AdhityaRamadhanus/userland/pkg/mocks/repository"
 	"github.com/AdhityaRamadhanus/userland/pkg/storage/postgres"
 	"github.com/AdhityaRamadhanus/userland/pkg/storage/redis"
 	"github.com/AdhityaRamadhanus/userland/pkg/userlandtest"
@@ -30,7 +34,9 @@ type AuthenticationServiceTestSuite struct {
 	DB                    *sqlx.DB
 	RedisClient           *_redis.Client
 	UserRepository        userland.UserRepository
+	EventRepository       userland.EventRepository
 	KeyValueService       userland.KeyValueService
+	TrustedDeviceRepo     userland.TrustedDeviceRepository
 	AuthenticationService authentication.Service
 }
 
@@ -64,11 +70,14 @@ func (suite *AuthenticationServiceTestSuite) SetupSuite() {
 	suite.RedisClient = redisClient
 	suite.KeyValueService = redis.NewKeyValueService(redisClient)
 	suite.UserRepository = postgres.NewUserRepository(pgConn)
+	suite.EventRepository = postgres.NewEventRepository(pgConn)
+	suite.TrustedDeviceRepo = redis.NewTrustedDeviceRepository(redisClient)
 	suite.AuthenticationService = authentication.NewService(
 		authentication.WithConfiguration(suite.Config),
 		authentication.WithKeyValueService(suite.KeyValueService),
 		authentication.WithMailingClient(mailing.NewMailingClient("")),
 		authentication.WithUserRepository(suite.UserRepository),
+		authentication.WithTrustedDeviceRepository(suite.TrustedDeviceRepo),
 	)
 	suite.AuthenticationService = authentication.NewInstrumentorService(
 		metrics.PrometheusRequestLatency("service", "authentication", authentication.MetricKeys),
@@ -80,6 +89,7 @@ func (suite AuthenticationServiceTestSuite) SetupTest() {
 	queries := []string{
 		"DELETE FROM users",
 		"DELETE FROM events",
+		"DELETE FROM login_risk_assessments",
 	}
 
 	for _, query := range queries {
@@ -129,7 +139,7 @@ func (suite AuthenticationServiceTestSuite) TestRegister() {
 
 	for _, tc := range testCases {
 		suite.T().Run(tc.name, func(t *testing.T) {
-			err := suite.AuthenticationService.Register(tc.args.user)
+			err := suite.AuthenticationService.Register(context.Background(), tc.args.user)
 			if err != tc.wantErr {
 				t.Fatalf("AuthenticationService.Register(user) err = %v; want %v", err, tc.wantErr)
 			}
@@ -167,7 +177,7 @@ func (suite AuthenticationServiceTestSuite) TestRequestVerification_email() {
 
 	for _, tc := range testCases {
 		suite.T().Run(tc.name, func(t *testing.T) {
-			_, err := suite.AuthenticationService.RequestVerification("email.verify", tc.args.email)
+			_, err := suite.AuthenticationService.RequestVerification(context.Background(), "email.verify", tc.args.email)
 			if err != tc.wantErr {
 				t.Fatalf("AuthenticationService.RequestVerification(%q, %q) err = %v; want %v", "email.verify", tc.args.email, err, tc.wantErr)
 			}
@@ -196,26 +206,26 @@ func (suite AuthenticationServiceTestSuite) TestVerifyAccount_email() {
 
 	for _, tc := range testCases {
 		suite.T().Run(tc.name, func(t *testing.T) {
-			verificationID, err := suite.AuthenticationService.RequestVerification("email.verify", tc.args.email)
+			verificationID, err := suite.AuthenticationService.RequestVerification(context.Background(), "email.verify", tc.args.email)
 			if err != nil {
 				t.Fatalf("AuthenticationService.RequestVerification(%q, %q) err = %v; want nil", "email.verify", tc.args.email, err)
 			}
-			user, err := suite.UserRepository.FindByEmail(tc.args.email)
+			user, err := suite.UserRepository.FindByEmail(context.Background(), tc.args.email)
 			if err != nil {
 				t.Fatalf("UserRepository.FindByEmail(%q) err = %v; want nil", tc.args.email, err)
 			}
 			// get code, must be within security.EmailVerificationExpiration
 			key := keygenerator.EmailVerificationKey(user.ID, verificationID)
-			val, err := suite.KeyValueService.Get(key)
+			val, err := suite.KeyValueService.Get(context.Background(), key)
 			if err != nil {
 				t.Fatalf("KeyValueService.Get(%q) err = %v; want nil", key, err)
 			}
 
-			if err := suite.AuthenticationService.VerifyAccount(verificationID, tc.args.email, string(val)); err != nil {
+			if err := suite.AuthenticationService.VerifyAccount(context.Background(), verificationID, tc.args.email, string(val)); err != nil {
 				t.Fatalf("AuthenticationService.VerifyAccount(%q, %q, <val>) err = %v; want nil", verificationID, tc.args.email, err)
 			}
 
-			user, err = suite.UserRepository.FindByEmail(tc.args.email)
+			user, err = suite.UserRepository.FindByEmail(context.Background(), tc.args.email)
 			if err != nil {
 				t.Fatalf("UserRepository.FindByEmail(%q) err = %v; want nil", tc.args.email, err)
 			}
@@ -231,6 +241,15 @@ func (suite AuthenticationServiceTestSuite) TestLogin_withoutTFA() {
 	// setup
 	defaultUser := userlandtest.TestCreateUser(suite.T(), suite.UserRepository)
 	verifiedUser := userlandtest.TestCreateUser(suite.T(), suite.UserRepository, userlandtest.WithUserEmail("verified@gmail.com"), userlandtest.Verified(true))
+	deletedUser := userlandtest.TestCreateUser(suite.T(), suite.UserRepository, userlandtest.WithUserEmail("deleted@gmail.com"), userlandtest.Verified(true))
+	pendingDeletionUser, err := suite.UserRepository.Find(context.Background(), deletedUser.ID)
+	if err != nil {
+		suite.T().Fatalf("UserRepository.Find(%d) err = %v; want nil", deletedUser.ID, err)
+	}
+	pendingDeletionUser.DeletionRequestedAt = time.Now()
+	if err := suite.UserRepository.Update(context.Background(), pendingDeletionUser); err != nil {
+		suite.T().Fatalf("UserRepository.Update(user) err = %v; want nil", err)
+	}
 
 	type args struct {
 		email    string
@@ -258,11 +277,19 @@ func (suite AuthenticationServiceTestSuite) TestLogin_withoutTFA() {
 			},
 			wantErr: nil,
 		},
+		{
+			name: "pending_deletion_user",
+			args: args{
+				email:    pendingDeletionUser.Email,
+				password: "test123",
+			},
+			wantErr: authentication.ErrAccountDeleted,
+		},
 	}
 
 	for _, tc := range testCases {
 		suite.T().Run(tc.name, func(t *testing.T) {
-			requireTFA, _, err := suite.AuthenticationService.Login(tc.args.email, tc.args.password)
+			requireTFA, _, err := suite.AuthenticationService.Login(context.Background(), tc.args.email, tc.args.password, authentication.LoginOptions{})
 			if err != tc.wantErr {
 				t.Fatalf("AuthenticationService.Login(%q, %q) err = %v; want %v", tc.args.email, tc.args.password, err, tc.wantErr)
 			}
@@ -281,6 +308,355 @@ func (suite AuthenticationServiceTestSuite) TestLogin_withoutTFA() {
 	}
 }
 
+//noticeMailingClient pass action links of notice emails through a channel, alerts are sent from their own goroutine
+type noticeMailingClient struct {
+	mailing.Client
+	actionLinks chan string
+}
+
+func newNoticeMailingClient() noticeMailingClient {
+	return noticeMailingClient{actionLinks: make(chan string, 10)}
+}
+
+func (m noticeMailingClient) SendNoticeEmail(recipientAddress string, recipientName string, subject string, message string, actionLink string) error {
+	m.actionLinks <- actionLink
+	return nil
+}
+
+//waitActionLink return the next action link or false when none is sent within timeout
+func (m noticeMailingClient) waitActionLink(timeout time.Duration) (string, bool) {
+	select {
+	case actionLink := <-m.actionLinks:
+		return actionLink, true
+	case <-time.After(timeout):
+		return "", false
+	}
+}
+
+func (m noticeMailingClient) SendOTPEmail(recipientAddress string, recipientName string, otpType string, otp string) error {
+	return nil
+}
+
+func (suite AuthenticationServiceTestSuite) TestLogin_alertNewSignIn() {
+	verifiedUser := userlandtest.TestCreateUser(suite.T(), suite.UserRepository, userlandtest.Verified(true))
+	knownLogin := userland.Event{
+		UserID:     verifiedUser.ID,
+		Event:      authentication.EventLogin,
+		UserAgent:  "Mozilla/5.0 (X11; Linux x86_64) Firefox/68.0",
+		IP:         "10.10.10.10",
+		ClientID:   1,
+		ClientName: "web",
+		Timestamp:  time.Now(),
+	}
+	if err := suite.EventRepository.Insert(context.Background(), knownLogin); err != nil {
+		suite.T().Fatalf("EventRepository.Insert() err = %v; want nil", err)
+	}
+
+	cfg := *suite.Config
+	cfg.LoginAlert.Enabled = true
+	cfg.LoginAlert.DenialLink = "http://localhost:8000/signin_denial"
+	mailingClient := newNoticeMailingClient()
+	authenticationService := authentication.NewService(
+		authentication.WithConfiguration(&cfg),
+		authentication.WithKeyValueService(suite.KeyValueService),
+		authentication.WithMailingClient(mailingClient),
+		authentication.WithUserRepository(suite.UserRepository),
+		authentication.WithEventRepository(suite.EventRepository),
+	)
+
+	type args struct {
+		options authentication.LoginOptions
+	}
+	testCases := []struct {
+		name      string
+		args      args
+		wantAlert bool
+	}{
+		{
+			name: "known ip and device",
+			args: args{
+				options: authentication.LoginOptions{UserAgent: knownLogin.UserAgent, IP: knownLogin.IP, ClientID: knownLogin.ClientID, ClientName: knownLogin.ClientName},
+			},
+			wantAlert: false,
+		},
+		{
+			name: "new ip",
+			args: args{
+				options: authentication.LoginOptions{UserAgent: knownLogin.UserAgent, IP: "20.20.20.20", ClientID: knownLogin.ClientID, ClientName: knownLogin.ClientName},
+			},
+			wantAlert: true,
+		},
+		{
+			name: "new device",
+			args: args{
+				options: authentication.LoginOptions{UserAgent: "curl/7.54.0", IP: knownLogin.IP, ClientID: knownLogin.ClientID, ClientName: knownLogin.ClientName},
+			},
+			wantAlert: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		suite.T().Run(tc.name, func(t *testing.T) {
+			if _, _, err := authenticationService.Login(context.Background(), verifiedUser.Email, userlandtest.DefaultUserPassword, tc.args.options); err != nil {
+				t.Fatalf("AuthenticationService.Login() err = %v; want nil", err)
+			}
+			if _, gotAlert := mailingClient.waitActionLink(500 * time.Millisecond); gotAlert != tc.wantAlert {
+				t.Errorf("AuthenticationService.Login() sent alert = %t; want %t", gotAlert, tc.wantAlert)
+			}
+		})
+	}
+}
+
+func (suite AuthenticationServiceTestSuite) TestVerifyTFA_alertNewSignIn() {
+	tfaUser := userlandtest.TestCreateTFAEnabledUser(suite.T(), suite.UserRepository, userlandtest.Verified(true))
+	if err := suite.EventRepository.Insert(context.Background(), userland.Event{
+		UserID:    tfaUser.ID,
+		Event:     authentication.EventLogin,
+		IP:        "10.10.10.10",
+		Timestamp: time.Now(),
+	}); err != nil {
+		suite.T().Fatalf("EventRepository.Insert() err = %v; want nil", err)
+	}
+
+	cfg := *suite.Config
+	cfg.LoginAlert.Enabled = true
+	mailingClient := newNoticeMailingClient()
+	authenticationService := authentication.NewService(
+		authentication.WithConfiguration(&cfg),
+		authentication.WithKeyValueService(suite.KeyValueService),
+		authentication.WithMailingClient(mailingClient),
+		authentication.WithUserRepository(suite.UserRepository),
+		authentication.WithEventRepository(suite.EventRepository),
+	)
+
+	requireTFA, tfaToken, err := authenticationService.Login(context.Background(), tfaUser.Email, userlandtest.DefaultUserPassword, authentication.LoginOptions{IP: "20.20.20.20"})
+	if err != nil {
+		suite.T().Fatalf("AuthenticationService.Login() err = %v; want nil", err)
+	}
+	if !requireTFA {
+		suite.T().Fatalf("AuthenticationService.Login() requireTFA = false; want true")
+	}
+	// password alone doesn't complete authentication
+	if _, gotAlert := mailingClient.waitActionLink(500 * time.Millisecond); gotAlert {
+		suite.T().Errorf("AuthenticationService.Login() sent alert = true; want false")
+	}
+
+	code, err := suite.KeyValueService.Get(context.Background(), keygenerator.TFAVerificationKey(tfaUser.ID, tfaToken.Key))
+	if err != nil {
+		suite.T().Fatalf("KeyValueService.Get(tfaKey) err = %v; want nil", err)
+	}
+	if _, err := authenticationService.VerifyTFA(context.Background(), tfaToken.Key, tfaUser.ID, string(code)); err != nil {
+		suite.T().Fatalf("AuthenticationService.VerifyTFA() err = %v; want nil", err)
+	}
+	if _, gotAlert := mailingClient.waitActionLink(time.Second); !gotAlert {
+		suite.T().Errorf("AuthenticationService.VerifyTFA() sent alert = false; want true")
+	}
+}
+
+func (suite AuthenticationServiceTestSuite) TestDenySignIn() {
+	verifiedUser := userlandtest.TestCreateUser(suite.T(), suite.UserRepository, userlandtest.Verified(true))
+	if err := suite.EventRepository.Insert(context.Background(), userland.Event{
+		UserID:    verifiedUser.ID,
+		Event:     authentication.EventLogin,
+		IP:        "10.10.10.10",
+		Timestamp: time.Now(),
+	}); err != nil {
+		suite.T().Fatalf("EventRepository.Insert() err = %v; want nil", err)
+	}
+
+	cfg := *suite.Config
+	cfg.LoginAlert.Enabled = true
+	mailingClient := newNoticeMailingClient()
+	authenticationService := authentication.NewService(
+		authentication.WithConfiguration(&cfg),
+		authentication.WithKeyValueService(suite.KeyValueService),
+		authentication.WithMailingClient(mailingClient),
+		authentication.WithUserRepository(suite.UserRepository),
+		authentication.WithEventRepository(suite.EventRepository),
+	)
+
+	if _, _, err := authenticationService.Login(context.Background(), verifiedUser.Email, userlandtest.DefaultUserPassword, authentication.LoginOptions{IP: "20.20.20.20"}); err != nil {
+		suite.T().Fatalf("AuthenticationService.Login() err = %v; want nil", err)
+	}
+	actionLink, ok := mailingClient.waitActionLink(time.Second)
+	if !ok {
+		suite.T().Fatalf("AuthenticationService.Login() sent alert = false; want true")
+	}
+	token := actionLink[strings.Index(actionLink, "token=")+len("token="):]
+
+	userID, err := authenticationService.DenySignIn(context.Background(), token)
+	if err != nil {
+		suite.T().Fatalf("AuthenticationService.DenySignIn(<token>) err = %v; want nil", err)
+	}
+	if userID != verifiedUser.ID {
+		suite.T().Errorf("AuthenticationService.DenySignIn(<token>) userID = %d; want %d", userID, verifiedUser.ID)
+	}
+
+	// old password no longer works
+	if _, _, err := authenticationService.Login(context.Background(), verifiedUser.Email, userlandtest.DefaultUserPassword, authentication.LoginOptions{}); err != authentication.ErrWrongPassword {
+		suite.T().Errorf("AuthenticationService.Login() err = %v; want %v", err, authentication.ErrWrongPassword)
+	}
+	// link is single use
+	if _, err := authenticationService.DenySignIn(context.Background(), token); err != authentication.ErrSignInDenialInvalid {
+		suite.T().Errorf("AuthenticationService.DenySignIn(<token>) err = %v; want %v", err, authentication.ErrSignInDenialInvalid)
+	}
+}
+
+func (suite AuthenticationServiceTestSuite) TestLogin_riskAssessment() {
+	verifiedUser := userlandtest.TestCreateUser(suite.T(), suite.UserRepository, userlandtest.Verified(true))
+	knownLogin := userland.Event{
+		UserID:     verifiedUser.ID,
+		Event:      authentication.EventLogin,
+		UserAgent:  "Mozilla/5.0 (X11; Linux x86_64; rv:68.0) Gecko/20100101 Firefox/68.0",
+		IP:         "10.10.10.10",
+		ClientID:   1,
+		ClientName: "web",
+		Timestamp:  time.Now(),
+	}
+	if err := suite.EventRepository.Insert(context.Background(), knownLogin); err != nil {
+		suite.T().Fatalf("EventRepository.Insert() err = %v; want nil", err)
+	}
+
+	cfg := *suite.Config
+	cfg.LoginAlert.Enabled = false
+	cfg.Risk.Enabled = true
+	cfg.Risk.ChallengeThreshold = 40
+	cfg.Risk.BlockThreshold = 80
+	cfg.Risk.MaxTravelSpeed = 1000
+	authenticationService := authentication.NewService(
+		authentication.WithConfiguration(&cfg),
+		authentication.WithKeyValueService(suite.KeyValueService),
+		authentication.WithMailingClient(newNoticeMailingClient()),
+		authentication.WithUserRepository(suite.UserRepository),
+		authentication.WithEventRepository(suite.EventRepository),
+		authentication.WithGeolocationService(repository.GeolocationService{Geolocations: map[string]userland.Geolocation{
+			"10.10.10.10": {Country: "ID", City: "Jakarta", Latitude: -6.2, Longitude: 106.8},
+			"10.10.10.11": {Country: "ID", City: "Jakarta", Latitude: -6.2, Longitude: 106.8},
+			"20.20.20.20": {Country: "FR", City: "Paris", Latitude: 48.85, Longitude: 2.35},
+		}}),
+		authentication.WithLoginRiskAssessmentRepository(postgres.NewLoginRiskAssessmentRepository(suite.DB)),
+	)
+
+	type args struct {
+		options authentication.LoginOptions
+	}
+	testCases := []struct {
+		name           string
+		args           args
+		wantRequireTFA bool
+		wantErr        error
+	}{
+		{
+			name: "known ip and device",
+			args: args{
+				options: authentication.LoginOptions{UserAgent: knownLogin.UserAgent, IP: knownLogin.IP},
+			},
+			wantRequireTFA: false,
+			wantErr:        nil,
+		},
+		{
+			name: "new ip nearby",
+			args: args{
+				options: authentication.LoginOptions{UserAgent: knownLogin.UserAgent, IP: "10.10.10.11"},
+			},
+			wantRequireTFA: false,
+			wantErr:        nil,
+		},
+		{
+			name: "impossible travel",
+			args: args{
+				options: authentication.LoginOptions{UserAgent: knownLogin.UserAgent, IP: "20.20.20.20"},
+			},
+			wantRequireTFA: true,
+			wantErr:        nil,
+		},
+		{
+			name: "impossible travel with unusual user agent",
+			args: args{
+				options: authentication.LoginOptions{UserAgent: "", IP: "20.20.20.20"},
+			},
+			wantRequireTFA: false,
+			wantErr:        authentication.ErrLoginBlocked,
+		},
+	}
+
+	for _, tc := range testCases {
+		suite.T().Run(tc.name, func(t *testing.T) {
+			requireTFA, _, err := authenticationService.Login(context.Background(), verifiedUser.Email, userlandtest.DefaultUserPassword, tc.args.options)
+			if err != tc.wantErr {
+				t.Fatalf("AuthenticationService.Login() err = %v; want %v", err, tc.wantErr)
+			}
+			if requireTFA != tc.wantRequireTFA {
+				t.Errorf("AuthenticationService.Login() requireTFA = %t; want %t", requireTFA, tc.wantRequireTFA)
+			}
+		})
+	}
+
+	// every assessment is recorded for review
+	decisions := []string{}
+	query := "SELECT decision FROM login_risk_assessments WHERE user_id=$1 ORDER BY id"
+	if err := suite.DB.Select(&decisions, query, verifiedUser.ID); err != nil {
+		suite.T().Fatalf("DB.Select(%q) err = %v; want nil", query, err)
+	}
+	wantDecisions := []string{
+		userland.LoginRiskDecisionAllow,
+		userland.LoginRiskDecisionAllow,
+		userland.LoginRiskDecisionChallenge,
+		userland.LoginRiskDecisionBlock,
+	}
+	if strings.Join(decisions, ",") != strings.Join(wantDecisions, ",") {
+		suite.T().Errorf("recorded decisions = %v; want %v", decisions, wantDecisions)
+	}
+}
+
+func (suite AuthenticationServiceTestSuite) TestLogin_riskAssessmentFailedAttempts() {
+	verifiedUser := userlandtest.TestCreateUser(suite.T(), suite.UserRepository, userlandtest.Verified(true))
+
+	cfg := *suite.Config
+	cfg.Risk.Enabled = true
+	cfg.Risk.ChallengeThreshold = 40
+	cfg.Risk.BlockThreshold = 80
+	authenticationService := authentication.NewService(
+		authentication.WithConfiguration(&cfg),
+		authentication.WithKeyValueService(suite.KeyValueService),
+		authentication.WithMailingClient(newNoticeMailingClient()),
+		authentication.WithUserRepository(suite.UserRepository),
+	)
+
+	options := authentication.LoginOptions{UserAgent: "Mozilla/5.0 (X11; Linux x86_64; rv:68.0) Gecko/20100101 Firefox/68.0"}
+	for i := 0; i < 4; i++ {
+		if _, _, err := authenticationService.Login(context.Background(), verifiedUser.Email, "wrong password", options); err != authentication.ErrWrongPassword {
+			suite.T().Fatalf("AuthenticationService.Login() err = %v; want %v", err, authentication.ErrWrongPassword)
+		}
+	}
+
+	// right password after many failures still has to pass the challenge
+	requireTFA, tfaToken, err := authenticationService.Login(context.Background(), verifiedUser.Email, userlandtest.DefaultUserPassword, options)
+	if err != nil {
+		suite.T().Fatalf("AuthenticationService.Login() err = %v; want nil", err)
+	}
+	if !requireTFA {
+		suite.T().Fatalf("AuthenticationService.Login() requireTFA = false; want true")
+	}
+
+	code, err := suite.KeyValueService.Get(context.Background(), keygenerator.TFAVerificationKey(verifiedUser.ID, tfaToken.Key))
+	if err != nil {
+		suite.T().Fatalf("KeyValueService.Get(tfaKey) err = %v; want nil", err)
+	}
+	if _, err := authenticationService.VerifyTFA(context.Background(), tfaToken.Key, verifiedUser.ID, string(code)); err != nil {
+		suite.T().Fatalf("AuthenticationService.VerifyTFA() err = %v; want nil", err)
+	}
+
+	// failures are forgotten once the challenge is passed
+	requireTFA, _, err = authenticationService.Login(context.Background(), verifiedUser.Email, userlandtest.DefaultUserPassword, options)
+	if err != nil {
+		suite.T().Fatalf("AuthenticationService.Login() err = %v; want nil", err)
+	}
+	if requireTFA {
+		suite.T().Errorf("AuthenticationService.Login() requireTFA = true; want false")
+	}
+}
+
 func (suite AuthenticationServiceTestSuite) TestLogin_withTFA() {
 	// setup
 	defaultUser := userlandtest.TestCreateUser(suite.T(), suite.UserRepository)
@@ -319,7 +695,7 @@ func (suite AuthenticationServiceTestSuite) TestLogin_withTFA() {
 	for _, tc := range testCases {
 		suite.T().Run(tc.name, func(t *testing.T) {
 			// setup
-			user, err := suite.UserRepository.FindByEmail(tc.args.email)
+			user, err := suite.UserRepository.FindByEmail(context.Background(), tc.args.email)
 			if err != nil {
 				t.Fatalf("UserRepository.FindByEmail(%q) err = %v; want nil", tc.args.email, err)
 			}
@@ -327,11 +703,11 @@ func (suite AuthenticationServiceTestSuite) TestLogin_withTFA() {
 			user.Verified = tc.args.verified
 			user.TFAEnabled = true
 			user.TFAEnabledAt = time.Now()
-			if err := suite.UserRepository.Update(user); err != nil {
+			if err := suite.UserRepository.Update(context.Background(), user); err != nil {
 				t.Fatalf("UserRepository.Update(user) err = %v; want nil", err)
 			}
 
-			requireTFA, _, err := suite.AuthenticationService.Login(tc.args.email, tc.args.password)
+			requireTFA, _, err := suite.AuthenticationService.Login(context.Background(), tc.args.email, tc.args.password, authentication.LoginOptions{})
 			if err != tc.wantErr {
 				t.Fatalf("AuthenticationService.Login(%q, %q) err = %v; want %v", tc.args.email, tc.args.password, err, tc.wantErr)
 			}
@@ -377,17 +753,17 @@ func (suite AuthenticationServiceTestSuite) TestVerifyTFA() {
 	for _, tc := range testCases {
 		suite.T().Run(tc.name, func(t *testing.T) {
 			// setup
-			user, err := suite.UserRepository.FindByEmail(tc.args.email)
+			user, err := suite.UserRepository.FindByEmail(context.Background(), tc.args.email)
 			if err != nil {
 				t.Fatalf("UserRepository.FindByEmail(%q) err = %v; want nil", tc.args.email, err)
 			}
 			user.TFAEnabled = true
 			user.Verified = true
-			if err := suite.UserRepository.Update(user); err != nil {
+			if err := suite.UserRepository.Update(context.Background(), user); err != nil {
 				t.Fatalf("UserRepository.Update(user) err = %v; want nil", err)
 			}
 
-			requireTFA, tfaToken, err := suite.AuthenticationService.Login(tc.args.email, tc.args.password)
+			requireTFA, tfaToken, err := suite.AuthenticationService.Login(context.Background(), tc.args.email, tc.args.password, authentication.LoginOptions{})
 			if err != nil {
 				t.Fatalf("AuthenticationService.Login(%q, %q) err = %v; want %v", tc.args.email, tc.args.password, err, tc.wantErr)
 			}
@@ -397,19 +773,90 @@ func (suite AuthenticationServiceTestSuite) TestVerifyTFA() {
 			}
 
 			tfaKey := keygenerator.TFAVerificationKey(user.ID, tfaToken.Key)
-			expectedCode, err := suite.KeyValueService.Get(tfaKey)
+			expectedCode, err := suite.KeyValueService.Get(context.Background(), tfaKey)
 			if err != nil {
 				t.Fatalf("KeyValueService.Get(%q) err = %v; want nil", tfaKey, err)
 			}
 
 			// TODO check access token
-			if _, err := suite.AuthenticationService.VerifyTFA(tfaToken.Key, user.ID, string(expectedCode)); err != tc.wantErr {
+			if _, err := suite.AuthenticationService.VerifyTFA(context.Background(), tfaToken.Key, user.ID, string(expectedCode)); err != tc.wantErr {
 				t.Fatalf("AuthenticationService.VerifyTFA(%q, %d, <code>) err = %v; want %v", tfaToken.Key, user.ID, err, tc.wantErr)
 			}
 		})
 	}
 }
 
+func (suite AuthenticationServiceTestSuite) TestLogin_withTrustedDevice() {
+	// setup
+	defaultUser := userlandtest.TestCreateUser(suite.T(), suite.UserRepository, userlandtest.Verified(true))
+	defaultUser.TFAEnabled = true
+	defaultUser.TFAEnabledAt = time.Now()
+	if err := suite.UserRepository.Update(context.Background(), *defaultUser); err != nil {
+		suite.T().Fatalf("UserRepository.Update(user) err = %v; want nil", err)
+	}
+
+	userAgent := "Mozilla/5.0 (X11; Linux x86_64)"
+	deviceToken, _, err := suite.AuthenticationService.TrustDevice(context.Background(), defaultUser.ID, userland.TrustedDevice{
+		UserAgent:  userAgent,
+		IP:         "127.0.0.1",
+		ClientName: "test",
+	})
+	if err != nil {
+		suite.T().Fatalf("AuthenticationService.TrustDevice() err = %v; want nil", err)
+	}
+
+	type args struct {
+		options authentication.LoginOptions
+	}
+	testCases := []struct {
+		name           string
+		args           args
+		wantRequireTFA bool
+	}{
+		{
+			name: "trusted device",
+			args: args{
+				options: authentication.LoginOptions{DeviceToken: deviceToken, UserAgent: userAgent},
+			},
+			wantRequireTFA: false,
+		},
+		{
+			name: "different user agent",
+			args: args{
+				options: authentication.LoginOptions{DeviceToken: deviceToken, UserAgent: "curl/7.58.0"},
+			},
+			wantRequireTFA: true,
+		},
+		{
+			name: "tampered device token",
+			args: args{
+				options: authentication.LoginOptions{DeviceToken: deviceToken + "x", UserAgent: userAgent},
+			},
+			wantRequireTFA: true,
+		},
+		{
+			name: "without device token",
+			args: args{
+				options: authentication.LoginOptions{UserAgent: userAgent},
+			},
+			wantRequireTFA: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		suite.T().Run(tc.name, func(t *testing.T) {
+			requireTFA, _, err := suite.AuthenticationService.Login(context.Background(), defaultUser.Email, userlandtest.DefaultUserPassword, tc.args.options)
+			if err != nil {
+				t.Fatalf("AuthenticationService.Login() err = %v; want nil", err)
+			}
+
+			if requireTFA != tc.wantRequireTFA {
+				t.Errorf("AuthenticationService.Login() requireTFA = %t; want %t", requireTFA, tc.wantRequireTFA)
+			}
+		})
+	}
+}
+
 func (suite AuthenticationServiceTestSuite) TestVerifyTFABypass() {
 	// setup
 	defaultUser := userlandtest.TestCreateUser(suite.T(), suite.UserRepository)
@@ -456,26 +903,27 @@ func (suite AuthenticationServiceTestSuite) TestVerifyTFABypass() {
 	for _, tc := range testCases {
 		suite.T().Run(tc.name, func(t *testing.T) {
 			// setup
-			user, err := suite.UserRepository.FindByEmail(tc.args.email)
+			user, err := suite.UserRepository.FindByEmail(context.Background(), tc.args.email)
 			if err != nil {
 				t.Fatalf("UserRepository.FindByEmail(%q) err = %v; want nil", tc.args.email, err)
 			}
 			user.TFAEnabled = true
 			user.Verified = true
-			if err := suite.UserRepository.Update(user); err != nil {
+			if err := suite.UserRepository.Update(context.Background(), user); err != nil {
 				t.Fatalf("UserRepository.Update(user) err = %v; want nil", err)
 			}
+			user.Version++
 
 			hashedBackupCodes := []string{}
 			for _, backupCode := range tc.args.backupCodes {
 				hashedBackupCodes = append(hashedBackupCodes, security.HashPassword(backupCode))
 			}
 			user.BackupCodes = hashedBackupCodes
-			if err := suite.UserRepository.StoreBackupCodes(user); err != nil {
+			if err := suite.UserRepository.StoreBackupCodes(context.Background(), user); err != nil {
 				t.Fatalf("UserRepository.StoreBackupCodes(user) err = %v; want nil", err)
 			}
 
-			requireTFA, tfaToken, err := suite.AuthenticationService.Login(tc.args.email, tc.args.password)
+			requireTFA, tfaToken, err := suite.AuthenticationService.Login(context.Background(), tc.args.email, tc.args.password, authentication.LoginOptions{})
 			if err != nil {
 				t.Fatalf("AuthenticationService.Login(%q, %q) err = %v; want %v", tc.args.email, tc.args.password, err, tc.wantErr)
 			}
@@ -485,13 +933,69 @@ func (suite AuthenticationServiceTestSuite) TestVerifyTFABypass() {
 			}
 
 			// TODO check access token
-			if _, err := suite.AuthenticationService.VerifyTFABypass(tfaToken.Key, user.ID, tc.args.usedCode); err != tc.wantErr {
+			if _, err := suite.AuthenticationService.VerifyTFABypass(context.Background(), tfaToken.Key, user.ID, tc.args.usedCode); err != tc.wantErr {
 				t.Fatalf("AuthenticationService.VerifyTFABypass(%q, %d, %q) err = %v; want %v", tfaToken.Key, user.ID, tc.args.usedCode, err, tc.wantErr)
 			}
 		})
 	}
 }
 
+func (suite AuthenticationServiceTestSuite) TestVerifyTFABypass_concurrentConsumption() {
+	// setup
+	user := userlandtest.TestCreateUser(suite.T(), suite.UserRepository)
+	user.TFAEnabled = true
+	user.Verified = true
+	if err := suite.UserRepository.Update(context.Background(), *user); err != nil {
+		suite.T().Fatalf("UserRepository.Update(user) err = %v; want nil", err)
+	}
+	user.Version++
+	user.BackupCodes = []string{security.HashPassword("backupCode1"), security.HashPassword("backupCode2")}
+	if err := suite.UserRepository.StoreBackupCodes(context.Background(), *user); err != nil {
+		suite.T().Fatalf("UserRepository.StoreBackupCodes(user) err = %v; want nil", err)
+	}
+
+	// two sign-ins race with the same backup code, only one may consume it
+	const attempts = 2
+	errs := make(chan error, attempts)
+	var wg sync.WaitGroup
+	for i := 0; i < attempts; i++ {
+		_, tfaToken, err := suite.AuthenticationService.Login(context.Background(), user.Email, userlandtest.DefaultUserPassword, authentication.LoginOptions{})
+		if err != nil {
+			suite.T().Fatalf("AuthenticationService.Login(%q) err = %v; want nil", user.Email, err)
+		}
+		wg.Add(1)
+		go func(tfaToken string) {
+			defer wg.Done()
+			_, err := suite.AuthenticationService.VerifyTFABypass(context.Background(), tfaToken, user.ID, "backupCode1")
+			errs <- err
+		}(tfaToken.Key)
+	}
+	wg.Wait()
+	close(errs)
+
+	succeeded := 0
+	for err := range errs {
+		switch err {
+		case nil:
+			succeeded++
+		case authentication.ErrWrongBackupCode:
+		default:
+			suite.T().Errorf("AuthenticationService.VerifyTFABypass(backupCode1) err = %v; want nil or %v", err, authentication.ErrWrongBackupCode)
+		}
+	}
+	if succeeded != 1 {
+		suite.T().Errorf("AuthenticationService.VerifyTFABypass(backupCode1) succeeded %d times; want 1", succeeded)
+	}
+
+	found, err := suite.UserRepository.Find(context.Background(), user.ID)
+	if err != nil {
+		suite.T().Fatalf("UserRepository.Find(%d) err = %v; want nil", user.ID, err)
+	}
+	if len(found.BackupCodes) != 1 {
+		suite.T().Errorf("UserRepository.Find(%d) len(BackupCodes) = %d; want 1", user.ID, len(found.BackupCodes))
+	}
+}
+
 func (suite AuthenticationServiceTestSuite) TestForgotPassword() {
 	defaultUser := userlandtest.TestCreateUser(suite.T(), suite.UserRepository)
 
@@ -522,13 +1026,155 @@ func (suite AuthenticationServiceTestSuite) TestForgotPassword() {
 
 	for _, tc := range testCases {
 		suite.T().Run(tc.name, func(t *testing.T) {
-			if _, err := suite.AuthenticationService.ForgotPassword(tc.args.email); err != tc.wantErr {
+			if _, err := suite.AuthenticationService.ForgotPassword(context.Background(), tc.args.email); err != tc.wantErr {
 				t.Fatalf("AuthenticationService.ForgotPassword(%q) err = %v; want %v", tc.args.email, err, tc.wantErr)
 			}
 		})
 	}
 }
 
+func (suite AuthenticationServiceTestSuite) TestReauthenticate() {
+	defaultUser := userlandtest.TestCreateUser(suite.T(), suite.UserRepository, userlandtest.Verified(true))
+	sessionID := security.GenerateUUID()
+
+	type args struct {
+		method     string
+		credential string
+	}
+
+	testCases := []struct {
+		name    string
+		args    args
+		wantErr error
+	}{
+		{
+			name: "success with password",
+			args: args{
+				method:     authentication.ReauthenticationByPassword,
+				credential: userlandtest.DefaultUserPassword,
+			},
+			wantErr: nil,
+		},
+		{
+			name: "wrong password",
+			args: args{
+				method:     authentication.ReauthenticationByPassword,
+				credential: "wrongpassword",
+			},
+			wantErr: authentication.ErrWrongPassword,
+		},
+		{
+			name: "otp not requested",
+			args: args{
+				method:     authentication.ReauthenticationByOTP,
+				credential: "123456",
+			},
+			wantErr: authentication.ErrOTPInvalid,
+		},
+		{
+			name: "totp without tfa",
+			args: args{
+				method:     authentication.ReauthenticationByTOTP,
+				credential: "123456",
+			},
+			wantErr: authentication.ErrTOTPNotEnabled,
+		},
+		{
+			name: "unknown method",
+			args: args{
+				method:     "carrier-pigeon",
+				credential: "coo",
+			},
+			wantErr: authentication.ErrServiceNotImplemented,
+		},
+	}
+
+	for _, tc := range testCases {
+		suite.T().Run(tc.name, func(t *testing.T) {
+			if _, err := suite.AuthenticationService.Reauthenticate(context.Background(), defaultUser.ID, sessionID, tc.args.method, tc.args.credential); err != tc.wantErr {
+				t.Fatalf("AuthenticationService.Reauthenticate(%q) err = %v; want %v", tc.args.method, err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func (suite AuthenticationServiceTestSuite) TestReauthenticate_withOTP() {
+	defaultUser := userlandtest.TestCreateUser(suite.T(), suite.UserRepository, userlandtest.Verified(true))
+	sessionID := security.GenerateUUID()
+
+	if err := suite.AuthenticationService.RequestReauthentication(context.Background(), defaultUser.ID, sessionID); err != nil {
+		suite.T().Fatalf("AuthenticationService.RequestReauthentication() err = %v; want nil", err)
+	}
+
+	code, err := suite.KeyValueService.Get(context.Background(), keygenerator.ReauthenticationKey(defaultUser.ID, sessionID))
+	if err != nil {
+		suite.T().Fatalf("KeyValueService.Get() err = %v; want nil", err)
+	}
+
+	if _, err := suite.AuthenticationService.Reauthenticate(context.Background(), defaultUser.ID, sessionID, authentication.ReauthenticationByOTP, "000000x"); err != authentication.ErrWrongOTP {
+		suite.T().Fatalf("AuthenticationService.Reauthenticate() err = %v; want %v", err, authentication.ErrWrongOTP)
+	}
+	if _, err := suite.AuthenticationService.Reauthenticate(context.Background(), defaultUser.ID, sessionID, authentication.ReauthenticationByOTP, string(code)); err != nil {
+		suite.T().Fatalf("AuthenticationService.Reauthenticate() err = %v; want nil", err)
+	}
+	// code can only be used once
+	if _, err := suite.AuthenticationService.Reauthenticate(context.Background(), defaultUser.ID, sessionID, authentication.ReauthenticationByOTP, string(code)); err != authentication.ErrOTPInvalid {
+		suite.T().Fatalf("AuthenticationService.Reauthenticate() err = %v; want %v", err, authentication.ErrOTPInvalid)
+	}
+}
+
+func (suite AuthenticationServiceTestSuite) TestReauthenticate_withTOTP() {
+	secret, err := security.GenerateTOTPSecret()
+	if err != nil {
+		suite.T().Fatalf("security.GenerateTOTPSecret() err = %v; want nil", err)
+	}
+	defaultUser := userlandtest.TestCreateTFAEnabledUser(suite.T(), suite.UserRepository, userlandtest.Verified(true))
+	defaultUser.Version++
+	defaultUser.TFASecret = secret
+	if err := suite.UserRepository.Update(context.Background(), *defaultUser); err != nil {
+		suite.T().Fatalf("UserRepository.Update() err = %v; want nil", err)
+	}
+	sessionID := security.GenerateUUID()
+
+	code, err := security.GenerateTOTP(secret, time.Now())
+	if err != nil {
+		suite.T().Fatalf("security.GenerateTOTP() err = %v; want nil", err)
+	}
+	wrongCode, err := security.GenerateTOTP(secret, time.Now().Add(-5*security.TOTPPeriod))
+	if err != nil {
+		suite.T().Fatalf("security.GenerateTOTP() err = %v; want nil", err)
+	}
+
+	if wrongCode != code {
+		if _, err := suite.AuthenticationService.Reauthenticate(context.Background(), defaultUser.ID, sessionID, authentication.ReauthenticationByTOTP, wrongCode); err != authentication.ErrWrongOTP {
+			suite.T().Fatalf("AuthenticationService.Reauthenticate() err = %v; want %v", err, authentication.ErrWrongOTP)
+		}
+	}
+	accessToken, err := suite.AuthenticationService.Reauthenticate(context.Background(), defaultUser.ID, sessionID, authentication.ReauthenticationByTOTP, code)
+	if err != nil {
+		suite.T().Fatalf("AuthenticationService.Reauthenticate() err = %v; want nil", err)
+	}
+	if accessToken.Value == "" {
+		suite.T().Errorf("AuthenticationService.Reauthenticate() access token is empty")
+	}
+	// code can only be used once while it is valid
+	if _, err := suite.AuthenticationService.Reauthenticate(context.Background(), defaultUser.ID, security.GenerateUUID(), authentication.ReauthenticationByTOTP, code); err != authentication.ErrOTPInvalid {
+		suite.T().Fatalf("AuthenticationService.Reauthenticate() err = %v; want %v", err, authentication.ErrOTPInvalid)
+	}
+}
+
+func (suite AuthenticationServiceTestSuite) TestReauthenticate_pendingDeletion() {
+	defaultUser := userlandtest.TestCreateUser(suite.T(), suite.UserRepository, userlandtest.Verified(true))
+	defaultUser.DeletionRequestedAt = time.Now()
+	if err := suite.UserRepository.Update(context.Background(), *defaultUser); err != nil {
+		suite.T().Fatalf("UserRepository.Update() err = %v; want nil", err)
+	}
+
+	if _, err := suite.AuthenticationService.Reauthenticate(context.Background(), defaultUser.ID, security.GenerateUUID(), authentication.ReauthenticationByPassword, userlandtest.DefaultUserPassword); err != authentication.ErrAccountDeleted {
+		suite.T().Fatalf("AuthenticationService.Reauthenticate() err = %v; want %v", err, authentication.ErrAccountDeleted)
+	}
+}
+
 func (suite AuthenticationServiceTestSuite) TestResetPasswordIntegration() {
 	defaultUser := userlandtest.TestCreateUser(suite.T(), suite.UserRepository, userlandtest.Verified(true))
 
@@ -554,16 +1200,16 @@ func (suite AuthenticationServiceTestSuite) TestResetPasswordIntegration() {
 
 	for _, tc := range testCases {
 		suite.T().Run(tc.name, func(t *testing.T) {
-			verificationID, err := suite.AuthenticationService.ForgotPassword(tc.args.email)
+			verificationID, err := suite.AuthenticationService.ForgotPassword(context.Background(), tc.args.email)
 			if err != nil {
 				t.Fatalf("AuthenticationService.ForgotPassword(%q) err = %v; want %v", tc.args.email, err, tc.wantErr)
 			}
 
-			if err := suite.AuthenticationService.ResetPassword(verificationID, tc.args.newPassword); err != tc.wantErr {
+			if _, err := suite.AuthenticationService.ResetPassword(context.Background(), verificationID, tc.args.newPassword); err != tc.wantErr {
 				t.Fatalf("AuthenticationService.ResetPassword(%q, %q) err = %v; want %v", verificationID, tc.args.newPassword, err, tc.wantErr)
 			}
 
-			if _, _, err := suite.AuthenticationService.Login(tc.args.email, tc.args.newPassword); err != nil {
+			if _, _, err := suite.AuthenticationService.Login(context.Background(), tc.args.email, tc.args.newPassword, authentication.LoginOptions{}); err != nil {
 				t.Fatalf("AuthenticationService.Login(%q, %q) err = %v; want nil", tc.args.email, tc.args.newPassword, err)
 			}
 		})
@@ -0,0 +1,67 @@
+package client
+
+import (
+	"context"
+	"time"
+
+	"github.com/AdhityaRamadhanus/userland"
+	"github.com/go-kit/kit/metrics"
+)
+
+var (
+	MetricKeys = []string{"method"}
+)
+
+type instrumentorService struct {
+	requestLatency metrics.Histogram
+	next           Service
+}
+
+func NewInstrumentorService(latency metrics.Histogram, s Service) Service {
+	service := &instrumentorService{
+		requestLatency: latency,
+		next:           s,
+	}
+
+	return service
+}
+
+func (s instrumentorService) RegisterClient(ctx context.Context, client userland.Client) (userland.Client, string, string, error) {
+	defer func(begin time.Time) {
+		s.requestLatency.With("method", "RegisterClient").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return s.next.RegisterClient(ctx, client)
+}
+
+func (s instrumentorService) ListClients(ctx context.Context) (userland.Clients, error) {
+	defer func(begin time.Time) {
+		s.requestLatency.With("method", "ListClients").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return s.next.ListClients(ctx)
+}
+
+func (s instrumentorService) GetClient(ctx context.Context, clientID int) (userland.Client, error) {
+	defer func(begin time.Time) {
+		s.requestLatency.With("method", "GetClient").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return s.next.GetClient(ctx, clientID)
+}
+
+func (s instrumentorService) UpdateClient(ctx context.Context, client userland.Client) (userland.Client, error) {
+	defer func(begin time.Time) {
+		s.requestLatency.With("method", "UpdateClient").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return s.next.UpdateClient(ctx, client)
+}
+
+func (s instrumentorService) VerifyClient(ctx context.Context, credential string, secret string, origin string) (userland.Client, error) {
+	defer func(begin time.Time) {
+		s.requestLatency.With("method", "VerifyClient").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return s.next.VerifyClient(ctx, credential, secret, origin)
+}
@@ -0,0 +1,227 @@
+package client
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"time"
+
+	"github.com/AdhityaRamadhanus/userland"
+	"github.com/AdhityaRamadhanus/userland/pkg/common/keygenerator"
+	"github.com/AdhityaRamadhanus/userland/pkg/common/security"
+	"github.com/AdhityaRamadhanus/userland/pkg/config"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+//ClientCacheExpiration is how long a client verified by VerifyClient is cached, updates from other instances take up to this long to be seen
+const ClientCacheExpiration = 1 * time.Minute
+
+var (
+	ErrInvalidClientType      = errors.New("Client type must be one of web, mobile or server")
+	ErrInvalidClientStatus    = errors.New("Client status must be one of active or disabled")
+	ErrPublicServerClient     = errors.New("Server client cannot be public")
+	ErrInvalidClient          = errors.New("Client credential is invalid")
+	ErrWrongClientSecret      = errors.New("Client secret is wrong")
+	ErrClientDisabled         = errors.New("Client is disabled")
+	ErrClientOriginNotAllowed = errors.New("Origin is not allowed for this client")
+	ErrNoClientSigningKey     = errors.New("Client signing key is not configured")
+)
+
+//Service provide an interface to api client registry domain service
+type Service interface {
+	RegisterClient(ctx context.Context, client userland.Client) (registeredClient userland.Client, credential string, secret string, err error)
+	ListClients(ctx context.Context) (userland.Clients, error)
+	GetClient(ctx context.Context, clientID int) (userland.Client, error)
+	UpdateClient(ctx context.Context, client userland.Client) (userland.Client, error)
+	VerifyClient(ctx context.Context, credential string, secret string, origin string) (userland.Client, error)
+}
+
+func WithConfiguration(cfg *config.Configuration) func(service *service) {
+	return func(service *service) {
+		service.config = cfg
+	}
+}
+
+func WithClientRepository(clientRepository userland.ClientRepository) func(service *service) {
+	return func(service *service) {
+		service.clientRepository = clientRepository
+	}
+}
+
+//WithKeyValueService cache clients looked up by VerifyClient, every request is verified so it shouldn't hit the database each time
+func WithKeyValueService(keyValueService userland.KeyValueService) func(service *service) {
+	return func(service *service) {
+		service.keyValueService = keyValueService
+	}
+}
+
+func NewService(options ...func(*service)) Service {
+	service := &service{}
+	for _, option := range options {
+		option(service)
+	}
+
+	return service
+}
+
+type service struct {
+	config           *config.Configuration
+	clientRepository userland.ClientRepository
+	keyValueService  userland.KeyValueService
+}
+
+
+func validateClientStatus(status string) error {
+	switch status {
+	case userland.ClientStatusActive, userland.ClientStatusDisabled:
+		return nil
+	default:
+		return ErrInvalidClientStatus
+	}
+}
+
+//RegisterClient register a new active client, secret is only returned here and is empty for public clients
+func (s service) RegisterClient(ctx context.Context, client userland.Client) (registeredClient userland.Client, credential string, secret string, err error) {
+	if s.config.Client.SigningKey == "" {
+		return userland.Client{}, "", "", ErrNoClientSigningKey
+	}
+
+	switch client.Type {
+	case userland.ClientTypeWeb, userland.ClientTypeMobile:
+	case userland.ClientTypeServer:
+		if client.Public {
+			return userland.Client{}, "", "", ErrPublicServerClient
+		}
+	default:
+		return userland.Client{}, "", "", ErrInvalidClientType
+	}
+
+	client.SecretHash = ""
+	if !client.Public {
+		secret, err = security.GenerateRandomToken(32)
+		if err != nil {
+			return userland.Client{}, "", "", err
+		}
+		client.SecretHash = security.HashToken(secret)
+	}
+
+	client.Status = userland.ClientStatusActive
+	if err := s.clientRepository.Insert(ctx, &client); err != nil {
+		return userland.Client{}, "", "", err
+	}
+
+	return client, security.SignClientCredential(client.ID, s.config.Client.SigningKey), secret, nil
+}
+
+func (s service) ListClients(ctx context.Context) (userland.Clients, error) {
+	return s.clientRepository.FindAll(ctx)
+}
+
+func (s service) GetClient(ctx context.Context, clientID int) (userland.Client, error) {
+	return s.clientRepository.Find(ctx, clientID)
+}
+
+//UpdateClient update name, allowed origins and status of a client, type and secret can't be changed
+func (s service) UpdateClient(ctx context.Context, client userland.Client) (userland.Client, error) {
+	if err := validateClientStatus(client.Status); err != nil {
+		return userland.Client{}, err
+	}
+
+	existingClient, err := s.clientRepository.Find(ctx, client.ID)
+	if err != nil {
+		return userland.Client{}, err
+	}
+
+	existingClient.Name = client.Name
+	existingClient.AllowedOrigins = client.AllowedOrigins
+	existingClient.Status = client.Status
+	if err := s.clientRepository.Update(ctx, existingClient); err != nil {
+		return userland.Client{}, err
+	}
+	s.uncacheClient(ctx, client.ID)
+
+	return s.clientRepository.Find(ctx, client.ID)
+}
+
+//VerifyClient return the active client identified by credential, confidential clients must also present their secret.
+//Origin is checked against allowed origins of the client when the request has one
+func (s service) VerifyClient(ctx context.Context, credential string, secret string, origin string) (userland.Client, error) {
+	// without a signing key anyone could sign a credential, every client is treated as unknown
+	if s.config.Client.SigningKey == "" {
+		return userland.Client{}, ErrInvalidClient
+	}
+
+	clientID, err := security.ParseClientCredential(credential, s.config.Client.SigningKey)
+	if err != nil {
+		return userland.Client{}, ErrInvalidClient
+	}
+
+	client, err := s.findCachedClient(ctx, clientID)
+	if err != nil {
+		if err == userland.ErrClientNotFound {
+			return userland.Client{}, ErrInvalidClient
+		}
+		return userland.Client{}, err
+	}
+
+	if client.Status != userland.ClientStatusActive {
+		return userland.Client{}, ErrClientDisabled
+	}
+
+	if !client.Public && subtle.ConstantTimeCompare([]byte(security.HashToken(secret)), []byte(client.SecretHash)) != 1 {
+		return userland.Client{}, ErrWrongClientSecret
+	}
+
+	if origin != "" && len(client.AllowedOrigins) > 0 {
+		allowed := false
+		for _, allowedOrigin := range client.AllowedOrigins {
+			if allowedOrigin == origin {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return userland.Client{}, ErrClientOriginNotAllowed
+		}
+	}
+
+	return client, nil
+}
+
+//findCachedClient find client in the cache first and cache it when it has to be read from the repository
+func (s service) findCachedClient(ctx context.Context, clientID int) (userland.Client, error) {
+	if s.keyValueService == nil {
+		return s.clientRepository.Find(ctx, clientID)
+	}
+
+	clientKey := keygenerator.ClientKey(clientID)
+	if clientJSON, err := s.keyValueService.Get(ctx, clientKey); err == nil {
+		client := userland.Client{}
+		if err := json.Unmarshal(clientJSON, &client); err == nil {
+			return client, nil
+		}
+	}
+
+	client, err := s.clientRepository.Find(ctx, clientID)
+	if err != nil {
+		return userland.Client{}, err
+	}
+	clientJSON, err := json.Marshal(client)
+	if err != nil {
+		return userland.Client{}, err
+	}
+	if err := s.keyValueService.SetEx(ctx, clientKey, clientJSON, ClientCacheExpiration); err != nil {
+		log.WithError(err).Error("Error caching client")
+	}
+	return client, nil
+}
+
+func (s service) uncacheClient(ctx context.Context, clientID int) {
+	if s.keyValueService == nil {
+		return
+	}
+	if err := s.keyValueService.Delete(ctx, keygenerator.ClientKey(clientID)); err != nil {
+		log.WithError(err).Error("Error removing cached client")
+	}
+}
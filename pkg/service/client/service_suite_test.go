@@ -0,0 +1,362 @@
+// +build integration
+
+package client_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/AdhityaRamadhanus/userland"
+	"github.com/AdhityaRamadhanus/userland/pkg/common/metrics"
+	"github.com/AdhityaRamadhanus/userland/pkg/common/security"
+	"github.com/AdhityaRamadhanus/userland/pkg/config"
+	"github.com/AdhityaRamadhanus/userland/pkg/service/client"
+	"github.com/AdhityaRamadhanus/userland/pkg/storage/memory"
+	"github.com/AdhityaRamadhanus/userland/pkg/storage/postgres"
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq"
+	"github.com/stretchr/testify/suite"
+)
+
+type ClientServiceTestSuite struct {
+	suite.Suite
+	Config           *config.Configuration
+	DB               *sqlx.DB
+	ClientRepository userland.ClientRepository
+	ClientService    client.Service
+}
+
+func NewClientServiceTestSuite(cfg *config.Configuration) *ClientServiceTestSuite {
+	return &ClientServiceTestSuite{
+		Config: cfg,
+	}
+}
+
+func (suite *ClientServiceTestSuite) Teardown() {
+	suite.T().Log("Teardown ClientServiceTestSuite")
+	suite.DB.Close()
+}
+
+// before each test
+func (suite *ClientServiceTestSuite) SetupSuite() {
+	suite.T().Log("Connecting to postgres at", suite.Config.Postgres)
+	pgConn, err := postgres.CreateConnection(suite.Config.Postgres)
+	if err != nil {
+		suite.T().Fatalf("postgres.CreateConnection() err = %v", err)
+	}
+
+	suite.DB = pgConn
+	suite.ClientRepository = postgres.NewClientRepository(pgConn)
+	suite.ClientService = client.NewService(
+		client.WithConfiguration(suite.Config),
+		client.WithClientRepository(suite.ClientRepository),
+	)
+	suite.ClientService = client.NewInstrumentorService(metrics.PrometheusRequestLatency("service", "client", client.MetricKeys), suite.ClientService)
+}
+
+func (suite ClientServiceTestSuite) SetupTest() {
+	query := "DELETE FROM clients"
+	if _, err := suite.DB.Query(query); err != nil {
+		suite.T().Fatalf("DB.Query(%q) err = %v; want nil", query, err)
+	}
+}
+
+func (suite ClientServiceTestSuite) TestRegisterClient() {
+	type args struct {
+		client userland.Client
+	}
+	testCases := []struct {
+		name       string
+		args       args
+		wantSecret bool
+		wantErr    error
+	}{
+		{
+			name: "public web client",
+			args: args{
+				client: userland.Client{Name: "web", Type: userland.ClientTypeWeb, Public: true},
+			},
+			wantSecret: false,
+			wantErr:    nil,
+		},
+		{
+			name: "confidential server client",
+			args: args{
+				client: userland.Client{Name: "backend", Type: userland.ClientTypeServer},
+			},
+			wantSecret: true,
+			wantErr:    nil,
+		},
+		{
+			name: "public server client",
+			args: args{
+				client: userland.Client{Name: "backend", Type: userland.ClientTypeServer, Public: true},
+			},
+			wantErr: client.ErrPublicServerClient,
+		},
+		{
+			name: "unknown type",
+			args: args{
+				client: userland.Client{Name: "tv", Type: "tv"},
+			},
+			wantErr: client.ErrInvalidClientType,
+		},
+	}
+
+	for _, tc := range testCases {
+		suite.T().Run(tc.name, func(t *testing.T) {
+			registeredClient, credential, secret, err := suite.ClientService.RegisterClient(context.Background(), tc.args.client)
+			if err != tc.wantErr {
+				t.Fatalf("ClientService.RegisterClient() err = %v; want %v", err, tc.wantErr)
+			}
+			if tc.wantErr != nil {
+				return
+			}
+			if registeredClient.Status != userland.ClientStatusActive {
+				t.Errorf("ClientService.RegisterClient() Status = %q; want %q", registeredClient.Status, userland.ClientStatusActive)
+			}
+			if credential == "" {
+				t.Errorf("ClientService.RegisterClient() credential is empty")
+			}
+			if gotSecret := secret != ""; gotSecret != tc.wantSecret {
+				t.Errorf("ClientService.RegisterClient() return secret = %t; want %t", gotSecret, tc.wantSecret)
+			}
+		})
+	}
+}
+
+func (suite ClientServiceTestSuite) TestVerifyClient() {
+	webClient, webCredential, _, err := suite.ClientService.RegisterClient(context.Background(), userland.Client{
+		Name:           "web",
+		Type:           userland.ClientTypeWeb,
+		Public:         true,
+		AllowedOrigins: []string{"https://userland.io"},
+	})
+	if err != nil {
+		suite.T().Fatalf("ClientService.RegisterClient() err = %v; want nil", err)
+	}
+	serverClient, serverCredential, serverSecret, err := suite.ClientService.RegisterClient(context.Background(), userland.Client{
+		Name: "backend",
+		Type: userland.ClientTypeServer,
+	})
+	if err != nil {
+		suite.T().Fatalf("ClientService.RegisterClient() err = %v; want nil", err)
+	}
+	disabledClient, disabledCredential, _, err := suite.ClientService.RegisterClient(context.Background(), userland.Client{
+		Name:   "legacy",
+		Type:   userland.ClientTypeMobile,
+		Public: true,
+	})
+	if err != nil {
+		suite.T().Fatalf("ClientService.RegisterClient() err = %v; want nil", err)
+	}
+	disabledClient.Status = userland.ClientStatusDisabled
+	if _, err := suite.ClientService.UpdateClient(context.Background(), disabledClient); err != nil {
+		suite.T().Fatalf("ClientService.UpdateClient() err = %v; want nil", err)
+	}
+
+	type args struct {
+		credential string
+		secret     string
+		origin     string
+	}
+	testCases := []struct {
+		name         string
+		args         args
+		wantClientID int
+		wantErr      error
+	}{
+		{
+			name: "public client from allowed origin",
+			args: args{
+				credential: webCredential,
+				origin:     "https://userland.io",
+			},
+			wantClientID: webClient.ID,
+			wantErr:      nil,
+		},
+		{
+			name: "public client without origin",
+			args: args{
+				credential: webCredential,
+			},
+			wantClientID: webClient.ID,
+			wantErr:      nil,
+		},
+		{
+			name: "public client from other origin",
+			args: args{
+				credential: webCredential,
+				origin:     "https://evil.io",
+			},
+			wantErr: client.ErrClientOriginNotAllowed,
+		},
+		{
+			name: "confidential client with secret",
+			args: args{
+				credential: serverCredential,
+				secret:     serverSecret,
+			},
+			wantClientID: serverClient.ID,
+			wantErr:      nil,
+		},
+		{
+			name: "confidential client without secret",
+			args: args{
+				credential: serverCredential,
+			},
+			wantErr: client.ErrWrongClientSecret,
+		},
+		{
+			name: "disabled client",
+			args: args{
+				credential: disabledCredential,
+			},
+			wantErr: client.ErrClientDisabled,
+		},
+		{
+			name: "forged credential",
+			args: args{
+				credential: "1.forged",
+			},
+			wantErr: client.ErrInvalidClient,
+		},
+	}
+
+	for _, tc := range testCases {
+		suite.T().Run(tc.name, func(t *testing.T) {
+			verifiedClient, err := suite.ClientService.VerifyClient(context.Background(), tc.args.credential, tc.args.secret, tc.args.origin)
+			if err != tc.wantErr {
+				t.Fatalf("ClientService.VerifyClient() err = %v; want %v", err, tc.wantErr)
+			}
+			if verifiedClient.ID != tc.wantClientID {
+				t.Errorf("ClientService.VerifyClient() ID = %d; want %d", verifiedClient.ID, tc.wantClientID)
+			}
+		})
+	}
+}
+
+func (suite ClientServiceTestSuite) TestVerifyClient_cached() {
+	clientService := client.NewService(
+		client.WithConfiguration(suite.Config),
+		client.WithClientRepository(suite.ClientRepository),
+		client.WithKeyValueService(memory.NewKeyValueService()),
+	)
+	registeredClient, credential, _, err := clientService.RegisterClient(context.Background(), userland.Client{Name: "web", Type: userland.ClientTypeWeb, Public: true})
+	if err != nil {
+		suite.T().Fatalf("ClientService.RegisterClient() err = %v; want nil", err)
+	}
+	if _, err := clientService.VerifyClient(context.Background(), credential, "", ""); err != nil {
+		suite.T().Fatalf("ClientService.VerifyClient() err = %v; want nil", err)
+	}
+
+	// a verified client is served from the cache
+	query := "DELETE FROM clients WHERE id = $1"
+	if _, err := suite.DB.Exec(query, registeredClient.ID); err != nil {
+		suite.T().Fatalf("DB.Exec(%q) err = %v; want nil", query, err)
+	}
+	if _, err := clientService.VerifyClient(context.Background(), credential, "", ""); err != nil {
+		suite.T().Errorf("ClientService.VerifyClient() on cached client err = %v; want nil", err)
+	}
+}
+
+func (suite ClientServiceTestSuite) TestVerifyClient_signingKey() {
+	registeredClient, credential, _, err := suite.ClientService.RegisterClient(context.Background(), userland.Client{Name: "web", Type: userland.ClientTypeWeb, Public: true})
+	if err != nil {
+		suite.T().Fatalf("ClientService.RegisterClient() err = %v; want nil", err)
+	}
+
+	// the access token secret doesn't sign client credentials
+	jwtSignedCredential := security.SignClientCredential(registeredClient.ID, suite.Config.JWTSecret)
+	if _, err := suite.ClientService.VerifyClient(context.Background(), jwtSignedCredential, "", ""); err != client.ErrInvalidClient {
+		suite.T().Errorf("ClientService.VerifyClient() with jwt signed credential err = %v; want %v", err, client.ErrInvalidClient)
+	}
+
+	cfg := *suite.Config
+	cfg.Client.SigningKey = ""
+	clientService := client.NewService(
+		client.WithConfiguration(&cfg),
+		client.WithClientRepository(suite.ClientRepository),
+	)
+	if _, _, _, err := clientService.RegisterClient(context.Background(), userland.Client{Name: "web", Type: userland.ClientTypeWeb, Public: true}); err != client.ErrNoClientSigningKey {
+		suite.T().Errorf("ClientService.RegisterClient() without signing key err = %v; want %v", err, client.ErrNoClientSigningKey)
+	}
+	if _, err := clientService.VerifyClient(context.Background(), credential, "", ""); err != client.ErrInvalidClient {
+		suite.T().Errorf("ClientService.VerifyClient() without signing key err = %v; want %v", err, client.ErrInvalidClient)
+	}
+}
+
+func (suite ClientServiceTestSuite) TestUpdateClient_uncached() {
+	clientService := client.NewService(
+		client.WithConfiguration(suite.Config),
+		client.WithClientRepository(suite.ClientRepository),
+		client.WithKeyValueService(memory.NewKeyValueService()),
+	)
+	registeredClient, credential, _, err := clientService.RegisterClient(context.Background(), userland.Client{Name: "web", Type: userland.ClientTypeWeb, Public: true})
+	if err != nil {
+		suite.T().Fatalf("ClientService.RegisterClient() err = %v; want nil", err)
+	}
+	if _, err := clientService.VerifyClient(context.Background(), credential, "", ""); err != nil {
+		suite.T().Fatalf("ClientService.VerifyClient() err = %v; want nil", err)
+	}
+
+	registeredClient.Status = userland.ClientStatusDisabled
+	if _, err := clientService.UpdateClient(context.Background(), registeredClient); err != nil {
+		suite.T().Fatalf("ClientService.UpdateClient() err = %v; want nil", err)
+	}
+	// disabling take effect right away
+	if _, err := clientService.VerifyClient(context.Background(), credential, "", ""); err != client.ErrClientDisabled {
+		suite.T().Errorf("ClientService.VerifyClient() err = %v; want %v", err, client.ErrClientDisabled)
+	}
+}
+
+func (suite ClientServiceTestSuite) TestUpdateClient() {
+	registeredClient, _, _, err := suite.ClientService.RegisterClient(context.Background(), userland.Client{Name: "web", Type: userland.ClientTypeWeb, Public: true})
+	if err != nil {
+		suite.T().Fatalf("ClientService.RegisterClient() err = %v; want nil", err)
+	}
+
+	type args struct {
+		client userland.Client
+	}
+	testCases := []struct {
+		name    string
+		args    args
+		wantErr error
+	}{
+		{
+			name: "updated",
+			args: args{
+				client: userland.Client{ID: registeredClient.ID, Name: "web app", Type: userland.ClientTypeServer, Status: userland.ClientStatusActive},
+			},
+			wantErr: nil,
+		},
+		{
+			name: "invalid status",
+			args: args{
+				client: userland.Client{ID: registeredClient.ID, Name: "web app", Status: "deleted"},
+			},
+			wantErr: client.ErrInvalidClientStatus,
+		},
+		{
+			name: "not found",
+			args: args{
+				client: userland.Client{ID: registeredClient.ID + 1, Name: "web app", Status: userland.ClientStatusActive},
+			},
+			wantErr: userland.ErrClientNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		suite.T().Run(tc.name, func(t *testing.T) {
+			updatedClient, err := suite.ClientService.UpdateClient(context.Background(), tc.args.client)
+			if err != tc.wantErr {
+				t.Fatalf("ClientService.UpdateClient() err = %v; want %v", err, tc.wantErr)
+			}
+			// type is fixed at registration
+			if tc.wantErr == nil && updatedClient.Type != userland.ClientTypeWeb {
+				t.Errorf("ClientService.UpdateClient() Type = %q; want %q", updatedClient.Type, userland.ClientTypeWeb)
+			}
+		})
+	}
+}
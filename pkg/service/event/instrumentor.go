@@ -1,6 +1,7 @@
 package event
 
 import (
+	"context"
 	"time"
 
 	"github.com/AdhityaRamadhanus/userland"
@@ -27,26 +28,34 @@ func NewInstrumentorService(latency metrics.Histogram, s Service) Service {
 	return service
 }
 
-func (s *instrumentorService) Log(eventName string, userID int, clientInfo map[string]interface{}) error {
+func (s *instrumentorService) Log(ctx context.Context, eventName string, userID int, clientInfo map[string]interface{}) error {
 	defer func(begin time.Time) {
 		s.requestLatency.With("method", "Log").Observe(time.Since(begin).Seconds())
 	}(time.Now())
 
-	return s.next.Log(eventName, userID, clientInfo)
+	return s.next.Log(ctx, eventName, userID, clientInfo)
 }
 
-func (s *instrumentorService) ListEvents(filter userland.EventFilterOptions, paging userland.EventPagingOptions) (events userland.Events, count int, err error) {
+func (s *instrumentorService) LogChanges(ctx context.Context, eventName string, userID int, clientInfo map[string]interface{}, changedFields []string) error {
+	defer func(begin time.Time) {
+		s.requestLatency.With("method", "LogChanges").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return s.next.LogChanges(ctx, eventName, userID, clientInfo, changedFields)
+}
+
+func (s *instrumentorService) ListEvents(ctx context.Context, filter userland.EventFilterOptions, paging userland.EventPagingOptions) (events userland.Events, count int, err error) {
 	defer func(begin time.Time) {
 		s.requestLatency.With("method", "ListEvents").Observe(time.Since(begin).Seconds())
 	}(time.Now())
 
-	return s.next.ListEvents(filter, paging)
+	return s.next.ListEvents(ctx, filter, paging)
 }
 
-func (s *instrumentorService) DeleteEventsByUserID(userID int) error {
+func (s *instrumentorService) DeleteEventsByUserID(ctx context.Context, userID int) error {
 	defer func(begin time.Time) {
 		s.requestLatency.With("method", "DeleteEventsByUserID").Observe(time.Since(begin).Seconds())
 	}(time.Now())
 
-	return s.next.DeleteEventsByUserID(userID)
+	return s.next.DeleteEventsByUserID(ctx, userID)
 }
@@ -1,6 +1,7 @@
 package event
 
 import (
+	"context"
 	"time"
 
 	"github.com/AdhityaRamadhanus/userland"
@@ -13,9 +14,11 @@ var (
 
 //Service provide an interface to story domain service
 type Service interface {
-	Log(eventName string, userID int, clientInfo map[string]interface{}) error
-	ListEvents(filter userland.EventFilterOptions, paging userland.EventPagingOptions) (events userland.Events, count int, err error)
-	DeleteEventsByUserID(userID int) error
+	Log(ctx context.Context, eventName string, userID int, clientInfo map[string]interface{}) error
+	// LogChanges log an event that changed user data, only the changed field names are recorded
+	LogChanges(ctx context.Context, eventName string, userID int, clientInfo map[string]interface{}, changedFields []string) error
+	ListEvents(ctx context.Context, filter userland.EventFilterOptions, paging userland.EventPagingOptions) (events userland.Events, count int, err error)
+	DeleteEventsByUserID(ctx context.Context, userID int) error
 }
 
 func WithEventRepository(eventRepository userland.EventRepository) func(service *service) {
@@ -24,6 +27,12 @@ func WithEventRepository(eventRepository userland.EventRepository) func(service
 	}
 }
 
+func WithGeolocationService(geolocationService userland.GeolocationService) func(service *service) {
+	return func(service *service) {
+		service.geolocationService = geolocationService
+	}
+}
+
 func NewService(options ...func(*service)) Service {
 	service := &service{}
 	for _, option := range options {
@@ -34,10 +43,15 @@ func NewService(options ...func(*service)) Service {
 }
 
 type service struct {
-	eventRepository userland.EventRepository
+	eventRepository    userland.EventRepository
+	geolocationService userland.GeolocationService
 }
 
-func (s service) Log(eventName string, userID int, clientInfo map[string]interface{}) (err error) {
+func (s service) Log(ctx context.Context, eventName string, userID int, clientInfo map[string]interface{}) error {
+	return s.LogChanges(ctx, eventName, userID, clientInfo, nil)
+}
+
+func (s service) LogChanges(ctx context.Context, eventName string, userID int, clientInfo map[string]interface{}, changedFields []string) (err error) {
 	defer func() {
 		if panicErr := recover(); panicErr != nil {
 			err = errors.Wrapf(ErrInvalidEvent, "Error in inserting event %s", panicErr)
@@ -46,25 +60,34 @@ func (s service) Log(eventName string, userID int, clientInfo map[string]interfa
 
 	// may panic on assertion
 	event := userland.Event{
-		UserAgent:  clientInfo["user_agent"].(string),
-		UserID:     userID,
-		Event:      eventName,
-		ClientID:   clientInfo["client_id"].(int),
-		ClientName: clientInfo["client_name"].(string),
-		IP:         clientInfo["ip"].(string),
-		Timestamp:  time.Now(),
+		UserAgent:     clientInfo["user_agent"].(string),
+		UserID:        userID,
+		Event:         eventName,
+		ClientID:      clientInfo["client_id"].(int),
+		ClientName:    clientInfo["client_name"].(string),
+		IP:            clientInfo["ip"].(string),
+		ChangedFields: changedFields,
+		Timestamp:     time.Now(),
+	}
+	// event is still logged when ip can't be located
+	if s.geolocationService != nil {
+		if geolocation, err := s.geolocationService.Lookup(event.IP); err == nil {
+			event.Country = geolocation.Country
+			event.City = geolocation.City
+			event.ASN = geolocation.ASN
+		}
 	}
-	if err := s.eventRepository.Insert(event); err != nil {
+	if err := s.eventRepository.Insert(ctx, event); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func (s service) ListEvents(filter userland.EventFilterOptions, paging userland.EventPagingOptions) (events userland.Events, count int, err error) {
-	return s.eventRepository.FindAll(filter, paging)
+func (s service) ListEvents(ctx context.Context, filter userland.EventFilterOptions, paging userland.EventPagingOptions) (events userland.Events, count int, err error) {
+	return s.eventRepository.FindAll(ctx, filter, paging)
 }
 
-func (s service) DeleteEventsByUserID(userID int) error {
-	return s.eventRepository.DeleteAllByUserID(userID)
+func (s service) DeleteEventsByUserID(ctx context.Context, userID int) error {
+	return s.eventRepository.DeleteAllByUserID(ctx, userID)
 }
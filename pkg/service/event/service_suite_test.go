@@ -3,11 +3,13 @@
 package event_test
 
 import (
+	"context"
 	"testing"
 
 	"github.com/AdhityaRamadhanus/userland"
 	"github.com/AdhityaRamadhanus/userland/pkg/common/metrics"
 	"github.com/AdhityaRamadhanus/userland/pkg/config"
+	"github.com/AdhityaRamadhanus/userland/pkg/mocks/repository"
 	"github.com/AdhityaRamadhanus/userland/pkg/service/event"
 	"github.com/AdhityaRamadhanus/userland/pkg/storage/postgres"
 	"github.com/jmoiron/sqlx"
@@ -105,9 +107,39 @@ func (suite EventServiceTestSuite) TestLog() {
 
 	for _, tc := range testCases {
 		suite.T().Run(tc.name, func(t *testing.T) {
-			if err := suite.EventService.Log(tc.args.name, tc.args.userID, tc.args.info); errors.Cause(err) != tc.wantErrCause {
+			if err := suite.EventService.Log(context.Background(), tc.args.name, tc.args.userID, tc.args.info); errors.Cause(err) != tc.wantErrCause {
 				t.Fatalf("EventService.Log(%q, %d, <info>) err = %v; want nil", tc.args.name, tc.args.userID, err)
 			}
 		})
 	}
 }
+
+func (suite EventServiceTestSuite) TestLog_withGeolocation() {
+	geolocation := userland.Geolocation{Country: "ID", City: "Jakarta", ASN: 7713}
+	eventService := event.NewService(
+		event.WithEventRepository(suite.EventRepository),
+		event.WithGeolocationService(repository.GeolocationService{DefaultGeolocation: geolocation}),
+	)
+
+	userID := 1
+	clientInfo := map[string]interface{}{
+		"client_id":   1,
+		"user_agent":  "test",
+		"client_name": "test",
+		"ip":          "36.72.10.1",
+	}
+	if err := eventService.Log(context.Background(), "something.something", userID, clientInfo); err != nil {
+		suite.T().Fatalf("EventService.Log() err = %v; want nil", err)
+	}
+
+	events, _, err := eventService.ListEvents(context.Background(), userland.EventFilterOptions{UserID: userID}, userland.EventPagingOptions{Limit: 10, SortBy: "timestamp", Order: "desc"})
+	if err != nil {
+		suite.T().Fatalf("EventService.ListEvents() err = %v; want nil", err)
+	}
+	if len(events) != 1 {
+		suite.T().Fatalf("EventService.ListEvents() len(events) = %d; want 1", len(events))
+	}
+	if events[0].Country != geolocation.Country || events[0].City != geolocation.City || events[0].ASN != geolocation.ASN {
+		suite.T().Errorf("EventService.ListEvents() event location = %s, %s, %d; want %+v", events[0].Country, events[0].City, events[0].ASN, geolocation)
+	}
+}
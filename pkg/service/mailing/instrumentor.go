@@ -41,3 +41,11 @@ func (s instrumentorService) SendVerificationEmail(recipient MailAddress, verifi
 
 	return s.next.SendVerificationEmail(recipient, verificationLink)
 }
+
+func (s instrumentorService) SendNoticeEmail(recipient MailAddress, subject string, message string, actionLink string) error {
+	defer func(begin time.Time) {
+		s.requestLatency.With("method", "SendNoticeEmail").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return s.next.SendNoticeEmail(recipient, subject, message, actionLink)
+}
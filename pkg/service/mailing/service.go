@@ -30,6 +30,7 @@ type service struct {
 type Service interface {
 	SendOTPEmail(recipient MailAddress, otpType string, otp string) error
 	SendVerificationEmail(recipient MailAddress, verificationLink string) error
+	SendNoticeEmail(recipient MailAddress, subject string, message string, actionLink string) error
 }
 
 func NewService(queueName, emailSender string, enqueuer *work.Enqueuer) Service {
@@ -111,3 +112,40 @@ func (s service) SendVerificationEmail(recipient MailAddress, verificationLink s
 
 	return nil
 }
+
+//SendNoticeEmail send account notice, action link is optional
+func (s service) SendNoticeEmail(recipient MailAddress, subject string, message string, actionLink string) (err error) {
+	opts := SendEmailOption{
+		From: MailAddress{
+			Name:    "Notice from Userland",
+			Address: s.emailSender,
+		},
+		To: []MailAddress{
+			{
+				Name:    recipient.Name,
+				Address: recipient.Address,
+			},
+		},
+		Subject:  subject,
+		Template: "notice",
+		TemplateArgs: map[string]interface{}{
+			"message":     message,
+			"action_link": actionLink,
+			"recipient":   recipient.Name,
+		},
+	}
+
+	// convert struct to json
+	work := work.Q{}
+	jsonBytes, err := json.Marshal(opts)
+	if err != nil {
+		return errors.Wrap(err, "json.Marshal() err")
+	}
+	json.Unmarshal(jsonBytes, &work)
+
+	if _, err := s.producer.Enqueue(s.queueName, work); err != nil {
+		return errors.Wrap(err, "producer.Enqueue() err")
+	}
+
+	return nil
+}
@@ -122,3 +122,39 @@ func (suite MailingServiceTestSuite) TestSendVerificationEmail() {
 		})
 	}
 }
+
+func (suite MailingServiceTestSuite) TestSendNoticeEmail() {
+	type args struct {
+		recipient  mailing.MailAddress
+		subject    string
+		message    string
+		actionLink string
+	}
+	testCases := []struct {
+		name    string
+		args    args
+		wantErr error
+	}{
+		{
+			name: "success",
+			args: args{
+				recipient: mailing.MailAddress{
+					Name:    "test",
+					Address: "test@coba.com",
+				},
+				subject:    "Running low on backup codes",
+				message:    "You have 2 backup codes left",
+				actionLink: "",
+			},
+			wantErr: nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		suite.T().Run(tc.name, func(t *testing.T) {
+			if err := suite.MailingService.SendNoticeEmail(tc.args.recipient, tc.args.subject, tc.args.message, tc.args.actionLink); err != tc.wantErr {
+				t.Fatalf("MailingService.SendNoticeEmail() err = %v; want %v", err, tc.wantErr)
+			}
+		})
+	}
+}
@@ -48,3 +48,27 @@ func EmailVerificationTemplate(args map[string]interface{}) (string, error) {
 	}
 	return tpl.String(), nil
 }
+
+func NoticeTemplate(args map[string]interface{}) (string, error) {
+	var tpl bytes.Buffer
+	tmpl, err := template.ParseFiles("templates/mailing/notice.html")
+	if err != nil {
+		return "", err
+	}
+
+	noticeTemplateArgs := struct {
+		Recipient  string `json:"recipient"`
+		Message    string `json:"message"`
+		ActionLink string `json:"action_link"`
+	}{
+		Recipient: args["recipient"].(string),
+		Message:   args["message"].(string),
+	}
+	if actionLink, ok := args["action_link"].(string); ok {
+		noticeTemplateArgs.ActionLink = actionLink
+	}
+	if err = tmpl.Execute(&tpl, noticeTemplateArgs); err != nil {
+		return "", err
+	}
+	return tpl.String(), nil
+}
@@ -17,6 +17,7 @@ var (
 	templateMap = map[string]TemplateGenerator{
 		"otp":                OTPTemplate,
 		"email_verification": EmailVerificationTemplate,
+		"notice":             NoticeTemplate,
 	}
 )
 
@@ -0,0 +1,344 @@
+package profile
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/AdhityaRamadhanus/userland"
+	"github.com/AdhityaRamadhanus/userland/pkg/common/keygenerator"
+	"github.com/AdhityaRamadhanus/userland/pkg/common/security"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	// download link of an export is valid this long when no expiration is configured
+	DefaultDataExportLinkExpiration = time.Hour * 24
+	//DefaultDataExportQueueSize is how many data exports can be pending when no queue size is configured
+	DefaultDataExportQueueSize = 100
+	//DataExportClaimExpiration bound how long a crashed exporter hold a claimed export before another one retry it
+	DataExportClaimExpiration = 10 * time.Minute
+
+	// events are read from EventRepository in pages of this size
+	exportEventPageSize = 100
+)
+
+//RequestExport record a pending data export of user, it is kept in DataExportRepository until an exporter complete it
+func (s service) RequestExport(ctx context.Context, userID int) (err error) {
+	// every export not completed yet count toward the queue, claimed or not
+	pending, err := s.dataExportRepository.FindAllPending(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+	if len(pending) >= s.exportQueueSize() {
+		return ErrExportQueueFull
+	}
+
+	return s.dataExportRepository.Insert(ctx, &userland.DataExport{UserID: userID})
+}
+
+//ExportPendingData claim pending data exports and export them one at a time,
+//a failed export is retried once its claim expire
+func (s service) ExportPendingData(ctx context.Context) (exported int, err error) {
+	dataExports, err := s.dataExportRepository.FindAllPending(ctx, time.Now().Add(-DataExportClaimExpiration))
+	if err != nil {
+		return 0, err
+	}
+
+	for _, dataExport := range dataExports {
+		if err := ctx.Err(); err != nil {
+			return exported, err
+		}
+
+		err := s.dataExportRepository.Claim(ctx, dataExport.ID, time.Now().Add(-DataExportClaimExpiration))
+		// another exporter got there first
+		if err == userland.ErrDataExportNotFound {
+			continue
+		}
+		if err != nil {
+			return exported, errors.Wrapf(err, "claim data export %d err", dataExport.ID)
+		}
+
+		err = s.exportData(ctx, dataExport)
+		// the account is purged already, there is nothing left to export
+		if err == userland.ErrUserNotFound {
+			s.dataExportRepository.Delete(ctx, dataExport.ID)
+			continue
+		}
+		if err != nil {
+			log.WithError(err).WithField("user_id", dataExport.UserID).Error("Failed to export user data")
+			continue
+		}
+		exported++
+	}
+	return exported, nil
+}
+
+//PurgeExpiredExports delete archives whose download link expired
+func (s service) PurgeExpiredExports(ctx context.Context) (purged int, err error) {
+	dataExports, err := s.dataExportRepository.FindAllExpired(ctx, time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	for _, dataExport := range dataExports {
+		if err := s.deleteExport(ctx, dataExport); err != nil {
+			return purged, err
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+//exportData assemble everything kept about a user into a zip of json files in object storage,
+//the user is emailed a link to DownloadExport valid for the configured link expiration
+func (s service) exportData(ctx context.Context, dataExport userland.DataExport) error {
+	user, err := s.userRepository.Find(ctx, dataExport.UserID)
+	if err != nil {
+		return err
+	}
+
+	archive, err := s.buildExport(ctx, user)
+	if err != nil {
+		return err
+	}
+
+	exportID := security.GenerateUUID()
+	path := fmt.Sprintf("exports/userland_%d_%s.zip", user.ID, exportID)
+	if _, err := s.objectStorageService.Write(ctx, bytes.NewReader(archive), userland.ObjectMetaData{
+		CacheControl: "private, no-store",
+		ContentType:  "application/zip",
+		Path:         path,
+	}); err != nil {
+		return err
+	}
+
+	// the archive is deleted once expiredAt pass, the record is what let it be found again
+	expiredAt := time.Now().Add(s.exportLinkExpiration())
+	if err := s.dataExportRepository.Complete(ctx, dataExport.ID, path, expiredAt); err != nil {
+		s.objectStorageService.Delete(ctx, path)
+		return err
+	}
+
+	token, err := security.GenerateRandomToken(32)
+	if err != nil {
+		return err
+	}
+	exportKey := keygenerator.DataExportKey(security.HashToken(token))
+	if err := s.keyValueService.SetEx(ctx, exportKey, []byte(path), time.Until(expiredAt)); err != nil {
+		return errors.Wrapf(err, "keyValueService.SetEx(%q, %s, exp) err", exportKey, path)
+	}
+
+	message := fmt.Sprintf(
+		"The export of your data is ready. Use the link below to download it before %s.",
+		expiredAt.UTC().Format(time.RFC1123),
+	)
+	actionLink := fmt.Sprintf("%s?token=%s", s.config.DataExport.DownloadLink, token)
+	if err := s.mailingClient.SendNoticeEmail(user.Email, user.Fullname, "Your data export is ready", message, actionLink); err != nil {
+		log.WithError(err).Error("Error sending email")
+	}
+	return nil
+}
+
+//DownloadExport return the zip a download token from ExportPendingData point to
+func (s service) DownloadExport(ctx context.Context, token string) (archive []byte, err error) {
+	path, err := s.keyValueService.Get(ctx, keygenerator.DataExportKey(security.HashToken(token)))
+	if err != nil {
+		return nil, ErrExportInvalid
+	}
+
+	archive, _, err = s.objectStorageService.Fetch(ctx, string(path))
+	if err != nil {
+		return nil, err
+	}
+	return archive, nil
+}
+
+//deleteExports delete every export of a user, pending ones included
+func (s service) deleteExports(ctx context.Context, userID int) error {
+	dataExports, err := s.dataExportRepository.FindAllByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, dataExport := range dataExports {
+		if err := s.deleteExport(ctx, dataExport); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//deleteExport delete the archive before its record, so a failure is retried by the next purge
+func (s service) deleteExport(ctx context.Context, dataExport userland.DataExport) error {
+	if dataExport.Path != "" {
+		if err := s.objectStorageService.Delete(ctx, dataExport.Path); err != nil {
+			return errors.Wrapf(err, "delete data export %d archive err", dataExport.ID)
+		}
+	}
+	return s.dataExportRepository.Delete(ctx, dataExport.ID)
+}
+
+func (s service) exportQueueSize() int {
+	if s.config != nil && s.config.DataExport.QueueSize > 0 {
+		return s.config.DataExport.QueueSize
+	}
+	return DefaultDataExportQueueSize
+}
+
+func (s service) exportLinkExpiration() time.Duration {
+	if s.config != nil && s.config.DataExport.LinkExpiration > 0 {
+		return s.config.DataExport.LinkExpiration
+	}
+	return DefaultDataExportLinkExpiration
+}
+
+//buildExport write profile.json, events.json, sessions.json and the profile picture if any into a zip,
+//password and backup code hashes are left out, they are credentials rather than personal data
+func (s service) buildExport(ctx context.Context, user userland.User) ([]byte, error) {
+	events, err := s.exportEvents(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	sessions, err := s.sessionRepository.FindAllByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	files := map[string]interface{}{
+		"profile.json":  exportUser(user),
+		"events.json":   events,
+		"sessions.json": exportSessions(sessions),
+	}
+
+	buffer := &bytes.Buffer{}
+	archive := zip.NewWriter(buffer)
+	for _, name := range []string{"profile.json", "events.json", "sessions.json"} {
+		content, err := json.MarshalIndent(files[name], "", "  ")
+		if err != nil {
+			return nil, errors.Wrapf(err, "json.MarshalIndent(%s) err", name)
+		}
+		if err := writeZipFile(archive, name, content); err != nil {
+			return nil, err
+		}
+	}
+
+	if user.PictureURL != "" {
+		picture, _, err := s.objectStorageService.Fetch(ctx, profilePicturePath(user.ID))
+		if err != nil {
+			return nil, errors.Wrap(err, "objectStorageService.Fetch(picture) err")
+		}
+		if err := writeZipFile(archive, "picture.jpeg", picture); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return nil, errors.Wrap(err, "archive.Close() err")
+	}
+	return buffer.Bytes(), nil
+}
+
+func (s service) exportEvents(ctx context.Context, userID int) ([]map[string]interface{}, error) {
+	exported := []map[string]interface{}{}
+	for offset := 0; ; offset += exportEventPageSize {
+		events, count, err := s.eventRepository.FindAll(ctx, userland.EventFilterOptions{UserID: userID}, userland.EventPagingOptions{
+			Limit:  exportEventPageSize,
+			Offset: offset,
+			SortBy: "timestamp",
+			Order:  "asc",
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, event := range events {
+			exported = append(exported, exportEvent(event))
+		}
+		if len(events) == 0 || offset+len(events) >= count {
+			return exported, nil
+		}
+	}
+}
+
+func writeZipFile(archive *zip.Writer, name string, content []byte) error {
+	file, err := archive.Create(name)
+	if err != nil {
+		return errors.Wrapf(err, "archive.Create(%s) err", name)
+	}
+	if _, err := file.Write(content); err != nil {
+		return errors.Wrapf(err, "write %s err", name)
+	}
+	return nil
+}
+
+func exportUser(user userland.User) map[string]interface{} {
+	return map[string]interface{}{
+		"id":                      user.ID,
+		"email":                   user.Email,
+		"fullname":                user.Fullname,
+		"phone":                   user.Phone,
+		"location":                user.Location,
+		"bio":                     user.Bio,
+		"web":                     user.WebURL,
+		"picture":                 user.PictureURL,
+		"verified":                user.Verified,
+		"tfa_enabled":             user.TFAEnabled,
+		"tfa_enabled_at":          user.TFAEnabledAt,
+		"backup_codes_created_at": user.BackupCodesCreatedAt,
+		"created_at":              user.CreatedAt,
+		"updated_at":              user.UpdatedAt,
+	}
+}
+
+func exportEvent(event userland.Event) map[string]interface{} {
+	return map[string]interface{}{
+		"event": event.Event,
+		"ua":    event.UserAgent,
+		"ip":    event.IP,
+		"client": map[string]interface{}{
+			"id":   event.ClientID,
+			"name": event.ClientName,
+		},
+		"location": map[string]interface{}{
+			"country": event.Country,
+			"city":    event.City,
+			"asn":     event.ASN,
+		},
+		"changed_fields": event.ChangedFields,
+		"created_at":     event.Timestamp,
+	}
+}
+
+func exportSessions(sessions userland.Sessions) []map[string]interface{} {
+	exported := []map[string]interface{}{}
+	for _, session := range sessions {
+		exported = append(exported, map[string]interface{}{
+			"session_id": session.ID,
+			"ip":         session.IP,
+			"client": map[string]interface{}{
+				"id":   session.ClientID,
+				"name": session.ClientName,
+			},
+			"user_agent": session.UserAgent,
+			"device": map[string]interface{}{
+				"browser": session.Browser,
+				"os":      session.OS,
+				"type":    session.DeviceType,
+			},
+			"location": map[string]interface{}{
+				"country": session.Country,
+				"city":    session.City,
+				"asn":     session.ASN,
+			},
+			"last_seen_at": session.LastSeenAt,
+			"last_seen_ip": session.LastSeenIP,
+			"expired_at":   session.ExpiredAt,
+			"created_at":   session.CreatedAt,
+		})
+	}
+	return exported
+}
@@ -0,0 +1,85 @@
+package profile
+
+import (
+	"context"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	//DefaultExportInterval is how often the exporter look for pending exports and expired archives
+	DefaultExportInterval = time.Minute
+)
+
+//Exporter run data exports in background and delete their archives once expired,
+//pending exports are kept by DataExportRepository so a restart doesn't lose them
+type Exporter struct {
+	service        Service
+	exportInterval time.Duration
+	wake           chan struct{}
+}
+
+func WithExportInterval(exportInterval time.Duration) func(exporter *Exporter) {
+	return func(exporter *Exporter) {
+		if exportInterval > 0 {
+			exporter.exportInterval = exportInterval
+		}
+	}
+}
+
+func NewExporter(service Service, options ...func(*Exporter)) *Exporter {
+	exporter := &Exporter{
+		service:        service,
+		exportInterval: DefaultExportInterval,
+		wake:           make(chan struct{}, 1),
+	}
+	for _, option := range options {
+		option(exporter)
+	}
+
+	return exporter
+}
+
+//Enqueue record the data export of user and wake the exporter up, it fail with ErrExportQueueFull instead of waiting
+func (e *Exporter) Enqueue(ctx context.Context, userID int) error {
+	if err := e.service.RequestExport(ctx, userID); err != nil {
+		return err
+	}
+
+	select {
+	case e.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+//Run export pending data and purge expired exports every export interval, or as soon as one is enqueued, until ctx is done
+func (e *Exporter) Run(ctx context.Context) error {
+	ticker := time.NewTicker(e.exportInterval)
+	defer ticker.Stop()
+	for {
+		exported, err := e.service.ExportPendingData(ctx)
+		if err != nil {
+			log.WithError(err).Warn("Failed to export pending user data")
+		}
+		if exported > 0 {
+			log.WithField("exported", exported).Info("Exported user data")
+		}
+
+		purged, err := e.service.PurgeExpiredExports(ctx)
+		if err != nil {
+			log.WithError(err).Warn("Failed to purge expired data exports")
+		}
+		if purged > 0 {
+			log.WithField("purged", purged).Info("Purged expired data exports")
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		case <-e.wake:
+		}
+	}
+}
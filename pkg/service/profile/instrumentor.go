@@ -1,6 +1,7 @@
 package profile
 
 import (
+	"context"
 	"io"
 	"time"
 
@@ -28,90 +29,154 @@ func NewInstrumentorService(latency metrics.Histogram, s Service) Service {
 	return service
 }
 
-func (s instrumentorService) ProfileByEmail(email string) (userland.User, error) {
+func (s instrumentorService) ProfileByEmail(ctx context.Context, email string) (userland.User, error) {
 	defer func(begin time.Time) {
 		s.requestLatency.With("method", "ProfileByEmail").Observe(time.Since(begin).Seconds())
 	}(time.Now())
 
-	return s.next.ProfileByEmail(email)
+	return s.next.ProfileByEmail(ctx, email)
 }
 
-func (s instrumentorService) Profile(userID int) (userland.User, error) {
+func (s instrumentorService) Profile(ctx context.Context, userID int) (userland.User, error) {
 	defer func(begin time.Time) {
 		s.requestLatency.With("method", "Profile").Observe(time.Since(begin).Seconds())
 	}(time.Now())
 
-	return s.next.Profile(userID)
+	return s.next.Profile(ctx, userID)
 }
 
-func (s instrumentorService) SetProfile(user userland.User) error {
+func (s instrumentorService) SetProfile(ctx context.Context, user userland.User) error {
 	defer func(begin time.Time) {
 		s.requestLatency.With("method", "SetProfile").Observe(time.Since(begin).Seconds())
 	}(time.Now())
 
-	return s.next.SetProfile(user)
+	return s.next.SetProfile(ctx, user)
 }
 
-func (s instrumentorService) RequestChangeEmail(user userland.User, newEmail string) (verificationID string, err error) {
+func (s instrumentorService) UpdateProfile(ctx context.Context, userID int, version int, patch userland.ProfilePatch) error {
+	defer func(begin time.Time) {
+		s.requestLatency.With("method", "UpdateProfile").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return s.next.UpdateProfile(ctx, userID, version, patch)
+}
+
+func (s instrumentorService) RequestChangeEmail(ctx context.Context, user userland.User, newEmail string) (verificationID string, err error) {
 	defer func(begin time.Time) {
 		s.requestLatency.With("method", "RequestChangeEmail").Observe(time.Since(begin).Seconds())
 	}(time.Now())
 
-	return s.next.RequestChangeEmail(user, newEmail)
+	return s.next.RequestChangeEmail(ctx, user, newEmail)
 }
 
-func (s instrumentorService) ChangeEmail(user userland.User, verificationID string) error {
+func (s instrumentorService) ChangeEmail(ctx context.Context, user userland.User, verificationID string) error {
 	defer func(begin time.Time) {
 		s.requestLatency.With("method", "ChangeEmail").Observe(time.Since(begin).Seconds())
 	}(time.Now())
 
-	return s.next.ChangeEmail(user, verificationID)
+	return s.next.ChangeEmail(ctx, user, verificationID)
 }
 
-func (s instrumentorService) ChangePassword(user userland.User, oldPassword string, newPassword string) error {
+func (s instrumentorService) ChangePassword(ctx context.Context, user userland.User, oldPassword string, newPassword string) error {
 	defer func(begin time.Time) {
 		s.requestLatency.With("method", "ChangePassword").Observe(time.Since(begin).Seconds())
 	}(time.Now())
 
-	return s.next.ChangePassword(user, oldPassword, newPassword)
+	return s.next.ChangePassword(ctx, user, oldPassword, newPassword)
 }
 
-func (s instrumentorService) EnrollTFA(user userland.User) (secret string, qrcodeImageBase64 string, err error) {
+func (s instrumentorService) EnrollTFA(ctx context.Context, user userland.User) (secret string, qrcodeImageBase64 string, err error) {
 	defer func(begin time.Time) {
 		s.requestLatency.With("method", "EnrollTFA").Observe(time.Since(begin).Seconds())
 	}(time.Now())
 
-	return s.next.EnrollTFA(user)
+	return s.next.EnrollTFA(ctx, user)
 }
 
-func (s instrumentorService) ActivateTFA(user userland.User, secret string, code string) ([]string, error) {
+func (s instrumentorService) ActivateTFA(ctx context.Context, user userland.User, secret string, code string) ([]string, error) {
 	defer func(begin time.Time) {
 		s.requestLatency.With("method", "ActivateTFA").Observe(time.Since(begin).Seconds())
 	}(time.Now())
 
-	return s.next.ActivateTFA(user, secret, code)
+	return s.next.ActivateTFA(ctx, user, secret, code)
 }
 
-func (s instrumentorService) RemoveTFA(user userland.User, currPassword string) error {
+func (s instrumentorService) RemoveTFA(ctx context.Context, user userland.User, currPassword string) error {
 	defer func(begin time.Time) {
 		s.requestLatency.With("method", "RemoveTFA").Observe(time.Since(begin).Seconds())
 	}(time.Now())
 
-	return s.next.RemoveTFA(user, currPassword)
+	return s.next.RemoveTFA(ctx, user, currPassword)
 }
 
-func (s instrumentorService) DeleteAccount(user userland.User, currPassword string) error {
+func (s instrumentorService) DeleteAccount(ctx context.Context, user userland.User, currPassword string) error {
 	defer func(begin time.Time) {
 		s.requestLatency.With("method", "DeleteAccount").Observe(time.Since(begin).Seconds())
 	}(time.Now())
 
-	return s.next.DeleteAccount(user, currPassword)
+	return s.next.DeleteAccount(ctx, user, currPassword)
+}
+
+func (s instrumentorService) RestoreAccount(ctx context.Context, token string) (int, error) {
+	defer func(begin time.Time) {
+		s.requestLatency.With("method", "RestoreAccount").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return s.next.RestoreAccount(ctx, token)
+}
+
+func (s instrumentorService) PurgeDeletedAccounts(ctx context.Context) (int, error) {
+	defer func(begin time.Time) {
+		s.requestLatency.With("method", "PurgeDeletedAccounts").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return s.next.PurgeDeletedAccounts(ctx)
+}
+
+func (s instrumentorService) RequestExport(ctx context.Context, userID int) error {
+	defer func(begin time.Time) {
+		s.requestLatency.With("method", "RequestExport").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return s.next.RequestExport(ctx, userID)
 }
 
-func (s instrumentorService) SetProfilePicture(user userland.User, image io.Reader) error {
+func (s instrumentorService) ExportPendingData(ctx context.Context) (int, error) {
+	defer func(begin time.Time) {
+		s.requestLatency.With("method", "ExportPendingData").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return s.next.ExportPendingData(ctx)
+}
+
+func (s instrumentorService) PurgeExpiredExports(ctx context.Context) (int, error) {
+	defer func(begin time.Time) {
+		s.requestLatency.With("method", "PurgeExpiredExports").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return s.next.PurgeExpiredExports(ctx)
+}
+
+func (s instrumentorService) DownloadExport(ctx context.Context, token string) ([]byte, error) {
+	defer func(begin time.Time) {
+		s.requestLatency.With("method", "DownloadExport").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return s.next.DownloadExport(ctx, token)
+}
+
+func (s instrumentorService) SetProfilePicture(ctx context.Context, user userland.User, image io.Reader) error {
 	defer func(begin time.Time) {
 		s.requestLatency.With("method", "SetProfilePicture").Observe(time.Since(begin).Seconds())
 	}(time.Now())
 
-	return s.next.SetProfilePicture(user, image)
+	return s.next.SetProfilePicture(ctx, user, image)
+}
+
+func (s instrumentorService) RegenerateBackupCodes(ctx context.Context, user userland.User) ([]string, error) {
+	defer func(begin time.Time) {
+		s.requestLatency.With("method", "RegenerateBackupCodes").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return s.next.RegenerateBackupCodes(ctx, user)
 }
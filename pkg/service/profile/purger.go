@@ -0,0 +1,60 @@
+package profile
+
+import (
+	"context"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	//DefaultPurgeInterval is how often the purger look for accounts whose grace period is over
+	DefaultPurgeInterval = time.Hour
+)
+
+//Purger remove accounts pending deletion in background once their grace period is over
+type Purger struct {
+	service       Service
+	purgeInterval time.Duration
+}
+
+func WithPurgeInterval(purgeInterval time.Duration) func(purger *Purger) {
+	return func(purger *Purger) {
+		if purgeInterval > 0 {
+			purger.purgeInterval = purgeInterval
+		}
+	}
+}
+
+func NewPurger(service Service, options ...func(*Purger)) *Purger {
+	purger := &Purger{
+		service:       service,
+		purgeInterval: DefaultPurgeInterval,
+	}
+	for _, option := range options {
+		option(purger)
+	}
+
+	return purger
+}
+
+//Run purge deleted accounts every purge interval until ctx is done
+func (p *Purger) Run(ctx context.Context) error {
+	ticker := time.NewTicker(p.purgeInterval)
+	defer ticker.Stop()
+	for {
+		purged, err := p.service.PurgeDeletedAccounts(ctx)
+		if err != nil {
+			log.WithError(err).Warn("Failed to purge deleted accounts")
+		}
+		if purged > 0 {
+			log.WithField("purged", purged).Info("Purged deleted accounts")
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -1,31 +1,50 @@
 package profile
 
 import (
+	"context"
 	"encoding/base64"
 	"fmt"
 	"io"
+	"strconv"
 	"time"
 
 	"github.com/AdhityaRamadhanus/userland"
 	mailing "github.com/AdhityaRamadhanus/userland/pkg/common/http/clients/mailing"
 	"github.com/AdhityaRamadhanus/userland/pkg/common/keygenerator"
+	"github.com/AdhityaRamadhanus/userland/pkg/common/optimistic"
 	"github.com/AdhityaRamadhanus/userland/pkg/common/security"
+	"github.com/AdhityaRamadhanus/userland/pkg/config"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 	qrcode "github.com/skip2/go-qrcode"
 )
 
 var (
-	EventChangeEmailRequest = "user.profile.change_email_request"
-	EventChangeEmail        = "user.profile.change_email"
-	EventChangePassword     = "user.profile.change_password"
-	EventEnableTFA          = "user.profile.enable_tfa"
-	EventDisableTFA         = "user.profile.disable_tfa"
+	EventUpdateProfile         = "user.profile.update"
+	EventChangeEmailRequest    = "user.profile.change_email_request"
+	EventChangeEmail           = "user.profile.change_email"
+	EventChangePassword        = "user.profile.change_password"
+	EventEnableTFA             = "user.profile.enable_tfa"
+	EventDisableTFA            = "user.profile.disable_tfa"
+	EventRegenerateBackupCodes = "user.profile.regenerate_backup_codes"
+	EventDeleteAccount         = "user.profile.delete_account"
+	EventRestoreAccount        = "user.profile.restore_account"
+	EventRequestExport         = "user.profile.request_export"
+
+	DefaultBackupCodeCount  = 5
+	DefaultBackupCodeLength = 6
+
+	// account pending deletion can be restored this long when no grace period is configured
+	DefaultAccountDeletionGracePeriod = time.Hour * 24 * 14
 
 	ErrEmailAlreadyUsed  = errors.New("Email is already used")
 	ErrWrongPassword     = errors.New("Wrong password")
 	ErrTFAAlreadyEnabled = errors.New("TFA already enabled")
 	ErrWrongOTP          = errors.New("Wrong OTP")
+	ErrTFANotEnabled     = errors.New("TFA is not enabled")
+	ErrRestoreInvalid    = errors.New("Account restore link is invalid or expired")
+	ErrExportInvalid     = errors.New("Data export link is invalid or expired")
+	ErrExportQueueFull   = errors.New("Too many data exports in progress, try again later")
 )
 
 func WithMailingClient(mailingClient mailing.Client) func(service *service) {
@@ -34,12 +53,48 @@ func WithMailingClient(mailingClient mailing.Client) func(service *service) {
 	}
 }
 
+func WithConfiguration(cfg *config.Configuration) func(service *service) {
+	return func(service *service) {
+		service.config = cfg
+	}
+}
+
 func WithUserRepository(userRepository userland.UserRepository) func(service *service) {
 	return func(service *service) {
 		service.userRepository = userRepository
 	}
 }
 
+func WithTxManager(txManager userland.TxManager) func(service *service) {
+	return func(service *service) {
+		service.txManager = txManager
+	}
+}
+
+func WithEventRepository(eventRepository userland.EventRepository) func(service *service) {
+	return func(service *service) {
+		service.eventRepository = eventRepository
+	}
+}
+
+func WithSessionRepository(sessionRepository userland.SessionRepository) func(service *service) {
+	return func(service *service) {
+		service.sessionRepository = sessionRepository
+	}
+}
+
+func WithTrustedDeviceRepository(trustedDeviceRepository userland.TrustedDeviceRepository) func(service *service) {
+	return func(service *service) {
+		service.trustedDeviceRepository = trustedDeviceRepository
+	}
+}
+
+func WithDataExportRepository(dataExportRepository userland.DataExportRepository) func(service *service) {
+	return func(service *service) {
+		service.dataExportRepository = dataExportRepository
+	}
+}
+
 func WithKeyValueService(keyValueService userland.KeyValueService) func(service *service) {
 	return func(service *service) {
 		service.keyValueService = keyValueService
@@ -54,17 +109,25 @@ func WithObjectStorageService(objectStorageService userland.ObjectStorageService
 
 //Service provide an interface to story domain service
 type Service interface {
-	ProfileByEmail(email string) (userland.User, error)
-	Profile(userID int) (userland.User, error)
-	SetProfile(user userland.User) error
-	SetProfilePicture(user userland.User, image io.Reader) error
-	RequestChangeEmail(user userland.User, newEmail string) (verificationID string, err error)
-	ChangeEmail(user userland.User, verificationID string) error
-	ChangePassword(user userland.User, oldPassword, newPassword string) error
-	EnrollTFA(user userland.User) (secret string, qrcodeImageBase64 string, err error)
-	ActivateTFA(user userland.User, secret string, code string) ([]string, error)
-	RemoveTFA(user userland.User, currPassword string) error
-	DeleteAccount(user userland.User, currPassword string) error
+	ProfileByEmail(ctx context.Context, email string) (userland.User, error)
+	Profile(ctx context.Context, userID int) (userland.User, error)
+	SetProfile(ctx context.Context, user userland.User) error
+	UpdateProfile(ctx context.Context, userID int, version int, patch userland.ProfilePatch) error
+	SetProfilePicture(ctx context.Context, user userland.User, image io.Reader) error
+	RequestChangeEmail(ctx context.Context, user userland.User, newEmail string) (verificationID string, err error)
+	ChangeEmail(ctx context.Context, user userland.User, verificationID string) error
+	ChangePassword(ctx context.Context, user userland.User, oldPassword, newPassword string) error
+	EnrollTFA(ctx context.Context, user userland.User) (secret string, qrcodeImageBase64 string, err error)
+	ActivateTFA(ctx context.Context, user userland.User, secret string, code string) ([]string, error)
+	RemoveTFA(ctx context.Context, user userland.User, currPassword string) error
+	RegenerateBackupCodes(ctx context.Context, user userland.User) ([]string, error)
+	DeleteAccount(ctx context.Context, user userland.User, currPassword string) error
+	RestoreAccount(ctx context.Context, token string) (userID int, err error)
+	PurgeDeletedAccounts(ctx context.Context) (purged int, err error)
+	RequestExport(ctx context.Context, userID int) error
+	ExportPendingData(ctx context.Context) (exported int, err error)
+	PurgeExpiredExports(ctx context.Context) (purged int, err error)
+	DownloadExport(ctx context.Context, token string) (archive []byte, err error)
 }
 
 func NewService(options ...func(*service)) Service {
@@ -77,32 +140,44 @@ func NewService(options ...func(*service)) Service {
 }
 
 type service struct {
-	mailingClient        mailing.Client
-	userRepository       userland.UserRepository
-	keyValueService      userland.KeyValueService
-	objectStorageService userland.ObjectStorageService
+	config                  *config.Configuration
+	mailingClient           mailing.Client
+	userRepository          userland.UserRepository
+	eventRepository         userland.EventRepository
+	sessionRepository       userland.SessionRepository
+	trustedDeviceRepository userland.TrustedDeviceRepository
+	dataExportRepository    userland.DataExportRepository
+	txManager               userland.TxManager
+	keyValueService         userland.KeyValueService
+	objectStorageService    userland.ObjectStorageService
+}
+
+func (s service) ProfileByEmail(ctx context.Context, email string) (user userland.User, err error) {
+	return s.userRepository.FindByEmail(ctx, email)
 }
 
-func (s service) ProfileByEmail(email string) (user userland.User, err error) {
-	return s.userRepository.FindByEmail(email)
+func (s service) Profile(ctx context.Context, userID int) (user userland.User, err error) {
+	return s.userRepository.Find(ctx, userID)
 }
 
-func (s service) Profile(userID int) (user userland.User, err error) {
-	return s.userRepository.Find(userID)
+func (s service) SetProfile(ctx context.Context, user userland.User) (err error) {
+	// user is a whole representation based on user.Version, it is not retried on conflict
+	return s.userRepository.Update(ctx, user)
 }
 
-func (s service) SetProfile(user userland.User) (err error) {
-	return s.userRepository.Update(user)
+func (s service) UpdateProfile(ctx context.Context, userID int, version int, patch userland.ProfilePatch) (err error) {
+	// only the patched columns are written, version 0 apply the patch on whatever is stored
+	return s.userRepository.UpdateProfile(ctx, userID, version, patch)
 }
 
-func (s service) RequestChangeEmail(user userland.User, newEmail string) (verificationID string, err error) {
-	if _, err = s.userRepository.FindByEmail(newEmail); err == nil { // user present
+func (s service) RequestChangeEmail(ctx context.Context, user userland.User, newEmail string) (verificationID string, err error) {
+	if _, err = s.userRepository.FindByEmail(ctx, newEmail); err == nil { // user present
 		return "", ErrEmailAlreadyUsed
 	}
 
 	verificationID = security.GenerateUUID()
 	emailVerificationKey := keygenerator.EmailVerificationKey(user.ID, verificationID)
-	if err := s.keyValueService.SetEx(emailVerificationKey, []byte(newEmail), security.EmailVerificationExpiration); err != nil {
+	if err := s.keyValueService.SetEx(ctx, emailVerificationKey, []byte(newEmail), security.EmailVerificationExpiration); err != nil {
 		return "", errors.New(fmt.Sprintf("keyValueService.SetEx(%q, %s, exp) err", emailVerificationKey, newEmail))
 	}
 
@@ -114,33 +189,49 @@ func (s service) RequestChangeEmail(user userland.User, newEmail string) (verifi
 	return verificationID, nil
 }
 
-func (s service) ChangeEmail(user userland.User, verificationID string) (err error) {
+func (s service) ChangeEmail(ctx context.Context, user userland.User, verificationID string) (err error) {
 	verificationKey := keygenerator.EmailVerificationKey(user.ID, verificationID)
-	newEmail, err := s.keyValueService.Get(verificationKey)
+	newEmail, err := s.keyValueService.Get(ctx, verificationKey)
 	if err != nil {
 		return err
 	}
 
-	defer s.keyValueService.Delete(verificationKey)
-	user.Email = string(newEmail)
-	return s.userRepository.Update(user)
+	defer s.keyValueService.Delete(ctx, verificationKey)
+	// the email could be taken after the change is requested
+	return s.txManager.WithinTx(ctx, func(repositories userland.TxRepositories) error {
+		if _, err := repositories.UserRepository.FindByEmail(ctx, string(newEmail)); err == nil { // user present
+			return ErrEmailAlreadyUsed
+		}
+		_, err := optimistic.UpdateUser(ctx, repositories.UserRepository, user, func(user *userland.User) {
+			user.Email = string(newEmail)
+		})
+		return err
+	})
 }
 
-func (s service) ChangePassword(user userland.User, oldPassword string, newPassword string) (err error) {
-	if err := security.ComparePassword(user.Password, oldPassword); err != nil {
-		return ErrWrongPassword
-	}
-
-	user.Password = security.HashPassword(newPassword)
-	return s.userRepository.Update(user)
+func (s service) ChangePassword(ctx context.Context, user userland.User, oldPassword string, newPassword string) (err error) {
+	password := security.HashPassword(newPassword)
+	// old password is checked against every fresh copy, a password changed concurrently must not be overwritten
+	_, err = optimistic.UpdateUserChecked(ctx, s.userRepository, user, func(user *userland.User) error {
+		if err := security.ComparePassword(user.Password, oldPassword); err != nil {
+			return ErrWrongPassword
+		}
+		user.Password = password
+		return nil
+	})
+	return err
 }
 
-func (s service) EnrollTFA(user userland.User) (secret string, qrcodeImageBase64 string, err error) {
+func (s service) EnrollTFA(ctx context.Context, user userland.User) (secret string, qrcodeImageBase64 string, err error) {
 	if user.TFAEnabled {
 		return "", "", ErrTFAAlreadyEnabled
 	}
 
-	secret = security.GenerateUUID()
+	// secret is the TOTP secret of the authenticator app, it is kept with the user once TFA is activated
+	secret, err = security.GenerateTOTPSecret()
+	if err != nil {
+		return "", "", err
+	}
 	code, err := security.GenerateOTP(6)
 	if err != nil {
 		return "", "", err
@@ -153,17 +244,17 @@ func (s service) EnrollTFA(user userland.User) (secret string, qrcodeImageBase64
 	}
 
 	qrcodeImageBase64 = base64.StdEncoding.EncodeToString(qrCodeImageBytes)
-	s.keyValueService.SetEx(keygenerator.TFAActivationKey(user.ID, secret), []byte(code), time.Second*60*5)
+	s.keyValueService.SetEx(ctx, keygenerator.TFAActivationKey(user.ID, secret), []byte(code), time.Second*60*5)
 	return secret, qrcodeImageBase64, nil
 }
 
-func (s service) ActivateTFA(user userland.User, secret string, code string) (backupCodes []string, err error) {
+func (s service) ActivateTFA(ctx context.Context, user userland.User, secret string, code string) (backupCodes []string, err error) {
 	if user.TFAEnabled {
 		return nil, ErrTFAAlreadyEnabled
 	}
 
 	tfaActivationKey := keygenerator.TFAActivationKey(user.ID, secret)
-	expectedCode, err := s.keyValueService.Get(tfaActivationKey)
+	expectedCode, err := s.keyValueService.Get(ctx, tfaActivationKey)
 	if err != nil {
 		return nil, err
 	}
@@ -172,59 +263,263 @@ func (s service) ActivateTFA(user userland.User, secret string, code string) (ba
 		return nil, ErrWrongOTP
 	}
 
-	// create 5 backup codes
+	generated := userland.User{}
+	backupCodes, err = s.generateBackupCodes(&generated)
+	if err != nil {
+		return nil, err
+	}
+	tfaEnabledAt := time.Now()
+	err = s.txManager.WithinTx(ctx, func(repositories userland.TxRepositories) error {
+		user, err := optimistic.UpdateUserChecked(ctx, repositories.UserRepository, user, func(user *userland.User) error {
+			if user.TFAEnabled {
+				return ErrTFAAlreadyEnabled
+			}
+			user.TFAEnabled = true
+			user.TFAEnabledAt = tfaEnabledAt
+			user.TFASecret = secret
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		_, err = optimistic.StoreBackupCodesChecked(ctx, repositories.UserRepository, user, func(user *userland.User) error {
+			user.BackupCodes = generated.BackupCodes
+			user.BackupCodesCreatedAt = generated.BackupCodesCreatedAt
+			return nil
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	defer s.keyValueService.Delete(ctx, tfaActivationKey)
+	return backupCodes, nil
+}
+
+func (s service) backupCodeCount() int {
+	if s.config != nil && s.config.TFA.BackupCodeCount > 0 {
+		return s.config.TFA.BackupCodeCount
+	}
+	return DefaultBackupCodeCount
+}
+
+func (s service) gracePeriod() time.Duration {
+	if s.config != nil && s.config.AccountDeletion.GracePeriod > 0 {
+		return s.config.AccountDeletion.GracePeriod
+	}
+	return DefaultAccountDeletionGracePeriod
+}
+
+func (s service) backupCodeLength() int {
+	if s.config != nil && s.config.TFA.BackupCodeLength > 0 {
+		return s.config.TFA.BackupCodeLength
+	}
+	return DefaultBackupCodeLength
+}
+
+//generateBackupCodes replace user backup codes with a new set, only the hashes are kept in user
+func (s service) generateBackupCodes(user *userland.User) (backupCodes []string, err error) {
 	backupCodes = []string{}
 	user.BackupCodes = []string{}
-	for i := 0; i < 5; i++ {
-		code, _ := security.GenerateOTP(6)
+	for i := 0; i < s.backupCodeCount(); i++ {
+		code, err := security.GenerateOTP(s.backupCodeLength())
+		if err != nil {
+			return nil, err
+		}
 		backupCodes = append(backupCodes, code)
 		user.BackupCodes = append(user.BackupCodes, security.HashPassword(code))
 	}
-	user.TFAEnabled = true
-	user.TFAEnabledAt = time.Now()
+	user.BackupCodesCreatedAt = time.Now()
+
+	return backupCodes, nil
+}
 
-	// TODO wrap in transaction
-	err = s.userRepository.StoreBackupCodes(user)
+func (s service) RegenerateBackupCodes(ctx context.Context, user userland.User) (backupCodes []string, err error) {
+	if !user.TFAEnabled {
+		return nil, ErrTFANotEnabled
+	}
+
+	// the old set is invalidated by overwriting it
+	generated := userland.User{}
+	backupCodes, err = s.generateBackupCodes(&generated)
+	if err != nil {
+		return nil, err
+	}
+	_, err = optimistic.StoreBackupCodesChecked(ctx, s.userRepository, user, func(user *userland.User) error {
+		if !user.TFAEnabled {
+			return ErrTFANotEnabled
+		}
+		user.BackupCodes = generated.BackupCodes
+		user.BackupCodesCreatedAt = generated.BackupCodesCreatedAt
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	err = s.userRepository.Update(user)
 
-	defer s.keyValueService.Delete(tfaActivationKey)
-	return backupCodes, err
+	return backupCodes, nil
 }
 
-func (s service) RemoveTFA(user userland.User, currPassword string) error {
+func (s service) RemoveTFA(ctx context.Context, user userland.User, currPassword string) error {
 	if err := security.ComparePassword(user.Password, currPassword); err != nil {
 		return ErrWrongPassword
 	}
 
-	// TODO wrap in transaction
-	user.BackupCodes = []string{}
-	s.userRepository.StoreBackupCodes(user)
-	user.TFAEnabled = false
-	return s.userRepository.Update(user)
+	return s.txManager.WithinTx(ctx, func(repositories userland.TxRepositories) error {
+		user, err := optimistic.UpdateUser(ctx, repositories.UserRepository, user, func(user *userland.User) {
+			user.TFAEnabled = false
+			user.TFASecret = ""
+		})
+		if err != nil {
+			return err
+		}
+		_, err = optimistic.StoreBackupCodesChecked(ctx, repositories.UserRepository, user, func(user *userland.User) error {
+			user.BackupCodes = []string{}
+			user.BackupCodesCreatedAt = time.Time{}
+			return nil
+		})
+		return err
+	})
 }
 
-func (s service) DeleteAccount(user userland.User, currPassword string) (err error) {
+//DeleteAccount mark user pending deletion and email a restore link valid for the grace period,
+//ending sessions is left to the caller
+func (s service) DeleteAccount(ctx context.Context, user userland.User, currPassword string) (err error) {
 	if err := security.ComparePassword(user.Password, currPassword); err != nil {
 		return ErrWrongPassword
 	}
 
-	// TODO remove event in handler
-	return s.userRepository.Delete(user.ID)
+	token, err := security.GenerateRandomToken(32)
+	if err != nil {
+		return err
+	}
+	restoreKey := keygenerator.AccountRestoreKey(security.HashToken(token))
+	if err := s.keyValueService.SetEx(ctx, restoreKey, []byte(strconv.Itoa(user.ID)), s.gracePeriod()); err != nil {
+		return errors.Wrapf(err, "keyValueService.SetEx(%q, %d, exp) err", restoreKey, user.ID)
+	}
+
+	deletionRequestedAt := time.Now()
+	user, err = optimistic.UpdateUser(ctx, s.userRepository, user, func(user *userland.User) {
+		user.DeletionRequestedAt = deletionRequestedAt
+	})
+	if err != nil {
+		return err
+	}
+
+	message := fmt.Sprintf(
+		"Your account is scheduled for deletion and will be permanently removed after %s. If you changed your mind, use the link below to restore it.",
+		deletionRequestedAt.Add(s.gracePeriod()).UTC().Format(time.RFC1123),
+	)
+	actionLink := fmt.Sprintf("%s?token=%s", s.config.AccountDeletion.RestoreLink, token)
+	if err := s.mailingClient.SendNoticeEmail(user.Email, user.Fullname, "Your account is scheduled for deletion", message, actionLink); err != nil {
+		log.WithError(err).Error("Error sending email")
+	}
+	return nil
 }
 
-func (s service) SetProfilePicture(user userland.User, image io.Reader) (err error) {
-	link, err := s.objectStorageService.Write(image, userland.ObjectMetaData{
+//RestoreAccount cancel the pending deletion of the account owning a restore token
+func (s service) RestoreAccount(ctx context.Context, token string) (userID int, err error) {
+	restoreKey := keygenerator.AccountRestoreKey(security.HashToken(token))
+	userIDBytes, err := s.keyValueService.Get(ctx, restoreKey)
+	if err != nil {
+		return 0, ErrRestoreInvalid
+	}
+
+	userID, err = strconv.Atoi(string(userIDBytes))
+	if err != nil {
+		return 0, ErrRestoreInvalid
+	}
+
+	user, err := s.userRepository.Find(ctx, userID)
+	if err == userland.ErrUserNotFound {
+		return 0, ErrRestoreInvalid
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if !user.DeletionRequestedAt.IsZero() {
+		_, err = optimistic.UpdateUser(ctx, s.userRepository, user, func(user *userland.User) {
+			user.DeletionRequestedAt = time.Time{}
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+	s.keyValueService.Delete(ctx, restoreKey)
+	return user.ID, nil
+}
+
+//PurgeDeletedAccounts permanently remove accounts whose grace period is over along with their events, profile picture and data exports
+func (s service) PurgeDeletedAccounts(ctx context.Context) (purged int, err error) {
+	users, err := s.userRepository.FindAllPendingDeletion(ctx, time.Now().Add(-s.gracePeriod()))
+	if err != nil {
+		return 0, err
+	}
+
+	for _, user := range users {
+		// session history and risk assessments hold ips, user agents and locations, they go with the user
+		err := s.txManager.WithinTx(ctx, func(repositories userland.TxRepositories) error {
+			if err := repositories.EventRepository.DeleteAllByUserID(ctx, user.ID); err != nil {
+				return err
+			}
+			if err := repositories.SessionRepository.DeleteAllByUserID(ctx, user.ID); err != nil {
+				return err
+			}
+			if err := repositories.LoginRiskAssessmentRepository.DeleteAllByUserID(ctx, user.ID); err != nil {
+				return err
+			}
+			return repositories.UserRepository.Delete(ctx, user.ID)
+		})
+		// another purger got there first
+		if err == userland.ErrUserNotFound {
+			continue
+		}
+		if err != nil {
+			return purged, errors.Wrapf(err, "purge user %d err", user.ID)
+		}
+
+		// the account is gone already, a leftover picture is not worth failing the purge
+		if err := s.objectStorageService.Delete(ctx, profilePicturePath(user.ID)); err != nil {
+			log.WithError(err).WithField("user_id", user.ID).Error("Error deleting profile picture")
+		}
+		// exports left behind are still purged once their link expire
+		if err := s.deleteExports(ctx, user.ID); err != nil {
+			log.WithError(err).WithField("user_id", user.ID).Error("Error deleting data exports")
+		}
+		// sessions kept in redis and trusted devices are outside the transaction, the user is gone already so a failure is only logged
+		if s.sessionRepository != nil {
+			if err := s.sessionRepository.DeleteAllByUserID(ctx, user.ID); err != nil {
+				log.WithError(err).WithField("user_id", user.ID).Error("Error deleting sessions")
+			}
+		}
+		if s.trustedDeviceRepository != nil {
+			if err := s.trustedDeviceRepository.DeleteAllByUserID(ctx, user.ID); err != nil {
+				log.WithError(err).WithField("user_id", user.ID).Error("Error deleting trusted devices")
+			}
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+func profilePicturePath(userID int) string {
+	return fmt.Sprintf("userland_%d_profile.jpeg", userID)
+}
+
+func (s service) SetProfilePicture(ctx context.Context, user userland.User, image io.Reader) (err error) {
+	link, err := s.objectStorageService.Write(ctx, image, userland.ObjectMetaData{
 		CacheControl: "public, max-age=86400",
 		ContentType:  "image/jpeg",
-		Path:         fmt.Sprintf("userland_%d_profile.jpeg", user.ID),
+		Path:         profilePicturePath(user.ID),
 	})
 	if err != nil {
 		return err
 	}
 
-	user.PictureURL = link
-	return s.SetProfile(user)
+	_, err = optimistic.UpdateUser(ctx, s.userRepository, user, func(user *userland.User) {
+		user.PictureURL = link
+	})
+	return err
 }
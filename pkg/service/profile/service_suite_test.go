@@ -3,14 +3,24 @@
 package profile_test
 
 import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/AdhityaRamadhanus/userland"
 	"github.com/AdhityaRamadhanus/userland/pkg/common/http/clients/mailing"
 	"github.com/AdhityaRamadhanus/userland/pkg/common/keygenerator"
 	"github.com/AdhityaRamadhanus/userland/pkg/common/metrics"
+	"github.com/AdhityaRamadhanus/userland/pkg/common/security"
 	"github.com/AdhityaRamadhanus/userland/pkg/config"
 	"github.com/AdhityaRamadhanus/userland/pkg/service/profile"
+	"github.com/AdhityaRamadhanus/userland/pkg/storage/memory"
 	"github.com/AdhityaRamadhanus/userland/pkg/storage/postgres"
 	"github.com/AdhityaRamadhanus/userland/pkg/storage/redis"
 	"github.com/AdhityaRamadhanus/userland/pkg/userlandtest"
@@ -26,7 +36,12 @@ type ProfileServiceTestSuite struct {
 	DB              *sqlx.DB
 	RedisClient     *_redis.Client
 	UserRepository  userland.UserRepository
+	EventRepository userland.EventRepository
 	KeyValueService userland.KeyValueService
+	ObjectStorage   *memory.ObjectStorageService
+	DataExports     *memory.DataExportRepository
+	Sessions        userland.SessionRepository
+	TrustedDevices  userland.TrustedDeviceRepository
 	ProfileService  profile.Service
 }
 
@@ -58,10 +73,22 @@ func (suite *ProfileServiceTestSuite) SetupSuite() {
 	suite.RedisClient = redisClient
 	suite.KeyValueService = redis.NewKeyValueService(redisClient)
 	suite.UserRepository = postgres.NewUserRepository(pgConn)
+	suite.EventRepository = postgres.NewEventRepository(pgConn)
+	suite.ObjectStorage = memory.NewObjectStorageService("http://localhost")
+	suite.DataExports = memory.NewDataExportRepository()
+	suite.Sessions = postgres.NewSessionRepository(pgConn)
+	suite.TrustedDevices = redis.NewTrustedDeviceRepository(redisClient)
 	suite.ProfileService = profile.NewService(
+		profile.WithConfiguration(suite.Config),
 		profile.WithKeyValueService(suite.KeyValueService),
+		profile.WithObjectStorageService(suite.ObjectStorage),
 		profile.WithMailingClient(mailing.NewMailingClient("")),
 		profile.WithUserRepository(suite.UserRepository),
+		profile.WithEventRepository(suite.EventRepository),
+		profile.WithSessionRepository(suite.Sessions),
+		profile.WithTrustedDeviceRepository(suite.TrustedDevices),
+		profile.WithDataExportRepository(suite.DataExports),
+		profile.WithTxManager(postgres.NewTxManager(pgConn)),
 	)
 	suite.ProfileService = profile.NewInstrumentorService(
 		metrics.PrometheusRequestLatency("service", "authentication", profile.MetricKeys),
@@ -73,6 +100,8 @@ func (suite *ProfileServiceTestSuite) SetupTest() {
 	queries := []string{
 		"DELETE FROM users",
 		"DELETE FROM events",
+		"DELETE FROM sessions",
+		"DELETE FROM login_risk_assessments",
 	}
 
 	for _, query := range queries {
@@ -114,7 +143,7 @@ func (suite ProfileServiceTestSuite) TestProfile() {
 
 	for _, tc := range testCases {
 		suite.T().Run(tc.name, func(t *testing.T) {
-			user, err := suite.ProfileService.Profile(tc.args.userID)
+			user, err := suite.ProfileService.Profile(context.Background(), tc.args.userID)
 			if err != tc.wantErr {
 				t.Fatalf("ProfileService.Profile(%d) err = %v; want %v", tc.args.userID, err, tc.wantErr)
 			}
@@ -179,7 +208,7 @@ func (suite ProfileServiceTestSuite) TestSetProfile() {
 				WebURL:   tc.args.web,
 			}
 
-			if err := suite.ProfileService.SetProfile(user); err != tc.wantErr {
+			if err := suite.ProfileService.SetProfile(context.Background(), user); err != tc.wantErr {
 				t.Fatalf("ProfileService.SetProfile(user) err = %v; want %v", err, tc.wantErr)
 			}
 		})
@@ -220,12 +249,12 @@ func (suite ProfileServiceTestSuite) TestRequestChangeEmail() {
 
 	for _, tc := range testCases {
 		suite.T().Run(tc.name, func(t *testing.T) {
-			user, err := suite.ProfileService.Profile(tc.args.userID)
+			user, err := suite.ProfileService.Profile(context.Background(), tc.args.userID)
 			if err != nil {
 				t.Fatalf("ProfileService.Profile(%d) err = %v; want nil", tc.args.userID, err)
 			}
 
-			if _, err := suite.ProfileService.RequestChangeEmail(user, tc.args.newEmail); err != tc.wantErr {
+			if _, err := suite.ProfileService.RequestChangeEmail(context.Background(), user, tc.args.newEmail); err != tc.wantErr {
 				t.Fatalf("ProfileService.RequestChangeEmail(<user>, %s) err = %v; want %v", tc.args.newEmail, err, tc.wantErr)
 			}
 		})
@@ -256,25 +285,39 @@ func (suite ProfileServiceTestSuite) TestChangeEmail() {
 
 	for _, tc := range testCases {
 		suite.T().Run(tc.name, func(t *testing.T) {
-			user, err := suite.ProfileService.Profile(tc.args.userID)
+			user, err := suite.ProfileService.Profile(context.Background(), tc.args.userID)
 			if err != nil {
 				t.Fatalf("ProfileService.Profile(%d) err = %v; want nil", tc.args.userID, err)
 			}
-			verificationID, err := suite.ProfileService.RequestChangeEmail(user, tc.args.newEmail)
+			verificationID, err := suite.ProfileService.RequestChangeEmail(context.Background(), user, tc.args.newEmail)
 			if err != nil {
 				t.Fatalf("ProfileService.RequestChangeEmail(<user>, %q) err = %v; want nil", tc.args.newEmail, err)
 			}
-			if err := suite.ProfileService.ChangeEmail(user, verificationID); err != tc.wantErr {
+			if err := suite.ProfileService.ChangeEmail(context.Background(), user, verificationID); err != tc.wantErr {
 				t.Fatalf("ProfileService.ChangeEmail(<user>, verificationID) err = %v; want %v", err, tc.wantErr)
 			}
 			// check by finding user by email
-			if _, err := suite.ProfileService.ProfileByEmail(tc.args.newEmail); err != nil {
+			if _, err := suite.ProfileService.ProfileByEmail(context.Background(), tc.args.newEmail); err != nil {
 				t.Errorf("ProfileService.ProfileByEmail(%q) err = %v; want nil", tc.args.newEmail, err)
 			}
 		})
 	}
 }
 
+func (suite ProfileServiceTestSuite) TestChangeEmailTakenAfterRequest() {
+	user := userlandtest.TestCreateUser(suite.T(), suite.UserRepository)
+	newEmail := "adhitya.ramadhanus_1993@gmail.com"
+
+	verificationID, err := suite.ProfileService.RequestChangeEmail(context.Background(), *user, newEmail)
+	if err != nil {
+		suite.T().Fatalf("ProfileService.RequestChangeEmail(<user>, %q) err = %v; want nil", newEmail, err)
+	}
+	userlandtest.TestCreateUser(suite.T(), suite.UserRepository, userlandtest.WithUserEmail(newEmail))
+	if err := suite.ProfileService.ChangeEmail(context.Background(), *user, verificationID); err != profile.ErrEmailAlreadyUsed {
+		suite.T().Fatalf("ProfileService.ChangeEmail(<user>, verificationID) err = %v; want %v", err, profile.ErrEmailAlreadyUsed)
+	}
+}
+
 func (suite ProfileServiceTestSuite) TestChangePassword() {
 	defaultUser := userlandtest.TestCreateUser(suite.T(), suite.UserRepository, userlandtest.Verified(true))
 
@@ -301,18 +344,41 @@ func (suite ProfileServiceTestSuite) TestChangePassword() {
 
 	for _, tc := range testCases {
 		suite.T().Run(tc.name, func(t *testing.T) {
-			user, err := suite.ProfileService.Profile(tc.args.userID)
+			user, err := suite.ProfileService.Profile(context.Background(), tc.args.userID)
 			if err != nil {
 				t.Fatalf("ProfileService.Profile(%d) err = %v; want nil", tc.args.userID, err)
 			}
 
-			if err := suite.ProfileService.ChangePassword(user, tc.args.oldPassword, tc.args.newPassword); err != tc.wantErr {
+			if err := suite.ProfileService.ChangePassword(context.Background(), user, tc.args.oldPassword, tc.args.newPassword); err != tc.wantErr {
 				t.Fatalf("ProfileService.ChangePassword(user, oldpass, newpass) err = %v; want %v", err, tc.wantErr)
 			}
 		})
 	}
 }
 
+func (suite ProfileServiceTestSuite) TestChangePassword_concurrentChange() {
+	user := userlandtest.TestCreateUser(suite.T(), suite.UserRepository, userlandtest.Verified(true))
+
+	// the password is changed by another request after user was read
+	concurrent := *user
+	concurrent.Password = security.HashPassword("concurrent123")
+	if err := suite.UserRepository.Update(context.Background(), concurrent); err != nil {
+		suite.T().Fatalf("UserRepository.Update() err = %v; want nil", err)
+	}
+
+	if err := suite.ProfileService.ChangePassword(context.Background(), *user, "test123", "test12345"); err != profile.ErrWrongPassword {
+		suite.T().Fatalf("ProfileService.ChangePassword(<stale user>, oldpass, newpass) err = %v; want %v", err, profile.ErrWrongPassword)
+	}
+
+	found, err := suite.UserRepository.Find(context.Background(), user.ID)
+	if err != nil {
+		suite.T().Fatalf("UserRepository.Find() err = %v; want nil", err)
+	}
+	if err := security.ComparePassword(found.Password, "concurrent123"); err != nil {
+		suite.T().Errorf("stored password = %v; want the concurrent password kept", err)
+	}
+}
+
 func (suite ProfileServiceTestSuite) TestEnrollTFA() {
 	defaultUser := userlandtest.TestCreateUser(suite.T(), suite.UserRepository)
 	tfaEnabledUser := userlandtest.TestCreateTFAEnabledUser(suite.T(), suite.UserRepository, userlandtest.WithUserEmail("tfa@gmail.com"))
@@ -344,11 +410,11 @@ func (suite ProfileServiceTestSuite) TestEnrollTFA() {
 
 	for _, tc := range testCases {
 		suite.T().Run(tc.name, func(t *testing.T) {
-			user, err := suite.ProfileService.Profile(tc.args.userID)
+			user, err := suite.ProfileService.Profile(context.Background(), tc.args.userID)
 			if err != nil {
 				t.Fatalf("ProfileService.Profile(%d) err = %v; want nil", tc.args.userID, err)
 			}
-			if _, _, err := suite.ProfileService.EnrollTFA(user); err != tc.wantErr {
+			if _, _, err := suite.ProfileService.EnrollTFA(context.Background(), user); err != tc.wantErr {
 				t.Fatalf("ProfileService.EnrollTFA(user) err = %v; want %v", err, tc.wantErr)
 			}
 		})
@@ -377,22 +443,22 @@ func (suite ProfileServiceTestSuite) TestActivateTFA() {
 
 	for _, tc := range testCases {
 		suite.T().Run(tc.name, func(t *testing.T) {
-			user, err := suite.ProfileService.Profile(tc.args.userID)
+			user, err := suite.ProfileService.Profile(context.Background(), tc.args.userID)
 			if err != nil {
 				t.Fatalf("ProfileService.Profile(%d) err = %v; want nil", tc.args.userID, err)
 			}
-			secret, _, err := suite.ProfileService.EnrollTFA(user)
+			secret, _, err := suite.ProfileService.EnrollTFA(context.Background(), user)
 			if err != nil {
 				t.Fatalf("ProfileService.EnrollTFA(user) err = %v; want %v", err, tc.wantErr)
 			}
 
 			tfaActivationKey := keygenerator.TFAActivationKey(user.ID, secret)
-			code, err := suite.KeyValueService.Get(tfaActivationKey)
+			code, err := suite.KeyValueService.Get(context.Background(), tfaActivationKey)
 			if err != nil {
 				t.Fatalf("KeyValueService.Get(%q) err = %v; want %v", tfaActivationKey, err, tc.wantErr)
 			}
 
-			if _, err := suite.ProfileService.ActivateTFA(user, secret, string(code)); err != tc.wantErr {
+			if _, err := suite.ProfileService.ActivateTFA(context.Background(), user, secret, string(code)); err != tc.wantErr {
 				t.Fatalf("ProfileService.ActivateTFA(<user>, secret, code) err = %v; want %v", err, tc.wantErr)
 			}
 
@@ -400,7 +466,7 @@ func (suite ProfileServiceTestSuite) TestActivateTFA() {
 				return
 			}
 
-			user, err = suite.ProfileService.Profile(tc.args.userID)
+			user, err = suite.ProfileService.Profile(context.Background(), tc.args.userID)
 			if err != nil {
 				t.Fatalf("ProfileService.Profile(%d) err = %v; want nil", tc.args.userID, err)
 			}
@@ -408,6 +474,9 @@ func (suite ProfileServiceTestSuite) TestActivateTFA() {
 			if !user.TFAEnabled {
 				t.Error("user.TFAEnabled = false; want true")
 			}
+			if user.TFASecret != secret {
+				t.Errorf("user.TFASecret = %q; want %q", user.TFASecret, secret)
+			}
 		})
 	}
 }
@@ -435,15 +504,15 @@ func (suite ProfileServiceTestSuite) TestRemoveTFA() {
 
 	for _, tc := range testCases {
 		suite.T().Run(tc.name, func(t *testing.T) {
-			user, err := suite.ProfileService.Profile(tc.args.userID)
+			user, err := suite.ProfileService.Profile(context.Background(), tc.args.userID)
 			if err != nil {
 				t.Fatalf("ProfileService.Profile(%d) err = %v; want nil", tc.args.userID, err)
 			}
-			if err := suite.ProfileService.RemoveTFA(user, tc.args.password); err != tc.wantErr {
+			if err := suite.ProfileService.RemoveTFA(context.Background(), user, tc.args.password); err != tc.wantErr {
 				t.Fatalf("ProfileService.RemoveTFA(user, password) err = %v; want %v", err, tc.wantErr)
 			}
 
-			user, err = suite.ProfileService.Profile(tc.args.userID)
+			user, err = suite.ProfileService.Profile(context.Background(), tc.args.userID)
 			if err != nil {
 				t.Fatalf("ProfileService.Profile(%d) err = %v; want nil", tc.args.userID, err)
 			}
@@ -451,6 +520,71 @@ func (suite ProfileServiceTestSuite) TestRemoveTFA() {
 			if user.TFAEnabled {
 				t.Error("user.TFAEnabled = true; want false")
 			}
+			if user.TFASecret != "" {
+				t.Errorf("user.TFASecret = %q; want empty", user.TFASecret)
+			}
+		})
+	}
+}
+
+func (suite ProfileServiceTestSuite) TestRegenerateBackupCodes() {
+	tfaEnabledUser := userlandtest.TestCreateTFAEnabledUser(suite.T(), suite.UserRepository)
+	defaultUser := userlandtest.TestCreateUser(suite.T(), suite.UserRepository, userlandtest.WithUserEmail("another@gmail.com"))
+	type args struct {
+		userID int
+	}
+	testCases := []struct {
+		name    string
+		args    args
+		wantErr error
+	}{
+		{
+			name: "success",
+			args: args{
+				userID: tfaEnabledUser.ID,
+			},
+			wantErr: nil,
+		},
+		{
+			name: "tfa not enabled",
+			args: args{
+				userID: defaultUser.ID,
+			},
+			wantErr: profile.ErrTFANotEnabled,
+		},
+	}
+
+	for _, tc := range testCases {
+		suite.T().Run(tc.name, func(t *testing.T) {
+			user, err := suite.ProfileService.Profile(context.Background(), tc.args.userID)
+			if err != nil {
+				t.Fatalf("ProfileService.Profile(%d) err = %v; want nil", tc.args.userID, err)
+			}
+			backupCodes, err := suite.ProfileService.RegenerateBackupCodes(context.Background(), user)
+			if err != tc.wantErr {
+				t.Fatalf("ProfileService.RegenerateBackupCodes(user) err = %v; want %v", err, tc.wantErr)
+			}
+
+			if tc.wantErr != nil {
+				return
+			}
+
+			user, err = suite.ProfileService.Profile(context.Background(), tc.args.userID)
+			if err != nil {
+				t.Fatalf("ProfileService.Profile(%d) err = %v; want nil", tc.args.userID, err)
+			}
+
+			if len(user.BackupCodes) != profile.DefaultBackupCodeCount {
+				t.Errorf("len(user.BackupCodes) = %d; want %d", len(user.BackupCodes), profile.DefaultBackupCodeCount)
+			}
+			if user.BackupCodesCreatedAt.IsZero() {
+				t.Error("user.BackupCodesCreatedAt is zero; want regeneration time")
+			}
+			for _, backupCode := range backupCodes {
+				if len(backupCode) != profile.DefaultBackupCodeLength {
+					t.Errorf("len(backupCode) = %d; want %d", len(backupCode), profile.DefaultBackupCodeLength)
+				}
+			}
 		})
 	}
 }
@@ -487,11 +621,14 @@ func (suite ProfileServiceTestSuite) TestDeleteAccount() {
 
 	for _, tc := range testCases {
 		suite.T().Run(tc.name, func(t *testing.T) {
-			user, err := suite.ProfileService.Profile(tc.args.userID)
+			user, err := suite.ProfileService.Profile(context.Background(), tc.args.userID)
 			if err != nil {
 				t.Fatalf("ProfileService.Profile(%d) err = %v; want nil", tc.args.userID, err)
 			}
-			if err := suite.ProfileService.DeleteAccount(user, tc.args.password); err != tc.wantErr {
+			if err := suite.EventRepository.Insert(context.Background(), userland.Event{UserID: user.ID, Event: "user.login", Timestamp: time.Now()}); err != nil {
+				t.Fatalf("EventRepository.Insert() err = %v; want nil", err)
+			}
+			if err := suite.ProfileService.DeleteAccount(context.Background(), user, tc.args.password); err != tc.wantErr {
 				t.Fatalf("ProfileService.Profile(%d) err = %v; want nil", tc.args.userID, err)
 			}
 
@@ -499,9 +636,341 @@ func (suite ProfileServiceTestSuite) TestDeleteAccount() {
 				return
 			}
 
-			if _, err := suite.ProfileService.Profile(tc.args.userID); err != userland.ErrUserNotFound {
-				t.Errorf("failed in deleting account, user %d still exist", tc.args.userID)
+			// account is kept until the grace period is over
+			user, err = suite.ProfileService.Profile(context.Background(), tc.args.userID)
+			if err != nil || user.DeletionRequestedAt.IsZero() {
+				t.Errorf("ProfileService.Profile(%d) = %v, %v; want user pending deletion", tc.args.userID, user.DeletionRequestedAt, err)
 			}
 		})
 	}
 }
+
+func (suite ProfileServiceTestSuite) TestRestoreAccount() {
+	defaultUser := userlandtest.TestCreateUser(suite.T(), suite.UserRepository)
+	pendingUser, err := suite.UserRepository.Find(context.Background(), defaultUser.ID)
+	if err != nil {
+		suite.T().Fatalf("UserRepository.Find() err = %v; want nil", err)
+	}
+	pendingUser.DeletionRequestedAt = time.Now()
+	if err := suite.UserRepository.Update(context.Background(), pendingUser); err != nil {
+		suite.T().Fatalf("UserRepository.Update() err = %v; want nil", err)
+	}
+	token := "some-restore-token"
+	restoreKey := keygenerator.AccountRestoreKey(security.HashToken(token))
+	if err := suite.KeyValueService.SetEx(context.Background(), restoreKey, []byte(strconv.Itoa(pendingUser.ID)), time.Minute); err != nil {
+		suite.T().Fatalf("KeyValueService.SetEx() err = %v; want nil", err)
+	}
+
+	testCases := []struct {
+		name    string
+		token   string
+		wantErr error
+	}{
+		{
+			name:    "success",
+			token:   token,
+			wantErr: nil,
+		},
+		{
+			name:    "token is used",
+			token:   token,
+			wantErr: profile.ErrRestoreInvalid,
+		},
+		{
+			name:    "unknown token",
+			token:   "some-gibberish-token",
+			wantErr: profile.ErrRestoreInvalid,
+		},
+	}
+
+	for _, tc := range testCases {
+		suite.T().Run(tc.name, func(t *testing.T) {
+			userID, err := suite.ProfileService.RestoreAccount(context.Background(), tc.token)
+			if err != tc.wantErr {
+				t.Fatalf("ProfileService.RestoreAccount(%q) err = %v; want %v", tc.token, err, tc.wantErr)
+			}
+
+			if tc.wantErr != nil {
+				return
+			}
+
+			user, err := suite.ProfileService.Profile(context.Background(), userID)
+			if err != nil || !user.DeletionRequestedAt.IsZero() {
+				t.Errorf("ProfileService.Profile(%d) = %v, %v; want user not pending deletion", userID, user.DeletionRequestedAt, err)
+			}
+		})
+	}
+}
+
+func (suite ProfileServiceTestSuite) TestPurgeDeletedAccounts() {
+	expiredUser := userlandtest.TestCreateUser(suite.T(), suite.UserRepository)
+	recentUser := userlandtest.TestCreateUser(suite.T(), suite.UserRepository, userlandtest.WithUserEmail("another@gmail.com"))
+	activeUser := userlandtest.TestCreateUser(suite.T(), suite.UserRepository, userlandtest.WithUserEmail("active@gmail.com"))
+	requestedAt := map[int]time.Time{
+		expiredUser.ID: time.Now().Add(-profile.DefaultAccountDeletionGracePeriod - time.Hour),
+		recentUser.ID:  time.Now(),
+	}
+	for userID, deletionRequestedAt := range requestedAt {
+		user, err := suite.UserRepository.Find(context.Background(), userID)
+		if err != nil {
+			suite.T().Fatalf("UserRepository.Find(%d) err = %v; want nil", userID, err)
+		}
+		user.DeletionRequestedAt = deletionRequestedAt
+		if err := suite.UserRepository.Update(context.Background(), user); err != nil {
+			suite.T().Fatalf("UserRepository.Update(%d) err = %v; want nil", userID, err)
+		}
+	}
+	if err := suite.EventRepository.Insert(context.Background(), userland.Event{UserID: expiredUser.ID, Event: "user.login", Timestamp: time.Now()}); err != nil {
+		suite.T().Fatalf("EventRepository.Insert() err = %v; want nil", err)
+	}
+	picture := fmt.Sprintf("userland_%d_profile.jpeg", expiredUser.ID)
+	if _, err := suite.ObjectStorage.Write(context.Background(), strings.NewReader("jpeg"), userland.ObjectMetaData{Path: picture}); err != nil {
+		suite.T().Fatalf("ObjectStorage.Write() err = %v; want nil", err)
+	}
+	archive := suite.createDataExport(expiredUser.ID, time.Now().Add(time.Hour))
+	// an ended session is kept for audit until the purge
+	for _, sessionID := range []string{"active-session", "ended-session"} {
+		if err := suite.Sessions.Create(context.Background(), expiredUser.ID, userland.Session{ID: sessionID, IP: "10.10.10.10", Expiration: time.Hour}); err != nil {
+			suite.T().Fatalf("Sessions.Create(%q) err = %v; want nil", sessionID, err)
+		}
+	}
+	if err := suite.Sessions.DeleteBySessionID(context.Background(), expiredUser.ID, "ended-session"); err != nil {
+		suite.T().Fatalf("Sessions.DeleteBySessionID() err = %v; want nil", err)
+	}
+	loginRiskAssessments := postgres.NewLoginRiskAssessmentRepository(suite.DB)
+	if err := loginRiskAssessments.Insert(context.Background(), &userland.LoginRiskAssessment{UserID: expiredUser.ID, IP: "10.10.10.10", Decision: userland.LoginRiskDecisionAllow}); err != nil {
+		suite.T().Fatalf("LoginRiskAssessments.Insert() err = %v; want nil", err)
+	}
+	if err := suite.TrustedDevices.Create(context.Background(), expiredUser.ID, userland.TrustedDevice{ID: "some-device", IP: "10.10.10.10", Expiration: time.Hour}); err != nil {
+		suite.T().Fatalf("TrustedDevices.Create() err = %v; want nil", err)
+	}
+
+	purged, err := suite.ProfileService.PurgeDeletedAccounts(context.Background())
+	if err != nil || purged != 1 {
+		suite.T().Fatalf("ProfileService.PurgeDeletedAccounts() = %d, %v; want 1, nil", purged, err)
+	}
+
+	if _, err := suite.UserRepository.Find(context.Background(), expiredUser.ID); err != userland.ErrUserNotFound {
+		suite.T().Errorf("UserRepository.Find(%d) err = %v; want %v", expiredUser.ID, err, userland.ErrUserNotFound)
+	}
+	_, count, err := suite.EventRepository.FindAll(context.Background(), userland.EventFilterOptions{UserID: expiredUser.ID}, userland.EventPagingOptions{Limit: 10})
+	if err != nil || count != 0 {
+		suite.T().Errorf("EventRepository.FindAll(user %d) count = %d, err = %v; want 0, nil", expiredUser.ID, count, err)
+	}
+	if _, _, err := suite.ObjectStorage.Fetch(context.Background(), picture); err != memory.ErrObjectNotFound {
+		suite.T().Errorf("profile picture %s still exist", picture)
+	}
+	if _, _, err := suite.ObjectStorage.Fetch(context.Background(), archive); err != memory.ErrObjectNotFound {
+		suite.T().Errorf("data export %s still exist", archive)
+	}
+	if dataExports, _ := suite.DataExports.FindAllByUserID(context.Background(), expiredUser.ID); len(dataExports) != 0 {
+		suite.T().Errorf("DataExports.FindAllByUserID(%d) = %d exports; want 0", expiredUser.ID, len(dataExports))
+	}
+	for _, table := range []string{"sessions", "login_risk_assessments"} {
+		var rows int
+		query := fmt.Sprintf("SELECT count(*) FROM %s WHERE user_id=$1", table)
+		if err := suite.DB.Get(&rows, query, expiredUser.ID); err != nil || rows != 0 {
+			suite.T().Errorf("DB.Get(%q) = %d, %v; want 0, nil", query, rows, err)
+		}
+	}
+	if devices, err := suite.TrustedDevices.FindAllByUserID(context.Background(), expiredUser.ID); err != nil || len(devices) != 0 {
+		suite.T().Errorf("TrustedDevices.FindAllByUserID(%d) = %d devices, %v; want 0, nil", expiredUser.ID, len(devices), err)
+	}
+	for _, userID := range []int{recentUser.ID, activeUser.ID} {
+		if _, err := suite.UserRepository.Find(context.Background(), userID); err != nil {
+			suite.T().Errorf("UserRepository.Find(%d) err = %v; want nil", userID, err)
+		}
+	}
+}
+
+//noticeMailingClient keep action links of notice emails in memory
+type noticeMailingClient struct {
+	mailing.Client
+	actionLinks *[]string
+}
+
+func (m noticeMailingClient) SendNoticeEmail(recipientAddress string, recipientName string, subject string, message string, actionLink string) error {
+	*m.actionLinks = append(*m.actionLinks, actionLink)
+	return nil
+}
+
+func (suite ProfileServiceTestSuite) TestExportData() {
+	defaultUser := userlandtest.TestCreateUser(suite.T(), suite.UserRepository)
+	user, err := suite.UserRepository.Find(context.Background(), defaultUser.ID)
+	if err != nil {
+		suite.T().Fatalf("UserRepository.Find() err = %v; want nil", err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := suite.EventRepository.Insert(context.Background(), userland.Event{UserID: user.ID, Event: "user.login", Timestamp: time.Now()}); err != nil {
+			suite.T().Fatalf("EventRepository.Insert() err = %v; want nil", err)
+		}
+	}
+	sessionRepository := memory.NewSessionRepository()
+	if err := sessionRepository.Create(context.Background(), user.ID, userland.Session{ID: "some-session", IP: "10.10.10.10", Expiration: time.Hour}); err != nil {
+		suite.T().Fatalf("SessionRepository.Create() err = %v; want nil", err)
+	}
+
+	actionLinks := []string{}
+	profileService := profile.NewService(
+		profile.WithConfiguration(suite.Config),
+		profile.WithKeyValueService(suite.KeyValueService),
+		profile.WithObjectStorageService(suite.ObjectStorage),
+		profile.WithMailingClient(noticeMailingClient{actionLinks: &actionLinks}),
+		profile.WithUserRepository(suite.UserRepository),
+		profile.WithEventRepository(suite.EventRepository),
+		profile.WithSessionRepository(sessionRepository),
+		profile.WithDataExportRepository(memory.NewDataExportRepository()),
+	)
+
+	if err := profileService.RequestExport(context.Background(), user.ID); err != nil {
+		suite.T().Fatalf("ProfileService.RequestExport(%d) err = %v; want nil", user.ID, err)
+	}
+	if exported, err := profileService.ExportPendingData(context.Background()); err != nil || exported != 1 {
+		suite.T().Fatalf("ProfileService.ExportPendingData() = %d, %v; want 1, nil", exported, err)
+	}
+	if exported, err := profileService.ExportPendingData(context.Background()); err != nil || exported != 0 {
+		suite.T().Fatalf("ProfileService.ExportPendingData() again = %d, %v; want 0, nil", exported, err)
+	}
+	if len(actionLinks) != 1 {
+		suite.T().Fatalf("%d emails sent; want 1", len(actionLinks))
+	}
+	token := actionLinks[0][strings.Index(actionLinks[0], "token=")+len("token="):]
+
+	archive, err := profileService.DownloadExport(context.Background(), token)
+	if err != nil {
+		suite.T().Fatalf("ProfileService.DownloadExport() err = %v; want nil", err)
+	}
+	reader, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		suite.T().Fatalf("zip.NewReader() err = %v; want nil", err)
+	}
+	wantCounts := map[string]int{"events.json": 3, "sessions.json": 1}
+	for _, file := range reader.File {
+		content, err := file.Open()
+		if err != nil {
+			suite.T().Fatalf("file.Open(%s) err = %v; want nil", file.Name, err)
+		}
+		var exported interface{}
+		if err := json.NewDecoder(content).Decode(&exported); err != nil {
+			suite.T().Fatalf("json.Decode(%s) err = %v; want nil", file.Name, err)
+		}
+		content.Close()
+
+		switch file.Name {
+		case "profile.json":
+			exportedUser := exported.(map[string]interface{})
+			if exportedUser["email"] != user.Email {
+				suite.T().Errorf("profile.json email = %v; want %s", exportedUser["email"], user.Email)
+			}
+			if _, ok := exportedUser["password"]; ok {
+				suite.T().Errorf("profile.json contain password")
+			}
+		default:
+			if count := len(exported.([]interface{})); count != wantCounts[file.Name] {
+				suite.T().Errorf("%s has %d entries; want %d", file.Name, count, wantCounts[file.Name])
+			}
+			delete(wantCounts, file.Name)
+		}
+	}
+	if len(wantCounts) != 0 {
+		suite.T().Errorf("export is missing %v", wantCounts)
+	}
+
+	if _, err := profileService.DownloadExport(context.Background(), "some-gibberish-token"); err != profile.ErrExportInvalid {
+		suite.T().Errorf("ProfileService.DownloadExport(gibberish) err = %v; want %v", err, profile.ErrExportInvalid)
+	}
+}
+
+//createDataExport record a completed data export of user with its archive in object storage
+func (suite ProfileServiceTestSuite) createDataExport(userID int, expiredAt time.Time) (path string) {
+	dataExport := userland.DataExport{UserID: userID}
+	if err := suite.DataExports.Insert(context.Background(), &dataExport); err != nil {
+		suite.T().Fatalf("DataExports.Insert() err = %v; want nil", err)
+	}
+	path = fmt.Sprintf("exports/userland_%d_%d.zip", userID, dataExport.ID)
+	if _, err := suite.ObjectStorage.Write(context.Background(), strings.NewReader("PK"), userland.ObjectMetaData{Path: path}); err != nil {
+		suite.T().Fatalf("ObjectStorage.Write() err = %v; want nil", err)
+	}
+	if err := suite.DataExports.Complete(context.Background(), dataExport.ID, path, expiredAt); err != nil {
+		suite.T().Fatalf("DataExports.Complete() err = %v; want nil", err)
+	}
+	return path
+}
+
+func (suite ProfileServiceTestSuite) TestRequestExport() {
+	cfg := *suite.Config
+	cfg.DataExport.QueueSize = 2
+	profileService := profile.NewService(
+		profile.WithConfiguration(&cfg),
+		profile.WithDataExportRepository(memory.NewDataExportRepository()),
+	)
+
+	testCases := []struct {
+		name    string
+		userID  int
+		wantErr error
+	}{
+		{
+			name:    "success",
+			userID:  1,
+			wantErr: nil,
+		},
+		{
+			name:    "same user again",
+			userID:  1,
+			wantErr: nil,
+		},
+		{
+			name:    "queue full",
+			userID:  2,
+			wantErr: profile.ErrExportQueueFull,
+		},
+	}
+
+	for _, tc := range testCases {
+		suite.T().Run(tc.name, func(t *testing.T) {
+			if err := profileService.RequestExport(context.Background(), tc.userID); err != tc.wantErr {
+				t.Fatalf("ProfileService.RequestExport(%d) err = %v; want %v", tc.userID, err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func (suite ProfileServiceTestSuite) TestExportPendingData_purgedUser() {
+	dataExports := memory.NewDataExportRepository()
+	profileService := profile.NewService(
+		profile.WithConfiguration(suite.Config),
+		profile.WithUserRepository(suite.UserRepository),
+		profile.WithDataExportRepository(dataExports),
+	)
+	if err := profileService.RequestExport(context.Background(), 1); err != nil {
+		suite.T().Fatalf("ProfileService.RequestExport(1) err = %v; want nil", err)
+	}
+
+	if exported, err := profileService.ExportPendingData(context.Background()); err != nil || exported != 0 {
+		suite.T().Fatalf("ProfileService.ExportPendingData() = %d, %v; want 0, nil", exported, err)
+	}
+	if pending, _ := dataExports.FindAllPending(context.Background(), time.Now().Add(time.Hour)); len(pending) != 0 {
+		suite.T().Errorf("DataExports.FindAllPending() = %d exports; want the export of a missing user dropped", len(pending))
+	}
+}
+
+func (suite ProfileServiceTestSuite) TestPurgeExpiredExports() {
+	expired := suite.createDataExport(1, time.Now().Add(-time.Minute))
+	valid := suite.createDataExport(1, time.Now().Add(time.Hour))
+
+	purged, err := suite.ProfileService.PurgeExpiredExports(context.Background())
+	if err != nil || purged != 1 {
+		suite.T().Fatalf("ProfileService.PurgeExpiredExports() = %d, %v; want 1, nil", purged, err)
+	}
+
+	if _, _, err := suite.ObjectStorage.Fetch(context.Background(), expired); err != memory.ErrObjectNotFound {
+		suite.T().Errorf("expired data export %s still exist", expired)
+	}
+	if _, _, err := suite.ObjectStorage.Fetch(context.Background(), valid); err != nil {
+		suite.T().Errorf("ObjectStorage.Fetch(%s) err = %v; want nil", valid, err)
+	}
+	if purged, err := suite.ProfileService.PurgeExpiredExports(context.Background()); err != nil || purged != 0 {
+		suite.T().Errorf("ProfileService.PurgeExpiredExports() again = %d, %v; want 0, nil", purged, err)
+	}
+}
@@ -0,0 +1,52 @@
+package saml
+
+import (
+	"context"
+	"time"
+
+	"github.com/AdhityaRamadhanus/userland"
+	"github.com/AdhityaRamadhanus/userland/pkg/common/security"
+	"github.com/go-kit/kit/metrics"
+)
+
+var (
+	MetricKeys = []string{"method"}
+)
+
+type instrumentorService struct {
+	requestLatency metrics.Histogram
+	next           Service
+}
+
+func NewInstrumentorService(latency metrics.Histogram, s Service) Service {
+	service := &instrumentorService{
+		requestLatency: latency,
+		next:           s,
+	}
+
+	return service
+}
+
+func (s instrumentorService) ImportIdentityProvider(ctx context.Context, tenant string, metadata []byte, attributeMapping map[string]string, domains []string) (userland.IdentityProvider, error) {
+	defer func(begin time.Time) {
+		s.requestLatency.With("method", "ImportIdentityProvider").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return s.next.ImportIdentityProvider(ctx, tenant, metadata, attributeMapping, domains)
+}
+
+func (s instrumentorService) ServiceProviderMetadata(ctx context.Context, tenant string) ([]byte, error) {
+	defer func(begin time.Time) {
+		s.requestLatency.With("method", "ServiceProviderMetadata").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return s.next.ServiceProviderMetadata(ctx, tenant)
+}
+
+func (s instrumentorService) ConsumeAssertion(ctx context.Context, tenant string, samlResponse string) (userland.User, bool, security.AccessToken, error) {
+	defer func(begin time.Time) {
+		s.requestLatency.With("method", "ConsumeAssertion").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return s.next.ConsumeAssertion(ctx, tenant, samlResponse)
+}
@@ -0,0 +1,60 @@
+package saml
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/xml"
+	"strings"
+
+	"github.com/AdhityaRamadhanus/userland"
+)
+
+//ParseIdentityProviderMetadata extract entity id, single sign on url and signing certificates from identity provider metadata
+func ParseIdentityProviderMetadata(metadata []byte) (userland.IdentityProvider, error) {
+	entityDescriptor := EntityDescriptor{}
+	if err := xml.Unmarshal(metadata, &entityDescriptor); err != nil {
+		return userland.IdentityProvider{}, ErrInvalidMetadata
+	}
+
+	idpDescriptor := entityDescriptor.IDPSSODescriptor
+	if entityDescriptor.EntityID == "" || idpDescriptor == nil {
+		return userland.IdentityProvider{}, ErrInvalidMetadata
+	}
+
+	identityProvider := userland.IdentityProvider{
+		EntityID:     entityDescriptor.EntityID,
+		Certificates: []string{},
+		Metadata:     string(metadata),
+	}
+
+	for _, singleSignOnService := range idpDescriptor.SingleSignOnServices {
+		if singleSignOnService.Binding == HTTPRedirectBinding || singleSignOnService.Binding == HTTPPostBinding {
+			identityProvider.SSOURL = singleSignOnService.Location
+			break
+		}
+	}
+
+	for _, keyDescriptor := range idpDescriptor.KeyDescriptors {
+		// key descriptor without use attribute is used for both signing and encryption
+		if keyDescriptor.Use != "" && keyDescriptor.Use != KeyDescriptorSigning {
+			continue
+		}
+		for _, certificate := range keyDescriptor.KeyInfo.X509Certificates {
+			certificate = strings.Join(strings.Fields(certificate), "")
+			certificateBytes, err := base64.StdEncoding.DecodeString(certificate)
+			if err != nil {
+				return userland.IdentityProvider{}, ErrInvalidMetadata
+			}
+			if _, err := x509.ParseCertificate(certificateBytes); err != nil {
+				return userland.IdentityProvider{}, ErrInvalidMetadata
+			}
+			identityProvider.Certificates = append(identityProvider.Certificates, certificate)
+		}
+	}
+
+	if len(identityProvider.Certificates) == 0 {
+		return userland.IdentityProvider{}, ErrInvalidMetadata
+	}
+
+	return identityProvider, nil
+}
@@ -0,0 +1,280 @@
+package saml
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/AdhityaRamadhanus/userland"
+	mailing "github.com/AdhityaRamadhanus/userland/pkg/common/http/clients/mailing"
+	"github.com/AdhityaRamadhanus/userland/pkg/common/keygenerator"
+	"github.com/AdhityaRamadhanus/userland/pkg/common/optimistic"
+	"github.com/AdhityaRamadhanus/userland/pkg/common/security"
+	"github.com/AdhityaRamadhanus/userland/pkg/config"
+	"github.com/AdhityaRamadhanus/userland/pkg/service/authentication"
+	"github.com/pkg/errors"
+	dsig "github.com/russellhaering/goxmldsig"
+)
+
+var (
+	EventSAMLLogin = "user.authentication.saml_login"
+
+	ErrInvalidMetadata                = errors.New("Invalid identity provider metadata")
+	ErrInvalidResponse                = errors.New("Invalid SAML response")
+	ErrInvalidSignature               = errors.New("SAML response signature is invalid")
+	ErrInvalidDestination             = errors.New("SAML response destination mismatch")
+	ErrResponseNotSuccess             = errors.New("SAML response status is not success")
+	ErrEncryptedAssertionNotSupported = errors.New("Encrypted assertion is not supported")
+	ErrInvalidIssuer                  = errors.New("Assertion issuer mismatch")
+	ErrInvalidAudience                = errors.New("Assertion audience mismatch")
+	ErrAssertionExpired               = errors.New("Assertion expired or not yet valid")
+	ErrSubjectNotConfirmed            = errors.New("Assertion subject cannot be confirmed")
+	ErrAssertionReplayed              = errors.New("Assertion has already been used")
+	ErrMissingEmailAttribute          = errors.New("Assertion does not contain email")
+	ErrAccountDeleted                 = userland.ErrAccountDeleted
+	ErrEmailDomainNotOwned            = errors.New("Identity provider is not authoritative for the asserted email domain")
+)
+
+//Service provide an interface to SAML 2.0 service provider domain service
+type Service interface {
+	ImportIdentityProvider(ctx context.Context, tenant string, metadata []byte, attributeMapping map[string]string, domains []string) (userland.IdentityProvider, error)
+	ServiceProviderMetadata(ctx context.Context, tenant string) ([]byte, error)
+	// ConsumeAssertion return a tfa token instead of an access token when user has tfa enabled
+	ConsumeAssertion(ctx context.Context, tenant string, samlResponse string) (user userland.User, requireTFA bool, accessToken security.AccessToken, err error)
+}
+
+func WithConfiguration(cfg *config.Configuration) func(service *service) {
+	return func(service *service) {
+		service.config = cfg
+	}
+}
+
+func WithIdentityProviderRepository(identityProviderRepository userland.IdentityProviderRepository) func(service *service) {
+	return func(service *service) {
+		service.identityProviderRepository = identityProviderRepository
+	}
+}
+
+func WithUserRepository(userRepository userland.UserRepository) func(service *service) {
+	return func(service *service) {
+		service.userRepository = userRepository
+	}
+}
+
+func WithMailingClient(mailingClient mailing.Client) func(service *service) {
+	return func(service *service) {
+		service.mailingClient = mailingClient
+	}
+}
+
+func WithKeyValueService(keyValueService userland.KeyValueService) func(service *service) {
+	return func(service *service) {
+		service.keyValueService = keyValueService
+	}
+}
+
+//WithClock set clock used to validate assertion, mostly for testing
+func WithClock(clock *dsig.Clock) func(service *service) {
+	return func(service *service) {
+		service.clock = clock
+	}
+}
+
+func NewService(options ...func(*service)) Service {
+	service := &service{}
+	for _, option := range options {
+		option(service)
+	}
+
+	return service
+}
+
+type service struct {
+	config                     *config.Configuration
+	identityProviderRepository userland.IdentityProviderRepository
+	userRepository             userland.UserRepository
+	keyValueService            userland.KeyValueService
+	mailingClient              mailing.Client
+	clock                      *dsig.Clock
+}
+
+func (s service) serviceProvider(identityProvider userland.IdentityProvider) ServiceProvider {
+	baseURL := strings.TrimSuffix(s.config.SAML.BaseURL, "/")
+	return ServiceProvider{
+		EntityID:         fmt.Sprintf("%s/api/saml/%s/metadata", baseURL, identityProvider.Tenant),
+		ACSURL:           fmt.Sprintf("%s/api/saml/%s/acs", baseURL, identityProvider.Tenant),
+		IdentityProvider: identityProvider,
+		Clock:            s.clock,
+	}
+}
+
+func (s service) ImportIdentityProvider(ctx context.Context, tenant string, metadata []byte, attributeMapping map[string]string, domains []string) (identityProvider userland.IdentityProvider, err error) {
+	identityProvider, err = ParseIdentityProviderMetadata(metadata)
+	if err != nil {
+		return userland.IdentityProvider{}, err
+	}
+	identityProvider.Tenant = tenant
+	identityProvider.AttributeMapping = attributeMapping
+	if identityProvider.AttributeMapping == nil {
+		identityProvider.AttributeMapping = map[string]string{}
+	}
+	identityProvider.Domains = []string{}
+	for _, domain := range domains {
+		identityProvider.Domains = append(identityProvider.Domains, strings.ToLower(strings.TrimPrefix(strings.TrimSpace(domain), "@")))
+	}
+
+	existingIdentityProvider, err := s.identityProviderRepository.FindByTenant(ctx, tenant)
+	switch err {
+	case nil:
+		identityProvider.ID = existingIdentityProvider.ID
+		identityProvider.CreatedAt = existingIdentityProvider.CreatedAt
+		if err := s.identityProviderRepository.Update(ctx, identityProvider); err != nil {
+			return userland.IdentityProvider{}, err
+		}
+	case userland.ErrIdentityProviderNotFound:
+		if err := s.identityProviderRepository.Insert(ctx, &identityProvider); err != nil {
+			return userland.IdentityProvider{}, err
+		}
+	default:
+		return userland.IdentityProvider{}, err
+	}
+
+	return identityProvider, nil
+}
+
+func (s service) ServiceProviderMetadata(ctx context.Context, tenant string) (metadata []byte, err error) {
+	identityProvider, err := s.identityProviderRepository.FindByTenant(ctx, tenant)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.serviceProvider(identityProvider).Metadata()
+}
+
+func (s service) ConsumeAssertion(ctx context.Context, tenant string, samlResponse string) (user userland.User, requireTFA bool, accessToken security.AccessToken, err error) {
+	identityProvider, err := s.identityProviderRepository.FindByTenant(ctx, tenant)
+	if err != nil {
+		return userland.User{}, false, security.AccessToken{}, err
+	}
+
+	assertion, err := s.serviceProvider(identityProvider).ValidateResponse(samlResponse)
+	if err != nil {
+		return userland.User{}, false, security.AccessToken{}, err
+	}
+
+	// bearer assertion can only be consumed once while it is still valid
+	assertionKey := keygenerator.SAMLAssertionKey(tenant, assertion.ID)
+	if _, err := s.keyValueService.Get(ctx, assertionKey); err == nil {
+		return userland.User{}, false, security.AccessToken{}, ErrAssertionReplayed
+	}
+	assertionExpiration := assertion.ExpiresAt().Sub(s.clock.Now()) + MaxClockSkew
+	if err := s.keyValueService.SetEx(ctx, assertionKey, []byte(assertion.Subject.NameID.Value), assertionExpiration); err != nil {
+		return userland.User{}, false, security.AccessToken{}, err
+	}
+
+	assertedUser := assertion.User(identityProvider.AttributeMapping)
+	if assertedUser.Email == "" {
+		return userland.User{}, false, security.AccessToken{}, ErrMissingEmailAttribute
+	}
+
+	user, err = s.provisionUser(ctx, identityProvider, assertion.Subject.NameID.Value, assertedUser)
+	if err != nil {
+		return userland.User{}, false, security.AccessToken{}, err
+	}
+
+	// the identity provider vouch for the password, not for the second factor
+	if user.TFAEnabled {
+		accessToken, err = authentication.StartTFA(ctx, s.keyValueService, s.mailingClient, s.config.JWTSecret, user)
+		if err != nil {
+			return userland.User{}, false, security.AccessToken{}, err
+		}
+		return user, true, accessToken, nil
+	}
+
+	accessToken, err = security.CreateAccessToken(user, s.config.JWTSecret, security.AccessTokenOptions{
+		Expiration: security.UserAccessTokenExpiration,
+		Scope:      security.UserTokenScope,
+		Authentication: security.Authentication{
+			Methods: []string{security.SAMLAuthenticationMethod},
+		},
+	})
+	if err != nil {
+		return userland.User{}, false, security.AccessToken{}, err
+	}
+
+	return user, false, accessToken, nil
+}
+
+//provisionUser find the user a subject signed in as before, otherwise link or create the user of the asserted email,
+//an existing account is only linked when the identity provider own its email domain, and only its empty fields are filled
+func (s service) provisionUser(ctx context.Context, identityProvider userland.IdentityProvider, nameID string, assertedUser userland.User) (user userland.User, err error) {
+	userID, err := s.identityProviderRepository.FindLinkedUserID(ctx, identityProvider.ID, nameID)
+	switch err {
+	case nil:
+		user, err = s.userRepository.Find(ctx, userID)
+		// the linked user is purged, a new account may be provisioned below
+		if err == userland.ErrUserNotFound {
+			break
+		}
+		if err != nil {
+			return userland.User{}, err
+		}
+		return s.fillProfile(ctx, user, assertedUser)
+	case userland.ErrIdentityProviderLinkNotFound:
+	default:
+		return userland.User{}, err
+	}
+
+	if !identityProvider.Owns(assertedUser.Email) {
+		return userland.User{}, ErrEmailDomainNotOwned
+	}
+
+	user, err = s.userRepository.FindByEmail(ctx, assertedUser.Email)
+	switch err {
+	case nil:
+		user, err = s.fillProfile(ctx, user, assertedUser)
+		if err != nil {
+			return userland.User{}, err
+		}
+	case userland.ErrUserNotFound:
+		// user can't login with password until they reset it
+		password := security.GenerateUUID()
+		user = assertedUser
+		if user.Fullname == "" {
+			user.Fullname = user.Email
+		}
+		user.Password = security.HashPassword(password)
+		user.Verified = true
+		if err := s.userRepository.Insert(ctx, &user); err != nil {
+			return userland.User{}, err
+		}
+	default:
+		return userland.User{}, err
+	}
+
+	if err := s.identityProviderRepository.Link(ctx, identityProvider.ID, nameID, user.ID); err != nil {
+		return userland.User{}, err
+	}
+	return user, nil
+}
+
+//fillProfile set the profile fields user left empty from the assertion, the user own whatever they already set
+func (s service) fillProfile(ctx context.Context, user userland.User, assertedUser userland.User) (userland.User, error) {
+	if err := user.CheckNotDeleted(); err != nil {
+		return userland.User{}, err
+	}
+
+	if user.Phone != "" && user.Location != "" && user.Verified {
+		return user, nil
+	}
+	return optimistic.UpdateUser(ctx, s.userRepository, user, func(user *userland.User) {
+		if user.Phone == "" {
+			user.Phone = assertedUser.Phone
+		}
+		if user.Location == "" {
+			user.Location = assertedUser.Location
+		}
+		// reaching here means the identity provider own the email domain
+		user.Verified = true
+	})
+}
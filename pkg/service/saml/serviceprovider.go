@@ -0,0 +1,265 @@
+package saml
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/xml"
+	"strings"
+	"time"
+
+	"github.com/AdhityaRamadhanus/userland"
+	"github.com/beevik/etree"
+	"github.com/pkg/errors"
+	dsig "github.com/russellhaering/goxmldsig"
+	"github.com/russellhaering/goxmldsig/etreeutils"
+)
+
+var (
+	//MaxClockSkew is tolerated clock difference between identity provider and service provider
+	MaxClockSkew = time.Second * 90
+
+	defaultAttributeMapping = map[string]string{
+		"email":    "email",
+		"fullname": "name",
+		"phone":    "phone",
+		"location": "location",
+	}
+)
+
+//ServiceProvider validate SAML responses issued by a tenant identity provider
+type ServiceProvider struct {
+	EntityID         string
+	ACSURL           string
+	IdentityProvider userland.IdentityProvider
+	Clock            *dsig.Clock
+}
+
+//Metadata build SAML 2.0 service provider metadata
+func (sp ServiceProvider) Metadata() ([]byte, error) {
+	entityDescriptor := EntityDescriptor{
+		EntityID: sp.EntityID,
+		SPSSODescriptor: &SPSSODescriptor{
+			ProtocolSupportEnumeration: protocolNamespace,
+			WantAssertionsSigned:       true,
+			NameIDFormats:              []string{NameIDFormatEmail},
+			AssertionConsumerServices: []IndexedEndpoint{
+				{
+					Binding:   HTTPPostBinding,
+					Location:  sp.ACSURL,
+					Index:     0,
+					IsDefault: true,
+				},
+			},
+		},
+	}
+
+	metadata, err := xml.MarshalIndent(entityDescriptor, "", "  ")
+	if err != nil {
+		return nil, errors.Wrap(err, "xml.MarshalIndent(entityDescriptor) err")
+	}
+
+	return append([]byte(xml.Header), metadata...), nil
+}
+
+//ValidateResponse verify a base64 encoded SAMLResponse from HTTP-POST binding and return its assertion
+func (sp ServiceProvider) ValidateResponse(encodedResponse string) (Assertion, error) {
+	rawResponse, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encodedResponse))
+	if err != nil {
+		return Assertion{}, ErrInvalidResponse
+	}
+
+	doc := etree.NewDocument()
+	if err := doc.ReadFromBytes(rawResponse); err != nil {
+		return Assertion{}, ErrInvalidResponse
+	}
+
+	responseEl := doc.Root()
+	if responseEl == nil || responseEl.Tag != "Response" || responseEl.NamespaceURI() != protocolNamespace {
+		return Assertion{}, ErrInvalidResponse
+	}
+
+	validationContext, err := sp.validationContext()
+	if err != nil {
+		return Assertion{}, err
+	}
+
+	// only trust what is covered by the signature, either the whole response or the assertion
+	responseSigned := false
+	if signatureEl, _ := etreeutils.NSFindOneChild(responseEl, dsig.Namespace, dsig.SignatureTag); signatureEl != nil {
+		if responseEl, err = validationContext.Validate(responseEl); err != nil {
+			return Assertion{}, ErrInvalidSignature
+		}
+		responseSigned = true
+	}
+
+	if destination := responseEl.SelectAttrValue("Destination", ""); destination != "" && destination != sp.ACSURL {
+		return Assertion{}, ErrInvalidDestination
+	}
+
+	statusCodeEl := responseEl.FindElement("./Status/StatusCode")
+	if statusCodeEl == nil || statusCodeEl.SelectAttrValue("Value", "") != StatusSuccess {
+		return Assertion{}, ErrResponseNotSuccess
+	}
+
+	if encryptedAssertionEl, _ := etreeutils.NSFindOneChild(responseEl, assertionNamespace, "EncryptedAssertion"); encryptedAssertionEl != nil {
+		return Assertion{}, ErrEncryptedAssertionNotSupported
+	}
+
+	assertionEl, err := etreeutils.NSFindOneChild(responseEl, assertionNamespace, "Assertion")
+	if err != nil || assertionEl == nil {
+		return Assertion{}, ErrInvalidResponse
+	}
+
+	nsContext, err := etreeutils.NSBuildParentContext(assertionEl)
+	if err != nil {
+		return Assertion{}, ErrInvalidResponse
+	}
+	assertionEl, err = etreeutils.NSDetatch(nsContext, assertionEl)
+	if err != nil {
+		return Assertion{}, ErrInvalidResponse
+	}
+
+	if !responseSigned {
+		if assertionEl, err = validationContext.Validate(assertionEl); err != nil {
+			return Assertion{}, ErrInvalidSignature
+		}
+	}
+
+	assertion := Assertion{}
+	if err := etreeutils.NSUnmarshalElement(etreeutils.NewDefaultNSContext(), assertionEl, &assertion); err != nil {
+		return Assertion{}, ErrInvalidResponse
+	}
+
+	if err := sp.validateAssertion(assertion); err != nil {
+		return Assertion{}, err
+	}
+
+	return assertion, nil
+}
+
+func (sp ServiceProvider) validationContext() (*dsig.ValidationContext, error) {
+	certificateStore := &dsig.MemoryX509CertificateStore{
+		Roots: []*x509.Certificate{},
+	}
+	for _, certificate := range sp.IdentityProvider.Certificates {
+		certificateBytes, err := base64.StdEncoding.DecodeString(certificate)
+		if err != nil {
+			return nil, errors.Wrap(err, "base64.StdEncoding.DecodeString(certificate) err")
+		}
+		parsedCertificate, err := x509.ParseCertificate(certificateBytes)
+		if err != nil {
+			return nil, errors.Wrap(err, "x509.ParseCertificate() err")
+		}
+		certificateStore.Roots = append(certificateStore.Roots, parsedCertificate)
+	}
+
+	validationContext := dsig.NewDefaultValidationContext(certificateStore)
+	validationContext.Clock = sp.Clock
+	return validationContext, nil
+}
+
+func (sp ServiceProvider) validateAssertion(assertion Assertion) error {
+	now := sp.Clock.Now()
+
+	if assertion.ID == "" {
+		return ErrInvalidResponse
+	}
+
+	if assertion.Issuer != sp.IdentityProvider.EntityID {
+		return ErrInvalidIssuer
+	}
+
+	if !assertion.Conditions.NotBefore.IsZero() && now.Add(MaxClockSkew).Before(assertion.Conditions.NotBefore) {
+		return ErrAssertionExpired
+	}
+	if !assertion.Conditions.NotOnOrAfter.IsZero() && !now.Add(-MaxClockSkew).Before(assertion.Conditions.NotOnOrAfter) {
+		return ErrAssertionExpired
+	}
+
+	for _, audienceRestriction := range assertion.Conditions.AudienceRestrictions {
+		audienceFound := false
+		for _, audience := range audienceRestriction.Audiences {
+			if audience == sp.EntityID {
+				audienceFound = true
+				break
+			}
+		}
+		if !audienceFound {
+			return ErrInvalidAudience
+		}
+	}
+
+	bearerConfirmed := false
+	for _, subjectConfirmation := range assertion.Subject.SubjectConfirmations {
+		if subjectConfirmation.Method != SubjectMethodBearer {
+			continue
+		}
+		confirmationData := subjectConfirmation.SubjectConfirmationData
+		if confirmationData.Recipient != "" && confirmationData.Recipient != sp.ACSURL {
+			continue
+		}
+		// web browser SSO profile require bearer confirmation to be time bounded
+		if confirmationData.NotOnOrAfter.IsZero() || !now.Add(-MaxClockSkew).Before(confirmationData.NotOnOrAfter) {
+			continue
+		}
+		bearerConfirmed = true
+		break
+	}
+	if !bearerConfirmed {
+		return ErrSubjectNotConfirmed
+	}
+
+	return nil
+}
+
+//ExpiresAt return the latest time the assertion can still be presented
+func (a Assertion) ExpiresAt() time.Time {
+	expiresAt := a.Conditions.NotOnOrAfter
+	for _, subjectConfirmation := range a.Subject.SubjectConfirmations {
+		notOnOrAfter := subjectConfirmation.SubjectConfirmationData.NotOnOrAfter
+		if notOnOrAfter.After(expiresAt) {
+			expiresAt = notOnOrAfter
+		}
+	}
+
+	return expiresAt
+}
+
+//Attribute return first value of an attribute matched by its name or friendly name
+func (a Assertion) Attribute(name string) string {
+	for _, attribute := range a.AttributeStatement.Attributes {
+		if attribute.Name != name && attribute.FriendlyName != name {
+			continue
+		}
+		if len(attribute.Values) > 0 {
+			return strings.TrimSpace(attribute.Values[0])
+		}
+	}
+
+	return ""
+}
+
+//User map assertion attributes onto userland user, attributeMapping map user field to SAML attribute name
+func (a Assertion) User(attributeMapping map[string]string) userland.User {
+	mapping := map[string]string{}
+	for field, attributeName := range defaultAttributeMapping {
+		mapping[field] = attributeName
+	}
+	for field, attributeName := range attributeMapping {
+		mapping[field] = attributeName
+	}
+
+	user := userland.User{
+		Email:    a.Attribute(mapping["email"]),
+		Fullname: a.Attribute(mapping["fullname"]),
+		Phone:    a.Attribute(mapping["phone"]),
+		Location: a.Attribute(mapping["location"]),
+	}
+
+	nameID := strings.TrimSpace(a.Subject.NameID.Value)
+	if user.Email == "" && (a.Subject.NameID.Format == NameIDFormatEmail || strings.Contains(nameID, "@")) {
+		user.Email = nameID
+	}
+
+	return user
+}
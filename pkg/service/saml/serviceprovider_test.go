@@ -0,0 +1,321 @@
+// +build unit
+
+package saml_test
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AdhityaRamadhanus/userland"
+	"github.com/AdhityaRamadhanus/userland/pkg/service/saml"
+	"github.com/beevik/etree"
+	dsig "github.com/russellhaering/goxmldsig"
+)
+
+var (
+	testNow         = time.Now().UTC().Truncate(time.Second)
+	testIDPEntityID = "https://idp.example.com/metadata"
+	testSPEntityID  = "https://userland.example.com/api/saml/acme/metadata"
+	testACSURL      = "https://userland.example.com/api/saml/acme/acs"
+)
+
+//testIdentityProvider sign assertions with a throwaway key like a real identity provider would
+type testIdentityProvider struct {
+	keyStore    dsig.X509KeyStore
+	certificate string
+}
+
+func newTestIdentityProvider(t *testing.T) testIdentityProvider {
+	keyStore := dsig.RandomKeyStoreForTest()
+	_, certificate, err := keyStore.GetKeyPair()
+	if err != nil {
+		t.Fatalf("keyStore.GetKeyPair() err = %v; want nil", err)
+	}
+
+	return testIdentityProvider{
+		keyStore:    keyStore,
+		certificate: base64.StdEncoding.EncodeToString(certificate),
+	}
+}
+
+func (idp testIdentityProvider) metadata() string {
+	return fmt.Sprintf(`<md:EntityDescriptor xmlns:md="urn:oasis:names:tc:SAML:2.0:metadata" entityID="%s">
+  <md:IDPSSODescriptor protocolSupportEnumeration="urn:oasis:names:tc:SAML:2.0:protocol">
+    <md:KeyDescriptor use="signing">
+      <ds:KeyInfo xmlns:ds="http://www.w3.org/2000/09/xmldsig#">
+        <ds:X509Data>
+          <ds:X509Certificate>%s</ds:X509Certificate>
+        </ds:X509Data>
+      </ds:KeyInfo>
+    </md:KeyDescriptor>
+    <md:NameIDFormat>urn:oasis:names:tc:SAML:1.1:nameid-format:emailAddress</md:NameIDFormat>
+    <md:SingleSignOnService Binding="urn:oasis:names:tc:SAML:2.0:bindings:HTTP-Redirect" Location="https://idp.example.com/sso"/>
+  </md:IDPSSODescriptor>
+</md:EntityDescriptor>`, testIDPEntityID, idp.certificate)
+}
+
+type assertionOptions struct {
+	issuer       string
+	audience     string
+	recipient    string
+	notOnOrAfter time.Time
+	email        string
+}
+
+func defaultAssertionOptions() assertionOptions {
+	return assertionOptions{
+		issuer:       testIDPEntityID,
+		audience:     testSPEntityID,
+		recipient:    testACSURL,
+		notOnOrAfter: testNow.Add(5 * time.Minute),
+		email:        "adhitya.ramadhanus@gmail.com",
+	}
+}
+
+func (idp testIdentityProvider) assertion(t *testing.T, opts assertionOptions, signed bool) string {
+	assertion := fmt.Sprintf(`<saml:Assertion xmlns:saml="urn:oasis:names:tc:SAML:2.0:assertion" ID="_assertion-1" Version="2.0" IssueInstant="%[1]s">
+  <saml:Issuer>%[2]s</saml:Issuer>
+  <saml:Subject>
+    <saml:NameID Format="urn:oasis:names:tc:SAML:1.1:nameid-format:emailAddress">%[5]s</saml:NameID>
+    <saml:SubjectConfirmation Method="urn:oasis:names:tc:SAML:2.0:cm:bearer">
+      <saml:SubjectConfirmationData Recipient="%[4]s" NotOnOrAfter="%[6]s"/>
+    </saml:SubjectConfirmation>
+  </saml:Subject>
+  <saml:Conditions NotBefore="%[1]s" NotOnOrAfter="%[6]s">
+    <saml:AudienceRestriction>
+      <saml:Audience>%[3]s</saml:Audience>
+    </saml:AudienceRestriction>
+  </saml:Conditions>
+  <saml:AttributeStatement>
+    <saml:Attribute Name="urn:oid:2.16.840.1.113730.3.1.241" FriendlyName="displayName">
+      <saml:AttributeValue>Adhitya Ramadhanus</saml:AttributeValue>
+    </saml:Attribute>
+  </saml:AttributeStatement>
+</saml:Assertion>`,
+		testNow.Format(time.RFC3339), opts.issuer, opts.audience, opts.recipient, opts.email, opts.notOnOrAfter.Format(time.RFC3339))
+	if !signed {
+		return assertion
+	}
+
+	doc := etree.NewDocument()
+	if err := doc.ReadFromString(assertion); err != nil {
+		t.Fatalf("doc.ReadFromString() err = %v; want nil", err)
+	}
+
+	signingContext := dsig.NewDefaultSigningContext(idp.keyStore)
+	signingContext.Canonicalizer = dsig.MakeC14N10ExclusiveCanonicalizerWithPrefixList("")
+	signedAssertion, err := signingContext.SignEnveloped(doc.Root())
+	if err != nil {
+		t.Fatalf("signingContext.SignEnveloped() err = %v; want nil", err)
+	}
+
+	doc.SetRoot(signedAssertion)
+	signedAssertionString, err := doc.WriteToString()
+	if err != nil {
+		t.Fatalf("doc.WriteToString() err = %v; want nil", err)
+	}
+
+	return signedAssertionString
+}
+
+func response(assertion string, statusCode string) string {
+	rawResponse := fmt.Sprintf(`<samlp:Response xmlns:samlp="urn:oasis:names:tc:SAML:2.0:protocol" ID="_response-1" Version="2.0" IssueInstant="%s" Destination="%s">
+  <samlp:Status>
+    <samlp:StatusCode Value="%s"/>
+  </samlp:Status>
+  %s
+</samlp:Response>`, testNow.Format(time.RFC3339), testACSURL, statusCode, assertion)
+
+	return base64.StdEncoding.EncodeToString([]byte(rawResponse))
+}
+
+func TestParseIdentityProviderMetadata(t *testing.T) {
+	idp := newTestIdentityProvider(t)
+
+	testCases := []struct {
+		name     string
+		metadata string
+		wantErr  error
+	}{
+		{
+			name:     "success",
+			metadata: idp.metadata(),
+			wantErr:  nil,
+		},
+		{
+			name:     "not xml",
+			metadata: "not xml",
+			wantErr:  saml.ErrInvalidMetadata,
+		},
+		{
+			name:     "without certificate",
+			metadata: strings.Replace(idp.metadata(), idp.certificate, "", 1),
+			wantErr:  saml.ErrInvalidMetadata,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			identityProvider, err := saml.ParseIdentityProviderMetadata([]byte(tc.metadata))
+			if err != tc.wantErr {
+				t.Fatalf("saml.ParseIdentityProviderMetadata() err = %v; want %v", err, tc.wantErr)
+			}
+			if tc.wantErr != nil {
+				return
+			}
+			if identityProvider.EntityID != testIDPEntityID {
+				t.Errorf("identityProvider.EntityID = %q; want %q", identityProvider.EntityID, testIDPEntityID)
+			}
+			if identityProvider.SSOURL != "https://idp.example.com/sso" {
+				t.Errorf("identityProvider.SSOURL = %q; want %q", identityProvider.SSOURL, "https://idp.example.com/sso")
+			}
+			if len(identityProvider.Certificates) != 1 || identityProvider.Certificates[0] != idp.certificate {
+				t.Errorf("identityProvider.Certificates = %v; want [%s]", identityProvider.Certificates, idp.certificate)
+			}
+		})
+	}
+}
+
+func TestServiceProvider_ValidateResponse(t *testing.T) {
+	idp := newTestIdentityProvider(t)
+	otherIDP := newTestIdentityProvider(t)
+
+	identityProvider, err := saml.ParseIdentityProviderMetadata([]byte(idp.metadata()))
+	if err != nil {
+		t.Fatalf("saml.ParseIdentityProviderMetadata() err = %v; want nil", err)
+	}
+	serviceProvider := saml.ServiceProvider{
+		EntityID:         testSPEntityID,
+		ACSURL:           testACSURL,
+		IdentityProvider: identityProvider,
+		Clock:            dsig.NewFakeClockAt(testNow),
+	}
+
+	withOptions := func(modify func(opts *assertionOptions)) assertionOptions {
+		opts := defaultAssertionOptions()
+		modify(&opts)
+		return opts
+	}
+
+	testCases := []struct {
+		name         string
+		samlResponse string
+		wantErr      error
+	}{
+		{
+			name:         "success",
+			samlResponse: response(idp.assertion(t, defaultAssertionOptions(), true), saml.StatusSuccess),
+			wantErr:      nil,
+		},
+		{
+			name:         "unsigned assertion",
+			samlResponse: response(idp.assertion(t, defaultAssertionOptions(), false), saml.StatusSuccess),
+			wantErr:      saml.ErrInvalidSignature,
+		},
+		{
+			name:         "signed by unknown identity provider",
+			samlResponse: response(otherIDP.assertion(t, defaultAssertionOptions(), true), saml.StatusSuccess),
+			wantErr:      saml.ErrInvalidSignature,
+		},
+		{
+			name: "tampered assertion",
+			samlResponse: response(
+				strings.Replace(idp.assertion(t, defaultAssertionOptions(), true), "adhitya.ramadhanus@gmail.com", "attacker@example.com", 1),
+				saml.StatusSuccess,
+			),
+			wantErr: saml.ErrInvalidSignature,
+		},
+		{
+			name:         "status not success",
+			samlResponse: response(idp.assertion(t, defaultAssertionOptions(), true), "urn:oasis:names:tc:SAML:2.0:status:Requester"),
+			wantErr:      saml.ErrResponseNotSuccess,
+		},
+		{
+			name: "wrong issuer",
+			samlResponse: response(idp.assertion(t, withOptions(func(opts *assertionOptions) {
+				opts.issuer = "https://evil.example.com"
+			}), true), saml.StatusSuccess),
+			wantErr: saml.ErrInvalidIssuer,
+		},
+		{
+			name: "wrong audience",
+			samlResponse: response(idp.assertion(t, withOptions(func(opts *assertionOptions) {
+				opts.audience = "https://other-sp.example.com"
+			}), true), saml.StatusSuccess),
+			wantErr: saml.ErrInvalidAudience,
+		},
+		{
+			name: "expired assertion",
+			samlResponse: response(idp.assertion(t, withOptions(func(opts *assertionOptions) {
+				opts.notOnOrAfter = testNow.Add(-5 * time.Minute)
+			}), true), saml.StatusSuccess),
+			wantErr: saml.ErrAssertionExpired,
+		},
+		{
+			name: "wrong recipient",
+			samlResponse: response(idp.assertion(t, withOptions(func(opts *assertionOptions) {
+				opts.recipient = "https://other-sp.example.com/acs"
+			}), true), saml.StatusSuccess),
+			wantErr: saml.ErrSubjectNotConfirmed,
+		},
+		{
+			name:         "not base64",
+			samlResponse: "%%%",
+			wantErr:      saml.ErrInvalidResponse,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assertion, err := serviceProvider.ValidateResponse(tc.samlResponse)
+			if err != tc.wantErr {
+				t.Fatalf("serviceProvider.ValidateResponse() err = %v; want %v", err, tc.wantErr)
+			}
+			if tc.wantErr != nil {
+				return
+			}
+
+			user := assertion.User(map[string]string{"fullname": "displayName"})
+			wantUser := userland.User{
+				Email:    "adhitya.ramadhanus@gmail.com",
+				Fullname: "Adhitya Ramadhanus",
+			}
+			if user.Email != wantUser.Email || user.Fullname != wantUser.Fullname {
+				t.Errorf("assertion.User() = %+v; want %+v", user, wantUser)
+			}
+		})
+	}
+}
+
+func TestServiceProvider_Metadata(t *testing.T) {
+	serviceProvider := saml.ServiceProvider{
+		EntityID: testSPEntityID,
+		ACSURL:   testACSURL,
+	}
+
+	metadata, err := serviceProvider.Metadata()
+	if err != nil {
+		t.Fatalf("serviceProvider.Metadata() err = %v; want nil", err)
+	}
+
+	doc := etree.NewDocument()
+	if err := doc.ReadFromBytes(metadata); err != nil {
+		t.Fatalf("doc.ReadFromBytes() err = %v; want nil", err)
+	}
+	if entityID := doc.Root().SelectAttrValue("entityID", ""); entityID != testSPEntityID {
+		t.Errorf("entityID = %q; want %q", entityID, testSPEntityID)
+	}
+	acsEl := doc.FindElement("//AssertionConsumerService")
+	if acsEl == nil {
+		t.Fatalf("AssertionConsumerService not found in metadata")
+	}
+	if location := acsEl.SelectAttrValue("Location", ""); location != testACSURL {
+		t.Errorf("AssertionConsumerService Location = %q; want %q", location, testACSURL)
+	}
+	if binding := acsEl.SelectAttrValue("Binding", ""); binding != saml.HTTPPostBinding {
+		t.Errorf("AssertionConsumerService Binding = %q; want %q", binding, saml.HTTPPostBinding)
+	}
+}
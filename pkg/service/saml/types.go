@@ -0,0 +1,136 @@
+package saml
+
+import (
+	"encoding/xml"
+	"time"
+)
+
+const (
+	protocolNamespace  = "urn:oasis:names:tc:SAML:2.0:protocol"
+	assertionNamespace = "urn:oasis:names:tc:SAML:2.0:assertion"
+	metadataNamespace  = "urn:oasis:names:tc:SAML:2.0:metadata"
+
+	HTTPPostBinding     = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-POST"
+	HTTPRedirectBinding = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-Redirect"
+
+	StatusSuccess        = "urn:oasis:names:tc:SAML:2.0:status:Success"
+	NameIDFormatEmail    = "urn:oasis:names:tc:SAML:1.1:nameid-format:emailAddress"
+	SubjectMethodBearer  = "urn:oasis:names:tc:SAML:2.0:cm:bearer"
+	KeyDescriptorSigning = "signing"
+)
+
+//EntityDescriptor is SAML 2.0 metadata root element of both identity provider and service provider
+type EntityDescriptor struct {
+	XMLName          xml.Name          `xml:"urn:oasis:names:tc:SAML:2.0:metadata EntityDescriptor"`
+	EntityID         string            `xml:"entityID,attr"`
+	IDPSSODescriptor *IDPSSODescriptor `xml:"urn:oasis:names:tc:SAML:2.0:metadata IDPSSODescriptor,omitempty"`
+	SPSSODescriptor  *SPSSODescriptor  `xml:"urn:oasis:names:tc:SAML:2.0:metadata SPSSODescriptor,omitempty"`
+}
+
+//IDPSSODescriptor describe identity provider signing keys and single sign on endpoints
+type IDPSSODescriptor struct {
+	ProtocolSupportEnumeration string              `xml:"protocolSupportEnumeration,attr"`
+	KeyDescriptors             []KeyDescriptor     `xml:"urn:oasis:names:tc:SAML:2.0:metadata KeyDescriptor"`
+	NameIDFormats              []string            `xml:"urn:oasis:names:tc:SAML:2.0:metadata NameIDFormat"`
+	SingleSignOnServices       []Endpoint          `xml:"urn:oasis:names:tc:SAML:2.0:metadata SingleSignOnService"`
+	Attributes                 []AttributeMetadata `xml:"urn:oasis:names:tc:SAML:2.0:assertion Attribute"`
+}
+
+//SPSSODescriptor describe service provider assertion consumer endpoints
+type SPSSODescriptor struct {
+	ProtocolSupportEnumeration string            `xml:"protocolSupportEnumeration,attr"`
+	AuthnRequestsSigned        bool              `xml:"AuthnRequestsSigned,attr"`
+	WantAssertionsSigned       bool              `xml:"WantAssertionsSigned,attr"`
+	NameIDFormats              []string          `xml:"urn:oasis:names:tc:SAML:2.0:metadata NameIDFormat"`
+	AssertionConsumerServices  []IndexedEndpoint `xml:"urn:oasis:names:tc:SAML:2.0:metadata AssertionConsumerService"`
+}
+
+//KeyDescriptor hold certificates used by an entity
+type KeyDescriptor struct {
+	Use     string  `xml:"use,attr,omitempty"`
+	KeyInfo KeyInfo `xml:"http://www.w3.org/2000/09/xmldsig# KeyInfo"`
+}
+
+//KeyInfo hold base64 encoded X509 certificates
+type KeyInfo struct {
+	X509Certificates []string `xml:"http://www.w3.org/2000/09/xmldsig# X509Data>X509Certificate"`
+}
+
+//Endpoint is location of a SAML binding
+type Endpoint struct {
+	Binding  string `xml:"Binding,attr"`
+	Location string `xml:"Location,attr"`
+}
+
+//IndexedEndpoint is location of a SAML binding with index
+type IndexedEndpoint struct {
+	Binding   string `xml:"Binding,attr"`
+	Location  string `xml:"Location,attr"`
+	Index     int    `xml:"index,attr"`
+	IsDefault bool   `xml:"isDefault,attr,omitempty"`
+}
+
+//AttributeMetadata is attribute advertised by identity provider metadata
+type AttributeMetadata struct {
+	Name         string `xml:"Name,attr"`
+	FriendlyName string `xml:"FriendlyName,attr"`
+}
+
+//Assertion is a SAML 2.0 assertion issued by identity provider
+type Assertion struct {
+	ID                 string             `xml:"ID,attr"`
+	IssueInstant       time.Time          `xml:"IssueInstant,attr"`
+	Issuer             string             `xml:"urn:oasis:names:tc:SAML:2.0:assertion Issuer"`
+	Subject            Subject            `xml:"urn:oasis:names:tc:SAML:2.0:assertion Subject"`
+	Conditions         Conditions         `xml:"urn:oasis:names:tc:SAML:2.0:assertion Conditions"`
+	AttributeStatement AttributeStatement `xml:"urn:oasis:names:tc:SAML:2.0:assertion AttributeStatement"`
+}
+
+//Subject is principal of the assertion
+type Subject struct {
+	NameID               NameID                `xml:"urn:oasis:names:tc:SAML:2.0:assertion NameID"`
+	SubjectConfirmations []SubjectConfirmation `xml:"urn:oasis:names:tc:SAML:2.0:assertion SubjectConfirmation"`
+}
+
+//NameID is identifier of the subject
+type NameID struct {
+	Format string `xml:"Format,attr"`
+	Value  string `xml:",chardata"`
+}
+
+//SubjectConfirmation is how the subject is confirmed by relying party
+type SubjectConfirmation struct {
+	Method                  string                  `xml:"Method,attr"`
+	SubjectConfirmationData SubjectConfirmationData `xml:"urn:oasis:names:tc:SAML:2.0:assertion SubjectConfirmationData"`
+}
+
+//SubjectConfirmationData restrict where and until when the assertion can be presented
+type SubjectConfirmationData struct {
+	Recipient    string    `xml:"Recipient,attr"`
+	InResponseTo string    `xml:"InResponseTo,attr"`
+	NotOnOrAfter time.Time `xml:"NotOnOrAfter,attr"`
+}
+
+//Conditions restrict validity period and audience of the assertion
+type Conditions struct {
+	NotBefore            time.Time             `xml:"NotBefore,attr"`
+	NotOnOrAfter         time.Time             `xml:"NotOnOrAfter,attr"`
+	AudienceRestrictions []AudienceRestriction `xml:"urn:oasis:names:tc:SAML:2.0:assertion AudienceRestriction"`
+}
+
+//AudienceRestriction list the intended audience of the assertion
+type AudienceRestriction struct {
+	Audiences []string `xml:"urn:oasis:names:tc:SAML:2.0:assertion Audience"`
+}
+
+//AttributeStatement hold subject attributes
+type AttributeStatement struct {
+	Attributes []Attribute `xml:"urn:oasis:names:tc:SAML:2.0:assertion Attribute"`
+}
+
+//Attribute is a subject attribute with its values
+type Attribute struct {
+	Name         string   `xml:"Name,attr"`
+	FriendlyName string   `xml:"FriendlyName,attr"`
+	Values       []string `xml:"urn:oasis:names:tc:SAML:2.0:assertion AttributeValue"`
+}
@@ -1,6 +1,7 @@
 package session
 
 import (
+	"context"
 	"time"
 
 	"github.com/AdhityaRamadhanus/userland"
@@ -28,50 +29,90 @@ func NewInstrumentorService(latency metrics.Histogram, s Service) Service {
 	return service
 }
 
-func (s instrumentorService) CreateSession(userID int, session userland.Session) error {
+func (s instrumentorService) CreateSession(ctx context.Context, userID int, session userland.Session) error {
 	defer func(begin time.Time) {
 		s.requestLatency.With("method", "CreateSession").Observe(time.Since(begin).Seconds())
 	}(time.Now())
 
-	return s.next.CreateSession(userID, session)
+	return s.next.CreateSession(ctx, userID, session)
 }
 
-func (s instrumentorService) ListSession(userID int) (userland.Sessions, error) {
+func (s instrumentorService) ListSession(ctx context.Context, userID int) (userland.Sessions, error) {
 	defer func(begin time.Time) {
 		s.requestLatency.With("method", "ListSession").Observe(time.Since(begin).Seconds())
 	}(time.Now())
 
-	return s.next.ListSession(userID)
+	return s.next.ListSession(ctx, userID)
 }
 
-func (s instrumentorService) EndSession(userID int, currentSessionID string) error {
+func (s instrumentorService) TouchSession(ctx context.Context, userID int, sessionID string, ip string) error {
+	defer func(begin time.Time) {
+		s.requestLatency.With("method", "TouchSession").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return s.next.TouchSession(ctx, userID, sessionID, ip)
+}
+
+func (s instrumentorService) EndSession(ctx context.Context, userID int, currentSessionID string) error {
 	defer func(begin time.Time) {
 		s.requestLatency.With("method", "EndSession").Observe(time.Since(begin).Seconds())
 	}(time.Now())
 
-	return s.next.EndSession(userID, currentSessionID)
+	return s.next.EndSession(ctx, userID, currentSessionID)
 }
 
-func (s instrumentorService) EndOtherSessions(userID int, currentSessionID string) error {
+func (s instrumentorService) EndOtherSessions(ctx context.Context, userID int, currentSessionID string) error {
 	defer func(begin time.Time) {
 		s.requestLatency.With("method", "EndOtherSessions").Observe(time.Since(begin).Seconds())
 	}(time.Now())
 
-	return s.next.EndOtherSessions(userID, currentSessionID)
+	return s.next.EndOtherSessions(ctx, userID, currentSessionID)
 }
 
-func (s instrumentorService) CreateRefreshToken(user userland.User, currentSessionID string) (security.AccessToken, error) {
+func (s instrumentorService) EndRefreshedSession(ctx context.Context, userID int, previousSessionID string) error {
+	defer func(begin time.Time) {
+		s.requestLatency.With("method", "EndRefreshedSession").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return s.next.EndRefreshedSession(ctx, userID, previousSessionID)
+}
+
+func (s instrumentorService) CreateRefreshToken(ctx context.Context, user userland.User, currentSessionID string, authentication security.Authentication) (security.AccessToken, error) {
 	defer func(begin time.Time) {
 		s.requestLatency.With("method", "CreateRefreshToken").Observe(time.Since(begin).Seconds())
 	}(time.Now())
 
-	return s.next.CreateRefreshToken(user, currentSessionID)
+	return s.next.CreateRefreshToken(ctx, user, currentSessionID, authentication)
 }
 
-func (s instrumentorService) CreateNewAccessToken(user userland.User, refreshTokenID string) (security.AccessToken, error) {
+func (s instrumentorService) CreateNewAccessToken(ctx context.Context, user userland.User, refreshTokenID string, authentication security.Authentication) (security.AccessToken, error) {
 	defer func(begin time.Time) {
 		s.requestLatency.With("method", "CreateNewAccessToken").Observe(time.Since(begin).Seconds())
 	}(time.Now())
 
-	return s.next.CreateNewAccessToken(user, refreshTokenID)
+	return s.next.CreateNewAccessToken(ctx, user, refreshTokenID, authentication)
+}
+
+func (s instrumentorService) ListTrustedDevices(ctx context.Context, userID int) (userland.TrustedDevices, error) {
+	defer func(begin time.Time) {
+		s.requestLatency.With("method", "ListTrustedDevices").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return s.next.ListTrustedDevices(ctx, userID)
+}
+
+func (s instrumentorService) RevokeTrustedDevice(ctx context.Context, userID int, deviceID string) error {
+	defer func(begin time.Time) {
+		s.requestLatency.With("method", "RevokeTrustedDevice").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return s.next.RevokeTrustedDevice(ctx, userID, deviceID)
+}
+
+func (s instrumentorService) RevokeAllTrustedDevices(ctx context.Context, userID int) error {
+	defer func(begin time.Time) {
+		s.requestLatency.With("method", "RevokeAllTrustedDevices").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return s.next.RevokeAllTrustedDevices(ctx, userID)
 }
@@ -1,20 +1,45 @@
 package session
 
 import (
+	"context"
+	"sort"
+	"strconv"
+	"time"
+
 	"github.com/AdhityaRamadhanus/userland"
 	"github.com/AdhityaRamadhanus/userland/pkg/common/keygenerator"
 	"github.com/AdhityaRamadhanus/userland/pkg/common/security"
+	"github.com/AdhityaRamadhanus/userland/pkg/common/useragent"
 	"github.com/AdhityaRamadhanus/userland/pkg/config"
+	"github.com/pkg/errors"
+)
+
+var (
+	EventSessionEvicted = "user.session.evicted"
+
+	//DefaultActivityThrottle is the minimum interval between two session activity writes
+	DefaultActivityThrottle = time.Minute
+	//SessionLimitLockExpiration bound how long a crashed sign-in can hold the session limit lock of a user
+	SessionLimitLockExpiration = 5 * time.Second
+	// interval between two attempts to take a held session limit lock
+	sessionLimitLockRetry = 20 * time.Millisecond
+
+	ErrSessionLimitReached = errors.New("Session limit reached")
 )
 
 //Service provide an interface to story domain service
 type Service interface {
-	CreateSession(userID int, session userland.Session) error
-	ListSession(userID int) (userland.Sessions, error)
-	EndSession(userID int, currentSessionID string) error
-	EndOtherSessions(userID int, currentSessionID string) error
-	CreateRefreshToken(user userland.User, currentSessionID string) (security.AccessToken, error)
-	CreateNewAccessToken(user userland.User, refreshTokenID string) (security.AccessToken, error)
+	CreateSession(ctx context.Context, userID int, session userland.Session) error
+	ListSession(ctx context.Context, userID int) (userland.Sessions, error)
+	TouchSession(ctx context.Context, userID int, sessionID string, ip string) error
+	EndSession(ctx context.Context, userID int, currentSessionID string) error
+	EndOtherSessions(ctx context.Context, userID int, currentSessionID string) error
+	EndRefreshedSession(ctx context.Context, userID int, previousSessionID string) error
+	CreateRefreshToken(ctx context.Context, user userland.User, currentSessionID string, authentication security.Authentication) (security.AccessToken, error)
+	CreateNewAccessToken(ctx context.Context, user userland.User, refreshTokenID string, authentication security.Authentication) (security.AccessToken, error)
+	ListTrustedDevices(ctx context.Context, userID int) (userland.TrustedDevices, error)
+	RevokeTrustedDevice(ctx context.Context, userID int, deviceID string) error
+	RevokeAllTrustedDevices(ctx context.Context, userID int) error
 }
 
 func WithSessionRepository(sessionRepository userland.SessionRepository) func(service *service) {
@@ -23,12 +48,36 @@ func WithSessionRepository(sessionRepository userland.SessionRepository) func(se
 	}
 }
 
+func WithTrustedDeviceRepository(trustedDeviceRepository userland.TrustedDeviceRepository) func(service *service) {
+	return func(service *service) {
+		service.trustedDeviceRepository = trustedDeviceRepository
+	}
+}
+
+func WithEventRepository(eventRepository userland.EventRepository) func(service *service) {
+	return func(service *service) {
+		service.eventRepository = eventRepository
+	}
+}
+
+func WithGeolocationService(geolocationService userland.GeolocationService) func(service *service) {
+	return func(service *service) {
+		service.geolocationService = geolocationService
+	}
+}
+
 func WithKeyValueService(keyValueService userland.KeyValueService) func(service *service) {
 	return func(service *service) {
 		service.keyValueService = keyValueService
 	}
 }
 
+func WithRevocationService(revocationService userland.RevocationService) func(service *service) {
+	return func(service *service) {
+		service.revocationService = revocationService
+	}
+}
+
 func WithConfiguration(cfg *config.Configuration) func(service *service) {
 	return func(service *service) {
 		service.config = cfg
@@ -45,75 +94,416 @@ func NewService(options ...func(*service)) Service {
 }
 
 type service struct {
-	config            *config.Configuration
-	keyValueService   userland.KeyValueService
-	sessionRepository userland.SessionRepository
+	config                  *config.Configuration
+	eventRepository         userland.EventRepository
+	geolocationService      userland.GeolocationService
+	keyValueService         userland.KeyValueService
+	revocationService       userland.RevocationService
+	sessionRepository       userland.SessionRepository
+	trustedDeviceRepository userland.TrustedDeviceRepository
 }
 
-func (s service) CreateSession(userID int, session userland.Session) (err error) {
+func (s service) CreateSession(ctx context.Context, userID int, session userland.Session) (err error) {
+	// counting and creating sessions must not interleave with another sign-in of the same user
+	if maxSessions, maxClientSessions := s.sessionLimits(session); !isElevated(session) && (maxSessions > 0 || maxClientSessions > 0) {
+		unlock, err := s.lockSessionLimits(ctx, userID)
+		if err != nil {
+			return err
+		}
+		defer unlock()
+
+		if err := s.enforceSessionLimits(ctx, userID, session); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	device := useragent.Parse(session.UserAgent)
+	session.Browser = device.Browser
+	session.OS = device.OS
+	session.DeviceType = device.Type
+	session.LastSeenIP = session.IP
+	// session is still created when ip can't be located
+	if s.geolocationService != nil {
+		if geolocation, err := s.geolocationService.Lookup(session.IP); err == nil {
+			session.Country = geolocation.Country
+			session.City = geolocation.City
+			session.ASN = geolocation.ASN
+		}
+	}
+	session.LastSeenAt = now
+
+	if session.Expiration == 0 {
+		session.Expiration = security.UserAccessTokenExpiration
+	}
+	ttl := session.Expiration
+	if slidingExpiration := s.slidingExpiration(); slidingExpiration > 0 && slidingExpiration < ttl {
+		ttl = slidingExpiration
+	}
+	session.ExpiredAt = now.Add(ttl)
+
 	tokenKey := keygenerator.TokenKey(session.ID)
-	if err := s.keyValueService.SetEx(tokenKey, []byte(session.Token), security.UserAccessTokenExpiration); err != nil {
+	if err := s.keyValueService.SetEx(ctx, tokenKey, []byte(session.Token), ttl); err != nil {
 		return err
 	}
-	return s.sessionRepository.Create(userID, session)
+	return s.sessionRepository.Create(ctx, userID, session)
 }
 
-func (s service) ListSession(userID int) (sessions userland.Sessions, err error) {
+func (s service) ListSession(ctx context.Context, userID int) (sessions userland.Sessions, err error) {
 	// remove expired sessions
-	s.sessionRepository.DeleteExpiredSessions(userID)
-	return s.sessionRepository.FindAllByUserID(userID)
+	if err := s.sessionRepository.DeleteExpiredSessions(ctx, userID); err != nil {
+		return nil, err
+	}
+	return s.sessionRepository.FindAllByUserID(ctx, userID)
+}
+
+//TouchSession record session activity at most once per activity throttle,
+//with sliding expiration the session is extended on activity but never past its absolute expiration
+func (s service) TouchSession(ctx context.Context, userID int, sessionID string, ip string) (err error) {
+	activityKey := keygenerator.SessionActivityKey(sessionID)
+	if _, err := s.keyValueService.Get(ctx, activityKey); err == nil {
+		return nil
+	}
+
+	session, err := s.sessionRepository.Find(ctx, userID, sessionID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	session.LastSeenAt = now
+	session.LastSeenIP = ip
+	session.UpdatedAt = now
+
+	slidingExpiration := s.slidingExpiration()
+	if slidingExpiration > 0 && session.Expiration > 0 {
+		expiredAt := now.Add(slidingExpiration)
+		if absoluteExpiredAt := session.CreatedAt.Add(session.Expiration); expiredAt.After(absoluteExpiredAt) {
+			expiredAt = absoluteExpiredAt
+		}
+		if expiredAt.After(session.ExpiredAt) {
+			tokenKey := keygenerator.TokenKey(sessionID)
+			if err := s.keyValueService.Expire(ctx, tokenKey, expiredAt.Sub(now)); err != nil {
+				return err
+			}
+			session.ExpiredAt = expiredAt
+		}
+	}
+
+	if err := s.sessionRepository.Update(ctx, userID, session); err != nil {
+		return err
+	}
+
+	return s.keyValueService.SetEx(ctx, activityKey, []byte(now.Format(time.RFC3339)), s.activityThrottle())
 }
 
-func (s service) EndSession(userID int, currentSessionID string) (err error) {
-	if err := s.sessionRepository.DeleteBySessionID(userID, currentSessionID); err != nil {
+func (s service) EndSession(ctx context.Context, userID int, currentSessionID string) (err error) {
+	if err := s.sessionRepository.DeleteBySessionID(ctx, userID, currentSessionID); err != nil {
 		return err
 	}
 
 	tokenKey := keygenerator.TokenKey(currentSessionID)
-	return s.keyValueService.Delete(tokenKey)
+	if err := s.keyValueService.Delete(ctx, tokenKey); err != nil {
+		return err
+	}
+	if err := s.revokeRefreshToken(ctx, currentSessionID); err != nil {
+		return err
+	}
+	return s.publishRevocation(ctx, userID, currentSessionID, userland.SessionEndReasonLogout)
 }
 
-func (s service) EndOtherSessions(userID int, currentSessionID string) (err error) {
-	deletedSessionIDs, err := s.sessionRepository.DeleteOtherSessions(userID, currentSessionID)
+func (s service) EndOtherSessions(ctx context.Context, userID int, currentSessionID string) (err error) {
+	deletedSessionIDs, err := s.sessionRepository.DeleteOtherSessions(ctx, userID, currentSessionID)
 	if err != nil {
 		return err
 	}
 
 	for _, deletedSessionID := range deletedSessionIDs {
 		tokenKey := keygenerator.TokenKey(deletedSessionID)
-		s.keyValueService.Delete(tokenKey)
+		s.keyValueService.Delete(ctx, tokenKey)
+		s.revokeRefreshToken(ctx, deletedSessionID)
+		s.publishRevocation(ctx, userID, deletedSessionID, userland.SessionEndReasonRevoked)
 	}
 	return nil
 }
 
-func (s service) CreateRefreshToken(user userland.User, currentSessionID string) (accessToken security.AccessToken, err error) {
+//EndRefreshedSession end the session a refresh token replaced, the revocation is published even when the session already expired
+//since its JWT may still be valid for stateless verification
+func (s service) EndRefreshedSession(ctx context.Context, userID int, previousSessionID string) (err error) {
+	if err := s.sessionRepository.DeleteBySessionID(ctx, userID, previousSessionID); err != nil && err != userland.ErrSessionNotFound {
+		return err
+	}
+
+	tokenKey := keygenerator.TokenKey(previousSessionID)
+	if err := s.keyValueService.Delete(ctx, tokenKey); err != nil {
+		return err
+	}
+	if err := s.revokeRefreshToken(ctx, previousSessionID); err != nil {
+		return err
+	}
+	return s.publishRevocation(ctx, userID, previousSessionID, userland.SessionEndReasonRefreshed)
+}
+
+//CreateRefreshToken carry authentication of current session, so refreshing doesn't count as authenticating again,
+//a session has at most one outstanding refresh token and it is revoked when the session ends
+func (s service) CreateRefreshToken(ctx context.Context, user userland.User, currentSessionID string, authentication security.Authentication) (accessToken security.AccessToken, err error) {
+	if err := user.CheckNotDeleted(); err != nil {
+		return security.AccessToken{}, err
+	}
+
 	refreshToken, err := security.CreateAccessToken(user, s.config.JWTSecret, security.AccessTokenOptions{
 		Scope:      security.RefreshTokenScope,
 		Expiration: security.RefreshAccessTokenExpiration,
 		CustomClaim: map[string]interface{}{
 			"previous_session_id": currentSessionID,
 		},
+		Authentication: authentication,
 	})
 	if err != nil {
 		return security.AccessToken{}, err
 	}
 
+	if err := s.revokeRefreshToken(ctx, currentSessionID); err != nil {
+		return security.AccessToken{}, err
+	}
+
 	tokenKey := keygenerator.TokenKey(refreshToken.Key)
-	s.keyValueService.SetEx(tokenKey, []byte(refreshToken.Value), security.RefreshAccessTokenExpiration)
+	if err := s.keyValueService.SetEx(ctx, tokenKey, []byte(refreshToken.Value), security.RefreshAccessTokenExpiration); err != nil {
+		return security.AccessToken{}, err
+	}
+	refreshTokenKey := keygenerator.SessionRefreshTokenKey(currentSessionID)
+	if err := s.keyValueService.SetEx(ctx, refreshTokenKey, []byte(refreshToken.Key), security.RefreshAccessTokenExpiration); err != nil {
+		return security.AccessToken{}, err
+	}
 
 	return refreshToken, nil
 }
 
-func (s service) CreateNewAccessToken(user userland.User, refreshTokenID string) (accessToken security.AccessToken, err error) {
+//CreateNewAccessToken exchange a refresh token, the refresh token is spent even when the user is pending deletion
+func (s service) CreateNewAccessToken(ctx context.Context, user userland.User, refreshTokenID string, authentication security.Authentication) (accessToken security.AccessToken, err error) {
+	if err := user.CheckNotDeleted(); err != nil {
+		s.keyValueService.Delete(ctx, keygenerator.TokenKey(refreshTokenID))
+		return security.AccessToken{}, err
+	}
+
 	newAccessToken, err := security.CreateAccessToken(user, s.config.JWTSecret, security.AccessTokenOptions{
-		Scope:      security.UserTokenScope,
-		Expiration: security.UserAccessTokenExpiration,
+		Scope:          security.UserTokenScope,
+		Expiration:     security.UserAccessTokenExpiration,
+		Authentication: authentication,
 	})
 	if err != nil {
 		return security.AccessToken{}, err
 	}
 
 	tokenKey := keygenerator.TokenKey(refreshTokenID)
-	s.keyValueService.Delete(tokenKey)
+	s.keyValueService.Delete(ctx, tokenKey)
 	return newAccessToken, nil
 }
+
+func (s service) ListTrustedDevices(ctx context.Context, userID int) (devices userland.TrustedDevices, err error) {
+	return s.trustedDeviceRepository.FindAllByUserID(ctx, userID)
+}
+
+func (s service) RevokeTrustedDevice(ctx context.Context, userID int, deviceID string) (err error) {
+	return s.trustedDeviceRepository.Delete(ctx, userID, deviceID)
+}
+
+func (s service) RevokeAllTrustedDevices(ctx context.Context, userID int) (err error) {
+	return s.trustedDeviceRepository.DeleteAllByUserID(ctx, userID)
+}
+
+//enforceSessionLimits make room for session according to the configured total and per client limits,
+//the oldest sessions are evicted or, with the reject policy, the new session is refused
+func (s service) enforceSessionLimits(ctx context.Context, userID int, session userland.Session) error {
+	maxSessions, maxClientSessions := s.sessionLimits(session)
+	if maxSessions <= 0 && maxClientSessions <= 0 {
+		return nil
+	}
+
+	if err := s.sessionRepository.DeleteExpiredSessions(ctx, userID); err != nil {
+		return err
+	}
+	sessions, err := s.sessionRepository.FindAllByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	activeSessions := userland.Sessions{}
+	for _, activeSession := range sessions {
+		// the refreshed session ends right after the new one is created
+		if activeSession.ID != session.PreviousSessionID && !isElevated(activeSession) {
+			activeSessions = append(activeSessions, activeSession)
+		}
+	}
+	sort.Slice(activeSessions, func(i, j int) bool {
+		return activeSessions[i].CreatedAt.Before(activeSessions[j].CreatedAt)
+	})
+
+	evicted := map[string]bool{}
+	evictedSessions := userland.Sessions{}
+	evictOldest := func(candidates userland.Sessions, limit int) {
+		for i := 0; i < len(candidates)-limit+1; i++ {
+			evicted[candidates[i].ID] = true
+			evictedSessions = append(evictedSessions, candidates[i])
+		}
+	}
+
+	if maxClientSessions > 0 {
+		clientSessions := userland.Sessions{}
+		for _, activeSession := range activeSessions {
+			if activeSession.ClientID == session.ClientID && activeSession.ClientName == session.ClientName {
+				clientSessions = append(clientSessions, activeSession)
+			}
+		}
+		evictOldest(clientSessions, maxClientSessions)
+	}
+	if maxSessions > 0 {
+		remainingSessions := userland.Sessions{}
+		for _, activeSession := range activeSessions {
+			if !evicted[activeSession.ID] {
+				remainingSessions = append(remainingSessions, activeSession)
+			}
+		}
+		evictOldest(remainingSessions, maxSessions)
+	}
+
+	if len(evictedSessions) == 0 {
+		return nil
+	}
+	if s.config.Session.LimitPolicy != config.SessionLimitPolicyEvictOldest {
+		return ErrSessionLimitReached
+	}
+
+	evictedSessionIDs := []string{}
+	for _, evictedSession := range evictedSessions {
+		evictedSessionIDs = append(evictedSessionIDs, evictedSession.ID)
+	}
+	if err := s.sessionRepository.EvictSessions(ctx, userID, evictedSessionIDs); err != nil {
+		return err
+	}
+
+	for _, evictedSession := range evictedSessions {
+		tokenKey := keygenerator.TokenKey(evictedSession.ID)
+		s.keyValueService.Delete(ctx, tokenKey)
+		s.revokeRefreshToken(ctx, evictedSession.ID)
+		s.publishRevocation(ctx, userID, evictedSession.ID, userland.SessionEndReasonEvicted)
+		s.logEviction(ctx, userID, evictedSession)
+	}
+	return nil
+}
+
+//isElevated report whether session is a short-lived step-up session from reauthentication,
+//those are neither limited nor counted toward session limits so a step-up can't push out the session it elevate
+func isElevated(session userland.Session) bool {
+	return session.Expiration == security.ReauthenticationExpiration
+}
+
+//sessionLimits return the total and per client session limits applying to session, zero is unlimited
+func (s service) sessionLimits(session userland.Session) (maxSessions int, maxClientSessions int) {
+	if s.config == nil {
+		return 0, 0
+	}
+	return s.config.Session.MaxSessions, s.clientSessionLimit(session)
+}
+
+//lockSessionLimits take the session limit lock of a user, shared by every instance through keyValueService,
+//a lock left by a crashed holder is free again after SessionLimitLockExpiration
+func (s service) lockSessionLimits(ctx context.Context, userID int) (unlock func(), err error) {
+	lockKey := keygenerator.SessionLimitLockKey(userID)
+	lockID := []byte(security.GenerateUUID())
+	for {
+		locked, err := s.keyValueService.SetNX(ctx, lockKey, lockID, SessionLimitLockExpiration)
+		if err != nil {
+			return nil, err
+		}
+		if locked {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(sessionLimitLockRetry):
+		}
+	}
+
+	return func() {
+		// the lock may have expired and been taken by another sign-in
+		if holder, err := s.keyValueService.Get(ctx, lockKey); err == nil && string(holder) == string(lockID) {
+			s.keyValueService.Delete(ctx, lockKey)
+		}
+	}, nil
+}
+
+//clientSessionLimit look up the limit by client id then client name, falling back to the limit shared by every client
+func (s service) clientSessionLimit(session userland.Session) int {
+	clientSessionLimits := s.config.Session.ClientSessionLimits
+	if limit, ok := clientSessionLimits[strconv.Itoa(session.ClientID)]; ok {
+		return limit
+	}
+	if limit, ok := clientSessionLimits[session.ClientName]; ok {
+		return limit
+	}
+	return s.config.Session.MaxSessionsPerClient
+}
+
+//logEviction record the eviction with the client info of the evicted session, so users can tell which device was signed out
+func (s service) logEviction(ctx context.Context, userID int, evictedSession userland.Session) error {
+	if s.eventRepository == nil {
+		return nil
+	}
+	return s.eventRepository.Insert(ctx, userland.Event{
+		UserID:     userID,
+		Event:      EventSessionEvicted,
+		UserAgent:  evictedSession.UserAgent,
+		IP:         evictedSession.IP,
+		ClientID:   evictedSession.ClientID,
+		ClientName: evictedSession.ClientName,
+		Timestamp:  time.Now(),
+	})
+}
+
+//revokeRefreshToken delete the refresh token issued from sessionID, refresh tokens are always verified against the key value service
+//so it can't be exchanged anymore
+func (s service) revokeRefreshToken(ctx context.Context, sessionID string) error {
+	refreshTokenKey := keygenerator.SessionRefreshTokenKey(sessionID)
+	refreshTokenID, err := s.keyValueService.Get(ctx, refreshTokenKey)
+	if err == userland.ErrKeyNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := s.keyValueService.Delete(ctx, keygenerator.TokenKey(string(refreshTokenID))); err != nil {
+		return err
+	}
+	return s.keyValueService.Delete(ctx, refreshTokenKey)
+}
+
+//publishRevocation is a no-op when no revocation service is configured
+func (s service) publishRevocation(ctx context.Context, userID int, sessionID string, reason string) error {
+	if s.revocationService == nil {
+		return nil
+	}
+	return s.revocationService.Publish(ctx, userland.SessionRevocation{
+		SessionID: sessionID,
+		UserID:    userID,
+		Reason:    reason,
+		RevokedAt: time.Now(),
+	})
+}
+
+func (s service) activityThrottle() time.Duration {
+	if s.config != nil && s.config.Session.ActivityThrottle > 0 {
+		return s.config.Session.ActivityThrottle
+	}
+	return DefaultActivityThrottle
+}
+
+func (s service) slidingExpiration() time.Duration {
+	if s.config != nil {
+		return s.config.Session.SlidingExpiration
+	}
+	return 0
+}
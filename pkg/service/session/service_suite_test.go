@@ -3,12 +3,17 @@
 package session_test
 
 import (
+	"context"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/AdhityaRamadhanus/userland"
+	"github.com/AdhityaRamadhanus/userland/pkg/common/keygenerator"
 	"github.com/AdhityaRamadhanus/userland/pkg/common/metrics"
 	"github.com/AdhityaRamadhanus/userland/pkg/common/security"
 	"github.com/AdhityaRamadhanus/userland/pkg/config"
+	"github.com/AdhityaRamadhanus/userland/pkg/mocks/repository"
 	"github.com/AdhityaRamadhanus/userland/pkg/service/authentication"
 	"github.com/AdhityaRamadhanus/userland/pkg/service/session"
 	"github.com/AdhityaRamadhanus/userland/pkg/storage/redis"
@@ -24,6 +29,7 @@ type SessionServiceTestSuite struct {
 	RedisClient       *_redis.Client
 	KeyValueService   userland.KeyValueService
 	SessionRepository userland.SessionRepository
+	RevocationService userland.RevocationService
 	SessionService    session.Service
 }
 
@@ -55,10 +61,12 @@ func (suite *SessionServiceTestSuite) SetupSuite() {
 	suite.RedisClient = redisClient
 	suite.KeyValueService = redis.NewKeyValueService(redisClient)
 	suite.SessionRepository = redis.NewSessionRepository(redisClient)
+	suite.RevocationService = redis.NewRevocationService(redisClient)
 	suite.SessionService = session.NewService(
 		session.WithConfiguration(suite.Config),
 		session.WithKeyValueService(suite.KeyValueService),
 		session.WithSessionRepository(suite.SessionRepository),
+		session.WithRevocationService(suite.RevocationService),
 	)
 	suite.SessionService = session.NewInstrumentorService(
 		metrics.PrometheusRequestLatency("service", "authentication", authentication.MetricKeys),
@@ -95,13 +103,277 @@ func (suite SessionServiceTestSuite) TestCreateSession() {
 
 	for _, tc := range testCases {
 		suite.T().Run(tc.name, func(t *testing.T) {
-			if err := suite.SessionService.CreateSession(tc.args.userID, tc.args.session); err != tc.wantErr {
+			if err := suite.SessionService.CreateSession(context.Background(), tc.args.userID, tc.args.session); err != tc.wantErr {
 				t.Fatalf("SessionService.CreateSession(%d, <session>) err = %v; want %v", tc.args.userID, err, tc.wantErr)
 			}
 		})
 	}
 }
 
+func (suite SessionServiceTestSuite) TestCreateSession_withGeolocation() {
+	geolocation := userland.Geolocation{Country: "ID", City: "Jakarta", ASN: 7713}
+	sessionService := session.NewService(
+		session.WithConfiguration(suite.Config),
+		session.WithGeolocationService(repository.GeolocationService{DefaultGeolocation: geolocation}),
+		session.WithKeyValueService(suite.KeyValueService),
+		session.WithSessionRepository(suite.SessionRepository),
+	)
+
+	userID := 1
+	sessionID := security.GenerateUUID()
+	if err := sessionService.CreateSession(context.Background(), userID, userland.Session{
+		ID:         sessionID,
+		Token:      "test",
+		IP:         "36.72.10.1",
+		Expiration: security.UserAccessTokenExpiration,
+	}); err != nil {
+		suite.T().Fatalf("SessionService.CreateSession(%d, <session>) err = %v; want nil", userID, err)
+	}
+
+	createdSession, err := suite.SessionRepository.Find(context.Background(), userID, sessionID)
+	if err != nil {
+		suite.T().Fatalf("SessionRepository.Find(%d, %s) err = %v; want nil", userID, sessionID, err)
+	}
+	if createdSession.Country != geolocation.Country || createdSession.City != geolocation.City || createdSession.ASN != geolocation.ASN {
+		suite.T().Errorf("session location = %s, %s, %d; want %+v", createdSession.Country, createdSession.City, createdSession.ASN, geolocation)
+	}
+}
+
+//eventRepository keep inserted events in memory
+type eventRepository struct {
+	userland.EventRepository
+	events *userland.Events
+}
+
+func (e eventRepository) Insert(ctx context.Context, event userland.Event) error {
+	*e.events = append(*e.events, event)
+	return nil
+}
+
+func (suite SessionServiceTestSuite) TestCreateSession_withSessionLimits() {
+	type args struct {
+		limitPolicy         string
+		maxSessions         int
+		clientSessionLimits map[string]int
+		clientNames         []string
+	}
+	testCases := []struct {
+		name             string
+		args             args
+		wantErr          error
+		wantEvicted      []int
+		wantSessionCount int
+	}{
+		{
+			name: "evict oldest session over total limit",
+			args: args{
+				limitPolicy: config.SessionLimitPolicyEvictOldest,
+				maxSessions: 2,
+				clientNames: []string{"web", "web", "web"},
+			},
+			wantErr:          nil,
+			wantEvicted:      []int{0},
+			wantSessionCount: 2,
+		},
+		{
+			name: "evict oldest session of the same client over client limit",
+			args: args{
+				limitPolicy:         config.SessionLimitPolicyEvictOldest,
+				clientSessionLimits: map[string]int{"mobile": 1},
+				clientNames:         []string{"mobile", "web", "mobile"},
+			},
+			wantErr:          nil,
+			wantEvicted:      []int{0},
+			wantSessionCount: 2,
+		},
+		{
+			name: "reject session over total limit",
+			args: args{
+				limitPolicy: config.SessionLimitPolicyReject,
+				maxSessions: 1,
+				clientNames: []string{"web", "web"},
+			},
+			wantErr:          session.ErrSessionLimitReached,
+			wantEvicted:      []int{},
+			wantSessionCount: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		suite.T().Run(tc.name, func(t *testing.T) {
+			if err := suite.RedisClient.FlushAll().Err(); err != nil {
+				t.Fatalf("RedisClient.FlushAll() err = %v; want nil", err)
+			}
+
+			cfg := *suite.Config
+			cfg.Session.LimitPolicy = tc.args.limitPolicy
+			cfg.Session.MaxSessions = tc.args.maxSessions
+			cfg.Session.MaxSessionsPerClient = 0
+			cfg.Session.ClientSessionLimits = tc.args.clientSessionLimits
+			events := userland.Events{}
+			sessionService := session.NewService(
+				session.WithConfiguration(&cfg),
+				session.WithEventRepository(eventRepository{events: &events}),
+				session.WithKeyValueService(suite.KeyValueService),
+				session.WithSessionRepository(suite.SessionRepository),
+			)
+
+			userID := 1
+			sessionIDs := []string{}
+			var err error
+			for _, clientName := range tc.args.clientNames {
+				sessionID := security.GenerateUUID()
+				err = sessionService.CreateSession(context.Background(), userID, userland.Session{
+					ID:         sessionID,
+					Token:      "test",
+					IP:         "123.123.13.123",
+					ClientName: clientName,
+					Expiration: security.UserAccessTokenExpiration,
+				})
+				sessionIDs = append(sessionIDs, sessionID)
+			}
+			if err != tc.wantErr {
+				t.Fatalf("SessionService.CreateSession(%d, <session>) err = %v; want %v", userID, err, tc.wantErr)
+			}
+
+			sessions, err := sessionService.ListSession(context.Background(), userID)
+			if err != nil {
+				t.Fatalf("SessionService.ListSession(%d) err = %v; want nil", userID, err)
+			}
+			if gotCount := len(sessions); gotCount != tc.wantSessionCount {
+				t.Errorf("SessionService.ListSession(%d) len(sessions) = %d; want %d", userID, gotCount, tc.wantSessionCount)
+			}
+
+			for _, evicted := range tc.wantEvicted {
+				tokenKey := keygenerator.TokenKey(sessionIDs[evicted])
+				if _, err := suite.KeyValueService.Get(context.Background(), tokenKey); err != userland.ErrKeyNotFound {
+					t.Errorf("KeyValueService.Get(%q) err = %v; want %v", tokenKey, err, userland.ErrKeyNotFound)
+				}
+			}
+			if gotEvents := len(events); gotEvents != len(tc.wantEvicted) {
+				t.Errorf("len(events) = %d; want %d", gotEvents, len(tc.wantEvicted))
+			}
+			for _, event := range events {
+				if event.Event != session.EventSessionEvicted {
+					t.Errorf("event.Event = %q; want %q", event.Event, session.EventSessionEvicted)
+				}
+			}
+		})
+	}
+}
+
+func (suite SessionServiceTestSuite) TestCreateSession_elevatedAtSessionLimits() {
+	for _, limitPolicy := range []string{config.SessionLimitPolicyEvictOldest, config.SessionLimitPolicyReject} {
+		suite.T().Run(limitPolicy, func(t *testing.T) {
+			if err := suite.RedisClient.FlushAll().Err(); err != nil {
+				t.Fatalf("RedisClient.FlushAll() err = %v; want nil", err)
+			}
+
+			cfg := *suite.Config
+			cfg.Session.LimitPolicy = limitPolicy
+			cfg.Session.MaxSessions = 0
+			cfg.Session.MaxSessionsPerClient = 1
+			cfg.Session.ClientSessionLimits = nil
+			events := userland.Events{}
+			sessionService := session.NewService(
+				session.WithConfiguration(&cfg),
+				session.WithEventRepository(eventRepository{events: &events}),
+				session.WithKeyValueService(suite.KeyValueService),
+				session.WithSessionRepository(suite.SessionRepository),
+			)
+
+			userID := 1
+			mainSession := userland.Session{
+				ID:         security.GenerateUUID(),
+				Token:      "test",
+				IP:         "123.123.13.123",
+				ClientName: "web",
+				Expiration: security.UserAccessTokenExpiration,
+			}
+			if err := sessionService.CreateSession(context.Background(), userID, mainSession); err != nil {
+				t.Fatalf("SessionService.CreateSession(%d, main) err = %v; want nil", userID, err)
+			}
+
+			// step-up of the main session, as the reauthenticate handler create it
+			elevatedSession := mainSession
+			elevatedSession.ID = security.GenerateUUID()
+			elevatedSession.Expiration = security.ReauthenticationExpiration
+			if err := sessionService.CreateSession(context.Background(), userID, elevatedSession); err != nil {
+				t.Fatalf("SessionService.CreateSession(%d, elevated) err = %v; want nil", userID, err)
+			}
+
+			sessions, err := sessionService.ListSession(context.Background(), userID)
+			if err != nil {
+				t.Fatalf("SessionService.ListSession(%d) err = %v; want nil", userID, err)
+			}
+			if gotCount := len(sessions); gotCount != 2 {
+				t.Errorf("SessionService.ListSession(%d) len(sessions) = %d; want 2", userID, gotCount)
+			}
+			tokenKey := keygenerator.TokenKey(mainSession.ID)
+			if _, err := suite.KeyValueService.Get(context.Background(), tokenKey); err != nil {
+				t.Errorf("KeyValueService.Get(%q) err = %v; want main session kept", tokenKey, err)
+			}
+			if len(events) != 0 {
+				t.Errorf("len(events) = %d; want no eviction", len(events))
+			}
+		})
+	}
+}
+
+func (suite SessionServiceTestSuite) TestCreateSession_concurrentWithSessionLimits() {
+	cfg := *suite.Config
+	cfg.Session.LimitPolicy = config.SessionLimitPolicyReject
+	cfg.Session.MaxSessions = 2
+	cfg.Session.MaxSessionsPerClient = 0
+	cfg.Session.ClientSessionLimits = nil
+	sessionService := session.NewService(
+		session.WithConfiguration(&cfg),
+		session.WithKeyValueService(suite.KeyValueService),
+		session.WithSessionRepository(suite.SessionRepository),
+	)
+
+	userID := 1
+	signIns := 10
+	errs := make(chan error, signIns)
+	wg := sync.WaitGroup{}
+	for i := 0; i < signIns; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- sessionService.CreateSession(context.Background(), userID, userland.Session{
+				ID:         security.GenerateUUID(),
+				Token:      "test",
+				IP:         "123.123.13.123",
+				Expiration: security.UserAccessTokenExpiration,
+			})
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	created := 0
+	for err := range errs {
+		switch err {
+		case nil:
+			created++
+		case session.ErrSessionLimitReached:
+		default:
+			suite.T().Errorf("SessionService.CreateSession(%d, <session>) err = %v; want nil or %v", userID, err, session.ErrSessionLimitReached)
+		}
+	}
+	if created != cfg.Session.MaxSessions {
+		suite.T().Errorf("created sessions = %d; want %d", created, cfg.Session.MaxSessions)
+	}
+
+	sessions, err := sessionService.ListSession(context.Background(), userID)
+	if err != nil {
+		suite.T().Fatalf("SessionService.ListSession(%d) err = %v; want nil", userID, err)
+	}
+	if gotCount := len(sessions); gotCount != cfg.Session.MaxSessions {
+		suite.T().Errorf("SessionService.ListSession(%d) len(sessions) = %d; want %d", userID, gotCount, cfg.Session.MaxSessions)
+	}
+}
+
 func (suite SessionServiceTestSuite) TestListSession() {
 	type args struct {
 		userID           int
@@ -136,7 +408,7 @@ func (suite SessionServiceTestSuite) TestListSession() {
 				userlandtest.WithNumberOfSessions(tc.args.numberOfSessions),
 				userlandtest.WithUserID(tc.args.userID),
 			)
-			sessions, err := suite.SessionService.ListSession(tc.args.userID)
+			sessions, err := suite.SessionService.ListSession(context.Background(), tc.args.userID)
 			if err != nil {
 				t.Fatalf("SessionService.ListSession(%d) err = %v; want nil", tc.args.userID, err)
 			}
@@ -148,6 +420,103 @@ func (suite SessionServiceTestSuite) TestListSession() {
 	}
 }
 
+func (suite SessionServiceTestSuite) TestTouchSession() {
+	type args struct {
+		userID int
+		ips    []string
+	}
+	testCases := []struct {
+		name           string
+		args           args
+		wantLastSeenIP string
+	}{
+		{
+			name: "record last seen ip",
+			args: args{
+				userID: 1,
+				ips:    []string{"10.10.10.10"},
+			},
+			wantLastSeenIP: "10.10.10.10",
+		},
+		{
+			name: "throttle subsequent activity",
+			args: args{
+				userID: 2,
+				ips:    []string{"10.10.10.10", "11.11.11.11"},
+			},
+			wantLastSeenIP: "10.10.10.10",
+		},
+	}
+
+	for _, tc := range testCases {
+		suite.T().Run(tc.name, func(t *testing.T) {
+			sessionID := security.GenerateUUID()
+			if err := suite.SessionService.CreateSession(context.Background(), tc.args.userID, userland.Session{
+				ID:         sessionID,
+				Token:      "test",
+				IP:         "123.123.13.123",
+				UserAgent:  "Mozilla/5.0 (X11; Linux x86_64; rv:68.0) Gecko/20100101 Firefox/68.0",
+				Expiration: security.UserAccessTokenExpiration,
+			}); err != nil {
+				t.Fatalf("SessionService.CreateSession(%d, <session>) err = %v; want nil", tc.args.userID, err)
+			}
+
+			for _, ip := range tc.args.ips {
+				if err := suite.SessionService.TouchSession(context.Background(), tc.args.userID, sessionID, ip); err != nil {
+					t.Fatalf("SessionService.TouchSession(%d, %s, %s) err = %v; want nil", tc.args.userID, sessionID, ip, err)
+				}
+			}
+
+			session, err := suite.SessionRepository.Find(context.Background(), tc.args.userID, sessionID)
+			if err != nil {
+				t.Fatalf("SessionRepository.Find(%d, %s) err = %v; want nil", tc.args.userID, sessionID, err)
+			}
+			if session.LastSeenIP != tc.wantLastSeenIP {
+				t.Errorf("session.LastSeenIP = %s; want %s", session.LastSeenIP, tc.wantLastSeenIP)
+			}
+			if session.DeviceType != "desktop" {
+				t.Errorf("session.DeviceType = %s; want desktop", session.DeviceType)
+			}
+		})
+	}
+}
+
+func (suite SessionServiceTestSuite) TestTouchSession_withSlidingExpiration() {
+	cfg := *suite.Config
+	cfg.Session.SlidingExpiration = time.Minute
+	sessionService := session.NewService(
+		session.WithConfiguration(&cfg),
+		session.WithKeyValueService(suite.KeyValueService),
+		session.WithSessionRepository(suite.SessionRepository),
+	)
+
+	userID := 1
+	sessionID := security.GenerateUUID()
+	if err := sessionService.CreateSession(context.Background(), userID, userland.Session{
+		ID:         sessionID,
+		Token:      "test",
+		IP:         "123.123.13.123",
+		Expiration: security.UserAccessTokenExpiration,
+	}); err != nil {
+		suite.T().Fatalf("SessionService.CreateSession(%d, <session>) err = %v; want nil", userID, err)
+	}
+
+	tokenKey := keygenerator.TokenKey(sessionID)
+	ttl := suite.RedisClient.TTL(tokenKey).Val()
+	if ttl > cfg.Session.SlidingExpiration {
+		suite.T().Errorf("RedisClient.TTL(%q) = %v; want <= %v", tokenKey, ttl, cfg.Session.SlidingExpiration)
+	}
+
+	time.Sleep(time.Second)
+	if err := sessionService.TouchSession(context.Background(), userID, sessionID, "10.10.10.10"); err != nil {
+		suite.T().Fatalf("SessionService.TouchSession(%d, %s) err = %v; want nil", userID, sessionID, err)
+	}
+	extendedTTL := suite.RedisClient.TTL(tokenKey).Val()
+	if extendedTTL <= ttl-time.Second {
+		suite.T().Errorf("RedisClient.TTL(%q) = %v after activity; want extended from %v", tokenKey, extendedTTL, ttl)
+	}
+}
+
 func (suite SessionServiceTestSuite) TestEndSession() {
 	type args struct {
 		userID int
@@ -169,10 +538,10 @@ func (suite SessionServiceTestSuite) TestEndSession() {
 	for _, tc := range testCases {
 		suite.T().Run(tc.name, func(t *testing.T) {
 			session := userlandtest.TestCreateSession(t, suite.SessionRepository, userlandtest.WithUserID(tc.args.userID))
-			if err := suite.SessionService.EndSession(tc.args.userID, session.ID); err != tc.wantErr {
+			if err := suite.SessionService.EndSession(context.Background(), tc.args.userID, session.ID); err != tc.wantErr {
 				t.Fatalf("SessionService.EndSession(%d, <sessionID>) err = %v; want %v", tc.args.userID, err, tc.wantErr)
 			}
-			sessions, err := suite.SessionService.ListSession(tc.args.userID)
+			sessions, err := suite.SessionService.ListSession(context.Background(), tc.args.userID)
 			if err != nil {
 				t.Fatalf("SessionService.ListSession(%d) err = %v; want nil", tc.args.userID, err)
 			}
@@ -184,6 +553,53 @@ func (suite SessionServiceTestSuite) TestEndSession() {
 	}
 }
 
+func (suite SessionServiceTestSuite) TestEndSession_publishRevocation() {
+	subscription, err := suite.RevocationService.Subscribe(context.Background())
+	if err != nil {
+		suite.T().Fatalf("RevocationService.Subscribe() err = %v; want nil", err)
+	}
+	defer subscription.Close()
+
+	userID := 1
+	createdSession := userlandtest.TestCreateSession(suite.T(), suite.SessionRepository, userlandtest.WithUserID(userID))
+	if err := suite.SessionService.EndSession(context.Background(), userID, createdSession.ID); err != nil {
+		suite.T().Fatalf("SessionService.EndSession(%d, <sessionID>) err = %v; want nil", userID, err)
+	}
+
+	select {
+	case revocation := <-subscription.Revocations():
+		if revocation.SessionID != createdSession.ID || revocation.Reason != userland.SessionEndReasonLogout {
+			suite.T().Errorf("revocation = %+v; want session %s with reason %s", revocation, createdSession.ID, userland.SessionEndReasonLogout)
+		}
+	case <-time.After(5 * time.Second):
+		suite.T().Fatal("SessionService.EndSession() didn't publish revocation")
+	}
+}
+
+func (suite SessionServiceTestSuite) TestEndRefreshedSession_publishRevocation() {
+	subscription, err := suite.RevocationService.Subscribe(context.Background())
+	if err != nil {
+		suite.T().Fatalf("RevocationService.Subscribe() err = %v; want nil", err)
+	}
+	defer subscription.Close()
+
+	// the session may be gone already, its JWT still has to be revoked
+	userID := 1
+	previousSessionID := security.GenerateUUID()
+	if err := suite.SessionService.EndRefreshedSession(context.Background(), userID, previousSessionID); err != nil {
+		suite.T().Fatalf("SessionService.EndRefreshedSession(%d, <sessionID>) err = %v; want nil", userID, err)
+	}
+
+	select {
+	case revocation := <-subscription.Revocations():
+		if revocation.SessionID != previousSessionID || revocation.Reason != userland.SessionEndReasonRefreshed {
+			suite.T().Errorf("revocation = %+v; want session %s with reason %s", revocation, previousSessionID, userland.SessionEndReasonRefreshed)
+		}
+	case <-time.After(5 * time.Second):
+		suite.T().Fatal("SessionService.EndRefreshedSession() didn't publish revocation")
+	}
+}
+
 func (suite SessionServiceTestSuite) TestEndOtherSessions() {
 	type args struct {
 		userID           int
@@ -207,10 +623,10 @@ func (suite SessionServiceTestSuite) TestEndOtherSessions() {
 	for _, tc := range testCases {
 		suite.T().Run(tc.name, func(t *testing.T) {
 			sessions := userlandtest.TestCreateSessions(t, suite.SessionRepository, userlandtest.WithNumberOfSessions(tc.args.numberOfSessions))
-			if err := suite.SessionService.EndOtherSessions(tc.args.userID, sessions[0].ID); err != tc.wantErr {
+			if err := suite.SessionService.EndOtherSessions(context.Background(), tc.args.userID, sessions[0].ID); err != tc.wantErr {
 				t.Fatalf("SessionService.EndOtherSessions(%d, <sessions[0].ID>) err = %v; want %v", tc.args.userID, err, tc.wantErr)
 			}
-			sessions, err := suite.SessionService.ListSession(tc.args.userID)
+			sessions, err := suite.SessionService.ListSession(context.Background(), tc.args.userID)
 			if err != nil {
 				t.Fatalf("SessionService.ListSession(%d) err = %v; want nil", tc.args.userID, err)
 			}
@@ -244,11 +660,24 @@ func (suite SessionServiceTestSuite) TestCreateRefreshToken() {
 			},
 			wantErr: nil,
 		},
+		{
+			name: "pending deletion",
+			args: args{
+				user: userland.User{
+					ID:                  1,
+					Fullname:            "adhitya",
+					Email:               "test@coba.com",
+					DeletionRequestedAt: time.Now(),
+				},
+				sessionID: security.GenerateUUID(),
+			},
+			wantErr: userland.ErrAccountDeleted,
+		},
 	}
 
 	for _, tc := range testCases {
 		suite.T().Run(tc.name, func(t *testing.T) {
-			if _, err := suite.SessionService.CreateRefreshToken(tc.args.user, tc.args.sessionID); err != nil {
+			if _, err := suite.SessionService.CreateRefreshToken(context.Background(), tc.args.user, tc.args.sessionID, security.Authentication{}); err != tc.wantErr {
 				t.Fatalf("SessionService.CreateRefreshToken() err = %v; want %v", err, tc.wantErr)
 			}
 		})
@@ -281,14 +710,65 @@ func (suite SessionServiceTestSuite) TestCreateNewAccessToken() {
 
 	for _, tc := range testCases {
 		suite.T().Run(tc.name, func(t *testing.T) {
-			refreshToken, err := suite.SessionService.CreateRefreshToken(tc.args.user, tc.args.sessionID)
+			refreshToken, err := suite.SessionService.CreateRefreshToken(context.Background(), tc.args.user, tc.args.sessionID, security.Authentication{})
 			if err != nil {
 				t.Fatalf("SessionService.CreateRefreshToken() err = %v; want nil", err)
 			}
 
-			if _, err = suite.SessionService.CreateNewAccessToken(tc.args.user, refreshToken.Key); err != tc.wantErr {
+			if _, err = suite.SessionService.CreateNewAccessToken(context.Background(), tc.args.user, refreshToken.Key, security.Authentication{}); err != tc.wantErr {
 				t.Fatalf("SessionService.CreateNewAccessToken() err = %v; want %v", err, tc.wantErr)
 			}
 		})
 	}
 }
+
+func (suite SessionServiceTestSuite) TestCreateNewAccessToken_pendingDeletion() {
+	user := userland.User{
+		ID:       1,
+		Fullname: "adhitya",
+		Email:    "test@coba.com",
+	}
+	refreshToken, err := suite.SessionService.CreateRefreshToken(context.Background(), user, security.GenerateUUID(), security.Authentication{})
+	if err != nil {
+		suite.T().Fatalf("SessionService.CreateRefreshToken() err = %v; want nil", err)
+	}
+
+	user.DeletionRequestedAt = time.Now()
+	if _, err := suite.SessionService.CreateNewAccessToken(context.Background(), user, refreshToken.Key, security.Authentication{}); err != userland.ErrAccountDeleted {
+		suite.T().Fatalf("SessionService.CreateNewAccessToken() err = %v; want %v", err, userland.ErrAccountDeleted)
+	}
+
+	tokenKey := keygenerator.TokenKey(refreshToken.Key)
+	if _, err := suite.KeyValueService.Get(context.Background(), tokenKey); err != userland.ErrKeyNotFound {
+		suite.T().Errorf("KeyValueService.Get(%q) err = %v; want %v", tokenKey, err, userland.ErrKeyNotFound)
+	}
+}
+
+func (suite SessionServiceTestSuite) TestEndOtherSessions_revokeRefreshTokens() {
+	user := userland.User{
+		ID:       1,
+		Fullname: "adhitya",
+		Email:    "test@coba.com",
+	}
+	sessions := userlandtest.TestCreateSessions(suite.T(), suite.SessionRepository, userlandtest.WithNumberOfSessions(2))
+	refreshTokens := []security.AccessToken{}
+	for _, session := range sessions {
+		refreshToken, err := suite.SessionService.CreateRefreshToken(context.Background(), user, session.ID, security.Authentication{})
+		if err != nil {
+			suite.T().Fatalf("SessionService.CreateRefreshToken() err = %v; want nil", err)
+		}
+		refreshTokens = append(refreshTokens, refreshToken)
+	}
+
+	// ending every session, as deleting the account does, leave no refresh token to exchange
+	if err := suite.SessionService.EndOtherSessions(context.Background(), user.ID, ""); err != nil {
+		suite.T().Fatalf("SessionService.EndOtherSessions(%d, \"\") err = %v; want nil", user.ID, err)
+	}
+
+	for _, refreshToken := range refreshTokens {
+		tokenKey := keygenerator.TokenKey(refreshToken.Key)
+		if _, err := suite.KeyValueService.Get(context.Background(), tokenKey); err != userland.ErrKeyNotFound {
+			suite.T().Errorf("KeyValueService.Get(%q) err = %v; want %v", tokenKey, err, userland.ErrKeyNotFound)
+		}
+	}
+}
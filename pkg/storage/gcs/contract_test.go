@@ -0,0 +1,61 @@
+// +build integration
+
+package gcs_test
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"cloud.google.com/go/storage"
+	"github.com/AdhityaRamadhanus/userland"
+	"github.com/AdhityaRamadhanus/userland/pkg/storage/gcs"
+	"github.com/AdhityaRamadhanus/userland/pkg/userlandtest"
+	"google.golang.org/api/option"
+)
+
+const contractBucket = "userland-contract"
+
+//emulatorTransport sends every request to the emulator, reads are hard coded to storage.googleapis.com in the client
+type emulatorTransport struct {
+	emulator *url.URL
+}
+
+func (e emulatorTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	emulatorReq := new(http.Request)
+	*emulatorReq = *req
+	emulatorURL := *req.URL
+	emulatorURL.Scheme = e.emulator.Scheme
+	emulatorURL.Host = e.emulator.Host
+	emulatorReq.URL = &emulatorURL
+	emulatorReq.Host = e.emulator.Host
+	return http.DefaultTransport.RoundTrip(emulatorReq)
+}
+
+func TestObjectStorageServiceContract(t *testing.T) {
+	emulator, err := url.Parse(emulatorHost)
+	if err != nil {
+		t.Fatalf("url.Parse(%q) err = %v; want nil", emulatorHost, err)
+	}
+
+	ctx := context.Background()
+	storageClient, err := storage.NewClient(ctx, option.WithHTTPClient(&http.Client{Transport: emulatorTransport{emulator: emulator}}))
+	if err != nil {
+		t.Fatalf("storage.NewClient() err = %v; want nil", err)
+	}
+	defer storageClient.Close()
+
+	bucket := storageClient.Bucket(contractBucket)
+	if _, err := bucket.Attrs(ctx); err == storage.ErrBucketNotExist {
+		if err := bucket.Create(ctx, "userland-test", nil); err != nil {
+			t.Fatalf("bucket.Create(%q) err = %v; want nil", contractBucket, err)
+		}
+	} else if err != nil {
+		t.Fatalf("bucket.Attrs(%q) err = %v; want nil", contractBucket, err)
+	}
+
+	userlandtest.RunObjectStorageServiceContract(t, func(t *testing.T) userland.ObjectStorageService {
+		return gcs.NewObjectStorageService(storageClient, contractBucket)
+	})
+}
@@ -24,10 +24,10 @@ func NewObjectStorageService(storageClient *storage.Client, bucketName string) *
 	}
 }
 
-func (o *ObjectStorageService) Write(reader io.Reader, metadata userland.ObjectMetaData) (string, error) {
+func (o *ObjectStorageService) Write(ctx context.Context, reader io.Reader, metadata userland.ObjectMetaData) (string, error) {
 	bucket := o.storageClient.Bucket(o.bucketName)
 	object := bucket.Object(metadata.Path)
-	objectWriter := object.NewWriter(context.Background())
+	objectWriter := object.NewWriter(ctx)
 	objectWriter.ContentType = metadata.ContentType
 	objectWriter.CacheControl = metadata.CacheControl
 
@@ -38,14 +38,17 @@ func (o *ObjectStorageService) Write(reader io.Reader, metadata userland.ObjectM
 		return "", err
 	}
 
-	attrs, _ := object.Attrs(context.Background())
+	attrs, err := object.Attrs(ctx)
+	if err != nil {
+		return "", err
+	}
 	return fmt.Sprintf("https://storage.googleapis.com/%s/%s", attrs.Bucket, attrs.Name), nil
 }
 
-func (o *ObjectStorageService) Fetch(path string) ([]byte, userland.ObjectMetaData, error) {
+func (o *ObjectStorageService) Fetch(ctx context.Context, path string) ([]byte, userland.ObjectMetaData, error) {
 	bucket := o.storageClient.Bucket(o.bucketName)
 	object := bucket.Object(path)
-	objectReader, err := object.NewReader(context.Background())
+	objectReader, err := object.NewReader(ctx)
 	if err != nil {
 		return nil, userland.ObjectMetaData{}, err
 	}
@@ -63,3 +66,11 @@ func (o *ObjectStorageService) Fetch(path string) ([]byte, userland.ObjectMetaDa
 	}
 	return content, metadata, nil
 }
+
+func (o *ObjectStorageService) Delete(ctx context.Context, path string) error {
+	err := o.storageClient.Bucket(o.bucketName).Object(path).Delete(ctx)
+	if err == storage.ErrObjectNotExist {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,38 @@
+// +build integration
+
+package gcs_test
+
+import (
+	"flag"
+	"log"
+	"os"
+	"testing"
+
+	"github.com/joho/godotenv"
+)
+
+var emulatorHost string
+
+func TestMain(m *testing.M) {
+	var envPath string
+	var envPrefix string
+	var yamlPath string
+	flag.StringVar(&envPath, "env-path", ".env", "set env path for test")
+	flag.StringVar(&envPrefix, "env-prefix", "TEST", "set env prefix for test")
+	flag.StringVar(&yamlPath, "config-yaml", ".config.yaml", "set config.yaml for test")
+
+	flag.Parse()
+
+	err := godotenv.Load(envPath)
+	if err != nil {
+		log.Fatalf("godotenv.Load(%q) err = %v; want nil", envPath, err)
+	}
+
+	//the storage client can't be pointed at an emulator by itself, the tests talk to fake-gcs-server instead
+	emulatorHost = os.Getenv("STORAGE_EMULATOR_HOST")
+	if emulatorHost == "" {
+		emulatorHost = "http://localhost:4443"
+	}
+	exitCode := m.Run()
+	os.Exit(exitCode)
+}
@@ -0,0 +1,96 @@
+package maxmind
+
+import (
+	"net"
+
+	"github.com/AdhityaRamadhanus/userland"
+	"github.com/oschwald/maxminddb-golang"
+	"github.com/pkg/errors"
+)
+
+type cityRecord struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+	City struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
+	Location struct {
+		Latitude  float64 `maxminddb:"latitude"`
+		Longitude float64 `maxminddb:"longitude"`
+	} `maxminddb:"location"`
+}
+
+type asnRecord struct {
+	AutonomousSystemNumber int `maxminddb:"autonomous_system_number"`
+}
+
+//GeolocationService implements userland.GeolocationService interface using MaxMind format (MMDB) database files
+type GeolocationService struct {
+	cityReader *maxminddb.Reader
+	asnReader  *maxminddb.Reader
+}
+
+//NewGeolocationService open a city database and an ASN database, either path can be empty to skip it
+func NewGeolocationService(cityDatabasePath string, asnDatabasePath string) (*GeolocationService, error) {
+	geolocationService := &GeolocationService{}
+	if cityDatabasePath != "" {
+		cityReader, err := maxminddb.Open(cityDatabasePath)
+		if err != nil {
+			return nil, errors.Wrapf(err, "maxminddb.Open(%q) err", cityDatabasePath)
+		}
+		geolocationService.cityReader = cityReader
+	}
+
+	if asnDatabasePath != "" {
+		asnReader, err := maxminddb.Open(asnDatabasePath)
+		if err != nil {
+			geolocationService.Close()
+			return nil, errors.Wrapf(err, "maxminddb.Open(%q) err", asnDatabasePath)
+		}
+		geolocationService.asnReader = asnReader
+	}
+
+	return geolocationService, nil
+}
+
+//Lookup locate ip, an address missing from the databases (e.g private ranges) give an empty geolocation
+func (g GeolocationService) Lookup(ip string) (userland.Geolocation, error) {
+	parsedIP := net.ParseIP(ip)
+	if parsedIP == nil {
+		return userland.Geolocation{}, errors.Errorf("invalid ip address %q", ip)
+	}
+
+	geolocation := userland.Geolocation{}
+	if g.cityReader != nil {
+		record := cityRecord{}
+		if err := g.cityReader.Lookup(parsedIP, &record); err != nil {
+			return userland.Geolocation{}, errors.Wrap(err, "cityReader.Lookup() err")
+		}
+		geolocation.Country = record.Country.ISOCode
+		geolocation.City = record.City.Names["en"]
+		geolocation.Latitude = record.Location.Latitude
+		geolocation.Longitude = record.Location.Longitude
+	}
+
+	if g.asnReader != nil {
+		record := asnRecord{}
+		if err := g.asnReader.Lookup(parsedIP, &record); err != nil {
+			return userland.Geolocation{}, errors.Wrap(err, "asnReader.Lookup() err")
+		}
+		geolocation.ASN = record.AutonomousSystemNumber
+	}
+
+	return geolocation, nil
+}
+
+//Close release the database files
+func (g GeolocationService) Close() error {
+	if g.cityReader != nil {
+		g.cityReader.Close()
+	}
+	if g.asnReader != nil {
+		g.asnReader.Close()
+	}
+	return nil
+}
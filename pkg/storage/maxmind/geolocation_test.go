@@ -0,0 +1,132 @@
+//+build unit
+
+package maxmind_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"math"
+	"os"
+	"testing"
+
+	"github.com/AdhityaRamadhanus/userland"
+	"github.com/AdhityaRamadhanus/userland/pkg/storage/maxmind"
+)
+
+// mmdb data section encoding, see https://maxmind.github.io/MaxMind-DB/
+func mmdbString(s string) []byte {
+	return append([]byte{byte(2<<5 | len(s))}, s...)
+}
+
+func mmdbUint16(n uint16) []byte {
+	return []byte{byte(5<<5 | 2), byte(n >> 8), byte(n)}
+}
+
+func mmdbDouble(f float64) []byte {
+	encoded := make([]byte, 9)
+	encoded[0] = byte(3<<5 | 8)
+	binary.BigEndian.PutUint64(encoded[1:], math.Float64bits(f))
+	return encoded
+}
+
+func mmdbMap(pairs ...[]byte) []byte {
+	encoded := []byte{byte(7<<5 | len(pairs)/2)}
+	for _, pair := range pairs {
+		encoded = append(encoded, pair...)
+	}
+	return encoded
+}
+
+func mmdbArray(items ...[]byte) []byte {
+	encoded := []byte{byte(len(items)), 11 - 7}
+	for _, item := range items {
+		encoded = append(encoded, item...)
+	}
+	return encoded
+}
+
+//writeTestDatabase write an ipv4 database with a single node, 0.0.0.0/1 resolve to record and 128.0.0.0/1 is not found
+func writeTestDatabase(t *testing.T) string {
+	record := mmdbMap(
+		mmdbString("country"), mmdbMap(mmdbString("iso_code"), mmdbString("ID")),
+		mmdbString("city"), mmdbMap(mmdbString("names"), mmdbMap(mmdbString("en"), mmdbString("Jakarta"))),
+		mmdbString("location"), mmdbMap(mmdbString("latitude"), mmdbDouble(-6.2), mmdbString("longitude"), mmdbDouble(106.8)),
+		mmdbString("autonomous_system_number"), mmdbUint16(7713),
+	)
+	metadata := mmdbMap(
+		mmdbString("node_count"), mmdbUint16(1),
+		mmdbString("record_size"), mmdbUint16(24),
+		mmdbString("ip_version"), mmdbUint16(4),
+		mmdbString("database_type"), mmdbString("Userland-Test"),
+		mmdbString("languages"), mmdbArray(mmdbString("en")),
+		mmdbString("binary_format_major_version"), mmdbUint16(2),
+		mmdbString("binary_format_minor_version"), mmdbUint16(0),
+		mmdbString("build_epoch"), mmdbUint16(0),
+		mmdbString("description"), mmdbMap(mmdbString("en"), mmdbString("test")),
+	)
+
+	database := bytes.Buffer{}
+	// left record point to the data section (node count + 16), right record is node count meaning not found
+	database.Write([]byte{0, 0, 17, 0, 0, 1})
+	database.Write(make([]byte, 16))
+	database.Write(record)
+	database.WriteString("\xab\xcd\xefMaxMind.com")
+	database.Write(metadata)
+
+	file, err := ioutil.TempFile("", "userland-*.mmdb")
+	if err != nil {
+		t.Fatalf("ioutil.TempFile() err = %v; want nil", err)
+	}
+	defer file.Close()
+	if _, err := file.Write(database.Bytes()); err != nil {
+		t.Fatalf("file.Write() err = %v; want nil", err)
+	}
+	return file.Name()
+}
+
+func TestGeolocationService_Lookup(t *testing.T) {
+	databasePath := writeTestDatabase(t)
+	defer os.Remove(databasePath)
+
+	geolocationService, err := maxmind.NewGeolocationService(databasePath, databasePath)
+	if err != nil {
+		t.Fatalf("maxmind.NewGeolocationService() err = %v; want nil", err)
+	}
+	defer geolocationService.Close()
+
+	testCases := []struct {
+		name            string
+		ip              string
+		wantGeolocation userland.Geolocation
+		wantErr         bool
+	}{
+		{
+			name:            "located ip",
+			ip:              "36.72.10.1",
+			wantGeolocation: userland.Geolocation{Country: "ID", City: "Jakarta", ASN: 7713, Latitude: -6.2, Longitude: 106.8},
+		},
+		{
+			name:            "unknown ip",
+			ip:              "192.168.1.1",
+			wantGeolocation: userland.Geolocation{},
+		},
+		{
+			name:    "invalid ip",
+			ip:      "not-an-ip",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			geolocation, err := geolocationService.Lookup(tc.ip)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("GeolocationService.Lookup(%q) err = %v; want err %t", tc.ip, err, tc.wantErr)
+			}
+			if geolocation != tc.wantGeolocation {
+				t.Errorf("GeolocationService.Lookup(%q) = %+v; want %+v", tc.ip, geolocation, tc.wantGeolocation)
+			}
+		})
+	}
+}
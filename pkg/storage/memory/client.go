@@ -0,0 +1,90 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/AdhityaRamadhanus/userland"
+)
+
+//ClientRepository implements userland.ClientRepository interface in memory
+type ClientRepository struct {
+	mutex   sync.RWMutex
+	clients map[int]userland.Client
+	nextID  int
+}
+
+//NewClientRepository construct an empty ClientRepository
+func NewClientRepository() *ClientRepository {
+	return &ClientRepository{
+		clients: map[int]userland.Client{},
+		nextID:  1,
+	}
+}
+
+//Find Client by id
+func (c *ClientRepository) Find(ctx context.Context, id int) (userland.Client, error) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	client, ok := c.clients[id]
+	if !ok {
+		return userland.Client{}, userland.ErrClientNotFound
+	}
+	return copyClient(client), nil
+}
+
+//FindAll registered clients ordered by id
+func (c *ClientRepository) FindAll(ctx context.Context) (userland.Clients, error) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	clients := userland.Clients{}
+	for _, client := range c.clients {
+		clients = append(clients, copyClient(client))
+	}
+	sort.Slice(clients, func(i, j int) bool {
+		return clients[i].ID < clients[j].ID
+	})
+	return clients, nil
+}
+
+//Insert insert client and set its id
+func (c *ClientRepository) Insert(ctx context.Context, client *userland.Client) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	now := time.Now()
+	client.ID = c.nextID
+	client.CreatedAt = now
+	client.UpdatedAt = now
+	c.nextID++
+	c.clients[client.ID] = copyClient(*client)
+	return nil
+}
+
+//Update update name, allowed origins, status and secret of a client
+func (c *ClientRepository) Update(ctx context.Context, client userland.Client) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	stored, ok := c.clients[client.ID]
+	if !ok {
+		return userland.ErrClientNotFound
+	}
+
+	stored.Name = client.Name
+	stored.SecretHash = client.SecretHash
+	stored.AllowedOrigins = client.AllowedOrigins
+	stored.Status = client.Status
+	stored.UpdatedAt = time.Now()
+	c.clients[client.ID] = copyClient(stored)
+	return nil
+}
+
+func copyClient(client userland.Client) userland.Client {
+	client.AllowedOrigins = append([]string(nil), client.AllowedOrigins...)
+	return client
+}
@@ -0,0 +1,47 @@
+//+build unit
+
+package memory_test
+
+import (
+	"testing"
+
+	"github.com/AdhityaRamadhanus/userland"
+	"github.com/AdhityaRamadhanus/userland/pkg/storage/memory"
+	"github.com/AdhityaRamadhanus/userland/pkg/userlandtest"
+)
+
+func TestUserRepository_contract(t *testing.T) {
+	userlandtest.RunUserRepositoryContract(t, func(t *testing.T) userland.UserRepository {
+		return memory.NewUserRepository()
+	})
+}
+
+func TestEventRepository_contract(t *testing.T) {
+	userlandtest.RunEventRepositoryContract(t, func(t *testing.T) userland.EventRepository {
+		return memory.NewEventRepository()
+	})
+}
+
+func TestSessionRepository_contract(t *testing.T) {
+	userlandtest.RunSessionRepositoryContract(t, func(t *testing.T) userland.SessionRepository {
+		return memory.NewSessionRepository()
+	})
+}
+
+func TestDataExportRepository_contract(t *testing.T) {
+	userlandtest.RunDataExportRepositoryContract(t, func(t *testing.T) userland.DataExportRepository {
+		return memory.NewDataExportRepository()
+	})
+}
+
+func TestKeyValueService_contract(t *testing.T) {
+	userlandtest.RunKeyValueServiceContract(t, func(t *testing.T) userland.KeyValueService {
+		return memory.NewKeyValueService()
+	})
+}
+
+func TestObjectStorageService_contract(t *testing.T) {
+	userlandtest.RunObjectStorageServiceContract(t, func(t *testing.T) userland.ObjectStorageService {
+		return memory.NewObjectStorageService("memory://objects")
+	})
+}
@@ -0,0 +1,122 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/AdhityaRamadhanus/userland"
+)
+
+//DataExportRepository implements userland.DataExportRepository interface in memory
+type DataExportRepository struct {
+	mutex       sync.RWMutex
+	dataExports map[int]userland.DataExport
+	nextID      int
+}
+
+//NewDataExportRepository construct an empty DataExportRepository
+func NewDataExportRepository() *DataExportRepository {
+	return &DataExportRepository{
+		dataExports: map[int]userland.DataExport{},
+		nextID:      1,
+	}
+}
+
+//Insert record a pending data export and set its id
+func (d *DataExportRepository) Insert(ctx context.Context, dataExport *userland.DataExport) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	dataExport.ID = d.nextID
+	dataExport.RequestedAt = time.Now()
+	d.nextID++
+
+	d.dataExports[dataExport.ID] = userland.DataExport{
+		ID:          dataExport.ID,
+		UserID:      dataExport.UserID,
+		RequestedAt: dataExport.RequestedAt,
+	}
+	return nil
+}
+
+//FindAllPending return exports not completed yet and not claimed since startedBefore, oldest first
+func (d *DataExportRepository) FindAllPending(ctx context.Context, startedBefore time.Time) (userland.DataExports, error) {
+	return d.findAll(func(dataExport userland.DataExport) bool {
+		return isPending(dataExport, startedBefore)
+	}), nil
+}
+
+//Claim mark a pending export as started
+func (d *DataExportRepository) Claim(ctx context.Context, id int, startedBefore time.Time) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	dataExport, ok := d.dataExports[id]
+	if !ok || !isPending(dataExport, startedBefore) {
+		return userland.ErrDataExportNotFound
+	}
+	dataExport.StartedAt = time.Now()
+	d.dataExports[id] = dataExport
+	return nil
+}
+
+//Complete set the archive path and link expiration of an export
+func (d *DataExportRepository) Complete(ctx context.Context, id int, path string, expiredAt time.Time) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	dataExport, ok := d.dataExports[id]
+	if !ok {
+		return userland.ErrDataExportNotFound
+	}
+	dataExport.Path = path
+	dataExport.CompletedAt = time.Now()
+	dataExport.ExpiredAt = expiredAt
+	d.dataExports[id] = dataExport
+	return nil
+}
+
+//FindAllExpired return completed exports whose download link expired before expiredBefore
+func (d *DataExportRepository) FindAllExpired(ctx context.Context, expiredBefore time.Time) (userland.DataExports, error) {
+	return d.findAll(func(dataExport userland.DataExport) bool {
+		return !dataExport.CompletedAt.IsZero() && dataExport.ExpiredAt.Before(expiredBefore)
+	}), nil
+}
+
+//FindAllByUserID return every export of a user, oldest first
+func (d *DataExportRepository) FindAllByUserID(ctx context.Context, userID int) (userland.DataExports, error) {
+	return d.findAll(func(dataExport userland.DataExport) bool {
+		return dataExport.UserID == userID
+	}), nil
+}
+
+//Delete remove an export, a missing one is not an error
+func (d *DataExportRepository) Delete(ctx context.Context, id int) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	delete(d.dataExports, id)
+	return nil
+}
+
+func (d *DataExportRepository) findAll(match func(dataExport userland.DataExport) bool) userland.DataExports {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	dataExports := userland.DataExports{}
+	for _, dataExport := range d.dataExports {
+		if match(dataExport) {
+			dataExports = append(dataExports, dataExport)
+		}
+	}
+	sort.Slice(dataExports, func(i, j int) bool {
+		return dataExports[i].ID < dataExports[j].ID
+	})
+	return dataExports
+}
+
+func isPending(dataExport userland.DataExport, startedBefore time.Time) bool {
+	return dataExport.CompletedAt.IsZero() && (dataExport.StartedAt.IsZero() || dataExport.StartedAt.Before(startedBefore))
+}
@@ -0,0 +1,101 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/AdhityaRamadhanus/userland"
+)
+
+//EventRepository implements userland.EventRepository interface in memory
+type EventRepository struct {
+	mutex  sync.RWMutex
+	events userland.Events
+	nextID int
+}
+
+//NewEventRepository construct an empty EventRepository
+func NewEventRepository() *EventRepository {
+	return &EventRepository{
+		events: userland.Events{},
+		nextID: 1,
+	}
+}
+
+//FindAll return a page of events matching filter and the count of all matching events,
+//events can be sorted by id, timestamp or created_at
+func (e *EventRepository) FindAll(ctx context.Context, filter userland.EventFilterOptions, paging userland.EventPagingOptions) (userland.Events, int, error) {
+	e.mutex.RLock()
+	matched := userland.Events{}
+	for _, event := range e.events {
+		if filter.UserID > 0 && event.UserID != filter.UserID {
+			continue
+		}
+		if len(filter.IP) > 0 && event.IP != filter.IP {
+			continue
+		}
+		if len(filter.Event) > 0 && event.Event != filter.Event {
+			continue
+		}
+		matched = append(matched, event)
+	}
+	e.mutex.RUnlock()
+
+	less := func(i, j int) bool {
+		switch paging.SortBy {
+		case "timestamp":
+			return matched[i].Timestamp.Before(matched[j].Timestamp)
+		case "created_at":
+			return matched[i].CreatedAt.Before(matched[j].CreatedAt)
+		default:
+			return matched[i].ID < matched[j].ID
+		}
+	}
+	if paging.Order == "desc" {
+		sort.SliceStable(matched, func(i, j int) bool { return less(j, i) })
+	} else {
+		sort.SliceStable(matched, less)
+	}
+
+	eventsCount := len(matched)
+	start := paging.Offset
+	if start > eventsCount {
+		start = eventsCount
+	}
+	end := eventsCount
+	if paging.Limit > 0 && start+paging.Limit < end {
+		end = start + paging.Limit
+	}
+
+	return matched[start:end], eventsCount, nil
+}
+
+//Insert record event
+func (e *EventRepository) Insert(ctx context.Context, event userland.Event) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	event.ID = e.nextID
+	event.ChangedFields = append([]string(nil), event.ChangedFields...)
+	event.CreatedAt = time.Now()
+	e.nextID++
+	e.events = append(e.events, event)
+	return nil
+}
+
+//DeleteAllByUserID delete every event of user
+func (e *EventRepository) DeleteAllByUserID(ctx context.Context, userID int) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	kept := userland.Events{}
+	for _, event := range e.events {
+		if event.UserID != userID {
+			kept = append(kept, event)
+		}
+	}
+	e.events = kept
+	return nil
+}
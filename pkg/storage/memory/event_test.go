@@ -0,0 +1,43 @@
+//+build unit
+
+package memory_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/AdhityaRamadhanus/userland"
+	"github.com/AdhityaRamadhanus/userland/pkg/storage/memory"
+)
+
+func TestEventRepository_FindAll(t *testing.T) {
+	eventRepository := memory.NewEventRepository()
+	now := time.Now()
+	for i := 0; i < 5; i++ {
+		eventRepository.Insert(context.Background(), userland.Event{UserID: 1, Event: "user.login", Timestamp: now.Add(time.Duration(i) * time.Minute)})
+	}
+	eventRepository.Insert(context.Background(), userland.Event{UserID: 2, Event: "user.login", Timestamp: now})
+
+	events, count, err := eventRepository.FindAll(context.Background(),
+		userland.EventFilterOptions{UserID: 1},
+		userland.EventPagingOptions{Limit: 2, Offset: 1, SortBy: "timestamp", Order: "desc"},
+	)
+	if err != nil {
+		t.Fatalf("eventRepository.FindAll() err = %v; want nil", err)
+	}
+	if count != 5 {
+		t.Errorf("eventRepository.FindAll() count = %d; want 5", count)
+	}
+	if len(events) != 2 || !events[0].Timestamp.Equal(now.Add(3*time.Minute)) || !events[1].Timestamp.Equal(now.Add(2*time.Minute)) {
+		t.Errorf("eventRepository.FindAll() = %+v; want second and third newest events", events)
+	}
+
+	if err := eventRepository.DeleteAllByUserID(context.Background(), 1); err != nil {
+		t.Fatalf("eventRepository.DeleteAllByUserID() err = %v; want nil", err)
+	}
+	_, count, _ = eventRepository.FindAll(context.Background(), userland.EventFilterOptions{}, userland.EventPagingOptions{Limit: 10})
+	if count != 1 {
+		t.Errorf("eventRepository.FindAll() count = %d after deleting user events; want 1", count)
+	}
+}
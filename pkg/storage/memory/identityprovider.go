@@ -0,0 +1,111 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/AdhityaRamadhanus/userland"
+)
+
+//IdentityProviderRepository implements userland.IdentityProviderRepository interface in memory, tenant is unique
+type IdentityProviderRepository struct {
+	mutex             sync.RWMutex
+	identityProviders map[string]userland.IdentityProvider
+	links             map[identityProviderLink]int
+	nextID            int
+}
+
+type identityProviderLink struct {
+	identityProviderID int
+	nameID             string
+}
+
+//NewIdentityProviderRepository construct an empty IdentityProviderRepository
+func NewIdentityProviderRepository() *IdentityProviderRepository {
+	return &IdentityProviderRepository{
+		identityProviders: map[string]userland.IdentityProvider{},
+		links:             map[identityProviderLink]int{},
+		nextID:            1,
+	}
+}
+
+//FindByTenant IdentityProvider by tenant
+func (i *IdentityProviderRepository) FindByTenant(ctx context.Context, tenant string) (userland.IdentityProvider, error) {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
+	identityProvider, ok := i.identityProviders[tenant]
+	if !ok {
+		return userland.IdentityProvider{}, userland.ErrIdentityProviderNotFound
+	}
+	return copyIdentityProvider(identityProvider), nil
+}
+
+//Insert insert identity provider and set its id
+func (i *IdentityProviderRepository) Insert(ctx context.Context, identityProvider *userland.IdentityProvider) error {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	if _, ok := i.identityProviders[identityProvider.Tenant]; ok {
+		return userland.ErrDuplicateKey
+	}
+
+	now := time.Now()
+	identityProvider.ID = i.nextID
+	identityProvider.CreatedAt = now
+	identityProvider.UpdatedAt = now
+	i.nextID++
+	i.identityProviders[identityProvider.Tenant] = copyIdentityProvider(*identityProvider)
+	return nil
+}
+
+//Update update identity provider of a tenant
+func (i *IdentityProviderRepository) Update(ctx context.Context, identityProvider userland.IdentityProvider) error {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	stored, ok := i.identityProviders[identityProvider.Tenant]
+	if !ok {
+		return userland.ErrIdentityProviderNotFound
+	}
+
+	identityProvider = copyIdentityProvider(identityProvider)
+	identityProvider.ID = stored.ID
+	identityProvider.CreatedAt = stored.CreatedAt
+	identityProvider.UpdatedAt = time.Now()
+	i.identityProviders[identityProvider.Tenant] = identityProvider
+	return nil
+}
+
+//FindLinkedUserID find the user linked to a subject of identity provider
+func (i *IdentityProviderRepository) FindLinkedUserID(ctx context.Context, identityProviderID int, nameID string) (int, error) {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
+	userID, ok := i.links[identityProviderLink{identityProviderID: identityProviderID, nameID: nameID}]
+	if !ok {
+		return 0, userland.ErrIdentityProviderLinkNotFound
+	}
+	return userID, nil
+}
+
+//Link link a subject of identity provider to user
+func (i *IdentityProviderRepository) Link(ctx context.Context, identityProviderID int, nameID string, userID int) error {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	i.links[identityProviderLink{identityProviderID: identityProviderID, nameID: nameID}] = userID
+	return nil
+}
+
+func copyIdentityProvider(identityProvider userland.IdentityProvider) userland.IdentityProvider {
+	identityProvider.Certificates = append([]string(nil), identityProvider.Certificates...)
+	identityProvider.Domains = append([]string(nil), identityProvider.Domains...)
+	attributeMapping := map[string]string{}
+	for attribute, mapped := range identityProvider.AttributeMapping {
+		attributeMapping[attribute] = mapped
+	}
+	identityProvider.AttributeMapping = attributeMapping
+	return identityProvider
+}
@@ -0,0 +1,133 @@
+package memory
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/AdhityaRamadhanus/userland"
+	"github.com/pkg/errors"
+)
+
+type keyValueEntry struct {
+	value     []byte
+	expiredAt time.Time // zero when the key never expire
+}
+
+func (e keyValueEntry) expired(now time.Time) bool {
+	return !e.expiredAt.IsZero() && !now.Before(e.expiredAt)
+}
+
+//KeyValueService implements userland.KeyValueService interface in memory, expired keys are removed lazily on access
+type KeyValueService struct {
+	mutex   sync.Mutex
+	entries map[string]keyValueEntry
+}
+
+//NewKeyValueService construct an empty KeyValueService
+func NewKeyValueService() *KeyValueService {
+	return &KeyValueService{
+		entries: map[string]keyValueEntry{},
+	}
+}
+
+//Get value of a key
+func (k *KeyValueService) Get(ctx context.Context, key string) ([]byte, error) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	entry, ok := k.entries[key]
+	if !ok {
+		return nil, userland.ErrKeyNotFound
+	}
+	if entry.expired(time.Now()) {
+		delete(k.entries, key)
+		return nil, userland.ErrKeyNotFound
+	}
+	return append([]byte(nil), entry.value...), nil
+}
+
+//Set value of a key without expiration
+func (k *KeyValueService) Set(ctx context.Context, key string, value []byte) error {
+	return k.SetEx(ctx, key, value, 0)
+}
+
+//Delete a key
+func (k *KeyValueService) Delete(ctx context.Context, key string) error {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	delete(k.entries, key)
+	return nil
+}
+
+//SetEx set value of a key expiring after expiration, zero expiration never expire
+func (k *KeyValueService) SetEx(ctx context.Context, key string, value []byte, expiration time.Duration) error {
+	entry := keyValueEntry{value: append([]byte(nil), value...)}
+	if expiration > 0 {
+		entry.expiredAt = time.Now().Add(expiration)
+	}
+
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+	k.entries[key] = entry
+	return nil
+}
+
+//Expire set a new expiration of an existing key
+func (k *KeyValueService) Expire(ctx context.Context, key string, expiration time.Duration) error {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	now := time.Now()
+	entry, ok := k.entries[key]
+	if !ok || entry.expired(now) {
+		delete(k.entries, key)
+		return userland.ErrKeyNotFound
+	}
+	entry.expiredAt = now.Add(expiration)
+	k.entries[key] = entry
+	return nil
+}
+
+//SetNX set value of a key expiring after expiration only when the key doesn't exist
+func (k *KeyValueService) SetNX(ctx context.Context, key string, value []byte, expiration time.Duration) (bool, error) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	now := time.Now()
+	if entry, ok := k.entries[key]; ok && !entry.expired(now) {
+		return false, nil
+	}
+	entry := keyValueEntry{value: append([]byte(nil), value...)}
+	if expiration > 0 {
+		entry.expiredAt = now.Add(expiration)
+	}
+	k.entries[key] = entry
+	return true, nil
+}
+
+//Incr increment the integer value of a key and make it expire after expiration, zero expiration never expire
+func (k *KeyValueService) Incr(ctx context.Context, key string, expiration time.Duration) (int64, error) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	now := time.Now()
+	value := int64(0)
+	if entry, ok := k.entries[key]; ok && !entry.expired(now) {
+		var err error
+		value, err = strconv.ParseInt(string(entry.value), 10, 64)
+		if err != nil {
+			return 0, errors.Wrapf(err, "Incr(%q) value is not an integer", key)
+		}
+	}
+	value++
+
+	entry := keyValueEntry{value: []byte(strconv.FormatInt(value, 10))}
+	if expiration > 0 {
+		entry.expiredAt = now.Add(expiration)
+	}
+	k.entries[key] = entry
+	return value, nil
+}
@@ -0,0 +1,47 @@
+//+build unit
+
+package memory_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/AdhityaRamadhanus/userland"
+	"github.com/AdhityaRamadhanus/userland/pkg/storage/memory"
+)
+
+func TestKeyValueService_expiration(t *testing.T) {
+	keyValueService := memory.NewKeyValueService()
+	keyValueService.Set(context.Background(), "persistent", []byte("value"))
+	keyValueService.SetEx(context.Background(), "expiring", []byte("value"), 20*time.Millisecond)
+	keyValueService.SetEx(context.Background(), "extended", []byte("value"), 20*time.Millisecond)
+	if err := keyValueService.Expire(context.Background(), "extended", time.Minute); err != nil {
+		t.Fatalf("keyValueService.Expire(context.Background(), extended) err = %v; want nil", err)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+
+	testCases := []struct {
+		key     string
+		wantErr error
+	}{
+		{key: "persistent"},
+		{key: "expiring", wantErr: userland.ErrKeyNotFound},
+		{key: "extended"},
+		{key: "missing", wantErr: userland.ErrKeyNotFound},
+	}
+	for _, tc := range testCases {
+		value, err := keyValueService.Get(context.Background(), tc.key)
+		if err != tc.wantErr {
+			t.Errorf("keyValueService.Get(%q) err = %v; want %v", tc.key, err, tc.wantErr)
+		}
+		if tc.wantErr == nil && string(value) != "value" {
+			t.Errorf("keyValueService.Get(%q) = %q; want %q", tc.key, value, "value")
+		}
+	}
+
+	if err := keyValueService.Expire(context.Background(), "expiring", time.Minute); err != userland.ErrKeyNotFound {
+		t.Errorf("keyValueService.Expire(expiring) err = %v; want %v", err, userland.ErrKeyNotFound)
+	}
+}
@@ -0,0 +1,54 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/AdhityaRamadhanus/userland"
+)
+
+//LoginRiskAssessmentRepository implements userland.LoginRiskAssessmentRepository interface in memory
+type LoginRiskAssessmentRepository struct {
+	mutex       sync.Mutex
+	assessments []userland.LoginRiskAssessment
+	nextID      int
+}
+
+//NewLoginRiskAssessmentRepository construct an empty LoginRiskAssessmentRepository
+func NewLoginRiskAssessmentRepository() *LoginRiskAssessmentRepository {
+	return &LoginRiskAssessmentRepository{
+		assessments: []userland.LoginRiskAssessment{},
+		nextID:      1,
+	}
+}
+
+//Insert record login risk assessment and set its id
+func (l *LoginRiskAssessmentRepository) Insert(ctx context.Context, assessment *userland.LoginRiskAssessment) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	assessment.ID = l.nextID
+	assessment.CreatedAt = time.Now()
+	l.nextID++
+
+	stored := *assessment
+	stored.Signals = append([]string(nil), assessment.Signals...)
+	l.assessments = append(l.assessments, stored)
+	return nil
+}
+
+//DeleteAllByUserID remove every assessment of a user
+func (l *LoginRiskAssessmentRepository) DeleteAllByUserID(ctx context.Context, userID int) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	assessments := []userland.LoginRiskAssessment{}
+	for _, assessment := range l.assessments {
+		if assessment.UserID != userID {
+			assessments = append(assessments, assessment)
+		}
+	}
+	l.assessments = assessments
+	return nil
+}
@@ -0,0 +1,69 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sync"
+
+	"github.com/AdhityaRamadhanus/userland"
+	"github.com/pkg/errors"
+)
+
+var (
+	//ErrObjectNotFound represent object is not found when fetching from ObjectStorageService
+	ErrObjectNotFound = errors.New("Object not found")
+)
+
+type object struct {
+	content  []byte
+	metadata userland.ObjectMetaData
+}
+
+//ObjectStorageService implements userland.ObjectStorageService interface in memory
+type ObjectStorageService struct {
+	mutex   sync.RWMutex
+	objects map[string]object
+	baseURL string
+}
+
+//NewObjectStorageService construct an empty ObjectStorageService, links of written objects are baseURL followed by their path
+func NewObjectStorageService(baseURL string) *ObjectStorageService {
+	return &ObjectStorageService{
+		objects: map[string]object{},
+		baseURL: baseURL,
+	}
+}
+
+func (o *ObjectStorageService) Write(ctx context.Context, reader io.Reader, metadata userland.ObjectMetaData) (string, error) {
+	content, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return "", errors.Wrap(err, "ioutil.ReadAll(reader) err")
+	}
+	metadata.Size = int64(len(content))
+
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	o.objects[metadata.Path] = object{content: content, metadata: metadata}
+	return fmt.Sprintf("%s/%s", o.baseURL, metadata.Path), nil
+}
+
+func (o *ObjectStorageService) Fetch(ctx context.Context, path string) ([]byte, userland.ObjectMetaData, error) {
+	o.mutex.RLock()
+	defer o.mutex.RUnlock()
+
+	object, ok := o.objects[path]
+	if !ok {
+		return nil, userland.ObjectMetaData{}, ErrObjectNotFound
+	}
+	return append([]byte(nil), object.content...), object.metadata, nil
+}
+
+func (o *ObjectStorageService) Delete(ctx context.Context, path string) error {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	delete(o.objects, path)
+	return nil
+}
@@ -0,0 +1,68 @@
+package memory
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/AdhityaRamadhanus/userland"
+	"github.com/pkg/errors"
+)
+
+//RateLimitService implements userland.RateLimitService interface in memory with the same GCRA as the redis one,
+//counters are local to the process
+type RateLimitService struct {
+	mutex sync.Mutex
+	// theoretical arrival time of the next request at the sustained rate, by key
+	arrivals map[string]time.Time
+}
+
+//NewRateLimitService construct a RateLimitService without any counted request
+func NewRateLimitService() *RateLimitService {
+	return &RateLimitService{
+		arrivals: map[string]time.Time{},
+	}
+}
+
+func (r *RateLimitService) Allow(ctx context.Context, key string, rateLimit userland.RateLimit) (userland.RateLimitResult, error) {
+	if rateLimit.Limit <= 0 || rateLimit.Window <= 0 {
+		return userland.RateLimitResult{}, errors.Errorf("invalid rate limit %d per %s", rateLimit.Limit, rateLimit.Window)
+	}
+	burst := rateLimit.Burst
+	if burst <= 0 {
+		burst = rateLimit.Limit
+	}
+	emissionInterval := rateLimit.Window / time.Duration(rateLimit.Limit)
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	now := time.Now()
+	// forget keys whose burst is full again
+	for arrivalKey, arrival := range r.arrivals {
+		if arrival.Before(now) {
+			delete(r.arrivals, arrivalKey)
+		}
+	}
+
+	tat, ok := r.arrivals[key]
+	if !ok {
+		tat = now
+	}
+	newTat := tat.Add(emissionInterval)
+	diff := now.Sub(newTat.Add(-emissionInterval * time.Duration(burst)))
+	if diff < 0 {
+		return userland.RateLimitResult{
+			RetryAfter: -diff,
+			ResetAfter: tat.Sub(now),
+		}, nil
+	}
+
+	r.arrivals[key] = newTat
+	return userland.RateLimitResult{
+		Allowed:    true,
+		Remaining:  int(math.Floor(float64(diff) / float64(emissionInterval))),
+		ResetAfter: newTat.Sub(now),
+	}, nil
+}
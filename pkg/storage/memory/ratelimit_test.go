@@ -0,0 +1,39 @@
+//+build unit
+
+package memory_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/AdhityaRamadhanus/userland"
+	"github.com/AdhityaRamadhanus/userland/pkg/storage/memory"
+)
+
+func TestRateLimitService_Allow(t *testing.T) {
+	rateLimitService := memory.NewRateLimitService()
+	rateLimit := userland.RateLimit{Limit: 10, Burst: 3, Window: time.Minute}
+
+	for i := 0; i < rateLimit.Burst; i++ {
+		result, err := rateLimitService.Allow(context.Background(), "login:ip:127.0.0.1", rateLimit)
+		if err != nil || !result.Allowed {
+			t.Fatalf("rateLimitService.Allow() request %d = %+v, %v; want allowed", i, result, err)
+		}
+		if result.Remaining != rateLimit.Burst-i-1 {
+			t.Errorf("rateLimitService.Allow(context.Background()) request %d remaining = %d; want %d", i, result.Remaining, rateLimit.Burst-i-1)
+		}
+	}
+
+	result, err := rateLimitService.Allow(context.Background(), "login:ip:127.0.0.1", rateLimit)
+	if err != nil || result.Allowed {
+		t.Fatalf("rateLimitService.Allow() over burst = %+v, %v; want throttled", result, err)
+	}
+	if result.RetryAfter <= 0 || result.RetryAfter > 6*time.Second {
+		t.Errorf("rateLimitService.Allow(context.Background()) RetryAfter = %s; want within one emission interval", result.RetryAfter)
+	}
+
+	if result, _ := rateLimitService.Allow(context.Background(), "login:ip:127.0.0.2", rateLimit); !result.Allowed {
+		t.Errorf("rateLimitService.Allow() other key = %+v; want allowed", result)
+	}
+}
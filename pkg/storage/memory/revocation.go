@@ -0,0 +1,99 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/AdhityaRamadhanus/userland"
+	"github.com/AdhityaRamadhanus/userland/pkg/common/security"
+)
+
+//RevocationService implements userland.RevocationService interface in memory, revocations only reach subscribers of the same process
+type RevocationService struct {
+	mutex         sync.Mutex
+	revocations   userland.SessionRevocations
+	subscriptions map[*revocationSubscription]bool
+	retention     time.Duration
+}
+
+//NewRevocationService construct a RevocationService without revocations
+func NewRevocationService() *RevocationService {
+	return &RevocationService{
+		revocations:   userland.SessionRevocations{},
+		subscriptions: map[*revocationSubscription]bool{},
+		retention:     security.RefreshAccessTokenExpiration,
+	}
+}
+
+func (r *RevocationService) Publish(ctx context.Context, revocation userland.SessionRevocation) error {
+	if revocation.RevokedAt.IsZero() {
+		revocation.RevokedAt = time.Now()
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	retainedSince := time.Now().Add(-r.retention)
+	retained := userland.SessionRevocations{}
+	for _, existing := range r.revocations {
+		if !existing.RevokedAt.Before(retainedSince) {
+			retained = append(retained, existing)
+		}
+	}
+	r.revocations = append(retained, revocation)
+
+	for subscription := range r.subscriptions {
+		// like redis pub/sub, a subscriber too slow to keep up miss the message
+		select {
+		case subscription.revocations <- revocation:
+		default:
+		}
+	}
+	return nil
+}
+
+func (r *RevocationService) FindAllSince(ctx context.Context, since time.Time) (userland.SessionRevocations, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	revocations := userland.SessionRevocations{}
+	for _, revocation := range r.revocations {
+		if !revocation.RevokedAt.Before(since) {
+			revocations = append(revocations, revocation)
+		}
+	}
+	return revocations, nil
+}
+
+func (r *RevocationService) Subscribe(ctx context.Context) (userland.RevocationSubscription, error) {
+	subscription := &revocationSubscription{
+		service:     r,
+		revocations: make(chan userland.SessionRevocation, 64),
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.subscriptions[subscription] = true
+	return subscription, nil
+}
+
+type revocationSubscription struct {
+	service     *RevocationService
+	revocations chan userland.SessionRevocation
+	closeOnce   sync.Once
+}
+
+func (s *revocationSubscription) Revocations() <-chan userland.SessionRevocation {
+	return s.revocations
+}
+
+func (s *revocationSubscription) Close() error {
+	s.closeOnce.Do(func() {
+		s.service.mutex.Lock()
+		defer s.service.mutex.Unlock()
+		delete(s.service.subscriptions, s)
+		close(s.revocations)
+	})
+	return nil
+}
@@ -0,0 +1,44 @@
+//+build unit
+
+package memory_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/AdhityaRamadhanus/userland"
+	"github.com/AdhityaRamadhanus/userland/pkg/storage/memory"
+)
+
+func TestRevocationService(t *testing.T) {
+	revocationService := memory.NewRevocationService()
+	subscription, err := revocationService.Subscribe(context.Background())
+	if err != nil {
+		t.Fatalf("revocationService.Subscribe() err = %v; want nil", err)
+	}
+
+	since := time.Now()
+	revocationService.Publish(context.Background(), userland.SessionRevocation{SessionID: "session", UserID: 1, Reason: userland.SessionEndReasonLogout})
+
+	select {
+	case revocation := <-subscription.Revocations():
+		if revocation.SessionID != "session" {
+			t.Errorf("subscription.Revocations() = %+v; want session revoked", revocation)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("subscription.Revocations() got nothing; want published revocation")
+	}
+
+	revocations, _ := revocationService.FindAllSince(context.Background(), since)
+	if len(revocations) != 1 {
+		t.Errorf("revocationService.FindAllSince() = %+v; want published revocation", revocations)
+	}
+
+	subscription.Close()
+	if _, ok := <-subscription.Revocations(); ok {
+		t.Errorf("subscription.Revocations() open after Close; want closed")
+	}
+	// publishing after every subscriber left must not block or panic
+	revocationService.Publish(context.Background(), userland.SessionRevocation{SessionID: "other"})
+}
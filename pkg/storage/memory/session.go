@@ -0,0 +1,153 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/AdhityaRamadhanus/userland"
+)
+
+//SessionRepository implements userland.SessionRepository interface in memory, sessions are listed by expiration like in redis
+type SessionRepository struct {
+	mutex    sync.RWMutex
+	sessions map[int]map[string]userland.Session
+}
+
+//NewSessionRepository construct an empty SessionRepository
+func NewSessionRepository() *SessionRepository {
+	return &SessionRepository{
+		sessions: map[int]map[string]userland.Session{},
+	}
+}
+
+func (s *SessionRepository) Create(ctx context.Context, userID int, session userland.Session) error {
+	now := time.Now()
+	session.CreatedAt = now
+	session.UpdatedAt = now
+	if session.LastSeenAt.IsZero() {
+		session.LastSeenAt = now
+	}
+	if session.LastSeenIP == "" {
+		session.LastSeenIP = session.IP
+	}
+	if session.ExpiredAt.IsZero() {
+		session.ExpiredAt = now.Add(session.Expiration)
+	}
+	// the token is never stored, same as the other repositories
+	session.Token = ""
+	session.PreviousSessionID = ""
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if _, ok := s.sessions[userID]; !ok {
+		s.sessions[userID] = map[string]userland.Session{}
+	}
+	s.sessions[userID][session.ID] = session
+	return nil
+}
+
+func (s *SessionRepository) Find(ctx context.Context, userID int, sessionID string) (userland.Session, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	session, ok := s.sessions[userID][sessionID]
+	if !ok || time.Now().After(session.ExpiredAt) {
+		return userland.Session{}, userland.ErrSessionNotFound
+	}
+	return session, nil
+}
+
+func (s *SessionRepository) FindAllByUserID(ctx context.Context, userID int) (userland.Sessions, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	now := time.Now()
+	sessions := userland.Sessions{}
+	for _, session := range s.sessions[userID] {
+		// expired sessions are left for DeleteExpiredSessions, same as postgres
+		if now.After(session.ExpiredAt) {
+			continue
+		}
+		sessions = append(sessions, session)
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].ExpiredAt.Before(sessions[j].ExpiredAt)
+	})
+	return sessions, nil
+}
+
+func (s *SessionRepository) Update(ctx context.Context, userID int, session userland.Session) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	stored, ok := s.sessions[userID][session.ID]
+	if !ok {
+		return userland.ErrSessionNotFound
+	}
+	if session.ExpiredAt.IsZero() {
+		session.ExpiredAt = stored.ExpiredAt
+	}
+	session.Token = ""
+	session.PreviousSessionID = ""
+	s.sessions[userID][session.ID] = session
+	return nil
+}
+
+func (s *SessionRepository) DeleteExpiredSessions(ctx context.Context, userID int) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+	for sessionID, session := range s.sessions[userID] {
+		if !session.ExpiredAt.After(now) {
+			delete(s.sessions[userID], sessionID)
+		}
+	}
+	return nil
+}
+
+func (s *SessionRepository) DeleteBySessionID(ctx context.Context, userID int, sessionID string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, ok := s.sessions[userID][sessionID]; !ok {
+		return userland.ErrSessionNotFound
+	}
+	delete(s.sessions[userID], sessionID)
+	return nil
+}
+
+func (s *SessionRepository) DeleteOtherSessions(ctx context.Context, userID int, currentSessionID string) (deletedSessionIDs []string, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	deletedSessionIDs = []string{}
+	for sessionID := range s.sessions[userID] {
+		if sessionID == currentSessionID {
+			continue
+		}
+		delete(s.sessions[userID], sessionID)
+		deletedSessionIDs = append(deletedSessionIDs, sessionID)
+	}
+	return deletedSessionIDs, nil
+}
+
+func (s *SessionRepository) EvictSessions(ctx context.Context, userID int, sessionIDs []string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, sessionID := range sessionIDs {
+		delete(s.sessions[userID], sessionID)
+	}
+	return nil
+}
+
+func (s *SessionRepository) DeleteAllByUserID(ctx context.Context, userID int) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.sessions, userID)
+	return nil
+}
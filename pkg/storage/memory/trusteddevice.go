@@ -0,0 +1,98 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/AdhityaRamadhanus/userland"
+)
+
+//TrustedDeviceRepository implements userland.TrustedDeviceRepository interface in memory, expired devices are dropped when read
+type TrustedDeviceRepository struct {
+	mutex   sync.Mutex
+	devices map[int]map[string]userland.TrustedDevice
+}
+
+//NewTrustedDeviceRepository construct an empty TrustedDeviceRepository
+func NewTrustedDeviceRepository() *TrustedDeviceRepository {
+	return &TrustedDeviceRepository{
+		devices: map[int]map[string]userland.TrustedDevice{},
+	}
+}
+
+func (t *TrustedDeviceRepository) Create(ctx context.Context, userID int, device userland.TrustedDevice) error {
+	now := time.Now()
+	device.CreatedAt = now
+	device.LastUsedAt = now
+	device.ExpiredAt = now.Add(device.Expiration)
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if _, ok := t.devices[userID]; !ok {
+		t.devices[userID] = map[string]userland.TrustedDevice{}
+	}
+	t.devices[userID][device.ID] = device
+	return nil
+}
+
+func (t *TrustedDeviceRepository) Find(ctx context.Context, userID int, deviceID string) (userland.TrustedDevice, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	device, ok := t.devices[userID][deviceID]
+	if !ok {
+		return userland.TrustedDevice{}, userland.ErrTrustedDeviceNotFound
+	}
+	if time.Now().After(device.ExpiredAt) {
+		delete(t.devices[userID], deviceID)
+		return userland.TrustedDevice{}, userland.ErrTrustedDeviceNotFound
+	}
+	return device, nil
+}
+
+func (t *TrustedDeviceRepository) FindAllByUserID(ctx context.Context, userID int) (userland.TrustedDevices, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	now := time.Now()
+	devices := userland.TrustedDevices{}
+	for deviceID, device := range t.devices[userID] {
+		if now.After(device.ExpiredAt) {
+			delete(t.devices[userID], deviceID)
+			continue
+		}
+		devices = append(devices, device)
+	}
+	return devices, nil
+}
+
+func (t *TrustedDeviceRepository) Update(ctx context.Context, userID int, device userland.TrustedDevice) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if _, ok := t.devices[userID][device.ID]; !ok {
+		return userland.ErrTrustedDeviceNotFound
+	}
+	t.devices[userID][device.ID] = device
+	return nil
+}
+
+func (t *TrustedDeviceRepository) Delete(ctx context.Context, userID int, deviceID string) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if _, ok := t.devices[userID][deviceID]; !ok {
+		return userland.ErrTrustedDeviceNotFound
+	}
+	delete(t.devices[userID], deviceID)
+	return nil
+}
+
+func (t *TrustedDeviceRepository) DeleteAllByUserID(ctx context.Context, userID int) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	delete(t.devices, userID)
+	return nil
+}
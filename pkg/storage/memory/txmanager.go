@@ -0,0 +1,148 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/AdhityaRamadhanus/userland"
+)
+
+//TxManager implements userland.TxManager interface in memory, units of work are serialized and the repositories are
+//restored to a snapshot taken before fn when it fails. Writes made outside WithinTx while fn run are lost on rollback too
+type TxManager struct {
+	mutex                         sync.Mutex
+	userRepository                *UserRepository
+	eventRepository               *EventRepository
+	sessionRepository             *SessionRepository
+	loginRiskAssessmentRepository *LoginRiskAssessmentRepository
+}
+
+//NewTxManager construct a TxManager running units of work against the given repositories
+func NewTxManager(userRepository *UserRepository, eventRepository *EventRepository, sessionRepository *SessionRepository, loginRiskAssessmentRepository *LoginRiskAssessmentRepository) *TxManager {
+	return &TxManager{
+		userRepository:                userRepository,
+		eventRepository:               eventRepository,
+		sessionRepository:             sessionRepository,
+		loginRiskAssessmentRepository: loginRiskAssessmentRepository,
+	}
+}
+
+//WithinTx run fn with the in-memory repositories, one unit of work at a time, fn error is returned as is after rolling back
+func (t *TxManager) WithinTx(ctx context.Context, fn func(repositories userland.TxRepositories) error) (err error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	users := t.userRepository.snapshot()
+	events := t.eventRepository.snapshot()
+	sessions := t.sessionRepository.snapshot()
+	assessments := t.loginRiskAssessmentRepository.snapshot()
+	rollback := func() {
+		t.userRepository.restore(users)
+		t.eventRepository.restore(events)
+		t.sessionRepository.restore(sessions)
+		t.loginRiskAssessmentRepository.restore(assessments)
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			rollback()
+			panic(p)
+		}
+	}()
+
+	repositories := userland.TxRepositories{
+		UserRepository:                t.userRepository,
+		EventRepository:               t.eventRepository,
+		SessionRepository:             t.sessionRepository,
+		LoginRiskAssessmentRepository: t.loginRiskAssessmentRepository,
+	}
+	if err := fn(repositories); err != nil {
+		rollback()
+		return err
+	}
+	return nil
+}
+
+type userSnapshot struct {
+	users  map[int]userland.User
+	nextID int
+}
+
+func (u *UserRepository) snapshot() userSnapshot {
+	u.mutex.RLock()
+	defer u.mutex.RUnlock()
+
+	users := make(map[int]userland.User, len(u.users))
+	for id, user := range u.users {
+		users[id] = copyUser(user)
+	}
+	return userSnapshot{users: users, nextID: u.nextID}
+}
+
+func (u *UserRepository) restore(snapshot userSnapshot) {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+
+	u.users = snapshot.users
+	u.nextID = snapshot.nextID
+}
+
+type eventSnapshot struct {
+	events userland.Events
+	nextID int
+}
+
+func (e *EventRepository) snapshot() eventSnapshot {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
+	return eventSnapshot{events: append(userland.Events(nil), e.events...), nextID: e.nextID}
+}
+
+func (e *EventRepository) restore(snapshot eventSnapshot) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	e.events = snapshot.events
+	e.nextID = snapshot.nextID
+}
+
+func (s *SessionRepository) snapshot() map[int]map[string]userland.Session {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	sessions := make(map[int]map[string]userland.Session, len(s.sessions))
+	for userID, userSessions := range s.sessions {
+		sessions[userID] = make(map[string]userland.Session, len(userSessions))
+		for sessionID, session := range userSessions {
+			sessions[userID][sessionID] = session
+		}
+	}
+	return sessions
+}
+
+func (s *SessionRepository) restore(sessions map[int]map[string]userland.Session) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.sessions = sessions
+}
+
+type loginRiskAssessmentSnapshot struct {
+	assessments []userland.LoginRiskAssessment
+	nextID      int
+}
+
+func (l *LoginRiskAssessmentRepository) snapshot() loginRiskAssessmentSnapshot {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	return loginRiskAssessmentSnapshot{assessments: append([]userland.LoginRiskAssessment(nil), l.assessments...), nextID: l.nextID}
+}
+
+func (l *LoginRiskAssessmentRepository) restore(snapshot loginRiskAssessmentSnapshot) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.assessments = snapshot.assessments
+	l.nextID = snapshot.nextID
+}
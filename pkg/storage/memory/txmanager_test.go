@@ -0,0 +1,59 @@
+//+build unit
+
+package memory_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/AdhityaRamadhanus/userland"
+	"github.com/AdhityaRamadhanus/userland/pkg/storage/memory"
+	"github.com/AdhityaRamadhanus/userland/pkg/userlandtest"
+)
+
+func TestTxManager_WithinTx(t *testing.T) {
+	userRepository := memory.NewUserRepository()
+	eventRepository := memory.NewEventRepository()
+	sessionRepository := memory.NewSessionRepository()
+	txManager := memory.NewTxManager(userRepository, eventRepository, sessionRepository, memory.NewLoginRiskAssessmentRepository())
+	user := userlandtest.TestCreateUser(t, userRepository)
+	session := userlandtest.TestCreateSession(t, sessionRepository, userlandtest.WithUserID(user.ID))
+
+	user.Fullname = "Committed"
+	err := txManager.WithinTx(context.Background(), func(repositories userland.TxRepositories) error {
+		return repositories.UserRepository.Update(context.Background(), *user)
+	})
+	if err != nil {
+		t.Fatalf("txManager.WithinTx() err = %v; want nil", err)
+	}
+	if found, err := userRepository.Find(context.Background(), user.ID); err != nil || found.Fullname != "Committed" {
+		t.Errorf("userRepository.Find() = %q, %v after commit; want %q, nil", found.Fullname, err, "Committed")
+	}
+
+	errAbort := errors.New("abort")
+	err = txManager.WithinTx(context.Background(), func(repositories userland.TxRepositories) error {
+		if err := repositories.EventRepository.Insert(context.Background(), userland.Event{UserID: user.ID, Event: "Delete Account"}); err != nil {
+			return err
+		}
+		if err := repositories.SessionRepository.DeleteAllByUserID(context.Background(), user.ID); err != nil {
+			return err
+		}
+		if err := repositories.UserRepository.Delete(context.Background(), user.ID); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	if err != errAbort {
+		t.Fatalf("txManager.WithinTx() err = %v; want %v", err, errAbort)
+	}
+	if _, err := userRepository.Find(context.Background(), user.ID); err != nil {
+		t.Errorf("userRepository.Find() err = %v after rollback; want nil", err)
+	}
+	if _, count, err := eventRepository.FindAll(context.Background(), userland.EventFilterOptions{UserID: user.ID}, userland.EventPagingOptions{Limit: 10}); err != nil || count != 0 {
+		t.Errorf("eventRepository.FindAll() count = %d, %v after rollback; want 0, nil", count, err)
+	}
+	if _, err := sessionRepository.Find(context.Background(), user.ID, session.ID); err != nil {
+		t.Errorf("sessionRepository.Find() err = %v after rollback; want nil", err)
+	}
+}
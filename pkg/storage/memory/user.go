@@ -0,0 +1,198 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/AdhityaRamadhanus/userland"
+)
+
+//UserRepository implements userland.UserRepository interface in memory, email is unique and matched case sensitively like in postgres
+type UserRepository struct {
+	mutex  sync.RWMutex
+	users  map[int]userland.User
+	nextID int
+}
+
+//NewUserRepository construct an empty UserRepository
+func NewUserRepository() *UserRepository {
+	return &UserRepository{
+		users:  map[int]userland.User{},
+		nextID: 1,
+	}
+}
+
+//Find User by id
+func (u *UserRepository) Find(ctx context.Context, id int) (userland.User, error) {
+	u.mutex.RLock()
+	defer u.mutex.RUnlock()
+
+	user, ok := u.users[id]
+	if !ok {
+		return userland.User{}, userland.ErrUserNotFound
+	}
+	return copyUser(user), nil
+}
+
+//FindByEmail User by email
+func (u *UserRepository) FindByEmail(ctx context.Context, email string) (userland.User, error) {
+	u.mutex.RLock()
+	defer u.mutex.RUnlock()
+
+	for _, user := range u.users {
+		if user.Email == email {
+			return copyUser(user), nil
+		}
+	}
+	return userland.User{}, userland.ErrUserNotFound
+}
+
+//Insert insert user and set its id
+func (u *UserRepository) Insert(ctx context.Context, user *userland.User) error {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+
+	if u.emailTaken(user.Email, 0) {
+		return userland.ErrDuplicateKey
+	}
+
+	now := time.Now()
+	user.ID = u.nextID
+	user.CreatedAt = now
+	user.UpdatedAt = now
+	user.Version = 1
+	u.nextID++
+
+	stored := copyUser(*user)
+	// backup codes are only stored by StoreBackupCodes
+	stored.BackupCodes = nil
+	stored.BackupCodesCreatedAt = time.Time{}
+	u.users[user.ID] = stored
+	return nil
+}
+
+//Update update user, backup codes are left untouched
+func (u *UserRepository) Update(ctx context.Context, user userland.User) error {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+
+	stored, ok := u.users[user.ID]
+	if !ok {
+		return userland.ErrUserNotFound
+	}
+	if stored.Version != user.Version {
+		return userland.ErrConcurrentModification
+	}
+	if u.emailTaken(user.Email, user.ID) {
+		return userland.ErrDuplicateKey
+	}
+
+	user = copyUser(user)
+	user.BackupCodes = stored.BackupCodes
+	user.BackupCodesCreatedAt = stored.BackupCodesCreatedAt
+	user.CreatedAt = stored.CreatedAt
+	user.UpdatedAt = time.Now()
+	user.Version++
+	u.users[user.ID] = user
+	return nil
+}
+
+//UpdateProfile change only the profile fields set in patch
+func (u *UserRepository) UpdateProfile(ctx context.Context, id int, version int, patch userland.ProfilePatch) error {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+
+	stored, ok := u.users[id]
+	if !ok {
+		return userland.ErrUserNotFound
+	}
+	if version > 0 && stored.Version != version {
+		return userland.ErrConcurrentModification
+	}
+
+	fields := []struct {
+		field *string
+		value *string
+	}{
+		{&stored.Fullname, patch.Fullname},
+		{&stored.Phone, patch.Phone},
+		{&stored.Location, patch.Location},
+		{&stored.Bio, patch.Bio},
+		{&stored.WebURL, patch.WebURL},
+	}
+	for _, field := range fields {
+		if field.value != nil {
+			*field.field = *field.value
+		}
+	}
+	stored.UpdatedAt = time.Now()
+	stored.Version++
+	u.users[id] = stored
+	return nil
+}
+
+//StoreBackupCodes replace backup codes of user, the version is checked and bumped like in Update
+func (u *UserRepository) StoreBackupCodes(ctx context.Context, user userland.User) error {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+
+	stored, ok := u.users[user.ID]
+	if !ok {
+		return userland.ErrUserNotFound
+	}
+	if stored.Version != user.Version {
+		return userland.ErrConcurrentModification
+	}
+
+	stored.BackupCodes = append([]string(nil), user.BackupCodes...)
+	stored.BackupCodesCreatedAt = user.BackupCodesCreatedAt
+	stored.UpdatedAt = time.Now()
+	stored.Version++
+	u.users[user.ID] = stored
+	return nil
+}
+
+//Delete delete user by id
+func (u *UserRepository) Delete(ctx context.Context, id int) error {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+
+	if _, ok := u.users[id]; !ok {
+		return userland.ErrUserNotFound
+	}
+	delete(u.users, id)
+	return nil
+}
+
+//FindAllPendingDeletion return users whose deletion was requested before requestedBefore, oldest request first
+func (u *UserRepository) FindAllPendingDeletion(ctx context.Context, requestedBefore time.Time) ([]userland.User, error) {
+	u.mutex.RLock()
+	defer u.mutex.RUnlock()
+
+	users := []userland.User{}
+	for _, user := range u.users {
+		if !user.DeletionRequestedAt.IsZero() && user.DeletionRequestedAt.Before(requestedBefore) {
+			users = append(users, copyUser(user))
+		}
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].DeletionRequestedAt.Before(users[j].DeletionRequestedAt) })
+	return users, nil
+}
+
+func (u *UserRepository) emailTaken(email string, exceptID int) bool {
+	for id, user := range u.users {
+		if id != exceptID && user.Email == email {
+			return true
+		}
+	}
+	return false
+}
+
+func copyUser(user userland.User) userland.User {
+	if user.BackupCodes != nil {
+		user.BackupCodes = append([]string(nil), user.BackupCodes...)
+	}
+	return user
+}
@@ -0,0 +1,59 @@
+//+build unit
+
+package memory_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/AdhityaRamadhanus/userland"
+	"github.com/AdhityaRamadhanus/userland/pkg/storage/memory"
+)
+
+func TestUserRepository(t *testing.T) {
+	userRepository := memory.NewUserRepository()
+	user := userland.User{Email: "john@example.com", Fullname: "John", Password: "hash"}
+	if err := userRepository.Insert(context.Background(), &user); err != nil {
+		t.Fatalf("userRepository.Insert() err = %v; want nil", err)
+	}
+	if user.ID == 0 {
+		t.Fatalf("userRepository.Insert(context.Background()) user.ID = 0; want id assigned")
+	}
+
+	duplicate := userland.User{Email: "john@example.com"}
+	if err := userRepository.Insert(context.Background(), &duplicate); err != userland.ErrDuplicateKey {
+		t.Errorf("userRepository.Insert(duplicate) err = %v; want %v", err, userland.ErrDuplicateKey)
+	}
+
+	user.BackupCodes = []string{"code"}
+	if err := userRepository.StoreBackupCodes(context.Background(), user); err != nil {
+		t.Fatalf("userRepository.StoreBackupCodes() err = %v; want nil", err)
+	}
+	user.Version++
+	user.Fullname = "John Doe"
+	user.BackupCodes = nil
+	if err := userRepository.Update(context.Background(), user); err != nil {
+		t.Fatalf("userRepository.Update() err = %v; want nil", err)
+	}
+
+	found, err := userRepository.FindByEmail(context.Background(), "john@example.com")
+	if err != nil {
+		t.Fatalf("userRepository.FindByEmail() err = %v; want nil", err)
+	}
+	if found.Fullname != "John Doe" || len(found.BackupCodes) != 1 {
+		t.Errorf("userRepository.FindByEmail() = %+v; want updated fullname and stored backup codes", found)
+	}
+
+	// returned users don't share memory with the repository
+	found.BackupCodes[0] = "changed"
+	if found, _ := userRepository.Find(context.Background(), user.ID); found.BackupCodes[0] != "code" {
+		t.Errorf("userRepository.Find(context.Background()) BackupCodes = %v; want [code]", found.BackupCodes)
+	}
+
+	if err := userRepository.Delete(context.Background(), user.ID); err != nil {
+		t.Fatalf("userRepository.Delete() err = %v; want nil", err)
+	}
+	if _, err := userRepository.Find(context.Background(), user.ID); err != userland.ErrUserNotFound {
+		t.Errorf("userRepository.Find(deleted) err = %v; want %v", err, userland.ErrUserNotFound)
+	}
+}
@@ -0,0 +1,193 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/AdhityaRamadhanus/userland"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+type ClientScanStruct struct {
+	ID             int
+	Name           string
+	Type           string
+	Public         bool
+	SecretHash     sql.NullString `db:"secret_hash"`
+	AllowedOrigins pq.StringArray `db:"allowed_origins"`
+	Status         string
+	CreatedAt      time.Time `db:"created_at"`
+	UpdatedAt      time.Time `db:"updated_at"`
+}
+
+/*
+ClientRepository is implementation of ClientRepository interface
+of userland domain using postgre
+*/
+type ClientRepository struct {
+	db *sqlx.DB
+	repositoryOptions
+}
+
+//NewClientRepository is constructor to create client repository
+func NewClientRepository(conn *sqlx.DB, opts ...RepositoryOption) *ClientRepository {
+	return &ClientRepository{
+		db:                conn,
+		repositoryOptions: buildRepositoryOptions(opts),
+	}
+}
+
+//Find Client by id
+func (c ClientRepository) Find(ctx context.Context, id int) (userland.Client, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	clientScanStruct := ClientScanStruct{}
+	query := `SELECT
+				id,
+				name,
+				type,
+				public,
+				secret_hash,
+				allowed_origins,
+				status,
+				created_at,
+				updated_at
+			FROM clients
+			WHERE id=$1`
+
+	stmt, err := c.db.PreparexContext(ctx, query)
+	if err != nil {
+		return userland.Client{}, errors.Wrap(err, "db.Preparex(query) err")
+	}
+
+	if err := stmt.GetContext(ctx, &clientScanStruct, id); err != nil {
+		if err == sql.ErrNoRows {
+			return userland.Client{}, userland.ErrClientNotFound
+		}
+		return userland.Client{}, errors.Wrap(err, "stmt.Get() err")
+	}
+
+	return c.convertStructScanToEntity(clientScanStruct), nil
+}
+
+//FindAll registered clients ordered by id
+func (c ClientRepository) FindAll(ctx context.Context) (userland.Clients, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	clientScanStructs := []ClientScanStruct{}
+	query := `SELECT
+				id,
+				name,
+				type,
+				public,
+				secret_hash,
+				allowed_origins,
+				status,
+				created_at,
+				updated_at
+			FROM clients
+			ORDER BY id`
+
+	if err := c.db.SelectContext(ctx, &clientScanStructs, query); err != nil {
+		return nil, errors.Wrap(err, "db.Select() err")
+	}
+
+	clients := userland.Clients{}
+	for _, clientScanStruct := range clientScanStructs {
+		clients = append(clients, c.convertStructScanToEntity(clientScanStruct))
+	}
+	return clients, nil
+}
+
+//Insert insert client to datastore
+func (c ClientRepository) Insert(ctx context.Context, client *userland.Client) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	query := `INSERT INTO clients (
+				name,
+				type,
+				public,
+				secret_hash,
+				allowed_origins,
+				status,
+				created_at,
+				updated_at
+			) VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6, now(), now()) RETURNING id, created_at, updated_at`
+
+	row := c.db.QueryRowContext(ctx,
+		query,
+		client.Name,
+		client.Type,
+		client.Public,
+		client.SecretHash,
+		pq.Array(client.AllowedOrigins),
+		client.Status,
+	)
+	if err := row.Scan(&client.ID, &client.CreatedAt, &client.UpdatedAt); err != nil {
+		return errors.Wrap(err, "row.Scan() err")
+	}
+
+	return nil
+}
+
+//Update update name, allowed origins, status and secret of a client
+func (c ClientRepository) Update(ctx context.Context, client userland.Client) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	query := `UPDATE clients SET (
+				name,
+				secret_hash,
+				allowed_origins,
+				status,
+				updated_at
+			) = ($2, NULLIF($3, ''), $4, $5, now()) WHERE id=$1`
+
+	res, err := c.db.ExecContext(ctx,
+		query,
+		client.ID,
+		client.Name,
+		client.SecretHash,
+		pq.Array(client.AllowedOrigins),
+		client.Status,
+	)
+	if err != nil {
+		return errors.Wrap(err, "db.Exec() err")
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "res.RowsAffected() err")
+	}
+
+	if rowsAffected == 0 {
+		return userland.ErrClientNotFound
+	}
+
+	return nil
+}
+
+func (c ClientRepository) convertStructScanToEntity(clientScanStruct ClientScanStruct) userland.Client {
+	client := userland.Client{
+		ID:             clientScanStruct.ID,
+		Name:           clientScanStruct.Name,
+		Type:           clientScanStruct.Type,
+		Public:         clientScanStruct.Public,
+		AllowedOrigins: []string(clientScanStruct.AllowedOrigins),
+		Status:         clientScanStruct.Status,
+		CreatedAt:      clientScanStruct.CreatedAt,
+		UpdatedAt:      clientScanStruct.UpdatedAt,
+	}
+
+	if clientScanStruct.SecretHash.Valid {
+		client.SecretHash = clientScanStruct.SecretHash.String
+	}
+
+	return client
+}
@@ -0,0 +1,177 @@
+// +build integration
+
+package postgres_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/AdhityaRamadhanus/userland"
+	"github.com/AdhityaRamadhanus/userland/pkg/config"
+	"github.com/AdhityaRamadhanus/userland/pkg/storage/postgres"
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq"
+	"github.com/stretchr/testify/suite"
+)
+
+type ClientRepositoryTestSuite struct {
+	suite.Suite
+	Config           *config.Configuration
+	DB               *sqlx.DB
+	ClientRepository userland.ClientRepository
+}
+
+func NewClientRepositoryTestSuite(cfg *config.Configuration) *ClientRepositoryTestSuite {
+	return &ClientRepositoryTestSuite{
+		Config: cfg,
+	}
+}
+
+func (suite *ClientRepositoryTestSuite) Teardown() {
+	suite.T().Log("Teardown ClientRepositoryTestSuite")
+	suite.DB.Close()
+}
+
+func (suite *ClientRepositoryTestSuite) SetupSuite() {
+	suite.T().Log("Connecting to postgres at", suite.Config.Postgres)
+	pgConn, err := postgres.CreateConnection(suite.Config.Postgres)
+	if err != nil {
+		suite.T().Fatalf("postgres.CreateConnection() err = %v; want nil", err)
+	}
+
+	suite.DB = pgConn
+	suite.ClientRepository = postgres.NewClientRepository(pgConn)
+}
+
+func (suite *ClientRepositoryTestSuite) SetupTest() {
+	query := "DELETE FROM clients"
+	if _, err := suite.DB.Query(query); err != nil {
+		suite.T().Fatalf("suite.DB.Query(%q) err = %v; want nil", query, err)
+	}
+}
+
+func (suite *ClientRepositoryTestSuite) TestInsert() {
+	client := userland.Client{
+		Name:           "web",
+		Type:           userland.ClientTypeWeb,
+		Public:         true,
+		AllowedOrigins: []string{"https://userland.io"},
+		Status:         userland.ClientStatusActive,
+	}
+	if err := suite.ClientRepository.Insert(context.Background(), &client); err != nil {
+		suite.T().Fatalf("ClientRepository.Insert(client) err = %v; want nil", err)
+	}
+	if client.ID == 0 {
+		suite.T().Errorf("ClientRepository.Insert(client) client.ID = 0; want generated id")
+	}
+}
+
+func (suite *ClientRepositoryTestSuite) TestFind() {
+	defaultClient := userland.Client{
+		Name:           "web",
+		Type:           userland.ClientTypeWeb,
+		Public:         true,
+		AllowedOrigins: []string{"https://userland.io"},
+		Status:         userland.ClientStatusActive,
+	}
+	if err := suite.ClientRepository.Insert(context.Background(), &defaultClient); err != nil {
+		suite.T().Fatalf("ClientRepository.Insert(client) err = %v; want nil", err)
+	}
+
+	type args struct {
+		id int
+	}
+	testCases := []struct {
+		name    string
+		args    args
+		wantErr error
+	}{
+		{
+			name: "found",
+			args: args{
+				id: defaultClient.ID,
+			},
+			wantErr: nil,
+		},
+		{
+			name: "not found",
+			args: args{
+				id: defaultClient.ID + 1,
+			},
+			wantErr: userland.ErrClientNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		suite.T().Run(tc.name, func(t *testing.T) {
+			client, err := suite.ClientRepository.Find(context.Background(), tc.args.id)
+			if err != tc.wantErr {
+				t.Fatalf("ClientRepository.Find(%d) err = %v; want %v", tc.args.id, err, tc.wantErr)
+			}
+			if tc.wantErr == nil && (len(client.AllowedOrigins) != 1 || client.AllowedOrigins[0] != "https://userland.io") {
+				t.Errorf("ClientRepository.Find(%d) AllowedOrigins = %v; want [https://userland.io]", tc.args.id, client.AllowedOrigins)
+			}
+		})
+	}
+}
+
+func (suite *ClientRepositoryTestSuite) TestFindAll() {
+	for _, name := range []string{"web", "backend"} {
+		client := userland.Client{Name: name, Type: userland.ClientTypeServer, Status: userland.ClientStatusActive}
+		if err := suite.ClientRepository.Insert(context.Background(), &client); err != nil {
+			suite.T().Fatalf("ClientRepository.Insert(client) err = %v; want nil", err)
+		}
+	}
+
+	clients, err := suite.ClientRepository.FindAll(context.Background())
+	if err != nil {
+		suite.T().Fatalf("ClientRepository.FindAll() err = %v; want nil", err)
+	}
+	if len(clients) != 2 {
+		suite.T().Errorf("len(ClientRepository.FindAll()) = %d; want 2", len(clients))
+	}
+}
+
+func (suite *ClientRepositoryTestSuite) TestUpdate() {
+	defaultClient := userland.Client{
+		Name:   "backend",
+		Type:   userland.ClientTypeServer,
+		Status: userland.ClientStatusActive,
+	}
+	if err := suite.ClientRepository.Insert(context.Background(), &defaultClient); err != nil {
+		suite.T().Fatalf("ClientRepository.Insert(client) err = %v; want nil", err)
+	}
+
+	type args struct {
+		client userland.Client
+	}
+	testCases := []struct {
+		name    string
+		args    args
+		wantErr error
+	}{
+		{
+			name: "found",
+			args: args{
+				client: userland.Client{ID: defaultClient.ID, Name: "backend", Status: userland.ClientStatusDisabled},
+			},
+			wantErr: nil,
+		},
+		{
+			name: "not found",
+			args: args{
+				client: userland.Client{ID: defaultClient.ID + 1, Name: "backend", Status: userland.ClientStatusDisabled},
+			},
+			wantErr: userland.ErrClientNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		suite.T().Run(tc.name, func(t *testing.T) {
+			err := suite.ClientRepository.Update(context.Background(), tc.args.client)
+			if err != tc.wantErr {
+				t.Fatalf("ClientRepository.Update(client) err = %v; want %v", err, tc.wantErr)
+			}
+		})
+	}
+}
@@ -1,7 +1,10 @@
 package postgres
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
+	"time"
 
 	"github.com/AdhityaRamadhanus/userland/pkg/config"
 	"github.com/jmoiron/sqlx"
@@ -19,3 +22,44 @@ func CreateConnection(cfg config.PostgresConfig) (*sqlx.DB, error) {
 	)
 	return sqlx.Open("postgres", connString)
 }
+
+//database is satisfied by both *sqlx.DB and *sqlx.Tx, so a repository can run its queries inside a transaction
+type database interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	NamedExecContext(ctx context.Context, query string, arg interface{}) (sql.Result, error)
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+	PreparexContext(ctx context.Context, query string) (*sqlx.Stmt, error)
+	PrepareNamedContext(ctx context.Context, query string) (*sqlx.NamedStmt, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+	SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+}
+
+//RepositoryOption configure every repository of this package
+type RepositoryOption func(*repositoryOptions)
+
+type repositoryOptions struct {
+	queryTimeout time.Duration
+}
+
+//WithQueryTimeout cancel a query running longer than timeout, even when the caller context has no deadline
+func WithQueryTimeout(timeout time.Duration) RepositoryOption {
+	return func(opts *repositoryOptions) {
+		opts.queryTimeout = timeout
+	}
+}
+
+func buildRepositoryOptions(opts []RepositoryOption) repositoryOptions {
+	options := repositoryOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
+
+//withTimeout derive the context a repository call run its queries with
+func (o repositoryOptions) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if o.queryTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, o.queryTimeout)
+}
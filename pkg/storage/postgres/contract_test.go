@@ -0,0 +1,56 @@
+// +build integration
+
+package postgres_test
+
+import (
+	"testing"
+
+	"github.com/AdhityaRamadhanus/userland"
+	"github.com/AdhityaRamadhanus/userland/pkg/storage/postgres"
+	"github.com/AdhityaRamadhanus/userland/pkg/userlandtest"
+	"github.com/jmoiron/sqlx"
+)
+
+//truncate clear table before every contract test so each one start from an empty repository
+func truncate(t *testing.T, db *sqlx.DB, table string) {
+	query := "DELETE FROM " + table
+	if _, err := db.Exec(query); err != nil {
+		t.Fatalf("db.Exec(%q) err = %v; want nil", query, err)
+	}
+}
+
+func TestRepositoryContracts(t *testing.T) {
+	db, err := postgres.CreateConnection(cfg.Postgres)
+	if err != nil {
+		t.Fatalf("postgres.CreateConnection() err = %v; want nil", err)
+	}
+	defer db.Close()
+
+	t.Run("UserRepository", func(t *testing.T) {
+		userlandtest.RunUserRepositoryContract(t, func(t *testing.T) userland.UserRepository {
+			truncate(t, db, "users")
+			return postgres.NewUserRepository(db)
+		})
+	})
+
+	t.Run("EventRepository", func(t *testing.T) {
+		userlandtest.RunEventRepositoryContract(t, func(t *testing.T) userland.EventRepository {
+			truncate(t, db, "events")
+			return postgres.NewEventRepository(db)
+		})
+	})
+
+	t.Run("SessionRepository", func(t *testing.T) {
+		userlandtest.RunSessionRepositoryContract(t, func(t *testing.T) userland.SessionRepository {
+			truncate(t, db, "sessions")
+			return postgres.NewSessionRepository(db)
+		})
+	})
+
+	t.Run("DataExportRepository", func(t *testing.T) {
+		userlandtest.RunDataExportRepositoryContract(t, func(t *testing.T) userland.DataExportRepository {
+			truncate(t, db, "data_exports")
+			return postgres.NewDataExportRepository(db)
+		})
+	})
+}
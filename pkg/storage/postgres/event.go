@@ -1,6 +1,7 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"strings"
@@ -8,6 +9,7 @@ import (
 
 	"github.com/AdhityaRamadhanus/userland"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"github.com/pkg/errors"
 )
 
@@ -19,8 +21,13 @@ type EventScanStruct struct {
 	IP         sql.NullString
 	ClientID   int    `db:"client_id"`
 	ClientName string `db:"client_name"`
-	Timestamp  time.Time
-	CreatedAt  time.Time `db:"created_at"`
+	Country    sql.NullString
+	City       sql.NullString
+	ASN        sql.NullInt64
+	// ChangedFields is null for events that change nothing
+	ChangedFields pq.StringArray `db:"changed_fields"`
+	Timestamp     time.Time
+	CreatedAt     time.Time `db:"created_at"`
 }
 
 /*
@@ -28,18 +35,23 @@ EventRepository is implementation of EventRepository interface
 of userland domain using postgre
 */
 type EventRepository struct {
-	db *sqlx.DB
+	db database
+	repositoryOptions
 }
 
 //NewEventRepository is constructor to create story repository
-func NewEventRepository(conn *sqlx.DB) *EventRepository {
+func NewEventRepository(conn *sqlx.DB, opts ...RepositoryOption) *EventRepository {
 	return &EventRepository{
-		db: conn,
+		db:                conn,
+		repositoryOptions: buildRepositoryOptions(opts),
 	}
 }
 
 //Find User by id
-func (e EventRepository) FindAll(filter userland.EventFilterOptions, paging userland.EventPagingOptions) (events userland.Events, eventsCount int, err error) {
+func (e EventRepository) FindAll(ctx context.Context, filter userland.EventFilterOptions, paging userland.EventPagingOptions) (events userland.Events, eventsCount int, err error) {
+	ctx, cancel := e.withTimeout(ctx)
+	defer cancel()
+
 	scanStructEvents := []EventScanStruct{}
 
 	whereArgs := []interface{}{}
@@ -67,7 +79,7 @@ func (e EventRepository) FindAll(filter userland.EventFilterOptions, paging user
 		// build where queries from sub queries,
 		// eg: whereSubStatements = ["order_id=$1", "product_id=$2", "name=$3"]
 		// whereStatement = "WHERE order_id=$1 AND product_id=$2 AND name=$3"
-		whereStatement = fmt.Sprintf(whereStatement, strings.Join(whereSubStatements, "AND "))
+		whereStatement = fmt.Sprintf(whereStatement, strings.Join(whereSubStatements, " AND "))
 	}
 
 	selectQuery := fmt.Sprintf(
@@ -79,6 +91,10 @@ func (e EventRepository) FindAll(filter userland.EventFilterOptions, paging user
 			ip,
 			client_id,
 			client_name,
+			country,
+			city,
+			asn,
+			changed_fields,
 			timestamp,
 			created_at
 		FROM events 
@@ -93,17 +109,17 @@ func (e EventRepository) FindAll(filter userland.EventFilterOptions, paging user
 		paging.Offset,
 	)
 
-	stmt, err := e.db.Preparex(selectQuery)
+	stmt, err := e.db.PreparexContext(ctx, selectQuery)
 	if err != nil {
 		return userland.Events{}, 0, errors.Wrap(err, "db.Preparex() err")
 	}
 
-	if err := stmt.Select(&scanStructEvents, whereArgs...); err != nil {
+	if err := stmt.SelectContext(ctx, &scanStructEvents, whereArgs...); err != nil {
 		return userland.Events{}, 0, errors.Wrap(err, "stmt.Select() err")
 	}
 
 	countQuery := fmt.Sprintf(`SELECT count(*) FROM events %s`, whereStatement)
-	row := e.db.QueryRow(countQuery, whereArgs...)
+	row := e.db.QueryRowContext(ctx, countQuery, whereArgs...)
 	row.Scan(&eventsCount)
 
 	events = userland.Events{}
@@ -113,20 +129,26 @@ func (e EventRepository) FindAll(filter userland.EventFilterOptions, paging user
 	return events, eventsCount, nil
 }
 
-func (s EventRepository) DeleteAllByUserID(userID int) error {
+func (s EventRepository) DeleteAllByUserID(ctx context.Context, userID int) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
 	query := `DELETE FROM events where user_id=$1`
 
-	deleteStatement, err := s.db.Prepare(query)
+	deleteStatement, err := s.db.PrepareContext(ctx, query)
 	if err != nil {
 		return err
 	}
 
 	defer deleteStatement.Close()
-	_, err = deleteStatement.Exec(userID)
+	_, err = deleteStatement.ExecContext(ctx, userID)
 	return err
 }
 
-func (e EventRepository) Insert(event userland.Event) error {
+func (e EventRepository) Insert(ctx context.Context, event userland.Event) error {
+	ctx, cancel := e.withTimeout(ctx)
+	defer cancel()
+
 	query := `INSERT INTO events (
 				user_id,
 				event, 
@@ -134,32 +156,58 @@ func (e EventRepository) Insert(event userland.Event) error {
 				ip,
 				client_id,
 				client_name,
+				country,
+				city,
+				asn,
+				changed_fields,
 				timestamp,
 				created_at
 			) VALUES (
-				:userid, 
-				:event, 
-				:useragent, 
-				:ip,
-				:clientid,
-				:clientname,
-				:timestamp,
+				$1, 
+				$2, 
+				$3, 
+				$4,
+				$5,
+				$6,
+				NULLIF($7, ''),
+				NULLIF($8, ''),
+				NULLIF($9, 0),
+				$10,
+				$11,
 				now()
 			)`
 
-	_, err := e.db.NamedQuery(query, event)
+	// changed_fields stays null instead of an empty array when nothing is changed
+	var changedFields interface{}
+	if len(event.ChangedFields) > 0 {
+		changedFields = pq.Array(event.ChangedFields)
+	}
+	_, err := e.db.ExecContext(ctx, query,
+		event.UserID,
+		event.Event,
+		event.UserAgent,
+		event.IP,
+		event.ClientID,
+		event.ClientName,
+		event.Country,
+		event.City,
+		event.ASN,
+		changedFields,
+		event.Timestamp,
+	)
 	return err
 }
 
 func (e EventRepository) convertStructScanToEntity(eventScanStruct EventScanStruct) userland.Event {
 	event := userland.Event{
-		ID:         eventScanStruct.ID,
-		UserID:     eventScanStruct.UserID,
-		Event:      eventScanStruct.Event,
-		ClientID:   eventScanStruct.ClientID,
-		ClientName: eventScanStruct.ClientName,
-		Timestamp:  eventScanStruct.Timestamp,
-		CreatedAt:  eventScanStruct.CreatedAt,
+		ID:            eventScanStruct.ID,
+		UserID:        eventScanStruct.UserID,
+		Event:         eventScanStruct.Event,
+		ClientID:      eventScanStruct.ClientID,
+		ClientName:    eventScanStruct.ClientName,
+		ChangedFields: []string(eventScanStruct.ChangedFields),
+		Timestamp:     eventScanStruct.Timestamp,
+		CreatedAt:     eventScanStruct.CreatedAt,
 	}
 
 	if eventScanStruct.IP.Valid {
@@ -168,6 +216,15 @@ func (e EventRepository) convertStructScanToEntity(eventScanStruct EventScanStru
 	if eventScanStruct.UserAgent.Valid {
 		event.UserAgent = eventScanStruct.UserAgent.String
 	}
+	if eventScanStruct.Country.Valid {
+		event.Country = eventScanStruct.Country.String
+	}
+	if eventScanStruct.City.Valid {
+		event.City = eventScanStruct.City.String
+	}
+	if eventScanStruct.ASN.Valid {
+		event.ASN = int(eventScanStruct.ASN.Int64)
+	}
 
 	return event
 }
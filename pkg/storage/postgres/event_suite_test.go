@@ -3,6 +3,7 @@
 package postgres_test
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -100,7 +101,7 @@ func (suite *EventRepositoryTestSuite) TestFindAll() {
 
 	for _, tc := range testCases {
 		suite.T().Run(tc.name, func(t *testing.T) {
-			events, count, err := suite.EventRepository.FindAll(tc.args.filter, tc.args.paging)
+			events, count, err := suite.EventRepository.FindAll(context.Background(), tc.args.filter, tc.args.paging)
 			if err != nil {
 				t.Fatalf("EventRepository.FindAll() err = %v; want nil", err)
 			}
@@ -146,7 +147,7 @@ func (suite *EventRepositoryTestSuite) TestInsert() {
 
 	for _, tc := range testCases {
 		suite.T().Run(tc.name, func(t *testing.T) {
-			if err := suite.EventRepository.Insert(tc.args.event); err != tc.wantErr {
+			if err := suite.EventRepository.Insert(context.Background(), tc.args.event); err != tc.wantErr {
 				t.Errorf("EventRepository.Insert(event) err = %v; want %v", err, tc.wantErr)
 			}
 		})
@@ -177,7 +178,7 @@ func (suite *EventRepositoryTestSuite) TestDeleteAllByUserID() {
 
 	for _, tc := range testCases {
 		suite.T().Run(tc.name, func(t *testing.T) {
-			if err := suite.EventRepository.DeleteAllByUserID(tc.args.userID); err != tc.wantErr {
+			if err := suite.EventRepository.DeleteAllByUserID(context.Background(), tc.args.userID); err != tc.wantErr {
 				t.Errorf("EventRepository.DeleteAllByUserID(userID) err = %v; want %v", err, tc.wantErr)
 			}
 		})
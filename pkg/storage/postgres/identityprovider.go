@@ -0,0 +1,229 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/AdhityaRamadhanus/userland"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+type IdentityProviderScanStruct struct {
+	ID               int
+	Tenant           string
+	EntityID         string         `db:"entity_id"`
+	SSOURL           sql.NullString `db:"sso_url"`
+	Certificates     pq.StringArray
+	AttributeMapping sql.NullString `db:"attribute_mapping"`
+	Domains          pq.StringArray
+	Metadata         sql.NullString
+	CreatedAt        time.Time `db:"created_at"`
+	UpdatedAt        time.Time `db:"updated_at"`
+}
+
+/*
+IdentityProviderRepository is implementation of IdentityProviderRepository interface
+of userland domain using postgre
+*/
+type IdentityProviderRepository struct {
+	db *sqlx.DB
+	repositoryOptions
+}
+
+//NewIdentityProviderRepository is constructor to create identity provider repository
+func NewIdentityProviderRepository(conn *sqlx.DB, opts ...RepositoryOption) *IdentityProviderRepository {
+	return &IdentityProviderRepository{
+		db:                conn,
+		repositoryOptions: buildRepositoryOptions(opts),
+	}
+}
+
+//FindByTenant IdentityProvider by tenant
+func (i IdentityProviderRepository) FindByTenant(ctx context.Context, tenant string) (identityProvider userland.IdentityProvider, err error) {
+	ctx, cancel := i.withTimeout(ctx)
+	defer cancel()
+
+	identityProviderScanStruct := IdentityProviderScanStruct{}
+	query := `SELECT
+				id,
+				tenant,
+				entity_id,
+				sso_url,
+				certificates,
+				attribute_mapping,
+				domains,
+				metadata,
+				created_at,
+				updated_at
+			FROM identity_providers
+			WHERE tenant=$1`
+
+	stmt, err := i.db.PreparexContext(ctx, query)
+	if err != nil {
+		return userland.IdentityProvider{}, errors.Wrap(err, "db.Preparex(query) err")
+	}
+
+	if err := stmt.GetContext(ctx, &identityProviderScanStruct, tenant); err != nil {
+		if err == sql.ErrNoRows {
+			return userland.IdentityProvider{}, userland.ErrIdentityProviderNotFound
+		}
+		return userland.IdentityProvider{}, errors.Wrap(err, "stmt.Get() err")
+	}
+
+	return i.convertStructScanToEntity(identityProviderScanStruct)
+}
+
+//Insert insert identity provider to datastore
+func (i IdentityProviderRepository) Insert(ctx context.Context, identityProvider *userland.IdentityProvider) error {
+	ctx, cancel := i.withTimeout(ctx)
+	defer cancel()
+
+	attributeMapping, err := json.Marshal(identityProvider.AttributeMapping)
+	if err != nil {
+		return errors.Wrap(err, "json.Marshal(attributeMapping) err")
+	}
+
+	query := `INSERT INTO identity_providers (
+				tenant,
+				entity_id,
+				sso_url,
+				certificates,
+				attribute_mapping,
+				metadata,
+				domains,
+				created_at,
+				updated_at
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, now(), now()) RETURNING id`
+
+	row := i.db.QueryRowContext(ctx,
+		query,
+		identityProvider.Tenant,
+		identityProvider.EntityID,
+		identityProvider.SSOURL,
+		pq.Array(identityProvider.Certificates),
+		string(attributeMapping),
+		identityProvider.Metadata,
+		pq.Array(identityProvider.Domains),
+	)
+	if err := row.Scan(&identityProvider.ID); err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "unique_violation" {
+			return userland.ErrDuplicateKey
+		}
+		return errors.Wrap(err, "row.Scan() err")
+	}
+
+	return nil
+}
+
+//Update update identity provider of a tenant
+func (i IdentityProviderRepository) Update(ctx context.Context, identityProvider userland.IdentityProvider) error {
+	ctx, cancel := i.withTimeout(ctx)
+	defer cancel()
+
+	attributeMapping, err := json.Marshal(identityProvider.AttributeMapping)
+	if err != nil {
+		return errors.Wrap(err, "json.Marshal(attributeMapping) err")
+	}
+
+	query := `UPDATE identity_providers SET (
+				entity_id,
+				sso_url,
+				certificates,
+				attribute_mapping,
+				metadata,
+				domains,
+				updated_at
+			) = ($2, $3, $4, $5, $6, $7, now()) WHERE tenant=$1`
+
+	res, err := i.db.ExecContext(ctx,
+		query,
+		identityProvider.Tenant,
+		identityProvider.EntityID,
+		identityProvider.SSOURL,
+		pq.Array(identityProvider.Certificates),
+		string(attributeMapping),
+		identityProvider.Metadata,
+		pq.Array(identityProvider.Domains),
+	)
+	if err != nil {
+		return errors.Wrap(err, "db.Exec() err")
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "res.RowsAffected() err")
+	}
+
+	if rowsAffected == 0 {
+		return userland.ErrIdentityProviderNotFound
+	}
+
+	return nil
+}
+
+//FindLinkedUserID find the user linked to a subject of identity provider
+func (i IdentityProviderRepository) FindLinkedUserID(ctx context.Context, identityProviderID int, nameID string) (userID int, err error) {
+	ctx, cancel := i.withTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT user_id FROM identity_provider_links WHERE identity_provider_id=$1 AND name_id=$2`
+	if err := i.db.GetContext(ctx, &userID, query, identityProviderID, nameID); err != nil {
+		if err == sql.ErrNoRows {
+			return 0, userland.ErrIdentityProviderLinkNotFound
+		}
+		return 0, errors.Wrap(err, "db.Get() err")
+	}
+
+	return userID, nil
+}
+
+//Link link a subject of identity provider to user
+func (i IdentityProviderRepository) Link(ctx context.Context, identityProviderID int, nameID string, userID int) error {
+	ctx, cancel := i.withTimeout(ctx)
+	defer cancel()
+
+	query := `INSERT INTO identity_provider_links (
+				identity_provider_id,
+				name_id,
+				user_id,
+				created_at
+			) VALUES ($1, $2, $3, now())
+			ON CONFLICT (identity_provider_id, name_id) DO UPDATE SET user_id=EXCLUDED.user_id`
+
+	if _, err := i.db.ExecContext(ctx, query, identityProviderID, nameID, userID); err != nil {
+		return errors.Wrap(err, "db.Exec() err")
+	}
+
+	return nil
+}
+
+func (i IdentityProviderRepository) convertStructScanToEntity(identityProviderScanStruct IdentityProviderScanStruct) (userland.IdentityProvider, error) {
+	identityProvider := userland.IdentityProvider{
+		ID:               identityProviderScanStruct.ID,
+		Tenant:           identityProviderScanStruct.Tenant,
+		EntityID:         identityProviderScanStruct.EntityID,
+		Certificates:     []string(identityProviderScanStruct.Certificates),
+		AttributeMapping: map[string]string{},
+		Domains:          []string(identityProviderScanStruct.Domains),
+		CreatedAt:        identityProviderScanStruct.CreatedAt,
+		UpdatedAt:        identityProviderScanStruct.UpdatedAt,
+	}
+
+	if identityProviderScanStruct.SSOURL.Valid {
+		identityProvider.SSOURL = identityProviderScanStruct.SSOURL.String
+	}
+	if identityProviderScanStruct.AttributeMapping.Valid {
+		if err := json.Unmarshal([]byte(identityProviderScanStruct.AttributeMapping.String), &identityProvider.AttributeMapping); err != nil {
+			return userland.IdentityProvider{}, errors.Wrap(err, "json.Unmarshal(attributeMapping) err")
+		}
+	}
+	if identityProviderScanStruct.Metadata.Valid {
+		identityProvider.Metadata = identityProviderScanStruct.Metadata.String
+	}
+
+	return identityProvider, nil
+}
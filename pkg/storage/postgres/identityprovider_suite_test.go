@@ -0,0 +1,231 @@
+// +build integration
+
+package postgres_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/AdhityaRamadhanus/userland"
+	"github.com/AdhityaRamadhanus/userland/pkg/config"
+	"github.com/AdhityaRamadhanus/userland/pkg/storage/postgres"
+	"github.com/AdhityaRamadhanus/userland/pkg/userlandtest"
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq"
+	"github.com/stretchr/testify/suite"
+)
+
+type IdentityProviderRepositoryTestSuite struct {
+	suite.Suite
+	Config                     *config.Configuration
+	DB                         *sqlx.DB
+	IdentityProviderRepository userland.IdentityProviderRepository
+}
+
+func NewIdentityProviderRepositoryTestSuite(cfg *config.Configuration) *IdentityProviderRepositoryTestSuite {
+	return &IdentityProviderRepositoryTestSuite{
+		Config: cfg,
+	}
+}
+
+func (suite *IdentityProviderRepositoryTestSuite) Teardown() {
+	suite.T().Log("Teardown IdentityProviderRepositoryTestSuite")
+	suite.DB.Close()
+}
+
+func (suite *IdentityProviderRepositoryTestSuite) SetupSuite() {
+	suite.T().Log("Connecting to postgres at", suite.Config.Postgres)
+	pgConn, err := postgres.CreateConnection(suite.Config.Postgres)
+	if err != nil {
+		suite.T().Fatalf("postgres.CreateConnection() err = %v; want nil", err)
+	}
+
+	suite.DB = pgConn
+	suite.IdentityProviderRepository = postgres.NewIdentityProviderRepository(pgConn)
+}
+
+func (suite *IdentityProviderRepositoryTestSuite) SetupTest() {
+	query := "DELETE FROM identity_providers"
+	if _, err := suite.DB.Query(query); err != nil {
+		suite.T().Fatalf("suite.DB.Query(%q) err = %v; want nil", query, err)
+	}
+}
+
+func (suite *IdentityProviderRepositoryTestSuite) TestInsert() {
+	type args struct {
+		identityProvider userland.IdentityProvider
+	}
+
+	testCases := []struct {
+		name    string
+		args    args
+		wantErr error
+	}{
+		{
+			name: "inserted",
+			args: args{
+				identityProvider: userland.IdentityProvider{
+					Tenant:           "acme",
+					EntityID:         "https://idp.acme.com/metadata",
+					SSOURL:           "https://idp.acme.com/sso",
+					Certificates:     []string{"xxx"},
+					AttributeMapping: map[string]string{"email": "mail"},
+				},
+			},
+			wantErr: nil,
+		},
+		{
+			name: "failed_duplicate",
+			args: args{
+				identityProvider: userland.IdentityProvider{
+					Tenant:   "acme",
+					EntityID: "https://idp.acme.com/metadata",
+				},
+			},
+			wantErr: userland.ErrDuplicateKey,
+		},
+	}
+
+	for _, tc := range testCases {
+		suite.T().Run(tc.name, func(t *testing.T) {
+			err := suite.IdentityProviderRepository.Insert(context.Background(), &tc.args.identityProvider)
+			if err != tc.wantErr {
+				t.Fatalf("IdentityProviderRepository.Insert(identityProvider) err = %v; want %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func (suite *IdentityProviderRepositoryTestSuite) TestFindByTenant() {
+	defaultIdentityProvider := userland.IdentityProvider{
+		Tenant:           "acme",
+		EntityID:         "https://idp.acme.com/metadata",
+		Certificates:     []string{"xxx"},
+		AttributeMapping: map[string]string{"email": "mail"},
+		Domains:          []string{"acme.com"},
+	}
+	if err := suite.IdentityProviderRepository.Insert(context.Background(), &defaultIdentityProvider); err != nil {
+		suite.T().Fatalf("IdentityProviderRepository.Insert(identityProvider) err = %v; want nil", err)
+	}
+
+	type args struct {
+		tenant string
+	}
+
+	testCases := []struct {
+		name    string
+		args    args
+		wantErr error
+	}{
+		{
+			name: "found",
+			args: args{
+				tenant: "acme",
+			},
+			wantErr: nil,
+		},
+		{
+			name: "not found",
+			args: args{
+				tenant: "globex",
+			},
+			wantErr: userland.ErrIdentityProviderNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		suite.T().Run(tc.name, func(t *testing.T) {
+			identityProvider, err := suite.IdentityProviderRepository.FindByTenant(context.Background(), tc.args.tenant)
+			if err != tc.wantErr {
+				t.Fatalf("IdentityProviderRepository.FindByTenant(%q) err = %v; want %v", tc.args.tenant, err, tc.wantErr)
+			}
+			if tc.wantErr == nil && identityProvider.AttributeMapping["email"] != "mail" {
+				t.Errorf("IdentityProviderRepository.FindByTenant(%q) AttributeMapping[email] = %q; want %q", tc.args.tenant, identityProvider.AttributeMapping["email"], "mail")
+			}
+			if tc.wantErr == nil && !identityProvider.Owns("wile@acme.com") {
+				t.Errorf("IdentityProviderRepository.FindByTenant(%q) Domains = %v; want [acme.com]", tc.args.tenant, identityProvider.Domains)
+			}
+		})
+	}
+}
+
+func (suite *IdentityProviderRepositoryTestSuite) TestUpdate() {
+	defaultIdentityProvider := userland.IdentityProvider{
+		Tenant:   "acme",
+		EntityID: "https://idp.acme.com/metadata",
+	}
+	if err := suite.IdentityProviderRepository.Insert(context.Background(), &defaultIdentityProvider); err != nil {
+		suite.T().Fatalf("IdentityProviderRepository.Insert(identityProvider) err = %v; want nil", err)
+	}
+
+	type args struct {
+		identityProvider userland.IdentityProvider
+	}
+
+	testCases := []struct {
+		name    string
+		args    args
+		wantErr error
+	}{
+		{
+			name: "found",
+			args: args{
+				identityProvider: userland.IdentityProvider{
+					Tenant:       "acme",
+					EntityID:     "https://idp.acme.com/metadata/v2",
+					Certificates: []string{"yyy"},
+				},
+			},
+			wantErr: nil,
+		},
+		{
+			name: "not found",
+			args: args{
+				identityProvider: userland.IdentityProvider{
+					Tenant:   "globex",
+					EntityID: "https://idp.globex.com/metadata",
+				},
+			},
+			wantErr: userland.ErrIdentityProviderNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		suite.T().Run(tc.name, func(t *testing.T) {
+			err := suite.IdentityProviderRepository.Update(context.Background(), tc.args.identityProvider)
+			if err != tc.wantErr {
+				t.Fatalf("IdentityProviderRepository.Update(identityProvider) err = %v; want %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func (suite *IdentityProviderRepositoryTestSuite) TestLink() {
+	query := "DELETE FROM users"
+	if _, err := suite.DB.Query(query); err != nil {
+		suite.T().Fatalf("suite.DB.Query(%q) err = %v; want nil", query, err)
+	}
+	userRepository := postgres.NewUserRepository(suite.DB)
+	user := userlandtest.TestCreateUser(suite.T(), userRepository)
+	anotherUser := userlandtest.TestCreateUser(suite.T(), userRepository, userlandtest.WithUserEmail("another@gmail.com"))
+	identityProvider := userland.IdentityProvider{
+		Tenant:   "acme",
+		EntityID: "https://idp.acme.com/metadata",
+	}
+	if err := suite.IdentityProviderRepository.Insert(context.Background(), &identityProvider); err != nil {
+		suite.T().Fatalf("IdentityProviderRepository.Insert(identityProvider) err = %v; want nil", err)
+	}
+
+	if _, err := suite.IdentityProviderRepository.FindLinkedUserID(context.Background(), identityProvider.ID, "wile"); err != userland.ErrIdentityProviderLinkNotFound {
+		suite.T().Fatalf("IdentityProviderRepository.FindLinkedUserID() err = %v; want %v", err, userland.ErrIdentityProviderLinkNotFound)
+	}
+	for _, userID := range []int{user.ID, user.ID, anotherUser.ID} {
+		if err := suite.IdentityProviderRepository.Link(context.Background(), identityProvider.ID, "wile", userID); err != nil {
+			suite.T().Fatalf("IdentityProviderRepository.Link(%d) err = %v; want nil", userID, err)
+		}
+	}
+	userID, err := suite.IdentityProviderRepository.FindLinkedUserID(context.Background(), identityProvider.ID, "wile")
+	if err != nil || userID != anotherUser.ID {
+		suite.T().Errorf("IdentityProviderRepository.FindLinkedUserID() = %d, %v; want %d, nil", userID, err, anotherUser.ID)
+	}
+}
@@ -50,3 +50,39 @@ func TestEventRepository(t *testing.T) {
 	suite.Run(t, suiteTest)
 	suiteTest.Teardown()
 }
+
+func TestIdentityProviderRepository(t *testing.T) {
+	suiteTest := NewIdentityProviderRepositoryTestSuite(cfg)
+	suite.Run(t, suiteTest)
+	suiteTest.Teardown()
+}
+
+func TestSessionRepository(t *testing.T) {
+	suiteTest := NewSessionRepositoryTestSuite(cfg)
+	suite.Run(t, suiteTest)
+	suiteTest.Teardown()
+}
+
+func TestLoginRiskAssessmentRepository(t *testing.T) {
+	suiteTest := NewLoginRiskAssessmentRepositoryTestSuite(cfg)
+	suite.Run(t, suiteTest)
+	suiteTest.Teardown()
+}
+
+func TestClientRepository(t *testing.T) {
+	suiteTest := NewClientRepositoryTestSuite(cfg)
+	suite.Run(t, suiteTest)
+	suiteTest.Teardown()
+}
+
+func TestTxManager(t *testing.T) {
+	suiteTest := NewTxManagerTestSuite(cfg)
+	suite.Run(t, suiteTest)
+	suiteTest.Teardown()
+}
+
+func TestMigrationDriver(t *testing.T) {
+	suiteTest := NewMigrationDriverTestSuite(cfg)
+	suite.Run(t, suiteTest)
+	suiteTest.Teardown()
+}
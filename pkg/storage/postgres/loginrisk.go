@@ -0,0 +1,69 @@
+package postgres
+
+import (
+	"context"
+	"github.com/AdhityaRamadhanus/userland"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+/*
+LoginRiskAssessmentRepository is implementation of LoginRiskAssessmentRepository interface
+of userland domain using postgre
+*/
+type LoginRiskAssessmentRepository struct {
+	db database
+	repositoryOptions
+}
+
+//NewLoginRiskAssessmentRepository is constructor to create login risk assessment repository
+func NewLoginRiskAssessmentRepository(conn *sqlx.DB, opts ...RepositoryOption) *LoginRiskAssessmentRepository {
+	return &LoginRiskAssessmentRepository{
+		db:                conn,
+		repositoryOptions: buildRepositoryOptions(opts),
+	}
+}
+
+//Insert record login risk assessment to datastore
+func (l LoginRiskAssessmentRepository) Insert(ctx context.Context, assessment *userland.LoginRiskAssessment) error {
+	ctx, cancel := l.withTimeout(ctx)
+	defer cancel()
+
+	query := `INSERT INTO login_risk_assessments (
+				user_id,
+				ip,
+				user_agent,
+				score,
+				signals,
+				decision,
+				created_at
+			) VALUES ($1, $2, $3, $4, $5, $6, now()) RETURNING id, created_at`
+
+	row := l.db.QueryRowContext(ctx,
+		query,
+		assessment.UserID,
+		assessment.IP,
+		assessment.UserAgent,
+		assessment.Score,
+		pq.Array(assessment.Signals),
+		assessment.Decision,
+	)
+	if err := row.Scan(&assessment.ID, &assessment.CreatedAt); err != nil {
+		return errors.Wrap(err, "row.Scan() err")
+	}
+
+	return nil
+}
+
+//DeleteAllByUserID remove every assessment of a user
+func (l LoginRiskAssessmentRepository) DeleteAllByUserID(ctx context.Context, userID int) error {
+	ctx, cancel := l.withTimeout(ctx)
+	defer cancel()
+
+	if _, err := l.db.ExecContext(ctx, `DELETE FROM login_risk_assessments WHERE user_id=$1`, userID); err != nil {
+		return errors.Wrap(err, "db.Exec() err")
+	}
+
+	return nil
+}
@@ -0,0 +1,129 @@
+// +build integration
+
+package postgres_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/AdhityaRamadhanus/userland"
+	"github.com/AdhityaRamadhanus/userland/pkg/config"
+	"github.com/AdhityaRamadhanus/userland/pkg/storage/postgres"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/suite"
+)
+
+type LoginRiskAssessmentRepositoryTestSuite struct {
+	suite.Suite
+	Config                        *config.Configuration
+	DB                            *sqlx.DB
+	LoginRiskAssessmentRepository userland.LoginRiskAssessmentRepository
+}
+
+func NewLoginRiskAssessmentRepositoryTestSuite(cfg *config.Configuration) *LoginRiskAssessmentRepositoryTestSuite {
+	return &LoginRiskAssessmentRepositoryTestSuite{
+		Config: cfg,
+	}
+}
+
+func (suite *LoginRiskAssessmentRepositoryTestSuite) Teardown() {
+	suite.T().Log("Teardown LoginRiskAssessmentRepositoryTestSuite")
+	suite.DB.Close()
+}
+
+func (suite *LoginRiskAssessmentRepositoryTestSuite) SetupSuite() {
+	suite.T().Log("Connecting to postgres at", suite.Config.Postgres)
+	pgConn, err := postgres.CreateConnection(suite.Config.Postgres)
+	if err != nil {
+		suite.T().Fatalf("postgres.CreateConnection() err = %v; want nil", err)
+	}
+
+	suite.DB = pgConn
+	suite.LoginRiskAssessmentRepository = postgres.NewLoginRiskAssessmentRepository(pgConn)
+}
+
+func (suite *LoginRiskAssessmentRepositoryTestSuite) SetupTest() {
+	query := "DELETE FROM login_risk_assessments"
+	if _, err := suite.DB.Query(query); err != nil {
+		suite.T().Fatalf("suite.DB.Query(%q) err = %v; want nil", query, err)
+	}
+}
+
+func (suite *LoginRiskAssessmentRepositoryTestSuite) TestInsert() {
+	type args struct {
+		assessment userland.LoginRiskAssessment
+	}
+
+	testCases := []struct {
+		name    string
+		args    args
+		wantErr error
+	}{
+		{
+			name: "inserted with signals",
+			args: args{
+				assessment: userland.LoginRiskAssessment{
+					UserID:    1,
+					IP:        "10.0.0.1",
+					UserAgent: "Mozilla/5.0",
+					Score:     70,
+					Signals:   []string{"new_ip", "impossible_travel"},
+					Decision:  userland.LoginRiskDecisionChallenge,
+				},
+			},
+			wantErr: nil,
+		},
+		{
+			name: "inserted without signals",
+			args: args{
+				assessment: userland.LoginRiskAssessment{
+					UserID:   1,
+					Decision: userland.LoginRiskDecisionAllow,
+				},
+			},
+			wantErr: nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		suite.T().Run(tc.name, func(t *testing.T) {
+			err := suite.LoginRiskAssessmentRepository.Insert(context.Background(), &tc.args.assessment)
+			if err != tc.wantErr {
+				t.Fatalf("LoginRiskAssessmentRepository.Insert(assessment) err = %v; want %v", err, tc.wantErr)
+			}
+
+			signals := pq.StringArray{}
+			query := "SELECT signals FROM login_risk_assessments WHERE id=$1"
+			if err := suite.DB.QueryRow(query, tc.args.assessment.ID).Scan(&signals); err != nil {
+				t.Fatalf("suite.DB.QueryRow(%q) err = %v; want nil", query, err)
+			}
+			if len(signals) != len(tc.args.assessment.Signals) {
+				t.Errorf("signals = %v; want %v", signals, tc.args.assessment.Signals)
+			}
+		})
+	}
+}
+
+func (suite *LoginRiskAssessmentRepositoryTestSuite) TestDeleteAllByUserID() {
+	for _, userID := range []int{1, 1, 2} {
+		assessment := userland.LoginRiskAssessment{UserID: userID, IP: "10.0.0.1", Decision: userland.LoginRiskDecisionAllow}
+		if err := suite.LoginRiskAssessmentRepository.Insert(context.Background(), &assessment); err != nil {
+			suite.T().Fatalf("LoginRiskAssessmentRepository.Insert(user %d) err = %v; want nil", userID, err)
+		}
+	}
+
+	if err := suite.LoginRiskAssessmentRepository.DeleteAllByUserID(context.Background(), 1); err != nil {
+		suite.T().Fatalf("LoginRiskAssessmentRepository.DeleteAllByUserID(1) err = %v; want nil", err)
+	}
+	for userID, want := range map[int]int{1: 0, 2: 1} {
+		var count int
+		query := "SELECT count(*) FROM login_risk_assessments WHERE user_id=$1"
+		if err := suite.DB.Get(&count, query, userID); err != nil {
+			suite.T().Fatalf("suite.DB.Get(%q, %d) err = %v; want nil", query, userID, err)
+		}
+		if count != want {
+			suite.T().Errorf("login risk assessments of user %d = %d; want %d", userID, count, want)
+		}
+	}
+}
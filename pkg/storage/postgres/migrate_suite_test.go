@@ -0,0 +1,68 @@
+// +build integration
+
+package postgres_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/AdhityaRamadhanus/userland/pkg/config"
+	"github.com/AdhityaRamadhanus/userland/pkg/storage/postgres"
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq"
+	"github.com/stretchr/testify/suite"
+)
+
+type MigrationDriverTestSuite struct {
+	suite.Suite
+	Config *config.Configuration
+	DB     *sqlx.DB
+}
+
+func NewMigrationDriverTestSuite(cfg *config.Configuration) *MigrationDriverTestSuite {
+	return &MigrationDriverTestSuite{
+		Config: cfg,
+	}
+}
+
+func (suite *MigrationDriverTestSuite) Teardown() {
+	suite.T().Log("Teardown MigrationDriverTestSuite")
+	suite.DB.Close()
+}
+
+func (suite *MigrationDriverTestSuite) SetupSuite() {
+	suite.T().Log("Connecting to postgres at", suite.Config.Postgres)
+	pgConn, err := postgres.CreateConnection(suite.Config.Postgres)
+	if err != nil {
+		suite.T().Fatalf("postgres.CreateConnection() err = %v; want nil", err)
+	}
+
+	suite.DB = pgConn
+}
+
+func (suite *MigrationDriverTestSuite) TestLock() {
+	first := postgres.NewMigrationDriver(suite.DB)
+	second := postgres.NewMigrationDriver(suite.DB)
+	if err := first.Lock(context.Background()); err != nil {
+		suite.T().Fatalf("first.Lock() err = %v; want nil", err)
+	}
+
+	// another replica wait until the lock is released
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	if err := second.Lock(ctx); err == nil {
+		second.Unlock(context.Background())
+		suite.T().Fatalf("second.Lock() err = nil while locked; want err")
+	}
+
+	if err := first.Unlock(context.Background()); err != nil {
+		suite.T().Fatalf("first.Unlock() err = %v; want nil", err)
+	}
+	if err := second.Lock(context.Background()); err != nil {
+		suite.T().Fatalf("second.Lock() err = %v after unlock; want nil", err)
+	}
+	if _, _, err := second.Version(context.Background()); err != nil {
+		suite.T().Errorf("second.Version() err = %v; want nil", err)
+	}
+	second.Unlock(context.Background())
+}
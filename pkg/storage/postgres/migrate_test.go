@@ -0,0 +1,40 @@
+//+build unit
+
+package postgres_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/AdhityaRamadhanus/userland/pkg/storage/postgres"
+)
+
+func TestMigrations_embedded(t *testing.T) {
+	paths, err := filepath.Glob("migration/*.sql")
+	if err != nil {
+		t.Fatalf("filepath.Glob(migration) err = %v; want nil", err)
+	}
+	migrations, err := postgres.Migrations()
+	if err != nil {
+		t.Fatalf("postgres.Migrations() err = %v; want nil", err)
+	}
+	if len(migrations)*2 != len(paths) {
+		t.Fatalf("postgres.Migrations() = %d migrations; want %d, run go generate", len(migrations), len(paths)/2)
+	}
+
+	queries := map[string]string{}
+	for _, m := range migrations {
+		queries[m.String()+".up.sql"] = m.Up
+		queries[m.String()+".down.sql"] = m.Down
+	}
+	for _, path := range paths {
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatalf("ioutil.ReadFile(%q) err = %v; want nil", path, err)
+		}
+		if queries[filepath.Base(path)] != string(content) {
+			t.Errorf("embedded %s differ from the file, run go generate", filepath.Base(path))
+		}
+	}
+}
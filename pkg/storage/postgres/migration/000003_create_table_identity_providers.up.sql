@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS identity_providers (
+    id serial PRIMARY KEY,
+    tenant varchar(255) NOT NULL,
+    entity_id TEXT NOT NULL,
+    sso_url TEXT,
+    certificates text[],
+    attribute_mapping TEXT,
+    metadata TEXT,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP,
+
+	CONSTRAINT identity_providers_unique_tenant UNIQUE (tenant)
+);
+
+CREATE INDEX IF NOT EXISTS index_identity_providers_on_tenant ON public.identity_providers USING btree (tenant);
\ No newline at end of file
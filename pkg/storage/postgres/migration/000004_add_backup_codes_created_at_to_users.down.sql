@@ -0,0 +1 @@
+ALTER TABLE users DROP COLUMN IF EXISTS backup_codes_created_at;
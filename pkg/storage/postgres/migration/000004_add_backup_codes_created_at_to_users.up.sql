@@ -0,0 +1 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS backup_codes_created_at TIMESTAMP;
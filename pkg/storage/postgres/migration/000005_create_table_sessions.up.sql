@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS sessions (
+    id varchar(64) PRIMARY KEY,
+    user_id int NOT NULL,
+    ip TEXT,
+    client_id int,
+    client_name TEXT,
+    user_agent TEXT,
+    browser TEXT,
+    os TEXT,
+    device_type varchar(32),
+    last_seen_ip TEXT,
+    last_seen_at TIMESTAMP,
+    expiration bigint NOT NULL DEFAULT 0,
+    expired_at TIMESTAMP NOT NULL,
+    ended_at TIMESTAMP,
+    end_reason varchar(64),
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS index_sessions_on_user_id ON public.sessions USING btree (user_id);
+CREATE INDEX IF NOT EXISTS index_sessions_on_user_id_active ON public.sessions USING btree (user_id) WHERE ended_at IS NULL;
@@ -0,0 +1,6 @@
+ALTER TABLE events DROP COLUMN IF EXISTS country;
+ALTER TABLE events DROP COLUMN IF EXISTS city;
+ALTER TABLE events DROP COLUMN IF EXISTS asn;
+ALTER TABLE sessions DROP COLUMN IF EXISTS country;
+ALTER TABLE sessions DROP COLUMN IF EXISTS city;
+ALTER TABLE sessions DROP COLUMN IF EXISTS asn;
@@ -0,0 +1,6 @@
+ALTER TABLE events ADD COLUMN IF NOT EXISTS country varchar(2);
+ALTER TABLE events ADD COLUMN IF NOT EXISTS city varchar(128);
+ALTER TABLE events ADD COLUMN IF NOT EXISTS asn integer;
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS country varchar(2);
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS city varchar(128);
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS asn integer;
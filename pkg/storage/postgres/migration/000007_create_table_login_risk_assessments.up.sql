@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS login_risk_assessments (
+    id serial PRIMARY KEY,
+    user_id int NOT NULL,
+    ip TEXT,
+    user_agent TEXT,
+    score int NOT NULL DEFAULT 0,
+    signals TEXT[],
+    decision varchar(32) NOT NULL,
+    created_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS index_login_risk_assessments_on_user_id ON public.login_risk_assessments USING btree (user_id);
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS clients (
+    id serial PRIMARY KEY,
+    name TEXT NOT NULL,
+    type varchar(32) NOT NULL,
+    public boolean NOT NULL DEFAULT false,
+    secret_hash TEXT,
+    allowed_origins TEXT[],
+    status varchar(32) NOT NULL,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP
+);
@@ -0,0 +1 @@
+ALTER TABLE events ADD COLUMN IF NOT EXISTS changed_fields TEXT[];
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS index_users_on_deletion_requested_at;
+ALTER TABLE users DROP COLUMN IF EXISTS deletion_requested_at;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS identity_provider_links;
+ALTER TABLE identity_providers DROP COLUMN IF EXISTS domains;
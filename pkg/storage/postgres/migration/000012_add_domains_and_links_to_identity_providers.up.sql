@@ -0,0 +1,10 @@
+ALTER TABLE identity_providers ADD COLUMN IF NOT EXISTS domains text[] NOT NULL DEFAULT '{}';
+
+CREATE TABLE IF NOT EXISTS identity_provider_links (
+    identity_provider_id int NOT NULL REFERENCES identity_providers (id) ON DELETE CASCADE,
+    name_id TEXT NOT NULL,
+    user_id int NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at TIMESTAMP,
+
+    PRIMARY KEY (identity_provider_id, name_id)
+);
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS data_exports (
+    id serial PRIMARY KEY,
+    user_id int NOT NULL,
+    path TEXT,
+    requested_at TIMESTAMP NOT NULL,
+    started_at TIMESTAMP,
+    completed_at TIMESTAMP,
+    expired_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS index_data_exports_on_user_id ON public.data_exports USING btree (user_id);
+CREATE INDEX IF NOT EXISTS index_data_exports_on_requested_at_pending ON public.data_exports USING btree (requested_at) WHERE completed_at IS NULL;
+CREATE INDEX IF NOT EXISTS index_data_exports_on_expired_at ON public.data_exports USING btree (expired_at) WHERE completed_at IS NOT NULL;
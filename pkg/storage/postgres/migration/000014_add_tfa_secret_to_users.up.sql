@@ -0,0 +1 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS tfa_secret varchar(64);
@@ -0,0 +1,35 @@
+// Code generated by script/embed_migrations from migration; DO NOT EDIT.
+
+package postgres
+
+// migrationFiles hold the content of every file in migration keyed by file name
+var migrationFiles = map[string]string{
+	"000001_create_table_users.down.sql":                          "DROP TABLE IF EXISTS users;",
+	"000001_create_table_users.up.sql":                            "CREATE TABLE IF NOT EXISTS users (\n    id serial PRIMARY KEY,\n    email varchar(255) NOT NULL,\n    fullname varchar(255) NOT NULL,\n    phone varchar(255),\n    location varchar(255),\n    bio varchar(255),\n    web_url varchar(255),\n    picture_url varchar(255),\n    tfa_enabled boolean,\n    verified boolean,\n    password TEXT NOT NULL,\n    backup_codes text[],\n    tfa_enabled_at TIMESTAMP,\n    created_at TIMESTAMP,\n    updated_at TIMESTAMP,\n\n\tCONSTRAINT users_unique_email UNIQUE (email)\n);\n\nCREATE INDEX IF NOT EXISTS index_users_on_email ON public.users USING btree (email);",
+	"000002_create_table_events.down.sql":                         "DROP TABLE IF EXISTS events;",
+	"000002_create_table_events.up.sql":                           "CREATE TABLE IF NOT EXISTS events (\n    id serial PRIMARY KEY,\n    user_id int NOT NULL,\n    event varchar(255) NOT NULL,\n    user_agent TEXT,\n    ip TEXT,\n    client_id int,\n    client_name TEXT,\n    timestamp TIMESTAMP NOT NULL,\n    created_at TIMESTAMP\n);\n\nCREATE INDEX IF NOT EXISTS index_events_on_user_id ON public.events USING btree (user_id);\nCREATE INDEX IF NOT EXISTS index_events_on_event ON public.events USING btree (event);",
+	"000003_create_table_identity_providers.down.sql":             "DROP TABLE IF EXISTS identity_providers;",
+	"000003_create_table_identity_providers.up.sql":               "CREATE TABLE IF NOT EXISTS identity_providers (\n    id serial PRIMARY KEY,\n    tenant varchar(255) NOT NULL,\n    entity_id TEXT NOT NULL,\n    sso_url TEXT,\n    certificates text[],\n    attribute_mapping TEXT,\n    metadata TEXT,\n    created_at TIMESTAMP,\n    updated_at TIMESTAMP,\n\n\tCONSTRAINT identity_providers_unique_tenant UNIQUE (tenant)\n);\n\nCREATE INDEX IF NOT EXISTS index_identity_providers_on_tenant ON public.identity_providers USING btree (tenant);",
+	"000004_add_backup_codes_created_at_to_users.down.sql":        "ALTER TABLE users DROP COLUMN IF EXISTS backup_codes_created_at;\n",
+	"000004_add_backup_codes_created_at_to_users.up.sql":          "ALTER TABLE users ADD COLUMN IF NOT EXISTS backup_codes_created_at TIMESTAMP;\n",
+	"000005_create_table_sessions.down.sql":                       "DROP TABLE IF EXISTS sessions;\n",
+	"000005_create_table_sessions.up.sql":                         "CREATE TABLE IF NOT EXISTS sessions (\n    id varchar(64) PRIMARY KEY,\n    user_id int NOT NULL,\n    ip TEXT,\n    client_id int,\n    client_name TEXT,\n    user_agent TEXT,\n    browser TEXT,\n    os TEXT,\n    device_type varchar(32),\n    last_seen_ip TEXT,\n    last_seen_at TIMESTAMP,\n    expiration bigint NOT NULL DEFAULT 0,\n    expired_at TIMESTAMP NOT NULL,\n    ended_at TIMESTAMP,\n    end_reason varchar(64),\n    created_at TIMESTAMP,\n    updated_at TIMESTAMP\n);\n\nCREATE INDEX IF NOT EXISTS index_sessions_on_user_id ON public.sessions USING btree (user_id);\nCREATE INDEX IF NOT EXISTS index_sessions_on_user_id_active ON public.sessions USING btree (user_id) WHERE ended_at IS NULL;\n",
+	"000006_add_geolocation_to_events_and_sessions.down.sql":      "ALTER TABLE events DROP COLUMN IF EXISTS country;\nALTER TABLE events DROP COLUMN IF EXISTS city;\nALTER TABLE events DROP COLUMN IF EXISTS asn;\nALTER TABLE sessions DROP COLUMN IF EXISTS country;\nALTER TABLE sessions DROP COLUMN IF EXISTS city;\nALTER TABLE sessions DROP COLUMN IF EXISTS asn;\n",
+	"000006_add_geolocation_to_events_and_sessions.up.sql":        "ALTER TABLE events ADD COLUMN IF NOT EXISTS country varchar(2);\nALTER TABLE events ADD COLUMN IF NOT EXISTS city varchar(128);\nALTER TABLE events ADD COLUMN IF NOT EXISTS asn integer;\nALTER TABLE sessions ADD COLUMN IF NOT EXISTS country varchar(2);\nALTER TABLE sessions ADD COLUMN IF NOT EXISTS city varchar(128);\nALTER TABLE sessions ADD COLUMN IF NOT EXISTS asn integer;\n",
+	"000007_create_table_login_risk_assessments.down.sql":         "DROP TABLE IF EXISTS login_risk_assessments;\n",
+	"000007_create_table_login_risk_assessments.up.sql":           "CREATE TABLE IF NOT EXISTS login_risk_assessments (\n    id serial PRIMARY KEY,\n    user_id int NOT NULL,\n    ip TEXT,\n    user_agent TEXT,\n    score int NOT NULL DEFAULT 0,\n    signals TEXT[],\n    decision varchar(32) NOT NULL,\n    created_at TIMESTAMP\n);\n\nCREATE INDEX IF NOT EXISTS index_login_risk_assessments_on_user_id ON public.login_risk_assessments USING btree (user_id);\n",
+	"000008_create_table_clients.down.sql":                        "DROP TABLE IF EXISTS clients;\n",
+	"000008_create_table_clients.up.sql":                          "CREATE TABLE IF NOT EXISTS clients (\n    id serial PRIMARY KEY,\n    name TEXT NOT NULL,\n    type varchar(32) NOT NULL,\n    public boolean NOT NULL DEFAULT false,\n    secret_hash TEXT,\n    allowed_origins TEXT[],\n    status varchar(32) NOT NULL,\n    created_at TIMESTAMP,\n    updated_at TIMESTAMP\n);\n",
+	"000009_add_version_to_users.down.sql":                        "ALTER TABLE users DROP COLUMN IF EXISTS version;\n",
+	"000009_add_version_to_users.up.sql":                          "ALTER TABLE users ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;\n",
+	"000010_add_changed_fields_to_events.down.sql":                "ALTER TABLE events DROP COLUMN IF EXISTS changed_fields;\n",
+	"000010_add_changed_fields_to_events.up.sql":                  "ALTER TABLE events ADD COLUMN IF NOT EXISTS changed_fields TEXT[];\n",
+	"000011_add_deletion_requested_at_to_users.down.sql":          "DROP INDEX IF EXISTS index_users_on_deletion_requested_at;\nALTER TABLE users DROP COLUMN IF EXISTS deletion_requested_at;\n",
+	"000011_add_deletion_requested_at_to_users.up.sql":            "ALTER TABLE users ADD COLUMN IF NOT EXISTS deletion_requested_at TIMESTAMP;\nCREATE INDEX IF NOT EXISTS index_users_on_deletion_requested_at ON users (deletion_requested_at) WHERE deletion_requested_at IS NOT NULL;\n",
+	"000012_add_domains_and_links_to_identity_providers.down.sql": "DROP TABLE IF EXISTS identity_provider_links;\nALTER TABLE identity_providers DROP COLUMN IF EXISTS domains;\n",
+	"000012_add_domains_and_links_to_identity_providers.up.sql":   "ALTER TABLE identity_providers ADD COLUMN IF NOT EXISTS domains text[] NOT NULL DEFAULT '{}';\n\nCREATE TABLE IF NOT EXISTS identity_provider_links (\n    identity_provider_id int NOT NULL REFERENCES identity_providers (id) ON DELETE CASCADE,\n    name_id TEXT NOT NULL,\n    user_id int NOT NULL REFERENCES users (id) ON DELETE CASCADE,\n    created_at TIMESTAMP,\n\n    PRIMARY KEY (identity_provider_id, name_id)\n);\n",
+	"000013_create_table_data_exports.down.sql":                   "DROP TABLE IF EXISTS data_exports;\n",
+	"000013_create_table_data_exports.up.sql":                     "CREATE TABLE IF NOT EXISTS data_exports (\n    id serial PRIMARY KEY,\n    user_id int NOT NULL,\n    path TEXT,\n    requested_at TIMESTAMP NOT NULL,\n    started_at TIMESTAMP,\n    completed_at TIMESTAMP,\n    expired_at TIMESTAMP\n);\n\nCREATE INDEX IF NOT EXISTS index_data_exports_on_user_id ON public.data_exports USING btree (user_id);\nCREATE INDEX IF NOT EXISTS index_data_exports_on_requested_at_pending ON public.data_exports USING btree (requested_at) WHERE completed_at IS NULL;\nCREATE INDEX IF NOT EXISTS index_data_exports_on_expired_at ON public.data_exports USING btree (expired_at) WHERE completed_at IS NOT NULL;\n",
+	"000014_add_tfa_secret_to_users.down.sql":                     "ALTER TABLE users DROP COLUMN IF EXISTS tfa_secret;\n",
+	"000014_add_tfa_secret_to_users.up.sql":                       "ALTER TABLE users ADD COLUMN IF NOT EXISTS tfa_secret varchar(64);\n",
+}
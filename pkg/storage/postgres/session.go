@@ -0,0 +1,363 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/AdhityaRamadhanus/userland"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+type SessionScanStruct struct {
+	ID         string
+	UserID     int `db:"user_id"`
+	IP         sql.NullString
+	ClientID   sql.NullInt64  `db:"client_id"`
+	ClientName sql.NullString `db:"client_name"`
+	UserAgent  sql.NullString `db:"user_agent"`
+	Browser    sql.NullString
+	OS         sql.NullString
+	DeviceType sql.NullString `db:"device_type"`
+	Country    sql.NullString
+	City       sql.NullString
+	ASN        sql.NullInt64
+	LastSeenIP sql.NullString `db:"last_seen_ip"`
+	LastSeenAt pq.NullTime    `db:"last_seen_at"`
+	Expiration int64
+	ExpiredAt  time.Time      `db:"expired_at"`
+	EndedAt    pq.NullTime    `db:"ended_at"`
+	EndReason  sql.NullString `db:"end_reason"`
+	CreatedAt  time.Time      `db:"created_at"`
+	UpdatedAt  time.Time      `db:"updated_at"`
+}
+
+/*
+SessionRepository is implementation of SessionRepository interface
+of userland domain using postgre, ended sessions are kept with ended_at and end_reason for audit.
+Times are stored in UTC and compared against the app clock rather than now(), the columns have no time zone
+*/
+type SessionRepository struct {
+	db database
+	repositoryOptions
+}
+
+// NewSessionRepository is constructor to create session repository
+func NewSessionRepository(conn *sqlx.DB, opts ...RepositoryOption) *SessionRepository {
+	return &SessionRepository{
+		db:                conn,
+		repositoryOptions: buildRepositoryOptions(opts),
+	}
+}
+
+const sessionColumns = `id,
+				user_id,
+				ip,
+				client_id,
+				client_name,
+				user_agent,
+				browser,
+				os,
+				device_type,
+				country,
+				city,
+				asn,
+				last_seen_ip,
+				last_seen_at,
+				expiration,
+				expired_at,
+				ended_at,
+				end_reason,
+				created_at,
+				updated_at`
+
+// Create insert a new active session
+func (s SessionRepository) Create(ctx context.Context, userID int, session userland.Session) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	now := time.Now().UTC()
+	if session.LastSeenAt.IsZero() {
+		session.LastSeenAt = now
+	}
+	if session.LastSeenIP == "" {
+		session.LastSeenIP = session.IP
+	}
+	if session.ExpiredAt.IsZero() {
+		session.ExpiredAt = now.Add(session.Expiration)
+	}
+
+	query := `INSERT INTO sessions (
+				id,
+				user_id,
+				ip,
+				client_id,
+				client_name,
+				user_agent,
+				browser,
+				os,
+				device_type,
+				country,
+				city,
+				asn,
+				last_seen_ip,
+				last_seen_at,
+				expiration,
+				expired_at,
+				created_at,
+				updated_at
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NULLIF($10, ''), NULLIF($11, ''), NULLIF($12, 0), $13, $14, $15, $16, $17, $17)`
+
+	_, err := s.db.ExecContext(ctx,
+		query,
+		session.ID,
+		userID,
+		session.IP,
+		session.ClientID,
+		session.ClientName,
+		session.UserAgent,
+		session.Browser,
+		session.OS,
+		session.DeviceType,
+		session.Country,
+		session.City,
+		session.ASN,
+		session.LastSeenIP,
+		session.LastSeenAt.UTC(),
+		int64(session.Expiration.Seconds()),
+		session.ExpiredAt.UTC(),
+		now,
+	)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "unique_violation" {
+			return userland.ErrDuplicateKey
+		}
+		return errors.Wrap(err, "db.Exec() err")
+	}
+
+	return nil
+}
+
+// Find active session of a user
+func (s SessionRepository) Find(ctx context.Context, userID int, sessionID string) (userland.Session, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	sessionScanStruct := SessionScanStruct{}
+	query := `SELECT ` + sessionColumns + `
+			FROM sessions
+			WHERE id=$1 AND user_id=$2 AND ended_at IS NULL AND expired_at > $3`
+
+	stmt, err := s.db.PreparexContext(ctx, query)
+	if err != nil {
+		return userland.Session{}, errors.Wrap(err, "db.Preparex(query) err")
+	}
+	defer stmt.Close()
+
+	if err := stmt.GetContext(ctx, &sessionScanStruct, sessionID, userID, time.Now().UTC()); err != nil {
+		if err == sql.ErrNoRows {
+			return userland.Session{}, userland.ErrSessionNotFound
+		}
+		return userland.Session{}, errors.Wrap(err, "stmt.Get() err")
+	}
+
+	return s.convertStructScanToEntity(sessionScanStruct), nil
+}
+
+// FindAllByUserID return sessions of a user that are neither ended nor expired
+func (s SessionRepository) FindAllByUserID(ctx context.Context, userID int) (userland.Sessions, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	scanStructSessions := []SessionScanStruct{}
+	query := `SELECT ` + sessionColumns + `
+			FROM sessions
+			WHERE user_id=$1 AND ended_at IS NULL AND expired_at > $2
+			ORDER BY created_at ASC`
+
+	stmt, err := s.db.PreparexContext(ctx, query)
+	if err != nil {
+		return nil, errors.Wrap(err, "db.Preparex(query) err")
+	}
+	defer stmt.Close()
+
+	if err := stmt.SelectContext(ctx, &scanStructSessions, userID, time.Now().UTC()); err != nil {
+		return nil, errors.Wrap(err, "stmt.Select() err")
+	}
+
+	sessions := userland.Sessions{}
+	for _, scanStructSession := range scanStructSessions {
+		sessions = append(sessions, s.convertStructScanToEntity(scanStructSession))
+	}
+	return sessions, nil
+}
+
+// Update activity and expiration of an active session
+func (s SessionRepository) Update(ctx context.Context, userID int, session userland.Session) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `UPDATE sessions SET (
+				last_seen_ip,
+				last_seen_at,
+				expired_at,
+				updated_at
+			) = ($3, $4, $5, $6) WHERE id=$1 AND user_id=$2 AND ended_at IS NULL`
+
+	res, err := s.db.ExecContext(ctx, query, session.ID, userID, session.LastSeenIP, session.LastSeenAt.UTC(), session.ExpiredAt.UTC(), time.Now().UTC())
+	if err != nil {
+		return errors.Wrap(err, "db.Exec() err")
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "res.RowsAffected() err")
+	}
+
+	if rowsAffected == 0 {
+		return userland.ErrSessionNotFound
+	}
+
+	return nil
+}
+
+// DeleteExpiredSessions end sessions past their expiration
+func (s SessionRepository) DeleteExpiredSessions(ctx context.Context, userID int) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `UPDATE sessions SET (ended_at, end_reason, updated_at) = (expired_at, $2, $3)
+			WHERE user_id=$1 AND ended_at IS NULL AND expired_at <= $3`
+
+	if _, err := s.db.ExecContext(ctx, query, userID, userland.SessionEndReasonExpired, time.Now().UTC()); err != nil {
+		return errors.Wrap(err, "db.Exec() err")
+	}
+
+	return nil
+}
+
+// DeleteBySessionID end a session, the row is kept for audit
+func (s SessionRepository) DeleteBySessionID(ctx context.Context, userID int, sessionID string) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `UPDATE sessions SET (ended_at, end_reason, updated_at) = ($4, $3, $4)
+			WHERE id=$1 AND user_id=$2 AND ended_at IS NULL`
+
+	res, err := s.db.ExecContext(ctx, query, sessionID, userID, userland.SessionEndReasonLogout, time.Now().UTC())
+	if err != nil {
+		return errors.Wrap(err, "db.Exec() err")
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "res.RowsAffected() err")
+	}
+
+	if rowsAffected == 0 {
+		return userland.ErrSessionNotFound
+	}
+
+	return nil
+}
+
+// DeleteOtherSessions end every active session of a user except the current one
+func (s SessionRepository) DeleteOtherSessions(ctx context.Context, userID int, currentSessionID string) (deletedSessionIDs []string, err error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `UPDATE sessions SET (ended_at, end_reason, updated_at) = ($4, $3, $4)
+			WHERE user_id=$1 AND id<>$2 AND ended_at IS NULL
+			RETURNING id`
+
+	deletedSessionIDs = []string{}
+	if err := s.db.SelectContext(ctx, &deletedSessionIDs, query, userID, currentSessionID, userland.SessionEndReasonRevoked, time.Now().UTC()); err != nil {
+		return nil, errors.Wrap(err, "db.Select() err")
+	}
+
+	return deletedSessionIDs, nil
+}
+
+// EvictSessions end sessions pushed out by the concurrent session limits
+func (s SessionRepository) EvictSessions(ctx context.Context, userID int, sessionIDs []string) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `UPDATE sessions SET (ended_at, end_reason, updated_at) = ($4, $3, $4)
+			WHERE user_id=$1 AND id = ANY($2) AND ended_at IS NULL`
+
+	if _, err := s.db.ExecContext(ctx, query, userID, pq.Array(sessionIDs), userland.SessionEndReasonEvicted, time.Now().UTC()); err != nil {
+		return errors.Wrap(err, "db.Exec() err")
+	}
+
+	return nil
+}
+
+// DeleteAllByUserID remove every session of a user, ended ones included
+func (s SessionRepository) DeleteAllByUserID(ctx context.Context, userID int) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM sessions WHERE user_id=$1`, userID); err != nil {
+		return errors.Wrap(err, "db.Exec() err")
+	}
+
+	return nil
+}
+
+func (s SessionRepository) convertStructScanToEntity(sessionScanStruct SessionScanStruct) userland.Session {
+	session := userland.Session{
+		ID:         sessionScanStruct.ID,
+		Expiration: time.Duration(sessionScanStruct.Expiration) * time.Second,
+		ExpiredAt:  sessionScanStruct.ExpiredAt,
+		CreatedAt:  sessionScanStruct.CreatedAt,
+		UpdatedAt:  sessionScanStruct.UpdatedAt,
+	}
+
+	if sessionScanStruct.IP.Valid {
+		session.IP = sessionScanStruct.IP.String
+	}
+	if sessionScanStruct.ClientID.Valid {
+		session.ClientID = int(sessionScanStruct.ClientID.Int64)
+	}
+	if sessionScanStruct.ClientName.Valid {
+		session.ClientName = sessionScanStruct.ClientName.String
+	}
+	if sessionScanStruct.UserAgent.Valid {
+		session.UserAgent = sessionScanStruct.UserAgent.String
+	}
+	if sessionScanStruct.Browser.Valid {
+		session.Browser = sessionScanStruct.Browser.String
+	}
+	if sessionScanStruct.OS.Valid {
+		session.OS = sessionScanStruct.OS.String
+	}
+	if sessionScanStruct.DeviceType.Valid {
+		session.DeviceType = sessionScanStruct.DeviceType.String
+	}
+	if sessionScanStruct.Country.Valid {
+		session.Country = sessionScanStruct.Country.String
+	}
+	if sessionScanStruct.City.Valid {
+		session.City = sessionScanStruct.City.String
+	}
+	if sessionScanStruct.ASN.Valid {
+		session.ASN = int(sessionScanStruct.ASN.Int64)
+	}
+	if sessionScanStruct.LastSeenIP.Valid {
+		session.LastSeenIP = sessionScanStruct.LastSeenIP.String
+	}
+	if sessionScanStruct.LastSeenAt.Valid {
+		session.LastSeenAt = sessionScanStruct.LastSeenAt.Time
+	}
+	if sessionScanStruct.EndedAt.Valid {
+		session.EndedAt = sessionScanStruct.EndedAt.Time
+	}
+	if sessionScanStruct.EndReason.Valid {
+		session.EndReason = sessionScanStruct.EndReason.String
+	}
+
+	return session
+}
@@ -0,0 +1,302 @@
+// +build integration
+
+package postgres_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/AdhityaRamadhanus/userland"
+	"github.com/AdhityaRamadhanus/userland/pkg/config"
+	"github.com/AdhityaRamadhanus/userland/pkg/storage/postgres"
+	"github.com/AdhityaRamadhanus/userland/pkg/userlandtest"
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq"
+	"github.com/stretchr/testify/suite"
+)
+
+type SessionRepositoryTestSuite struct {
+	suite.Suite
+	Config            *config.Configuration
+	DB                *sqlx.DB
+	SessionRepository userland.SessionRepository
+}
+
+func NewSessionRepositoryTestSuite(cfg *config.Configuration) *SessionRepositoryTestSuite {
+	return &SessionRepositoryTestSuite{
+		Config: cfg,
+	}
+}
+
+func (suite *SessionRepositoryTestSuite) Teardown() {
+	suite.T().Log("Teardown SessionRepositoryTestSuite")
+	suite.DB.Close()
+}
+
+func (suite *SessionRepositoryTestSuite) SetupSuite() {
+	suite.T().Log("Connecting to postgres at", suite.Config.Postgres)
+	pgConn, err := postgres.CreateConnection(suite.Config.Postgres)
+	if err != nil {
+		suite.T().Fatalf("postgres.CreateConnection() err = %v; want nil", err)
+	}
+
+	suite.DB = pgConn
+	suite.SessionRepository = postgres.NewSessionRepository(pgConn)
+}
+
+func (suite *SessionRepositoryTestSuite) SetupTest() {
+	query := "DELETE FROM sessions"
+	if _, err := suite.DB.Exec(query); err != nil {
+		suite.T().Fatalf("suite.DB.Exec(%q) err = %v; want nil", query, err)
+	}
+}
+
+func (suite *SessionRepositoryTestSuite) TestFind() {
+	type args struct {
+		userID int
+	}
+	testCases := []struct {
+		name    string
+		args    args
+		create  bool
+		wantErr error
+	}{
+		{
+			name: "success",
+			args: args{
+				userID: 1,
+			},
+			create:  true,
+			wantErr: nil,
+		},
+		{
+			name: "not found",
+			args: args{
+				userID: 2,
+			},
+			create:  false,
+			wantErr: userland.ErrSessionNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		suite.T().Run(tc.name, func(t *testing.T) {
+			sessionID := "unknown-session"
+			if tc.create {
+				sessionID = userlandtest.TestCreateSession(t, suite.SessionRepository, userlandtest.WithUserID(tc.args.userID)).ID
+			}
+			session, err := suite.SessionRepository.Find(context.Background(), tc.args.userID, sessionID)
+			if err != tc.wantErr {
+				t.Fatalf("SessionRepository.Find(%d, %s) err = %v; want %v", tc.args.userID, sessionID, err, tc.wantErr)
+			}
+			if err == nil && session.ID != sessionID {
+				t.Errorf("SessionRepository.Find(%d, %s) session.ID = %s; want %s", tc.args.userID, sessionID, session.ID, sessionID)
+			}
+		})
+	}
+}
+
+func (suite *SessionRepositoryTestSuite) TestFind_timeZone() {
+	userID := 1
+	// app clock far from the database time zone must neither keep an expired session nor shift expired_at
+	zone := time.FixedZone("UTC+7", 7*60*60)
+	testCases := []struct {
+		name      string
+		expiredAt time.Time
+		wantErr   error
+	}{
+		{
+			name:      "active",
+			expiredAt: time.Now().Add(time.Minute).In(zone),
+			wantErr:   nil,
+		},
+		{
+			name:      "expired",
+			expiredAt: time.Now().Add(-time.Minute).In(zone),
+			wantErr:   userland.ErrSessionNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		suite.T().Run(tc.name, func(t *testing.T) {
+			session := userland.Session{
+				ID:         tc.name,
+				IP:         "127.0.0.1",
+				Expiration: time.Minute,
+				ExpiredAt:  tc.expiredAt,
+			}
+			if err := suite.SessionRepository.Create(context.Background(), userID, session); err != nil {
+				t.Fatalf("SessionRepository.Create(%d, <session>) err = %v; want nil", userID, err)
+			}
+
+			found, err := suite.SessionRepository.Find(context.Background(), userID, session.ID)
+			if err != tc.wantErr {
+				t.Fatalf("SessionRepository.Find(%d, %s) err = %v; want %v", userID, session.ID, err, tc.wantErr)
+			}
+			if err == nil && found.ExpiredAt.Sub(tc.expiredAt).Round(time.Second) != 0 {
+				t.Errorf("SessionRepository.Find(%d, %s) ExpiredAt = %v; want %v", userID, session.ID, found.ExpiredAt, tc.expiredAt)
+			}
+		})
+	}
+}
+
+func (suite *SessionRepositoryTestSuite) TestUpdate() {
+	userID := 1
+	createdSession := userlandtest.TestCreateSession(suite.T(), suite.SessionRepository, userlandtest.WithUserID(userID))
+	session, err := suite.SessionRepository.Find(context.Background(), userID, createdSession.ID)
+	if err != nil {
+		suite.T().Fatalf("SessionRepository.Find(%d, %s) err = %v; want nil", userID, createdSession.ID, err)
+	}
+
+	session.LastSeenIP = "10.10.10.10"
+	session.LastSeenAt = time.Now()
+	if err := suite.SessionRepository.Update(context.Background(), userID, session); err != nil {
+		suite.T().Fatalf("SessionRepository.Update(%d, <session>) err = %v; want nil", userID, err)
+	}
+
+	updatedSession, err := suite.SessionRepository.Find(context.Background(), userID, session.ID)
+	if err != nil {
+		suite.T().Fatalf("SessionRepository.Find(%d, %s) err = %v; want nil", userID, session.ID, err)
+	}
+	if updatedSession.LastSeenIP != session.LastSeenIP {
+		suite.T().Errorf("SessionRepository.Find(%d, %s) LastSeenIP = %s; want %s", userID, session.ID, updatedSession.LastSeenIP, session.LastSeenIP)
+	}
+}
+
+func (suite *SessionRepositoryTestSuite) TestDeleteExpiredSessions() {
+	userID := 1
+	shortExp := 100 * time.Millisecond
+	userlandtest.TestCreateSessions(suite.T(), suite.SessionRepository,
+		userlandtest.WithUserID(userID),
+		userlandtest.WithNumberOfSessions(1),
+		userlandtest.WithExpiration(shortExp),
+	)
+	userlandtest.TestCreateSessions(suite.T(), suite.SessionRepository,
+		userlandtest.WithUserID(userID),
+		userlandtest.WithNumberOfSessions(2),
+		userlandtest.WithExpiration(100*time.Second),
+	)
+	time.Sleep(2 * shortExp)
+
+	if err := suite.SessionRepository.DeleteExpiredSessions(context.Background(), userID); err != nil {
+		suite.T().Fatalf("SessionRepository.DeleteExpiredSessions(%d) err = %v; want nil", userID, err)
+	}
+	sessions, err := suite.SessionRepository.FindAllByUserID(context.Background(), userID)
+	if err != nil {
+		suite.T().Fatalf("SessionRepository.FindAllByUserID(%d) err = %v; want nil", userID, err)
+	}
+	if len(sessions) != 2 {
+		suite.T().Errorf("SessionRepository.FindAllByUserID(%d) len(sessions) = %d; want 2", userID, len(sessions))
+	}
+}
+
+func (suite *SessionRepositoryTestSuite) TestFindAllByUserIDSkipExpired() {
+	userID := 1
+	shortExp := 100 * time.Millisecond
+	userlandtest.TestCreateSessions(suite.T(), suite.SessionRepository,
+		userlandtest.WithUserID(userID),
+		userlandtest.WithNumberOfSessions(1),
+		userlandtest.WithExpiration(shortExp),
+	)
+	userlandtest.TestCreateSessions(suite.T(), suite.SessionRepository,
+		userlandtest.WithUserID(userID),
+		userlandtest.WithNumberOfSessions(2),
+		userlandtest.WithExpiration(100*time.Second),
+	)
+	time.Sleep(2 * shortExp)
+
+	sessions, err := suite.SessionRepository.FindAllByUserID(context.Background(), userID)
+	if err != nil {
+		suite.T().Fatalf("SessionRepository.FindAllByUserID(%d) err = %v; want nil", userID, err)
+	}
+	if len(sessions) != 2 {
+		suite.T().Errorf("SessionRepository.FindAllByUserID(%d) len(sessions) = %d; want 2", userID, len(sessions))
+	}
+}
+
+func (suite *SessionRepositoryTestSuite) TestDeleteBySessionID() {
+	userID := 1
+	sessions := userlandtest.TestCreateSessions(suite.T(), suite.SessionRepository,
+		userlandtest.WithUserID(userID),
+		userlandtest.WithNumberOfSessions(3),
+	)
+	endedSession := sessions[len(sessions)-1]
+	if err := suite.SessionRepository.DeleteBySessionID(context.Background(), userID, endedSession.ID); err != nil {
+		suite.T().Fatalf("SessionRepository.DeleteBySessionID(%d, %s) err = %v; want nil", userID, endedSession.ID, err)
+	}
+	if err := suite.SessionRepository.DeleteBySessionID(context.Background(), userID, endedSession.ID); err != userland.ErrSessionNotFound {
+		suite.T().Errorf("SessionRepository.DeleteBySessionID(%d, %s) second call err = %v; want %v", userID, endedSession.ID, err, userland.ErrSessionNotFound)
+	}
+
+	activeSessions, err := suite.SessionRepository.FindAllByUserID(context.Background(), userID)
+	if err != nil {
+		suite.T().Fatalf("SessionRepository.FindAllByUserID(%d) err = %v; want nil", userID, err)
+	}
+	if len(activeSessions) != 2 {
+		suite.T().Errorf("SessionRepository.FindAllByUserID(%d) len(sessions) = %d; want 2", userID, len(activeSessions))
+	}
+
+	// ended session is retained for audit
+	var endReason string
+	query := "SELECT end_reason FROM sessions WHERE id=$1 AND ended_at IS NOT NULL"
+	if err := suite.DB.Get(&endReason, query, endedSession.ID); err != nil {
+		suite.T().Fatalf("suite.DB.Get(%q) err = %v; want nil", query, err)
+	}
+	if endReason != userland.SessionEndReasonLogout {
+		suite.T().Errorf("end_reason = %s; want %s", endReason, userland.SessionEndReasonLogout)
+	}
+}
+
+func (suite *SessionRepositoryTestSuite) TestDeleteOtherSessions() {
+	userID := 1
+	sessions := userlandtest.TestCreateSessions(suite.T(), suite.SessionRepository,
+		userlandtest.WithUserID(userID),
+		userlandtest.WithNumberOfSessions(3),
+	)
+	keepSessionID := sessions[0].ID
+	deletedSessionIDs, err := suite.SessionRepository.DeleteOtherSessions(context.Background(), userID, keepSessionID)
+	if err != nil {
+		suite.T().Fatalf("SessionRepository.DeleteOtherSessions(%d, %s) err = %v; want nil", userID, keepSessionID, err)
+	}
+	if len(deletedSessionIDs) != 2 {
+		suite.T().Errorf("SessionRepository.DeleteOtherSessions(%d, %s) len(deletedSessionIDs) = %d; want 2", userID, keepSessionID, len(deletedSessionIDs))
+	}
+
+	activeSessions, err := suite.SessionRepository.FindAllByUserID(context.Background(), userID)
+	if err != nil {
+		suite.T().Fatalf("SessionRepository.FindAllByUserID(%d) err = %v; want nil", userID, err)
+	}
+	if len(activeSessions) != 1 || activeSessions[0].ID != keepSessionID {
+		suite.T().Errorf("SessionRepository.FindAllByUserID(%d) = %v; want only %s", userID, activeSessions, keepSessionID)
+	}
+}
+
+func (suite *SessionRepositoryTestSuite) TestEvictSessions() {
+	userID := 1
+	sessions := userlandtest.TestCreateSessions(suite.T(), suite.SessionRepository,
+		userlandtest.WithUserID(userID),
+		userlandtest.WithNumberOfSessions(3),
+	)
+	evictedSessionIDs := []string{sessions[0].ID, sessions[1].ID}
+	if err := suite.SessionRepository.EvictSessions(context.Background(), userID, evictedSessionIDs); err != nil {
+		suite.T().Fatalf("SessionRepository.EvictSessions(%d, %v) err = %v; want nil", userID, evictedSessionIDs, err)
+	}
+
+	activeSessions, err := suite.SessionRepository.FindAllByUserID(context.Background(), userID)
+	if err != nil {
+		suite.T().Fatalf("SessionRepository.FindAllByUserID(%d) err = %v; want nil", userID, err)
+	}
+	if len(activeSessions) != 1 || activeSessions[0].ID != sessions[2].ID {
+		suite.T().Errorf("SessionRepository.FindAllByUserID(%d) = %v; want only %s", userID, activeSessions, sessions[2].ID)
+	}
+
+	var endReason string
+	query := "SELECT end_reason FROM sessions WHERE id=$1 AND ended_at IS NOT NULL"
+	if err := suite.DB.Get(&endReason, query, sessions[0].ID); err != nil {
+		suite.T().Fatalf("suite.DB.Get(%q) err = %v; want nil", query, err)
+	}
+	if endReason != userland.SessionEndReasonEvicted {
+		suite.T().Errorf("end_reason = %s; want %s", endReason, userland.SessionEndReasonEvicted)
+	}
+}
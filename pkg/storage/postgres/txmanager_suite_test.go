@@ -0,0 +1,109 @@
+// +build integration
+
+package postgres_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/AdhityaRamadhanus/userland"
+	"github.com/AdhityaRamadhanus/userland/pkg/config"
+	"github.com/AdhityaRamadhanus/userland/pkg/storage/postgres"
+	"github.com/AdhityaRamadhanus/userland/pkg/userlandtest"
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq"
+	"github.com/stretchr/testify/suite"
+)
+
+type TxManagerTestSuite struct {
+	suite.Suite
+	Config          *config.Configuration
+	DB              *sqlx.DB
+	UserRepository  userland.UserRepository
+	EventRepository userland.EventRepository
+	TxManager       userland.TxManager
+}
+
+func NewTxManagerTestSuite(cfg *config.Configuration) *TxManagerTestSuite {
+	return &TxManagerTestSuite{
+		Config: cfg,
+	}
+}
+
+func (suite *TxManagerTestSuite) Teardown() {
+	suite.T().Log("Teardown TxManagerTestSuite")
+	suite.DB.Close()
+}
+
+func (suite *TxManagerTestSuite) SetupSuite() {
+	suite.T().Log("Connecting to postgres at", suite.Config.Postgres)
+	pgConn, err := postgres.CreateConnection(suite.Config.Postgres)
+	if err != nil {
+		suite.T().Fatalf("postgres.CreateConnection() err = %v; want nil", err)
+	}
+
+	suite.DB = pgConn
+	suite.UserRepository = postgres.NewUserRepository(pgConn)
+	suite.EventRepository = postgres.NewEventRepository(pgConn)
+	suite.TxManager = postgres.NewTxManager(pgConn)
+}
+
+func (suite *TxManagerTestSuite) SetupTest() {
+	queries := []string{
+		"DELETE FROM users",
+		"DELETE FROM events",
+	}
+
+	for _, query := range queries {
+		if _, err := suite.DB.Query(query); err != nil {
+			suite.T().Fatalf("DB.Query(%q) err = %v; want nil", query, err)
+		}
+	}
+}
+
+func (suite *TxManagerTestSuite) TestWithinTxCommit() {
+	user := userlandtest.TestCreateUser(suite.T(), suite.UserRepository)
+	user.Fullname = "Committed"
+
+	err := suite.TxManager.WithinTx(context.Background(), func(repositories userland.TxRepositories) error {
+		if err := repositories.EventRepository.Insert(context.Background(), userland.Event{UserID: user.ID, Event: "user.login", Timestamp: time.Now()}); err != nil {
+			return err
+		}
+		return repositories.UserRepository.Update(context.Background(), *user)
+	})
+	if err != nil {
+		suite.T().Fatalf("TxManager.WithinTx() err = %v; want nil", err)
+	}
+
+	updatedUser, err := suite.UserRepository.Find(context.Background(), user.ID)
+	if err != nil || updatedUser.Fullname != "Committed" {
+		suite.T().Errorf("UserRepository.Find() = %q, %v; want %q, nil", updatedUser.Fullname, err, "Committed")
+	}
+	_, count, err := suite.EventRepository.FindAll(context.Background(), userland.EventFilterOptions{UserID: user.ID}, userland.EventPagingOptions{Limit: 10})
+	if err != nil || count != 1 {
+		suite.T().Errorf("EventRepository.FindAll() count = %d, err = %v; want 1, nil", count, err)
+	}
+}
+
+func (suite *TxManagerTestSuite) TestWithinTxRollback() {
+	user := userlandtest.TestCreateUser(suite.T(), suite.UserRepository)
+	errAbort := errors.New("abort")
+
+	err := suite.TxManager.WithinTx(context.Background(), func(repositories userland.TxRepositories) error {
+		if err := repositories.EventRepository.DeleteAllByUserID(context.Background(), user.ID); err != nil {
+			return err
+		}
+		if err := repositories.UserRepository.Delete(context.Background(), user.ID); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	if err != errAbort {
+		suite.T().Fatalf("TxManager.WithinTx() err = %v; want %v", err, errAbort)
+	}
+
+	if _, err := suite.UserRepository.Find(context.Background(), user.ID); err != nil {
+		suite.T().Errorf("UserRepository.Find() err = %v after rollback; want nil", err)
+	}
+}
@@ -1,7 +1,10 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/AdhityaRamadhanus/userland"
@@ -24,8 +27,14 @@ type UserScanStruct struct {
 	Verified     sql.NullBool
 	BackupCodes  pq.StringArray `db:"backup_codes"`
 	TFAEnabledAt pq.NullTime    `db:"tfa_enabled_at"`
-	CreatedAt    time.Time      `db:"created_at"`
-	UpdatedAt    time.Time      `db:"updated_at"`
+	TFASecret    sql.NullString `db:"tfa_secret"`
+	// BackupCodesCreatedAt is null when backup codes is never generated
+	BackupCodesCreatedAt pq.NullTime `db:"backup_codes_created_at"`
+	CreatedAt            time.Time   `db:"created_at"`
+	UpdatedAt            time.Time   `db:"updated_at"`
+	Version              int
+	// DeletionRequestedAt is null unless the account is pending deletion
+	DeletionRequestedAt pq.NullTime `db:"deletion_requested_at"`
 }
 
 /*
@@ -33,18 +42,23 @@ UserRepository is implementation of UserRepository interface
 of userland domain using postgre
 */
 type UserRepository struct {
-	db *sqlx.DB
+	db database
+	repositoryOptions
 }
 
 //NewUserRepository is constructor to create story repository
-func NewUserRepository(conn *sqlx.DB) *UserRepository {
+func NewUserRepository(conn *sqlx.DB, opts ...RepositoryOption) *UserRepository {
 	return &UserRepository{
-		db: conn,
+		db:                conn,
+		repositoryOptions: buildRepositoryOptions(opts),
 	}
 }
 
 //Find User by id
-func (s UserRepository) Find(id int) (user userland.User, err error) {
+func (s UserRepository) Find(ctx context.Context, id int) (user userland.User, err error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
 	userScanStruct := UserScanStruct{}
 	query := `SELECT
 				id,
@@ -60,17 +74,21 @@ func (s UserRepository) Find(id int) (user userland.User, err error) {
 				password,
 				backup_codes,
 				tfa_enabled_at,
+				tfa_secret,
+				backup_codes_created_at,
 				created_at, 
-				updated_at
+				updated_at,
+				version,
+				deletion_requested_at
 			FROM users 
 			WHERE id=$1`
 
-	stmt, err := s.db.Preparex(query)
+	stmt, err := s.db.PreparexContext(ctx, query)
 	if err != nil {
 		return userland.User{}, errors.Wrap(err, "db.Preparex(query) err")
 	}
 
-	if err := stmt.Get(&userScanStruct, id); err != nil {
+	if err := stmt.GetContext(ctx, &userScanStruct, id); err != nil {
 		if err == sql.ErrNoRows {
 			return userland.User{}, userland.ErrUserNotFound
 		}
@@ -81,7 +99,10 @@ func (s UserRepository) Find(id int) (user userland.User, err error) {
 }
 
 //FindByEmail User by email
-func (s UserRepository) FindByEmail(email string) (user userland.User, err error) {
+func (s UserRepository) FindByEmail(ctx context.Context, email string) (user userland.User, err error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
 	userScanStruct := UserScanStruct{}
 	query := `SELECT
 				id,
@@ -97,17 +118,21 @@ func (s UserRepository) FindByEmail(email string) (user userland.User, err error
 				password,
 				backup_codes,
 				tfa_enabled_at,
+				tfa_secret,
+				backup_codes_created_at,
 				created_at, 
-				updated_at
+				updated_at,
+				version,
+				deletion_requested_at
 			FROM users 
 			WHERE email=$1`
 
-	stmt, err := s.db.Preparex(query)
+	stmt, err := s.db.PreparexContext(ctx, query)
 	if err != nil {
 		return userland.User{}, errors.Wrap(err, "db.Preparex(query) err")
 	}
 
-	if err := stmt.Get(&userScanStruct, email); err != nil {
+	if err := stmt.GetContext(ctx, &userScanStruct, email); err != nil {
 		if err == sql.ErrNoRows {
 			return userland.User{}, userland.ErrUserNotFound
 		}
@@ -117,17 +142,66 @@ func (s UserRepository) FindByEmail(email string) (user userland.User, err error
 	return s.convertStructScanToEntity(userScanStruct), nil
 }
 
+//FindAllPendingDeletion return users whose deletion was requested before requestedBefore
+func (s UserRepository) FindAllPendingDeletion(ctx context.Context, requestedBefore time.Time) (users []userland.User, err error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	userScanStructs := []UserScanStruct{}
+	query := `SELECT
+				id,
+				email, 
+				fullname, 
+				phone, 
+				location,
+				bio,
+				web_url,
+				picture_url,
+				verified,
+				tfa_enabled,
+				password,
+				backup_codes,
+				tfa_enabled_at,
+				tfa_secret,
+				backup_codes_created_at,
+				created_at, 
+				updated_at,
+				version,
+				deletion_requested_at
+			FROM users 
+			WHERE deletion_requested_at IS NOT NULL AND deletion_requested_at < $1
+			ORDER BY deletion_requested_at`
+
+	stmt, err := s.db.PreparexContext(ctx, query)
+	if err != nil {
+		return nil, errors.Wrap(err, "db.Preparex(query) err")
+	}
+
+	if err := stmt.SelectContext(ctx, &userScanStructs, requestedBefore); err != nil {
+		return nil, errors.Wrap(err, "stmt.Select() err")
+	}
+
+	users = []userland.User{}
+	for _, userScanStruct := range userScanStructs {
+		users = append(users, s.convertStructScanToEntity(userScanStruct))
+	}
+	return users, nil
+}
+
 //Delete delete story by id
-func (s UserRepository) Delete(id int) error {
+func (s UserRepository) Delete(ctx context.Context, id int) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
 	query := `DELETE FROM users where id=$1`
 
-	deleteStatement, err := s.db.Prepare(query)
+	deleteStatement, err := s.db.PrepareContext(ctx, query)
 	if err != nil {
 		return errors.Wrap(err, "db.Preparex(query) err")
 	}
 
 	defer deleteStatement.Close()
-	res, err := deleteStatement.Exec(id)
+	res, err := deleteStatement.ExecContext(ctx, id)
 	if err != nil {
 		return errors.Wrap(err, "deleteStatement.Exec() err")
 	}
@@ -145,7 +219,10 @@ func (s UserRepository) Delete(id int) error {
 }
 
 //Insert insert story to datastore
-func (s UserRepository) Insert(user *userland.User) error {
+func (s UserRepository) Insert(ctx context.Context, user *userland.User) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
 	query := `INSERT INTO users (
 				email, 
 				fullname, 
@@ -170,14 +247,14 @@ func (s UserRepository) Insert(user *userland.User) error {
 				:verified,
 				now(), 
 				now()
-			) RETURNING id`
+			) RETURNING id, version`
 
-	stmt, err := s.db.PrepareNamed(query)
+	stmt, err := s.db.PrepareNamedContext(ctx, query)
 	if err != nil {
 		return errors.Wrap(err, "db.PrepareNamed(query) err")
 	}
 
-	row := stmt.QueryRow(user)
+	row := stmt.QueryRowContext(ctx, user)
 	if err := row.Err(); err != nil {
 		if err.(*pq.Error).Code.Name() == "unique_violation" {
 			return userland.ErrDuplicateKey
@@ -185,12 +262,15 @@ func (s UserRepository) Insert(user *userland.User) error {
 		return errors.Wrap(err, "stmt.Query(user) err")
 	}
 
-	row.Scan(&user.ID)
+	row.Scan(&user.ID, &user.Version)
 	return nil
 }
 
 //Update update story
-func (s UserRepository) Update(user userland.User) error {
+func (s UserRepository) Update(ctx context.Context, user userland.User) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
 	query := `UPDATE users SET (
 				email, 
 				fullname,
@@ -203,7 +283,10 @@ func (s UserRepository) Update(user userland.User) error {
 				verified,
 				tfa_enabled,
 				tfa_enabled_at,
-				updated_at
+				tfa_secret,
+				deletion_requested_at,
+				updated_at,
+				version
 			) = (
 				:email, 
 				:fullname,
@@ -216,10 +299,13 @@ func (s UserRepository) Update(user userland.User) error {
 				:verified,
 				:tfaenabled,
 				:tfaenabledat,
-				now()
-			) WHERE id=:id`
+				NULLIF(:tfasecret, ''),
+				NULLIF(:deletionrequestedat, CAST('0001-01-01 00:00:00' AS timestamp)),
+				now(),
+				version + 1
+			) WHERE id=:id AND version=:version`
 
-	res, err := s.db.NamedExec(query, user)
+	res, err := s.db.NamedExecContext(ctx, query, user)
 	if err != nil {
 		return errors.Wrap(err, "db.NamedQuery() err")
 	}
@@ -230,15 +316,86 @@ func (s UserRepository) Update(user userland.User) error {
 	}
 
 	if rowsAffected == 0 {
-		return userland.ErrUserNotFound
+		return s.updateConflict(ctx, user.ID)
+	}
+
+	return nil
+}
+
+//UpdateProfile update only the profile columns set in patch
+func (s UserRepository) UpdateProfile(ctx context.Context, id int, version int, patch userland.ProfilePatch) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	args := []interface{}{id}
+	setStatements := []string{}
+	// build set statements from the patched columns, eg: ["fullname=$2", "bio=$3"]
+	columns := []struct {
+		name  string
+		value *string
+	}{
+		{"fullname", patch.Fullname},
+		{"phone", patch.Phone},
+		{"location", patch.Location},
+		{"bio", patch.Bio},
+		{"web_url", patch.WebURL},
+	}
+	for _, column := range columns {
+		if column.value != nil {
+			args = append(args, *column.value)
+			setStatements = append(setStatements, fmt.Sprintf("%s=$%d", column.name, len(args)))
+		}
+	}
+	setStatements = append(setStatements, "updated_at=now()", "version=version + 1")
+
+	whereStatement := "WHERE id=$1"
+	if version > 0 {
+		args = append(args, version)
+		whereStatement = fmt.Sprintf("%s AND version=$%d", whereStatement, len(args))
+	}
+
+	query := fmt.Sprintf(`UPDATE users SET %s %s`, strings.Join(setStatements, ", "), whereStatement)
+	res, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return errors.Wrap(err, "db.Exec() err")
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "res.RowsAffected() err")
+	}
+
+	if rowsAffected == 0 {
+		return s.updateConflict(ctx, id)
 	}
 
 	return nil
 }
 
-func (s UserRepository) StoreBackupCodes(user userland.User) error {
-	query := `UPDATE users SET (backup_codes, updated_at) = ($2, now()) WHERE id=$1`
-	res, err := s.db.Exec(query, user.ID, pq.Array(user.BackupCodes))
+//updateConflict tell why an update matched no row, either user is gone or its version has moved
+func (s UserRepository) updateConflict(ctx context.Context, id int) error {
+	var version int
+	if err := s.db.QueryRowContext(ctx, `SELECT version FROM users WHERE id=$1`, id).Scan(&version); err != nil {
+		if err == sql.ErrNoRows {
+			return userland.ErrUserNotFound
+		}
+		return errors.Wrap(err, "row.Scan(version) err")
+	}
+
+	return userland.ErrConcurrentModification
+}
+
+func (s UserRepository) StoreBackupCodes(ctx context.Context, user userland.User) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `UPDATE users SET (backup_codes, backup_codes_created_at, updated_at, version) = ($2, $3, now(), version + 1)
+			WHERE id=$1 AND version=$4`
+	backupCodesCreatedAt := pq.NullTime{
+		Time:  user.BackupCodesCreatedAt,
+		Valid: !user.BackupCodesCreatedAt.IsZero(),
+	}
+	res, err := s.db.ExecContext(ctx, query, user.ID, pq.Array(user.BackupCodes), backupCodesCreatedAt, user.Version)
 	if err != nil {
 		return errors.Wrap(err, "db.Query() err")
 	}
@@ -249,7 +406,7 @@ func (s UserRepository) StoreBackupCodes(user userland.User) error {
 	}
 
 	if rowsAffected == 0 {
-		return userland.ErrUserNotFound
+		return s.updateConflict(ctx, user.ID)
 	}
 
 	return nil
@@ -264,6 +421,7 @@ func (u UserRepository) convertStructScanToEntity(userScanStruct UserScanStruct)
 		BackupCodes: []string(userScanStruct.BackupCodes),
 		CreatedAt:   userScanStruct.CreatedAt,
 		UpdatedAt:   userScanStruct.UpdatedAt,
+		Version:     userScanStruct.Version,
 	}
 
 	if userScanStruct.Phone.Valid {
@@ -290,6 +448,15 @@ func (u UserRepository) convertStructScanToEntity(userScanStruct UserScanStruct)
 	if userScanStruct.TFAEnabledAt.Valid {
 		user.TFAEnabledAt = userScanStruct.TFAEnabledAt.Time
 	}
+	if userScanStruct.TFASecret.Valid {
+		user.TFASecret = userScanStruct.TFASecret.String
+	}
+	if userScanStruct.BackupCodesCreatedAt.Valid {
+		user.BackupCodesCreatedAt = userScanStruct.BackupCodesCreatedAt.Time
+	}
+	if userScanStruct.DeletionRequestedAt.Valid {
+		user.DeletionRequestedAt = userScanStruct.DeletionRequestedAt.Time
+	}
 
 	return user
 }
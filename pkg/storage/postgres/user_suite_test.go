@@ -3,6 +3,7 @@
 package postgres_test
 
 import (
+	"context"
 	"testing"
 
 	"github.com/AdhityaRamadhanus/userland"
@@ -85,7 +86,7 @@ func (suite *UserRepositoryTestSuite) TestInsert() {
 	}
 	for _, tc := range testCases {
 		suite.T().Run(tc.name, func(t *testing.T) {
-			err := suite.UserRepository.Insert(&tc.args.user)
+			err := suite.UserRepository.Insert(context.Background(), &tc.args.user)
 			if err != tc.wantErr {
 				t.Fatalf("UserRepository.Insert(user) err = %v; want %v", err, tc.wantErr)
 			}
@@ -123,7 +124,7 @@ func (suite *UserRepositoryTestSuite) TestFindByEmail() {
 
 	for _, tc := range testCases {
 		suite.T().Run(tc.name, func(t *testing.T) {
-			user, err := suite.UserRepository.FindByEmail(tc.args.email)
+			user, err := suite.UserRepository.FindByEmail(context.Background(), tc.args.email)
 			if err != tc.wantErr {
 				t.Fatalf("UserRepository.FindByEmail(email) err = %v; want %v", err, tc.wantErr)
 			}
@@ -164,7 +165,7 @@ func (suite *UserRepositoryTestSuite) TestFindByID() {
 
 	for _, tc := range testCases {
 		suite.T().Run(tc.name, func(t *testing.T) {
-			user, err := suite.UserRepository.Find(tc.args.userID)
+			user, err := suite.UserRepository.Find(context.Background(), tc.args.userID)
 			if err != tc.wantErr {
 				t.Fatalf("UserRepository.Find(email) err = %v; want %v", err, tc.wantErr)
 			}
@@ -197,10 +198,23 @@ func (suite *UserRepositoryTestSuite) TestUpdate() {
 					Email:      "adhitya.ramadhanus@gmail.com",
 					Phone:      "08123456789",
 					Bio:        "Test Update",
+					Version:    defaultUser.Version,
 				},
 			},
 			wantErr: nil,
 		},
+		{
+			name: "stale version",
+			args: args{
+				user: userland.User{
+					ID:       defaultUser.ID,
+					Fullname: "Adhitya Ramadhanus",
+					Email:    "adhitya.ramadhanus@gmail.com",
+					Version:  defaultUser.Version,
+				},
+			},
+			wantErr: userland.ErrConcurrentModification,
+		},
 		{
 			name: "not found",
 			args: args{
@@ -219,7 +233,7 @@ func (suite *UserRepositoryTestSuite) TestUpdate() {
 
 	for _, tc := range testCases {
 		suite.T().Run(tc.name, func(t *testing.T) {
-			err := suite.UserRepository.Update(tc.args.user)
+			err := suite.UserRepository.Update(context.Background(), tc.args.user)
 			if err != tc.wantErr {
 				t.Fatalf("UserRepository.Update(user) err = %v; want %v", err, tc.wantErr)
 			}
@@ -265,7 +279,7 @@ func (suite *UserRepositoryTestSuite) TestStoreBackupCodes() {
 
 	for _, tc := range testCases {
 		suite.T().Run(tc.name, func(t *testing.T) {
-			err := suite.UserRepository.StoreBackupCodes(tc.args.user)
+			err := suite.UserRepository.StoreBackupCodes(context.Background(), tc.args.user)
 			if err != tc.wantErr {
 				t.Fatalf("UserRepository.StoreBackupCodes(user) err = %v; want %v", err, tc.wantErr)
 			}
@@ -303,7 +317,7 @@ func (suite *UserRepositoryTestSuite) TestDelete() {
 
 	for _, tc := range testCases {
 		suite.T().Run(tc.name, func(t *testing.T) {
-			err := suite.UserRepository.Delete(tc.args.userID)
+			err := suite.UserRepository.Delete(context.Background(), tc.args.userID)
 			if err != tc.wantErr {
 				t.Fatalf("UserRepository.Delete(userID) err = %v; want %v", err, tc.wantErr)
 			}
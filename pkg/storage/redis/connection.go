@@ -1,6 +1,7 @@
 package redis
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/AdhityaRamadhanus/userland/pkg/config"
@@ -13,12 +14,48 @@ import (
 func CreateClient(cfg config.RedisConfig, db int) (*redis.Client, error) {
 	redisAddr := fmt.Sprintf("%s:%d", cfg.Host, cfg.Port)
 	redisClient := _redis.NewClient(&_redis.Options{
-		Addr:     redisAddr,
-		Password: cfg.Password, // no password set
-		DB:       db,           // use default DB
+		Addr:         redisAddr,
+		Password:     cfg.Password, // no password set
+		DB:           db,           // use default DB
+		DialTimeout:  cfg.CommandTimeout,
+		ReadTimeout:  cfg.CommandTimeout,
+		WriteTimeout: cfg.CommandTimeout,
 	})
 	if _, err := redisClient.Ping().Result(); err != nil {
 		return nil, errors.Wrapf(err, "redisClient.Ping().Result() err;")
 	}
 	return redisClient, nil
 }
+
+/*
+withContext bind ctx to redisClient for a single command or pipeline, go-redis v6 keep the context
+without ever reading it, so a done ctx is checked here and the command fail with ctx error without
+reaching redis. A command already sent is bounded by the client read and write timeouts instead
+*/
+func withContext(ctx context.Context, redisClient *redis.Client) *redis.Client {
+	if err := ctx.Err(); err != nil {
+		return refusingClient(ctx, err)
+	}
+	return redisClient.WithContext(ctx)
+}
+
+//refusingClient never dial, its limiter fail every command and pipeline with err
+func refusingClient(ctx context.Context, err error) *redis.Client {
+	redisClient := _redis.NewClient(&_redis.Options{
+		// no idle connection is ever reaped, so no reaper goroutine is started
+		IdleTimeout: -1,
+	})
+	redisClient.SetLimiter(refusingLimiter{err: err})
+	return redisClient.WithContext(ctx)
+}
+
+//refusingLimiter implements redis.Limiter, go-redis set the error of Allow on every command it refuse
+type refusingLimiter struct {
+	err error
+}
+
+func (r refusingLimiter) Allow() error {
+	return r.err
+}
+
+func (r refusingLimiter) ReportResult(result error) {}
@@ -0,0 +1,80 @@
+//+build unit
+
+package redis_test
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/AdhityaRamadhanus/userland/pkg/config"
+	"github.com/AdhityaRamadhanus/userland/pkg/storage/redis"
+	_redis "github.com/go-redis/redis"
+	"github.com/pkg/errors"
+)
+
+//silentServer accept connections and never answer, it count the accepted connections
+func silentServer(t *testing.T) (listener net.Listener, accepted *int32) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() err = %v; want nil", err)
+	}
+
+	accepted = new(int32)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			atomic.AddInt32(accepted, 1)
+			defer conn.Close()
+		}
+	}()
+	return listener, accepted
+}
+
+func TestKeyValueService_context(t *testing.T) {
+	listener, accepted := silentServer(t)
+	defer listener.Close()
+
+	redisClient := _redis.NewClient(&_redis.Options{Addr: listener.Addr().String()})
+	defer redisClient.Close()
+	keyValueService := redis.NewKeyValueService(redisClient)
+
+	canceledCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := keyValueService.Get(canceledCtx, "key"); errors.Cause(err) != context.Canceled {
+		t.Errorf("keyValueService.Get() with canceled context err = %v; want %v", err, context.Canceled)
+	}
+
+	expiredCtx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	if _, err := keyValueService.Incr(expiredCtx, "key", time.Minute); errors.Cause(err) != context.DeadlineExceeded {
+		t.Errorf("keyValueService.Incr() past deadline err = %v; want %v", err, context.DeadlineExceeded)
+	}
+
+	if n := atomic.LoadInt32(accepted); n != 0 {
+		t.Errorf("redis connections = %d; want 0, a done context must not reach redis", n)
+	}
+}
+
+func TestCreateClient_commandTimeout(t *testing.T) {
+	listener, _ := silentServer(t)
+	defer listener.Close()
+
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	portNumber, _ := strconv.Atoi(port)
+	cfg := config.RedisConfig{Host: host, Port: portNumber, CommandTimeout: 100 * time.Millisecond}
+
+	start := time.Now()
+	if _, err := redis.CreateClient(cfg, 0); err == nil {
+		t.Fatalf("redis.CreateClient() to a server that never answer err = nil; want timeout")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("redis.CreateClient() took %v; want it bounded by command timeout %v", elapsed, cfg.CommandTimeout)
+	}
+}
@@ -0,0 +1,39 @@
+// +build integration
+
+package redis_test
+
+import (
+	"testing"
+
+	"github.com/AdhityaRamadhanus/userland"
+	"github.com/AdhityaRamadhanus/userland/pkg/storage/redis"
+	"github.com/AdhityaRamadhanus/userland/pkg/userlandtest"
+)
+
+func TestRepositoryContracts(t *testing.T) {
+	redisClient, err := redis.CreateClient(cfg.Redis, 0)
+	if err != nil {
+		t.Fatalf("redis.CreateClient() err = %v; want nil", err)
+	}
+	defer redisClient.Close()
+
+	flushAll := func(t *testing.T) {
+		if err := redisClient.FlushAll().Err(); err != nil {
+			t.Fatalf("redisClient.FlushAll() err = %v; want nil", err)
+		}
+	}
+
+	t.Run("KeyValueService", func(t *testing.T) {
+		userlandtest.RunKeyValueServiceContract(t, func(t *testing.T) userland.KeyValueService {
+			flushAll(t)
+			return redis.NewKeyValueService(redisClient)
+		})
+	})
+
+	t.Run("SessionRepository", func(t *testing.T) {
+		userlandtest.RunSessionRepositoryContract(t, func(t *testing.T) userland.SessionRepository {
+			flushAll(t)
+			return redis.NewSessionRepository(redisClient)
+		})
+	})
+}
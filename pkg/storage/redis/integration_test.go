@@ -50,3 +50,21 @@ func TestSessionRepository(t *testing.T) {
 	suite.Run(t, suiteTest)
 	suiteTest.Teardown()
 }
+
+func TestTrustedDeviceRepository(t *testing.T) {
+	suiteTest := NewTrustedDeviceRepositoryTestSuite(cfg)
+	suite.Run(t, suiteTest)
+	suiteTest.Teardown()
+}
+
+func TestRevocationService(t *testing.T) {
+	suiteTest := NewRevocationServiceTestSuite(cfg)
+	suite.Run(t, suiteTest)
+	suiteTest.Teardown()
+}
+
+func TestRateLimitService(t *testing.T) {
+	suiteTest := NewRateLimitServiceTestSuite(cfg)
+	suite.Run(t, suiteTest)
+	suiteTest.Teardown()
+}
@@ -1,6 +1,7 @@
 package redis
 
 import (
+	"context"
 	"time"
 
 	"github.com/AdhityaRamadhanus/userland"
@@ -21,8 +22,8 @@ func NewKeyValueService(redisClient *redis.Client) *KeyValueService {
 }
 
 //Get a cache in bytes from a key
-func (c KeyValueService) Get(key string) (result []byte, err error) {
-	val, err := c.redisClient.Get(key).Bytes()
+func (c KeyValueService) Get(ctx context.Context, key string) (result []byte, err error) {
+	val, err := withContext(ctx, c.redisClient).Get(key).Bytes()
 	if err != nil {
 		if err == redis.Nil {
 			return nil, userland.ErrKeyNotFound
@@ -34,8 +35,8 @@ func (c KeyValueService) Get(key string) (result []byte, err error) {
 }
 
 //Set cache in bytes with key without expiration
-func (c KeyValueService) Set(key string, value []byte) (err error) {
-	if err := c.redisClient.Set(key, string(value), 0).Err(); err != nil {
+func (c KeyValueService) Set(ctx context.Context, key string, value []byte) (err error) {
+	if err := withContext(ctx, c.redisClient).Set(key, string(value), 0).Err(); err != nil {
 		return errors.Wrapf(err, "redisClient.Set(%q, <val>, 0) err", key)
 	}
 
@@ -43,8 +44,8 @@ func (c KeyValueService) Set(key string, value []byte) (err error) {
 }
 
 //Delete cache in bytes with key without expiration
-func (c KeyValueService) Delete(key string) (err error) {
-	if err := c.redisClient.Del(key).Err(); err != nil {
+func (c KeyValueService) Delete(ctx context.Context, key string) (err error) {
+	if err := withContext(ctx, c.redisClient).Del(key).Err(); err != nil {
 		return errors.Wrapf(err, "redisClient.Delete(%q) err", key)
 	}
 
@@ -52,10 +53,48 @@ func (c KeyValueService) Delete(key string) (err error) {
 }
 
 //SetEx cache in bytes with key with expiration
-func (c KeyValueService) SetEx(key string, value []byte, expiration time.Duration) (err error) {
-	if err := c.redisClient.Set(key, value, expiration).Err(); err != nil {
+func (c KeyValueService) SetEx(ctx context.Context, key string, value []byte, expiration time.Duration) (err error) {
+	if err := withContext(ctx, c.redisClient).Set(key, value, expiration).Err(); err != nil {
 		return errors.Wrapf(err, "redisClient.Set(%q, <val>, %d) err", key, expiration)
 	}
 
 	return nil
 }
+
+//Expire set a new expiration of an existing key
+func (c KeyValueService) Expire(ctx context.Context, key string, expiration time.Duration) (err error) {
+	exists, err := withContext(ctx, c.redisClient).Expire(key, expiration).Result()
+	if err != nil {
+		return errors.Wrapf(err, "redisClient.Expire(%q, %d) err", key, expiration)
+	}
+	if !exists {
+		return userland.ErrKeyNotFound
+	}
+
+	return nil
+}
+
+//SetNX cache in bytes with key with expiration only when key doesn't exist
+func (c KeyValueService) SetNX(ctx context.Context, key string, value []byte, expiration time.Duration) (set bool, err error) {
+	set, err = withContext(ctx, c.redisClient).SetNX(key, value, expiration).Result()
+	if err != nil {
+		return false, errors.Wrapf(err, "redisClient.SetNX(%q, <val>, %d) err", key, expiration)
+	}
+
+	return set, nil
+}
+
+//Incr increment the integer value of key and set its expiration in one transaction
+func (c KeyValueService) Incr(ctx context.Context, key string, expiration time.Duration) (value int64, err error) {
+	var incr *redis.IntCmd
+	_, err = withContext(ctx, c.redisClient).TxPipelined(func(pipe redis.Pipeliner) error {
+		incr = pipe.Incr(key)
+		pipe.Expire(key, expiration)
+		return nil
+	})
+	if err != nil {
+		return 0, errors.Wrapf(err, "redisClient.Incr(%q) err", key)
+	}
+
+	return incr.Val(), nil
+}
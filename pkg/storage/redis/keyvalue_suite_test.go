@@ -3,6 +3,7 @@
 package redis_test
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -78,7 +79,7 @@ func (suite *KeyValueServiceTestSuite) TestGet() {
 
 	for _, tc := range testCases {
 		suite.T().Run(tc.name, func(t *testing.T) {
-			val, err := suite.KeyValueService.Get(tc.args.key)
+			val, err := suite.KeyValueService.Get(context.Background(), tc.args.key)
 			if err != nil && !tc.wantErr {
 				t.Fatalf("KeyValueService.Get() err = %v; want nil", err)
 			}
@@ -117,7 +118,7 @@ func (suite *KeyValueServiceTestSuite) TestSet() {
 
 	for _, tc := range testCases {
 		suite.T().Run(tc.name, func(t *testing.T) {
-			err := suite.KeyValueService.Set(tc.args.key, tc.args.val)
+			err := suite.KeyValueService.Set(context.Background(), tc.args.key, tc.args.val)
 			if err != nil && !tc.wantErr {
 				t.Fatalf("KeyValueService.Set() err = %v; want nil", err)
 			}
@@ -152,7 +153,7 @@ func (suite *KeyValueServiceTestSuite) TestSetEx() {
 
 	for _, tc := range testCases {
 		suite.T().Run(tc.name, func(t *testing.T) {
-			err := suite.KeyValueService.SetEx(tc.args.key, tc.args.val, tc.args.exp)
+			err := suite.KeyValueService.SetEx(context.Background(), tc.args.key, tc.args.val, tc.args.exp)
 			if err != nil && !tc.wantErr {
 				t.Fatalf("KeyValueService.SetEx() err = %v; want nil", err)
 			}
@@ -160,12 +161,12 @@ func (suite *KeyValueServiceTestSuite) TestSetEx() {
 				t.Fatalf("KeyValueService.SetEx() err = nil; want not nil")
 			}
 
-			if _, err := suite.KeyValueService.Get(tc.args.key); err != nil && err == userland.ErrKeyNotFound {
+			if _, err := suite.KeyValueService.Get(context.Background(), tc.args.key); err != nil && err == userland.ErrKeyNotFound {
 				t.Fatalf("KeyValueService.Get(notExpKey) err = %v; want nil", err)
 			}
 
 			time.Sleep(tc.args.exp + (100 * time.Millisecond))
-			if _, err := suite.KeyValueService.Get(tc.args.key); err != userland.ErrKeyNotFound {
+			if _, err := suite.KeyValueService.Get(context.Background(), tc.args.key); err != userland.ErrKeyNotFound {
 				t.Fatalf("KeyValueService.Get(expKey) err = nil; want %v", userland.ErrKeyNotFound.Error())
 			}
 		})
@@ -193,7 +194,7 @@ func (suite *KeyValueServiceTestSuite) TestDelete() {
 
 	for _, tc := range testCases {
 		suite.T().Run(tc.name, func(t *testing.T) {
-			err := suite.KeyValueService.Delete(tc.args.key)
+			err := suite.KeyValueService.Delete(context.Background(), tc.args.key)
 			if err != nil && !tc.wantErr {
 				t.Fatalf("KeyValueService.Delete() err = %v; want nil", err)
 			}
@@ -201,7 +202,7 @@ func (suite *KeyValueServiceTestSuite) TestDelete() {
 				t.Fatalf("KeyValueService.Delete() err = nil; want not nil")
 			}
 
-			if _, err := suite.KeyValueService.Get(tc.args.key); err != userland.ErrKeyNotFound {
+			if _, err := suite.KeyValueService.Get(context.Background(), tc.args.key); err != userland.ErrKeyNotFound {
 				t.Fatalf("KeyValueService.Get(deletedKey) err = nil; want %v", userland.ErrKeyNotFound.Error())
 			}
 		})
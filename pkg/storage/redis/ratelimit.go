@@ -0,0 +1,82 @@
+package redis
+
+import (
+	"context"
+	"time"
+
+	"github.com/AdhityaRamadhanus/userland"
+	"github.com/go-redis/redis"
+	"github.com/pkg/errors"
+)
+
+/*
+allowScript implement GCRA, the key hold the theoretical arrival time (in ms) of the next request at the sustained rate.
+A request is allowed as long as it doesn't arrive earlier than burst emission intervals before that time.
+KEYS[1] rate limit key, ARGV[1] burst, ARGV[2] emission interval in ms
+return {allowed, remaining, retry after ms, reset after ms}
+*/
+var allowScript = redis.NewScript(`
+redis.replicate_commands()
+local burst = tonumber(ARGV[1])
+local emission_interval = tonumber(ARGV[2])
+local time = redis.call("TIME")
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+
+local tat = tonumber(redis.call("GET", KEYS[1])) or now
+if tat < now then
+  tat = now
+end
+
+local new_tat = tat + emission_interval
+local diff = now - (new_tat - emission_interval * burst)
+if diff < 0 then
+  return {0, 0, math.ceil(-diff), math.ceil(tat - now)}
+end
+
+redis.call("SET", KEYS[1], new_tat, "PX", math.ceil(new_tat - now))
+return {1, math.floor(diff / emission_interval), 0, math.ceil(new_tat - now)}
+`)
+
+//RateLimitService implements userland.RateLimitService interface using redis, counters are shared by every api instance
+type RateLimitService struct {
+	redisClient *redis.Client
+}
+
+//NewRateLimitService construct a new RateLimitService from redis client
+func NewRateLimitService(redisClient *redis.Client) *RateLimitService {
+	return &RateLimitService{
+		redisClient: redisClient,
+	}
+}
+
+func (r RateLimitService) Allow(ctx context.Context, key string, rateLimit userland.RateLimit) (userland.RateLimitResult, error) {
+	if rateLimit.Limit <= 0 || rateLimit.Window <= 0 {
+		return userland.RateLimitResult{}, errors.Errorf("invalid rate limit %d per %s", rateLimit.Limit, rateLimit.Window)
+	}
+	burst := rateLimit.Burst
+	if burst <= 0 {
+		burst = rateLimit.Limit
+	}
+	emissionInterval := float64(rateLimit.Window) / float64(time.Millisecond) / float64(rateLimit.Limit)
+
+	values, err := allowScript.Run(withContext(ctx, r.redisClient), []string{key}, burst, emissionInterval).Result()
+	if err != nil {
+		return userland.RateLimitResult{}, errors.Wrapf(err, "allowScript.Run(%q) err", key)
+	}
+
+	result, ok := values.([]interface{})
+	if !ok || len(result) != 4 {
+		return userland.RateLimitResult{}, errors.Errorf("unexpected rate limit script result %v", values)
+	}
+	allowed, _ := result[0].(int64)
+	remaining, _ := result[1].(int64)
+	retryAfter, _ := result[2].(int64)
+	resetAfter, _ := result[3].(int64)
+
+	return userland.RateLimitResult{
+		Allowed:    allowed == 1,
+		Remaining:  int(remaining),
+		RetryAfter: time.Duration(retryAfter) * time.Millisecond,
+		ResetAfter: time.Duration(resetAfter) * time.Millisecond,
+	}, nil
+}
@@ -0,0 +1,72 @@
+// +build integration
+
+package redis_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/AdhityaRamadhanus/userland"
+	"github.com/AdhityaRamadhanus/userland/pkg/config"
+	"github.com/AdhityaRamadhanus/userland/pkg/storage/redis"
+	_redis "github.com/go-redis/redis"
+	"github.com/stretchr/testify/suite"
+)
+
+type RateLimitServiceTestSuite struct {
+	suite.Suite
+	Config           *config.Configuration
+	RedisClient      *_redis.Client
+	RateLimitService userland.RateLimitService
+}
+
+func NewRateLimitServiceTestSuite(cfg *config.Configuration) *RateLimitServiceTestSuite {
+	return &RateLimitServiceTestSuite{
+		Config: cfg,
+	}
+}
+
+func (suite *RateLimitServiceTestSuite) Teardown() {
+	suite.T().Log("Teardown RateLimitServiceTestSuite")
+	suite.RedisClient.Close()
+}
+
+func (suite *RateLimitServiceTestSuite) SetupTest() {
+	if err := suite.RedisClient.FlushAll().Err(); err != nil {
+		suite.T().Fatalf("RedisClient.FlushAll() err = %v; want nil", err)
+	}
+}
+
+func (suite *RateLimitServiceTestSuite) SetupSuite() {
+	suite.T().Logf("Connecting to redis at %v", suite.Config.Redis)
+	redisClient, err := redis.CreateClient(suite.Config.Redis, 0)
+	if err != nil {
+		suite.T().Fatalf("redis.CreateClient() err = %v; want nil", err)
+	}
+	suite.RedisClient = redisClient
+	suite.RateLimitService = redis.NewRateLimitService(redisClient)
+}
+
+func (suite *RateLimitServiceTestSuite) TestAllow() {
+	rateLimit := userland.RateLimit{Limit: 10, Burst: 3, Window: time.Minute}
+	for i := 0; i < rateLimit.Burst; i++ {
+		result, err := suite.RateLimitService.Allow(context.Background(), "rate-limit:login:ip:127.0.0.1", rateLimit)
+		suite.Nil(err, "should allow request")
+		suite.True(result.Allowed, "burst should be allowed")
+		suite.Equal(rateLimit.Burst-i-1, result.Remaining)
+	}
+
+	result, err := suite.RateLimitService.Allow(context.Background(), "rate-limit:login:ip:127.0.0.1", rateLimit)
+	suite.Nil(err, "should count request")
+	suite.False(result.Allowed, "request over burst should be throttled")
+	suite.True(result.RetryAfter > 0 && result.RetryAfter <= rateLimit.Window/time.Duration(rateLimit.Limit), "should retry after one emission interval")
+
+	result, err = suite.RateLimitService.Allow(context.Background(), "rate-limit:login:ip:127.0.0.2", rateLimit)
+	suite.Nil(err, "should allow request")
+	suite.True(result.Allowed, "other key should have its own burst")
+}
+
+func (suite *RateLimitServiceTestSuite) TestAllow_invalidRateLimit() {
+	_, err := suite.RateLimitService.Allow(context.Background(), "rate-limit:login:ip:127.0.0.1", userland.RateLimit{})
+	suite.NotNil(err, "should reject rate limit without limit and window")
+}
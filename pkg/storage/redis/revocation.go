@@ -0,0 +1,167 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/AdhityaRamadhanus/userland"
+	"github.com/AdhityaRamadhanus/userland/pkg/common/keygenerator"
+	"github.com/AdhityaRamadhanus/userland/pkg/common/security"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/go-redis/redis"
+)
+
+type sessionRevocationMessage struct {
+	SessionID string    `json:"session_id"`
+	UserID    int       `json:"user_id"`
+	Reason    string    `json:"reason"`
+	RevokedAt time.Time `json:"revoked_at"`
+}
+
+/*
+RevocationService implements userland.RevocationService interface using redis pub/sub,
+revocations are also kept in a sorted set for as long as a token could still be valid so late subscribers can catch up
+*/
+type RevocationService struct {
+	redisClient *redis.Client
+	retention   time.Duration
+}
+
+//NewRevocationService construct a new RevocationService from redis client
+func NewRevocationService(redisClient *redis.Client) *RevocationService {
+	return &RevocationService{
+		redisClient: redisClient,
+		retention:   security.RefreshAccessTokenExpiration,
+	}
+}
+
+func (r RevocationService) Publish(ctx context.Context, revocation userland.SessionRevocation) (err error) {
+	if revocation.RevokedAt.IsZero() {
+		revocation.RevokedAt = time.Now()
+	}
+	messageBytes, err := json.Marshal(sessionRevocationMessage{
+		SessionID: revocation.SessionID,
+		UserID:    revocation.UserID,
+		Reason:    revocation.Reason,
+		RevokedAt: revocation.RevokedAt,
+	})
+	if err != nil {
+		return errors.Wrap(err, "json.Marshal() err")
+	}
+
+	logKey := keygenerator.SessionRevocationLogKey()
+	retainedSince := strconv.FormatInt(epochMillis(time.Now().Add(-r.retention)), 10)
+	_, err = withContext(ctx, r.redisClient).TxPipelined(func(pipe redis.Pipeliner) error {
+		pipe.ZAdd(logKey, redis.Z{Score: float64(epochMillis(revocation.RevokedAt)), Member: string(messageBytes)})
+		pipe.ZRemRangeByScore(logKey, "-inf", "("+retainedSince)
+		return nil
+	})
+	if err != nil {
+		return errors.Wrapf(err, "redisClient.TxPipelined(ZAdd, ZRemRangeByScore %q) err", logKey)
+	}
+
+	channel := keygenerator.SessionRevocationChannel()
+	if err := withContext(ctx, r.redisClient).Publish(channel, string(messageBytes)).Err(); err != nil {
+		return errors.Wrapf(err, "redisClient.Publish(%q) err", channel)
+	}
+
+	return nil
+}
+
+func (r RevocationService) FindAllSince(ctx context.Context, since time.Time) (userland.SessionRevocations, error) {
+	logKey := keygenerator.SessionRevocationLogKey()
+	min := strconv.FormatInt(epochMillis(since), 10)
+	messages, err := withContext(ctx, r.redisClient).ZRangeByScore(logKey, redis.ZRangeBy{Min: min, Max: "+inf"}).Result()
+	if err != nil {
+		return nil, errors.Wrapf(err, "redisClient.ZRangeByScore(%q, %s) err", logKey, min)
+	}
+
+	revocations := userland.SessionRevocations{}
+	for _, message := range messages {
+		revocation, err := parseSessionRevocation(message)
+		if err != nil {
+			continue
+		}
+		revocations = append(revocations, revocation)
+	}
+
+	return revocations, nil
+}
+
+func (r RevocationService) Subscribe(ctx context.Context) (userland.RevocationSubscription, error) {
+	channel := keygenerator.SessionRevocationChannel()
+	pubsub := withContext(ctx, r.redisClient).Subscribe(channel)
+	// wait for confirmation so no revocation published after Subscribe returns is missed
+	if _, err := pubsub.Receive(); err != nil {
+		pubsub.Close()
+		return nil, errors.Wrapf(err, "redisClient.Subscribe(%q) err", channel)
+	}
+
+	subscription := &revocationSubscription{
+		pubsub:      pubsub,
+		revocations: make(chan userland.SessionRevocation, 64),
+		done:        make(chan struct{}),
+	}
+	go subscription.forward()
+
+	return subscription, nil
+}
+
+type revocationSubscription struct {
+	pubsub      *redis.PubSub
+	revocations chan userland.SessionRevocation
+	done        chan struct{}
+	closeOnce   sync.Once
+}
+
+func (s *revocationSubscription) Revocations() <-chan userland.SessionRevocation {
+	return s.revocations
+}
+
+func (s *revocationSubscription) Close() (err error) {
+	s.closeOnce.Do(func() {
+		close(s.done)
+		err = s.pubsub.Close()
+	})
+	return err
+}
+
+//forward decode pub/sub messages until the subscription is closed
+func (s *revocationSubscription) forward() {
+	defer close(s.revocations)
+	for message := range s.pubsub.Channel() {
+		revocation, err := parseSessionRevocation(message.Payload)
+		if err != nil {
+			logrus.WithError(err).Error("Failed to parse session revocation")
+			continue
+		}
+		select {
+		case s.revocations <- revocation:
+		case <-s.done:
+			return
+		}
+	}
+}
+
+func parseSessionRevocation(message string) (userland.SessionRevocation, error) {
+	revocationMessage := sessionRevocationMessage{}
+	if err := json.Unmarshal([]byte(message), &revocationMessage); err != nil {
+		return userland.SessionRevocation{}, errors.Wrap(err, "json.Unmarshal() err")
+	}
+
+	return userland.SessionRevocation{
+		SessionID: revocationMessage.SessionID,
+		UserID:    revocationMessage.UserID,
+		Reason:    revocationMessage.Reason,
+		RevokedAt: revocationMessage.RevokedAt,
+	}, nil
+}
+
+func epochMillis(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
@@ -0,0 +1,91 @@
+// +build integration
+
+package redis_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/AdhityaRamadhanus/userland"
+	"github.com/AdhityaRamadhanus/userland/pkg/config"
+	"github.com/AdhityaRamadhanus/userland/pkg/storage/redis"
+	_redis "github.com/go-redis/redis"
+	"github.com/stretchr/testify/suite"
+)
+
+type RevocationServiceTestSuite struct {
+	suite.Suite
+	Config            *config.Configuration
+	RedisClient       *_redis.Client
+	RevocationService userland.RevocationService
+}
+
+func NewRevocationServiceTestSuite(cfg *config.Configuration) *RevocationServiceTestSuite {
+	return &RevocationServiceTestSuite{
+		Config: cfg,
+	}
+}
+
+func (suite *RevocationServiceTestSuite) Teardown() {
+	suite.T().Log("Teardown RevocationServiceTestSuite")
+	suite.RedisClient.Close()
+}
+
+func (suite *RevocationServiceTestSuite) SetupTest() {
+	if err := suite.RedisClient.FlushAll().Err(); err != nil {
+		suite.T().Fatalf("RedisClient.FlushAll() err = %v; want nil", err)
+	}
+}
+
+func (suite *RevocationServiceTestSuite) SetupSuite() {
+	suite.T().Logf("Connecting to redis at %v", suite.Config.Redis)
+	redisClient, err := redis.CreateClient(suite.Config.Redis, 0)
+	if err != nil {
+		suite.T().Fatalf("redis.CreateClient() err = %v; want nil", err)
+	}
+	suite.RedisClient = redisClient
+	suite.RevocationService = redis.NewRevocationService(redisClient)
+}
+
+func (suite *RevocationServiceTestSuite) TestPublishAndSubscribe() {
+	subscription, err := suite.RevocationService.Subscribe(context.Background())
+	if err != nil {
+		suite.T().Fatalf("RevocationService.Subscribe() err = %v; want nil", err)
+	}
+	defer subscription.Close()
+
+	revocation := userland.SessionRevocation{SessionID: "session-1", UserID: 1, Reason: userland.SessionEndReasonLogout}
+	if err := suite.RevocationService.Publish(context.Background(), revocation); err != nil {
+		suite.T().Fatalf("RevocationService.Publish() err = %v; want nil", err)
+	}
+
+	select {
+	case received := <-subscription.Revocations():
+		if received.SessionID != revocation.SessionID {
+			suite.T().Errorf("received.SessionID = %s; want %s", received.SessionID, revocation.SessionID)
+		}
+	case <-time.After(5 * time.Second):
+		suite.T().Fatal("RevocationSubscription.Revocations() received nothing")
+	}
+}
+
+func (suite *RevocationServiceTestSuite) TestFindAllSince() {
+	now := time.Now()
+	revocations := []userland.SessionRevocation{
+		{SessionID: "old-session", UserID: 1, Reason: userland.SessionEndReasonLogout, RevokedAt: now.Add(-time.Hour)},
+		{SessionID: "new-session", UserID: 1, Reason: userland.SessionEndReasonRevoked, RevokedAt: now},
+	}
+	for _, revocation := range revocations {
+		if err := suite.RevocationService.Publish(context.Background(), revocation); err != nil {
+			suite.T().Fatalf("RevocationService.Publish() err = %v; want nil", err)
+		}
+	}
+
+	found, err := suite.RevocationService.FindAllSince(context.Background(), now.Add(-time.Minute))
+	if err != nil {
+		suite.T().Fatalf("RevocationService.FindAllSince() err = %v; want nil", err)
+	}
+	if len(found) != 1 || found[0].SessionID != "new-session" {
+		suite.T().Errorf("RevocationService.FindAllSince() = %v; want only new-session", found)
+	}
+}
@@ -1,6 +1,7 @@
 package redis
 
 import (
+	"context"
 	"encoding/json"
 	"math"
 	"strconv"
@@ -13,6 +14,29 @@ import (
 	"github.com/go-redis/redis"
 )
 
+type sessionZSetMember struct {
+	ID         string    `json:"session_id"`
+	IP         string    `json:"ip"`
+	ClientID   int       `json:"client_id"`
+	ClientName string    `json:"client_name"`
+	UserAgent  string    `json:"user_agent"`
+	Browser    string    `json:"browser"`
+	OS         string    `json:"os"`
+	DeviceType string    `json:"device_type"`
+	Country    string    `json:"country,omitempty"`
+	City       string    `json:"city,omitempty"`
+	ASN        int       `json:"asn,omitempty"`
+	LastSeenIP string    `json:"last_seen_ip"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	Expiration int64     `json:"expiration"`
+	ExpiredAt  time.Time `json:"expired_at"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+//MaxWatchAttempts is how many times a change of a session list is run before giving up when other writers keep changing it
+var MaxWatchAttempts = 3
+
 //SessionRepository implements userland.SessionRepository interface using redis
 type SessionRepository struct {
 	redisClient *redis.Client
@@ -25,128 +49,287 @@ func NewSessionRepository(redisClient *redis.Client) *SessionRepository {
 	}
 }
 
-func (s SessionRepository) Create(userID int, session userland.Session) (err error) {
-	sessionTimestamp := time.Now().Unix() + int64(session.Expiration.Seconds())
-	sessionMap := map[string]interface{}{
-		"session_id":  session.ID,
-		"ip":          session.IP,
-		"client_id":   session.ClientID,
-		"client_name": session.ClientName,
-		"created_at":  time.Now(),
-		"updated_at":  time.Now(),
+func (s SessionRepository) Create(ctx context.Context, userID int, session userland.Session) (err error) {
+	now := time.Now()
+	session.CreatedAt = now
+	session.UpdatedAt = now
+	if session.LastSeenAt.IsZero() {
+		session.LastSeenAt = now
+	}
+	if session.LastSeenIP == "" {
+		session.LastSeenIP = session.IP
+	}
+	if session.ExpiredAt.IsZero() {
+		session.ExpiredAt = now.Add(session.Expiration)
 	}
 
-	sessionMapBytes, err := json.Marshal(sessionMap)
+	return s.store(ctx, userID, session)
+}
+
+func (s SessionRepository) Find(ctx context.Context, userID int, sessionID string) (userland.Session, error) {
+	_, session, err := s.findMember(withContext(ctx, s.redisClient), userID, sessionID)
 	if err != nil {
-		return errors.Wrap(err, "json.Marshal() err")
+		return userland.Session{}, err
 	}
-
-	sessionListKey := keygenerator.SessionListKey(userID)
-	if err := s.redisClient.ZAdd(sessionListKey, redis.Z{Score: float64(sessionTimestamp), Member: string(sessionMapBytes)}).Err(); err != nil {
-		return errors.Wrapf(err, "redisClient.ZAdd(%q, redisZ) err", sessionListKey)
+	if !session.ExpiredAt.IsZero() && time.Now().After(session.ExpiredAt) {
+		return userland.Session{}, userland.ErrSessionNotFound
 	}
 
-	return nil
+	return session, nil
 }
 
-func (s SessionRepository) FindAllByUserID(userID int) (userland.Sessions, error) {
+func (s SessionRepository) FindAllByUserID(ctx context.Context, userID int) (userland.Sessions, error) {
 	sessionListKey := keygenerator.SessionListKey(userID)
-	sessionsStr, err := s.redisClient.ZRange(sessionListKey, math.MinInt64, math.MaxInt64).Result()
+	sessionsStr, err := withContext(ctx, s.redisClient).ZRange(sessionListKey, math.MinInt64, math.MaxInt64).Result()
 	if err != nil {
 		return nil, errors.Wrapf(err, "redisClient.ZRange(%q) err", sessionListKey)
 	}
 
+	now := time.Now()
 	sessions := userland.Sessions{}
 	for _, sessionStr := range sessionsStr {
-		sessionMap := map[string]interface{}{}
-		if err := json.Unmarshal([]byte(sessionStr), &sessionMap); err != nil {
-			continue
-		}
-		createdAt, err := time.Parse(time.RFC3339, sessionMap["created_at"].(string))
+		session, err := s.parse(sessionStr)
 		if err != nil {
 			continue
 		}
-		updatedAt, err := time.Parse(time.RFC3339, sessionMap["updated_at"].(string))
-		if err != nil {
+		// expired sessions are left for DeleteExpiredSessions
+		if !session.ExpiredAt.IsZero() && now.After(session.ExpiredAt) {
 			continue
 		}
-		session := userland.Session{
-			ID:         sessionMap["session_id"].(string),
-			IP:         sessionMap["ip"].(string),
-			ClientID:   int(sessionMap["client_id"].(float64)),
-			ClientName: sessionMap["client_name"].(string),
-			CreatedAt:  createdAt,
-			UpdatedAt:  updatedAt,
-		}
 		sessions = append(sessions, session)
 	}
 
 	return sessions, nil
 }
 
-func (s SessionRepository) DeleteExpiredSessions(userID int) (err error) {
+//Update replace the stored session, the score follow session.ExpiredAt so sliding expiration is honored by DeleteExpiredSessions
+func (s SessionRepository) Update(ctx context.Context, userID int, session userland.Session) (err error) {
+	sessionMemberBytes, err := s.marshal(session)
+	if err != nil {
+		return err
+	}
+
+	sessionListKey := keygenerator.SessionListKey(userID)
+	return s.watchSessionList(ctx, userID, func(tx *redis.Tx) error {
+		member, _, err := s.findMember(tx, userID, session.ID)
+		if err != nil {
+			return err
+		}
+
+		score := float64(session.ExpiredAt.Unix())
+		if session.ExpiredAt.IsZero() {
+			// members created before expired_at was stored, keep their original score
+			score, err = tx.ZScore(sessionListKey, member).Result()
+			if err != nil {
+				return errors.Wrapf(err, "redisClient.ZScore(%q) err", sessionListKey)
+			}
+		}
+
+		_, err = tx.Pipelined(func(pipe redis.Pipeliner) error {
+			pipe.ZRem(sessionListKey, member)
+			pipe.ZAdd(sessionListKey, redis.Z{Score: score, Member: string(sessionMemberBytes)})
+			return nil
+		})
+		return err
+	})
+}
+
+func (s SessionRepository) DeleteExpiredSessions(ctx context.Context, userID int) (err error) {
 	sessionListKey := keygenerator.SessionListKey(userID)
 	nowEpochStr := strconv.FormatInt(time.Now().Unix(), 10)
-	if err := s.redisClient.ZRemRangeByScore(sessionListKey, "-inf", nowEpochStr).Err(); err != nil {
+	if err := withContext(ctx, s.redisClient).ZRemRangeByScore(sessionListKey, "-inf", nowEpochStr).Err(); err != nil {
 		return errors.Wrapf(err, "redisClient.ZRemRangeByScore(%q, -inf, %s) err", sessionListKey, nowEpochStr)
 	}
 
 	return nil
 }
 
-func (s SessionRepository) DeleteBySessionID(userID int, sessionID string) (err error) {
+func (s SessionRepository) DeleteBySessionID(ctx context.Context, userID int, sessionID string) (err error) {
 	sessionListKey := keygenerator.SessionListKey(userID)
-	sessionsStr, err := s.redisClient.ZRange(sessionListKey, math.MinInt64, math.MaxInt64).Result()
-	if err != nil {
-		return errors.Wrapf(err, "redisClient.ZRange(%q) err", sessionListKey)
-	}
-
-	var toBedeletedSession string
-	for _, sessionStr := range sessionsStr {
-		sessionMap := map[string]interface{}{}
-		if err := json.Unmarshal([]byte(sessionStr), &sessionMap); err != nil {
+	return s.watchSessionList(ctx, userID, func(tx *redis.Tx) error {
+		member, _, err := s.findMember(tx, userID, sessionID)
+		if err != nil {
 			return err
 		}
-		sessionID := sessionMap["session_id"].(string)
-		if sessionID == sessionID {
-			toBedeletedSession = sessionStr
-			break
-		}
+
+		_, err = tx.Pipelined(func(pipe redis.Pipeliner) error {
+			pipe.ZRem(sessionListKey, member)
+			return nil
+		})
+		return err
+	})
+}
+
+func (s SessionRepository) DeleteOtherSessions(ctx context.Context, userID int, currentSessionID string) (deletedSessionIDs []string, err error) {
+	err = s.removeMembers(ctx, userID, func(session userland.Session) bool {
+		return session.ID != currentSessionID
+	}, &deletedSessionIDs)
+	if err != nil {
+		return nil, err
 	}
-	if toBedeletedSession == "" {
-		return userland.ErrSessionNotFound
+
+	return deletedSessionIDs, nil
+}
+
+func (s SessionRepository) EvictSessions(ctx context.Context, userID int, sessionIDs []string) (err error) {
+	evicted := map[string]bool{}
+	for _, sessionID := range sessionIDs {
+		evicted[sessionID] = true
 	}
 
-	if err := s.redisClient.ZRem(sessionListKey, toBedeletedSession).Err(); err != nil {
-		return errors.Wrapf(err, "redisClient.ZRem(%q, %q) err", sessionListKey, toBedeletedSession)
+	return s.removeMembers(ctx, userID, func(session userland.Session) bool {
+		return evicted[session.ID]
+	}, nil)
+}
+
+func (s SessionRepository) DeleteAllByUserID(ctx context.Context, userID int) (err error) {
+	sessionListKey := keygenerator.SessionListKey(userID)
+	if err := withContext(ctx, s.redisClient).Del(sessionListKey).Err(); err != nil {
+		return errors.Wrapf(err, "redisClient.Del(%q) err", sessionListKey)
 	}
 
 	return nil
 }
 
-func (s SessionRepository) DeleteOtherSessions(userID int, currentSessionID string) (deletedSessionIDs []string, err error) {
+//removeMembers remove every session matched by match in one watched transaction, the removed ids are kept in removedSessionIDs when not nil
+func (s SessionRepository) removeMembers(ctx context.Context, userID int, match func(session userland.Session) bool, removedSessionIDs *[]string) error {
 	sessionListKey := keygenerator.SessionListKey(userID)
-	sessionsStr, err := s.redisClient.ZRange(sessionListKey, math.MinInt64, math.MaxInt64).Result()
+	return s.watchSessionList(ctx, userID, func(tx *redis.Tx) error {
+		sessionsStr, err := tx.ZRange(sessionListKey, math.MinInt64, math.MaxInt64).Result()
+		if err != nil {
+			return errors.Wrapf(err, "redisClient.ZRange(%q) err", sessionListKey)
+		}
+
+		members := []interface{}{}
+		sessionIDs := []string{}
+		for _, sessionStr := range sessionsStr {
+			session, err := s.parse(sessionStr)
+			if err != nil || !match(session) {
+				continue
+			}
+			members = append(members, sessionStr)
+			sessionIDs = append(sessionIDs, session.ID)
+		}
+		if removedSessionIDs != nil {
+			*removedSessionIDs = sessionIDs
+		}
+		if len(members) == 0 {
+			return nil
+		}
+
+		_, err = tx.Pipelined(func(pipe redis.Pipeliner) error {
+			pipe.ZRem(sessionListKey, members...)
+			return nil
+		})
+		return err
+	})
+}
+
+//watchSessionList run fn with the session list of user watched, writes fn queue with tx.Pipelined are discarded when another
+//writer changed the list after fn read it, fn is then run again on a fresh read so a removed session is never put back
+func (s SessionRepository) watchSessionList(ctx context.Context, userID int, fn func(tx *redis.Tx) error) error {
+	// a transaction doesn't carry the client context, check it before every attempt
+	sessionListKey := keygenerator.SessionListKey(userID)
+	for attempt := 1; ; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		err := s.redisClient.Watch(fn, sessionListKey)
+		if err != redis.TxFailedErr {
+			return err
+		}
+		if attempt >= MaxWatchAttempts {
+			return errors.Wrapf(err, "redisClient.Watch(%q) err", sessionListKey)
+		}
+	}
+}
+
+func (s SessionRepository) findMember(redisClient redis.Cmdable, userID int, sessionID string) (member string, session userland.Session, err error) {
+	sessionListKey := keygenerator.SessionListKey(userID)
+	sessionsStr, err := redisClient.ZRange(sessionListKey, math.MinInt64, math.MaxInt64).Result()
 	if err != nil {
-		return nil, errors.Wrapf(err, "redisClient.ZRange(%q) err", sessionListKey)
+		return "", userland.Session{}, errors.Wrapf(err, "redisClient.ZRange(%q) err", sessionListKey)
 	}
 
-	deletedSessionIDs = []string{}
 	for _, sessionStr := range sessionsStr {
-		sessionMap := map[string]interface{}{}
-		if err := json.Unmarshal([]byte(sessionStr), &sessionMap); err != nil {
+		session, err := s.parse(sessionStr)
+		if err != nil {
 			continue
 		}
-		sessionID := sessionMap["session_id"].(string)
-		if sessionID == currentSessionID {
-			continue
+		if session.ID == sessionID {
+			return sessionStr, session, nil
 		}
+	}
 
-		if err = s.redisClient.ZRem(sessionListKey, sessionStr).Err(); err != nil {
-			continue
-		}
-		deletedSessionIDs = append(deletedSessionIDs, sessionID)
+	return "", userland.Session{}, userland.ErrSessionNotFound
+}
+
+func (s SessionRepository) store(ctx context.Context, userID int, session userland.Session) error {
+	sessionMemberBytes, err := s.marshal(session)
+	if err != nil {
+		return err
 	}
 
-	return deletedSessionIDs, nil
+	sessionListKey := keygenerator.SessionListKey(userID)
+	if err := withContext(ctx, s.redisClient).ZAdd(sessionListKey, redis.Z{Score: float64(session.ExpiredAt.Unix()), Member: string(sessionMemberBytes)}).Err(); err != nil {
+		return errors.Wrapf(err, "redisClient.ZAdd(%q, redisZ) err", sessionListKey)
+	}
+
+	return nil
+}
+
+func (s SessionRepository) marshal(session userland.Session) ([]byte, error) {
+	sessionMemberBytes, err := json.Marshal(sessionZSetMember{
+		ID:         session.ID,
+		IP:         session.IP,
+		ClientID:   session.ClientID,
+		ClientName: session.ClientName,
+		UserAgent:  session.UserAgent,
+		Browser:    session.Browser,
+		OS:         session.OS,
+		DeviceType: session.DeviceType,
+		Country:    session.Country,
+		City:       session.City,
+		ASN:        session.ASN,
+		LastSeenIP: session.LastSeenIP,
+		LastSeenAt: session.LastSeenAt,
+		Expiration: int64(session.Expiration.Seconds()),
+		ExpiredAt:  session.ExpiredAt,
+		CreatedAt:  session.CreatedAt,
+		UpdatedAt:  session.UpdatedAt,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "json.Marshal() err")
+	}
+
+	return sessionMemberBytes, nil
+}
+
+//parse also accept members written before session metadata existed, missing fields are left empty
+func (s SessionRepository) parse(sessionStr string) (userland.Session, error) {
+	sessionMember := sessionZSetMember{}
+	if err := json.Unmarshal([]byte(sessionStr), &sessionMember); err != nil {
+		return userland.Session{}, errors.Wrap(err, "json.Unmarshal() err")
+	}
+
+	return userland.Session{
+		ID:         sessionMember.ID,
+		IP:         sessionMember.IP,
+		ClientID:   sessionMember.ClientID,
+		ClientName: sessionMember.ClientName,
+		UserAgent:  sessionMember.UserAgent,
+		Browser:    sessionMember.Browser,
+		OS:         sessionMember.OS,
+		DeviceType: sessionMember.DeviceType,
+		Country:    sessionMember.Country,
+		City:       sessionMember.City,
+		ASN:        sessionMember.ASN,
+		LastSeenIP: sessionMember.LastSeenIP,
+		LastSeenAt: sessionMember.LastSeenAt,
+		Expiration: time.Duration(sessionMember.Expiration) * time.Second,
+		ExpiredAt:  sessionMember.ExpiredAt,
+		CreatedAt:  sessionMember.CreatedAt,
+		UpdatedAt:  sessionMember.UpdatedAt,
+	}, nil
 }
@@ -3,10 +3,13 @@
 package redis_test
 
 import (
+	"context"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/AdhityaRamadhanus/userland"
+	"github.com/AdhityaRamadhanus/userland/pkg/common/keygenerator"
 	"github.com/AdhityaRamadhanus/userland/pkg/common/security"
 	"github.com/AdhityaRamadhanus/userland/pkg/config"
 	"github.com/AdhityaRamadhanus/userland/pkg/storage/redis"
@@ -78,7 +81,7 @@ func (suite *SessionRepositoryTestSuite) TestCreate() {
 
 	for _, tc := range testCases {
 		suite.T().Run(tc.name, func(t *testing.T) {
-			err := suite.SessionRepository.Create(tc.args.userID, tc.args.session)
+			err := suite.SessionRepository.Create(context.Background(), tc.args.userID, tc.args.session)
 			if err != nil && !tc.wantErr {
 				t.Fatalf("SessionRepository.Create() err = %v; want nil", err)
 			}
@@ -90,6 +93,139 @@ func (suite *SessionRepositoryTestSuite) TestCreate() {
 	}
 }
 
+func (suite *SessionRepositoryTestSuite) TestFind() {
+	type args struct {
+		userID int
+	}
+	testCases := []struct {
+		name    string
+		args    args
+		create  bool
+		wantErr error
+	}{
+		{
+			name: "success",
+			args: args{
+				userID: 1,
+			},
+			create:  true,
+			wantErr: nil,
+		},
+		{
+			name: "not found",
+			args: args{
+				userID: 2,
+			},
+			create:  false,
+			wantErr: userland.ErrSessionNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		suite.T().Run(tc.name, func(t *testing.T) {
+			sessionID := security.GenerateUUID()
+			if tc.create {
+				sessionID = userlandtest.TestCreateSession(t, suite.SessionRepository, userlandtest.WithUserID(tc.args.userID)).ID
+			}
+			session, err := suite.SessionRepository.Find(context.Background(), tc.args.userID, sessionID)
+			if err != tc.wantErr {
+				t.Fatalf("SessionRepository.Find(%d, %s) err = %v; want %v", tc.args.userID, sessionID, err, tc.wantErr)
+			}
+			if err == nil && session.ID != sessionID {
+				t.Errorf("SessionRepository.Find(%d, %s) session.ID = %s; want %s", tc.args.userID, sessionID, session.ID, sessionID)
+			}
+		})
+	}
+}
+
+func (suite *SessionRepositoryTestSuite) TestUpdate() {
+	type args struct {
+		userID     int
+		lastSeenIP string
+	}
+	testCases := []struct {
+		name string
+		args args
+	}{
+		{
+			name: "success",
+			args: args{
+				userID:     1,
+				lastSeenIP: "10.10.10.10",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		suite.T().Run(tc.name, func(t *testing.T) {
+			createdSession := userlandtest.TestCreateSession(t, suite.SessionRepository, userlandtest.WithUserID(tc.args.userID))
+			session, err := suite.SessionRepository.Find(context.Background(), tc.args.userID, createdSession.ID)
+			if err != nil {
+				t.Fatalf("SessionRepository.Find(%d, %s) err = %v; want nil", tc.args.userID, createdSession.ID, err)
+			}
+
+			session.LastSeenIP = tc.args.lastSeenIP
+			session.LastSeenAt = time.Now()
+			if err := suite.SessionRepository.Update(context.Background(), tc.args.userID, session); err != nil {
+				t.Fatalf("SessionRepository.Update(%d, <session>) err = %v; want nil", tc.args.userID, err)
+			}
+
+			sessions, err := suite.SessionRepository.FindAllByUserID(context.Background(), tc.args.userID)
+			if err != nil {
+				t.Fatalf("SessionRepository.FindAllByUserID(%d) err = %v; want nil", tc.args.userID, err)
+			}
+			if len(sessions) != 1 {
+				t.Fatalf("SessionRepository.FindAllByUserID(%d) len(sessions) = %d; want 1", tc.args.userID, len(sessions))
+			}
+			if sessions[0].LastSeenIP != tc.args.lastSeenIP {
+				t.Errorf("SessionRepository.FindAllByUserID(%d) LastSeenIP = %s; want %s", tc.args.userID, sessions[0].LastSeenIP, tc.args.lastSeenIP)
+			}
+		})
+	}
+}
+
+func (suite *SessionRepositoryTestSuite) TestUpdate_concurrent() {
+	userID := 1
+	session := userlandtest.TestCreateSession(suite.T(), suite.SessionRepository, userlandtest.WithUserID(userID))
+
+	// touches racing each other must leave one member, a touch racing a logout must not bring the session back
+	const updates = 5
+	var wg sync.WaitGroup
+	for i := 0; i < updates; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			updated := session
+			updated.LastSeenAt = time.Now().Add(time.Duration(i) * time.Second)
+			updated.ExpiredAt = time.Now().Add(time.Hour)
+			suite.SessionRepository.Update(context.Background(), userID, updated)
+		}(i)
+	}
+	wg.Wait()
+
+	members, err := suite.RedisClient.ZCard(keygenerator.SessionListKey(userID)).Result()
+	if err != nil || members != 1 {
+		suite.T().Fatalf("RedisClient.ZCard(session list) = %d, %v; want 1, nil", members, err)
+	}
+
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		suite.SessionRepository.Update(context.Background(), userID, session)
+	}()
+	go func() {
+		defer wg.Done()
+		if err := suite.SessionRepository.DeleteBySessionID(context.Background(), userID, session.ID); err != nil {
+			suite.T().Errorf("SessionRepository.DeleteBySessionID(%d, %s) err = %v; want nil", userID, session.ID, err)
+		}
+	}()
+	wg.Wait()
+
+	if _, err := suite.SessionRepository.Find(context.Background(), userID, session.ID); err != userland.ErrSessionNotFound {
+		suite.T().Errorf("SessionRepository.Find(%d, %s) after delete err = %v; want %v", userID, session.ID, err, userland.ErrSessionNotFound)
+	}
+}
+
 func (suite *SessionRepositoryTestSuite) TestFindAllByUserID() {
 	type args struct {
 		userID       int
@@ -122,7 +258,7 @@ func (suite *SessionRepositoryTestSuite) TestFindAllByUserID() {
 		suite.T().Run(tc.name, func(t *testing.T) {
 			// setup
 			userlandtest.TestCreateSessions(t, suite.SessionRepository, userlandtest.WithNumberOfSessions(tc.args.sessionCount))
-			sessions, err := suite.SessionRepository.FindAllByUserID(tc.args.userID)
+			sessions, err := suite.SessionRepository.FindAllByUserID(context.Background(), tc.args.userID)
 			if err != nil {
 				t.Fatalf("SessionRepository.FindAllByUserID(%d) err = %v; want nil", tc.args.userID, err)
 			}
@@ -176,10 +312,10 @@ func (suite *SessionRepositoryTestSuite) TestDeleteExpiredSessions() {
 			_ = longSessions
 			// wait till short sessions expired
 			time.Sleep(2 * shortExp)
-			if err := suite.SessionRepository.DeleteExpiredSessions(tc.args.userID); err != nil {
+			if err := suite.SessionRepository.DeleteExpiredSessions(context.Background(), tc.args.userID); err != nil {
 				t.Fatalf("SessionRepository.DeleteExpiredSessions(%d) err = %v; want nil", tc.args.userID, err)
 			}
-			sessions, err := suite.SessionRepository.FindAllByUserID(tc.args.userID)
+			sessions, err := suite.SessionRepository.FindAllByUserID(context.Background(), tc.args.userID)
 			if err != nil {
 				t.Fatalf("SessionRepository.FindAllByUserID(%d) err = %v; want nil", tc.args.userID, err)
 			}
@@ -194,7 +330,8 @@ func (suite *SessionRepositoryTestSuite) TestDeleteExpiredSessions() {
 
 func (suite *SessionRepositoryTestSuite) TestDeleteBySessionID() {
 	type args struct {
-		userID int
+		userID           int
+		numberOfSessions int
 	}
 	testCases := []struct {
 		name string
@@ -203,28 +340,46 @@ func (suite *SessionRepositoryTestSuite) TestDeleteBySessionID() {
 		{
 			name: "success",
 			args: args{
-				userID: 1,
+				userID:           1,
+				numberOfSessions: 1,
+			},
+		},
+		{
+			name: "only delete matching session",
+			args: args{
+				userID:           2,
+				numberOfSessions: 3,
 			},
 		},
 	}
 
 	for _, tc := range testCases {
 		suite.T().Run(tc.name, func(t *testing.T) {
-			session := userlandtest.TestCreateSession(t, suite.SessionRepository, userlandtest.WithUserID(tc.args.userID))
-			err := suite.SessionRepository.DeleteBySessionID(tc.args.userID, session.ID)
+			sessions := userlandtest.TestCreateSessions(t, suite.SessionRepository,
+				userlandtest.WithUserID(tc.args.userID),
+				userlandtest.WithNumberOfSessions(tc.args.numberOfSessions),
+			)
+			// delete the last session so a match on the first member is caught
+			session := sessions[len(sessions)-1]
+			err := suite.SessionRepository.DeleteBySessionID(context.Background(), tc.args.userID, session.ID)
 			if err != nil {
 				t.Fatalf("SessionRepository.DeleteBySessionID(%d, %s) err = %v; want nil", tc.args.userID, session.ID, err)
 			}
-			sessions, err := suite.SessionRepository.FindAllByUserID(tc.args.userID)
+			sessions, err = suite.SessionRepository.FindAllByUserID(context.Background(), tc.args.userID)
 			if err != nil {
 				t.Fatalf("SessionRepository.FindAllByUserID(%d) err = %v; want nil", tc.args.userID, err)
 			}
 
 			gotCount := len(sessions)
-			wantCount := 0
+			wantCount := tc.args.numberOfSessions - 1
 			if gotCount != wantCount {
 				t.Errorf("SessionRepository.FindAllByUserID(%d) len(sessions) = %d; want %d", tc.args.userID, gotCount, wantCount)
 			}
+			for _, remainingSession := range sessions {
+				if remainingSession.ID == session.ID {
+					t.Errorf("SessionRepository.FindAllByUserID(%d) contains deleted session %s", tc.args.userID, session.ID)
+				}
+			}
 		})
 	}
 }
@@ -253,11 +408,11 @@ func (suite *SessionRepositoryTestSuite) TestDeleteOtherSessions() {
 
 			// just pick the first session ID
 			keepSessionID := sessions[0].ID
-			_, err := suite.SessionRepository.DeleteOtherSessions(tc.args.userID, keepSessionID)
+			_, err := suite.SessionRepository.DeleteOtherSessions(context.Background(), tc.args.userID, keepSessionID)
 			if err != nil {
 				t.Fatalf("SessionRepository.DeleteBySessionID(%d, %s) err = %v; want nil", tc.args.userID, keepSessionID, err)
 			}
-			sessions, err = suite.SessionRepository.FindAllByUserID(tc.args.userID)
+			sessions, err = suite.SessionRepository.FindAllByUserID(context.Background(), tc.args.userID)
 			if err != nil {
 				t.Fatalf("SessionRepository.FindAllByUserID(%d) err = %v; want nil", tc.args.userID, err)
 			}
@@ -270,3 +425,23 @@ func (suite *SessionRepositoryTestSuite) TestDeleteOtherSessions() {
 		})
 	}
 }
+
+func (suite *SessionRepositoryTestSuite) TestEvictSessions() {
+	userID := 1
+	sessions := userlandtest.TestCreateSessions(suite.T(), suite.SessionRepository,
+		userlandtest.WithUserID(userID),
+		userlandtest.WithNumberOfSessions(3),
+	)
+	evictedSessionIDs := []string{sessions[0].ID, sessions[1].ID}
+	if err := suite.SessionRepository.EvictSessions(context.Background(), userID, evictedSessionIDs); err != nil {
+		suite.T().Fatalf("SessionRepository.EvictSessions(%d, %v) err = %v; want nil", userID, evictedSessionIDs, err)
+	}
+
+	activeSessions, err := suite.SessionRepository.FindAllByUserID(context.Background(), userID)
+	if err != nil {
+		suite.T().Fatalf("SessionRepository.FindAllByUserID(%d) err = %v; want nil", userID, err)
+	}
+	if len(activeSessions) != 1 || activeSessions[0].ID != sessions[2].ID {
+		suite.T().Errorf("SessionRepository.FindAllByUserID(%d) = %v; want only %s", userID, activeSessions, sessions[2].ID)
+	}
+}
@@ -0,0 +1,180 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/AdhityaRamadhanus/userland"
+	"github.com/AdhityaRamadhanus/userland/pkg/common/keygenerator"
+	"github.com/pkg/errors"
+
+	"github.com/go-redis/redis"
+)
+
+type trustedDeviceHashValue struct {
+	ID         string    `json:"device_id"`
+	TokenHash  string    `json:"token_hash"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	ClientID   int       `json:"client_id"`
+	ClientName string    `json:"client_name"`
+	ExpiredAt  time.Time `json:"expired_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+//TrustedDeviceRepository implements userland.TrustedDeviceRepository interface using redis
+type TrustedDeviceRepository struct {
+	redisClient *redis.Client
+}
+
+//NewTrustedDeviceRepository construct a new TrustedDeviceRepository from redis client
+func NewTrustedDeviceRepository(redisClient *redis.Client) *TrustedDeviceRepository {
+	return &TrustedDeviceRepository{
+		redisClient: redisClient,
+	}
+}
+
+func (t TrustedDeviceRepository) Create(ctx context.Context, userID int, device userland.TrustedDevice) (err error) {
+	now := time.Now()
+	device.CreatedAt = now
+	device.LastUsedAt = now
+	device.ExpiredAt = now.Add(device.Expiration)
+	if err := t.store(ctx, userID, device); err != nil {
+		return err
+	}
+
+	// the hash live as long as the newest device
+	trustedDeviceListKey := keygenerator.TrustedDeviceListKey(userID)
+	if err := withContext(ctx, t.redisClient).Expire(trustedDeviceListKey, device.Expiration).Err(); err != nil {
+		return errors.Wrapf(err, "redisClient.Expire(%q) err", trustedDeviceListKey)
+	}
+
+	return nil
+}
+
+func (t TrustedDeviceRepository) Find(ctx context.Context, userID int, deviceID string) (userland.TrustedDevice, error) {
+	trustedDeviceListKey := keygenerator.TrustedDeviceListKey(userID)
+	deviceStr, err := withContext(ctx, t.redisClient).HGet(trustedDeviceListKey, deviceID).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return userland.TrustedDevice{}, userland.ErrTrustedDeviceNotFound
+		}
+		return userland.TrustedDevice{}, errors.Wrapf(err, "redisClient.HGet(%q, %q) err", trustedDeviceListKey, deviceID)
+	}
+
+	device, err := t.parse(deviceStr)
+	if err != nil {
+		return userland.TrustedDevice{}, err
+	}
+	if time.Now().After(device.ExpiredAt) {
+		withContext(ctx, t.redisClient).HDel(trustedDeviceListKey, deviceID)
+		return userland.TrustedDevice{}, userland.ErrTrustedDeviceNotFound
+	}
+
+	return device, nil
+}
+
+func (t TrustedDeviceRepository) FindAllByUserID(ctx context.Context, userID int) (userland.TrustedDevices, error) {
+	trustedDeviceListKey := keygenerator.TrustedDeviceListKey(userID)
+	devicesStr, err := withContext(ctx, t.redisClient).HGetAll(trustedDeviceListKey).Result()
+	if err != nil {
+		return nil, errors.Wrapf(err, "redisClient.HGetAll(%q) err", trustedDeviceListKey)
+	}
+
+	now := time.Now()
+	devices := userland.TrustedDevices{}
+	for deviceID, deviceStr := range devicesStr {
+		device, err := t.parse(deviceStr)
+		if err != nil {
+			continue
+		}
+		// remove expired devices
+		if now.After(device.ExpiredAt) {
+			withContext(ctx, t.redisClient).HDel(trustedDeviceListKey, deviceID)
+			continue
+		}
+		devices = append(devices, device)
+	}
+
+	return devices, nil
+}
+
+func (t TrustedDeviceRepository) Update(ctx context.Context, userID int, device userland.TrustedDevice) (err error) {
+	trustedDeviceListKey := keygenerator.TrustedDeviceListKey(userID)
+	exists, err := withContext(ctx, t.redisClient).HExists(trustedDeviceListKey, device.ID).Result()
+	if err != nil {
+		return errors.Wrapf(err, "redisClient.HExists(%q, %q) err", trustedDeviceListKey, device.ID)
+	}
+	if !exists {
+		return userland.ErrTrustedDeviceNotFound
+	}
+
+	return t.store(ctx, userID, device)
+}
+
+func (t TrustedDeviceRepository) Delete(ctx context.Context, userID int, deviceID string) (err error) {
+	trustedDeviceListKey := keygenerator.TrustedDeviceListKey(userID)
+	deleted, err := withContext(ctx, t.redisClient).HDel(trustedDeviceListKey, deviceID).Result()
+	if err != nil {
+		return errors.Wrapf(err, "redisClient.HDel(%q, %q) err", trustedDeviceListKey, deviceID)
+	}
+	if deleted == 0 {
+		return userland.ErrTrustedDeviceNotFound
+	}
+
+	return nil
+}
+
+func (t TrustedDeviceRepository) DeleteAllByUserID(ctx context.Context, userID int) (err error) {
+	trustedDeviceListKey := keygenerator.TrustedDeviceListKey(userID)
+	if err := withContext(ctx, t.redisClient).Del(trustedDeviceListKey).Err(); err != nil {
+		return errors.Wrapf(err, "redisClient.Del(%q) err", trustedDeviceListKey)
+	}
+
+	return nil
+}
+
+func (t TrustedDeviceRepository) store(ctx context.Context, userID int, device userland.TrustedDevice) error {
+	deviceBytes, err := json.Marshal(trustedDeviceHashValue{
+		ID:         device.ID,
+		TokenHash:  device.TokenHash,
+		UserAgent:  device.UserAgent,
+		IP:         device.IP,
+		ClientID:   device.ClientID,
+		ClientName: device.ClientName,
+		ExpiredAt:  device.ExpiredAt,
+		LastUsedAt: device.LastUsedAt,
+		CreatedAt:  device.CreatedAt,
+	})
+	if err != nil {
+		return errors.Wrap(err, "json.Marshal() err")
+	}
+
+	trustedDeviceListKey := keygenerator.TrustedDeviceListKey(userID)
+	if err := withContext(ctx, t.redisClient).HSet(trustedDeviceListKey, device.ID, string(deviceBytes)).Err(); err != nil {
+		return errors.Wrapf(err, "redisClient.HSet(%q, %q) err", trustedDeviceListKey, device.ID)
+	}
+
+	return nil
+}
+
+func (t TrustedDeviceRepository) parse(deviceStr string) (userland.TrustedDevice, error) {
+	deviceHashValue := trustedDeviceHashValue{}
+	if err := json.Unmarshal([]byte(deviceStr), &deviceHashValue); err != nil {
+		return userland.TrustedDevice{}, errors.Wrap(err, "json.Unmarshal() err")
+	}
+
+	return userland.TrustedDevice{
+		ID:         deviceHashValue.ID,
+		TokenHash:  deviceHashValue.TokenHash,
+		UserAgent:  deviceHashValue.UserAgent,
+		IP:         deviceHashValue.IP,
+		ClientID:   deviceHashValue.ClientID,
+		ClientName: deviceHashValue.ClientName,
+		ExpiredAt:  deviceHashValue.ExpiredAt,
+		LastUsedAt: deviceHashValue.LastUsedAt,
+		CreatedAt:  deviceHashValue.CreatedAt,
+	}, nil
+}
@@ -0,0 +1,153 @@
+// +build integration
+
+package redis_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/AdhityaRamadhanus/userland"
+	"github.com/AdhityaRamadhanus/userland/pkg/common/security"
+	"github.com/AdhityaRamadhanus/userland/pkg/config"
+	"github.com/AdhityaRamadhanus/userland/pkg/storage/redis"
+	_redis "github.com/go-redis/redis"
+	"github.com/stretchr/testify/suite"
+)
+
+type TrustedDeviceRepositoryTestSuite struct {
+	suite.Suite
+	Config                  *config.Configuration
+	RedisClient             *_redis.Client
+	TrustedDeviceRepository userland.TrustedDeviceRepository
+}
+
+func NewTrustedDeviceRepositoryTestSuite(cfg *config.Configuration) *TrustedDeviceRepositoryTestSuite {
+	return &TrustedDeviceRepositoryTestSuite{
+		Config: cfg,
+	}
+}
+
+func (suite *TrustedDeviceRepositoryTestSuite) Teardown() {
+	suite.T().Log("Teardown TrustedDeviceRepositoryTestSuite")
+	suite.RedisClient.Close()
+}
+
+func (suite *TrustedDeviceRepositoryTestSuite) SetupTest() {
+	if err := suite.RedisClient.FlushAll().Err(); err != nil {
+		suite.T().Fatalf("RedisClient.FlushAll() err = %v; want nil", err)
+	}
+}
+
+func (suite *TrustedDeviceRepositoryTestSuite) SetupSuite() {
+	suite.T().Logf("Connecting to redis at %v", suite.Config.Redis)
+	redisClient, err := redis.CreateClient(suite.Config.Redis, 0)
+	if err != nil {
+		suite.T().Fatalf("redis.CreateClient() err = %v; want nil", err)
+	}
+	suite.RedisClient = redisClient
+	suite.TrustedDeviceRepository = redis.NewTrustedDeviceRepository(redisClient)
+}
+
+func (suite *TrustedDeviceRepositoryTestSuite) createTrustedDevice(t *testing.T, userID int, expiration time.Duration) userland.TrustedDevice {
+	device := userland.TrustedDevice{
+		ID:         security.GenerateUUID(),
+		TokenHash:  security.HashToken("secret"),
+		UserAgent:  "test",
+		IP:         "123.123.13.123",
+		ClientID:   1,
+		ClientName: "test",
+		Expiration: expiration,
+	}
+	if err := suite.TrustedDeviceRepository.Create(context.Background(), userID, device); err != nil {
+		t.Fatalf("TrustedDeviceRepository.Create() err = %v; want nil", err)
+	}
+
+	return device
+}
+
+func (suite *TrustedDeviceRepositoryTestSuite) TestFind() {
+	type args struct {
+		expiration time.Duration
+	}
+	testCases := []struct {
+		name    string
+		args    args
+		wantErr error
+	}{
+		{
+			name: "success",
+			args: args{
+				expiration: time.Minute,
+			},
+			wantErr: nil,
+		},
+		{
+			name: "expired",
+			args: args{
+				expiration: time.Millisecond,
+			},
+			wantErr: userland.ErrTrustedDeviceNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		suite.T().Run(tc.name, func(t *testing.T) {
+			device := suite.createTrustedDevice(t, 1, tc.args.expiration)
+			time.Sleep(10 * time.Millisecond)
+
+			foundDevice, err := suite.TrustedDeviceRepository.Find(context.Background(), 1, device.ID)
+			if err != tc.wantErr {
+				t.Fatalf("TrustedDeviceRepository.Find(1, %q) err = %v; want %v", device.ID, err, tc.wantErr)
+			}
+
+			if tc.wantErr == nil && foundDevice.TokenHash != device.TokenHash {
+				t.Errorf("TrustedDeviceRepository.Find(1, %q) TokenHash = %q; want %q", device.ID, foundDevice.TokenHash, device.TokenHash)
+			}
+		})
+	}
+}
+
+func (suite *TrustedDeviceRepositoryTestSuite) TestFindAllByUserID() {
+	suite.createTrustedDevice(suite.T(), 1, time.Minute)
+	suite.createTrustedDevice(suite.T(), 1, time.Minute)
+	suite.createTrustedDevice(suite.T(), 2, time.Minute)
+
+	devices, err := suite.TrustedDeviceRepository.FindAllByUserID(context.Background(), 1)
+	if err != nil {
+		suite.T().Fatalf("TrustedDeviceRepository.FindAllByUserID(1) err = %v; want nil", err)
+	}
+
+	if len(devices) != 2 {
+		suite.T().Errorf("TrustedDeviceRepository.FindAllByUserID(1) len(devices) = %d; want 2", len(devices))
+	}
+}
+
+func (suite *TrustedDeviceRepositoryTestSuite) TestDelete() {
+	device := suite.createTrustedDevice(suite.T(), 1, time.Minute)
+
+	if err := suite.TrustedDeviceRepository.Delete(context.Background(), 1, device.ID); err != nil {
+		suite.T().Fatalf("TrustedDeviceRepository.Delete(1, %q) err = %v; want nil", device.ID, err)
+	}
+
+	if err := suite.TrustedDeviceRepository.Delete(context.Background(), 1, device.ID); err != userland.ErrTrustedDeviceNotFound {
+		suite.T().Fatalf("TrustedDeviceRepository.Delete(1, %q) err = %v; want %v", device.ID, err, userland.ErrTrustedDeviceNotFound)
+	}
+}
+
+func (suite *TrustedDeviceRepositoryTestSuite) TestDeleteAllByUserID() {
+	suite.createTrustedDevice(suite.T(), 1, time.Minute)
+	suite.createTrustedDevice(suite.T(), 1, time.Minute)
+
+	if err := suite.TrustedDeviceRepository.DeleteAllByUserID(context.Background(), 1); err != nil {
+		suite.T().Fatalf("TrustedDeviceRepository.DeleteAllByUserID(1) err = %v; want nil", err)
+	}
+
+	devices, err := suite.TrustedDeviceRepository.FindAllByUserID(context.Background(), 1)
+	if err != nil {
+		suite.T().Fatalf("TrustedDeviceRepository.FindAllByUserID(1) err = %v; want nil", err)
+	}
+	if len(devices) != 0 {
+		suite.T().Errorf("TrustedDeviceRepository.FindAllByUserID(1) len(devices) = %d; want 0", len(devices))
+	}
+}
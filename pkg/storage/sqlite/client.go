@@ -0,0 +1,202 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/AdhityaRamadhanus/userland"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+)
+
+type ClientScanStruct struct {
+	ID         int
+	Name       string
+	Type       string
+	Public     bool
+	SecretHash sql.NullString `db:"secret_hash"`
+	// AllowedOrigins is a json array, sqlite has no array type
+	AllowedOrigins sql.NullString `db:"allowed_origins"`
+	Status         string
+	CreatedAt      time.Time `db:"created_at"`
+	UpdatedAt      time.Time `db:"updated_at"`
+}
+
+const clientColumns = `id,
+				name,
+				type,
+				public,
+				secret_hash,
+				allowed_origins,
+				status,
+				created_at,
+				updated_at`
+
+/*
+ClientRepository is implementation of ClientRepository interface
+of userland domain using sqlite
+*/
+type ClientRepository struct {
+	db database
+	repositoryOptions
+}
+
+//NewClientRepository is constructor to create client repository
+func NewClientRepository(conn *sqlx.DB, opts ...RepositoryOption) *ClientRepository {
+	return &ClientRepository{
+		db:                conn,
+		repositoryOptions: buildRepositoryOptions(opts),
+	}
+}
+
+//Find Client by id
+func (c ClientRepository) Find(ctx context.Context, id int) (userland.Client, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	clientScanStruct := ClientScanStruct{}
+	query := `SELECT ` + clientColumns + ` FROM clients WHERE id=?`
+	if err := c.db.GetContext(ctx, &clientScanStruct, query, id); err != nil {
+		if err == sql.ErrNoRows {
+			return userland.Client{}, userland.ErrClientNotFound
+		}
+		return userland.Client{}, errors.Wrap(err, "db.Get() err")
+	}
+
+	return c.convertStructScanToEntity(clientScanStruct)
+}
+
+//FindAll registered clients ordered by id
+func (c ClientRepository) FindAll(ctx context.Context) (userland.Clients, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	clientScanStructs := []ClientScanStruct{}
+	query := `SELECT ` + clientColumns + ` FROM clients ORDER BY id`
+	if err := c.db.SelectContext(ctx, &clientScanStructs, query); err != nil {
+		return nil, errors.Wrap(err, "db.Select() err")
+	}
+
+	clients := userland.Clients{}
+	for _, clientScanStruct := range clientScanStructs {
+		client, err := c.convertStructScanToEntity(clientScanStruct)
+		if err != nil {
+			return nil, err
+		}
+		clients = append(clients, client)
+	}
+	return clients, nil
+}
+
+//Insert insert client to datastore
+func (c ClientRepository) Insert(ctx context.Context, client *userland.Client) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	allowedOrigins, err := json.Marshal(client.AllowedOrigins)
+	if err != nil {
+		return errors.Wrap(err, "json.Marshal(client.AllowedOrigins) err")
+	}
+
+	query := `INSERT INTO clients (
+				name,
+				type,
+				public,
+				secret_hash,
+				allowed_origins,
+				status,
+				created_at,
+				updated_at
+			) VALUES (?, ?, ?, NULLIF(?, ''), ?, ?, ?, ?)`
+
+	now := time.Now().UTC()
+	res, err := c.db.ExecContext(ctx, query,
+		client.Name,
+		client.Type,
+		client.Public,
+		client.SecretHash,
+		string(allowedOrigins),
+		client.Status,
+		now,
+		now,
+	)
+	if err != nil {
+		return errors.Wrap(err, "db.Exec() err")
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return errors.Wrap(err, "res.LastInsertId() err")
+	}
+	client.ID = int(id)
+	client.CreatedAt = now
+	client.UpdatedAt = now
+	return nil
+}
+
+//Update update name, allowed origins, status and secret of a client
+func (c ClientRepository) Update(ctx context.Context, client userland.Client) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	allowedOrigins, err := json.Marshal(client.AllowedOrigins)
+	if err != nil {
+		return errors.Wrap(err, "json.Marshal(client.AllowedOrigins) err")
+	}
+
+	query := `UPDATE clients SET
+				name=?,
+				secret_hash=NULLIF(?, ''),
+				allowed_origins=?,
+				status=?,
+				updated_at=?
+			WHERE id=?`
+
+	res, err := c.db.ExecContext(ctx, query,
+		client.Name,
+		client.SecretHash,
+		string(allowedOrigins),
+		client.Status,
+		time.Now().UTC(),
+		client.ID,
+	)
+	if err != nil {
+		return errors.Wrap(err, "db.Exec() err")
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "res.RowsAffected() err")
+	}
+
+	if rowsAffected == 0 {
+		return userland.ErrClientNotFound
+	}
+
+	return nil
+}
+
+func (c ClientRepository) convertStructScanToEntity(clientScanStruct ClientScanStruct) (userland.Client, error) {
+	client := userland.Client{
+		ID:        clientScanStruct.ID,
+		Name:      clientScanStruct.Name,
+		Type:      clientScanStruct.Type,
+		Public:    clientScanStruct.Public,
+		Status:    clientScanStruct.Status,
+		CreatedAt: clientScanStruct.CreatedAt,
+		UpdatedAt: clientScanStruct.UpdatedAt,
+	}
+
+	if clientScanStruct.SecretHash.Valid {
+		client.SecretHash = clientScanStruct.SecretHash.String
+	}
+	if clientScanStruct.AllowedOrigins.Valid {
+		if err := json.Unmarshal([]byte(clientScanStruct.AllowedOrigins.String), &client.AllowedOrigins); err != nil {
+			return userland.Client{}, errors.Wrap(err, "json.Unmarshal(allowed_origins) err")
+		}
+	}
+
+	return client, nil
+}
@@ -0,0 +1,57 @@
+//+build unit
+
+package sqlite_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/AdhityaRamadhanus/userland"
+	"github.com/AdhityaRamadhanus/userland/pkg/storage/sqlite"
+)
+
+func TestClientRepository(t *testing.T) {
+	db := createMigratedConnection(t)
+	defer db.Close()
+
+	clientRepository := sqlite.NewClientRepository(db)
+	client := userland.Client{
+		Name:           "web",
+		Type:           userland.ClientTypeWeb,
+		Public:         true,
+		AllowedOrigins: []string{"https://userland.io"},
+		Status:         userland.ClientStatusActive,
+	}
+	if err := clientRepository.Insert(context.Background(), &client); err != nil || client.ID == 0 {
+		t.Fatalf("ClientRepository.Insert(client) = %d, %v; want generated id, nil", client.ID, err)
+	}
+
+	found, err := clientRepository.Find(context.Background(), client.ID)
+	if err != nil {
+		t.Fatalf("ClientRepository.Find(%d) err = %v; want nil", client.ID, err)
+	}
+	if !found.Public || !reflect.DeepEqual(found.AllowedOrigins, client.AllowedOrigins) || found.SecretHash != "" {
+		t.Errorf("ClientRepository.Find(%d) = %+v; want %+v", client.ID, found, client)
+	}
+	if _, err := clientRepository.Find(context.Background(), client.ID+1); err != userland.ErrClientNotFound {
+		t.Errorf("ClientRepository.Find(%d) err = %v; want %v", client.ID+1, err, userland.ErrClientNotFound)
+	}
+
+	client.Status = userland.ClientStatusDisabled
+	client.SecretHash = "hash"
+	if err := clientRepository.Update(context.Background(), client); err != nil {
+		t.Fatalf("ClientRepository.Update(client) err = %v; want nil", err)
+	}
+	if err := clientRepository.Update(context.Background(), userland.Client{ID: client.ID + 1}); err != userland.ErrClientNotFound {
+		t.Errorf("ClientRepository.Update(missing) err = %v; want %v", err, userland.ErrClientNotFound)
+	}
+
+	clients, err := clientRepository.FindAll(context.Background())
+	if err != nil || len(clients) != 1 {
+		t.Fatalf("ClientRepository.FindAll() = %d clients, %v; want 1, nil", len(clients), err)
+	}
+	if clients[0].Status != userland.ClientStatusDisabled || clients[0].SecretHash != "hash" {
+		t.Errorf("ClientRepository.FindAll()[0] = %+v; want updated client", clients[0])
+	}
+}
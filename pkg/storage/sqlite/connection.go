@@ -0,0 +1,69 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/AdhityaRamadhanus/userland/pkg/config"
+	"github.com/jmoiron/sqlx"
+	"github.com/mattn/go-sqlite3"
+)
+
+//CreateConnection open the sqlite database file at cfg.Path, sqlite allow a single writer so only one connection is kept open
+func CreateConnection(cfg config.SQLiteConfig) (*sqlx.DB, error) {
+	db, err := sqlx.Open("sqlite3", cfg.Path+"?_busy_timeout=5000&_foreign_keys=1")
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1)
+	// sqlx.Open only validate its arguments, ping so a path that can't be opened fail at startup
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+//isUniqueViolation also match primary key violation, text primary keys such as session id are not backed by rowid
+func isUniqueViolation(err error) bool {
+	sqliteErr, ok := err.(sqlite3.Error)
+	return ok && (sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique || sqliteErr.ExtendedCode == sqlite3.ErrConstraintPrimaryKey)
+}
+
+//database is satisfied by both *sqlx.DB and *sqlx.Tx, so a repository can run its queries inside a transaction
+type database interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+}
+
+//RepositoryOption configure every repository of this package
+type RepositoryOption func(*repositoryOptions)
+
+type repositoryOptions struct {
+	queryTimeout time.Duration
+}
+
+//WithQueryTimeout cancel a query running longer than timeout, even when the caller context has no deadline
+func WithQueryTimeout(timeout time.Duration) RepositoryOption {
+	return func(opts *repositoryOptions) {
+		opts.queryTimeout = timeout
+	}
+}
+
+func buildRepositoryOptions(opts []RepositoryOption) repositoryOptions {
+	options := repositoryOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
+
+//withTimeout derive the context a repository call run its queries with
+func (o repositoryOptions) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if o.queryTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, o.queryTimeout)
+}
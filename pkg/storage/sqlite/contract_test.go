@@ -0,0 +1,80 @@
+//+build unit
+
+package sqlite_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/AdhityaRamadhanus/userland"
+	"github.com/AdhityaRamadhanus/userland/pkg/common/migration"
+	"github.com/AdhityaRamadhanus/userland/pkg/config"
+	"github.com/AdhityaRamadhanus/userland/pkg/storage/sqlite"
+	"github.com/AdhityaRamadhanus/userland/pkg/userlandtest"
+	"github.com/jmoiron/sqlx"
+)
+
+//createMigratedConnection open an in-memory database with every up migration applied
+func createMigratedConnection(t *testing.T) *sqlx.DB {
+	db, err := sqlite.CreateConnection(config.SQLiteConfig{Path: ":memory:"})
+	if err != nil {
+		t.Fatalf("sqlite.CreateConnection() err = %v; want nil", err)
+	}
+
+	migrations, err := sqlite.Migrations()
+	if err != nil {
+		t.Fatalf("sqlite.Migrations() err = %v; want nil", err)
+	}
+	migrator := migration.NewMigrator(sqlite.NewMigrationDriver(db), migrations)
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("migrator.Up() err = %v; want nil", err)
+	}
+	return db
+}
+
+func truncate(t *testing.T, db *sqlx.DB, table string) {
+	query := "DELETE FROM " + table
+	if _, err := db.Exec(query); err != nil {
+		t.Fatalf("db.Exec(%q) err = %v; want nil", query, err)
+	}
+}
+
+func TestUserRepository_contract(t *testing.T) {
+	db := createMigratedConnection(t)
+	defer db.Close()
+
+	userlandtest.RunUserRepositoryContract(t, func(t *testing.T) userland.UserRepository {
+		truncate(t, db, "users")
+		return sqlite.NewUserRepository(db)
+	})
+}
+
+func TestEventRepository_contract(t *testing.T) {
+	db := createMigratedConnection(t)
+	defer db.Close()
+
+	userlandtest.RunEventRepositoryContract(t, func(t *testing.T) userland.EventRepository {
+		truncate(t, db, "events")
+		return sqlite.NewEventRepository(db)
+	})
+}
+
+func TestSessionRepository_contract(t *testing.T) {
+	db := createMigratedConnection(t)
+	defer db.Close()
+
+	userlandtest.RunSessionRepositoryContract(t, func(t *testing.T) userland.SessionRepository {
+		truncate(t, db, "sessions")
+		return sqlite.NewSessionRepository(db)
+	})
+}
+
+func TestDataExportRepository_contract(t *testing.T) {
+	db := createMigratedConnection(t)
+	defer db.Close()
+
+	userlandtest.RunDataExportRepositoryContract(t, func(t *testing.T) userland.DataExportRepository {
+		truncate(t, db, "data_exports")
+		return sqlite.NewDataExportRepository(db)
+	})
+}
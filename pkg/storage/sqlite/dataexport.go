@@ -0,0 +1,187 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/AdhityaRamadhanus/userland"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+)
+
+type DataExportScanStruct struct {
+	ID          int
+	UserID      int            `db:"user_id"`
+	Path        sql.NullString `db:"path"`
+	RequestedAt time.Time      `db:"requested_at"`
+	StartedAt   *time.Time     `db:"started_at"`
+	CompletedAt *time.Time     `db:"completed_at"`
+	ExpiredAt   *time.Time     `db:"expired_at"`
+}
+
+const dataExportColumns = `id,
+				user_id,
+				path,
+				requested_at,
+				started_at,
+				completed_at,
+				expired_at`
+
+/*
+DataExportRepository is implementation of DataExportRepository interface
+of userland domain using sqlite
+*/
+type DataExportRepository struct {
+	db database
+	repositoryOptions
+}
+
+//NewDataExportRepository is constructor to create data export repository
+func NewDataExportRepository(conn *sqlx.DB, opts ...RepositoryOption) *DataExportRepository {
+	return &DataExportRepository{
+		db:                conn,
+		repositoryOptions: buildRepositoryOptions(opts),
+	}
+}
+
+//Insert record a pending data export
+func (d DataExportRepository) Insert(ctx context.Context, dataExport *userland.DataExport) error {
+	ctx, cancel := d.withTimeout(ctx)
+	defer cancel()
+
+	now := time.Now().UTC()
+	query := `INSERT INTO data_exports (user_id, requested_at) VALUES (?, ?)`
+	res, err := d.db.ExecContext(ctx, query, dataExport.UserID, now)
+	if err != nil {
+		return errors.Wrap(err, "db.Exec() err")
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return errors.Wrap(err, "res.LastInsertId() err")
+	}
+	dataExport.ID = int(id)
+	dataExport.RequestedAt = now
+	return nil
+}
+
+//FindAllPending return exports not completed yet and not claimed since startedBefore, oldest first
+func (d DataExportRepository) FindAllPending(ctx context.Context, startedBefore time.Time) (userland.DataExports, error) {
+	query := `SELECT ` + dataExportColumns + `
+			FROM data_exports
+			WHERE completed_at IS NULL AND (started_at IS NULL OR started_at < ?)
+			ORDER BY requested_at, id`
+	return d.findAll(ctx, query, startedBefore.UTC())
+}
+
+//Claim mark a pending export as started, the conditional update let only one exporter win
+func (d DataExportRepository) Claim(ctx context.Context, id int, startedBefore time.Time) error {
+	ctx, cancel := d.withTimeout(ctx)
+	defer cancel()
+
+	query := `UPDATE data_exports SET started_at=?
+			WHERE id=? AND completed_at IS NULL AND (started_at IS NULL OR started_at < ?)`
+	res, err := d.db.ExecContext(ctx, query, time.Now().UTC(), id, startedBefore.UTC())
+	if err != nil {
+		return errors.Wrap(err, "db.Exec() err")
+	}
+
+	return dataExportAffected(res)
+}
+
+//Complete set the archive path and link expiration of an export
+func (d DataExportRepository) Complete(ctx context.Context, id int, path string, expiredAt time.Time) error {
+	ctx, cancel := d.withTimeout(ctx)
+	defer cancel()
+
+	query := `UPDATE data_exports SET path=?, completed_at=?, expired_at=? WHERE id=?`
+	res, err := d.db.ExecContext(ctx, query, path, time.Now().UTC(), expiredAt.UTC(), id)
+	if err != nil {
+		return errors.Wrap(err, "db.Exec() err")
+	}
+
+	return dataExportAffected(res)
+}
+
+//FindAllExpired return completed exports whose download link expired before expiredBefore
+func (d DataExportRepository) FindAllExpired(ctx context.Context, expiredBefore time.Time) (userland.DataExports, error) {
+	query := `SELECT ` + dataExportColumns + `
+			FROM data_exports
+			WHERE completed_at IS NOT NULL AND expired_at < ?
+			ORDER BY expired_at, id`
+	return d.findAll(ctx, query, expiredBefore.UTC())
+}
+
+//FindAllByUserID return every export of a user, oldest first
+func (d DataExportRepository) FindAllByUserID(ctx context.Context, userID int) (userland.DataExports, error) {
+	query := `SELECT ` + dataExportColumns + `
+			FROM data_exports
+			WHERE user_id=?
+			ORDER BY requested_at, id`
+	return d.findAll(ctx, query, userID)
+}
+
+//Delete remove an export, a missing one is not an error
+func (d DataExportRepository) Delete(ctx context.Context, id int) error {
+	ctx, cancel := d.withTimeout(ctx)
+	defer cancel()
+
+	query := `DELETE FROM data_exports WHERE id=?`
+	if _, err := d.db.ExecContext(ctx, query, id); err != nil {
+		return errors.Wrap(err, "db.Exec() err")
+	}
+
+	return nil
+}
+
+func (d DataExportRepository) findAll(ctx context.Context, query string, args ...interface{}) (userland.DataExports, error) {
+	ctx, cancel := d.withTimeout(ctx)
+	defer cancel()
+
+	dataExportScanStructs := []DataExportScanStruct{}
+	if err := d.db.SelectContext(ctx, &dataExportScanStructs, query, args...); err != nil {
+		return nil, errors.Wrap(err, "db.Select() err")
+	}
+
+	dataExports := userland.DataExports{}
+	for _, dataExportScanStruct := range dataExportScanStructs {
+		dataExports = append(dataExports, d.convertStructScanToEntity(dataExportScanStruct))
+	}
+	return dataExports, nil
+}
+
+func (d DataExportRepository) convertStructScanToEntity(dataExportScanStruct DataExportScanStruct) userland.DataExport {
+	dataExport := userland.DataExport{
+		ID:          dataExportScanStruct.ID,
+		UserID:      dataExportScanStruct.UserID,
+		Path:        dataExportScanStruct.Path.String,
+		RequestedAt: dataExportScanStruct.RequestedAt,
+	}
+
+	if dataExportScanStruct.StartedAt != nil {
+		dataExport.StartedAt = *dataExportScanStruct.StartedAt
+	}
+	if dataExportScanStruct.CompletedAt != nil {
+		dataExport.CompletedAt = *dataExportScanStruct.CompletedAt
+	}
+	if dataExportScanStruct.ExpiredAt != nil {
+		dataExport.ExpiredAt = *dataExportScanStruct.ExpiredAt
+	}
+
+	return dataExport
+}
+
+//dataExportAffected return ErrDataExportNotFound when the statement didn't touch any export
+func dataExportAffected(res sql.Result) error {
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "res.RowsAffected() err")
+	}
+
+	if rowsAffected == 0 {
+		return userland.ErrDataExportNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,207 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/AdhityaRamadhanus/userland"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+)
+
+type EventScanStruct struct {
+	ID         int
+	UserID     int `db:"user_id"`
+	Event      string
+	UserAgent  sql.NullString `db:"user_agent"`
+	IP         sql.NullString
+	ClientID   sql.NullInt64  `db:"client_id"`
+	ClientName sql.NullString `db:"client_name"`
+	Country    sql.NullString
+	City       sql.NullString
+	ASN        sql.NullInt64
+	// ChangedFields is a json array, sqlite has no array type
+	ChangedFields sql.NullString `db:"changed_fields"`
+	Timestamp     time.Time
+	CreatedAt     time.Time `db:"created_at"`
+}
+
+/*
+EventRepository is implementation of EventRepository interface
+of userland domain using sqlite
+*/
+type EventRepository struct {
+	db database
+	repositoryOptions
+}
+
+//NewEventRepository is constructor to create event repository
+func NewEventRepository(conn *sqlx.DB, opts ...RepositoryOption) *EventRepository {
+	return &EventRepository{
+		db:                conn,
+		repositoryOptions: buildRepositoryOptions(opts),
+	}
+}
+
+func (e EventRepository) FindAll(ctx context.Context, filter userland.EventFilterOptions, paging userland.EventPagingOptions) (events userland.Events, eventsCount int, err error) {
+	ctx, cancel := e.withTimeout(ctx)
+	defer cancel()
+
+	scanStructEvents := []EventScanStruct{}
+
+	whereArgs := []interface{}{}
+	whereSubStatements := []string{}
+	if filter.UserID > 0 {
+		whereArgs = append(whereArgs, filter.UserID)
+		whereSubStatements = append(whereSubStatements, "user_id=?")
+	}
+
+	if len(filter.IP) > 0 {
+		whereArgs = append(whereArgs, filter.IP)
+		whereSubStatements = append(whereSubStatements, "ip=?")
+	}
+
+	if len(filter.Event) > 0 {
+		whereArgs = append(whereArgs, filter.Event)
+		whereSubStatements = append(whereSubStatements, "event=?")
+	}
+
+	whereStatement := ""
+	if len(whereSubStatements) > 0 {
+		whereStatement = "WHERE " + strings.Join(whereSubStatements, " AND ")
+	}
+
+	selectQuery := fmt.Sprintf(
+		`SELECT
+			id,
+			user_id,
+			event,
+			user_agent,
+			ip,
+			client_id,
+			client_name,
+			country,
+			city,
+			asn,
+			changed_fields,
+			timestamp,
+			created_at
+		FROM events
+		%s
+		ORDER BY %s %s, id %s
+		LIMIT %d
+		OFFSET %d`,
+		whereStatement,
+		paging.SortBy,
+		paging.Order,
+		paging.Order,
+		paging.Limit,
+		paging.Offset,
+	)
+
+	if err := e.db.SelectContext(ctx, &scanStructEvents, selectQuery, whereArgs...); err != nil {
+		return userland.Events{}, 0, errors.Wrap(err, "db.Select() err")
+	}
+
+	countQuery := fmt.Sprintf(`SELECT count(*) FROM events %s`, whereStatement)
+	if err := e.db.GetContext(ctx, &eventsCount, countQuery, whereArgs...); err != nil {
+		return userland.Events{}, 0, errors.Wrap(err, "db.Get(count) err")
+	}
+
+	events = userland.Events{}
+	for _, scanStructEvent := range scanStructEvents {
+		event, err := e.convertStructScanToEntity(scanStructEvent)
+		if err != nil {
+			return userland.Events{}, 0, err
+		}
+		events = append(events, event)
+	}
+	return events, eventsCount, nil
+}
+
+func (e EventRepository) DeleteAllByUserID(ctx context.Context, userID int) error {
+	ctx, cancel := e.withTimeout(ctx)
+	defer cancel()
+
+	if _, err := e.db.ExecContext(ctx, `DELETE FROM events WHERE user_id=?`, userID); err != nil {
+		return errors.Wrap(err, "db.Exec() err")
+	}
+	return nil
+}
+
+func (e EventRepository) Insert(ctx context.Context, event userland.Event) error {
+	ctx, cancel := e.withTimeout(ctx)
+	defer cancel()
+
+	// changed_fields stays null when nothing is changed
+	changedFields := sql.NullString{}
+	if len(event.ChangedFields) > 0 {
+		encoded, err := json.Marshal(event.ChangedFields)
+		if err != nil {
+			return errors.Wrap(err, "json.Marshal(event.ChangedFields) err")
+		}
+		changedFields = sql.NullString{String: string(encoded), Valid: true}
+	}
+
+	query := `INSERT INTO events (
+				user_id,
+				event,
+				user_agent,
+				ip,
+				client_id,
+				client_name,
+				country,
+				city,
+				asn,
+				changed_fields,
+				timestamp,
+				created_at
+			) VALUES (?, ?, ?, ?, ?, ?, NULLIF(?, ''), NULLIF(?, ''), NULLIF(?, 0), ?, ?, ?)`
+
+	_, err := e.db.ExecContext(ctx, query,
+		event.UserID,
+		event.Event,
+		event.UserAgent,
+		event.IP,
+		event.ClientID,
+		event.ClientName,
+		event.Country,
+		event.City,
+		event.ASN,
+		changedFields,
+		event.Timestamp.UTC(),
+		time.Now().UTC(),
+	)
+	if err != nil {
+		return errors.Wrap(err, "db.Exec() err")
+	}
+	return nil
+}
+
+func (e EventRepository) convertStructScanToEntity(eventScanStruct EventScanStruct) (userland.Event, error) {
+	event := userland.Event{
+		ID:         eventScanStruct.ID,
+		UserID:     eventScanStruct.UserID,
+		Event:      eventScanStruct.Event,
+		UserAgent:  eventScanStruct.UserAgent.String,
+		IP:         eventScanStruct.IP.String,
+		ClientID:   int(eventScanStruct.ClientID.Int64),
+		ClientName: eventScanStruct.ClientName.String,
+		Country:    eventScanStruct.Country.String,
+		City:       eventScanStruct.City.String,
+		ASN:        int(eventScanStruct.ASN.Int64),
+		Timestamp:  eventScanStruct.Timestamp,
+		CreatedAt:  eventScanStruct.CreatedAt,
+	}
+
+	if eventScanStruct.ChangedFields.Valid {
+		if err := json.Unmarshal([]byte(eventScanStruct.ChangedFields.String), &event.ChangedFields); err != nil {
+			return userland.Event{}, errors.Wrap(err, "json.Unmarshal(changed_fields) err")
+		}
+	}
+	return event, nil
+}
@@ -0,0 +1,267 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/AdhityaRamadhanus/userland"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+)
+
+type IdentityProviderScanStruct struct {
+	ID       int
+	Tenant   string
+	EntityID string         `db:"entity_id"`
+	SSOURL   sql.NullString `db:"sso_url"`
+	// Certificates and Domains are json arrays, sqlite has no array type
+	Certificates     sql.NullString
+	AttributeMapping sql.NullString `db:"attribute_mapping"`
+	Domains          sql.NullString
+	Metadata         sql.NullString
+	CreatedAt        time.Time `db:"created_at"`
+	UpdatedAt        time.Time `db:"updated_at"`
+}
+
+/*
+IdentityProviderRepository is implementation of IdentityProviderRepository interface
+of userland domain using sqlite
+*/
+type IdentityProviderRepository struct {
+	db database
+	repositoryOptions
+}
+
+//NewIdentityProviderRepository is constructor to create identity provider repository
+func NewIdentityProviderRepository(conn *sqlx.DB, opts ...RepositoryOption) *IdentityProviderRepository {
+	return &IdentityProviderRepository{
+		db:                conn,
+		repositoryOptions: buildRepositoryOptions(opts),
+	}
+}
+
+//FindByTenant IdentityProvider by tenant
+func (i IdentityProviderRepository) FindByTenant(ctx context.Context, tenant string) (identityProvider userland.IdentityProvider, err error) {
+	ctx, cancel := i.withTimeout(ctx)
+	defer cancel()
+
+	identityProviderScanStruct := IdentityProviderScanStruct{}
+	query := `SELECT
+				id,
+				tenant,
+				entity_id,
+				sso_url,
+				certificates,
+				attribute_mapping,
+				domains,
+				metadata,
+				created_at,
+				updated_at
+			FROM identity_providers
+			WHERE tenant=?`
+
+	if err := i.db.GetContext(ctx, &identityProviderScanStruct, query, tenant); err != nil {
+		if err == sql.ErrNoRows {
+			return userland.IdentityProvider{}, userland.ErrIdentityProviderNotFound
+		}
+		return userland.IdentityProvider{}, errors.Wrap(err, "db.Get() err")
+	}
+
+	return i.convertStructScanToEntity(identityProviderScanStruct)
+}
+
+//Insert insert identity provider to datastore
+func (i IdentityProviderRepository) Insert(ctx context.Context, identityProvider *userland.IdentityProvider) error {
+	ctx, cancel := i.withTimeout(ctx)
+	defer cancel()
+
+	columns, err := i.marshalColumns(*identityProvider)
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT INTO identity_providers (
+				tenant,
+				entity_id,
+				sso_url,
+				certificates,
+				attribute_mapping,
+				metadata,
+				domains,
+				created_at,
+				updated_at
+			) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	now := time.Now().UTC()
+	res, err := i.db.ExecContext(ctx, query,
+		identityProvider.Tenant,
+		identityProvider.EntityID,
+		identityProvider.SSOURL,
+		columns.certificates,
+		columns.attributeMapping,
+		identityProvider.Metadata,
+		columns.domains,
+		now,
+		now,
+	)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return userland.ErrDuplicateKey
+		}
+		return errors.Wrap(err, "db.Exec() err")
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return errors.Wrap(err, "res.LastInsertId() err")
+	}
+	identityProvider.ID = int(id)
+	return nil
+}
+
+//Update update identity provider of a tenant
+func (i IdentityProviderRepository) Update(ctx context.Context, identityProvider userland.IdentityProvider) error {
+	ctx, cancel := i.withTimeout(ctx)
+	defer cancel()
+
+	columns, err := i.marshalColumns(identityProvider)
+	if err != nil {
+		return err
+	}
+
+	query := `UPDATE identity_providers SET
+				entity_id=?,
+				sso_url=?,
+				certificates=?,
+				attribute_mapping=?,
+				metadata=?,
+				domains=?,
+				updated_at=?
+			WHERE tenant=?`
+
+	res, err := i.db.ExecContext(ctx, query,
+		identityProvider.EntityID,
+		identityProvider.SSOURL,
+		columns.certificates,
+		columns.attributeMapping,
+		identityProvider.Metadata,
+		columns.domains,
+		time.Now().UTC(),
+		identityProvider.Tenant,
+	)
+	if err != nil {
+		return errors.Wrap(err, "db.Exec() err")
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "res.RowsAffected() err")
+	}
+
+	if rowsAffected == 0 {
+		return userland.ErrIdentityProviderNotFound
+	}
+
+	return nil
+}
+
+//FindLinkedUserID find the user linked to a subject of identity provider
+func (i IdentityProviderRepository) FindLinkedUserID(ctx context.Context, identityProviderID int, nameID string) (userID int, err error) {
+	ctx, cancel := i.withTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT user_id FROM identity_provider_links WHERE identity_provider_id=? AND name_id=?`
+	if err := i.db.GetContext(ctx, &userID, query, identityProviderID, nameID); err != nil {
+		if err == sql.ErrNoRows {
+			return 0, userland.ErrIdentityProviderLinkNotFound
+		}
+		return 0, errors.Wrap(err, "db.Get() err")
+	}
+
+	return userID, nil
+}
+
+//Link link a subject of identity provider to user
+func (i IdentityProviderRepository) Link(ctx context.Context, identityProviderID int, nameID string, userID int) error {
+	ctx, cancel := i.withTimeout(ctx)
+	defer cancel()
+
+	query := `INSERT INTO identity_provider_links (
+				identity_provider_id,
+				name_id,
+				user_id,
+				created_at
+			) VALUES (?, ?, ?, ?)
+			ON CONFLICT (identity_provider_id, name_id) DO UPDATE SET user_id=excluded.user_id`
+
+	if _, err := i.db.ExecContext(ctx, query, identityProviderID, nameID, userID, time.Now().UTC()); err != nil {
+		return errors.Wrap(err, "db.Exec() err")
+	}
+
+	return nil
+}
+
+//identityProviderColumns hold the columns stored as json
+type identityProviderColumns struct {
+	certificates     string
+	attributeMapping string
+	domains          string
+}
+
+func (i IdentityProviderRepository) marshalColumns(identityProvider userland.IdentityProvider) (identityProviderColumns, error) {
+	certificates, err := json.Marshal(identityProvider.Certificates)
+	if err != nil {
+		return identityProviderColumns{}, errors.Wrap(err, "json.Marshal(certificates) err")
+	}
+	attributeMapping, err := json.Marshal(identityProvider.AttributeMapping)
+	if err != nil {
+		return identityProviderColumns{}, errors.Wrap(err, "json.Marshal(attributeMapping) err")
+	}
+	domains, err := json.Marshal(identityProvider.Domains)
+	if err != nil {
+		return identityProviderColumns{}, errors.Wrap(err, "json.Marshal(domains) err")
+	}
+
+	return identityProviderColumns{
+		certificates:     string(certificates),
+		attributeMapping: string(attributeMapping),
+		domains:          string(domains),
+	}, nil
+}
+
+func (i IdentityProviderRepository) convertStructScanToEntity(identityProviderScanStruct IdentityProviderScanStruct) (userland.IdentityProvider, error) {
+	identityProvider := userland.IdentityProvider{
+		ID:               identityProviderScanStruct.ID,
+		Tenant:           identityProviderScanStruct.Tenant,
+		EntityID:         identityProviderScanStruct.EntityID,
+		AttributeMapping: map[string]string{},
+		CreatedAt:        identityProviderScanStruct.CreatedAt,
+		UpdatedAt:        identityProviderScanStruct.UpdatedAt,
+	}
+
+	if identityProviderScanStruct.SSOURL.Valid {
+		identityProvider.SSOURL = identityProviderScanStruct.SSOURL.String
+	}
+	if identityProviderScanStruct.Certificates.Valid {
+		if err := json.Unmarshal([]byte(identityProviderScanStruct.Certificates.String), &identityProvider.Certificates); err != nil {
+			return userland.IdentityProvider{}, errors.Wrap(err, "json.Unmarshal(certificates) err")
+		}
+	}
+	if identityProviderScanStruct.AttributeMapping.Valid {
+		if err := json.Unmarshal([]byte(identityProviderScanStruct.AttributeMapping.String), &identityProvider.AttributeMapping); err != nil {
+			return userland.IdentityProvider{}, errors.Wrap(err, "json.Unmarshal(attributeMapping) err")
+		}
+	}
+	if identityProviderScanStruct.Domains.Valid {
+		if err := json.Unmarshal([]byte(identityProviderScanStruct.Domains.String), &identityProvider.Domains); err != nil {
+			return userland.IdentityProvider{}, errors.Wrap(err, "json.Unmarshal(domains) err")
+		}
+	}
+	if identityProviderScanStruct.Metadata.Valid {
+		identityProvider.Metadata = identityProviderScanStruct.Metadata.String
+	}
+
+	return identityProvider, nil
+}
@@ -0,0 +1,88 @@
+//+build unit
+
+package sqlite_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/AdhityaRamadhanus/userland"
+	"github.com/AdhityaRamadhanus/userland/pkg/storage/sqlite"
+	"github.com/AdhityaRamadhanus/userland/pkg/userlandtest"
+)
+
+func TestIdentityProviderRepository(t *testing.T) {
+	db := createMigratedConnection(t)
+	defer db.Close()
+
+	identityProviderRepository := sqlite.NewIdentityProviderRepository(db)
+	identityProvider := userland.IdentityProvider{
+		Tenant:           "acme",
+		EntityID:         "https://idp.acme.com/metadata",
+		SSOURL:           "https://idp.acme.com/sso",
+		Certificates:     []string{"xxx"},
+		AttributeMapping: map[string]string{"email": "mail"},
+		Domains:          []string{"acme.com"},
+	}
+	if err := identityProviderRepository.Insert(context.Background(), &identityProvider); err != nil {
+		t.Fatalf("IdentityProviderRepository.Insert(identityProvider) err = %v; want nil", err)
+	}
+	duplicate := userland.IdentityProvider{Tenant: "acme", EntityID: "https://idp.acme.com/metadata"}
+	if err := identityProviderRepository.Insert(context.Background(), &duplicate); err != userland.ErrDuplicateKey {
+		t.Errorf("IdentityProviderRepository.Insert(duplicate) err = %v; want %v", err, userland.ErrDuplicateKey)
+	}
+
+	identityProvider.Domains = []string{"acme.com", "acme.io"}
+	if err := identityProviderRepository.Update(context.Background(), identityProvider); err != nil {
+		t.Fatalf("IdentityProviderRepository.Update(identityProvider) err = %v; want nil", err)
+	}
+	if err := identityProviderRepository.Update(context.Background(), userland.IdentityProvider{Tenant: "missing"}); err != userland.ErrIdentityProviderNotFound {
+		t.Errorf("IdentityProviderRepository.Update(missing) err = %v; want %v", err, userland.ErrIdentityProviderNotFound)
+	}
+
+	found, err := identityProviderRepository.FindByTenant(context.Background(), "acme")
+	if err != nil {
+		t.Fatalf("IdentityProviderRepository.FindByTenant(acme) err = %v; want nil", err)
+	}
+	if !reflect.DeepEqual(found.Certificates, identityProvider.Certificates) ||
+		!reflect.DeepEqual(found.AttributeMapping, identityProvider.AttributeMapping) ||
+		!reflect.DeepEqual(found.Domains, identityProvider.Domains) ||
+		found.SSOURL != identityProvider.SSOURL {
+		t.Errorf("IdentityProviderRepository.FindByTenant(acme) = %+v; want %+v", found, identityProvider)
+	}
+	if _, err := identityProviderRepository.FindByTenant(context.Background(), "missing"); err != userland.ErrIdentityProviderNotFound {
+		t.Errorf("IdentityProviderRepository.FindByTenant(missing) err = %v; want %v", err, userland.ErrIdentityProviderNotFound)
+	}
+}
+
+func TestIdentityProviderRepository_Link(t *testing.T) {
+	db := createMigratedConnection(t)
+	defer db.Close()
+
+	userRepository := sqlite.NewUserRepository(db)
+	user := userlandtest.TestCreateUser(t, userRepository)
+	anotherUser := userlandtest.TestCreateUser(t, userRepository, userlandtest.WithUserEmail("another@gmail.com"))
+
+	identityProviderRepository := sqlite.NewIdentityProviderRepository(db)
+	identityProvider := userland.IdentityProvider{
+		Tenant:   "acme",
+		EntityID: "https://idp.acme.com/metadata",
+	}
+	if err := identityProviderRepository.Insert(context.Background(), &identityProvider); err != nil {
+		t.Fatalf("IdentityProviderRepository.Insert(identityProvider) err = %v; want nil", err)
+	}
+
+	if _, err := identityProviderRepository.FindLinkedUserID(context.Background(), identityProvider.ID, "wile"); err != userland.ErrIdentityProviderLinkNotFound {
+		t.Fatalf("IdentityProviderRepository.FindLinkedUserID() err = %v; want %v", err, userland.ErrIdentityProviderLinkNotFound)
+	}
+	for _, userID := range []int{user.ID, user.ID, anotherUser.ID} {
+		if err := identityProviderRepository.Link(context.Background(), identityProvider.ID, "wile", userID); err != nil {
+			t.Fatalf("IdentityProviderRepository.Link(%d) err = %v; want nil", userID, err)
+		}
+	}
+	userID, err := identityProviderRepository.FindLinkedUserID(context.Background(), identityProvider.ID, "wile")
+	if err != nil || userID != anotherUser.ID {
+		t.Errorf("IdentityProviderRepository.FindLinkedUserID() = %d, %v; want %d, nil", userID, err, anotherUser.ID)
+	}
+}
@@ -0,0 +1,83 @@
+package sqlite
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/AdhityaRamadhanus/userland"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+)
+
+/*
+LoginRiskAssessmentRepository is implementation of LoginRiskAssessmentRepository interface
+of userland domain using sqlite
+*/
+type LoginRiskAssessmentRepository struct {
+	db database
+	repositoryOptions
+}
+
+//NewLoginRiskAssessmentRepository is constructor to create login risk assessment repository
+func NewLoginRiskAssessmentRepository(conn *sqlx.DB, opts ...RepositoryOption) *LoginRiskAssessmentRepository {
+	return &LoginRiskAssessmentRepository{
+		db:                conn,
+		repositoryOptions: buildRepositoryOptions(opts),
+	}
+}
+
+//Insert record login risk assessment to datastore
+func (l LoginRiskAssessmentRepository) Insert(ctx context.Context, assessment *userland.LoginRiskAssessment) error {
+	ctx, cancel := l.withTimeout(ctx)
+	defer cancel()
+
+	signals, err := json.Marshal(assessment.Signals)
+	if err != nil {
+		return errors.Wrap(err, "json.Marshal(assessment.Signals) err")
+	}
+
+	query := `INSERT INTO login_risk_assessments (
+				user_id,
+				ip,
+				user_agent,
+				score,
+				signals,
+				decision,
+				created_at
+			) VALUES (?, ?, ?, ?, ?, ?, ?)`
+
+	now := time.Now().UTC()
+	res, err := l.db.ExecContext(ctx, query,
+		assessment.UserID,
+		assessment.IP,
+		assessment.UserAgent,
+		assessment.Score,
+		string(signals),
+		assessment.Decision,
+		now,
+	)
+	if err != nil {
+		return errors.Wrap(err, "db.Exec() err")
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return errors.Wrap(err, "res.LastInsertId() err")
+	}
+	assessment.ID = int(id)
+	assessment.CreatedAt = now
+	return nil
+}
+
+//DeleteAllByUserID remove every assessment of a user
+func (l LoginRiskAssessmentRepository) DeleteAllByUserID(ctx context.Context, userID int) error {
+	ctx, cancel := l.withTimeout(ctx)
+	defer cancel()
+
+	if _, err := l.db.ExecContext(ctx, `DELETE FROM login_risk_assessments WHERE user_id=?`, userID); err != nil {
+		return errors.Wrap(err, "db.Exec() err")
+	}
+
+	return nil
+}
@@ -0,0 +1,68 @@
+//+build unit
+
+package sqlite_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/AdhityaRamadhanus/userland"
+	"github.com/AdhityaRamadhanus/userland/pkg/storage/sqlite"
+)
+
+func TestLoginRiskAssessmentRepository_Insert(t *testing.T) {
+	db := createMigratedConnection(t)
+	defer db.Close()
+
+	loginRiskAssessmentRepository := sqlite.NewLoginRiskAssessmentRepository(db)
+	assessment := userland.LoginRiskAssessment{
+		UserID:    1,
+		IP:        "10.0.0.1",
+		UserAgent: "Mozilla/5.0",
+		Score:     70,
+		Signals:   []string{"new_ip", "impossible_travel"},
+		Decision:  userland.LoginRiskDecisionChallenge,
+	}
+	if err := loginRiskAssessmentRepository.Insert(context.Background(), &assessment); err != nil {
+		t.Fatalf("LoginRiskAssessmentRepository.Insert(assessment) err = %v; want nil", err)
+	}
+	if assessment.ID == 0 || assessment.CreatedAt.IsZero() {
+		t.Errorf("LoginRiskAssessmentRepository.Insert(assessment) = %+v; want generated id and created at", assessment)
+	}
+
+	var signals string
+	query := "SELECT signals FROM login_risk_assessments WHERE id=?"
+	if err := db.QueryRow(query, assessment.ID).Scan(&signals); err != nil {
+		t.Fatalf("db.QueryRow(%q) err = %v; want nil", query, err)
+	}
+	if want := `["new_ip","impossible_travel"]`; signals != want {
+		t.Errorf("signals = %s; want %s", signals, want)
+	}
+}
+
+func TestLoginRiskAssessmentRepository_DeleteAllByUserID(t *testing.T) {
+	db := createMigratedConnection(t)
+	defer db.Close()
+
+	loginRiskAssessmentRepository := sqlite.NewLoginRiskAssessmentRepository(db)
+	for _, userID := range []int{1, 1, 2} {
+		assessment := userland.LoginRiskAssessment{UserID: userID, IP: "10.0.0.1", Decision: userland.LoginRiskDecisionAllow}
+		if err := loginRiskAssessmentRepository.Insert(context.Background(), &assessment); err != nil {
+			t.Fatalf("LoginRiskAssessmentRepository.Insert(user %d) err = %v; want nil", userID, err)
+		}
+	}
+
+	if err := loginRiskAssessmentRepository.DeleteAllByUserID(context.Background(), 1); err != nil {
+		t.Fatalf("LoginRiskAssessmentRepository.DeleteAllByUserID(1) err = %v; want nil", err)
+	}
+	for userID, want := range map[int]int{1: 0, 2: 1} {
+		var count int
+		query := "SELECT count(*) FROM login_risk_assessments WHERE user_id=?"
+		if err := db.Get(&count, query, userID); err != nil {
+			t.Fatalf("db.Get(%q, %d) err = %v; want nil", query, userID, err)
+		}
+		if count != want {
+			t.Errorf("login risk assessments of user %d = %d; want %d", userID, count, want)
+		}
+	}
+}
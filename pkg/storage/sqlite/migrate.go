@@ -0,0 +1,101 @@
+package sqlite
+
+//go:generate go run ../../../script/embed_migrations/main.go -dir migration -package sqlite -out migration_files.go
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/AdhityaRamadhanus/userland/pkg/common/migration"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+)
+
+//nilVersion is stored when a migration back to no version at all fail, same as golang-migrate
+const nilVersion = -1
+
+//Migrations return the migrations embedded from the migration directory
+func Migrations() ([]migration.Migration, error) {
+	return migration.Parse(migrationFiles)
+}
+
+/*
+MigrationDriver is implementation of migration.Driver using sqlite,
+the version is kept in schema_migrations the same way golang-migrate does
+*/
+type MigrationDriver struct {
+	db *sqlx.DB
+	// conn is pinned while locked, sqlite is only used by single node deployments so it is not locked against other processes
+	conn *sql.Conn
+}
+
+//NewMigrationDriver is constructor to create MigrationDriver
+func NewMigrationDriver(conn *sqlx.DB) *MigrationDriver {
+	return &MigrationDriver{
+		db: conn,
+	}
+}
+
+func (d *MigrationDriver) Lock(ctx context.Context) error {
+	conn, err := d.db.Conn(ctx)
+	if err != nil {
+		return errors.Wrap(err, "db.Conn() err")
+	}
+	d.conn = conn
+
+	query := `CREATE TABLE IF NOT EXISTS schema_migrations (version bigint NOT NULL PRIMARY KEY, dirty boolean NOT NULL)`
+	if _, err := d.conn.ExecContext(ctx, query); err != nil {
+		d.Unlock(ctx)
+		return errors.Wrap(err, "conn.Exec(create schema_migrations) err")
+	}
+	return nil
+}
+
+func (d *MigrationDriver) Unlock(ctx context.Context) error {
+	err := d.conn.Close()
+	d.conn = nil
+	return err
+}
+
+func (d *MigrationDriver) Version(ctx context.Context) (version int, dirty bool, err error) {
+	row := d.conn.QueryRowContext(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1`)
+	if err := row.Scan(&version, &dirty); err != nil {
+		if err == sql.ErrNoRows {
+			return 0, false, nil
+		}
+		return 0, false, errors.Wrap(err, "row.Scan() err")
+	}
+
+	if version == nilVersion {
+		version = 0
+	}
+	return version, dirty, nil
+}
+
+func (d *MigrationDriver) SetVersion(ctx context.Context, version int, dirty bool) error {
+	tx, err := d.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "conn.BeginTx() err")
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM schema_migrations`); err != nil {
+		return errors.Wrap(err, "tx.Exec(delete) err")
+	}
+	// a clean database without any migration has no row
+	if version > 0 || dirty {
+		if version == 0 {
+			version = nilVersion
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, dirty) VALUES (?, ?)`, version, dirty); err != nil {
+			return errors.Wrap(err, "tx.Exec(insert) err")
+		}
+	}
+
+	return errors.Wrap(tx.Commit(), "tx.Commit() err")
+}
+
+func (d *MigrationDriver) Exec(ctx context.Context, query string) error {
+	_, err := d.conn.ExecContext(ctx, query)
+	return err
+}
@@ -0,0 +1,84 @@
+//+build unit
+
+package sqlite_test
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/AdhityaRamadhanus/userland/pkg/common/migration"
+	"github.com/AdhityaRamadhanus/userland/pkg/config"
+	"github.com/AdhityaRamadhanus/userland/pkg/storage/sqlite"
+)
+
+func TestMigrations_embedded(t *testing.T) {
+	paths, err := filepath.Glob("migration/*.sql")
+	if err != nil {
+		t.Fatalf("filepath.Glob(migration) err = %v; want nil", err)
+	}
+	migrations, err := sqlite.Migrations()
+	if err != nil {
+		t.Fatalf("sqlite.Migrations() err = %v; want nil", err)
+	}
+	if len(migrations)*2 != len(paths) {
+		t.Fatalf("sqlite.Migrations() = %d migrations; want %d, run go generate", len(migrations), len(paths)/2)
+	}
+
+	queries := map[string]string{}
+	for _, m := range migrations {
+		queries[m.String()+".up.sql"] = m.Up
+		queries[m.String()+".down.sql"] = m.Down
+	}
+	for _, path := range paths {
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatalf("ioutil.ReadFile(%q) err = %v; want nil", path, err)
+		}
+		if queries[filepath.Base(path)] != string(content) {
+			t.Errorf("embedded %s differ from the file, run go generate", filepath.Base(path))
+		}
+	}
+}
+
+func TestMigrationDriver(t *testing.T) {
+	db, err := sqlite.CreateConnection(config.SQLiteConfig{Path: ":memory:"})
+	if err != nil {
+		t.Fatalf("sqlite.CreateConnection() err = %v; want nil", err)
+	}
+	defer db.Close()
+
+	migrations, err := sqlite.Migrations()
+	if err != nil {
+		t.Fatalf("sqlite.Migrations() err = %v; want nil", err)
+	}
+	latest := migrations[len(migrations)-1].Version
+	migrator := migration.NewMigrator(sqlite.NewMigrationDriver(db), migrations)
+	ctx := context.Background()
+
+	if applied, err := migrator.Up(ctx); err != nil || len(applied) != len(migrations) {
+		t.Fatalf("migrator.Up() = %d migrations, %v; want %d, nil", len(applied), err, len(migrations))
+	}
+	if status, err := migrator.Status(ctx); err != nil || status.Version != latest || status.Dirty || len(status.Pending) != 0 {
+		t.Errorf("migrator.Status() = %+v, %v; want version %d without pending migration", status, err, latest)
+	}
+
+	// every down migration must revert its up migration
+	if reverted, err := migrator.Down(ctx, len(migrations)+1); err != nil || len(reverted) != len(migrations) {
+		t.Fatalf("migrator.Down() = %d migrations, %v; want %d, nil", len(reverted), err, len(migrations))
+	}
+	if status, err := migrator.Status(ctx); err != nil || status.Version != 0 || len(status.Pending) != len(migrations) {
+		t.Errorf("migrator.Status() = %+v, %v; want version 0 with every migration pending", status, err)
+	}
+	if applied, err := migrator.Up(ctx); err != nil || len(applied) != len(migrations) {
+		t.Fatalf("migrator.Up() after down = %d migrations, %v; want %d, nil", len(applied), err, len(migrations))
+	}
+
+	if err := migrator.Force(ctx, migrations[0].Version); err != nil {
+		t.Fatalf("migrator.Force() err = %v; want nil", err)
+	}
+	if status, err := migrator.Status(ctx); err != nil || status.Version != migrations[0].Version {
+		t.Errorf("migrator.Status() = %+v, %v after force; want version %d", status, err, migrations[0].Version)
+	}
+}
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS events (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id int NOT NULL,
+    event varchar(255) NOT NULL,
+    user_agent TEXT,
+    ip TEXT,
+    client_id int,
+    client_name TEXT,
+    country varchar(2),
+    city varchar(128),
+    asn integer,
+    timestamp TIMESTAMP NOT NULL,
+    created_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS index_events_on_user_id ON events (user_id);
+CREATE INDEX IF NOT EXISTS index_events_on_event ON events (event);
@@ -0,0 +1,30 @@
+-- sqlite 3.24 cannot drop a column, the table is copied without it
+CREATE TABLE users_without_version (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    email varchar(255) NOT NULL,
+    fullname varchar(255) NOT NULL,
+    phone varchar(255),
+    location varchar(255),
+    bio varchar(255),
+    web_url varchar(255),
+    picture_url varchar(255),
+    tfa_enabled boolean,
+    verified boolean,
+    password TEXT NOT NULL,
+    backup_codes TEXT,
+    tfa_enabled_at TIMESTAMP,
+    backup_codes_created_at TIMESTAMP,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP,
+
+    CONSTRAINT users_unique_email UNIQUE (email)
+);
+
+INSERT INTO users_without_version
+SELECT id, email, fullname, phone, location, bio, web_url, picture_url, tfa_enabled, verified, password,
+    backup_codes, tfa_enabled_at, backup_codes_created_at, created_at, updated_at
+FROM users;
+
+DROP TABLE users;
+ALTER TABLE users_without_version RENAME TO users;
+CREATE INDEX IF NOT EXISTS index_users_on_email ON users (email);
@@ -0,0 +1,24 @@
+-- sqlite 3.24 cannot drop a column, the table is copied without it
+CREATE TABLE events_without_changed_fields (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id int NOT NULL,
+    event varchar(255) NOT NULL,
+    user_agent TEXT,
+    ip TEXT,
+    client_id int,
+    client_name TEXT,
+    country varchar(2),
+    city varchar(128),
+    asn integer,
+    timestamp TIMESTAMP NOT NULL,
+    created_at TIMESTAMP
+);
+
+INSERT INTO events_without_changed_fields
+SELECT id, user_id, event, user_agent, ip, client_id, client_name, country, city, asn, timestamp, created_at
+FROM events;
+
+DROP TABLE events;
+ALTER TABLE events_without_changed_fields RENAME TO events;
+CREATE INDEX IF NOT EXISTS index_events_on_user_id ON events (user_id);
+CREATE INDEX IF NOT EXISTS index_events_on_event ON events (event);
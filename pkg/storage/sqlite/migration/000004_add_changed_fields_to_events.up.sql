@@ -0,0 +1,2 @@
+-- changed_fields is a json array, sqlite has no array type
+ALTER TABLE events ADD COLUMN changed_fields TEXT;
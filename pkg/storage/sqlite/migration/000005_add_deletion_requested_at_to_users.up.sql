@@ -0,0 +1,2 @@
+ALTER TABLE users ADD COLUMN deletion_requested_at TIMESTAMP;
+CREATE INDEX IF NOT EXISTS index_users_on_deletion_requested_at ON users (deletion_requested_at);
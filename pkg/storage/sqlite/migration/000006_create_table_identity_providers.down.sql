@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS identity_provider_links;
+DROP TABLE IF EXISTS identity_providers;
@@ -0,0 +1,24 @@
+-- certificates and domains are json arrays, sqlite has no array type
+CREATE TABLE IF NOT EXISTS identity_providers (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    tenant varchar(255) NOT NULL,
+    entity_id TEXT NOT NULL,
+    sso_url TEXT,
+    certificates TEXT,
+    attribute_mapping TEXT,
+    metadata TEXT,
+    domains TEXT NOT NULL DEFAULT '[]',
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP,
+
+    CONSTRAINT identity_providers_unique_tenant UNIQUE (tenant)
+);
+
+CREATE TABLE IF NOT EXISTS identity_provider_links (
+    identity_provider_id int NOT NULL REFERENCES identity_providers (id) ON DELETE CASCADE,
+    name_id TEXT NOT NULL,
+    user_id int NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at TIMESTAMP,
+
+    PRIMARY KEY (identity_provider_id, name_id)
+);
@@ -0,0 +1,13 @@
+-- signals is a json array, sqlite has no array type
+CREATE TABLE IF NOT EXISTS login_risk_assessments (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id int NOT NULL,
+    ip TEXT,
+    user_agent TEXT,
+    score int NOT NULL DEFAULT 0,
+    signals TEXT,
+    decision varchar(32) NOT NULL,
+    created_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS index_login_risk_assessments_on_user_id ON login_risk_assessments (user_id);
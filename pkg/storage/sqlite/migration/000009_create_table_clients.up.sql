@@ -0,0 +1,12 @@
+-- allowed_origins is a json array, sqlite has no array type
+CREATE TABLE IF NOT EXISTS clients (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    type varchar(32) NOT NULL,
+    public boolean NOT NULL DEFAULT false,
+    secret_hash TEXT,
+    allowed_origins TEXT,
+    status varchar(32) NOT NULL,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP
+);
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS data_exports (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id int NOT NULL,
+    path TEXT,
+    requested_at TIMESTAMP NOT NULL,
+    started_at TIMESTAMP,
+    completed_at TIMESTAMP,
+    expired_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS index_data_exports_on_user_id ON data_exports (user_id);
@@ -0,0 +1,39 @@
+-- sqlite 3.24 cannot drop a column, the table is copied without it.
+-- dropping users cascade to identity provider links, they are kept aside meanwhile
+CREATE TEMP TABLE identity_provider_links_backup AS SELECT * FROM identity_provider_links;
+
+CREATE TABLE users_without_tfa_secret (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    email varchar(255) NOT NULL,
+    fullname varchar(255) NOT NULL,
+    phone varchar(255),
+    location varchar(255),
+    bio varchar(255),
+    web_url varchar(255),
+    picture_url varchar(255),
+    tfa_enabled boolean,
+    verified boolean,
+    password TEXT NOT NULL,
+    backup_codes TEXT,
+    tfa_enabled_at TIMESTAMP,
+    backup_codes_created_at TIMESTAMP,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP,
+    version INTEGER NOT NULL DEFAULT 1,
+    deletion_requested_at TIMESTAMP,
+
+    CONSTRAINT users_unique_email UNIQUE (email)
+);
+
+INSERT INTO users_without_tfa_secret
+SELECT id, email, fullname, phone, location, bio, web_url, picture_url, tfa_enabled, verified, password,
+    backup_codes, tfa_enabled_at, backup_codes_created_at, created_at, updated_at, version, deletion_requested_at
+FROM users;
+
+DROP TABLE users;
+ALTER TABLE users_without_tfa_secret RENAME TO users;
+CREATE INDEX IF NOT EXISTS index_users_on_email ON users (email);
+CREATE INDEX IF NOT EXISTS index_users_on_deletion_requested_at ON users (deletion_requested_at);
+
+INSERT INTO identity_provider_links SELECT * FROM identity_provider_links_backup;
+DROP TABLE identity_provider_links_backup;
@@ -0,0 +1 @@
+ALTER TABLE users ADD COLUMN tfa_secret varchar(64);
@@ -0,0 +1,29 @@
+// Code generated by script/embed_migrations from migration; DO NOT EDIT.
+
+package sqlite
+
+// migrationFiles hold the content of every file in migration keyed by file name
+var migrationFiles = map[string]string{
+	"000001_create_table_users.down.sql":                  "DROP TABLE IF EXISTS users;\n",
+	"000001_create_table_users.up.sql":                    "CREATE TABLE IF NOT EXISTS users (\n    id INTEGER PRIMARY KEY AUTOINCREMENT,\n    email varchar(255) NOT NULL,\n    fullname varchar(255) NOT NULL,\n    phone varchar(255),\n    location varchar(255),\n    bio varchar(255),\n    web_url varchar(255),\n    picture_url varchar(255),\n    tfa_enabled boolean,\n    verified boolean,\n    password TEXT NOT NULL,\n    backup_codes TEXT,\n    tfa_enabled_at TIMESTAMP,\n    backup_codes_created_at TIMESTAMP,\n    created_at TIMESTAMP,\n    updated_at TIMESTAMP,\n\n    CONSTRAINT users_unique_email UNIQUE (email)\n);\n\nCREATE INDEX IF NOT EXISTS index_users_on_email ON users (email);\n",
+	"000002_create_table_events.down.sql":                 "DROP TABLE IF EXISTS events;\n",
+	"000002_create_table_events.up.sql":                   "CREATE TABLE IF NOT EXISTS events (\n    id INTEGER PRIMARY KEY AUTOINCREMENT,\n    user_id int NOT NULL,\n    event varchar(255) NOT NULL,\n    user_agent TEXT,\n    ip TEXT,\n    client_id int,\n    client_name TEXT,\n    country varchar(2),\n    city varchar(128),\n    asn integer,\n    timestamp TIMESTAMP NOT NULL,\n    created_at TIMESTAMP\n);\n\nCREATE INDEX IF NOT EXISTS index_events_on_user_id ON events (user_id);\nCREATE INDEX IF NOT EXISTS index_events_on_event ON events (event);\n",
+	"000003_add_version_to_users.down.sql":                "-- sqlite 3.24 cannot drop a column, the table is copied without it\nCREATE TABLE users_without_version (\n    id INTEGER PRIMARY KEY AUTOINCREMENT,\n    email varchar(255) NOT NULL,\n    fullname varchar(255) NOT NULL,\n    phone varchar(255),\n    location varchar(255),\n    bio varchar(255),\n    web_url varchar(255),\n    picture_url varchar(255),\n    tfa_enabled boolean,\n    verified boolean,\n    password TEXT NOT NULL,\n    backup_codes TEXT,\n    tfa_enabled_at TIMESTAMP,\n    backup_codes_created_at TIMESTAMP,\n    created_at TIMESTAMP,\n    updated_at TIMESTAMP,\n\n    CONSTRAINT users_unique_email UNIQUE (email)\n);\n\nINSERT INTO users_without_version\nSELECT id, email, fullname, phone, location, bio, web_url, picture_url, tfa_enabled, verified, password,\n    backup_codes, tfa_enabled_at, backup_codes_created_at, created_at, updated_at\nFROM users;\n\nDROP TABLE users;\nALTER TABLE users_without_version RENAME TO users;\nCREATE INDEX IF NOT EXISTS index_users_on_email ON users (email);\n",
+	"000003_add_version_to_users.up.sql":                  "ALTER TABLE users ADD COLUMN version INTEGER NOT NULL DEFAULT 1;\n",
+	"000004_add_changed_fields_to_events.down.sql":        "-- sqlite 3.24 cannot drop a column, the table is copied without it\nCREATE TABLE events_without_changed_fields (\n    id INTEGER PRIMARY KEY AUTOINCREMENT,\n    user_id int NOT NULL,\n    event varchar(255) NOT NULL,\n    user_agent TEXT,\n    ip TEXT,\n    client_id int,\n    client_name TEXT,\n    country varchar(2),\n    city varchar(128),\n    asn integer,\n    timestamp TIMESTAMP NOT NULL,\n    created_at TIMESTAMP\n);\n\nINSERT INTO events_without_changed_fields\nSELECT id, user_id, event, user_agent, ip, client_id, client_name, country, city, asn, timestamp, created_at\nFROM events;\n\nDROP TABLE events;\nALTER TABLE events_without_changed_fields RENAME TO events;\nCREATE INDEX IF NOT EXISTS index_events_on_user_id ON events (user_id);\nCREATE INDEX IF NOT EXISTS index_events_on_event ON events (event);\n",
+	"000004_add_changed_fields_to_events.up.sql":          "-- changed_fields is a json array, sqlite has no array type\nALTER TABLE events ADD COLUMN changed_fields TEXT;\n",
+	"000005_add_deletion_requested_at_to_users.down.sql":  "-- sqlite 3.24 cannot drop a column, the table is copied without it\nCREATE TABLE users_without_deletion_requested_at (\n    id INTEGER PRIMARY KEY AUTOINCREMENT,\n    email varchar(255) NOT NULL,\n    fullname varchar(255) NOT NULL,\n    phone varchar(255),\n    location varchar(255),\n    bio varchar(255),\n    web_url varchar(255),\n    picture_url varchar(255),\n    tfa_enabled boolean,\n    verified boolean,\n    password TEXT NOT NULL,\n    backup_codes TEXT,\n    tfa_enabled_at TIMESTAMP,\n    backup_codes_created_at TIMESTAMP,\n    created_at TIMESTAMP,\n    updated_at TIMESTAMP,\n    version INTEGER NOT NULL DEFAULT 1,\n\n    CONSTRAINT users_unique_email UNIQUE (email)\n);\n\nINSERT INTO users_without_deletion_requested_at\nSELECT id, email, fullname, phone, location, bio, web_url, picture_url, tfa_enabled, verified, password,\n    backup_codes, tfa_enabled_at, backup_codes_created_at, created_at, updated_at, version\nFROM users;\n\nDROP TABLE users;\nALTER TABLE users_without_deletion_requested_at RENAME TO users;\nCREATE INDEX IF NOT EXISTS index_users_on_email ON users (email);\n",
+	"000005_add_deletion_requested_at_to_users.up.sql":    "ALTER TABLE users ADD COLUMN deletion_requested_at TIMESTAMP;\nCREATE INDEX IF NOT EXISTS index_users_on_deletion_requested_at ON users (deletion_requested_at);\n",
+	"000006_create_table_identity_providers.down.sql":     "DROP TABLE IF EXISTS identity_provider_links;\nDROP TABLE IF EXISTS identity_providers;\n",
+	"000006_create_table_identity_providers.up.sql":       "-- certificates and domains are json arrays, sqlite has no array type\nCREATE TABLE IF NOT EXISTS identity_providers (\n    id INTEGER PRIMARY KEY AUTOINCREMENT,\n    tenant varchar(255) NOT NULL,\n    entity_id TEXT NOT NULL,\n    sso_url TEXT,\n    certificates TEXT,\n    attribute_mapping TEXT,\n    metadata TEXT,\n    domains TEXT NOT NULL DEFAULT '[]',\n    created_at TIMESTAMP,\n    updated_at TIMESTAMP,\n\n    CONSTRAINT identity_providers_unique_tenant UNIQUE (tenant)\n);\n\nCREATE TABLE IF NOT EXISTS identity_provider_links (\n    identity_provider_id int NOT NULL REFERENCES identity_providers (id) ON DELETE CASCADE,\n    name_id TEXT NOT NULL,\n    user_id int NOT NULL REFERENCES users (id) ON DELETE CASCADE,\n    created_at TIMESTAMP,\n\n    PRIMARY KEY (identity_provider_id, name_id)\n);\n",
+	"000007_create_table_sessions.down.sql":               "DROP TABLE IF EXISTS sessions;\n",
+	"000007_create_table_sessions.up.sql":                 "CREATE TABLE IF NOT EXISTS sessions (\n    id varchar(64) PRIMARY KEY,\n    user_id int NOT NULL,\n    ip TEXT,\n    client_id int,\n    client_name TEXT,\n    user_agent TEXT,\n    browser TEXT,\n    os TEXT,\n    device_type varchar(32),\n    country varchar(2),\n    city varchar(128),\n    asn integer,\n    last_seen_ip TEXT,\n    last_seen_at TIMESTAMP,\n    expiration bigint NOT NULL DEFAULT 0,\n    expired_at TIMESTAMP NOT NULL,\n    ended_at TIMESTAMP,\n    end_reason varchar(64),\n    created_at TIMESTAMP,\n    updated_at TIMESTAMP\n);\n\nCREATE INDEX IF NOT EXISTS index_sessions_on_user_id ON sessions (user_id);\n",
+	"000008_create_table_login_risk_assessments.down.sql": "DROP TABLE IF EXISTS login_risk_assessments;\n",
+	"000008_create_table_login_risk_assessments.up.sql":   "-- signals is a json array, sqlite has no array type\nCREATE TABLE IF NOT EXISTS login_risk_assessments (\n    id INTEGER PRIMARY KEY AUTOINCREMENT,\n    user_id int NOT NULL,\n    ip TEXT,\n    user_agent TEXT,\n    score int NOT NULL DEFAULT 0,\n    signals TEXT,\n    decision varchar(32) NOT NULL,\n    created_at TIMESTAMP\n);\n\nCREATE INDEX IF NOT EXISTS index_login_risk_assessments_on_user_id ON login_risk_assessments (user_id);\n",
+	"000009_create_table_clients.down.sql":                "DROP TABLE IF EXISTS clients;\n",
+	"000009_create_table_clients.up.sql":                  "-- allowed_origins is a json array, sqlite has no array type\nCREATE TABLE IF NOT EXISTS clients (\n    id INTEGER PRIMARY KEY AUTOINCREMENT,\n    name TEXT NOT NULL,\n    type varchar(32) NOT NULL,\n    public boolean NOT NULL DEFAULT false,\n    secret_hash TEXT,\n    allowed_origins TEXT,\n    status varchar(32) NOT NULL,\n    created_at TIMESTAMP,\n    updated_at TIMESTAMP\n);\n",
+	"000010_create_table_data_exports.down.sql":           "DROP TABLE IF EXISTS data_exports;\n",
+	"000010_create_table_data_exports.up.sql":             "CREATE TABLE IF NOT EXISTS data_exports (\n    id INTEGER PRIMARY KEY AUTOINCREMENT,\n    user_id int NOT NULL,\n    path TEXT,\n    requested_at TIMESTAMP NOT NULL,\n    started_at TIMESTAMP,\n    completed_at TIMESTAMP,\n    expired_at TIMESTAMP\n);\n\nCREATE INDEX IF NOT EXISTS index_data_exports_on_user_id ON data_exports (user_id);\n",
+	"000011_add_tfa_secret_to_users.down.sql":             "-- sqlite 3.24 cannot drop a column, the table is copied without it.\n-- dropping users cascade to identity provider links, they are kept aside meanwhile\nCREATE TEMP TABLE identity_provider_links_backup AS SELECT * FROM identity_provider_links;\n\nCREATE TABLE users_without_tfa_secret (\n    id INTEGER PRIMARY KEY AUTOINCREMENT,\n    email varchar(255) NOT NULL,\n    fullname varchar(255) NOT NULL,\n    phone varchar(255),\n    location varchar(255),\n    bio varchar(255),\n    web_url varchar(255),\n    picture_url varchar(255),\n    tfa_enabled boolean,\n    verified boolean,\n    password TEXT NOT NULL,\n    backup_codes TEXT,\n    tfa_enabled_at TIMESTAMP,\n    backup_codes_created_at TIMESTAMP,\n    created_at TIMESTAMP,\n    updated_at TIMESTAMP,\n    version INTEGER NOT NULL DEFAULT 1,\n    deletion_requested_at TIMESTAMP,\n\n    CONSTRAINT users_unique_email UNIQUE (email)\n);\n\nINSERT INTO users_without_tfa_secret\nSELECT id, email, fullname, phone, location, bio, web_url, picture_url, tfa_enabled, verified, password,\n    backup_codes, tfa_enabled_at, backup_codes_created_at, created_at, updated_at, version, deletion_requested_at\nFROM users;\n\nDROP TABLE users;\nALTER TABLE users_without_tfa_secret RENAME TO users;\nCREATE INDEX IF NOT EXISTS index_users_on_email ON users (email);\nCREATE INDEX IF NOT EXISTS index_users_on_deletion_requested_at ON users (deletion_requested_at);\n\nINSERT INTO identity_provider_links SELECT * FROM identity_provider_links_backup;\nDROP TABLE identity_provider_links_backup;\n",
+	"000011_add_tfa_secret_to_users.up.sql":               "ALTER TABLE users ADD COLUMN tfa_secret varchar(64);\n",
+}
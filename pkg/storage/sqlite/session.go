@@ -0,0 +1,336 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/AdhityaRamadhanus/userland"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+)
+
+type SessionScanStruct struct {
+	ID         string
+	UserID     int `db:"user_id"`
+	IP         sql.NullString
+	ClientID   sql.NullInt64  `db:"client_id"`
+	ClientName sql.NullString `db:"client_name"`
+	UserAgent  sql.NullString `db:"user_agent"`
+	Browser    sql.NullString
+	OS         sql.NullString
+	DeviceType sql.NullString `db:"device_type"`
+	Country    sql.NullString
+	City       sql.NullString
+	ASN        sql.NullInt64
+	LastSeenIP sql.NullString `db:"last_seen_ip"`
+	LastSeenAt *time.Time     `db:"last_seen_at"`
+	Expiration int64
+	ExpiredAt  time.Time      `db:"expired_at"`
+	EndedAt    *time.Time     `db:"ended_at"`
+	EndReason  sql.NullString `db:"end_reason"`
+	CreatedAt  time.Time      `db:"created_at"`
+	UpdatedAt  time.Time      `db:"updated_at"`
+}
+
+/*
+SessionRepository is implementation of SessionRepository interface
+of userland domain using sqlite, ended sessions are kept with ended_at and end_reason for audit
+*/
+type SessionRepository struct {
+	db database
+	repositoryOptions
+}
+
+//NewSessionRepository is constructor to create session repository
+func NewSessionRepository(conn *sqlx.DB, opts ...RepositoryOption) *SessionRepository {
+	return &SessionRepository{
+		db:                conn,
+		repositoryOptions: buildRepositoryOptions(opts),
+	}
+}
+
+const sessionColumns = `id,
+				user_id,
+				ip,
+				client_id,
+				client_name,
+				user_agent,
+				browser,
+				os,
+				device_type,
+				country,
+				city,
+				asn,
+				last_seen_ip,
+				last_seen_at,
+				expiration,
+				expired_at,
+				ended_at,
+				end_reason,
+				created_at,
+				updated_at`
+
+//Create insert a new active session
+func (s SessionRepository) Create(ctx context.Context, userID int, session userland.Session) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	now := time.Now().UTC()
+	if session.LastSeenAt.IsZero() {
+		session.LastSeenAt = now
+	}
+	if session.LastSeenIP == "" {
+		session.LastSeenIP = session.IP
+	}
+	if session.ExpiredAt.IsZero() {
+		session.ExpiredAt = now.Add(session.Expiration)
+	}
+
+	query := `INSERT INTO sessions (
+				id,
+				user_id,
+				ip,
+				client_id,
+				client_name,
+				user_agent,
+				browser,
+				os,
+				device_type,
+				country,
+				city,
+				asn,
+				last_seen_ip,
+				last_seen_at,
+				expiration,
+				expired_at,
+				created_at,
+				updated_at
+			) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, NULLIF(?, ''), NULLIF(?, ''), NULLIF(?, 0), ?, ?, ?, ?, ?, ?)`
+
+	_, err := s.db.ExecContext(ctx, query,
+		session.ID,
+		userID,
+		session.IP,
+		session.ClientID,
+		session.ClientName,
+		session.UserAgent,
+		session.Browser,
+		session.OS,
+		session.DeviceType,
+		session.Country,
+		session.City,
+		session.ASN,
+		session.LastSeenIP,
+		session.LastSeenAt.UTC(),
+		int64(session.Expiration.Seconds()),
+		session.ExpiredAt.UTC(),
+		now,
+		now,
+	)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return userland.ErrDuplicateKey
+		}
+		return errors.Wrap(err, "db.Exec() err")
+	}
+
+	return nil
+}
+
+//Find active session of a user
+func (s SessionRepository) Find(ctx context.Context, userID int, sessionID string) (userland.Session, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	sessionScanStruct := SessionScanStruct{}
+	query := `SELECT ` + sessionColumns + `
+			FROM sessions
+			WHERE id=? AND user_id=? AND ended_at IS NULL AND expired_at > ?`
+
+	if err := s.db.GetContext(ctx, &sessionScanStruct, query, sessionID, userID, time.Now().UTC()); err != nil {
+		if err == sql.ErrNoRows {
+			return userland.Session{}, userland.ErrSessionNotFound
+		}
+		return userland.Session{}, errors.Wrap(err, "db.Get() err")
+	}
+
+	return s.convertStructScanToEntity(sessionScanStruct), nil
+}
+
+//FindAllByUserID return sessions of a user that are neither ended nor expired
+func (s SessionRepository) FindAllByUserID(ctx context.Context, userID int) (userland.Sessions, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	scanStructSessions := []SessionScanStruct{}
+	query := `SELECT ` + sessionColumns + `
+			FROM sessions
+			WHERE user_id=? AND ended_at IS NULL AND expired_at > ?
+			ORDER BY created_at ASC`
+
+	if err := s.db.SelectContext(ctx, &scanStructSessions, query, userID, time.Now().UTC()); err != nil {
+		return nil, errors.Wrap(err, "db.Select() err")
+	}
+
+	sessions := userland.Sessions{}
+	for _, scanStructSession := range scanStructSessions {
+		sessions = append(sessions, s.convertStructScanToEntity(scanStructSession))
+	}
+	return sessions, nil
+}
+
+//Update activity and expiration of an active session
+func (s SessionRepository) Update(ctx context.Context, userID int, session userland.Session) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `UPDATE sessions SET
+				last_seen_ip=?,
+				last_seen_at=?,
+				expired_at=?,
+				updated_at=?
+			WHERE id=? AND user_id=? AND ended_at IS NULL`
+
+	res, err := s.db.ExecContext(ctx, query, session.LastSeenIP, session.LastSeenAt.UTC(), session.ExpiredAt.UTC(), time.Now().UTC(), session.ID, userID)
+	if err != nil {
+		return errors.Wrap(err, "db.Exec() err")
+	}
+
+	return sessionAffected(res)
+}
+
+//DeleteExpiredSessions end sessions past their expiration
+func (s SessionRepository) DeleteExpiredSessions(ctx context.Context, userID int) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	now := time.Now().UTC()
+	query := `UPDATE sessions SET ended_at=expired_at, end_reason=?, updated_at=?
+			WHERE user_id=? AND ended_at IS NULL AND expired_at <= ?`
+
+	if _, err := s.db.ExecContext(ctx, query, userland.SessionEndReasonExpired, now, userID, now); err != nil {
+		return errors.Wrap(err, "db.Exec() err")
+	}
+
+	return nil
+}
+
+//DeleteBySessionID end a session, the row is kept for audit
+func (s SessionRepository) DeleteBySessionID(ctx context.Context, userID int, sessionID string) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	now := time.Now().UTC()
+	query := `UPDATE sessions SET ended_at=?, end_reason=?, updated_at=?
+			WHERE id=? AND user_id=? AND ended_at IS NULL`
+
+	res, err := s.db.ExecContext(ctx, query, now, userland.SessionEndReasonLogout, now, sessionID, userID)
+	if err != nil {
+		return errors.Wrap(err, "db.Exec() err")
+	}
+
+	return sessionAffected(res)
+}
+
+//DeleteOtherSessions end every active session of a user except the current one,
+//sqlite 3.24 has no RETURNING so the ids are selected first and only those are ended
+func (s SessionRepository) DeleteOtherSessions(ctx context.Context, userID int, currentSessionID string) (deletedSessionIDs []string, err error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	deletedSessionIDs = []string{}
+	query := `SELECT id FROM sessions WHERE user_id=? AND id<>? AND ended_at IS NULL`
+	if err := s.db.SelectContext(ctx, &deletedSessionIDs, query, userID, currentSessionID); err != nil {
+		return nil, errors.Wrap(err, "db.Select() err")
+	}
+
+	if err := s.endSessions(ctx, userID, deletedSessionIDs, userland.SessionEndReasonRevoked); err != nil {
+		return nil, err
+	}
+	return deletedSessionIDs, nil
+}
+
+//EvictSessions end sessions pushed out by the concurrent session limits
+func (s SessionRepository) EvictSessions(ctx context.Context, userID int, sessionIDs []string) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	return s.endSessions(ctx, userID, sessionIDs, userland.SessionEndReasonEvicted)
+}
+
+func (s SessionRepository) endSessions(ctx context.Context, userID int, sessionIDs []string, reason string) error {
+	if len(sessionIDs) == 0 {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	query, args, err := sqlx.In(`UPDATE sessions SET ended_at=?, end_reason=?, updated_at=?
+			WHERE user_id=? AND id IN (?) AND ended_at IS NULL`, now, reason, now, userID, sessionIDs)
+	if err != nil {
+		return errors.Wrap(err, "sqlx.In() err")
+	}
+
+	if _, err := s.db.ExecContext(ctx, query, args...); err != nil {
+		return errors.Wrap(err, "db.Exec() err")
+	}
+
+	return nil
+}
+
+//DeleteAllByUserID remove every session of a user, ended ones included
+func (s SessionRepository) DeleteAllByUserID(ctx context.Context, userID int) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM sessions WHERE user_id=?`, userID); err != nil {
+		return errors.Wrap(err, "db.Exec() err")
+	}
+
+	return nil
+}
+
+func (s SessionRepository) convertStructScanToEntity(sessionScanStruct SessionScanStruct) userland.Session {
+	session := userland.Session{
+		ID:         sessionScanStruct.ID,
+		IP:         sessionScanStruct.IP.String,
+		ClientID:   int(sessionScanStruct.ClientID.Int64),
+		ClientName: sessionScanStruct.ClientName.String,
+		UserAgent:  sessionScanStruct.UserAgent.String,
+		Browser:    sessionScanStruct.Browser.String,
+		OS:         sessionScanStruct.OS.String,
+		DeviceType: sessionScanStruct.DeviceType.String,
+		Country:    sessionScanStruct.Country.String,
+		City:       sessionScanStruct.City.String,
+		ASN:        int(sessionScanStruct.ASN.Int64),
+		LastSeenIP: sessionScanStruct.LastSeenIP.String,
+		Expiration: time.Duration(sessionScanStruct.Expiration) * time.Second,
+		ExpiredAt:  sessionScanStruct.ExpiredAt,
+		EndReason:  sessionScanStruct.EndReason.String,
+		CreatedAt:  sessionScanStruct.CreatedAt,
+		UpdatedAt:  sessionScanStruct.UpdatedAt,
+	}
+
+	if sessionScanStruct.LastSeenAt != nil {
+		session.LastSeenAt = *sessionScanStruct.LastSeenAt
+	}
+	if sessionScanStruct.EndedAt != nil {
+		session.EndedAt = *sessionScanStruct.EndedAt
+	}
+
+	return session
+}
+
+//sessionAffected return ErrSessionNotFound when the statement didn't touch any active session
+func sessionAffected(res sql.Result) error {
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "res.RowsAffected() err")
+	}
+
+	if rowsAffected == 0 {
+		return userland.ErrSessionNotFound
+	}
+
+	return nil
+}
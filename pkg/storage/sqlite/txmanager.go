@@ -0,0 +1,53 @@
+package sqlite
+
+import (
+	"context"
+
+	"github.com/AdhityaRamadhanus/userland"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+)
+
+/*
+TxManager is implementation of TxManager interface
+of userland domain using sqlite transaction
+*/
+type TxManager struct {
+	db *sqlx.DB
+	repositoryOptions
+}
+
+//NewTxManager is constructor to create transaction manager, opts are applied to repositories bound to the transaction
+func NewTxManager(conn *sqlx.DB, opts ...RepositoryOption) *TxManager {
+	return &TxManager{
+		db:                conn,
+		repositoryOptions: buildRepositoryOptions(opts),
+	}
+}
+
+//WithinTx run fn inside a transaction, fn error is returned as is after rolling back
+func (t TxManager) WithinTx(ctx context.Context, fn func(repositories userland.TxRepositories) error) (err error) {
+	tx, err := t.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "db.BeginTxx() err")
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	repositories := userland.TxRepositories{
+		UserRepository:                &UserRepository{db: tx, repositoryOptions: t.repositoryOptions},
+		EventRepository:               &EventRepository{db: tx, repositoryOptions: t.repositoryOptions},
+		SessionRepository:             &SessionRepository{db: tx, repositoryOptions: t.repositoryOptions},
+		LoginRiskAssessmentRepository: &LoginRiskAssessmentRepository{db: tx, repositoryOptions: t.repositoryOptions},
+	}
+	if err := fn(repositories); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return errors.Wrap(tx.Commit(), "tx.Commit() err")
+}
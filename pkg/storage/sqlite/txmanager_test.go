@@ -0,0 +1,47 @@
+//+build unit
+
+package sqlite_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/AdhityaRamadhanus/userland"
+	"github.com/AdhityaRamadhanus/userland/pkg/storage/sqlite"
+	"github.com/AdhityaRamadhanus/userland/pkg/userlandtest"
+)
+
+func TestTxManager_WithinTx(t *testing.T) {
+	db := createMigratedConnection(t)
+	defer db.Close()
+
+	userRepository := sqlite.NewUserRepository(db)
+	txManager := sqlite.NewTxManager(db)
+	user := userlandtest.TestCreateUser(t, userRepository)
+
+	user.Fullname = "Committed"
+	err := txManager.WithinTx(context.Background(), func(repositories userland.TxRepositories) error {
+		return repositories.UserRepository.Update(context.Background(), *user)
+	})
+	if err != nil {
+		t.Fatalf("txManager.WithinTx() err = %v; want nil", err)
+	}
+	if found, err := userRepository.Find(context.Background(), user.ID); err != nil || found.Fullname != "Committed" {
+		t.Errorf("userRepository.Find() = %q, %v after commit; want %q, nil", found.Fullname, err, "Committed")
+	}
+
+	errAbort := errors.New("abort")
+	err = txManager.WithinTx(context.Background(), func(repositories userland.TxRepositories) error {
+		if err := repositories.UserRepository.Delete(context.Background(), user.ID); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	if err != errAbort {
+		t.Fatalf("txManager.WithinTx() err = %v; want %v", err, errAbort)
+	}
+	if _, err := userRepository.Find(context.Background(), user.ID); err != nil {
+		t.Errorf("userRepository.Find() err = %v after rollback; want nil", err)
+	}
+}
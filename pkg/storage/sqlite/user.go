@@ -0,0 +1,386 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/AdhityaRamadhanus/userland"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+)
+
+type UserScanStruct struct {
+	ID         int
+	Email      string
+	Fullname   string
+	Phone      sql.NullString
+	Location   sql.NullString
+	Bio        sql.NullString
+	WebURL     sql.NullString `db:"web_url"`
+	PictureURL sql.NullString `db:"picture_url"`
+	Password   string
+	TFAEnabled sql.NullBool `db:"tfa_enabled"`
+	Verified   sql.NullBool
+	// BackupCodes is a json array, sqlite has no array type
+	BackupCodes  sql.NullString `db:"backup_codes"`
+	TFAEnabledAt *time.Time     `db:"tfa_enabled_at"`
+	TFASecret    sql.NullString `db:"tfa_secret"`
+	// BackupCodesCreatedAt is null when backup codes is never generated
+	BackupCodesCreatedAt *time.Time `db:"backup_codes_created_at"`
+	CreatedAt            time.Time  `db:"created_at"`
+	UpdatedAt            time.Time  `db:"updated_at"`
+	Version              int
+	// DeletionRequestedAt is null unless the account is pending deletion
+	DeletionRequestedAt *time.Time `db:"deletion_requested_at"`
+}
+
+const userColumns = `id,
+				email,
+				fullname,
+				phone,
+				location,
+				bio,
+				web_url,
+				picture_url,
+				verified,
+				tfa_enabled,
+				password,
+				backup_codes,
+				tfa_enabled_at,
+				tfa_secret,
+				backup_codes_created_at,
+				created_at,
+				updated_at,
+				version,
+				deletion_requested_at`
+
+/*
+UserRepository is implementation of UserRepository interface
+of userland domain using sqlite
+*/
+type UserRepository struct {
+	db database
+	repositoryOptions
+}
+
+//NewUserRepository is constructor to create user repository
+func NewUserRepository(conn *sqlx.DB, opts ...RepositoryOption) *UserRepository {
+	return &UserRepository{
+		db:                conn,
+		repositoryOptions: buildRepositoryOptions(opts),
+	}
+}
+
+//Find User by id
+func (s UserRepository) Find(ctx context.Context, id int) (user userland.User, err error) {
+	return s.findBy(ctx, `id=?`, id)
+}
+
+//FindByEmail User by email
+func (s UserRepository) FindByEmail(ctx context.Context, email string) (user userland.User, err error) {
+	return s.findBy(ctx, `email=?`, email)
+}
+
+func (s UserRepository) findBy(ctx context.Context, where string, arg interface{}) (userland.User, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	userScanStruct := UserScanStruct{}
+	query := `SELECT ` + userColumns + ` FROM users WHERE ` + where
+
+	if err := s.db.GetContext(ctx, &userScanStruct, query, arg); err != nil {
+		if err == sql.ErrNoRows {
+			return userland.User{}, userland.ErrUserNotFound
+		}
+		return userland.User{}, errors.Wrap(err, "db.Get() err")
+	}
+
+	return s.convertStructScanToEntity(userScanStruct)
+}
+
+//FindAllPendingDeletion return users whose deletion was requested before requestedBefore
+func (s UserRepository) FindAllPendingDeletion(ctx context.Context, requestedBefore time.Time) ([]userland.User, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	userScanStructs := []UserScanStruct{}
+	query := `SELECT ` + userColumns + ` FROM users
+			WHERE deletion_requested_at IS NOT NULL AND deletion_requested_at < ?
+			ORDER BY deletion_requested_at`
+	if err := s.db.SelectContext(ctx, &userScanStructs, query, requestedBefore.UTC()); err != nil {
+		return nil, errors.Wrap(err, "db.Select() err")
+	}
+
+	users := []userland.User{}
+	for _, userScanStruct := range userScanStructs {
+		user, err := s.convertStructScanToEntity(userScanStruct)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, nil
+}
+
+//Delete delete user by id
+func (s UserRepository) Delete(ctx context.Context, id int) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctx, `DELETE FROM users WHERE id=?`, id)
+	if err != nil {
+		return errors.Wrap(err, "db.Exec() err")
+	}
+
+	return userAffected(res)
+}
+
+//Insert insert user to datastore and set its id
+func (s UserRepository) Insert(ctx context.Context, user *userland.User) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `INSERT INTO users (
+				email,
+				fullname,
+				phone,
+				location,
+				password,
+				bio,
+				web_url,
+				picture_url,
+				verified,
+				created_at,
+				updated_at
+			) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	now := time.Now().UTC()
+	res, err := s.db.ExecContext(ctx, query,
+		user.Email,
+		user.Fullname,
+		user.Phone,
+		user.Location,
+		user.Password,
+		user.Bio,
+		user.WebURL,
+		user.PictureURL,
+		user.Verified,
+		now,
+		now,
+	)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return userland.ErrDuplicateKey
+		}
+		return errors.Wrap(err, "db.Exec() err")
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return errors.Wrap(err, "res.LastInsertId() err")
+	}
+	user.ID = int(id)
+	user.Version = 1
+	return nil
+}
+
+//Update update user, backup codes are only changed through StoreBackupCodes
+func (s UserRepository) Update(ctx context.Context, user userland.User) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `UPDATE users SET
+				email=?,
+				fullname=?,
+				phone=?,
+				location=?,
+				bio=?,
+				web_url=?,
+				password=?,
+				picture_url=?,
+				verified=?,
+				tfa_enabled=?,
+				tfa_enabled_at=?,
+				tfa_secret=?,
+				deletion_requested_at=?,
+				updated_at=?,
+				version=version+1
+			WHERE id=? AND version=?`
+
+	res, err := s.db.ExecContext(ctx, query,
+		user.Email,
+		user.Fullname,
+		user.Phone,
+		user.Location,
+		user.Bio,
+		user.WebURL,
+		user.Password,
+		user.PictureURL,
+		user.Verified,
+		user.TFAEnabled,
+		nullTime(user.TFAEnabledAt),
+		nullString(user.TFASecret),
+		nullTime(user.DeletionRequestedAt),
+		time.Now().UTC(),
+		user.ID,
+		user.Version,
+	)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return userland.ErrDuplicateKey
+		}
+		return errors.Wrap(err, "db.Exec() err")
+	}
+
+	if err := userAffected(res); err != userland.ErrUserNotFound {
+		return err
+	}
+	return s.updateConflict(ctx, user.ID)
+}
+
+//UpdateProfile update only the profile columns set in patch
+func (s UserRepository) UpdateProfile(ctx context.Context, id int, version int, patch userland.ProfilePatch) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	args := []interface{}{}
+	setStatements := []string{}
+	columns := []struct {
+		name  string
+		value *string
+	}{
+		{"fullname", patch.Fullname},
+		{"phone", patch.Phone},
+		{"location", patch.Location},
+		{"bio", patch.Bio},
+		{"web_url", patch.WebURL},
+	}
+	for _, column := range columns {
+		if column.value != nil {
+			args = append(args, *column.value)
+			setStatements = append(setStatements, column.name+"=?")
+		}
+	}
+	args = append(args, time.Now().UTC(), id)
+	setStatements = append(setStatements, "updated_at=?", "version=version+1")
+
+	whereStatement := "WHERE id=?"
+	if version > 0 {
+		args = append(args, version)
+		whereStatement += " AND version=?"
+	}
+
+	query := fmt.Sprintf(`UPDATE users SET %s %s`, strings.Join(setStatements, ", "), whereStatement)
+	res, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return errors.Wrap(err, "db.Exec() err")
+	}
+
+	if err := userAffected(res); err != userland.ErrUserNotFound {
+		return err
+	}
+	return s.updateConflict(ctx, id)
+}
+
+//updateConflict tell why an update matched no row, either user is gone or its version has moved
+func (s UserRepository) updateConflict(ctx context.Context, id int) error {
+	var version int
+	if err := s.db.GetContext(ctx, &version, `SELECT version FROM users WHERE id=?`, id); err != nil {
+		if err == sql.ErrNoRows {
+			return userland.ErrUserNotFound
+		}
+		return errors.Wrap(err, "db.Get(version) err")
+	}
+
+	return userland.ErrConcurrentModification
+}
+
+func (s UserRepository) StoreBackupCodes(ctx context.Context, user userland.User) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	backupCodes, err := json.Marshal(user.BackupCodes)
+	if err != nil {
+		return errors.Wrap(err, "json.Marshal(user.BackupCodes) err")
+	}
+
+	query := `UPDATE users SET backup_codes=?, backup_codes_created_at=?, updated_at=?, version=version+1 WHERE id=? AND version=?`
+	res, err := s.db.ExecContext(ctx, query, string(backupCodes), nullTime(user.BackupCodesCreatedAt), time.Now().UTC(), user.ID, user.Version)
+	if err != nil {
+		return errors.Wrap(err, "db.Exec() err")
+	}
+
+	if err := userAffected(res); err != userland.ErrUserNotFound {
+		return err
+	}
+	return s.updateConflict(ctx, user.ID)
+}
+
+func (u UserRepository) convertStructScanToEntity(userScanStruct UserScanStruct) (userland.User, error) {
+	user := userland.User{
+		ID:         userScanStruct.ID,
+		Fullname:   userScanStruct.Fullname,
+		Email:      userScanStruct.Email,
+		Password:   userScanStruct.Password,
+		Phone:      userScanStruct.Phone.String,
+		Location:   userScanStruct.Location.String,
+		Bio:        userScanStruct.Bio.String,
+		WebURL:     userScanStruct.WebURL.String,
+		PictureURL: userScanStruct.PictureURL.String,
+		TFAEnabled: userScanStruct.TFAEnabled.Bool,
+		TFASecret:  userScanStruct.TFASecret.String,
+		Verified:   userScanStruct.Verified.Bool,
+		CreatedAt:  userScanStruct.CreatedAt,
+		UpdatedAt:  userScanStruct.UpdatedAt,
+		Version:    userScanStruct.Version,
+	}
+
+	if userScanStruct.BackupCodes.Valid {
+		if err := json.Unmarshal([]byte(userScanStruct.BackupCodes.String), &user.BackupCodes); err != nil {
+			return userland.User{}, errors.Wrap(err, "json.Unmarshal(backup_codes) err")
+		}
+	}
+	if userScanStruct.TFAEnabledAt != nil {
+		user.TFAEnabledAt = *userScanStruct.TFAEnabledAt
+	}
+	if userScanStruct.BackupCodesCreatedAt != nil {
+		user.BackupCodesCreatedAt = *userScanStruct.BackupCodesCreatedAt
+	}
+	if userScanStruct.DeletionRequestedAt != nil {
+		user.DeletionRequestedAt = *userScanStruct.DeletionRequestedAt
+	}
+
+	return user, nil
+}
+
+//userAffected return ErrUserNotFound when the statement didn't touch any user
+func userAffected(res sql.Result) error {
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "res.RowsAffected() err")
+	}
+
+	if rowsAffected == 0 {
+		return userland.ErrUserNotFound
+	}
+
+	return nil
+}
+
+//nullTime store zero time as null, non zero time is stored in UTC so stored times sort as text
+func nullTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	utc := t.UTC()
+	return &utc
+}
+
+//nullString store empty string as null
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
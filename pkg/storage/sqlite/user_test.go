@@ -0,0 +1,29 @@
+//+build unit
+
+package sqlite_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/AdhityaRamadhanus/userland/pkg/storage/sqlite"
+	"github.com/pkg/errors"
+)
+
+func TestUserRepository_context(t *testing.T) {
+	db := createMigratedConnection(t)
+	defer db.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	userRepository := sqlite.NewUserRepository(db)
+	if _, err := userRepository.Find(ctx, 1); errors.Cause(err) != context.Canceled {
+		t.Errorf("userRepository.Find() with canceled context err = %v; want %v", err, context.Canceled)
+	}
+
+	userRepository = sqlite.NewUserRepository(db, sqlite.WithQueryTimeout(time.Nanosecond))
+	if _, err := userRepository.Find(context.Background(), 1); errors.Cause(err) != context.DeadlineExceeded {
+		t.Errorf("userRepository.Find() past query timeout err = %v; want %v", err, context.DeadlineExceeded)
+	}
+}
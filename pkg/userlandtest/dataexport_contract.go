@@ -0,0 +1,175 @@
+package userlandtest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/AdhityaRamadhanus/userland"
+)
+
+//DataExportRepositoryFactory return an empty DataExportRepository, it is called before every contract test
+type DataExportRepositoryFactory func(t *testing.T) userland.DataExportRepository
+
+//RunDataExportRepositoryContract check behavior every userland.DataExportRepository implementation must share
+func RunDataExportRepositoryContract(t *testing.T, factory DataExportRepositoryFactory) {
+	insert := func(t *testing.T, dataExportRepository userland.DataExportRepository, userID int) userland.DataExport {
+		dataExport := userland.DataExport{UserID: userID}
+		if err := dataExportRepository.Insert(context.Background(), &dataExport); err != nil {
+			t.Fatalf("Insert(user %d) err = %v; want nil", userID, err)
+		}
+		return dataExport
+	}
+	dataExportIDs := func(dataExports userland.DataExports) []int {
+		ids := []int{}
+		for _, dataExport := range dataExports {
+			ids = append(ids, dataExport.ID)
+		}
+		return ids
+	}
+	sameIDs := func(got []int, want ...int) bool {
+		if len(got) != len(want) {
+			return false
+		}
+		for i := range got {
+			if got[i] != want[i] {
+				return false
+			}
+		}
+		return true
+	}
+
+	t.Run("Insert", func(t *testing.T) {
+		dataExportRepository := factory(t)
+		dataExport := insert(t, dataExportRepository, 1)
+		if dataExport.ID == 0 {
+			t.Errorf("Insert().ID = 0; want id set")
+		}
+		if !withinSecond(dataExport.RequestedAt, time.Now()) {
+			t.Errorf("Insert().RequestedAt = %v; want now", dataExport.RequestedAt)
+		}
+
+		found, err := dataExportRepository.FindAllByUserID(context.Background(), 1)
+		if err != nil || len(found) != 1 {
+			t.Fatalf("FindAllByUserID(1) = %v, %v; want 1 export", found, err)
+		}
+		if found[0].ID != dataExport.ID || found[0].Path != "" || !found[0].StartedAt.IsZero() || !found[0].CompletedAt.IsZero() {
+			t.Errorf("FindAllByUserID(1) = %+v; want pending export %d", found[0], dataExport.ID)
+		}
+	})
+
+	t.Run("FindAllPending", func(t *testing.T) {
+		dataExportRepository := factory(t)
+		first := insert(t, dataExportRepository, 1)
+		second := insert(t, dataExportRepository, 2)
+		completed := insert(t, dataExportRepository, 1)
+		if err := dataExportRepository.Complete(context.Background(), completed.ID, "exports/completed.zip", time.Now().Add(time.Hour)); err != nil {
+			t.Fatalf("Complete(%d) err = %v; want nil", completed.ID, err)
+		}
+
+		found, err := dataExportRepository.FindAllPending(context.Background(), time.Now())
+		if err != nil {
+			t.Fatalf("FindAllPending() err = %v; want nil", err)
+		}
+		if ids := dataExportIDs(found); !sameIDs(ids, first.ID, second.ID) {
+			t.Errorf("FindAllPending() = %v; want %v", ids, []int{first.ID, second.ID})
+		}
+	})
+
+	t.Run("Claim", func(t *testing.T) {
+		dataExportRepository := factory(t)
+		dataExport := insert(t, dataExportRepository, 1)
+		staleBefore := time.Now().Add(-time.Minute)
+
+		if err := dataExportRepository.Claim(context.Background(), dataExport.ID, staleBefore); err != nil {
+			t.Fatalf("Claim(%d) err = %v; want nil", dataExport.ID, err)
+		}
+		if err := dataExportRepository.Claim(context.Background(), dataExport.ID, staleBefore); err != userland.ErrDataExportNotFound {
+			t.Errorf("Claim(claimed) err = %v; want %v", err, userland.ErrDataExportNotFound)
+		}
+		if found, _ := dataExportRepository.FindAllPending(context.Background(), staleBefore); len(found) != 0 {
+			t.Errorf("FindAllPending() after Claim = %v; want none", dataExportIDs(found))
+		}
+
+		// a claim older than startedBefore is stale, its exporter is assumed gone
+		staleBefore = time.Now().Add(time.Minute)
+		if found, _ := dataExportRepository.FindAllPending(context.Background(), staleBefore); !sameIDs(dataExportIDs(found), dataExport.ID) {
+			t.Errorf("FindAllPending(stale) = %v; want %d", dataExportIDs(found), dataExport.ID)
+		}
+		if err := dataExportRepository.Claim(context.Background(), dataExport.ID, staleBefore); err != nil {
+			t.Errorf("Claim(stale) err = %v; want nil", err)
+		}
+
+		if err := dataExportRepository.Claim(context.Background(), dataExport.ID+1, staleBefore); err != userland.ErrDataExportNotFound {
+			t.Errorf("Claim(missing) err = %v; want %v", err, userland.ErrDataExportNotFound)
+		}
+	})
+
+	t.Run("Complete", func(t *testing.T) {
+		dataExportRepository := factory(t)
+		dataExport := insert(t, dataExportRepository, 1)
+		expiredAt := time.Now().Add(time.Hour)
+
+		if err := dataExportRepository.Complete(context.Background(), dataExport.ID, "exports/some.zip", expiredAt); err != nil {
+			t.Fatalf("Complete(%d) err = %v; want nil", dataExport.ID, err)
+		}
+		if err := dataExportRepository.Claim(context.Background(), dataExport.ID, time.Now().Add(time.Minute)); err != userland.ErrDataExportNotFound {
+			t.Errorf("Claim(completed) err = %v; want %v", err, userland.ErrDataExportNotFound)
+		}
+
+		found, err := dataExportRepository.FindAllByUserID(context.Background(), 1)
+		if err != nil || len(found) != 1 {
+			t.Fatalf("FindAllByUserID(1) = %v, %v; want 1 export", found, err)
+		}
+		if found[0].Path != "exports/some.zip" || !withinSecond(found[0].CompletedAt, time.Now()) || !withinSecond(found[0].ExpiredAt, expiredAt) {
+			t.Errorf("FindAllByUserID(1) = %+v; want completed export expiring at %v", found[0], expiredAt)
+		}
+
+		if err := dataExportRepository.Complete(context.Background(), dataExport.ID+1, "exports/missing.zip", expiredAt); err != userland.ErrDataExportNotFound {
+			t.Errorf("Complete(missing) err = %v; want %v", err, userland.ErrDataExportNotFound)
+		}
+	})
+
+	t.Run("FindAllExpired", func(t *testing.T) {
+		dataExportRepository := factory(t)
+		expired := insert(t, dataExportRepository, 1)
+		if err := dataExportRepository.Complete(context.Background(), expired.ID, "exports/expired.zip", time.Now().Add(-time.Minute)); err != nil {
+			t.Fatalf("Complete(%d) err = %v; want nil", expired.ID, err)
+		}
+		valid := insert(t, dataExportRepository, 1)
+		if err := dataExportRepository.Complete(context.Background(), valid.ID, "exports/valid.zip", time.Now().Add(time.Hour)); err != nil {
+			t.Fatalf("Complete(%d) err = %v; want nil", valid.ID, err)
+		}
+		insert(t, dataExportRepository, 1)
+
+		found, err := dataExportRepository.FindAllExpired(context.Background(), time.Now())
+		if err != nil {
+			t.Fatalf("FindAllExpired() err = %v; want nil", err)
+		}
+		if ids := dataExportIDs(found); !sameIDs(ids, expired.ID) {
+			t.Errorf("FindAllExpired() = %v; want %v", ids, []int{expired.ID})
+		}
+		if found[0].Path != "exports/expired.zip" {
+			t.Errorf("FindAllExpired()[0].Path = %q; want %q", found[0].Path, "exports/expired.zip")
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		dataExportRepository := factory(t)
+		dataExport := insert(t, dataExportRepository, 1)
+		other := insert(t, dataExportRepository, 2)
+
+		if err := dataExportRepository.Delete(context.Background(), dataExport.ID); err != nil {
+			t.Fatalf("Delete(%d) err = %v; want nil", dataExport.ID, err)
+		}
+		if found, _ := dataExportRepository.FindAllByUserID(context.Background(), 1); len(found) != 0 {
+			t.Errorf("FindAllByUserID(1) after Delete = %v; want none", dataExportIDs(found))
+		}
+		if found, _ := dataExportRepository.FindAllByUserID(context.Background(), 2); !sameIDs(dataExportIDs(found), other.ID) {
+			t.Errorf("FindAllByUserID(2) after Delete = %v; want %d", dataExportIDs(found), other.ID)
+		}
+		if err := dataExportRepository.Delete(context.Background(), dataExport.ID); err != nil {
+			t.Errorf("Delete(deleted) err = %v; want nil", err)
+		}
+	})
+}
@@ -0,0 +1,190 @@
+package userlandtest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/AdhityaRamadhanus/userland"
+)
+
+//EventRepositoryFactory return an empty EventRepository, it is called before every contract test
+type EventRepositoryFactory func(t *testing.T) userland.EventRepository
+
+//RunEventRepositoryContract check behavior every userland.EventRepository implementation must share
+func RunEventRepositoryContract(t *testing.T, factory EventRepositoryFactory) {
+	insertEvents := func(t *testing.T, eventRepository userland.EventRepository, events ...userland.Event) {
+		for _, event := range events {
+			if err := eventRepository.Insert(context.Background(), event); err != nil {
+				t.Fatalf("Insert(event) err = %v; want nil", err)
+			}
+		}
+	}
+	now := time.Now()
+	newestFirst := userland.EventPagingOptions{Limit: 10, SortBy: "timestamp", Order: "desc"}
+
+	t.Run("Insert", func(t *testing.T) {
+		eventRepository := factory(t)
+		insertEvents(t, eventRepository, userland.Event{
+			UserID:     1,
+			Event:      "authentication.login",
+			UserAgent:  "curl/7.54.0",
+			IP:         "127.0.0.1",
+			ClientID:   1,
+			ClientName: "web",
+			Country:    "ID",
+			City:       "Jakarta",
+			ASN:        7713,
+			Timestamp:  now,
+		})
+
+		events, count, err := eventRepository.FindAll(context.Background(), userland.EventFilterOptions{UserID: 1}, newestFirst)
+		if err != nil {
+			t.Fatalf("FindAll() err = %v; want nil", err)
+		}
+		if count != 1 || len(events) != 1 {
+			t.Fatalf("FindAll() = %d events, count %d; want 1 event, count 1", len(events), count)
+		}
+		event := events[0]
+		if event.Event != "authentication.login" || event.UserAgent != "curl/7.54.0" || event.IP != "127.0.0.1" ||
+			event.ClientID != 1 || event.ClientName != "web" || event.Country != "ID" || event.City != "Jakarta" ||
+			event.ASN != 7713 || !withinSecond(event.Timestamp, now) {
+			t.Errorf("FindAll()[0] = %+v; want inserted event", event)
+		}
+	})
+
+	t.Run("InsertChangedFields", func(t *testing.T) {
+		eventRepository := factory(t)
+		insertEvents(t, eventRepository,
+			userland.Event{UserID: 1, Event: "user.profile.update", ChangedFields: []string{"bio", "phone"}, Timestamp: now},
+			userland.Event{UserID: 2, Event: "authentication.login", Timestamp: now},
+		)
+
+		events, _, err := eventRepository.FindAll(context.Background(), userland.EventFilterOptions{UserID: 1}, newestFirst)
+		if err != nil || len(events) != 1 {
+			t.Fatalf("FindAll() = %d events, err = %v; want 1 event, nil", len(events), err)
+		}
+		if got := events[0].ChangedFields; len(got) != 2 || got[0] != "bio" || got[1] != "phone" {
+			t.Errorf("FindAll()[0].ChangedFields = %v; want [bio phone]", got)
+		}
+
+		events, _, err = eventRepository.FindAll(context.Background(), userland.EventFilterOptions{UserID: 2}, newestFirst)
+		if err != nil || len(events) != 1 {
+			t.Fatalf("FindAll() = %d events, err = %v; want 1 event, nil", len(events), err)
+		}
+		if got := events[0].ChangedFields; len(got) != 0 {
+			t.Errorf("FindAll()[0].ChangedFields = %v; want none", got)
+		}
+	})
+
+	t.Run("FindAll", func(t *testing.T) {
+		eventRepository := factory(t)
+		insertEvents(t, eventRepository,
+			userland.Event{UserID: 1, Event: "authentication.login", IP: "127.0.0.1", Timestamp: now.Add(-3 * time.Minute)},
+			userland.Event{UserID: 1, Event: "authentication.login", IP: "127.0.0.2", Timestamp: now.Add(-2 * time.Minute)},
+			userland.Event{UserID: 1, Event: "profile.update", IP: "127.0.0.1", Timestamp: now.Add(-1 * time.Minute)},
+			userland.Event{UserID: 2, Event: "authentication.login", IP: "127.0.0.1", Timestamp: now},
+		)
+
+		testCases := []struct {
+			name       string
+			filter     userland.EventFilterOptions
+			paging     userland.EventPagingOptions
+			wantIPs    []string
+			wantEvents []string
+			wantCount  int
+		}{
+			{
+				name:       "by user newest first",
+				filter:     userland.EventFilterOptions{UserID: 1},
+				paging:     newestFirst,
+				wantEvents: []string{"profile.update", "authentication.login", "authentication.login"},
+				wantIPs:    []string{"127.0.0.1", "127.0.0.2", "127.0.0.1"},
+				wantCount:  3,
+			},
+			{
+				name:       "by user oldest first",
+				filter:     userland.EventFilterOptions{UserID: 1},
+				paging:     userland.EventPagingOptions{Limit: 10, SortBy: "timestamp", Order: "asc"},
+				wantEvents: []string{"authentication.login", "authentication.login", "profile.update"},
+				wantIPs:    []string{"127.0.0.1", "127.0.0.2", "127.0.0.1"},
+				wantCount:  3,
+			},
+			{
+				name:       "by user and event",
+				filter:     userland.EventFilterOptions{UserID: 1, Event: "authentication.login"},
+				paging:     newestFirst,
+				wantEvents: []string{"authentication.login", "authentication.login"},
+				wantIPs:    []string{"127.0.0.2", "127.0.0.1"},
+				wantCount:  2,
+			},
+			{
+				name:       "by ip",
+				filter:     userland.EventFilterOptions{IP: "127.0.0.2"},
+				paging:     newestFirst,
+				wantEvents: []string{"authentication.login"},
+				wantIPs:    []string{"127.0.0.2"},
+				wantCount:  1,
+			},
+			{
+				name:       "count ignore paging",
+				filter:     userland.EventFilterOptions{UserID: 1},
+				paging:     userland.EventPagingOptions{Limit: 1, Offset: 1, SortBy: "timestamp", Order: "desc"},
+				wantEvents: []string{"authentication.login"},
+				wantIPs:    []string{"127.0.0.2"},
+				wantCount:  3,
+			},
+			{
+				name:       "offset past the end",
+				filter:     userland.EventFilterOptions{UserID: 1},
+				paging:     userland.EventPagingOptions{Limit: 10, Offset: 10, SortBy: "timestamp", Order: "desc"},
+				wantEvents: []string{},
+				wantIPs:    []string{},
+				wantCount:  3,
+			},
+		}
+
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				events, count, err := eventRepository.FindAll(context.Background(), tc.filter, tc.paging)
+				if err != nil {
+					t.Fatalf("FindAll() err = %v; want nil", err)
+				}
+				if count != tc.wantCount {
+					t.Errorf("FindAll() count = %d; want %d", count, tc.wantCount)
+				}
+				if len(events) != len(tc.wantEvents) {
+					t.Fatalf("FindAll() = %+v; want %d events", events, len(tc.wantEvents))
+				}
+				for i, event := range events {
+					if event.Event != tc.wantEvents[i] || event.IP != tc.wantIPs[i] {
+						t.Errorf("FindAll()[%d] = %s from %s; want %s from %s", i, event.Event, event.IP, tc.wantEvents[i], tc.wantIPs[i])
+					}
+				}
+			})
+		}
+	})
+
+	t.Run("DeleteAllByUserID", func(t *testing.T) {
+		eventRepository := factory(t)
+		insertEvents(t, eventRepository,
+			userland.Event{UserID: 1, Event: "authentication.login", Timestamp: now},
+			userland.Event{UserID: 1, Event: "authentication.logout", Timestamp: now},
+			userland.Event{UserID: 2, Event: "authentication.login", Timestamp: now},
+		)
+
+		if err := eventRepository.DeleteAllByUserID(context.Background(), 1); err != nil {
+			t.Fatalf("DeleteAllByUserID(1) err = %v; want nil", err)
+		}
+		if _, count, _ := eventRepository.FindAll(context.Background(), userland.EventFilterOptions{UserID: 1}, newestFirst); count != 0 {
+			t.Errorf("FindAll(user 1) count = %d after DeleteAllByUserID; want 0", count)
+		}
+		if _, count, _ := eventRepository.FindAll(context.Background(), userland.EventFilterOptions{UserID: 2}, newestFirst); count != 1 {
+			t.Errorf("FindAll(user 2) count = %d after DeleteAllByUserID(1); want 1", count)
+		}
+		// deleting nothing is not an error
+		if err := eventRepository.DeleteAllByUserID(context.Background(), 3); err != nil {
+			t.Errorf("DeleteAllByUserID(3) err = %v; want nil", err)
+		}
+	})
+}
@@ -0,0 +1,139 @@
+package userlandtest
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/AdhityaRamadhanus/userland"
+)
+
+//KeyValueServiceFactory return an empty KeyValueService, it is called before every contract test
+type KeyValueServiceFactory func(t *testing.T) userland.KeyValueService
+
+//RunKeyValueServiceContract check behavior every userland.KeyValueService implementation must share
+func RunKeyValueServiceContract(t *testing.T, factory KeyValueServiceFactory) {
+	assertValue := func(t *testing.T, keyValueService userland.KeyValueService, key string, want string) {
+		value, err := keyValueService.Get(context.Background(), key)
+		if err != nil {
+			t.Fatalf("Get(%q) err = %v; want nil", key, err)
+		}
+		if string(value) != want {
+			t.Errorf("Get(%q) = %q; want %q", key, value, want)
+		}
+	}
+	assertNotFound := func(t *testing.T, keyValueService userland.KeyValueService, key string) {
+		if _, err := keyValueService.Get(context.Background(), key); err != userland.ErrKeyNotFound {
+			t.Errorf("Get(%q) err = %v; want %v", key, err, userland.ErrKeyNotFound)
+		}
+	}
+
+	t.Run("Set", func(t *testing.T) {
+		keyValueService := factory(t)
+		assertNotFound(t, keyValueService, "contract:key")
+
+		if err := keyValueService.Set(context.Background(), "contract:key", []byte("value")); err != nil {
+			t.Fatalf("Set(%q) err = %v; want nil", "contract:key", err)
+		}
+		assertValue(t, keyValueService, "contract:key", "value")
+
+		if err := keyValueService.Set(context.Background(), "contract:key", []byte("overwritten")); err != nil {
+			t.Fatalf("Set(%q) err = %v; want nil", "contract:key", err)
+		}
+		assertValue(t, keyValueService, "contract:key", "overwritten")
+	})
+
+	t.Run("SetEx", func(t *testing.T) {
+		keyValueService := factory(t)
+		if err := keyValueService.SetEx(context.Background(), "contract:key", []byte("value"), 200*time.Millisecond); err != nil {
+			t.Fatalf("SetEx(%q) err = %v; want nil", "contract:key", err)
+		}
+		assertValue(t, keyValueService, "contract:key", "value")
+
+		time.Sleep(400 * time.Millisecond)
+		assertNotFound(t, keyValueService, "contract:key")
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		keyValueService := factory(t)
+		if err := keyValueService.Set(context.Background(), "contract:key", []byte("value")); err != nil {
+			t.Fatalf("Set(%q) err = %v; want nil", "contract:key", err)
+		}
+
+		if err := keyValueService.Delete(context.Background(), "contract:key"); err != nil {
+			t.Fatalf("Delete(%q) err = %v; want nil", "contract:key", err)
+		}
+		assertNotFound(t, keyValueService, "contract:key")
+	})
+
+	t.Run("Expire", func(t *testing.T) {
+		keyValueService := factory(t)
+		if err := keyValueService.Set(context.Background(), "contract:key", []byte("value")); err != nil {
+			t.Fatalf("Set(%q) err = %v; want nil", "contract:key", err)
+		}
+
+		if err := keyValueService.Expire(context.Background(), "contract:key", 200*time.Millisecond); err != nil {
+			t.Fatalf("Expire(%q) err = %v; want nil", "contract:key", err)
+		}
+		assertValue(t, keyValueService, "contract:key", "value")
+
+		time.Sleep(400 * time.Millisecond)
+		assertNotFound(t, keyValueService, "contract:key")
+
+		if err := keyValueService.Expire(context.Background(), "contract:missing", time.Minute); err != userland.ErrKeyNotFound {
+			t.Errorf("Expire(missing) err = %v; want %v", err, userland.ErrKeyNotFound)
+		}
+	})
+
+	t.Run("SetNX", func(t *testing.T) {
+		keyValueService := factory(t)
+		set, err := keyValueService.SetNX(context.Background(), "contract:key", []byte("first"), 200*time.Millisecond)
+		if err != nil || !set {
+			t.Fatalf("SetNX(%q) = %v, %v; want true, nil", "contract:key", set, err)
+		}
+
+		set, err = keyValueService.SetNX(context.Background(), "contract:key", []byte("second"), 200*time.Millisecond)
+		if err != nil || set {
+			t.Fatalf("SetNX(%q) on taken key = %v, %v; want false, nil", "contract:key", set, err)
+		}
+		assertValue(t, keyValueService, "contract:key", "first")
+
+		// the key is free again once it expire
+		time.Sleep(400 * time.Millisecond)
+		set, err = keyValueService.SetNX(context.Background(), "contract:key", []byte("third"), 200*time.Millisecond)
+		if err != nil || !set {
+			t.Fatalf("SetNX(%q) on expired key = %v, %v; want true, nil", "contract:key", set, err)
+		}
+		assertValue(t, keyValueService, "contract:key", "third")
+	})
+
+	t.Run("Incr", func(t *testing.T) {
+		keyValueService := factory(t)
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if _, err := keyValueService.Incr(context.Background(), "contract:counter", 200*time.Millisecond); err != nil {
+					t.Errorf("Incr(%q) err = %v; want nil", "contract:counter", err)
+				}
+			}()
+		}
+		wg.Wait()
+		// concurrent increments are not lost
+		assertValue(t, keyValueService, "contract:counter", "20")
+
+		value, err := keyValueService.Incr(context.Background(), "contract:counter", 200*time.Millisecond)
+		if err != nil || value != 21 {
+			t.Fatalf("Incr(%q) = %d, %v; want 21, nil", "contract:counter", value, err)
+		}
+
+		// the counter start over once it expire
+		time.Sleep(400 * time.Millisecond)
+		value, err = keyValueService.Incr(context.Background(), "contract:counter", 200*time.Millisecond)
+		if err != nil || value != 1 {
+			t.Fatalf("Incr(%q) on expired key = %d, %v; want 1, nil", "contract:counter", value, err)
+		}
+	})
+}
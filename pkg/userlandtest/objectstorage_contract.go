@@ -0,0 +1,69 @@
+package userlandtest
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/AdhityaRamadhanus/userland"
+)
+
+//ObjectStorageServiceFactory return an ObjectStorageService, it is called before every contract test
+type ObjectStorageServiceFactory func(t *testing.T) userland.ObjectStorageService
+
+//RunObjectStorageServiceContract check behavior every userland.ObjectStorageService implementation must share
+func RunObjectStorageServiceContract(t *testing.T, factory ObjectStorageServiceFactory) {
+	t.Run("Write", func(t *testing.T) {
+		objectStorageService := factory(t)
+		content := []byte("\x89PNG contract")
+		metadata := userland.ObjectMetaData{
+			ContentType:  "image/png",
+			CacheControl: "public, max-age=86400",
+			Path:         "contract/picture.png",
+		}
+
+		url, err := objectStorageService.Write(context.Background(), bytes.NewReader(content), metadata)
+		if err != nil {
+			t.Fatalf("Write(%q) err = %v; want nil", metadata.Path, err)
+		}
+		if url == "" {
+			t.Errorf("Write(%q) url = %q; want url of the object", metadata.Path, url)
+		}
+
+		fetched, fetchedMetadata, err := objectStorageService.Fetch(context.Background(), metadata.Path)
+		if err != nil {
+			t.Fatalf("Fetch(%q) err = %v; want nil", metadata.Path, err)
+		}
+		if !bytes.Equal(fetched, content) {
+			t.Errorf("Fetch(%q) = %q; want %q", metadata.Path, fetched, content)
+		}
+		if fetchedMetadata.ContentType != metadata.ContentType {
+			t.Errorf("Fetch(%q) content type = %q; want %q", metadata.Path, fetchedMetadata.ContentType, metadata.ContentType)
+		}
+	})
+
+	t.Run("Fetch missing", func(t *testing.T) {
+		objectStorageService := factory(t)
+		if _, _, err := objectStorageService.Fetch(context.Background(), "contract/missing.png"); err == nil {
+			t.Errorf("Fetch(missing) err = nil; want err")
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		objectStorageService := factory(t)
+		path := "contract/deleted.png"
+		if _, err := objectStorageService.Write(context.Background(), bytes.NewReader([]byte("content")), userland.ObjectMetaData{Path: path, ContentType: "image/png"}); err != nil {
+			t.Fatalf("Write() err = %v; want nil", err)
+		}
+
+		if err := objectStorageService.Delete(context.Background(), path); err != nil {
+			t.Fatalf("Delete(%q) err = %v; want nil", path, err)
+		}
+		if _, _, err := objectStorageService.Fetch(context.Background(), path); err == nil {
+			t.Errorf("Fetch(%q) err = nil after Delete; want err", path)
+		}
+		if err := objectStorageService.Delete(context.Background(), path); err != nil {
+			t.Errorf("Delete(%q) err = %v on missing object; want nil", path, err)
+		}
+	})
+}
@@ -1,6 +1,7 @@
 package userlandtest
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -57,7 +58,7 @@ func TestCreateSession(t *testing.T, sr userland.SessionRepository, opts ...func
 		Expiration: cfg.Expiration,
 	}
 
-	if err := sr.Create(cfg.UserID, session); err != nil {
+	if err := sr.Create(context.Background(), cfg.UserID, session); err != nil {
 		t.Fatalf("Failed to create session for user %d err = %v; want nil", cfg.UserID, err)
 	}
 	return session
@@ -85,7 +86,7 @@ func TestCreateSessions(t *testing.T, sr userland.SessionRepository, opts ...fun
 			Expiration: cfg.Expiration,
 		}
 
-		if err := sr.Create(cfg.UserID, session); err != nil {
+		if err := sr.Create(context.Background(), cfg.UserID, session); err != nil {
 			t.Fatalf("Failed to create session for user %d err = %v; want nil", cfg.UserID, err)
 		}
 		sessions = append(sessions, session)
@@ -0,0 +1,236 @@
+package userlandtest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/AdhityaRamadhanus/userland"
+	"github.com/AdhityaRamadhanus/userland/pkg/common/security"
+)
+
+//SessionRepositoryFactory return an empty SessionRepository, it is called before every contract test
+type SessionRepositoryFactory func(t *testing.T) userland.SessionRepository
+
+//RunSessionRepositoryContract check behavior every userland.SessionRepository implementation must share.
+//Ordering of FindAllByUserID is left to the implementation
+func RunSessionRepositoryContract(t *testing.T, factory SessionRepositoryFactory) {
+	createExpiredSession := func(t *testing.T, sessionRepository userland.SessionRepository, userID int) userland.Session {
+		session := userland.Session{
+			ID:         security.GenerateUUID(),
+			IP:         "123.123.13.123",
+			ClientID:   1,
+			ClientName: "test",
+			ExpiredAt:  time.Now().Add(-time.Hour),
+		}
+		if err := sessionRepository.Create(context.Background(), userID, session); err != nil {
+			t.Fatalf("Create(%d, expired session) err = %v; want nil", userID, err)
+		}
+		return session
+	}
+	sessionIDs := func(sessions userland.Sessions) map[string]bool {
+		ids := map[string]bool{}
+		for _, session := range sessions {
+			ids[session.ID] = true
+		}
+		return ids
+	}
+
+	t.Run("Find", func(t *testing.T) {
+		sessionRepository := factory(t)
+		session := TestCreateSession(t, sessionRepository)
+
+		found, err := sessionRepository.Find(context.Background(), DefaultSessionUserID, session.ID)
+		if err != nil {
+			t.Fatalf("Find(%d, %q) err = %v; want nil", DefaultSessionUserID, session.ID, err)
+		}
+		if found.ID != session.ID || found.IP != session.IP || found.ClientID != session.ClientID || found.ClientName != session.ClientName {
+			t.Errorf("Find(%d, %q) = %+v; want %+v", DefaultSessionUserID, session.ID, found, session)
+		}
+		if found.Token != "" {
+			t.Errorf("Find(%d, %q).Token = %q; want token not stored", DefaultSessionUserID, session.ID, found.Token)
+		}
+		if !withinSecond(found.ExpiredAt, time.Now().Add(DefaultSessionExpiration)) {
+			t.Errorf("Find(%d, %q).ExpiredAt = %v; want now + %s", DefaultSessionUserID, session.ID, found.ExpiredAt, DefaultSessionExpiration)
+		}
+
+		if _, err := sessionRepository.Find(context.Background(), DefaultSessionUserID+1, session.ID); err != userland.ErrSessionNotFound {
+			t.Errorf("Find(other user) err = %v; want %v", err, userland.ErrSessionNotFound)
+		}
+		if _, err := sessionRepository.Find(context.Background(), DefaultSessionUserID, security.GenerateUUID()); err != userland.ErrSessionNotFound {
+			t.Errorf("Find(missing) err = %v; want %v", err, userland.ErrSessionNotFound)
+		}
+
+		expired := createExpiredSession(t, sessionRepository, DefaultSessionUserID)
+		if _, err := sessionRepository.Find(context.Background(), DefaultSessionUserID, expired.ID); err != userland.ErrSessionNotFound {
+			t.Errorf("Find(expired) err = %v; want %v", err, userland.ErrSessionNotFound)
+		}
+	})
+
+	t.Run("FindAllByUserID", func(t *testing.T) {
+		sessionRepository := factory(t)
+		sessions := TestCreateSessions(t, sessionRepository, WithNumberOfSessions(3))
+		TestCreateSession(t, sessionRepository, WithUserID(DefaultSessionUserID+1))
+
+		found, err := sessionRepository.FindAllByUserID(context.Background(), DefaultSessionUserID)
+		if err != nil {
+			t.Fatalf("FindAllByUserID(%d) err = %v; want nil", DefaultSessionUserID, err)
+		}
+		ids := sessionIDs(found)
+		if len(ids) != len(sessions) {
+			t.Errorf("FindAllByUserID(%d) = %d sessions; want %d", DefaultSessionUserID, len(ids), len(sessions))
+		}
+		for _, session := range sessions {
+			if !ids[session.ID] {
+				t.Errorf("FindAllByUserID(%d) doesn't contain %q", DefaultSessionUserID, session.ID)
+			}
+		}
+
+		found, err = sessionRepository.FindAllByUserID(context.Background(), DefaultSessionUserID+2)
+		if err != nil || len(found) != 0 {
+			t.Errorf("FindAllByUserID(no sessions) = %v, %v; want empty, nil", found, err)
+		}
+
+		expired := createExpiredSession(t, sessionRepository, DefaultSessionUserID)
+		found, err = sessionRepository.FindAllByUserID(context.Background(), DefaultSessionUserID)
+		if err != nil {
+			t.Fatalf("FindAllByUserID(%d) err = %v; want nil", DefaultSessionUserID, err)
+		}
+		if sessionIDs(found)[expired.ID] {
+			t.Errorf("FindAllByUserID(%d) contain expired session %q", DefaultSessionUserID, expired.ID)
+		}
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		sessionRepository := factory(t)
+		session := TestCreateSession(t, sessionRepository)
+
+		session.LastSeenIP = "10.10.10.10"
+		session.LastSeenAt = time.Now()
+		session.ExpiredAt = time.Now().Add(2 * DefaultSessionExpiration)
+		if err := sessionRepository.Update(context.Background(), DefaultSessionUserID, session); err != nil {
+			t.Fatalf("Update(%d, session) err = %v; want nil", DefaultSessionUserID, err)
+		}
+
+		found, err := sessionRepository.Find(context.Background(), DefaultSessionUserID, session.ID)
+		if err != nil {
+			t.Fatalf("Find(%d, %q) err = %v; want nil", DefaultSessionUserID, session.ID, err)
+		}
+		if found.LastSeenIP != session.LastSeenIP || !withinSecond(found.LastSeenAt, session.LastSeenAt) || !withinSecond(found.ExpiredAt, session.ExpiredAt) {
+			t.Errorf("Find(%d, %q) after Update = %+v; want %+v", DefaultSessionUserID, session.ID, found, session)
+		}
+
+		missing := session
+		missing.ID = security.GenerateUUID()
+		if err := sessionRepository.Update(context.Background(), DefaultSessionUserID, missing); err != userland.ErrSessionNotFound {
+			t.Errorf("Update(missing) err = %v; want %v", err, userland.ErrSessionNotFound)
+		}
+	})
+
+	t.Run("DeleteExpiredSessions", func(t *testing.T) {
+		sessionRepository := factory(t)
+		session := TestCreateSession(t, sessionRepository)
+		expired := createExpiredSession(t, sessionRepository, DefaultSessionUserID)
+
+		if err := sessionRepository.DeleteExpiredSessions(context.Background(), DefaultSessionUserID); err != nil {
+			t.Fatalf("DeleteExpiredSessions(%d) err = %v; want nil", DefaultSessionUserID, err)
+		}
+		found, err := sessionRepository.FindAllByUserID(context.Background(), DefaultSessionUserID)
+		if err != nil {
+			t.Fatalf("FindAllByUserID(%d) err = %v; want nil", DefaultSessionUserID, err)
+		}
+		ids := sessionIDs(found)
+		if ids[expired.ID] || !ids[session.ID] || len(ids) != 1 {
+			t.Errorf("FindAllByUserID(%d) after DeleteExpiredSessions = %v; want only %q", DefaultSessionUserID, ids, session.ID)
+		}
+	})
+
+	t.Run("DeleteBySessionID", func(t *testing.T) {
+		sessionRepository := factory(t)
+		session := TestCreateSession(t, sessionRepository)
+
+		if err := sessionRepository.DeleteBySessionID(context.Background(), DefaultSessionUserID, session.ID); err != nil {
+			t.Fatalf("DeleteBySessionID(%d, %q) err = %v; want nil", DefaultSessionUserID, session.ID, err)
+		}
+		if _, err := sessionRepository.Find(context.Background(), DefaultSessionUserID, session.ID); err != userland.ErrSessionNotFound {
+			t.Errorf("Find(deleted) err = %v; want %v", err, userland.ErrSessionNotFound)
+		}
+		if found, _ := sessionRepository.FindAllByUserID(context.Background(), DefaultSessionUserID); len(found) != 0 {
+			t.Errorf("FindAllByUserID(%d) after DeleteBySessionID = %d sessions; want 0", DefaultSessionUserID, len(found))
+		}
+		if err := sessionRepository.DeleteBySessionID(context.Background(), DefaultSessionUserID, session.ID); err != userland.ErrSessionNotFound {
+			t.Errorf("DeleteBySessionID(deleted) err = %v; want %v", err, userland.ErrSessionNotFound)
+		}
+	})
+
+	t.Run("DeleteOtherSessions", func(t *testing.T) {
+		sessionRepository := factory(t)
+		sessions := TestCreateSessions(t, sessionRepository, WithNumberOfSessions(3))
+		other := TestCreateSession(t, sessionRepository, WithUserID(DefaultSessionUserID+1))
+		current := sessions[0]
+
+		deletedSessionIDs, err := sessionRepository.DeleteOtherSessions(context.Background(), DefaultSessionUserID, current.ID)
+		if err != nil {
+			t.Fatalf("DeleteOtherSessions(%d, %q) err = %v; want nil", DefaultSessionUserID, current.ID, err)
+		}
+		deleted := map[string]bool{}
+		for _, sessionID := range deletedSessionIDs {
+			deleted[sessionID] = true
+		}
+		if len(deleted) != 2 || !deleted[sessions[1].ID] || !deleted[sessions[2].ID] {
+			t.Errorf("DeleteOtherSessions(%d, %q) = %v; want %q and %q", DefaultSessionUserID, current.ID, deletedSessionIDs, sessions[1].ID, sessions[2].ID)
+		}
+
+		if _, err := sessionRepository.Find(context.Background(), DefaultSessionUserID, current.ID); err != nil {
+			t.Errorf("Find(current) err = %v; want nil", err)
+		}
+		if _, err := sessionRepository.Find(context.Background(), DefaultSessionUserID+1, other.ID); err != nil {
+			t.Errorf("Find(other user session) err = %v; want nil", err)
+		}
+		for _, session := range sessions[1:] {
+			if _, err := sessionRepository.Find(context.Background(), DefaultSessionUserID, session.ID); err != userland.ErrSessionNotFound {
+				t.Errorf("Find(deleted %q) err = %v; want %v", session.ID, err, userland.ErrSessionNotFound)
+			}
+		}
+	})
+
+	t.Run("EvictSessions", func(t *testing.T) {
+		sessionRepository := factory(t)
+		sessions := TestCreateSessions(t, sessionRepository, WithNumberOfSessions(3))
+
+		evicted := []string{sessions[0].ID, sessions[1].ID}
+		if err := sessionRepository.EvictSessions(context.Background(), DefaultSessionUserID, evicted); err != nil {
+			t.Fatalf("EvictSessions(%d, %v) err = %v; want nil", DefaultSessionUserID, evicted, err)
+		}
+		found, err := sessionRepository.FindAllByUserID(context.Background(), DefaultSessionUserID)
+		if err != nil {
+			t.Fatalf("FindAllByUserID(%d) err = %v; want nil", DefaultSessionUserID, err)
+		}
+		ids := sessionIDs(found)
+		if len(ids) != 1 || !ids[sessions[2].ID] {
+			t.Errorf("FindAllByUserID(%d) after EvictSessions = %v; want only %q", DefaultSessionUserID, ids, sessions[2].ID)
+		}
+	})
+
+	t.Run("DeleteAllByUserID", func(t *testing.T) {
+		sessionRepository := factory(t)
+		sessions := TestCreateSessions(t, sessionRepository, WithNumberOfSessions(2))
+		other := TestCreateSession(t, sessionRepository, WithUserID(DefaultSessionUserID+1))
+		if err := sessionRepository.DeleteBySessionID(context.Background(), DefaultSessionUserID, sessions[0].ID); err != nil {
+			t.Fatalf("DeleteBySessionID(%d, %q) err = %v; want nil", DefaultSessionUserID, sessions[0].ID, err)
+		}
+
+		if err := sessionRepository.DeleteAllByUserID(context.Background(), DefaultSessionUserID); err != nil {
+			t.Fatalf("DeleteAllByUserID(%d) err = %v; want nil", DefaultSessionUserID, err)
+		}
+		if found, err := sessionRepository.FindAllByUserID(context.Background(), DefaultSessionUserID); err != nil || len(found) != 0 {
+			t.Errorf("FindAllByUserID(%d) after DeleteAllByUserID = %v, %v; want none", DefaultSessionUserID, sessionIDs(found), err)
+		}
+		if _, err := sessionRepository.Find(context.Background(), DefaultSessionUserID+1, other.ID); err != nil {
+			t.Errorf("Find(other user) err = %v; want nil", err)
+		}
+		if err := sessionRepository.DeleteAllByUserID(context.Background(), DefaultSessionUserID); err != nil {
+			t.Errorf("DeleteAllByUserID(no sessions) err = %v; want nil", err)
+		}
+	})
+}
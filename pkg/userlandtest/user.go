@@ -1,6 +1,7 @@
 package userlandtest
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -38,7 +39,7 @@ func TestCreateUser(t *testing.T, ur userland.UserRepository, opts ...func(user
 	for _, opt := range opts {
 		opt(user)
 	}
-	if err := ur.Insert(user); err != nil {
+	if err := ur.Insert(context.Background(), user); err != nil {
 		t.Fatalf("Failed to create default user Insert(&user) err = %v; want nil", err)
 	}
 	return user
@@ -54,14 +55,14 @@ func TestCreateTFAEnabledUser(t *testing.T, ur userland.UserRepository, opts ...
 	for _, opt := range opts {
 		opt(user)
 	}
-	if err := ur.Insert(user); err != nil {
+	if err := ur.Insert(context.Background(), user); err != nil {
 		t.Fatalf("Failed to create default user Insert(&user) err = %v; want nil", err)
 	}
 
 	user.TFAEnabled = true
 	user.TFAEnabledAt = time.Now()
 
-	if err := ur.Update(*user); err != nil {
+	if err := ur.Update(context.Background(), *user); err != nil {
 		t.Fatalf("Failed to update default user Update(user) err = %v; want nil", err)
 	}
 	return user
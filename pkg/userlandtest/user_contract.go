@@ -0,0 +1,258 @@
+package userlandtest
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AdhityaRamadhanus/userland"
+)
+
+//UserRepositoryFactory return an empty UserRepository, it is called before every contract test
+type UserRepositoryFactory func(t *testing.T) userland.UserRepository
+
+//RunUserRepositoryContract check behavior every userland.UserRepository implementation must share
+func RunUserRepositoryContract(t *testing.T, factory UserRepositoryFactory) {
+	t.Run("Insert", func(t *testing.T) {
+		userRepository := factory(t)
+		user := TestCreateUser(t, userRepository)
+		if user.ID == 0 {
+			t.Errorf("Insert(&user) user.ID = 0; want id assigned")
+		}
+
+		duplicate := &userland.User{Email: user.Email, Fullname: "Duplicate", Password: user.Password}
+		if err := userRepository.Insert(context.Background(), duplicate); err != userland.ErrDuplicateKey {
+			t.Errorf("Insert(&duplicate) err = %v; want %v", err, userland.ErrDuplicateKey)
+		}
+	})
+
+	t.Run("Find", func(t *testing.T) {
+		userRepository := factory(t)
+		user := TestCreateUser(t, userRepository)
+
+		found, err := userRepository.Find(context.Background(), user.ID)
+		if err != nil {
+			t.Fatalf("Find(%d) err = %v; want nil", user.ID, err)
+		}
+		if found.ID != user.ID || found.Email != user.Email || found.Fullname != user.Fullname || found.Password != user.Password {
+			t.Errorf("Find(%d) = %+v; want %+v", user.ID, found, *user)
+		}
+
+		if _, err := userRepository.Find(context.Background(), user.ID+1); err != userland.ErrUserNotFound {
+			t.Errorf("Find(missing) err = %v; want %v", err, userland.ErrUserNotFound)
+		}
+	})
+
+	t.Run("FindByEmail", func(t *testing.T) {
+		userRepository := factory(t)
+		user := TestCreateUser(t, userRepository)
+
+		found, err := userRepository.FindByEmail(context.Background(), user.Email)
+		if err != nil {
+			t.Fatalf("FindByEmail(%q) err = %v; want nil", user.Email, err)
+		}
+		if found.ID != user.ID {
+			t.Errorf("FindByEmail(%q).ID = %d; want %d", user.Email, found.ID, user.ID)
+		}
+
+		if _, err := userRepository.FindByEmail(context.Background(), "missing@example.com"); err != userland.ErrUserNotFound {
+			t.Errorf("FindByEmail(missing) err = %v; want %v", err, userland.ErrUserNotFound)
+		}
+		// emails are matched case sensitively
+		if _, err := userRepository.FindByEmail(context.Background(), strings.ToUpper(user.Email)); err != userland.ErrUserNotFound {
+			t.Errorf("FindByEmail(upper case) err = %v; want %v", err, userland.ErrUserNotFound)
+		}
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		userRepository := factory(t)
+		user := TestCreateUser(t, userRepository)
+
+		user.Fullname = "Updated"
+		user.Phone = "+6281234567"
+		user.Location = "Jakarta"
+		user.Bio = "bio"
+		user.WebURL = "https://example.com"
+		user.PictureURL = "https://example.com/picture.jpeg"
+		user.Verified = true
+		user.TFAEnabled = true
+		user.TFAEnabledAt = time.Now()
+		user.TFASecret = "JBSWY3DPEHPK3PXP"
+		if err := userRepository.Update(context.Background(), *user); err != nil {
+			t.Fatalf("Update(user) err = %v; want nil", err)
+		}
+
+		found, err := userRepository.Find(context.Background(), user.ID)
+		if err != nil {
+			t.Fatalf("Find(%d) err = %v; want nil", user.ID, err)
+		}
+		if found.Fullname != user.Fullname || found.Phone != user.Phone || found.Location != user.Location ||
+			found.Bio != user.Bio || found.WebURL != user.WebURL || found.PictureURL != user.PictureURL ||
+			!found.Verified || !found.TFAEnabled || !withinSecond(found.TFAEnabledAt, user.TFAEnabledAt) || found.TFASecret != user.TFASecret {
+			t.Errorf("Find(%d) after Update = %+v; want %+v", user.ID, found, *user)
+		}
+		if found.Version != user.Version+1 {
+			t.Errorf("Find(%d).Version after Update = %d; want %d", user.ID, found.Version, user.Version+1)
+		}
+
+		missing := *user
+		missing.ID = user.ID + 1
+		missing.Email = "missing@example.com"
+		if err := userRepository.Update(context.Background(), missing); err != userland.ErrUserNotFound {
+			t.Errorf("Update(missing) err = %v; want %v", err, userland.ErrUserNotFound)
+		}
+	})
+
+	t.Run("UpdateConcurrentModification", func(t *testing.T) {
+		userRepository := factory(t)
+		user := TestCreateUser(t, userRepository)
+
+		stale := *user
+		user.Fullname = "First Writer"
+		if err := userRepository.Update(context.Background(), *user); err != nil {
+			t.Fatalf("Update(user) err = %v; want nil", err)
+		}
+
+		stale.Fullname = "Second Writer"
+		if err := userRepository.Update(context.Background(), stale); err != userland.ErrConcurrentModification {
+			t.Fatalf("Update(stale user) err = %v; want %v", err, userland.ErrConcurrentModification)
+		}
+		found, err := userRepository.Find(context.Background(), user.ID)
+		if err != nil {
+			t.Fatalf("Find(%d) err = %v; want nil", user.ID, err)
+		}
+		if found.Fullname != "First Writer" {
+			t.Errorf("Find(%d).Fullname = %q after rejected Update; want %q", user.ID, found.Fullname, "First Writer")
+		}
+	})
+
+	t.Run("UpdateProfile", func(t *testing.T) {
+		userRepository := factory(t)
+		user := TestCreateUser(t, userRepository)
+
+		bio := "patched bio"
+		location := ""
+		patch := userland.ProfilePatch{Bio: &bio, Location: &location}
+		if err := userRepository.UpdateProfile(context.Background(), user.ID, user.Version, patch); err != nil {
+			t.Fatalf("UpdateProfile(%d) err = %v; want nil", user.ID, err)
+		}
+
+		found, err := userRepository.Find(context.Background(), user.ID)
+		if err != nil {
+			t.Fatalf("Find(%d) err = %v; want nil", user.ID, err)
+		}
+		if found.Bio != bio || found.Location != "" {
+			t.Errorf("Find(%d) bio, location = %q, %q; want %q, %q", user.ID, found.Bio, found.Location, bio, "")
+		}
+		if found.Fullname != user.Fullname || found.Phone != user.Phone || found.WebURL != user.WebURL {
+			t.Errorf("Find(%d) changed a field missing from the patch", user.ID)
+		}
+		if found.Version != user.Version+1 {
+			t.Errorf("Find(%d).Version = %d; want %d", user.ID, found.Version, user.Version+1)
+		}
+
+		if err := userRepository.UpdateProfile(context.Background(), user.ID, user.Version, patch); err != userland.ErrConcurrentModification {
+			t.Errorf("UpdateProfile(stale version) err = %v; want %v", err, userland.ErrConcurrentModification)
+		}
+		if err := userRepository.UpdateProfile(context.Background(), user.ID, 0, patch); err != nil {
+			t.Errorf("UpdateProfile(version 0) err = %v; want nil", err)
+		}
+		if err := userRepository.UpdateProfile(context.Background(), user.ID+1000, 0, patch); err != userland.ErrUserNotFound {
+			t.Errorf("UpdateProfile(unknown id) err = %v; want %v", err, userland.ErrUserNotFound)
+		}
+	})
+
+	t.Run("StoreBackupCodes", func(t *testing.T) {
+		userRepository := factory(t)
+		user := TestCreateUser(t, userRepository)
+
+		user.BackupCodes = []string{"hash1", "hash2"}
+		user.BackupCodesCreatedAt = time.Now()
+		if err := userRepository.StoreBackupCodes(context.Background(), *user); err != nil {
+			t.Fatalf("StoreBackupCodes(user) err = %v; want nil", err)
+		}
+
+		// backup codes are versioned like the rest of user, a code consumed concurrently can't be stored twice
+		stale := *user
+		stale.BackupCodes = []string{"hash2"}
+		if err := userRepository.StoreBackupCodes(context.Background(), stale); err != userland.ErrConcurrentModification {
+			t.Errorf("StoreBackupCodes(stale version) err = %v; want %v", err, userland.ErrConcurrentModification)
+		}
+
+		// Update doesn't touch backup codes
+		user.Version++
+		user.BackupCodes = nil
+		if err := userRepository.Update(context.Background(), *user); err != nil {
+			t.Fatalf("Update(user) err = %v; want nil", err)
+		}
+
+		found, err := userRepository.Find(context.Background(), user.ID)
+		if err != nil {
+			t.Fatalf("Find(%d) err = %v; want nil", user.ID, err)
+		}
+		if len(found.BackupCodes) != 2 || found.BackupCodes[0] != "hash1" || !withinSecond(found.BackupCodesCreatedAt, user.BackupCodesCreatedAt) {
+			t.Errorf("Find(%d) backup codes = %v created at %v; want [hash1 hash2] created at %v", user.ID, found.BackupCodes, found.BackupCodesCreatedAt, user.BackupCodesCreatedAt)
+		}
+
+		missing := *user
+		missing.ID = user.ID + 1
+		if err := userRepository.StoreBackupCodes(context.Background(), missing); err != userland.ErrUserNotFound {
+			t.Errorf("StoreBackupCodes(missing) err = %v; want %v", err, userland.ErrUserNotFound)
+		}
+	})
+
+	t.Run("FindAllPendingDeletion", func(t *testing.T) {
+		userRepository := factory(t)
+		now := time.Now()
+		requestedAt := map[string]time.Time{
+			"expired@example.com": now.Add(-15 * 24 * time.Hour),
+			"recent@example.com":  now.Add(-time.Hour),
+			"active@example.com":  {},
+		}
+		for email, deletionRequestedAt := range requestedAt {
+			user := TestCreateUser(t, userRepository, WithUserEmail(email))
+			user.DeletionRequestedAt = deletionRequestedAt
+			if err := userRepository.Update(context.Background(), *user); err != nil {
+				t.Fatalf("Update(%q) err = %v; want nil", email, err)
+			}
+		}
+
+		users, err := userRepository.FindAllPendingDeletion(context.Background(), now.Add(-14*24*time.Hour))
+		if err != nil {
+			t.Fatalf("FindAllPendingDeletion() err = %v; want nil", err)
+		}
+		if len(users) != 1 || users[0].Email != "expired@example.com" {
+			t.Fatalf("FindAllPendingDeletion() = %+v; want only expired@example.com", users)
+		}
+		if !withinSecond(users[0].DeletionRequestedAt, requestedAt["expired@example.com"]) {
+			t.Errorf("FindAllPendingDeletion()[0].DeletionRequestedAt = %v; want %v", users[0].DeletionRequestedAt, requestedAt["expired@example.com"])
+		}
+
+		active, err := userRepository.FindByEmail(context.Background(), "active@example.com")
+		if err != nil || !active.DeletionRequestedAt.IsZero() {
+			t.Errorf("FindByEmail(active) DeletionRequestedAt = %v, %v; want zero, nil", active.DeletionRequestedAt, err)
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		userRepository := factory(t)
+		user := TestCreateUser(t, userRepository)
+
+		if err := userRepository.Delete(context.Background(), user.ID); err != nil {
+			t.Fatalf("Delete(%d) err = %v; want nil", user.ID, err)
+		}
+		if _, err := userRepository.Find(context.Background(), user.ID); err != userland.ErrUserNotFound {
+			t.Errorf("Find(deleted) err = %v; want %v", err, userland.ErrUserNotFound)
+		}
+		if err := userRepository.Delete(context.Background(), user.ID); err != userland.ErrUserNotFound {
+			t.Errorf("Delete(deleted) err = %v; want %v", err, userland.ErrUserNotFound)
+		}
+	})
+}
+
+//withinSecond compare times stored with different precision by each backend
+func withinSecond(got, want time.Time) bool {
+	diff := got.Sub(want)
+	return diff < time.Second && diff > -time.Second
+}
@@ -0,0 +1,26 @@
+package userland
+
+import (
+	"context"
+	"time"
+)
+
+//RateLimit allow up to Burst requests at once, refilled at Limit requests per Window
+type RateLimit struct {
+	Limit  int
+	Burst  int
+	Window time.Duration
+}
+
+//RateLimitResult is the outcome of counting a request against a RateLimit
+type RateLimitResult struct {
+	Allowed    bool
+	Remaining  int
+	RetryAfter time.Duration // until the next request is allowed, zero when allowed
+	ResetAfter time.Duration // until the full burst is available again
+}
+
+//RateLimitService provide an interface to count requests of a key against a rate limit
+type RateLimitService interface {
+	Allow(ctx context.Context, key string, rateLimit RateLimit) (RateLimitResult, error)
+}
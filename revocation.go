@@ -0,0 +1,30 @@
+package userland
+
+import (
+	"context"
+	"time"
+)
+
+//SessionRevocation is published whenever a session ended, so services caching access tokens can reject it
+type SessionRevocation struct {
+	SessionID string
+	UserID    int
+	Reason    string
+	RevokedAt time.Time
+}
+
+//SessionRevocations a collection of SessionRevocation
+type SessionRevocations []SessionRevocation
+
+//RevocationSubscription is a live feed of session revocations
+type RevocationSubscription interface {
+	Revocations() <-chan SessionRevocation
+	Close() error
+}
+
+//RevocationService provide an interface to publish and follow session revocations
+type RevocationService interface {
+	Publish(ctx context.Context, revocation SessionRevocation) error
+	FindAllSince(ctx context.Context, since time.Time) (SessionRevocations, error)
+	Subscribe(ctx context.Context) (RevocationSubscription, error)
+}
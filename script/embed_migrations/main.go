@@ -0,0 +1,49 @@
+//embed_migrations write the sql files of a migration directory into a go file, so a binary can migrate without the sql files
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+
+	"github.com/sirupsen/logrus"
+)
+
+func main() {
+	dir := flag.String("dir", "migration", "directory of the sql files")
+	pkg := flag.String("package", "", "package of the generated file")
+	out := flag.String("out", "migration_files.go", "generated file")
+	flag.Parse()
+
+	paths, err := filepath.Glob(filepath.Join(*dir, "*.sql"))
+	if err != nil {
+		logrus.Fatalf("filepath.Glob(%q) err = %v", *dir, err)
+	}
+	sort.Strings(paths)
+
+	source := &bytes.Buffer{}
+	fmt.Fprintf(source, "// Code generated by script/embed_migrations from %s; DO NOT EDIT.\n\n", *dir)
+	fmt.Fprintf(source, "package %s\n\n", *pkg)
+	fmt.Fprintf(source, "//migrationFiles hold the content of every file in %s keyed by file name\n", *dir)
+	fmt.Fprintf(source, "var migrationFiles = map[string]string{\n")
+	for _, path := range paths {
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			logrus.Fatalf("ioutil.ReadFile(%q) err = %v", path, err)
+		}
+		fmt.Fprintf(source, "%q: %q,\n", filepath.Base(path), content)
+	}
+	fmt.Fprintf(source, "}\n")
+
+	formatted, err := format.Source(source.Bytes())
+	if err != nil {
+		logrus.Fatalf("format.Source() err = %v", err)
+	}
+	if err := ioutil.WriteFile(*out, formatted, 0644); err != nil {
+		logrus.Fatalf("ioutil.WriteFile(%q) err = %v", *out, err)
+	}
+}
@@ -3,6 +3,7 @@ package userland
 import (
 	"github.com/go-errors/errors"
 
+	"context"
 	"time"
 )
 
@@ -11,6 +12,19 @@ var (
 	ErrSessionNotFound = errors.New("Session not found")
 )
 
+const (
+	//SessionEndReasonLogout session ended by its owner
+	SessionEndReasonLogout = "logout"
+	//SessionEndReasonRevoked session ended from another session
+	SessionEndReasonRevoked = "revoked"
+	//SessionEndReasonExpired session reached its expiration
+	SessionEndReasonExpired = "expired"
+	//SessionEndReasonEvicted session ended to make room for a newer one
+	SessionEndReasonEvicted = "evicted"
+	//SessionEndReasonRefreshed session replaced by a new one through a refresh token
+	SessionEndReasonRefreshed = "refreshed"
+)
+
 //Session is domain entity
 type Session struct {
 	ID         string
@@ -18,9 +32,23 @@ type Session struct {
 	IP         string
 	ClientID   int
 	ClientName string
+	UserAgent  string
+	Browser    string
+	OS         string
+	DeviceType string
+	Country    string
+	City       string
+	ASN        int
+	LastSeenIP string
+	LastSeenAt time.Time
 	Expiration time.Duration
+	ExpiredAt  time.Time
+	EndedAt    time.Time
+	EndReason  string
 	CreatedAt  time.Time
 	UpdatedAt  time.Time
+	// PreviousSessionID is the session being replaced on token refresh, it is not stored
+	PreviousSessionID string
 }
 
 //Sessions a collection of Session
@@ -28,9 +56,14 @@ type Sessions []Session
 
 //SessionRepository provide an interface to get user sessions
 type SessionRepository interface {
-	Create(userID int, session Session) error
-	FindAllByUserID(userID int) (Sessions, error)
-	DeleteExpiredSessions(userID int) error
-	DeleteBySessionID(userID int, sessionID string) error
-	DeleteOtherSessions(userID int, sessionID string) (deletedSessionIDs []string, err error)
+	Create(ctx context.Context, userID int, session Session) error
+	Find(ctx context.Context, userID int, sessionID string) (Session, error)
+	FindAllByUserID(ctx context.Context, userID int) (Sessions, error)
+	Update(ctx context.Context, userID int, session Session) error
+	DeleteExpiredSessions(ctx context.Context, userID int) error
+	DeleteBySessionID(ctx context.Context, userID int, sessionID string) error
+	DeleteOtherSessions(ctx context.Context, userID int, sessionID string) (deletedSessionIDs []string, err error)
+	EvictSessions(ctx context.Context, userID int, sessionIDs []string) error
+	// DeleteAllByUserID remove every session of a user, ended ones kept for audit included
+	DeleteAllByUserID(ctx context.Context, userID int) error
 }
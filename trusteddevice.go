@@ -0,0 +1,40 @@
+package userland
+
+import (
+	"github.com/go-errors/errors"
+
+	"context"
+	"time"
+)
+
+var (
+	//ErrTrustedDeviceNotFound represent trusted device not found
+	ErrTrustedDeviceNotFound = errors.New("Trusted device not found")
+)
+
+//TrustedDevice is domain entity of a device allowed to skip TFA challenge
+type TrustedDevice struct {
+	ID         string
+	TokenHash  string
+	UserAgent  string
+	IP         string
+	ClientID   int
+	ClientName string
+	Expiration time.Duration
+	ExpiredAt  time.Time
+	LastUsedAt time.Time
+	CreatedAt  time.Time
+}
+
+//TrustedDevices a collection of TrustedDevice
+type TrustedDevices []TrustedDevice
+
+//TrustedDeviceRepository provide an interface to get user trusted devices
+type TrustedDeviceRepository interface {
+	Create(ctx context.Context, userID int, device TrustedDevice) error
+	Find(ctx context.Context, userID int, deviceID string) (TrustedDevice, error)
+	FindAllByUserID(ctx context.Context, userID int) (TrustedDevices, error)
+	Update(ctx context.Context, userID int, device TrustedDevice) error
+	Delete(ctx context.Context, userID int, deviceID string) error
+	DeleteAllByUserID(ctx context.Context, userID int) error
+}
@@ -0,0 +1,19 @@
+package userland
+
+import (
+	"context"
+)
+
+//TxRepositories hold repositories bound to a single transaction
+type TxRepositories struct {
+	UserRepository                UserRepository
+	EventRepository               EventRepository
+	SessionRepository             SessionRepository
+	LoginRiskAssessmentRepository LoginRiskAssessmentRepository
+}
+
+//TxManager provide an interface to run several repository calls as one unit of work
+type TxManager interface {
+	//WithinTx commit every change made through repositories when fn return nil and roll them back otherwise
+	WithinTx(ctx context.Context, fn func(repositories TxRepositories) error) error
+}
@@ -3,6 +3,7 @@ package userland
 import (
 	"github.com/go-errors/errors"
 
+	"context"
 	"time"
 )
 
@@ -21,8 +22,16 @@ type User struct {
 	Verified     bool
 	BackupCodes  []string
 	TFAEnabledAt time.Time
-	CreatedAt    time.Time
-	UpdatedAt    time.Time
+	// TFASecret is the TOTP secret shared with the authenticator app, it is set when TFA is activated
+	TFASecret string
+	// BackupCodesCreatedAt is when the current set of backup codes is generated
+	BackupCodesCreatedAt time.Time
+	CreatedAt            time.Time
+	UpdatedAt            time.Time
+	// Version is incremented by one on every Update, an Update based on an older version is rejected
+	Version int
+	// DeletionRequestedAt is zero unless the account is pending deletion, it is purged once the restore window is over
+	DeletionRequestedAt time.Time
 }
 
 var (
@@ -30,15 +39,42 @@ var (
 	ErrUserNotFound = errors.New("User not found")
 	//ErrDuplicateKey represent insert duplicated user
 	ErrDuplicateKey = errors.New("Duplicate key in user")
+	//ErrConcurrentModification represent update on a user that has been updated since it was read
+	ErrConcurrentModification = errors.New("User was modified concurrently")
+	//ErrAccountDeleted represent signing in or refreshing a token of a user pending deletion
+	ErrAccountDeleted = errors.New("Account is pending deletion")
 )
 
+//CheckNotDeleted return ErrAccountDeleted when the account is pending deletion, it has to be restored with the emailed link first
+func (u User) CheckNotDeleted() error {
+	if !u.DeletionRequestedAt.IsZero() {
+		return ErrAccountDeleted
+	}
+	return nil
+}
+
+//ProfilePatch hold the profile fields to change, a nil field is left untouched and an empty one is cleared
+type ProfilePatch struct {
+	Fullname *string
+	Phone    *string
+	Location *string
+	Bio      *string
+	WebURL   *string
+}
+
 //UserRepository provide an interface to get user entities
 type UserRepository interface {
-	Find(id int) (User, error)
-	FindByEmail(email string) (User, error)
-	Insert(user *User) error
-	Update(user User) error
+	Find(ctx context.Context, id int) (User, error)
+	FindByEmail(ctx context.Context, email string) (User, error)
+	Insert(ctx context.Context, user *User) error
+	// Update fail with ErrConcurrentModification when user.Version is not the stored version
+	Update(ctx context.Context, user User) error
+	// UpdateProfile only write the columns set in patch, version 0 skip the version check
+	UpdateProfile(ctx context.Context, id int, version int, patch ProfilePatch) error
 	// problematic func here
-	StoreBackupCodes(user User) error
-	Delete(id int) error
+	// StoreBackupCodes fail with ErrConcurrentModification when user.Version is not the stored version, like Update
+	StoreBackupCodes(ctx context.Context, user User) error
+	Delete(ctx context.Context, id int) error
+	// FindAllPendingDeletion return users whose deletion was requested before requestedBefore
+	FindAllPendingDeletion(ctx context.Context, requestedBefore time.Time) ([]User, error)
 }